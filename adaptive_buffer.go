@@ -0,0 +1,181 @@
+package claude
+
+import (
+	"context"
+	"time"
+)
+
+// Bounds used by AdaptiveBuffering when its fields are unset.
+const (
+	defaultAdaptiveBufferMin      = 16
+	defaultAdaptiveBufferMax      = 4096
+	defaultAdaptiveBufferInterval = 250 * time.Millisecond
+)
+
+// AdaptiveBuffering replaces the fixed MessageChannelBufferSize with a
+// buffer that is resized from the observed producer and consumer rates: it
+// doubles after an interval in which the CLI outpaced the consumer and
+// routing stalled on a full buffer, and halves, releasing memory, after an
+// interval in which most of it sat unused. The size
+// starts at MessageChannelBufferSize, clamped to [Min, Max]. Every change is
+// reported as MetricBufferSize when Metrics implements GaugeMetrics.
+type AdaptiveBuffering struct {
+	Min      int           // Smallest size (default: 16)
+	Max      int           // Largest size (default: 4096)
+	Interval time.Duration // How often the size is re-evaluated (default: 250ms)
+}
+
+// bufferLabels identify the handler's message buffer in MetricBufferSize.
+var bufferLabels = map[string]string{"buffer": "messages"}
+
+// adaptiveBuffer is an elastic queue between the routing goroutine and the
+// message consumer. Go channels cannot be resized, so the queue is a slice
+// whose limit is tuned once per interval.
+type adaptiveBuffer struct {
+	in    chan map[string]interface{}
+	out   chan map[string]interface{}
+	flush chan chan struct{}
+
+	min, max, limit int
+	interval        time.Duration
+	metrics         Metrics
+
+	// Observations for the current interval; only used by run
+	peak    int
+	stalled bool
+}
+
+// newAdaptiveBuffer returns a buffer for config starting at initial
+// messages, or nil if config is nil.
+func newAdaptiveBuffer(config *AdaptiveBuffering, initial int, metrics Metrics) *adaptiveBuffer {
+	if config == nil {
+		return nil
+	}
+	b := &adaptiveBuffer{
+		in:       make(chan map[string]interface{}),
+		out:      make(chan map[string]interface{}),
+		flush:    make(chan chan struct{}),
+		min:      config.Min,
+		max:      config.Max,
+		interval: config.Interval,
+		metrics:  metrics,
+	}
+	if b.min <= 0 {
+		b.min = defaultAdaptiveBufferMin
+	}
+	if b.max <= 0 {
+		b.max = defaultAdaptiveBufferMax
+	}
+	if b.max < b.min {
+		b.max = b.min
+	}
+	if b.interval <= 0 {
+		b.interval = defaultAdaptiveBufferInterval
+	}
+	b.limit = min(max(initial, b.min), b.max)
+	return b
+}
+
+// run moves messages from in to out until in is closed and the queue is
+// empty, or ctx is done. It closes out on return.
+func (b *adaptiveBuffer) run(ctx context.Context) {
+	defer close(b.out)
+	b.report()
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	queue := make([]map[string]interface{}, 0, b.limit)
+	in := b.in
+	var waiters []chan struct{}
+	for {
+		if len(queue) == 0 {
+			for _, w := range waiters {
+				close(w)
+			}
+			waiters = nil
+			if in == nil {
+				return
+			}
+		}
+
+		// Stop accepting at the limit; the producer blocks until the
+		// consumer catches up, as with a full channel
+		recv := in
+		if len(queue) >= b.limit {
+			recv = nil
+			b.stalled = in != nil
+		}
+		var send chan map[string]interface{}
+		var head map[string]interface{}
+		if len(queue) > 0 {
+			send = b.out
+			head = queue[0]
+		}
+
+		select {
+		case msg, ok := <-recv:
+			if !ok {
+				in = nil
+				continue
+			}
+			queue = append(queue, msg)
+			b.peak = max(b.peak, len(queue))
+		case send <- head:
+			queue[0] = nil
+			queue = queue[1:]
+		case w := <-b.flush:
+			waiters = append(waiters, w)
+		case <-ticker.C:
+			if b.tune(len(queue)) {
+				// Reallocate so a shrunk queue releases its memory
+				queue = append(make([]map[string]interface{}, 0, b.limit), queue...)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// tune adjusts the limit from the interval's observations and reports
+// whether it shrank. queued is the current queue length.
+func (b *adaptiveBuffer) tune(queued int) bool {
+	grow := b.stalled
+	shrink := !b.stalled && b.peak < b.limit/4
+	b.peak, b.stalled = queued, false
+
+	switch {
+	case grow && b.limit < b.max:
+		b.limit = min(b.limit*2, b.max)
+	case shrink && b.limit > b.min:
+		b.limit = max(b.limit/2, b.min)
+		b.report()
+		return true
+	default:
+		return false
+	}
+	b.report()
+	return false
+}
+
+// wait blocks until every message accepted so far has been consumed, so an
+// error sent afterwards does not overtake them. Returns false if ctx is done.
+func (b *adaptiveBuffer) wait(ctx context.Context) bool {
+	done := make(chan struct{})
+	select {
+	case b.flush <- done:
+	case <-ctx.Done():
+		return false
+	}
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// report publishes the current limit as MetricBufferSize.
+func (b *adaptiveBuffer) report() {
+	setGauge(b.metrics, MetricBufferSize, float64(b.limit), bufferLabels)
+}
@@ -0,0 +1,196 @@
+package claude
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ApprovalRequest describes a tool use that CanUseTool asked a human about.
+type ApprovalRequest struct {
+	ToolName string                 `json:"tool_name"`
+	Input    map[string]interface{} `json:"input"` // The ask's UpdatedInput, or the tool's input
+	Message  string                 `json:"message,omitempty"`
+
+	// UpdatedPermissions are applied if the use is approved, unless the
+	// decision replaces them
+	UpdatedPermissions []PermissionUpdate `json:"updated_permissions,omitempty"`
+	Suggestions        []PermissionUpdate `json:"suggestions,omitempty"` // From the CLI
+	RequestID          string             `json:"request_id,omitempty"`
+	Metadata           Metadata           `json:"metadata,omitempty"`
+}
+
+// ApprovalDecision is a human's answer to an ApprovalRequest.
+type ApprovalDecision struct {
+	Approved bool `json:"approved"`
+
+	// UpdatedInput replaces the request's Input if approved
+	UpdatedInput map[string]interface{} `json:"updated_input,omitempty"`
+	// UpdatedPermissions replace the request's UpdatedPermissions if approved
+	UpdatedPermissions []PermissionUpdate `json:"updated_permissions,omitempty"`
+
+	Message   string `json:"message,omitempty"`   // Reason given to Claude if denied
+	Interrupt bool   `json:"interrupt,omitempty"` // Stop the turn if denied
+}
+
+// Approver mediates PermissionResultAsk results: when CanUseTool asks,
+// Approve puts the tool use to a human and its decision becomes an allow or
+// deny result. Set it as ClaudeAgentOptions.Approver. Approve may block
+// until the human answers; ctx ends if the CLI cancels the request.
+type Approver interface {
+	Approve(ctx context.Context, request ApprovalRequest) (ApprovalDecision, error)
+}
+
+// ApproverFunc adapts a function to an Approver.
+type ApproverFunc func(ctx context.Context, request ApprovalRequest) (ApprovalDecision, error)
+
+// Approve calls f.
+func (f ApproverFunc) Approve(ctx context.Context, request ApprovalRequest) (ApprovalDecision, error) {
+	return f(ctx, request)
+}
+
+// resolveAsk puts ask to approver and returns the resulting allow or deny.
+func resolveAsk(ctx context.Context, approver Approver, toolName string, input map[string]interface{}, permCtx ToolPermissionContext, ask PermissionResultAsk) (PermissionResult, error) {
+	if approver == nil {
+		return nil, fmt.Errorf("permission result ask requires ClaudeAgentOptions.Approver")
+	}
+	request := ApprovalRequest{
+		ToolName:           toolName,
+		Input:              input,
+		Message:            ask.Message,
+		UpdatedPermissions: ask.UpdatedPermissions,
+		Suggestions:        permCtx.Suggestions,
+		RequestID:          permCtx.RequestID,
+		Metadata:           permCtx.Metadata,
+	}
+	if ask.UpdatedInput != nil {
+		request.Input = ask.UpdatedInput
+	}
+
+	decision, err := approver.Approve(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("approver failed: %w", err)
+	}
+	if !decision.Approved {
+		message := decision.Message
+		if message == "" {
+			message = fmt.Sprintf("%s: denied by approver", toolName)
+		}
+		return PermissionResultDeny{Behavior: "deny", Message: message, Interrupt: decision.Interrupt}, nil
+	}
+	allow := PermissionResultAllow{Behavior: "allow", UpdatedInput: request.Input, UpdatedPermissions: request.UpdatedPermissions}
+	if decision.UpdatedInput != nil {
+		allow.UpdatedInput = decision.UpdatedInput
+	}
+	if decision.UpdatedPermissions != nil {
+		allow.UpdatedPermissions = decision.UpdatedPermissions
+	}
+	return allow, nil
+}
+
+// TerminalApprover asks on a terminal: it writes each request to Out and
+// reads a y/n answer from In. Requests are asked one at a time. A read
+// blocks until a line arrives, even if the CLI cancels the request.
+//
+// Example:
+//
+//	options := &claude.ClaudeAgentOptions{
+//	    CanUseTool: canUseTool, // Returns PermissionResultAsk for risky tools
+//	    Approver:   &claude.TerminalApprover{In: os.Stdin, Out: os.Stderr},
+//	}
+type TerminalApprover struct {
+	In  io.Reader
+	Out io.Writer
+
+	mu     sync.Mutex
+	reader *bufio.Reader
+}
+
+// Approve prompts for request. Answers other than y or yes deny it.
+func (t *TerminalApprover) Approve(ctx context.Context, request ApprovalRequest) (ApprovalDecision, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.reader == nil {
+		t.reader = bufio.NewReader(t.In)
+	}
+
+	input, _ := json.Marshal(request.Input)
+	prompt := fmt.Sprintf("%s %s\n", request.ToolName, input)
+	if request.Message != "" {
+		prompt += request.Message + "\n"
+	}
+	if _, err := io.WriteString(t.Out, prompt+"Allow? [y/N] "); err != nil {
+		return ApprovalDecision{}, err
+	}
+
+	line, err := t.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return ApprovalDecision{}, fmt.Errorf("failed to read answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return ApprovalDecision{Approved: true}, nil
+	}
+	return ApprovalDecision{Message: fmt.Sprintf("%s: denied at the terminal", request.ToolName)}, nil
+}
+
+// WebhookApprover POSTs each ApprovalRequest as JSON to URL and decodes the
+// response body as the ApprovalDecision, e.g. from a service that posts the
+// request to a chat channel and waits for a reviewer's button press.
+//
+// Example:
+//
+//	options := &claude.ClaudeAgentOptions{
+//	    CanUseTool: canUseTool,
+//	    Approver: &claude.WebhookApprover{
+//	        URL:     "https://approvals.example.com/claude",
+//	        Headers: map[string]string{"Authorization": "Bearer " + token},
+//	    },
+//	}
+type WebhookApprover struct {
+	URL     string
+	Client  *http.Client      // Default: a client with a 10 minute timeout, for the human to answer
+	Headers map[string]string // Extra request headers, e.g. Authorization
+}
+
+// Approve posts request and returns the webhook's decision.
+func (w *WebhookApprover) Approve(ctx context.Context, request ApprovalRequest) (ApprovalDecision, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return ApprovalDecision{}, fmt.Errorf("failed to encode approval request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return ApprovalDecision{}, fmt.Errorf("failed to create approval request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Minute}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ApprovalDecision{}, fmt.Errorf("approval webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return ApprovalDecision{}, fmt.Errorf("approval webhook: unexpected status %s", resp.Status)
+	}
+
+	var decision ApprovalDecision
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return ApprovalDecision{}, fmt.Errorf("approval webhook: invalid decision: %w", err)
+	}
+	return decision, nil
+}
@@ -0,0 +1,364 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultAuditResultBytes is the result length kept when
+// AuditLog.MaxResultBytes is unset.
+const defaultAuditResultBytes = 4096
+
+// AuditLog records every tool invocation as a structured AuditEntry: its
+// input, the CanUseTool decision, hook outcomes, duration, and result. Set
+// it as ClaudeAgentOptions.AuditLog. An entry is recorded when the tool's
+// result arrives, or when the turn ends without one.
+//
+// Example: JSON lines appended to a file:
+//
+//	auditFile, err := claude.OpenAuditFile("/var/log/agent/tools.jsonl")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer auditFile.Close()
+//	options := &claude.ClaudeAgentOptions{AuditLog: &claude.AuditLog{Sink: auditFile}}
+type AuditLog struct {
+	Sink AuditSink
+
+	// MaxResultBytes caps AuditEntry.Result; longer results are truncated
+	// and marked ResultTruncated (default 4096; negative omits results)
+	MaxResultBytes int
+
+	OnError func(err error) // Called when Sink fails; the entry is lost
+}
+
+// AuditEntry is the record of one tool invocation.
+type AuditEntry struct {
+	Time            time.Time              `json:"time"` // When the tool use arrived
+	SessionID       string                 `json:"session_id,omitempty"`
+	ToolUseID       string                 `json:"tool_use_id"`
+	ToolName        string                 `json:"tool_name"`
+	Input           map[string]interface{} `json:"input,omitempty"`
+	ParentToolUseID *string                `json:"parent_tool_use_id,omitempty"` // Set for subagent tool uses
+
+	// Decision is CanUseTool's "allow" or "deny"; empty if the CLI decided
+	// without asking, e.g. from its permission rules
+	Decision        string             `json:"decision,omitempty"`
+	DecisionMessage string             `json:"decision_message,omitempty"`
+	Hooks           []AuditHookOutcome `json:"hooks,omitempty"` // Hooks that decided something, in order
+
+	DurationMS      int64  `json:"duration_ms"` // From the tool use to its result
+	IsError         bool   `json:"is_error,omitempty"`
+	Result          string `json:"result,omitempty"` // Text of the result, after ToolResultFilter
+	ResultTruncated bool   `json:"result_truncated,omitempty"`
+	Incomplete      bool   `json:"incomplete,omitempty"` // The turn ended without a result
+}
+
+// AuditHookOutcome is a hook's effect on a tool invocation.
+type AuditHookOutcome struct {
+	Event    string `json:"event"`    // e.g. "PreToolUse"
+	Decision string `json:"decision"` // "allow", "deny", "ask", "block", or "stop"
+	Reason   string `json:"reason,omitempty"`
+}
+
+// AuditSink stores audit entries. Record is called from the SDK's message
+// loop, one entry at a time; a slow sink delays message delivery.
+type AuditSink interface {
+	Record(entry AuditEntry) error
+}
+
+// AuditFunc adapts a function to an AuditSink.
+type AuditFunc func(entry AuditEntry) error
+
+// Record calls f.
+func (f AuditFunc) Record(entry AuditEntry) error {
+	return f(entry)
+}
+
+// auditWriter writes entries as JSON lines.
+type auditWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewAuditWriter returns a sink that writes each entry to w as a line of JSON.
+func NewAuditWriter(w io.Writer) AuditSink {
+	return &auditWriter{w: w}
+}
+
+func (a *auditWriter) Record(entry AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.w.Write(append(data, '\n'))
+	return err
+}
+
+// AuditFile is an AuditSink appending JSON lines to a file.
+type AuditFile struct {
+	AuditSink
+	file *os.File
+}
+
+// OpenAuditFile opens path for appending audit entries, creating it with
+// mode 0600 if needed. Close the file when no client uses it any more.
+func OpenAuditFile(path string) (*AuditFile, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return &AuditFile{AuditSink: NewAuditWriter(file), file: file}, nil
+}
+
+// Close closes the file.
+func (f *AuditFile) Close() error {
+	return f.file.Close()
+}
+
+// auditor builds audit entries from the messages and control requests of a
+// connection. Entries are recorded from the routing goroutine only.
+type auditor struct {
+	log     *AuditLog
+	mu      sync.Mutex
+	entries map[string]*AuditEntry // Tool invocations awaiting results, by tool use ID
+	order   []string               // Their tool use IDs, in arrival order
+	now     func() time.Time
+}
+
+// newAuditor returns an auditor if options.AuditLog is set, or nil.
+func newAuditor(options *ClaudeAgentOptions) *auditor {
+	if options == nil || options.AuditLog == nil || options.AuditLog.Sink == nil {
+		return nil
+	}
+	return &auditor{log: options.AuditLog, entries: make(map[string]*AuditEntry), now: time.Now}
+}
+
+// entry returns the pending entry for toolUseID, creating it if needed.
+// The caller holds a.mu.
+func (a *auditor) entry(toolUseID string) *AuditEntry {
+	entry, ok := a.entries[toolUseID]
+	if !ok {
+		entry = &AuditEntry{Time: a.now(), ToolUseID: toolUseID}
+		a.entries[toolUseID] = entry
+		a.order = append(a.order, toolUseID)
+	}
+	return entry
+}
+
+// take removes and returns the pending entry for toolUseID, or nil.
+// The caller holds a.mu.
+func (a *auditor) take(toolUseID string) *AuditEntry {
+	entry, ok := a.entries[toolUseID]
+	if !ok {
+		return nil
+	}
+	delete(a.entries, toolUseID)
+	for i, id := range a.order {
+		if id == toolUseID {
+			a.order = append(a.order[:i], a.order[i+1:]...)
+			break
+		}
+	}
+	return entry
+}
+
+// observe records the tool uses and results of a raw message from the CLI,
+// and ends pending entries at the turn's result.
+func (a *auditor) observe(msg map[string]interface{}) {
+	if a == nil {
+		return
+	}
+	msgType, _ := msg["type"].(string)
+	if msgType == "result" {
+		a.flush()
+		return
+	}
+	if msgType != "assistant" && msgType != "user" {
+		return
+	}
+	inner, _ := msg["message"].(map[string]interface{})
+	blocks, _ := inner["content"].([]interface{})
+	sessionID, _ := msg["session_id"].(string)
+	parent, _ := msg["parent_tool_use_id"].(string)
+
+	var done []*AuditEntry
+	a.mu.Lock()
+	for _, raw := range blocks {
+		block, _ := raw.(map[string]interface{})
+		switch block["type"] {
+		case "tool_use":
+			id, _ := block["id"].(string)
+			if id == "" {
+				continue
+			}
+			entry := a.entry(id)
+			entry.SessionID = sessionID
+			entry.ToolName, _ = block["name"].(string)
+			entry.Input, _ = block["input"].(map[string]interface{})
+			if parent != "" {
+				entry.ParentToolUseID = &parent
+			}
+		case "tool_result":
+			id, _ := block["tool_use_id"].(string)
+			entry := a.take(id)
+			if entry == nil {
+				continue
+			}
+			entry.DurationMS = a.now().Sub(entry.Time).Milliseconds()
+			entry.IsError, _ = block["is_error"].(bool)
+			entry.Result, entry.ResultTruncated = a.truncate(toolResultText(block["content"]))
+			done = append(done, entry)
+		}
+	}
+	a.mu.Unlock()
+
+	for _, entry := range done {
+		a.record(*entry)
+	}
+}
+
+// permission records a CanUseTool decision. The request is matched to its
+// tool use by tool_use_id if the CLI sent one, otherwise to the oldest
+// undecided invocation of the same tool.
+func (a *auditor) permission(request, response map[string]interface{}) {
+	if a == nil {
+		return
+	}
+	toolName, _ := request["tool_name"].(string)
+	toolUseID, _ := request["tool_use_id"].(string)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var entry *AuditEntry
+	if toolUseID != "" {
+		entry = a.entry(toolUseID)
+	} else {
+		for _, id := range a.order {
+			if candidate := a.entries[id]; candidate.ToolName == toolName && candidate.Decision == "" {
+				entry = candidate
+				break
+			}
+		}
+	}
+	if entry == nil {
+		return
+	}
+	if entry.ToolName == "" {
+		entry.ToolName = toolName
+	}
+	entry.Decision, _ = response["behavior"].(string)
+	entry.DecisionMessage, _ = response["message"].(string)
+}
+
+// hook records the outcome of a tool hook, if it decided something.
+func (a *auditor) hook(input map[string]interface{}, toolUseID *string, response map[string]interface{}) {
+	if a == nil || stringValue(toolUseID) == "" {
+		return
+	}
+	outcome := hookOutcome(input, response)
+	if outcome.Decision == "" {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry := a.entry(*toolUseID)
+	if entry.ToolName == "" {
+		entry.ToolName, _ = input["tool_name"].(string)
+		entry.Input, _ = input["tool_input"].(map[string]interface{})
+	}
+	entry.Hooks = append(entry.Hooks, outcome)
+}
+
+// flush records every pending entry as incomplete.
+func (a *auditor) flush() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	pending := make([]*AuditEntry, 0, len(a.order))
+	for _, id := range a.order {
+		entry := a.entries[id]
+		entry.Incomplete = true
+		pending = append(pending, entry)
+	}
+	clear(a.entries)
+	a.order = nil
+	a.mu.Unlock()
+
+	for _, entry := range pending {
+		a.record(*entry)
+	}
+}
+
+// record passes entry to the sink.
+func (a *auditor) record(entry AuditEntry) {
+	if err := a.log.Sink.Record(entry); err != nil && a.log.OnError != nil {
+		a.log.OnError(err)
+	}
+}
+
+// truncate applies MaxResultBytes to text, cutting at a rune boundary.
+func (a *auditor) truncate(text string) (string, bool) {
+	limit := a.log.MaxResultBytes
+	if limit == 0 {
+		limit = defaultAuditResultBytes
+	}
+	if limit < 0 {
+		return "", false
+	}
+	if len(text) <= limit {
+		return text, false
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit], true
+}
+
+// hookOutcome describes what a hook's response decided, with an empty
+// Decision if it let the tool use proceed unchanged.
+func hookOutcome(input, response map[string]interface{}) AuditHookOutcome {
+	outcome := AuditHookOutcome{}
+	outcome.Event, _ = input["hook_event_name"].(string)
+	if specific, ok := response["hookSpecificOutput"].(map[string]interface{}); ok {
+		outcome.Decision, _ = specific["permissionDecision"].(string)
+		outcome.Reason, _ = specific["permissionDecisionReason"].(string)
+	}
+	if decision, _ := response["decision"].(string); outcome.Decision == "" && decision != "" {
+		outcome.Decision = decision
+		outcome.Reason, _ = response["reason"].(string)
+	}
+	if proceed, ok := response["continue"].(bool); ok && !proceed {
+		outcome.Decision = "stop"
+		outcome.Reason, _ = response["stopReason"].(string)
+	}
+	return outcome
+}
+
+// toolResultText returns the text of a raw tool_result's content.
+func toolResultText(content interface{}) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []interface{}:
+		var parts []string
+		for _, raw := range c {
+			if block, ok := raw.(map[string]interface{}); ok && block["type"] == "text" {
+				text, _ := block["text"].(string)
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package claude
+
+import (
+	"context"
+	"strings"
+)
+
+// authFailurePatterns are lowercase fragments of CLI output that indicate
+// missing or rejected credentials.
+var authFailurePatterns = []string{
+	"invalid api key",
+	"please run /login",
+	"not logged in",
+	"authentication_error",
+	"authentication failed",
+	"oauth token has expired",
+	"oauth token revoked",
+	"api error: 401",
+}
+
+// findAuthFailure returns the first line of output that indicates an
+// authentication failure, or "" if there is none.
+func findAuthFailure(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		lower := strings.ToLower(line)
+		for _, pattern := range authFailurePatterns {
+			if strings.Contains(lower, pattern) {
+				return strings.TrimSpace(line)
+			}
+		}
+	}
+	return ""
+}
+
+// authErrorFromMessage returns an AuthenticationError if msg reports that the
+// CLI is not authenticated.
+func authErrorFromMessage(msg Message) *AuthenticationError {
+	switch m := msg.(type) {
+	case *AssistantMessage:
+		if m.Error != "authentication_failed" {
+			return nil
+		}
+		var detail string
+		for _, block := range m.Content {
+			if text, ok := block.(TextBlock); ok {
+				detail = text.Text
+				break
+			}
+		}
+		return NewAuthenticationError(AuthErrorSourceAssistant, detail, nil)
+	case *ResultMessage:
+		if !m.IsError || m.Result == nil {
+			return nil
+		}
+		if detail := findAuthFailure(*m.Result); detail != "" {
+			return NewAuthenticationError(AuthErrorSourceResult, detail, nil)
+		}
+	}
+	return nil
+}
+
+// authTracker turns the first authentication failure seen in a response
+// stream into an error, giving OnAuthenticationError a chance to refresh
+// credentials.
+type authTracker struct {
+	callback AuthenticationCallback
+	reported bool
+}
+
+func newAuthTracker(options *ClaudeAgentOptions) *authTracker {
+	tracker := &authTracker{}
+	if options != nil {
+		tracker.callback = options.OnAuthenticationError
+	}
+	return tracker
+}
+
+// observe returns an error for the first message that reports an authentication failure.
+func (a *authTracker) observe(ctx context.Context, msg Message) error {
+	if a.reported {
+		return nil
+	}
+	authErr := authErrorFromMessage(msg)
+	if authErr == nil {
+		return nil
+	}
+	return a.resolve(ctx, authErr)
+}
+
+// resolve runs the authentication callback for err and returns err.
+// Errors that are not AuthenticationErrors are returned unchanged.
+func (a *authTracker) resolve(ctx context.Context, err error) error {
+	authErr, ok := err.(*AuthenticationError)
+	if !ok {
+		return err
+	}
+	if a.reported || a.callback == nil {
+		a.reported = true
+		return authErr
+	}
+	a.reported = true
+	if cbErr := a.callback(ctx, authErr); cbErr == nil {
+		authErr.Recovered = true
+	} else if authErr.Err == nil {
+		authErr.Err = cbErr
+	}
+	return authErr
+}
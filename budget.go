@@ -0,0 +1,270 @@
+package claude
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BudgetStep switches the model once cumulative session cost reaches AtUSD.
+type BudgetStep struct {
+	AtUSD float64
+	Model string // e.g. "claude-haiku-4-5"
+}
+
+// BudgetStrategy keeps a ClaudeSDKClient session running at reduced cost
+// instead of stopping at a single budget.
+//
+// As the cumulative cost reported in ResultMessages crosses each step, the
+// client calls SetModel with that step's model and emits an
+// EventTypeBudgetDowngrade event. Once HardCapUSD is reached the message
+// stream ends with a BudgetExceededError and further queries are refused.
+//
+// Example:
+//
+//	options := &claude.ClaudeAgentOptions{
+//	    BudgetStrategy: &claude.BudgetStrategy{
+//	        Steps: []claude.BudgetStep{
+//	            {AtUSD: 5, Model: "claude-sonnet-4-5"},
+//	            {AtUSD: 10, Model: "claude-haiku-4-5"},
+//	        },
+//	        HardCapUSD: 15,
+//	    },
+//	}
+type BudgetStrategy struct {
+	Steps      []BudgetStep
+	HardCapUSD float64 // 0 = no hard cap
+}
+
+// BudgetDowngradeEvent is the Data of an EventTypeBudgetDowngrade event.
+type BudgetDowngradeEvent struct {
+	CostUSD float64 `json:"cost_usd"` // Cumulative cost that triggered the step
+	AtUSD   float64 `json:"at_usd"`
+	Model   string  `json:"model"`
+}
+
+// budgetGuard applies a BudgetStrategy and BudgetLimits to one Query() call
+// or client connection. A nil guard ignores everything.
+type budgetGuard struct {
+	steps    []BudgetStep // Sorted by AtUSD
+	cap      float64
+	limits   BudgetLimits
+	maxUSD   *float64 // MaxBudgetUSD, enforced by the CLI; only used by remainingUSD
+	mu       sync.Mutex
+	next     int     // Index of the first step not yet applied
+	model    string  // Model of the last step applied, see stepModel
+	cost     float64 // Session cost
+	last     float64 // Cumulative cost in the last ResultMessage
+	start    float64 // Session cost when the current query began
+	daySpent float64 // Today's spend when last read or recorded
+	capErr   error   // Ends the session: hard cap, session, or daily limit
+	qErr     error   // Fails the current query
+}
+
+func newBudgetGuard(strategy *BudgetStrategy, limits *BudgetLimits, maxBudgetUSD *float64) *budgetGuard {
+	if strategy == nil && limits == nil && maxBudgetUSD == nil {
+		return nil
+	}
+	g := &budgetGuard{maxUSD: maxBudgetUSD}
+	if strategy != nil {
+		g.steps = append([]BudgetStep(nil), strategy.Steps...)
+		sort.SliceStable(g.steps, func(i, j int) bool { return g.steps[i].AtUSD < g.steps[j].AtUSD })
+		g.cap = strategy.HardCapUSD
+	}
+	if limits != nil {
+		g.limits = *limits
+	}
+	if g.limits.Store == nil {
+		g.limits.Store = defaultBudgetStore
+	}
+	if g.limits.Location == nil {
+		g.limits.Location = time.UTC
+	}
+	return g
+}
+
+// begin starts a query. It returns the error that ended the session, or a
+// BudgetExceededError if today's spend has reached the daily limit.
+func (g *budgetGuard) begin(ctx context.Context) error {
+	if g == nil {
+		return nil
+	}
+	if err := g.err(); err != nil {
+		return err
+	}
+	if g.limits.DailyUSD > 0 {
+		spent, err := g.limits.Store.Spend(ctx, g.today())
+		if err != nil {
+			return &ClaudeSDKError{Message: "failed to read daily budget spend", Err: err}
+		}
+		if spent >= g.limits.DailyUSD {
+			return NewScopedBudgetExceededError(BudgetScopeDaily, spent, g.limits.DailyUSD)
+		}
+		g.mu.Lock()
+		g.daySpent = spent
+		g.mu.Unlock()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.start = g.cost
+	g.qErr = nil
+	return nil
+}
+
+// observe records the cost in msg. It returns the step to apply, if a new
+// one was crossed, and a BudgetExceededError once the session must end.
+// When several steps are crossed at once only the last one is returned.
+// Exceeding the query limit is reported by queryErr.
+func (g *budgetGuard) observe(ctx context.Context, msg Message, errs *errorPipeline) (*BudgetStep, error) {
+	result, ok := msg.(*ResultMessage)
+	if g == nil || !ok || result.TotalCostUSD == nil {
+		return nil, nil
+	}
+
+	g.mu.Lock()
+	// The CLI reports the cumulative cost of its process, which starts over
+	// when the session is restarted
+	delta := *result.TotalCostUSD - g.last
+	if *result.TotalCostUSD < g.last {
+		delta = *result.TotalCostUSD
+	}
+	g.last = *result.TotalCostUSD
+	g.cost += delta
+
+	if g.cap > 0 && g.cost >= g.cap && g.capErr == nil {
+		g.capErr = NewBudgetExceededError(g.cost, g.cap)
+	}
+	if g.limits.SessionUSD > 0 && g.cost >= g.limits.SessionUSD && g.capErr == nil {
+		g.capErr = NewScopedBudgetExceededError(BudgetScopeSession, g.cost, g.limits.SessionUSD)
+	}
+	if queryCost := g.cost - g.start; g.limits.QueryUSD > 0 && queryCost >= g.limits.QueryUSD && g.qErr == nil {
+		g.qErr = NewScopedBudgetExceededError(BudgetScopeQuery, queryCost, g.limits.QueryUSD)
+	}
+
+	var step *BudgetStep
+	for g.next < len(g.steps) && g.cost >= g.steps[g.next].AtUSD {
+		step = &g.steps[g.next]
+		g.next++
+	}
+	g.mu.Unlock()
+
+	if g.limits.DailyUSD > 0 && delta > 0 {
+		g.addDailySpend(ctx, delta, errs)
+	}
+	if err := g.err(); err != nil {
+		return nil, err
+	}
+	return step, nil
+}
+
+// addDailySpend records delta in the store, ending the session once the
+// daily limit is reached. Store failures are reported to errs.
+func (g *budgetGuard) addDailySpend(ctx context.Context, delta float64, errs *errorPipeline) {
+	spent, err := g.limits.Store.AddSpend(ctx, g.today(), delta)
+	if err != nil {
+		errs.warn(&ClaudeSDKError{Message: "failed to record daily budget spend", Err: err})
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.daySpent = spent
+	if spent >= g.limits.DailyUSD && g.capErr == nil {
+		g.capErr = NewScopedBudgetExceededError(BudgetScopeDaily, spent, g.limits.DailyUSD)
+	}
+}
+
+func (g *budgetGuard) today() string {
+	return time.Now().In(g.limits.Location).Format("2006-01-02")
+}
+
+// queryErr returns the BudgetExceededError for the current query, if it
+// exceeded BudgetLimits.QueryUSD.
+func (g *budgetGuard) queryErr() error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.qErr
+}
+
+// err returns the BudgetExceededError once the session must end.
+func (g *budgetGuard) err() error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.capErr
+}
+
+// downgrade switches to step's model and reports it. It runs in the
+// background: SetModel waits for a control response that is read by the same
+// loop that delivers messages.
+func (g *budgetGuard) downgrade(ctx context.Context, handler *queryHandler, step BudgetStep, options *ClaudeAgentOptions) {
+	cost := g.costUSD()
+	loggerFor(options).Warn("budget step reached, switching model",
+		"cost_usd", cost, "at_usd", step.AtUSD, "model", step.Model)
+	emitEvent(handler.sink, Event{
+		Type: EventTypeBudgetDowngrade,
+		Data: BudgetDowngradeEvent{CostUSD: cost, AtUSD: step.AtUSD, Model: step.Model},
+	})
+
+	g.mu.Lock()
+	g.model = step.Model
+	g.mu.Unlock()
+
+	go func() {
+		if err := handler.SetModel(ctx, step.Model); err != nil {
+			handler.errs.warn(err)
+		}
+	}()
+}
+
+// stepModel returns the model switched to by the last step applied, or ""
+// if none was. connect switches restarted sessions to it, so a downgrade
+// outlives them.
+func (g *budgetGuard) stepModel() string {
+	if g == nil {
+		return ""
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.model
+}
+
+// remainingUSD returns what can still be spent before the tightest limit,
+// as of the last ResultMessage, or nil if no limit applies.
+func (g *budgetGuard) remainingUSD() *float64 {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var remaining *float64
+	consider := func(limit, spent float64) {
+		if limit <= 0 {
+			return
+		}
+		left := max(limit-spent, 0)
+		if remaining == nil || left < *remaining {
+			remaining = &left
+		}
+	}
+	consider(g.cap, g.cost)
+	consider(g.limits.SessionUSD, g.cost)
+	consider(g.limits.QueryUSD, g.cost-g.start)
+	consider(g.limits.DailyUSD, g.daySpent)
+	if g.maxUSD != nil {
+		consider(*g.maxUSD, g.last) // The CLI applies it to its process
+	}
+	return remaining
+}
+
+func (g *budgetGuard) costUSD() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.cost
+}
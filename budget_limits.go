@@ -0,0 +1,178 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BudgetScope identifies which limit a BudgetExceededError refers to.
+type BudgetScope string
+
+const (
+	BudgetScopeQuery   BudgetScope = "query"   // One query: a Query() call or one ClaudeSDKClient turn
+	BudgetScopeSession BudgetScope = "session" // One Query() call or client connection
+	BudgetScopeDaily   BudgetScope = "daily"   // All sessions sharing a BudgetStore, per calendar day
+)
+
+// ResultSubtypeErrorMaxBudget is the ResultMessage subtype the CLI reports
+// when MaxBudgetUSD is reached. Budgets enforced by the SDK are reported as
+// a BudgetExceededError instead; see BudgetExceededError.ResultSubtype.
+const ResultSubtypeErrorMaxBudget = "error_max_budget_usd"
+
+// BudgetLimits layers cost limits enforced by the SDK on top of
+// MaxBudgetUSD, which the CLI applies to a whole run.
+//
+// Cost is only known when a ResultMessage arrives, so limits are checked
+// after each query: the result that crosses a limit is still delivered.
+//   - QueryUSD fails the query that exceeded it; later queries may still run.
+//   - SessionUSD ends the session; further queries are refused.
+//   - DailyUSD ends the session and refuses new queries, in this and every
+//     other session using the same Store, until the day changes.
+//
+// Example:
+//
+//	options := &claude.ClaudeAgentOptions{
+//	    BudgetLimits: &claude.BudgetLimits{
+//	        QueryUSD:   0.50,
+//	        SessionUSD: 5,
+//	        DailyUSD:   50,
+//	        Store:      claude.NewFileBudgetStore("/var/lib/agent/spend.json"),
+//	    },
+//	}
+type BudgetLimits struct {
+	QueryUSD   float64 // 0 = no per-query limit
+	SessionUSD float64 // 0 = no per-session limit
+	DailyUSD   float64 // 0 = no daily limit
+
+	// Store records daily spend. Default: an in-memory store shared by all
+	// sessions in this process.
+	Store BudgetStore
+
+	// Location sets the day boundary for DailyUSD (default: UTC).
+	Location *time.Location
+}
+
+// BudgetStore persists spend per day for BudgetLimits.DailyUSD. Days are
+// formatted as "2006-01-02". Implementations must be safe for concurrent
+// use; share one store, e.g. backed by a database, to apply a daily budget
+// across processes.
+type BudgetStore interface {
+	// Spend returns the spend recorded for day.
+	Spend(ctx context.Context, day string) (float64, error)
+	// AddSpend adds usd to day's spend and returns the new total.
+	AddSpend(ctx context.Context, day string, usd float64) (float64, error)
+}
+
+var defaultBudgetStore = NewMemoryBudgetStore()
+
+// MemoryBudgetStore is a BudgetStore that keeps spend in memory.
+type MemoryBudgetStore struct {
+	mu    sync.Mutex
+	spend map[string]float64
+}
+
+// NewMemoryBudgetStore creates an empty MemoryBudgetStore.
+func NewMemoryBudgetStore() *MemoryBudgetStore {
+	return &MemoryBudgetStore{spend: make(map[string]float64)}
+}
+
+// Spend returns the spend recorded for day.
+func (s *MemoryBudgetStore) Spend(ctx context.Context, day string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.spend[day], nil
+}
+
+// AddSpend adds usd to day's spend and returns the new total.
+func (s *MemoryBudgetStore) AddSpend(ctx context.Context, day string, usd float64) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spend[day] += usd
+	return s.spend[day], nil
+}
+
+// fileBudgetStoreDays is how many days of spend a FileBudgetStore keeps.
+const fileBudgetStoreDays = 31
+
+// FileBudgetStore is a BudgetStore that keeps spend in a JSON file, so a
+// daily budget survives restarts. Writes are atomic, but concurrent
+// processes sharing one file may lose updates; use one store per process or
+// a database-backed store.
+type FileBudgetStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileBudgetStore creates a FileBudgetStore at path. The file is created
+// on first use.
+func NewFileBudgetStore(path string) *FileBudgetStore {
+	return &FileBudgetStore{path: path}
+}
+
+// Spend returns the spend recorded for day.
+func (s *FileBudgetStore) Spend(ctx context.Context, day string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	spend, err := s.load()
+	return spend[day], err
+}
+
+// AddSpend adds usd to day's spend and returns the new total.
+func (s *FileBudgetStore) AddSpend(ctx context.Context, day string, usd float64) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	spend, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	spend[day] += usd
+
+	// Drop the oldest days
+	days := make([]string, 0, len(spend))
+	for d := range spend {
+		days = append(days, d)
+	}
+	sort.Strings(days)
+	for len(days) > fileBudgetStoreDays {
+		delete(spend, days[0])
+		days = days[1:]
+	}
+
+	data, err := json.Marshal(spend)
+	if err != nil {
+		return 0, err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return 0, err
+	}
+	return spend[day], nil
+}
+
+func (s *FileBudgetStore) load() (map[string]float64, error) {
+	spend := make(map[string]float64)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return spend, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &spend); err != nil {
+		return nil, err
+	}
+	return spend, nil
+}
@@ -0,0 +1,60 @@
+package claude
+
+import (
+	"context"
+	"time"
+)
+
+// callbackTimeouts are the limits on user callbacks set by
+// ClaudeAgentOptions.CanUseToolTimeout and HookTimeout. Zero means none.
+type callbackTimeouts struct {
+	canUseTool time.Duration
+	hook       time.Duration
+}
+
+func newCallbackTimeouts(options *ClaudeAgentOptions) callbackTimeouts {
+	if options == nil {
+		return callbackTimeouts{}
+	}
+	return callbackTimeouts{canUseTool: options.CanUseToolTimeout, hook: options.HookTimeout}
+}
+
+// callWithTimeout runs a user callback with a context that expires after
+// timeout, or with ctx unchanged if timeout is not positive. If the
+// callback has not returned when its context expires, callWithTimeout
+// returns a *CallbackTimeoutError at once, so a callback that ignores its
+// context cannot hold up the control protocol; it is left to finish in the
+// background and its result is discarded.
+func callWithTimeout[T any](ctx context.Context, timeout time.Duration, callback string, fn func(context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		value T
+		err   error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		value, err := fn(ctx)
+		done <- outcome{value, err}
+	}()
+
+	select {
+	case result := <-done:
+		if result.err != nil && ctx.Err() == context.DeadlineExceeded {
+			// Gave up on its expired context, e.g. returning ctx.Err()
+			return result.value, NewCallbackTimeoutError(callback, timeout)
+		}
+		return result.value, result.err
+	case <-ctx.Done():
+		var zero T
+		if ctx.Err() == context.DeadlineExceeded {
+			return zero, NewCallbackTimeoutError(callback, timeout)
+		}
+		// Cancelled by the caller, e.g. when the CLI cancels the request
+		return zero, context.Cause(ctx)
+	}
+}
@@ -0,0 +1,111 @@
+package claude
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CancelBehavior selects what happens to the turn in progress when the
+// context of the query waiting for it is canceled. Either way the query's
+// channels close promptly with the context's error, and the turn's remaining
+// messages are discarded instead of reaching the next query.
+type CancelBehavior string
+
+const (
+	// CancelInterrupt interrupts the turn, so the CLI stops working on it.
+	// A one-shot Query with a string prompt has no control protocol; its CLI
+	// process is stopped instead.
+	CancelInterrupt CancelBehavior = ""
+
+	// CancelDrain lets the turn run to completion in the background. Query,
+	// whose CLI process ends with the query, stops the process without
+	// interrupting first.
+	CancelDrain CancelBehavior = "drain"
+)
+
+// cancelInterruptTimeout bounds the interrupt sent for a canceled query.
+const cancelInterruptTimeout = 5 * time.Second
+
+// interruptOnCancel reports whether a canceled query interrupts its turn.
+func interruptOnCancel(options *ClaudeAgentOptions) bool {
+	return options == nil || options.CancelBehavior != CancelDrain
+}
+
+// interruptCanceled interrupts the turn of a canceled Query before its CLI
+// process is stopped, so the session records the turn as interrupted. The
+// handler's messages are discarded meanwhile: the interrupt's response is
+// read by the same loop that delivers them.
+func interruptCanceled(ctx context.Context, q *queryHandler) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cancelInterruptTimeout)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Interrupt(ctx)
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		case _, ok := <-q.ReceiveMessages():
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// turnQueue orders the reads of ClaudeSDKClient.Query calls from the shared
+// message stream, so a canceled query keeps its place until the rest of its
+// turn has been discarded.
+type turnQueue struct {
+	mu   sync.Mutex
+	last chan struct{} // Closed when the last query queued is done
+}
+
+// next queues a query. It returns a channel closed when the previous query
+// is done reading, and done, which marks this one done; done may be called
+// more than once.
+func (q *turnQueue) next() (previous <-chan struct{}, done func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	previous = q.last
+	if previous == nil {
+		ready := make(chan struct{})
+		close(ready)
+		previous = ready
+	}
+	current := make(chan struct{})
+	q.last = current
+	var once sync.Once
+	return previous, func() { once.Do(func() { close(current) }) }
+}
+
+// abandonTurn discards the rest of a canceled query's turn, interrupting it
+// first unless CancelDrain is set, then marks the query done. responses is
+// the turn's ReceiveResponse stream, or nil if the query was canceled
+// before its turn came.
+func (c *ClaudeSDKClient) abandonTurn(previous <-chan struct{}, responses <-chan Message, done func()) {
+	defer done()
+	<-previous
+	conn := c.connection()
+	if responses == nil {
+		responses = c.ReceiveResponse(conn.ctx)
+	}
+	if interruptOnCancel(conn.options) {
+		go c.interruptTurn()
+	}
+	for range responses {
+	}
+}
+
+// interruptTurn interrupts the turn of a canceled query.
+func (c *ClaudeSDKClient) interruptTurn() {
+	conn := c.connection()
+	ctx, cancel := context.WithTimeout(conn.ctx, cancelInterruptTimeout)
+	defer cancel()
+	if err := c.Interrupt(ctx); err != nil && conn.ctx.Err() == nil {
+		loggerFor(conn.options).Warn("failed to interrupt a canceled query", "error", err)
+	}
+}
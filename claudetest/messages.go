@@ -0,0 +1,81 @@
+package claudetest
+
+// Model is the model named in the assistant messages built here.
+const Model = "claude-sonnet-4-5"
+
+// AssistantText builds an assistant message with one text block.
+func AssistantText(text string) map[string]interface{} {
+	return assistantMessage(map[string]interface{}{
+		"type": "text",
+		"text": text,
+	})
+}
+
+// AssistantToolUse builds an assistant message with text followed by a call
+// to toolName.
+func AssistantToolUse(text string, toolID string, toolName string, toolInput map[string]interface{}) map[string]interface{} {
+	return assistantMessage(
+		map[string]interface{}{
+			"type": "text",
+			"text": text,
+		},
+		map[string]interface{}{
+			"type":  "tool_use",
+			"id":    toolID,
+			"name":  toolName,
+			"input": toolInput,
+		},
+	)
+}
+
+func assistantMessage(blocks ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "assistant",
+		"message": map[string]interface{}{
+			"role":    "assistant",
+			"content": blocks,
+			"model":   Model,
+		},
+	}
+}
+
+// ToolResult builds the user message carrying the result of tool call
+// toolID, as the CLI sends after running a tool.
+func ToolResult(toolID string, content string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "user",
+		"message": map[string]interface{}{
+			"role": "user",
+			"content": []interface{}{
+				map[string]interface{}{
+					"type":        "tool_result",
+					"tool_use_id": toolID,
+					"content":     content,
+					"is_error":    isError,
+				},
+			},
+		},
+	}
+}
+
+// Result builds a successful result message, which ends a query.
+func Result(sessionID string, costUSD float64, durationMS int) map[string]interface{} {
+	return map[string]interface{}{
+		"type":            "result",
+		"subtype":         "success",
+		"duration_ms":     float64(durationMS),
+		"duration_api_ms": float64(durationMS - 200),
+		"is_error":        false,
+		"num_turns":       float64(1),
+		"session_id":      sessionID,
+		"total_cost_usd":  costUSD,
+	}
+}
+
+// ResultWithSubtype builds a result message with subtype, e.g.
+// "error_max_turns".
+func ResultWithSubtype(sessionID string, subtype string, costUSD float64, durationMS int) map[string]interface{} {
+	msg := Result(sessionID, costUSD, durationMS)
+	msg["subtype"] = subtype
+	return msg
+}
@@ -0,0 +1,355 @@
+// Package claudetest provides a mock Transport and message builders for
+// unit testing code built on the SDK without running the Claude Code CLI.
+//
+// A MockTransport stands in for the CLI: the test queues the messages the
+// CLI would send, and asserts on the messages the SDK wrote. The control
+// requests the SDK sends during Connect, Interrupt, SetModel, and
+// SetPermissionMode are answered with success automatically.
+//
+// Example:
+//
+//	transport := claudetest.NewMockTransport()
+//	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+//	if err := client.Connect(ctx); err != nil {
+//	    t.Fatal(err)
+//	}
+//	defer client.Close()
+//
+//	msgCh, errCh := client.Query(ctx, "What is 2 + 2?")
+//	transport.QueueResponse(claudetest.AssistantText("4"))
+//	transport.QueueResponse(claudetest.Result("session-1", 0.01, 500))
+package claudetest
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+// MockTransport is a claude.Transport whose CLI side is driven by the test.
+type MockTransport struct {
+	// InitResponse is the payload of the initialize response, e.g. the
+	// commands and output styles the CLI reports (default: none)
+	InitResponse map[string]interface{}
+
+	connected       bool
+	closed          bool
+	streamClosed    bool
+	writtenMessages []string
+	controlReplies  map[string]controlReply // Set with SetControlResponse or SetControlError
+	responseCh      chan map[string]interface{}
+	errorCh         chan error
+	mu              sync.Mutex
+	ctx             context.Context
+	cancel          context.CancelFunc
+}
+
+// controlReply is the configured answer to one control request subtype.
+type controlReply struct {
+	response map[string]interface{}
+	err      string
+}
+
+// NewMockTransport creates a MockTransport with nothing queued.
+func NewMockTransport() *MockTransport {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &MockTransport{
+		writtenMessages: make([]string, 0),
+		controlReplies:  make(map[string]controlReply),
+		responseCh:      make(chan map[string]interface{}, 10),
+		errorCh:         make(chan error, 1),
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+}
+
+func (m *MockTransport) Connect(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connected = true
+	return nil
+}
+
+func (m *MockTransport) Write(ctx context.Context, data string) error {
+	m.mu.Lock()
+	m.writtenMessages = append(m.writtenMessages, data)
+	m.mu.Unlock()
+
+	// Auto-respond to control requests
+	go m.handleControlRequest(data)
+
+	return nil
+}
+
+func (m *MockTransport) handleControlRequest(data string) {
+	var msg map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		return
+	}
+	if msg["type"] != "control_request" {
+		return
+	}
+
+	request, _ := msg["request"].(map[string]interface{})
+	requestID, _ := msg["request_id"].(string)
+	subtype, _ := request["subtype"].(string)
+
+	m.mu.Lock()
+	reply, configured := m.controlReplies[subtype]
+	m.mu.Unlock()
+
+	switch {
+	case configured && reply.err != "":
+		m.SimulateControlError(requestID, reply.err)
+	case configured:
+		m.SimulateControlResponse(requestID, reply.response)
+	case subtype == "initialize":
+		response := map[string]interface{}{
+			"request_id":   requestID,
+			"subtype":      "success",
+			"commands":     []interface{}{},
+			"output_style": "default",
+		}
+		if m.InitResponse != nil {
+			response["response"] = m.InitResponse
+		}
+		m.QueueResponse(map[string]interface{}{
+			"type":     "control_response",
+			"response": response,
+		})
+	case subtype == "interrupt", subtype == "set_permission_mode", subtype == "set_model":
+		m.SimulateControlResponse(requestID, nil)
+	}
+}
+
+func (m *MockTransport) ReadMessages(ctx context.Context) (<-chan map[string]interface{}, <-chan error) {
+	msgCh := make(chan map[string]interface{}, 10)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(msgCh)
+		defer close(errCh)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.ctx.Done():
+				return
+			case msg, ok := <-m.responseCh:
+				if !ok {
+					return
+				}
+				select {
+				case msgCh <- msg:
+				case <-ctx.Done():
+					return
+				}
+			case err, ok := <-m.errorCh:
+				if ok && err != nil {
+					errCh <- err
+					return
+				}
+			}
+		}
+	}()
+
+	return msgCh, errCh
+}
+
+func (m *MockTransport) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.closed {
+		m.closed = true
+		m.cancel()
+		close(m.errorCh)
+	}
+	return nil
+}
+
+func (m *MockTransport) IsReady() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.connected && !m.closed
+}
+
+// Closed reports whether the SDK has closed the transport.
+func (m *MockTransport) Closed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.closed
+}
+
+func (m *MockTransport) EndInput() error {
+	return nil
+}
+
+// GetWrittenMessages returns every line the SDK has written, in order.
+func (m *MockTransport) GetWrittenMessages() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]string, len(m.writtenMessages))
+	copy(result, m.writtenMessages)
+	return result
+}
+
+// writtenOfType returns the written messages of msgType, decoded.
+func (m *MockTransport) writtenOfType(msgType string) []map[string]interface{} {
+	var messages []map[string]interface{}
+	for _, data := range m.GetWrittenMessages() {
+		var msg map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &msg); err != nil {
+			continue
+		}
+		if msg["type"] == msgType {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// ControlRequests returns the control requests the SDK has written with
+// subtype, or all of them if subtype is empty. Each is the full message,
+// with "request_id" and "request".
+func (m *MockTransport) ControlRequests(subtype string) []map[string]interface{} {
+	var requests []map[string]interface{}
+	for _, msg := range m.writtenOfType("control_request") {
+		request, _ := msg["request"].(map[string]interface{})
+		if subtype == "" || request["subtype"] == subtype {
+			requests = append(requests, msg)
+		}
+	}
+	return requests
+}
+
+// UserMessages returns the user messages the SDK has written, such as
+// prompts.
+func (m *MockTransport) UserMessages() []map[string]interface{} {
+	return m.writtenOfType("user")
+}
+
+// WaitForControlRequest waits until the SDK writes a control request with
+// subtype and returns the first one.
+func (m *MockTransport) WaitForControlRequest(subtype string, timeout time.Duration) (map[string]interface{}, bool) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if requests := m.ControlRequests(subtype); len(requests) > 0 {
+			return requests[0], true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil, false
+}
+
+// RequireControlRequest is WaitForControlRequest with a one second timeout
+// that fails t if no such request is written.
+func (m *MockTransport) RequireControlRequest(t testing.TB, subtype string) map[string]interface{} {
+	t.Helper()
+	request, ok := m.WaitForControlRequest(subtype, time.Second)
+	if !ok {
+		t.Fatalf("expected a %s control request, got %d control requests", subtype, len(m.ControlRequests("")))
+	}
+	return request
+}
+
+// WaitForControlResponse waits until the SDK writes a control_response for requestID
+// and returns its inner response object.
+func (m *MockTransport) WaitForControlResponse(requestID string, timeout time.Duration) (map[string]interface{}, bool) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		for _, msg := range m.writtenOfType("control_response") {
+			response, _ := msg["response"].(map[string]interface{})
+			if response["request_id"] == requestID {
+				return response, true
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil, false
+}
+
+// SetControlResponse answers later control requests with subtype with a
+// success carrying response, e.g. for "mcp_status". It overrides the
+// automatic answers, including InitResponse for "initialize".
+func (m *MockTransport) SetControlResponse(subtype string, response map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.controlReplies[subtype] = controlReply{response: response}
+}
+
+// SetControlError answers later control requests with subtype with an
+// error response carrying message.
+func (m *MockTransport) SetControlError(subtype string, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.controlReplies[subtype] = controlReply{err: message}
+}
+
+// SimulateControlResponse answers the SDK's control request requestID with
+// a success carrying response, which may be nil.
+func (m *MockTransport) SimulateControlResponse(requestID string, response map[string]interface{}) {
+	inner := map[string]interface{}{
+		"request_id": requestID,
+		"subtype":    "success",
+	}
+	if response != nil {
+		inner["response"] = response
+	}
+	m.QueueResponse(map[string]interface{}{
+		"type":     "control_response",
+		"response": inner,
+	})
+}
+
+// SimulateControlError answers the SDK's control request requestID with an
+// error.
+func (m *MockTransport) SimulateControlError(requestID string, message string) {
+	m.QueueResponse(map[string]interface{}{
+		"type": "control_response",
+		"response": map[string]interface{}{
+			"request_id": requestID,
+			"subtype":    "error",
+			"error":      message,
+		},
+	})
+}
+
+// SendControlRequest sends a control request from the CLI, such as a
+// hook_callback or can_use_tool; wait for the SDK's answer with
+// WaitForControlResponse.
+func (m *MockTransport) SendControlRequest(requestID string, request map[string]interface{}) {
+	m.QueueResponse(map[string]interface{}{
+		"type":       "control_request",
+		"request_id": requestID,
+		"request":    request,
+	})
+}
+
+// QueueResponse delivers msg to the reader. Messages queued after Close are
+// dropped, so late auto-responses cannot race with shutdown.
+func (m *MockTransport) QueueResponse(msg map[string]interface{}) {
+	select {
+	case m.responseCh <- msg:
+	case <-m.ctx.Done():
+	}
+}
+
+// QueueError ends the message stream with err, as when the CLI fails.
+func (m *MockTransport) QueueError(err error) {
+	m.errorCh <- err
+}
+
+// CloseStream ends the message stream without an error once the queued
+// messages are read, as when the CLI's output ends. Nothing may be queued
+// afterwards.
+func (m *MockTransport) CloseStream() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.streamClosed {
+		m.streamClosed = true
+		close(m.responseCh)
+	}
+}
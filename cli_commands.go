@@ -0,0 +1,183 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// MCPServerStatus is one server reported by `claude mcp list`.
+type MCPServerStatus struct {
+	Name      string
+	Target    string // Command line of a stdio server, or URL of a remote one
+	Transport string // "stdio", "http" or "sse"
+	Status    string // As reported, e.g. "Connected" or "Failed to connect"
+	Connected bool
+}
+
+// ListMCPServers runs `claude mcp list` and returns the MCP servers the CLI
+// has configured for the current directory, with the outcome of its health
+// check. An empty cliPath finds the CLI as Query does. The health check
+// starts every server, so bound ctx as needed.
+func ListMCPServers(ctx context.Context, cliPath string) ([]MCPServerStatus, error) {
+	output, err := runCLICommand(ctx, cliPath, "mcp", "list")
+	if err != nil {
+		return nil, err
+	}
+	return parseMCPList(output), nil
+}
+
+// mcpListLine matches a server line of `claude mcp list`, e.g.
+// "github: npx -y @modelcontextprotocol/server-github - ✓ Connected".
+var mcpListLine = regexp.MustCompile(`^([^\s:]+): (.+) - (✓|✗|⚠|!)\s*(.+)$`)
+
+// mcpListTransport matches the transport suffix of a remote server's URL.
+var mcpListTransport = regexp.MustCompile(`^(.+) \((HTTP|SSE)\)$`)
+
+// parseMCPList parses the output of `claude mcp list`, skipping lines that
+// do not describe a server.
+func parseMCPList(output string) []MCPServerStatus {
+	var servers []MCPServerStatus
+	for _, line := range strings.Split(output, "\n") {
+		match := mcpListLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		server := MCPServerStatus{
+			Name:      match[1],
+			Target:    match[2],
+			Transport: "stdio",
+			Status:    match[4],
+			Connected: match[3] == "✓",
+		}
+		if m := mcpListTransport.FindStringSubmatch(server.Target); m != nil {
+			server.Target, server.Transport = m[1], strings.ToLower(m[2])
+		}
+		servers = append(servers, server)
+	}
+	return servers
+}
+
+// DoctorEntry is one "key: value" line of `claude doctor`.
+type DoctorEntry struct {
+	Key   string
+	Value string
+}
+
+// DoctorReport is the result of `claude doctor`.
+type DoctorReport struct {
+	Version  string        // CLI version, from "Currently running"
+	Entries  []DoctorEntry // Every "key: value" line, in order
+	Warnings []string      // Lines flagged as warnings or errors
+	Output   string        // The full output, without terminal escapes
+}
+
+// Get returns the value of the entry with key, or "".
+func (r *DoctorReport) Get(key string) string {
+	for _, entry := range r.Entries {
+		if strings.EqualFold(entry.Key, key) {
+			return entry.Value
+		}
+	}
+	return ""
+}
+
+// RunDoctor runs `claude doctor` and returns its diagnostics of the CLI
+// installation: install method, auto-update settings, and any problems
+// found. An empty cliPath finds the CLI as Query does. The report's lines
+// are parsed loosely, as their wording varies between CLI versions; Output
+// keeps the full text.
+func RunDoctor(ctx context.Context, cliPath string) (*DoctorReport, error) {
+	output, err := runCLICommand(ctx, cliPath, "doctor")
+	if err != nil {
+		return nil, err
+	}
+	return parseDoctor(output), nil
+}
+
+// ansiEscape matches terminal color and cursor sequences.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// parseDoctor parses the output of `claude doctor`.
+func parseDoctor(output string) *DoctorReport {
+	output = ansiEscape.ReplaceAllString(output, "")
+	report := &DoctorReport{Output: output}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "└├│─ "))
+		if line == "" {
+			continue
+		}
+		if warning, ok := cutDoctorWarning(line); ok {
+			report.Warnings = append(report.Warnings, warning)
+			continue
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok || strings.Contains(key, "  ") {
+			continue
+		}
+		report.Entries = append(report.Entries, DoctorEntry{Key: key, Value: strings.TrimSpace(value)})
+	}
+	if running := report.Get("Currently running"); running != "" {
+		report.Version = parseCLIVersion(running)
+	}
+	return report
+}
+
+// cutDoctorWarning returns line without its warning marker, if it has one.
+func cutDoctorWarning(line string) (string, bool) {
+	for _, prefix := range []string{"⚠", "✗", "Warning:", "Error:"} {
+		if rest, ok := strings.CutPrefix(line, prefix); ok {
+			return strings.TrimSpace(rest), true
+		}
+	}
+	return "", false
+}
+
+// GetCLIConfig runs `claude config get key` and returns the setting's
+// value, as the CLI prints it: plain text for strings, JSON for other
+// values. global reads the user-wide setting rather than the project's. An
+// empty cliPath finds the CLI as Query does.
+func GetCLIConfig(ctx context.Context, cliPath, key string, global bool) (string, error) {
+	args := []string{"config", "get"}
+	if global {
+		args = append(args, "--global")
+	}
+	output, err := runCLICommand(ctx, cliPath, append(args, key)...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// runCLICommand runs the CLI with args and no input, returning its standard
+// output. A failure to start is a CLIConnectionError and a non-zero exit a
+// ProcessError carrying standard error.
+func runCLICommand(ctx context.Context, cliPath string, args ...string) (string, error) {
+	if cliPath == "" {
+		var err error
+		if cliPath, err = findCLI(); err != nil {
+			return "", err
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, cliPath, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	command := "claude " + strings.Join(args, " ")
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return "", ctx.Err()
+	case errors.As(err, &exitErr):
+		return "", NewProcessError(fmt.Sprintf("%s failed", command), exitErr.ExitCode(), strings.TrimSpace(stderr.String()))
+	case err != nil:
+		return "", NewCLIConnectionError(fmt.Sprintf("failed to run %s", command), err)
+	}
+	return stdout.String(), nil
+}
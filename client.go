@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"strings"
+	"sync"
+	"time"
 )
 
 // ClaudeSDKClient provides bidirectional, interactive conversations with Claude Code.
@@ -36,6 +38,11 @@ import (
 //	if err := <-errCh; err != nil {
 //	    log.Fatal(err)
 //	}
+//
+// A client is safe for concurrent use by multiple goroutines as long as each
+// goroutine queries through its own Session, see ClaudeSDKClient.Session.
+// Calling Query or ReceiveResponse from several goroutines at once leaves
+// which goroutine receives which message undefined.
 type ClaudeSDKClient struct {
 	options         *ClaudeAgentOptions
 	customTransport Transport
@@ -43,7 +50,39 @@ type ClaudeSDKClient struct {
 	queryHandler    *queryHandler
 	ctx             context.Context
 	cancel          context.CancelFunc
-	currentSession  string // Auto-managed session ID
+	parser          messageParser
+	errs            *errorPipeline  // Per-connection error ordering, see Err()
+	connectCtx      context.Context // Parent context from Connect, reused on session restarts
+
+	// connMu is held for writing while the connection is set up, restarted,
+	// or torn down, and for reading while the fields above are read, see
+	// connection. Take it before mu.
+	connMu sync.RWMutex
+
+	mu             sync.Mutex
+	state          clientState         // Lifecycle, see checkConnected
+	sessionID      string              // CLI session ID observed in messages
+	conversationID string              // Session ID sent by Query, see conversation
+	pumpHandler    *queryHandler       // Connection read by PollMessages
+	pumpDone       chan struct{}       // Closed when that connection's stream ends
+	sessions       map[string]*Session // By ID, see Session
+	router         *sessionRouter      // Routes messages to Session queries
+
+	history     *messageHistory   // Optional bounded message history
+	timeline    *timelineBuilder  // Optional activity timeline, see Timeline()
+	compactions compactionLog     // See Compactions()
+	turns       turnQueue         // Orders Query's reads, see CancelBehavior
+	budget      *budgetGuard      // Optional BudgetStrategy, kept across session restarts
+	digests     *digestTracker    // Optional Notifier, kept across session restarts
+	costs       *CostTracker      // See CostTracker(), kept across session restarts
+	telemetry   *telemetry        // Optional tracing and metrics, kept across session restarts
+	pending     *pendingQueries   // Queries awaiting their result, see Shutdown
+	correlated  queryCorrelations // Correlation IDs of those queries, for events
+
+	oneShot bool // Connected with a string prompt, see Mode()
+
+	dynamicHooks *dynamicHooks    // Hooks added with AddHook, kept across session restarts
+	permissions  *permissionRules // Rules added with UpdatePermissions, kept across session restarts
 }
 
 // NewClaudeSDKClient creates a new Claude SDK client.
@@ -53,7 +92,12 @@ func NewClaudeSDKClient(options *ClaudeAgentOptions) *ClaudeSDKClient {
 	}
 
 	return &ClaudeSDKClient{
-		options: options,
+		options:      options,
+		dynamicHooks: newDynamicHooks(),
+		permissions:  newPermissionRules(),
+		costs:        newCostTracker(options),
+		telemetry:    newTelemetry(options),
+		pending:      &pendingQueries{},
 	}
 }
 
@@ -66,6 +110,11 @@ func NewClaudeSDKClientWithTransport(options *ClaudeAgentOptions, trans Transpor
 	return &ClaudeSDKClient{
 		options:         options,
 		customTransport: trans,
+		dynamicHooks:    newDynamicHooks(),
+		permissions:     newPermissionRules(),
+		costs:           newCostTracker(options),
+		telemetry:       newTelemetry(options),
+		pending:         &pendingQueries{},
 	}
 }
 
@@ -97,15 +146,57 @@ func (c *ClaudeSDKClient) Connect(ctx context.Context) error {
 //
 // The prompt parameter can be:
 //   - nil: Empty connection for interactive use
-//   - string: Initial prompt message
+//   - string: Initial prompt message, answered in one-shot mode (see Mode)
 //   - <-chan map[string]interface{}: Stream of input messages
 //
 // For most cases, use Connect() and then Query() instead.
+//
+// Connecting a client that is already connected returns ErrAlreadyConnected,
+// and connecting a closed client returns ErrClosed. If connecting fails the
+// client stays unconnected and Connect may be retried.
 func (c *ClaudeSDKClient) ConnectWithPrompt(ctx context.Context, prompt interface{}) error {
-	os.Setenv("CLAUDE_CODE_ENTRYPOINT", "sdk-go-client")
+	c.mu.Lock()
+	switch c.state {
+	case clientConnecting, clientConnected:
+		c.mu.Unlock()
+		return ErrAlreadyConnected
+	case clientClosed:
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.state = clientConnecting
+	c.mu.Unlock()
+
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	err := c.connect(ctx, prompt)
+
+	c.mu.Lock()
+	closed := c.state == clientClosed
+	switch {
+	case closed:
+		// Closed while connecting; Disconnect left the teardown to us
+		err = ErrClosed
+	case err != nil:
+		c.state = clientNew
+	default:
+		c.state = clientConnected
+	}
+	c.mu.Unlock()
+
+	if err != nil {
+		c.teardown()
+	}
+	return err
+}
 
+// connect starts the CLI and the control protocol.
+func (c *ClaudeSDKClient) connect(ctx context.Context, prompt interface{}) error {
 	// Create cancellable context
+	c.connectCtx = ctx
 	c.ctx, c.cancel = context.WithCancel(ctx)
+	// Queries sent on an earlier connection are never answered on this one
+	c.correlated.reset()
 
 	// Determine actual prompt (empty channel if nil)
 	actualPrompt := prompt
@@ -118,23 +209,54 @@ func (c *ClaudeSDKClient) ConnectWithPrompt(ctx context.Context, prompt interfac
 
 	// Validate and configure permission settings
 	_, isString := prompt.(string)
-	options, err := validateAndConfigurePermissions(c.options, !isString)
+	options, err := validateAndConfigurePermissions(c.permissions.wrap(c.options), !isString)
 	if err != nil {
 		return err
 	}
+	if err := validateMaxOutputTokens(options); err != nil {
+		return err
+	}
+	if err := validateAutoCompact(options); err != nil {
+		return err
+	}
+
+	if err := validateMcpToolsAtConnect(options); err != nil {
+		return err
+	}
+	if options.DynamicHooks {
+		options = c.dynamicHooks.withDispatchers(options)
+	}
+
+	c.parser = newMessageParser(options)
+	if c.history == nil && options.HistorySize != nil {
+		c.history = newMessageHistory(*options.HistorySize)
+	}
+	if c.timeline == nil {
+		c.timeline = newTimelineBuilder(options)
+	}
+	if c.budget == nil {
+		c.budget = newBudgetGuard(options.BudgetStrategy, options.BudgetLimits, options.MaxBudgetUSD)
+	}
+	if c.digests == nil {
+		c.digests = newDigestTracker(options)
+	}
 
 	// Use provided transport or create subprocess transport
 	if c.customTransport != nil {
 		c.transport = c.customTransport
 	} else {
-		var err error
-		c.transport, err = NewSubprocessCLITransport(actualPrompt, options, "")
+		subprocess, err := NewSubprocessCLITransport(actualPrompt, options, "")
 		if err != nil {
 			return err
 		}
+		subprocess.entrypoint = entrypointClient
+		c.transport = subprocess
 	}
+	compressor := newInputCompressor(options)
+	c.transport = withTranscript(withInputCompression(c.transport, compressor), options)
 
 	if err := c.transport.Connect(c.ctx); err != nil {
+		c.transport = nil // Nothing to close
 		return err
 	}
 
@@ -142,20 +264,37 @@ func (c *ClaudeSDKClient) ConnectWithPrompt(ctx context.Context, prompt interfac
 	sdkMcpServers := extractSdkMcpServers(c.options.McpServers)
 
 	// Determine buffer size
-	bufferSize := 100 // default
-	if options.MessageChannelBufferSize != nil && *options.MessageChannelBufferSize > 0 {
-		bufferSize = *options.MessageChannelBufferSize
-	}
+	bufferSize := bufferSizeOrDefault(options.MessageChannelBufferSize, defaultMessageChannelBufferSize)
 
-	// Create queryHandler - ClaudeSDKClient always uses streaming mode
+	// Create queryHandler; a string prompt starts a one-shot session
+	// without the control protocol
+	c.oneShot = isString
 	c.queryHandler = newQueryHandler(
 		c.transport,
-		true, // Always streaming mode
+		!isString,
 		options.CanUseTool,
 		options.Hooks,
 		sdkMcpServers,
 		bufferSize,
 	)
+	c.queryHandler.setTranscript(options)
+	c.queryHandler.setSDKInfo(options)
+	c.queryHandler.setFilters(options)
+	c.queryHandler.setMetrics(options)
+	c.queryHandler.setBuffering(options)
+	c.queryHandler.compressor = compressor
+	c.queryHandler.approver = options.Approver
+	c.queryHandler.callbackTimeout = newCallbackTimeouts(options)
+	c.queryHandler.audit = newAuditor(options)
+	c.queryHandler.telemetry = c.telemetry
+	c.queryHandler.queries = c.pending
+	c.errs = newErrorPipeline(options)
+	c.queryHandler.errs = c.errs
+	c.queryHandler.sink = eventSinkFor(options)
+	c.queryHandler.metadata.set(options.Metadata)
+	c.queryHandler.budget.setSession(ctx, c.budget)
+	context.AfterFunc(c.ctx, options.PolicyWatcher.subscribe(c.queryHandler.sink))
+	go c.digests.run(c.ctx)
 
 	// Start reading messages
 	if err := c.queryHandler.Start(c.ctx); err != nil {
@@ -167,6 +306,13 @@ func (c *ClaudeSDKClient) ConnectWithPrompt(ctx context.Context, prompt interfac
 		return err
 	}
 
+	// A budget downgrade applies to the conversation, not just one process
+	if model := c.budget.stepModel(); model != "" {
+		if err := c.queryHandler.SetModel(c.ctx, model); err != nil {
+			c.errs.warn(err)
+		}
+	}
+
 	// If we have an initial prompt stream, start streaming it
 	if prompt != nil {
 		if promptChan, ok := prompt.(<-chan map[string]interface{}); ok {
@@ -177,6 +323,29 @@ func (c *ClaudeSDKClient) ConnectWithPrompt(ctx context.Context, prompt interfac
 	return nil
 }
 
+// SessionMode is how a session talks to the CLI.
+type SessionMode string
+
+const (
+	// SessionModeInteractive sessions use the control protocol: queries can
+	// be sent, interrupted, and reconfigured while connected.
+	SessionModeInteractive SessionMode = "interactive"
+
+	// SessionModeOneShot sessions answer a single prompt given on the command
+	// line. Query, Interrupt, SetModel, SetPermissionMode, and other control
+	// methods return a *StreamingRequiredError.
+	SessionModeOneShot SessionMode = "one-shot"
+)
+
+// Mode returns SessionModeOneShot if the client was connected with a string
+// prompt, and SessionModeInteractive otherwise.
+func (c *ClaudeSDKClient) Mode() SessionMode {
+	if c.connection().oneShot {
+		return SessionModeOneShot
+	}
+	return SessionModeInteractive
+}
+
 // ReceiveMessages receives all messages from Claude.
 //
 // Returns a channel that yields messages until the client is disconnected
@@ -186,35 +355,103 @@ func (c *ClaudeSDKClient) ConnectWithPrompt(ctx context.Context, prompt interfac
 // readers on the underlying queryHandler channel. For multi-query workflows,
 // use Query() which properly manages message distribution.
 func (c *ClaudeSDKClient) ReceiveMessages(ctx context.Context) <-chan Message {
-	msgCh := make(chan Message, 10)
+	return c.receiveMessages(ctx, false)
+}
+
+// receiveMessages implements ReceiveMessages. With untilResult it stops
+// reading after a ResultMessage, leaving later messages for the next reader.
+func (c *ClaudeSDKClient) receiveMessages(ctx context.Context, untilResult bool) <-chan Message {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return c.receiveMessagesLocked(ctx, untilResult)
+}
+
+// receiveMessagesLocked implements receiveMessages; connMu must be held.
+func (c *ClaudeSDKClient) receiveMessagesLocked(ctx context.Context, untilResult bool) <-chan Message {
+	msgCh := make(chan Message, outputBufferSize(c.options))
+
+	// Capture the handler and parser so a concurrent session restart
+	// does not swap them out from under this reader.
+	handler := c.queryHandler
+	parser := c.parser
+	history := c.history
+	timeline := c.timeline
+	errs := c.errs
+	budget := c.budget
+	digests := c.digests
+	costs := c.costs
+	telemetry := c.telemetry
+	correlated := &c.correlated
+	connCtx := c.ctx
+	options := c.options
+	maxOutputTokens := options.MaxOutputTokens
 
 	go func() {
 		defer close(msgCh)
 
+		awaitingResult := false
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case err := <-c.queryHandler.ReceiveErrors():
+			case err := <-handler.ReceiveErrors():
 				if err != nil {
-					// Errors are logged but we continue to receive messages
+					// Terminal; available from Err() and Query's error channel
+					errs.fail(err)
 					return
 				}
-			case data, ok := <-c.queryHandler.ReceiveMessages():
+			case data, ok := <-handler.ReceiveMessages():
 				if !ok {
+					if err := <-handler.ReceiveErrors(); err != nil {
+						errs.fail(err)
+					} else if awaitingResult && connCtx.Err() == nil {
+						// The CLI went away mid-response, not closed by us
+						errs.fail(ErrStreamTruncated)
+					}
 					return
 				}
 
-				msg, err := parseMessage(data)
+				msg, err := parseOrSkip(parser, data, errs)
 				if err != nil {
+					errs.fail(err)
 					return
 				}
+				if msg == nil {
+					continue
+				}
+				_, isResult := msg.(*ResultMessage)
+				awaitingResult = !isResult
+				c.observeSessionID(msg)
+				annotateResult(msg, maxOutputTokens)
+				history.add(msg)
+				timeline.observe(msg, time.Now())
+				c.compactions.observe(msg, time.Now())
+				digests.observe(msg)
+				costs.observe(msg)
+				telemetry.observe(msg)
+				emitMessageEvents(handler.sink, msg, correlated.observe(msg))
+				step, budgetErr := budget.observe(connCtx, msg, errs)
+				if step != nil {
+					budget.downgrade(connCtx, handler, *step, options)
+				}
+				if _, ok := msg.(*ResultMessage); ok && budgetErr == nil {
+					// Fails this query only; see wrapReceiveResponseWithError
+					errs.warn(budget.queryErr())
+				}
 
 				select {
 				case msgCh <- msg:
 				case <-ctx.Done():
 					return
 				}
+				if budgetErr != nil {
+					// The ResultMessage that crossed the cap is still delivered
+					errs.fail(budgetErr)
+					return
+				}
+				if untilResult && isResult {
+					return
+				}
 			}
 		}
 	}()
@@ -260,19 +497,14 @@ func (c *ClaudeSDKClient) ReceiveMessages(ctx context.Context) <-chan Message {
 //	    // Process second response
 //	}
 func (c *ClaudeSDKClient) Query(ctx context.Context, prompt string) (<-chan Message, <-chan error) {
-	// Auto-generate session ID if not set
-	if c.currentSession == "" {
-		c.currentSession = "default"
-	}
-
 	// Send the query
-	err := c.QueryWithSession(ctx, prompt, c.currentSession)
+	err := c.QueryWithSession(ctx, prompt, c.conversation())
 	if err != nil {
 		// Return channels with error
 		msgCh := make(chan Message)
 		errCh := make(chan error, 1)
 		close(msgCh)
-		errCh <- err
+		errCh <- correlateError(err, CorrelationIDFromContext(ctx))
 		close(errCh)
 		return msgCh, errCh
 	}
@@ -282,22 +514,114 @@ func (c *ClaudeSDKClient) Query(ctx context.Context, prompt string) (<-chan Mess
 	return c.wrapReceiveResponseWithError(ctx)
 }
 
+// conversation returns the session ID Query sends prompts with, "default"
+// unless restored by ResumeFromState.
+func (c *ClaudeSDKClient) conversation() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conversationID == "" {
+		c.conversationID = "default"
+	}
+	return c.conversationID
+}
+
 // wrapReceiveResponseWithError wraps ReceiveResponse to also return an error channel
 func (c *ClaudeSDKClient) wrapReceiveResponseWithError(ctx context.Context) (<-chan Message, <-chan error) {
-	msgCh := make(chan Message, 10)
+	conn := c.connection()
+	msgCh := make(chan Message, outputBufferSize(conn.options))
 	errCh := make(chan error, 1)
 
+	correlationID := CorrelationIDFromContext(ctx)
+	auth := newAuthTracker(conn.options)
+	errs := conn.errs
+	previous, done := c.turns.next()
+
 	go func() {
 		defer close(msgCh)
 		defer close(errCh)
 
-		for msg := range c.ReceiveResponse(ctx) {
+		var queryErr error
+		var actions actionTracker
+		sawResult := false
+		// Read past a cancellation, so the rest of the turn can be discarded
+		// instead of reaching the next query
+		var responses <-chan Message
+		abandoned := false
+		defer func() {
+			if !abandoned {
+				done()
+			}
+		}()
+		canceled := func(turnOver bool) {
+			if !turnOver {
+				abandoned = true
+				go c.abandonTurn(previous, responses, done)
+			}
+			errCh <- correlateError(ctx.Err(), correlationID)
+		}
+		select {
+		case <-previous:
+		case <-ctx.Done():
+			canceled(false)
+			return
+		}
+		responses = c.ReceiveResponse(context.WithoutCancel(ctx))
+	receive:
+		for {
+			var msg Message
+			select {
+			case m, ok := <-responses:
+				if !ok {
+					break receive
+				}
+				msg = m
+			case <-ctx.Done():
+				canceled(false)
+				return
+			}
+			actions.observe(msg)
+			if result, ok := msg.(*ResultMessage); ok && finalSummaryWanted(conn.options, result, &actions) {
+				// A failed summary leaves the query's result intact
+				summary, err := c.finalSummary(ctx, &actions)
+				errs.warn(err)
+				result.Summary = summary
+			}
+			tagMessage(msg, correlationID)
 			select {
 			case msgCh <- msg:
 			case <-ctx.Done():
-				errCh <- ctx.Err()
+				_, isResult := msg.(*ResultMessage)
+				canceled(isResult)
 				return
 			}
+			if _, ok := msg.(*ResultMessage); ok {
+				sawResult = true
+				// A budget limit crossed by this query fails it
+				if err := c.budget.err(); err != nil && queryErr == nil {
+					queryErr = err
+				}
+				if err := c.budget.queryErr(); err != nil && queryErr == nil {
+					queryErr = err
+				}
+			}
+			if err := auth.observe(ctx, msg); err != nil && queryErr == nil {
+				queryErr = err
+			}
+			if err := errorFromResult(conn.options, msg); err != nil && queryErr == nil {
+				queryErr = err
+			}
+		}
+		if !sawResult && queryErr == nil {
+			// The stream ended early: report why
+			if queryErr = errs.err(); queryErr == nil {
+				queryErr = ctx.Err()
+			}
+			if queryErr == nil {
+				queryErr = ErrStreamTruncated
+			}
+		}
+		if queryErr != nil {
+			errCh <- correlateError(queryErr, correlationID)
 		}
 	}()
 
@@ -308,10 +632,44 @@ func (c *ClaudeSDKClient) wrapReceiveResponseWithError(ctx context.Context) (<-c
 //
 // For most cases, use Query() which auto-manages session IDs.
 // The prompt can be either a string or <-chan map[string]interface{}.
-func (c *ClaudeSDKClient) QueryWithSession(ctx context.Context, prompt interface{}, sessionID string) error {
-	if c.queryHandler == nil || c.transport == nil {
-		return NewCLIConnectionError("not connected. Call Connect() first", nil)
+// After Shutdown it returns ErrShuttingDown.
+func (c *ClaudeSDKClient) QueryWithSession(ctx context.Context, prompt interface{}, sessionID string) (err error) {
+	conn, err := c.connected()
+	if err != nil {
+		return err
 	}
+	if conn.oneShot {
+		return NewStreamingRequiredError("query", SessionModeOneShot)
+	}
+	// Counted until its result arrives; channel prompts count each message
+	if !c.pending.start() {
+		return ErrShuttingDown
+	}
+	defer func() {
+		if _, isString := prompt.(string); err != nil || !isString {
+			c.pending.finish()
+		}
+	}()
+	if err := c.budget.begin(ctx); err != nil {
+		return err
+	}
+	if tokens, ok := MaxOutputTokensFromContext(ctx); ok {
+		if current := conn.options.MaxOutputTokens; current == nil || *current != tokens {
+			if err := c.checkExclusive(); err != nil {
+				return err
+			}
+			if err := c.SetMaxOutputTokens(ctx, tokens); err != nil {
+				return err
+			}
+			if conn, err = c.connected(); err != nil {
+				return err
+			}
+		}
+	}
+	metadata := mergeMetadata(conn.options.Metadata, MetadataFromContext(ctx))
+	conn.handler.budget.setQuery(ctx)
+	c.timeline.markInput(time.Now())
+	c.telemetry.startQuery(ctx)
 
 	// Handle string prompts
 	if promptStr, ok := prompt.(string); ok {
@@ -325,7 +683,14 @@ func (c *ClaudeSDKClient) QueryWithSession(ctx context.Context, prompt interface
 			"session_id":         sessionID,
 		}
 		data, _ := json.Marshal(message)
-		return c.transport.Write(ctx, string(data)+"\n")
+		forget := conn.handler.metadata.begin(sessionID, metadata)
+		uncorrelate := c.correlated.begin(sessionID, CorrelationIDFromContext(ctx))
+		if err := conn.transport.Write(ctx, string(data)+"\n"); err != nil {
+			forget()
+			uncorrelate()
+			return err
+		}
+		return nil
 	}
 
 	// Handle channel prompts
@@ -335,8 +700,18 @@ func (c *ClaudeSDKClient) QueryWithSession(ctx context.Context, prompt interface
 				if msg["session_id"] == nil {
 					msg["session_id"] = sessionID
 				}
+				if !c.pending.start() {
+					return
+				}
 				data, _ := json.Marshal(msg)
-				c.transport.Write(ctx, string(data)+"\n")
+				id, _ := msg["session_id"].(string)
+				forget := conn.handler.metadata.begin(id, metadata)
+				uncorrelate := c.correlated.begin(id, CorrelationIDFromContext(ctx))
+				if conn.transport.Write(ctx, string(data)+"\n") != nil {
+					forget()
+					uncorrelate()
+					c.pending.finish()
+				}
 			}
 		}()
 		return nil
@@ -362,10 +737,11 @@ func (c *ClaudeSDKClient) QueryWithSession(ctx context.Context, prompt interface
 //	    log.Printf("Failed to interrupt: %v", err)
 //	}
 func (c *ClaudeSDKClient) Interrupt(ctx context.Context) error {
-	if c.queryHandler == nil {
-		return NewCLIConnectionError("not connected. Call Connect() first", nil)
+	conn, err := c.connected()
+	if err != nil {
+		return err
 	}
-	return c.queryHandler.Interrupt(ctx)
+	return conn.handler.Interrupt(ctx)
 }
 
 // SetPermissionMode changes permission mode during conversation.
@@ -375,20 +751,105 @@ func (c *ClaudeSDKClient) Interrupt(ctx context.Context) error {
 //   - "acceptEdits": Auto-accept file edits
 //   - "bypassPermissions": Allow all tools (use with caution)
 func (c *ClaudeSDKClient) SetPermissionMode(ctx context.Context, mode PermissionMode) error {
-	if c.queryHandler == nil {
-		return NewCLIConnectionError("not connected. Call Connect() first", nil)
+	conn, err := c.connected()
+	if err != nil {
+		return err
 	}
-	return c.queryHandler.SetPermissionMode(ctx, mode)
+	return conn.handler.SetPermissionMode(ctx, mode)
+}
+
+// AddHook registers callback for event while the client is running. matcher
+// selects tools by name like HookMatcher.Matcher: "" or "*" matches every
+// tool, "mcp__calc__*" is a glob, "/^mcp__/" a regular expression searched
+// for in the name, and anything else a regular expression matching the whole
+// name, such as "Write|Edit". Hooks added for the same event run in registration order, after
+// the hooks in ClaudeAgentOptions.Hooks; the first non-empty output is used.
+//
+// Requires ClaudeAgentOptions.DynamicHooks. Hooks may be added before Connect
+// and are kept across session restarts.
+//
+// Example:
+//
+//	handle, err := client.AddHook(claude.HookEventPreToolUse, "Bash", auditBash)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer client.RemoveHook(handle)
+func (c *ClaudeSDKClient) AddHook(event HookEvent, matcher string, callback HookCallback) (HookHandle, error) {
+	if !c.connection().options.DynamicHooks {
+		return HookHandle{}, fmt.Errorf("AddHook requires ClaudeAgentOptions.DynamicHooks")
+	}
+	return c.dynamicHooks.add(event, matcher, callback)
+}
+
+// RemoveHook unregisters a hook added with AddHook. It reports whether the
+// hook was registered. Invocations already in progress are not interrupted.
+func (c *ClaudeSDKClient) RemoveHook(handle HookHandle) bool {
+	return c.dynamicHooks.remove(handle)
+}
+
+// UpdatePermissions changes permissions while connected, e.g. to grant a
+// tool after the user approved it, without restarting with new options.
+//
+// setMode updates are applied immediately through the control protocol.
+// Rule updates (addRules, replaceRules, removeRules) take effect SDK-side at
+// once: matching deny rules, then allow rules, are checked before
+// ClaudeAgentOptions.CanUseTool, which is required for them. All updates other
+// than setMode are also sent to the CLI with the next allowed tool use, so
+// the CLI records them at their Destination; directory updates only take
+// effect then.
+//
+// Example:
+//
+//	allow := claude.PermissionBehaviorAllow
+//	npmTest := "npm test:*"
+//	err := client.UpdatePermissions(ctx, []claude.PermissionUpdate{{
+//	    Type:     claude.PermissionUpdateTypeAddRules,
+//	    Rules:    []claude.PermissionRuleValue{{ToolName: "Bash", RuleContent: &npmTest}},
+//	    Behavior: &allow,
+//	}})
+func (c *ClaudeSDKClient) UpdatePermissions(ctx context.Context, updates []PermissionUpdate) error {
+	conn, err := c.connected()
+	if err != nil {
+		return err
+	}
+
+	var modes []PermissionMode
+	var ruleUpdates []PermissionUpdate
+	for _, u := range updates {
+		if u.Type != PermissionUpdateTypeSetMode {
+			ruleUpdates = append(ruleUpdates, u)
+			continue
+		}
+		if u.Mode == nil {
+			return fmt.Errorf("setMode permission update requires Mode")
+		}
+		modes = append(modes, *u.Mode)
+	}
+	if len(ruleUpdates) > 0 && conn.options.CanUseTool == nil {
+		return fmt.Errorf("permission rule updates require ClaudeAgentOptions.CanUseTool")
+	}
+
+	if err := c.permissions.update(ruleUpdates); err != nil {
+		return err
+	}
+	for _, mode := range modes {
+		if err := conn.handler.SetPermissionMode(ctx, mode); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // SetModel changes the AI model during conversation.
 //
 // Examples: "claude-sonnet-4-5", "claude-opus-4-20250514"
 func (c *ClaudeSDKClient) SetModel(ctx context.Context, model string) error {
-	if c.queryHandler == nil {
-		return NewCLIConnectionError("not connected. Call Connect() first", nil)
+	conn, err := c.connected()
+	if err != nil {
+		return err
 	}
-	return c.queryHandler.SetModel(ctx, model)
+	return conn.handler.SetModel(ctx, model)
 }
 
 // GetServerInfo retrieves server initialization info including available commands.
@@ -398,42 +859,201 @@ func (c *ClaudeSDKClient) SetModel(ctx context.Context, model string) error {
 //   - Current and available output styles
 //   - Server capabilities
 func (c *ClaudeSDKClient) GetServerInfo() map[string]interface{} {
-	if c.queryHandler == nil {
+	handler := c.connection().handler
+	if handler == nil {
 		return nil
 	}
-	return c.queryHandler.GetInitResult()
+	return handler.GetInitResult()
 }
 
-// ReceiveResponse receives messages until and including a ResultMessage.
+// Err returns the error that ended the current connection's message stream,
+// or nil if the stream is healthy. Streams returned by ReceiveMessages and
+// ReceiveResponse close without an error value; use Err to find out why, and
+// StreamStatusOf to classify it. They also close when their context is done,
+// which is not recorded here.
+func (c *ClaudeSDKClient) Err() error {
+	return c.connection().errs.err()
+}
+
+// SessionID returns the CLI session ID observed in received messages.
 //
-// This is a convenience method over ReceiveMessages() for single-response workflows.
-// The channel will close after yielding a ResultMessage.
-func (c *ClaudeSDKClient) ReceiveResponse(ctx context.Context) <-chan Message {
-	msgCh := make(chan Message, 10)
+// The session ID becomes available once the CLI has sent its init system
+// message or a ResultMessage. Returns an empty string before then.
+func (c *ClaudeSDKClient) SessionID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sessionID
+}
 
-	go func() {
-		defer close(msgCh)
+// observeSessionID records the CLI session ID carried by msg, if any.
+func (c *ClaudeSDKClient) observeSessionID(msg Message) {
+	var sessionID string
+	switch m := msg.(type) {
+	case *ResultMessage:
+		sessionID = m.SessionID
+	case *StreamEvent:
+		sessionID = m.SessionID
+	case *SystemMessage:
+		sessionID, _ = m.Data["session_id"].(string)
+	}
+	if sessionID == "" {
+		return
+	}
 
-		// Create a cancellable context so we can stop ReceiveMessages when done
-		receiveCtx, cancel := context.WithCancel(ctx)
-		defer cancel()
+	c.mu.Lock()
+	c.sessionID = sessionID
+	c.mu.Unlock()
+}
 
-		for msg := range c.ReceiveMessages(receiveCtx) {
-			select {
-			case msgCh <- msg:
-			case <-ctx.Done():
-				return
+// SetSettingSources changes which filesystem settings the CLI loads, mid-session.
+//
+// The CLI reads setting sources only at startup, so this restarts the CLI
+// session with the new sources and resumes the current conversation (when a
+// session ID has been observed). Any in-flight response is abandoned; call it
+// between queries. Hooks, permission callbacks, and SDK MCP servers are
+// re-registered automatically.
+//
+// This lets an application start isolated (no filesystem settings) and opt
+// into project settings once the user grants access:
+//
+//	client := claude.NewClaudeSDKClient(nil) // No settings loaded
+//	client.Connect(ctx)
+//	// ... user approves access to project settings ...
+//	err := client.SetSettingSources(ctx, []claude.SettingSource{
+//	    claude.SettingSourceProject,
+//	})
+//
+// Custom transports must support Connect() after Close() for this to work.
+func (c *ClaudeSDKClient) SetSettingSources(ctx context.Context, sources []SettingSource) error {
+	if err := c.checkConnected(); err != nil {
+		return err
+	}
+
+	return c.restart(ctx, func(options *ClaudeAgentOptions) {
+		options.SettingSources = append([]SettingSource{}, sources...)
+	})
+}
+
+// resumeOptions returns a copy of the client options that resumes the current
+// session, if one has been observed.
+func (c *ClaudeSDKClient) resumeOptions() *ClaudeAgentOptions {
+	newOptions := *c.options
+	if sessionID := c.SessionID(); sessionID != "" {
+		newOptions.Resume = &sessionID
+		newOptions.ContinueConversation = false
+		newOptions.ForkSession = false
+	}
+	return &newOptions
+}
+
+// restart disconnects and reconnects with the resumeOptions changed by
+// update, keeping the original Connect() context as the parent of the new
+// session. It holds connMu throughout, so methods using the connection wait
+// for the new one instead of finding it half torn down.
+func (c *ClaudeSDKClient) restart(ctx context.Context, update func(*ClaudeAgentOptions)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if err := c.checkConnected(); err != nil {
+		// Disconnected, or a concurrent restart failed
+		return err
+	}
+	options := c.resumeOptions()
+	update(options)
+
+	parent := c.connectCtx
+	if parent == nil {
+		parent = ctx
+	}
+
+	if c.customTransport != nil {
+		if reconnect := CapabilitiesOf(c.customTransport).Reconnect; reconnect != nil && !*reconnect {
+			return NewCLIConnectionError("restarting the session requires a transport that supports reconnecting", nil)
+		}
+	}
+
+	if err := c.teardown(); err != nil {
+		return err
+	}
+	// Queries in flight ended with the old connection
+	c.pending.reset()
+
+	c.options = options
+	c.queryHandler = nil
+	c.transport = nil
+	if err := c.connect(parent, nil); err != nil {
+		// The session is gone; fail later calls instead of using it
+		c.teardown()
+		c.mu.Lock()
+		if c.state == clientConnected {
+			c.state = clientNew
+		}
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// GetOutputStyles returns the current and available output styles reported by
+// the CLI during initialization. Returns nil if not connected.
+func (c *ClaudeSDKClient) GetOutputStyles() *OutputStyleInfo {
+	initResult := c.GetServerInfo()
+	if initResult == nil {
+		return nil
+	}
+
+	info := &OutputStyleInfo{}
+	info.Current, _ = initResult["output_style"].(string)
+	if available, ok := initResult["available_output_styles"].([]interface{}); ok {
+		for _, style := range available {
+			if name, ok := style.(string); ok {
+				info.Available = append(info.Available, name)
 			}
+		}
+	}
+	return info
+}
 
-			// Stop after ResultMessage and cancel the context to stop ReceiveMessages
-			if _, ok := msg.(*ResultMessage); ok {
-				cancel()
-				return
+// SetOutputStyle changes the Claude Code output style mid-session.
+//
+// Output styles are applied at CLI startup, so like SetSettingSources this
+// restarts the CLI session with the new style and resumes the current
+// conversation. Call it between queries.
+//
+// If the CLI reported its available styles, unknown styles are rejected
+// without restarting.
+func (c *ClaudeSDKClient) SetOutputStyle(ctx context.Context, style string) error {
+	if err := c.checkConnected(); err != nil {
+		return err
+	}
+
+	if info := c.GetOutputStyles(); info != nil && len(info.Available) > 0 {
+		known := false
+		for _, name := range info.Available {
+			if name == style {
+				known = true
+				break
 			}
 		}
-	}()
+		if !known {
+			return fmt.Errorf("unknown output style %q (available: %s)", style, strings.Join(info.Available, ", "))
+		}
+	}
 
-	return msgCh
+	return c.restart(ctx, func(options *ClaudeAgentOptions) {
+		options.OutputStyle = &style
+	})
+}
+
+// ReceiveResponse receives messages until and including a ResultMessage.
+//
+// This is a convenience method over ReceiveMessages() for single-response workflows.
+// The channel will close after yielding a ResultMessage.
+func (c *ClaudeSDKClient) ReceiveResponse(ctx context.Context) <-chan Message {
+	return c.receiveMessages(ctx, true)
 }
 
 // Close closes the connection to Claude Code.
@@ -447,14 +1067,106 @@ func (c *ClaudeSDKClient) Close() error {
 // Disconnect closes the connection to Claude Code.
 //
 // Prefer using Close() for consistency with Python SDK.
+//
+// Disconnect is safe to call in any state and more than once; only the
+// first call closes the connection. A closed client cannot be connected
+// again: its methods return ErrClosed.
 func (c *ClaudeSDKClient) Disconnect() error {
+	c.mu.Lock()
+	previous := c.state
+	c.state = clientClosed
+	c.mu.Unlock()
+
+	if previous != clientConnected {
+		// Nothing to close, or ConnectWithPrompt tears down when it returns
+		return nil
+	}
+	c.telemetry.finish(ErrClosed)
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	return c.teardown()
+}
+
+// teardown stops the current connection, if any.
+func (c *ClaudeSDKClient) teardown() error {
 	if c.cancel != nil {
 		c.cancel()
 	}
-
 	if c.queryHandler != nil {
 		return c.queryHandler.Close()
 	}
-
+	if c.transport != nil {
+		return c.transport.Close()
+	}
 	return nil
 }
+
+// clientState is the lifecycle state of a ClaudeSDKClient.
+type clientState int
+
+const (
+	clientNew        clientState = iota // Not connected yet, or connecting failed
+	clientConnecting                    // ConnectWithPrompt in progress
+	clientConnected
+	clientClosed
+)
+
+// checkConnected returns ErrNotConnected or ErrClosed unless the client is
+// connected.
+func (c *ClaudeSDKClient) checkConnected() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch c.state {
+	case clientConnected:
+		return nil
+	case clientClosed:
+		return ErrClosed
+	default:
+		return ErrNotConnected
+	}
+}
+
+// clientConn is a snapshot of the fields connect sets, taken by connection
+// so that they can be used without racing a restart.
+type clientConn struct {
+	handler   *queryHandler
+	transport Transport
+	options   *ClaudeAgentOptions
+	ctx       context.Context
+	errs      *errorPipeline
+	oneShot   bool
+}
+
+// connection returns the current connection. Restarts replace it; callers
+// holding an old one get errors from its closed transport rather than nil
+// dereferences.
+func (c *ClaudeSDKClient) connection() clientConn {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return clientConn{
+		handler:   c.queryHandler,
+		transport: c.transport,
+		options:   c.options,
+		ctx:       c.ctx,
+		errs:      c.errs,
+		oneShot:   c.oneShot,
+	}
+}
+
+// connected is checkConnected returning the connection. It waits for a
+// restart in progress to finish.
+func (c *ClaudeSDKClient) connected() (clientConn, error) {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	if err := c.checkConnected(); err != nil {
+		return clientConn{}, err
+	}
+	return clientConn{
+		handler:   c.queryHandler,
+		transport: c.transport,
+		options:   c.options,
+		ctx:       c.ctx,
+		errs:      c.errs,
+		oneShot:   c.oneShot,
+	}, nil
+}
@@ -0,0 +1,157 @@
+// Command schemagen writes JSON Schemas for the SDK's message, option, hook,
+// and event types, generated from the Go types so they never drift from the
+// wire format the SDK expects.
+//
+// Usage:
+//
+//	go run ./cmd/schemagen -out schemas
+//
+// Each type is written to {out}/{TypeName}.schema.json. With -check, nothing
+// is written and the command fails if any file in -out is missing or stale,
+// which is useful in CI.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/schema"
+)
+
+// types are the documented types, in output order.
+var types = []interface{}{
+	// Messages
+	claude.UserMessage{},
+	claude.AssistantMessage{},
+	claude.SystemMessage{},
+	claude.ResultMessage{},
+	claude.StreamEvent{},
+
+	// Content blocks
+	claude.TextBlock{},
+	claude.ThinkingBlock{},
+	claude.ToolUseBlock{},
+	claude.ToolResultBlock{},
+	claude.ImageBlock{},
+
+	// Built-in tool inputs
+	claude.BashInput{},
+	claude.ReadInput{},
+	claude.WriteInput{},
+	claude.EditInput{},
+	claude.GlobInput{},
+	claude.GrepInput{},
+
+	// Options
+	claude.ClaudeAgentOptions{},
+	claude.AgentDefinition{},
+	claude.PermissionUpdate{},
+
+	// Hooks and permissions
+	claude.PreToolUseHookInput{},
+	claude.PostToolUseHookInput{},
+	claude.UserPromptSubmitHookInput{},
+	claude.StopHookInput{},
+	claude.SubagentStopHookInput{},
+	claude.PreCompactHookInput{},
+	claude.SessionStartHookInput{},
+	claude.SessionEndHookInput{},
+	claude.NotificationHookInput{},
+	claude.PermissionRequestHookInput{},
+	claude.HookJSONOutput{},
+	claude.PermissionResultAllow{},
+	claude.PermissionResultDeny{},
+
+	// Events and transcripts
+	claude.Event{},
+	claude.TranscriptRecord{},
+}
+
+// generator returns a Generator that knows the SDK's enums and interfaces.
+func generator() *schema.Generator {
+	return &schema.Generator{
+		Enums: map[reflect.Type][]interface{}{
+			reflect.TypeOf(claude.PermissionMode("")): {
+				claude.PermissionModeDefault, claude.PermissionModeAcceptEdits,
+				claude.PermissionModePlan, claude.PermissionModeBypassPermissions,
+			},
+			reflect.TypeOf(claude.SettingSource("")): {
+				claude.SettingSourceUser, claude.SettingSourceProject, claude.SettingSourceLocal,
+			},
+			reflect.TypeOf(claude.PermissionBehavior("")): {
+				claude.PermissionBehaviorAllow, claude.PermissionBehaviorDeny, claude.PermissionBehaviorAsk,
+			},
+			reflect.TypeOf(claude.PermissionUpdateDestination("")): {
+				claude.PermissionUpdateDestinationUserSettings, claude.PermissionUpdateDestinationProjectSettings,
+				claude.PermissionUpdateDestinationLocalSettings, claude.PermissionUpdateDestinationSession,
+			},
+			reflect.TypeOf(claude.PermissionUpdateType("")): {
+				claude.PermissionUpdateTypeAddRules, claude.PermissionUpdateTypeReplaceRules,
+				claude.PermissionUpdateTypeRemoveRules, claude.PermissionUpdateTypeSetMode,
+				claude.PermissionUpdateTypeAddDirectories, claude.PermissionUpdateTypeRemoveDirectories,
+			},
+			reflect.TypeOf(claude.EventType("")): {
+				claude.EventTypeMessage, claude.EventTypeToolUse, claude.EventTypePermissionDecision,
+				claude.EventTypeResult, claude.EventTypeBudgetDowngrade,
+			},
+		},
+		Implementations: map[reflect.Type][]reflect.Type{
+			reflect.TypeOf((*claude.ContentBlock)(nil)).Elem(): {
+				reflect.TypeOf(claude.TextBlock{}), reflect.TypeOf(claude.ThinkingBlock{}),
+				reflect.TypeOf(claude.ToolUseBlock{}), reflect.TypeOf(claude.ToolResultBlock{}),
+				reflect.TypeOf(claude.ImageBlock{}),
+			},
+			reflect.TypeOf((*claude.McpServerConfig)(nil)).Elem(): {
+				reflect.TypeOf(claude.McpStdioServerConfig{}), reflect.TypeOf(claude.McpSSEServerConfig{}),
+				reflect.TypeOf(claude.McpHTTPServerConfig{}), reflect.TypeOf(claude.McpSdkServerConfig{}),
+			},
+		},
+	}
+}
+
+func main() {
+	out := flag.String("out", "schemas", "output directory")
+	check := flag.Bool("check", false, "verify existing files instead of writing")
+	flag.Parse()
+
+	if !*check {
+		if err := os.MkdirAll(*out, 0o755); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	gen := generator()
+	stale := 0
+	for _, v := range types {
+		t := reflect.TypeOf(v)
+		data, err := json.MarshalIndent(gen.Schema(t), "", "  ")
+		if err != nil {
+			log.Fatalf("%s: %v", t.Name(), err)
+		}
+		data = append(data, '\n')
+		path := filepath.Join(*out, t.Name()+".schema.json")
+
+		if *check {
+			existing, err := os.ReadFile(path)
+			if err != nil || !bytes.Equal(existing, data) {
+				fmt.Fprintf(os.Stderr, "stale: %s\n", path)
+				stale++
+			}
+			continue
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if stale > 0 {
+		log.Fatalf("%d schema(s) out of date; run go run ./cmd/schemagen -out %s", stale, *out)
+	}
+}
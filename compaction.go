@@ -0,0 +1,240 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// systemSubtypeCompactBoundary is the SystemMessage subtype the CLI sends
+// when it compacts the conversation.
+const systemSubtypeCompactBoundary = "compact_boundary"
+
+// CLI settings for automatic compaction.
+const (
+	disableAutoCompactEnv   = "DISABLE_AUTO_COMPACT"
+	autoCompactThresholdEnv = "CLAUDE_AUTOCOMPACT_PCT_OVERRIDE"
+)
+
+// AutoCompactConfig controls the CLI's automatic compaction, which
+// summarizes the conversation when the context window fills up.
+type AutoCompactConfig struct {
+	// Disabled turns automatic compaction off; the conversation is then
+	// only compacted by Compact, and queries fail once the context is full
+	Disabled bool
+
+	// ThresholdPercent is how full the context window, in percent, gets
+	// before compacting, e.g. 60 to compact early and keep turns fast
+	// (default: the CLI's threshold)
+	ThresholdPercent int
+}
+
+// validateAutoCompact rejects thresholds that are not percentages.
+func validateAutoCompact(options *ClaudeAgentOptions) error {
+	if options.AutoCompact == nil {
+		return nil
+	}
+	if percent := options.AutoCompact.ThresholdPercent; percent < 0 || percent > 100 {
+		return fmt.Errorf("auto-compact threshold must be a percentage, got %d", percent)
+	}
+	return nil
+}
+
+// autoCompactEnvironment returns the CLI variables for config.
+func autoCompactEnvironment(config *AutoCompactConfig) map[string]string {
+	env := make(map[string]string)
+	if config == nil {
+		return env
+	}
+	if config.Disabled {
+		env[disableAutoCompactEnv] = "1"
+	}
+	if config.ThresholdPercent > 0 {
+		env[autoCompactThresholdEnv] = strconv.Itoa(config.ThresholdPercent)
+	}
+	return env
+}
+
+// ErrNotCompacted is returned by Compact when the CLI answered without
+// compacting, e.g. because the conversation was empty.
+var ErrNotCompacted = errors.New("conversation was not compacted")
+
+// PreCompactHook adapts a callback taking the typed PreCompact input to a
+// HookCallback, e.g. to save the full conversation before it is summarized.
+//
+// Example:
+//
+//	options.Hooks = map[claude.HookEvent][]claude.HookMatcher{
+//	    claude.HookEventPreCompact: {{Hooks: []claude.HookCallback{
+//	        claude.PreCompactHook(func(ctx context.Context, input *claude.PreCompactHookInput, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+//	            return claude.HookJSONOutput{}, archive(input.TranscriptPath, input.Trigger)
+//	        }),
+//	    }}},
+//	}
+func PreCompactHook(hook func(ctx context.Context, input *PreCompactHookInput, hookCtx HookContext) (HookJSONOutput, error)) HookCallback {
+	return func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
+		var typed PreCompactHookInput
+		if err := DecodeHookInput(input, &typed); err != nil {
+			return HookJSONOutput{}, err
+		}
+		return hook(ctx, &typed, hookCtx)
+	}
+}
+
+// Compaction is one compaction of the conversation by the CLI, which
+// replaces the messages so far with a summary to free context.
+type Compaction struct {
+	Time      time.Time // When the SDK received the compact_boundary message
+	Trigger   string    // "manual" (/compact) or "auto" (context full)
+	PreTokens int       // Context tokens before compaction, if reported
+	// Summary is the text that replaced the compacted messages, if the CLI
+	// sent it as the user message following the boundary
+	Summary string
+}
+
+// compactionLog records compactions seen by a client.
+type compactionLog struct {
+	mu          sync.Mutex
+	compactions []Compaction
+	awaiting    bool // The last compaction's summary may still arrive
+}
+
+// observe records msg if it is a compaction boundary or its summary.
+func (l *compactionLog) observe(msg Message, at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch m := msg.(type) {
+	case *SystemMessage:
+		if m.Subtype != systemSubtypeCompactBoundary {
+			return
+		}
+		compaction := Compaction{Time: at}
+		metadata, _ := m.Data["compact_metadata"].(map[string]interface{})
+		compaction.Trigger, _ = metadata["trigger"].(string)
+		if tokens, ok := metadata["pre_tokens"].(float64); ok {
+			compaction.PreTokens = int(tokens)
+		}
+		l.compactions = append(l.compactions, compaction)
+		l.awaiting = true
+	case *UserMessage:
+		if !l.awaiting || m.ParentToolUseID != nil {
+			return
+		}
+		l.awaiting = false
+		l.compactions[len(l.compactions)-1].Summary = userMessageText(m)
+	case *AssistantMessage, *ResultMessage:
+		l.awaiting = false
+	}
+}
+
+// snapshot returns a copy of the recorded compactions.
+func (l *compactionLog) snapshot() []Compaction {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Compaction(nil), l.compactions...)
+}
+
+// isCompactBoundary reports whether msg marks a compaction.
+func isCompactBoundary(msg Message) bool {
+	m, ok := msg.(*SystemMessage)
+	return ok && m.Subtype == systemSubtypeCompactBoundary
+}
+
+// userMessageText returns the text of a user message, or "" if it carries
+// tool results.
+func userMessageText(m *UserMessage) string {
+	switch content := m.Content.(type) {
+	case string:
+		return content
+	case []ContentBlock:
+		var text strings.Builder
+		for _, block := range content {
+			switch block := block.(type) {
+			case TextBlock:
+				text.WriteString(block.Text)
+			case ToolResultBlock:
+				return ""
+			}
+		}
+		return text.String()
+	}
+	return ""
+}
+
+// Compactions returns the compactions of this client's conversation, oldest
+// first. After a compaction, Claude no longer sees the earlier messages,
+// only the compaction's Summary; entries received before it are marked
+// HistoryEntry.Compacted.
+func (c *ClaudeSDKClient) Compactions() []Compaction {
+	return c.compactions.snapshot()
+}
+
+// Compact compacts the conversation now, replacing the messages so far with
+// a summary to free context, and returns the new compaction. instructions
+// tell the summary what to keep, e.g. "keep the list of failing tests"; ""
+// uses the CLI's default summary. PreCompact hooks see the instructions
+// with trigger "manual".
+//
+// The CLI exposes compaction as its /compact command, which Compact sends as
+// a query and waits for; call it between queries. It returns
+// ErrNotCompacted if the CLI finished without compacting.
+//
+// Example: compact once the context is mostly full
+//
+//	if usage.InputTokens+usage.CacheReadInputTokens > 150_000 {
+//	    compaction, err := client.Compact(ctx, "Keep the open TODOs and file paths")
+//	    if err != nil {
+//	        return err
+//	    }
+//	    log.Printf("compacted %d tokens", compaction.PreTokens)
+//	}
+func (c *ClaudeSDKClient) Compact(ctx context.Context, instructions string) (*Compaction, error) {
+	before := len(c.compactions.snapshot())
+	command := strings.TrimSpace("/compact " + instructions)
+	msgCh, errCh := c.Query(ctx, command)
+	for range msgCh {
+		// Compactions are recorded as the messages are received
+	}
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	compactions := c.compactions.snapshot()
+	if len(compactions) == before {
+		return nil, ErrNotCompacted
+	}
+	return &compactions[len(compactions)-1], nil
+}
+
+// CompactionSummary returns the summary that replaced the conversation at
+// its latest compaction. ok is false if there was no compaction or the CLI
+// did not send the summary.
+func (c *ClaudeSDKClient) CompactionSummary() (summary string, ok bool) {
+	compactions := c.compactions.snapshot()
+	if len(compactions) == 0 || compactions[len(compactions)-1].Summary == "" {
+		return "", false
+	}
+	return compactions[len(compactions)-1].Summary, true
+}
+
+// ContextHistory returns the retained history since the latest compaction,
+// which together with CompactionSummary is what Claude sees of the
+// conversation. It is History if there was no compaction, and nil if
+// history is disabled.
+func (c *ClaudeSDKClient) ContextHistory() []Message {
+	entries := c.history.snapshot()
+	if entries == nil {
+		return nil
+	}
+	messages := []Message{}
+	for _, entry := range entries {
+		if !entry.Compacted {
+			messages = append(messages, entry.Message)
+		}
+	}
+	return messages
+}
@@ -0,0 +1,138 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ControlClient is the low-level control protocol client that Query and
+// ClaudeSDKClient are built on. It is intended for framework authors who want
+// their own high-level API.
+//
+// A ControlClient speaks the stream-json control protocol over a connected
+// Transport. It answers the CLI's control requests itself — CanUseTool,
+// hook callbacks, and SDK MCP server messages, all registered from the
+// options passed to NewControlClient — and routes every other message,
+// unparsed, to Messages. It does not build CLI flags: create the transport
+// with the same options (in streaming mode) so the CLI sends those requests.
+//
+// Typical use:
+//
+//	transport, _ := claude.NewSubprocessCLITransport(promptCh, options, "")
+//	if err := transport.Connect(ctx); err != nil { ... }
+//	cc := claude.NewControlClient(transport, options)
+//	if err := cc.Start(ctx); err != nil { ... }
+//	if _, err := cc.Initialize(ctx); err != nil { ... }
+//	go cc.StreamInput(ctx, promptCh)
+//	for raw := range cc.Messages() {
+//	    msg, err := claude.ParseMessage(raw)
+//	    ...
+//	}
+//	defer cc.Close()
+type ControlClient struct {
+	q *queryHandler
+}
+
+// NewControlClient creates a ControlClient on transport, which must already
+// be connected. Hooks, CanUseTool, SDK MCP servers, Metadata, Transcript,
+// ToolResultFilter, EventSink, OnError, MessageChannelBufferSize, and
+// AdaptiveBuffering are taken from options; all other options only affect how
+// the transport starts the CLI.
+func NewControlClient(transport Transport, options *ClaudeAgentOptions) *ControlClient {
+	if options == nil {
+		options = &ClaudeAgentOptions{}
+	}
+	q := newQueryHandler(
+		transport,
+		true,
+		options.CanUseTool,
+		options.Hooks,
+		extractSdkMcpServers(options.McpServers),
+		bufferSizeOrDefault(options.MessageChannelBufferSize, defaultMessageChannelBufferSize),
+	)
+	q.setTranscript(options)
+	q.setSDKInfo(options)
+	q.setFilters(options)
+	q.setMetrics(options)
+	q.setBuffering(options)
+	q.errs = newErrorPipeline(options)
+	q.sink = eventSinkFor(options)
+	q.metadata.set(options.Metadata)
+	return &ControlClient{q: q}
+}
+
+// Start begins reading from the transport and routing messages. It must be
+// called once, before any other method.
+func (c *ControlClient) Start(ctx context.Context) error {
+	return c.q.Start(ctx)
+}
+
+// Initialize performs the initialize handshake, registering hooks with the
+// CLI, and returns the CLI's response.
+func (c *ControlClient) Initialize(ctx context.Context) (map[string]interface{}, error) {
+	return c.q.Initialize(ctx)
+}
+
+// InitResult returns the response to Initialize, or nil before it completes.
+func (c *ControlClient) InitResult() map[string]interface{} {
+	return c.q.GetInitResult()
+}
+
+// SendControlRequest sends a control request and waits for its response.
+// request holds the inner request, e.g. {"subtype": "interrupt"}, or one of
+// the typed requests converted with EncodeControlRequest; the request ID and
+// envelope are added.
+func (c *ControlClient) SendControlRequest(ctx context.Context, request map[string]interface{}) (map[string]interface{}, error) {
+	if _, ok := request["subtype"].(string); !ok {
+		return nil, fmt.Errorf("control request requires a subtype")
+	}
+	return c.q.sendControlRequest(ctx, request)
+}
+
+// Interrupt asks the CLI to stop the current turn.
+func (c *ControlClient) Interrupt(ctx context.Context) error {
+	return c.q.Interrupt(ctx)
+}
+
+// SetPermissionMode changes the permission mode.
+func (c *ControlClient) SetPermissionMode(ctx context.Context, mode PermissionMode) error {
+	return c.q.SetPermissionMode(ctx, mode)
+}
+
+// SetModel changes the model.
+func (c *ControlClient) SetModel(ctx context.Context, model string) error {
+	return c.q.SetModel(ctx, model)
+}
+
+// SendMessage writes one input message, e.g. a user message, to the CLI.
+func (c *ControlClient) SendMessage(ctx context.Context, message map[string]interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return c.q.transport.Write(ctx, string(data)+"\n")
+}
+
+// StreamInput writes messages from stream until it is closed, then ends the
+// transport's input. It blocks; run it in a goroutine.
+func (c *ControlClient) StreamInput(ctx context.Context, stream <-chan map[string]interface{}) error {
+	return c.q.StreamInput(ctx, stream)
+}
+
+// Messages returns the raw messages that are not part of the control
+// protocol. Use ParseMessage to convert them. The channel is closed when the
+// transport's stream ends.
+func (c *ControlClient) Messages() <-chan map[string]interface{} {
+	return c.q.ReceiveMessages()
+}
+
+// Errors returns the channel that receives the error that ended the stream.
+func (c *ControlClient) Errors() <-chan error {
+	return c.q.ReceiveErrors()
+}
+
+// Close stops routing, shuts down SDK MCP executors, and closes the transport.
+func (c *ControlClient) Close() error {
+	return c.q.Close()
+}
@@ -0,0 +1,204 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Message types of the control protocol. Control messages share the
+// stream-json connection with SDK messages and are told apart by "type".
+const (
+	MessageTypeControlRequest       = "control_request"
+	MessageTypeControlResponse      = "control_response"
+	MessageTypeControlCancelRequest = "control_cancel_request"
+)
+
+// ControlSubtype identifies the kind of a control request, or the outcome
+// of a control response.
+type ControlSubtype string
+
+const (
+	// Requests the SDK sends to the CLI
+	ControlSubtypeInitialize        ControlSubtype = "initialize"
+	ControlSubtypeInterrupt         ControlSubtype = "interrupt"
+	ControlSubtypeSetModel          ControlSubtype = "set_model"
+	ControlSubtypeSetPermissionMode ControlSubtype = "set_permission_mode"
+
+	// Requests the CLI sends to the SDK
+	ControlSubtypeCanUseTool   ControlSubtype = "can_use_tool"
+	ControlSubtypeHookCallback ControlSubtype = "hook_callback"
+	ControlSubtypeMcpMessage   ControlSubtype = "mcp_message"
+
+	// Response outcomes
+	ControlSubtypeSuccess ControlSubtype = "success"
+	ControlSubtypeError   ControlSubtype = "error"
+)
+
+// ControlRequest is the envelope of a control request, sent by either side.
+type ControlRequest struct {
+	Type      string                 `json:"type"` // MessageTypeControlRequest
+	RequestID string                 `json:"request_id"`
+	Request   map[string]interface{} `json:"request"` // Decode with DecodeControlRequest
+}
+
+// Subtype returns the subtype of the inner request.
+func (r ControlRequest) Subtype() ControlSubtype {
+	subtype, _ := r.Request["subtype"].(string)
+	return ControlSubtype(subtype)
+}
+
+// ControlResponse is the envelope of a control response.
+type ControlResponse struct {
+	Type     string              `json:"type"` // MessageTypeControlResponse
+	Response ControlResponseBody `json:"response"`
+}
+
+// ControlResponseBody is the inner response: the result of a successful
+// request, or why it failed.
+type ControlResponseBody struct {
+	Subtype   ControlSubtype         `json:"subtype"` // ControlSubtypeSuccess or ControlSubtypeError
+	RequestID string                 `json:"request_id"`
+	Response  map[string]interface{} `json:"response,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// ControlCancelRequest asks the other side to abandon a control request it
+// is handling.
+type ControlCancelRequest struct {
+	Type      string `json:"type"` // MessageTypeControlCancelRequest
+	RequestID string `json:"request_id"`
+}
+
+// InitializeRequest registers the SDK's hooks with the CLI and describes
+// the SDK.
+type InitializeRequest struct {
+	Subtype ControlSubtype         `json:"subtype"`         // ControlSubtypeInitialize
+	Hooks   map[string]interface{} `json:"hooks,omitempty"` // Matchers with hookCallbackIds, by hook event
+	SDK     map[string]interface{} `json:"sdk,omitempty"`
+}
+
+// InterruptRequest asks the CLI to stop the current turn.
+type InterruptRequest struct {
+	Subtype ControlSubtype `json:"subtype"` // ControlSubtypeInterrupt
+}
+
+// SetModelRequest changes the model for the rest of the session.
+type SetModelRequest struct {
+	Subtype ControlSubtype `json:"subtype"` // ControlSubtypeSetModel
+	Model   string         `json:"model"`
+}
+
+// SetPermissionModeRequest changes the permission mode.
+type SetPermissionModeRequest struct {
+	Subtype ControlSubtype `json:"subtype"` // ControlSubtypeSetPermissionMode
+	Mode    PermissionMode `json:"mode"`
+}
+
+// CanUseToolRequest asks the SDK whether a tool call may run.
+type CanUseToolRequest struct {
+	Subtype               ControlSubtype         `json:"subtype"` // ControlSubtypeCanUseTool
+	ToolName              string                 `json:"tool_name"`
+	Input                 map[string]interface{} `json:"input"`
+	PermissionSuggestions []PermissionUpdate     `json:"permission_suggestions,omitempty"`
+	BlockedPath           *string                `json:"blocked_path,omitempty"`
+}
+
+// HookCallbackRequest asks the SDK to run a hook registered by
+// InitializeRequest.
+type HookCallbackRequest struct {
+	Subtype    ControlSubtype         `json:"subtype"` // ControlSubtypeHookCallback
+	CallbackID string                 `json:"callback_id"`
+	Input      map[string]interface{} `json:"input"`
+	ToolUseID  *string                `json:"tool_use_id,omitempty"`
+}
+
+// McpMessageRequest carries a JSON-RPC message for an SDK MCP server.
+type McpMessageRequest struct {
+	Subtype    ControlSubtype `json:"subtype"` // ControlSubtypeMcpMessage
+	ServerName string         `json:"server_name"`
+	// Message is a JSON-RPC message object, or a batch of them
+	// ([]interface{}).
+	Message interface{} `json:"message"`
+}
+
+// EncodeControlRequest converts one of the typed requests above to the
+// inner request map of a ControlRequest, e.g. for
+// ControlClient.SendControlRequest.
+func EncodeControlRequest(request interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("control request must be a JSON object: %w", err)
+	}
+	if subtype, _ := m["subtype"].(string); subtype == "" {
+		return nil, fmt.Errorf("control request requires a subtype")
+	}
+	return m, nil
+}
+
+// DecodeControlRequest converts the inner request of a ControlRequest to
+// the typed request for its subtype, e.g. *CanUseToolRequest. Fields of the
+// wrong type are left zero, and permission suggestions the SDK does not
+// understand are skipped, so requests from newer CLIs still decode.
+func DecodeControlRequest(request map[string]interface{}) (interface{}, error) {
+	subtype, _ := request["subtype"].(string)
+	switch s := ControlSubtype(subtype); s {
+	case ControlSubtypeInitialize:
+		r := &InitializeRequest{Subtype: s}
+		r.Hooks, _ = request["hooks"].(map[string]interface{})
+		r.SDK, _ = request["sdk"].(map[string]interface{})
+		return r, nil
+	case ControlSubtypeInterrupt:
+		return &InterruptRequest{Subtype: s}, nil
+	case ControlSubtypeSetModel:
+		r := &SetModelRequest{Subtype: s}
+		r.Model, _ = request["model"].(string)
+		return r, nil
+	case ControlSubtypeSetPermissionMode:
+		r := &SetPermissionModeRequest{Subtype: s}
+		mode, _ := request["mode"].(string)
+		r.Mode = PermissionMode(mode)
+		return r, nil
+	case ControlSubtypeCanUseTool:
+		return decodeCanUseTool(request), nil
+	case ControlSubtypeHookCallback:
+		r := &HookCallbackRequest{Subtype: s}
+		r.CallbackID, _ = request["callback_id"].(string)
+		r.Input, _ = request["input"].(map[string]interface{})
+		if id, ok := request["tool_use_id"].(string); ok {
+			r.ToolUseID = &id
+		}
+		return r, nil
+	case ControlSubtypeMcpMessage:
+		r := &McpMessageRequest{Subtype: s, Message: request["message"]}
+		r.ServerName, _ = request["server_name"].(string)
+		return r, nil
+	}
+	return nil, fmt.Errorf("unsupported control request subtype: %s", subtype)
+}
+
+// decodeCanUseTool decodes a can_use_tool request.
+func decodeCanUseTool(request map[string]interface{}) *CanUseToolRequest {
+	r := &CanUseToolRequest{Subtype: ControlSubtypeCanUseTool}
+	r.ToolName, _ = request["tool_name"].(string)
+	r.Input, _ = request["input"].(map[string]interface{})
+	if path, ok := request["blocked_path"].(string); ok {
+		r.BlockedPath = &path
+	}
+	suggestions, _ := request["permission_suggestions"].([]interface{})
+	for _, s := range suggestions {
+		sMap, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		update, err := parsePermissionUpdate(sMap)
+		if err != nil {
+			continue
+		}
+		r.PermissionSuggestions = append(r.PermissionSuggestions, update)
+	}
+	return r
+}
@@ -0,0 +1,139 @@
+package claude
+
+import (
+	"context"
+	"sync"
+)
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a context carrying a caller-supplied correlation ID.
+//
+// When the returned context is passed to Query(), QueryStream(), or
+// ClaudeSDKClient.Query(), the ID is copied onto the CorrelationID field of
+// every message delivered for that query, and every error delivered on the
+// error channel is wrapped in a *CorrelatedError. This makes it possible to
+// stitch SDK output to application logs during incident investigation.
+//
+// Example:
+//
+//	ctx = claude.WithCorrelationID(ctx, requestID)
+//	msgCh, errCh := client.Query(ctx, prompt)
+//	for msg := range msgCh {
+//	    if result, ok := msg.(*claude.ResultMessage); ok {
+//	        log.Printf("[%s] done, session=%s uuid=%s", result.CorrelationID, result.SessionID, result.UUID)
+//	    }
+//	}
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation ID set by WithCorrelationID, if any.
+func CorrelationIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// tagMessage sets the correlation ID on msg. No-op for an empty ID.
+func tagMessage(msg Message, correlationID string) {
+	if correlationID == "" {
+		return
+	}
+
+	switch m := msg.(type) {
+	case *UserMessage:
+		m.CorrelationID = correlationID
+	case *AssistantMessage:
+		m.CorrelationID = correlationID
+	case *SystemMessage:
+		m.CorrelationID = correlationID
+	case *ResultMessage:
+		m.CorrelationID = correlationID
+	case *StreamEvent:
+		m.CorrelationID = correlationID
+	}
+}
+
+// correlateError wraps err with the correlation ID. Returns err unchanged for
+// a nil error or an empty ID.
+func correlateError(err error, correlationID string) error {
+	if err == nil || correlationID == "" {
+		return err
+	}
+	return &CorrelatedError{CorrelationID: correlationID, Err: err}
+}
+
+// queryCorrelations holds the correlation IDs of a client's queries awaiting
+// their ResultMessage, so the events emitted as messages are read carry the
+// ID of the query they answer. Like turnMetadata, a message belongs to the
+// oldest query with its session ID, or else to the oldest query.
+type queryCorrelations struct {
+	mu    sync.Mutex
+	turns []*correlatedTurn // Oldest first
+}
+
+// correlatedTurn is the correlation ID of one query in flight.
+type correlatedTurn struct {
+	sessionID     string
+	correlationID string
+}
+
+// begin records correlationID for a query sent for sessionID, until its
+// result. The returned func forgets it, for a query that could not be sent.
+func (q *queryCorrelations) begin(sessionID, correlationID string) func() {
+	turn := &correlatedTurn{sessionID: sessionID, correlationID: correlationID}
+	q.mu.Lock()
+	q.turns = append(q.turns, turn)
+	q.mu.Unlock()
+	return func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.remove(turn)
+	}
+}
+
+// observe returns the correlation ID of the query msg answers, forgetting
+// the query once msg is its ResultMessage.
+func (q *queryCorrelations) observe(msg Message) string {
+	sessionID, _, _ := messageIdentity(msg)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var turn *correlatedTurn
+	for _, queued := range q.turns {
+		if sessionID != "" && queued.sessionID == sessionID {
+			turn = queued
+			break
+		}
+	}
+	if turn == nil && len(q.turns) > 0 {
+		turn = q.turns[0]
+	}
+	if turn == nil {
+		return ""
+	}
+	if _, ok := msg.(*ResultMessage); ok {
+		q.remove(turn)
+	}
+	return turn.correlationID
+}
+
+// reset forgets every query, when a restart tears down the connection they
+// were sent on.
+func (q *queryCorrelations) reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.turns = nil
+}
+
+// remove drops turn. Callers hold mu.
+func (q *queryCorrelations) remove(turn *correlatedTurn) {
+	for i, queued := range q.turns {
+		if queued == turn {
+			q.turns = append(q.turns[:i], q.turns[i+1:]...)
+			return
+		}
+	}
+}
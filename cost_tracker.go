@@ -0,0 +1,155 @@
+package claude
+
+import (
+	"sync"
+)
+
+// CostAlert is a soft cost threshold for a ClaudeSDKClient. Unlike
+// MaxBudgetUSD, which the CLI enforces per query, and BudgetLimits, which
+// stop queries, crossing it only calls OnCross.
+type CostAlert struct {
+	ThresholdUSD float64
+
+	// OnCross is called once, from its own goroutine, with the summary of
+	// the result that brought the cumulative cost to ThresholdUSD or more
+	OnCross func(CostSummary)
+}
+
+// TokenTotals are token counts summed over queries.
+type TokenTotals struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// add adds a query's usage.
+func (t *TokenTotals) add(u *Usage) {
+	t.InputTokens += u.InputTokens
+	t.OutputTokens += u.OutputTokens
+	t.CacheCreationInputTokens += u.CacheCreationInputTokens
+	t.CacheReadInputTokens += u.CacheReadInputTokens
+}
+
+// SessionCost is one CLI session's share of a CostSummary.
+type SessionCost struct {
+	CostUSD float64     `json:"cost_usd"`
+	Queries int         `json:"queries"`
+	Tokens  TokenTotals `json:"tokens"`
+}
+
+// CostSummary is the spend of all queries on a client so far.
+type CostSummary struct {
+	TotalUSD float64     `json:"total_usd"`
+	Queries  int         `json:"queries"` // ResultMessages seen
+	Tokens   TokenTotals `json:"tokens"`
+	// Sessions breaks the totals down by the session ID of each
+	// ResultMessage
+	Sessions map[string]SessionCost `json:"sessions,omitempty"`
+}
+
+// CostTracker aggregates the cost and token usage reported in the
+// ResultMessages of every query on a ClaudeSDKClient, across sessions and
+// session restarts. Get it with ClaudeSDKClient.CostTracker.
+type CostTracker struct {
+	alert *CostAlert
+
+	mu       sync.Mutex
+	summary  CostSummary
+	lastUSD  float64 // Cumulative cost the CLI last reported
+	alerted  bool
+	sessions map[string]*SessionCost
+}
+
+// newCostTracker returns a tracker that alerts per options.CostAlert.
+func newCostTracker(options *ClaudeAgentOptions) *CostTracker {
+	t := &CostTracker{sessions: make(map[string]*SessionCost)}
+	if options != nil {
+		t.alert = options.CostAlert
+	}
+	return t
+}
+
+// TotalUSD returns the cumulative cost of all queries.
+func (t *CostTracker) TotalUSD() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.summary.TotalUSD
+}
+
+// Summary returns the totals so far.
+func (t *CostTracker) Summary() CostSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.summaryLocked()
+}
+
+// summaryLocked copies the summary. Callers hold mu.
+func (t *CostTracker) summaryLocked() CostSummary {
+	summary := t.summary
+	summary.Sessions = make(map[string]SessionCost, len(t.sessions))
+	for id, session := range t.sessions {
+		summary.Sessions[id] = *session
+	}
+	return summary
+}
+
+// observe records msg if it is a ResultMessage.
+func (t *CostTracker) observe(msg Message) {
+	result, ok := msg.(*ResultMessage)
+	if !ok {
+		return
+	}
+	usage, err := ParseUsage(result.Usage)
+	if err != nil {
+		usage = &Usage{}
+	}
+
+	t.mu.Lock()
+	var delta float64
+	if result.TotalCostUSD != nil {
+		// Cumulative per CLI process, which starts over on restarts
+		delta = *result.TotalCostUSD - t.lastUSD
+		if *result.TotalCostUSD < t.lastUSD {
+			delta = *result.TotalCostUSD
+		}
+		t.lastUSD = *result.TotalCostUSD
+	}
+	t.summary.TotalUSD += delta
+	t.summary.Queries++
+	t.summary.Tokens.add(usage)
+	session := t.sessions[result.SessionID]
+	if session == nil {
+		session = &SessionCost{}
+		t.sessions[result.SessionID] = session
+	}
+	session.CostUSD += delta
+	session.Queries++
+	session.Tokens.add(usage)
+
+	var crossed *CostSummary
+	if t.alert != nil && t.alert.OnCross != nil && !t.alerted && t.summary.TotalUSD >= t.alert.ThresholdUSD {
+		t.alerted = true
+		summary := t.summaryLocked()
+		crossed = &summary
+	}
+	t.mu.Unlock()
+
+	if crossed != nil {
+		go t.alert.OnCross(*crossed)
+	}
+}
+
+// CostTracker returns the client's cost tracker, which totals the cost and
+// tokens of every query since the client was created.
+//
+// Example:
+//
+//	summary := client.CostTracker().Summary()
+//	log.Printf("$%.2f over %d queries", summary.TotalUSD, summary.Queries)
+//	for id, session := range summary.Sessions {
+//	    log.Printf("  %s: $%.2f, %d output tokens", id, session.CostUSD, session.Tokens.OutputTokens)
+//	}
+func (c *ClaudeSDKClient) CostTracker() *CostTracker {
+	return c.costs
+}
@@ -0,0 +1,99 @@
+package claude
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DebugLevel is the severity of a DebugEvent.
+type DebugLevel string
+
+const (
+	DebugLevelDebug DebugLevel = "debug"
+	DebugLevelInfo  DebugLevel = "info"
+	DebugLevelWarn  DebugLevel = "warn"
+	DebugLevelError DebugLevel = "error"
+)
+
+// DebugEventKind classifies a DebugEvent by the CLI subsystem that logged it.
+type DebugEventKind string
+
+const (
+	DebugEventGeneral DebugEventKind = "general" // Not a recognized format; see Message
+	DebugEventMCP     DebugEventKind = "mcp"     // An MCP server; Fields["server"] names it
+	DebugEventHook    DebugEventKind = "hook"    // Hook matching and execution; Fields["event"], and Fields["query"] if logged
+)
+
+// DebugEvent is one line of the CLI's debug log, which it writes to stderr
+// with --debug-to-stderr, parsed from the form
+//
+//	[2025-01-02T15:04:05.000Z] [DEBUG] MCP server "github": Connection established
+//
+// (the timestamp is optional).
+type DebugEvent struct {
+	Time    time.Time         `json:"time,omitzero"` // Zero if the line has no timestamp
+	Level   DebugLevel        `json:"level"`
+	Kind    DebugEventKind    `json:"kind"`
+	Message string            `json:"message"`          // The line after the level tag
+	Fields  map[string]string `json:"fields,omitempty"` // Kind-specific values, see the DebugEventKind constants
+	Raw     string            `json:"raw"`              // The whole line
+}
+
+// DebugEventCallback is called for each debug line of CLI stderr, in
+// addition to ClaudeAgentOptions.Stderr. It is called from the transport's
+// stderr reader and must not block.
+type DebugEventCallback func(event DebugEvent)
+
+// debugLinePattern matches an optional timestamp, a level tag, and the message.
+var debugLinePattern = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}T[\d:.]+(?:Z|[+-]\d{2}:?\d{2})?)?\]?\s*\[(DEBUG|INFO|WARN|WARNING|ERROR)\]\s?(.*)$`)
+
+// debugLevels maps level tags to levels.
+var debugLevels = map[string]DebugLevel{
+	"DEBUG":   DebugLevelDebug,
+	"INFO":    DebugLevelInfo,
+	"WARN":    DebugLevelWarn,
+	"WARNING": DebugLevelWarn,
+	"ERROR":   DebugLevelError,
+}
+
+var (
+	debugMCPPattern       = regexp.MustCompile(`^MCP server "([^"]+)"`)
+	debugHookEventPattern = regexp.MustCompile(`\b(PreToolUse|PostToolUse|UserPromptSubmit|Stop|SubagentStop|PreCompact|SessionStart|SessionEnd|Notification|PermissionRequest)\b(?::(\S+))?`)
+	debugHookQueryPattern = regexp.MustCompile(`query:? "?([^"\s]+)"?`)
+)
+
+// ParseDebugLine parses a line of CLI stderr written with --debug-to-stderr.
+// ok is false if the line is not a debug log line, e.g. a stack trace.
+//
+// Example, for stderr collected some other way:
+//
+//	if event, ok := claude.ParseDebugLine(line); ok && event.Kind == claude.DebugEventMCP {
+//	    log.Printf("MCP %s: %s", event.Fields["server"], event.Message)
+//	}
+func ParseDebugLine(line string) (event DebugEvent, ok bool) {
+	match := debugLinePattern.FindStringSubmatch(strings.TrimSpace(line))
+	if match == nil {
+		return DebugEvent{}, false
+	}
+	event = DebugEvent{Level: debugLevels[match[2]], Kind: DebugEventGeneral, Message: match[3], Raw: line}
+	if match[1] != "" {
+		event.Time, _ = time.Parse(time.RFC3339Nano, match[1])
+	}
+
+	if m := debugMCPPattern.FindStringSubmatch(event.Message); m != nil {
+		event.Kind = DebugEventMCP
+		event.Fields = map[string]string{"server": m[1]}
+	} else if strings.Contains(strings.ToLower(event.Message), "hook") {
+		if m := debugHookEventPattern.FindStringSubmatch(event.Message); m != nil {
+			event.Kind = DebugEventHook
+			event.Fields = map[string]string{"event": m[1]}
+			if m[2] != "" {
+				event.Fields["query"] = m[2]
+			} else if q := debugHookQueryPattern.FindStringSubmatch(event.Message); q != nil {
+				event.Fields["query"] = q[1]
+			}
+		}
+	}
+	return event, true
+}
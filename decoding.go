@@ -0,0 +1,96 @@
+package claude
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DecodingWarning reports CLI output that was not valid UTF-8. The invalid
+// bytes were replaced with U+FFFD and the stream continued.
+type DecodingWarning struct {
+	Stream       string `json:"stream"`        // "stdout" or "stderr"
+	Line         int    `json:"line"`          // 1-based line number within the stream
+	InvalidBytes int    `json:"invalid_bytes"` // Number of bytes replaced
+	Sample       string `json:"sample"`        // Sanitized text around the first invalid byte
+}
+
+func (w DecodingWarning) String() string {
+	return fmt.Sprintf("%s line %d: replaced %d invalid UTF-8 bytes near %q", w.Stream, w.Line, w.InvalidBytes, w.Sample)
+}
+
+// DecodingWarningCallback is called for each line of CLI output that needed
+// sanitizing. It is called from the transport's reader goroutines and must
+// not block.
+type DecodingWarningCallback func(warning DecodingWarning)
+
+// decodingSampleBytes is how much context DecodingWarning.Sample keeps on
+// each side of the first invalid byte.
+const decodingSampleBytes = 32
+
+// utf8Environment pins the CLI's locale to UTF-8; see ForceUTF8Output.
+var utf8Environment = map[string]string{
+	"LANG":             "C.UTF-8",
+	"LC_ALL":           "C.UTF-8",
+	"PYTHONIOENCODING": "utf-8",
+	"PYTHONUTF8":       "1",
+}
+
+// sanitizeUTF8 replaces each invalid byte of line with U+FFFD. It returns the
+// line unchanged and ok=true if it was already valid; otherwise it returns
+// the sanitized line and a warning with Stream and Line unset.
+func sanitizeUTF8(line string) (string, DecodingWarning, bool) {
+	if utf8.ValidString(line) {
+		return line, DecodingWarning{}, true
+	}
+
+	var b strings.Builder
+	b.Grow(len(line) + 8)
+	first, invalid := -1, 0
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if r == utf8.RuneError && size == 1 {
+			if first < 0 {
+				first = b.Len()
+			}
+			invalid++
+			b.WriteRune(utf8.RuneError)
+		} else {
+			b.WriteString(line[i : i+size])
+		}
+		i += size
+	}
+	sanitized := b.String()
+	return sanitized, DecodingWarning{InvalidBytes: invalid, Sample: sampleAround(sanitized, first)}, false
+}
+
+// sampleAround returns up to decodingSampleBytes of s on each side of pos,
+// trimmed to rune boundaries.
+func sampleAround(s string, pos int) string {
+	start, end := pos-decodingSampleBytes, pos+decodingSampleBytes
+	if start < 0 {
+		start = 0
+	}
+	if end > len(s) {
+		end = len(s)
+	}
+	for start > 0 && !utf8.RuneStart(s[start]) {
+		start--
+	}
+	for end < len(s) && !utf8.RuneStart(s[end]) {
+		end++
+	}
+	return s[start:end]
+}
+
+// reportDecodingWarning delivers warning to the configured callback and
+// event sink.
+func reportDecodingWarning(options *ClaudeAgentOptions, warning DecodingWarning) {
+	if options == nil {
+		return
+	}
+	if options.DecodingWarning != nil {
+		options.DecodingWarning(warning)
+	}
+	emitEvent(eventSinkFor(options), Event{Type: EventTypeDecodingWarning, Data: warning})
+}
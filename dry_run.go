@@ -0,0 +1,181 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DryRunResult describes the CLI process a query would start.
+type DryRunResult struct {
+	CLIPath string   // Resolved CLI path, or "claude" if it is not installed
+	Args    []string // Arguments after the CLI path
+	Dir     string   // Working directory ("" = inherited)
+
+	// Env holds the variables set on top of the inherited environment.
+	Env map[string]string
+
+	// Stdin holds the JSON lines written before any response is read: the
+	// initialize request (with a placeholder request ID) and, for string
+	// prompts sent over stdin, the user message. Messages from a prompt
+	// channel are not consumed and are not included.
+	Stdin []string
+
+	// AgentsViaTempFile is true when the command line is too long and
+	// --agents would be passed as @file; Args shows the inline value.
+	AgentsViaTempFile bool
+}
+
+// ShellCommand returns the command line quoted for the current platform's
+// shell, suitable for copying into a terminal. Env is not included.
+func (r *DryRunResult) ShellCommand() string {
+	quote := posixQuote
+	if isWindows() {
+		quote = windowsQuote
+	}
+	parts := make([]string, 0, len(r.Args)+1)
+	parts = append(parts, quote(r.CLIPath))
+	for _, arg := range r.Args {
+		parts = append(parts, quote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// EnvList returns Env as sorted KEY=value pairs.
+func (r *DryRunResult) EnvList() []string {
+	env := make([]string, 0, len(r.Env))
+	for k, v := range r.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(env)
+	return env
+}
+
+// DryRun builds the command, environment, and initial stdin of the CLI
+// process without starting it or checking its version. It applies the same
+// option processing as the query APIs, so it shows exactly how options map
+// to flags.
+//
+// prompt is interpreted like the query APIs: a string as in Query, a
+// <-chan map[string]interface{} as in QueryStream, or nil as in
+// ClaudeSDKClient.Connect.
+//
+// Example:
+//
+//	result, err := claude.DryRun("Summarize README.md", options)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(result.ShellCommand())
+func DryRun(prompt interface{}, options *ClaudeAgentOptions) (*DryRunResult, error) {
+	if options == nil {
+		options = &ClaudeAgentOptions{}
+	}
+
+	entrypoint := entrypointQuery
+	var stdinPrompt map[string]interface{}
+	switch p := prompt.(type) {
+	case nil:
+		entrypoint = entrypointClient
+		prompt = (<-chan map[string]interface{})(nil)
+	case string:
+		if len(p) > largePromptThreshold(options) {
+			input, _ := streamedPrompt(p)
+			stdinPrompt = <-input
+			prompt = input
+		}
+	case <-chan map[string]interface{}:
+	default:
+		return nil, fmt.Errorf("prompt must be nil, string or <-chan map[string]interface{}")
+	}
+
+	_, isStreaming := prompt.(<-chan map[string]interface{})
+	configuredOptions, err := validateAndConfigurePermissions(options, isStreaming)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ValidateMcpTools(configuredOptions); err != nil {
+		return nil, err
+	}
+
+	cliPath, err := findCLI()
+	if err != nil {
+		cliPath = "claude"
+	}
+	transport, err := NewSubprocessCLITransport(prompt, configuredOptions, cliPath)
+	if err != nil {
+		return nil, err
+	}
+	transport.entrypoint = entrypoint
+
+	args := transport.buildArgs()
+	result := &DryRunResult{
+		CLIPath:           cliPath,
+		Args:              args,
+		Dir:               transport.cwd,
+		Env:               transport.envOverrides(),
+		AgentsViaTempFile: transport.agentsNeedTempFile(args),
+	}
+
+	if isStreaming {
+		q := newQueryHandler(nil, true, configuredOptions.CanUseTool, configuredOptions.Hooks, nil, 1)
+		q.setSDKInfo(configuredOptions)
+		lines := []map[string]interface{}{{
+			"type":       MessageTypeControlRequest,
+			"request_id": "req_1",
+			"request":    q.initializeRequest(),
+		}}
+		if stdinPrompt != nil {
+			lines = append(lines, stdinPrompt)
+		}
+		for _, line := range lines {
+			data, err := json.Marshal(line)
+			if err != nil {
+				return nil, err
+			}
+			result.Stdin = append(result.Stdin, string(data))
+		}
+	}
+
+	return result, nil
+}
+
+// posixQuote quotes s for POSIX shells.
+func posixQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// windowsQuote quotes s following the CommandLineToArgvW rules used by most
+// Windows programs.
+func windowsQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\v\"") {
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	backslashes := 0
+	for _, r := range s {
+		if r == '\\' {
+			backslashes++
+			continue
+		}
+		if r == '"' {
+			// Escape preceding backslashes and the quote itself
+			b.WriteString(strings.Repeat(`\`, 2*backslashes+1))
+		} else {
+			b.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		backslashes = 0
+		b.WriteRune(r)
+	}
+	// Backslashes before the closing quote must be doubled
+	b.WriteString(strings.Repeat(`\`, 2*backslashes))
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// dynamicHookEvents are the events a dispatcher is registered for when
+// ClaudeAgentOptions.DynamicHooks is set.
+var dynamicHookEvents = []HookEvent{
+	HookEventPreToolUse,
+	HookEventPostToolUse,
+	HookEventUserPromptSubmit,
+	HookEventStop,
+	HookEventSubagentStop,
+	HookEventPreCompact,
+	HookEventSessionStart,
+	HookEventSessionEnd,
+	HookEventNotification,
+	HookEventPermissionRequest,
+}
+
+// HookHandle identifies a hook added with ClaudeSDKClient.AddHook.
+type HookHandle struct {
+	id      uint64
+	event   HookEvent
+	matcher string
+}
+
+// Event returns the hook event the handle was registered for.
+func (h HookHandle) Event() HookEvent {
+	return h.event
+}
+
+// Matcher returns the tool name matcher the handle was registered with.
+func (h HookHandle) Matcher() string {
+	return h.matcher
+}
+
+// dynamicHook is one callback added at runtime.
+type dynamicHook struct {
+	handle   HookHandle
+	pattern  *regexp.Regexp // Compiled matcher; nil matches every tool
+	callback HookCallback
+}
+
+// dynamicHooks holds hooks added and removed while a client is running.
+//
+// The CLI only learns about hooks in the initialize request, so instead of
+// renegotiating, one catch-all dispatcher per event is registered at connect
+// time and matching is done SDK-side.
+type dynamicHooks struct {
+	mu     sync.RWMutex
+	nextID uint64
+	hooks  []dynamicHook
+}
+
+func newDynamicHooks() *dynamicHooks {
+	return &dynamicHooks{}
+}
+
+// add registers callback for event and tool names matching matcher.
+func (d *dynamicHooks) add(event HookEvent, matcher string, callback HookCallback) (HookHandle, error) {
+	if callback == nil {
+		return HookHandle{}, fmt.Errorf("hook callback is nil")
+	}
+	pattern, err := compileHookMatcher(matcher)
+	if err != nil {
+		return HookHandle{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nextID++
+	handle := HookHandle{id: d.nextID, event: event, matcher: matcher}
+	d.hooks = append(d.hooks, dynamicHook{handle: handle, pattern: pattern, callback: callback})
+	return handle, nil
+}
+
+// remove unregisters handle, reporting whether it was registered.
+func (d *dynamicHooks) remove(handle HookHandle) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, hook := range d.hooks {
+		if hook.handle == handle {
+			d.hooks = append(d.hooks[:i:i], d.hooks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// dispatcher returns the callback registered with the CLI for event. It runs
+// the matching hooks in registration order and returns the first non-empty
+// output or error.
+func (d *dynamicHooks) dispatcher(event HookEvent) HookCallback {
+	return func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
+		subject, hasSubject := hookMatchSubject(input)
+
+		d.mu.RLock()
+		var matched []HookCallback
+		for _, hook := range d.hooks {
+			if hook.handle.event != event {
+				continue
+			}
+			// Events without a subject (e.g. Stop) ignore the matcher, as in the CLI
+			if hasSubject && hook.pattern != nil && !hook.pattern.MatchString(subject) {
+				continue
+			}
+			matched = append(matched, hook.callback)
+		}
+		d.mu.RUnlock()
+
+		for _, callback := range matched {
+			output, err := callback(ctx, input, toolUseID, hookCtx)
+			if err != nil || !isEmptyHookOutput(output) {
+				return output, err
+			}
+		}
+		return HookJSONOutput{}, nil
+	}
+}
+
+// withDispatchers returns a copy of options with a catch-all dispatcher
+// appended to the hooks of every event.
+func (d *dynamicHooks) withDispatchers(options *ClaudeAgentOptions) *ClaudeAgentOptions {
+	newOpts := *options
+	newOpts.Hooks = make(map[HookEvent][]HookMatcher, len(dynamicHookEvents))
+	for event, matchers := range options.Hooks {
+		newOpts.Hooks[event] = matchers
+	}
+	for _, event := range dynamicHookEvents {
+		newOpts.Hooks[event] = append(
+			append([]HookMatcher{}, options.Hooks[event]...),
+			HookMatcher{Hooks: []HookCallback{d.dispatcher(event)}},
+		)
+	}
+	return &newOpts
+}
+
+// isEmptyHookOutput reports whether output leaves every field unset.
+func isEmptyHookOutput(output HookJSONOutput) bool {
+	return output.Continue == nil && output.SuppressOutput == nil && output.StopReason == nil &&
+		output.Async == nil && output.AsyncTimeout == nil &&
+		output.Decision == nil && output.SystemMessage == nil && output.Reason == nil &&
+		len(output.HookSpecificOutput) == 0
+}
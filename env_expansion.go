@@ -0,0 +1,174 @@
+package claude
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// expandOptions returns options with ${VAR} and ~ expanded in MCP server
+// commands, arguments, environments, URLs, and headers, and in local plugin
+// paths, so configs stay portable between machines. Variables are looked up
+// in options.Env, then in the SDK's environment; ${VAR:-default} supplies a
+// default. A variable that is unset and has no default is left as is, for
+// the CLI to expand, and $${ is passed on as ${ without expanding it. That
+// defers the reference to the CLI rather than making it literal: the CLI
+// has no escape and expands ${VAR} when VAR is set in its environment.
+// options is not modified; it is returned as is when nothing needs expanding.
+func expandOptions(options *ClaudeAgentOptions) (*ClaudeAgentOptions, error) {
+	if len(options.McpServers) == 0 && len(options.Plugins) == 0 {
+		return options, nil
+	}
+	x := envExpander{env: options.Env}
+
+	// Sorted so the first error is deterministic
+	names := make([]string, 0, len(options.McpServers))
+	for name := range options.McpServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	servers := make(map[string]McpServerConfig, len(options.McpServers))
+	for _, name := range names {
+		field := "McpServers[" + name + "]"
+		switch config := options.McpServers[name].(type) {
+		case McpStdioServerConfig:
+			config.Command = x.path(field+".Command", config.Command)
+			config.Args = x.paths(field+".Args", config.Args)
+			config.Env = x.values(field+".Env", config.Env)
+			servers[name] = config
+		case McpSSEServerConfig:
+			config.URL = x.expand(field+".URL", config.URL)
+			config.Headers = x.values(field+".Headers", config.Headers)
+			servers[name] = config
+		case McpHTTPServerConfig:
+			config.URL = x.expand(field+".URL", config.URL)
+			config.Headers = x.values(field+".Headers", config.Headers)
+			servers[name] = config
+		default:
+			servers[name] = config
+		}
+	}
+
+	var plugins []SdkPluginConfig
+	if options.Plugins != nil {
+		plugins = make([]SdkPluginConfig, len(options.Plugins))
+		for i, plugin := range options.Plugins {
+			plugin.Path = x.path(fmt.Sprintf("Plugins[%d].Path", i), plugin.Path)
+			plugins[i] = plugin
+		}
+	}
+
+	if x.err != nil {
+		return nil, x.err
+	}
+	expanded := *options
+	if options.McpServers != nil {
+		expanded.McpServers = servers
+	}
+	expanded.Plugins = plugins
+	return &expanded, nil
+}
+
+// envExpander expands values, keeping the first error.
+type envExpander struct {
+	env map[string]string
+	err error
+}
+
+func (x *envExpander) lookup(name string) (string, bool) {
+	if value, ok := x.env[name]; ok {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
+
+// expand replaces ${VAR} and ${VAR:-default} in s, and $${ with an
+// unexpanded ${. field names s in errors.
+func (x *envExpander) expand(field, s string) string {
+	if x.err != nil || !strings.Contains(s, "${") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		if start > 0 && s[start-1] == '$' {
+			// Escaped: $${ is left for the CLI as ${, with or without a }
+			b.WriteString(s[:start-1] + "${")
+			s = s[start+2:]
+			continue
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			x.err = NewEnvExpansionError(field, "", fmt.Sprintf("unterminated ${ in %q", s))
+			return s
+		}
+		b.WriteString(s[:start])
+		name, def, hasDefault := strings.Cut(s[start+2:start+end], ":-")
+		if name == "" {
+			x.err = NewEnvExpansionError(field, "", fmt.Sprintf("empty variable name in %q", s))
+			return s
+		}
+		value, ok := x.lookup(name)
+		switch {
+		case ok && (value != "" || !hasDefault):
+			b.WriteString(value)
+		case hasDefault:
+			b.WriteString(def)
+		default:
+			// Unset: leave it for the CLI, which expands MCP configs itself
+			b.WriteString(s[start : start+end+1])
+		}
+		s = s[start+end+1:]
+	}
+}
+
+// path is expand plus expansion of a leading ~ to the home directory.
+func (x *envExpander) path(field, s string) string {
+	s = x.expand(field, s)
+	if x.err != nil || (s != "~" && !strings.HasPrefix(s, "~/") && !strings.HasPrefix(s, `~\`)) {
+		return s
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		x.err = NewEnvExpansionError(field, "", fmt.Sprintf("cannot expand ~: %v", err))
+		return s
+	}
+	if s == "~" {
+		return home
+	}
+	return filepath.Join(home, s[2:])
+}
+
+func (x *envExpander) paths(field string, values []string) []string {
+	if values == nil {
+		return nil
+	}
+	expanded := make([]string, len(values))
+	for i, v := range values {
+		expanded[i] = x.path(fmt.Sprintf("%s[%d]", field, i), v)
+	}
+	return expanded
+}
+
+func (x *envExpander) values(field string, values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	expanded := make(map[string]string, len(values))
+	for _, k := range keys {
+		expanded[k] = x.expand(field+"["+k+"]", values[k])
+	}
+	return expanded
+}
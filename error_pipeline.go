@@ -0,0 +1,99 @@
+package claude
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ErrorSeverity classifies errors reported through ClaudeAgentOptions.OnError.
+type ErrorSeverity string
+
+const (
+	// ErrorSeverityWarning marks a recoverable problem; the stream continues.
+	// Examples: an unknown message type was skipped, a hook callback failed and
+	// an error was returned to the CLI, a control response could not be written.
+	ErrorSeverityWarning ErrorSeverity = "warning"
+
+	// ErrorSeverityFatal marks the error that ended the stream. At most one
+	// fatal error is reported per Query() call or client connection, and it is
+	// the error delivered on the error channel.
+	ErrorSeverityFatal ErrorSeverity = "fatal"
+)
+
+// ErrorCallback receives every error in the order it occurred, with its
+// severity. Calls are serialized; the callback must not block.
+type ErrorCallback func(err error, severity ErrorSeverity)
+
+// errorPipeline orders errors from the transport, query handler, and parser.
+// Warnings are reported and otherwise ignored; the first fatal error becomes
+// the terminal error and later ones are dropped. A nil pipeline is valid and
+// ignores everything.
+type errorPipeline struct {
+	mu       sync.Mutex
+	callback ErrorCallback
+	terminal error
+}
+
+func newErrorPipeline(options *ClaudeAgentOptions) *errorPipeline {
+	pipeline := &errorPipeline{}
+	if options != nil {
+		pipeline.callback = options.OnError
+	}
+	return pipeline
+}
+
+// warn reports a recoverable error.
+func (p *errorPipeline) warn(err error) {
+	if p == nil || err == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.terminal == nil && p.callback != nil {
+		p.callback(err, ErrorSeverityWarning)
+	}
+}
+
+// fail records err as the terminal error unless one is already set, and
+// returns the terminal error.
+func (p *errorPipeline) fail(err error) error {
+	if p == nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.terminal == nil && err != nil {
+		p.terminal = err
+		if p.callback != nil {
+			p.callback(err, ErrorSeverityFatal)
+		}
+	}
+	return p.terminal
+}
+
+// err returns the terminal error, or nil if the stream has not failed.
+func (p *errorPipeline) err() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.terminal
+}
+
+// knownMessageTypes are the message types the parser understands.
+var knownMessageTypes = map[string]bool{
+	"user": true, "assistant": true, "system": true, "result": true, "stream_event": true,
+}
+
+// parseOrSkip parses data, reporting unknown message types as warnings so a
+// newer CLI cannot end the stream. It returns a nil message and nil error for
+// skipped messages; any other parse failure is returned for the caller to
+// treat as fatal.
+func parseOrSkip(parser messageParser, data map[string]interface{}, errs *errorPipeline) (Message, error) {
+	if msgType, ok := data["type"].(string); ok && !knownMessageTypes[msgType] {
+		errs.warn(NewMessageParseError(fmt.Sprintf("skipped unknown message type: %s", msgType), data))
+		return nil, nil
+	}
+	return parser.parse(data)
+}
@@ -1,6 +1,10 @@
 package claude
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 // ClaudeSDKError is the base error type for all Claude SDK errors.
 type ClaudeSDKError struct {
@@ -19,6 +23,34 @@ func (e *ClaudeSDKError) Unwrap() error {
 	return e.Err
 }
 
+// Errors returned for ClaudeSDKClient lifecycle misuse; compare with
+// errors.Is. They are *CLIConnectionError values, so code that matches that
+// type keeps working.
+var (
+	// ErrNotConnected is returned by methods that need a connection when
+	// Connect has not been called or did not succeed.
+	ErrNotConnected = NewCLIConnectionError("not connected. Call Connect() first", nil)
+
+	// ErrAlreadyConnected is returned by Connect on a connected client.
+	ErrAlreadyConnected = NewCLIConnectionError("already connected. Create a new client for another session", nil)
+
+	// ErrClosed is returned by every method that needs a connection, and by
+	// Connect, after Close.
+	ErrClosed = NewCLIConnectionError("client is closed", nil)
+
+	// ErrStreamTruncated is the terminal error of a stream whose CLI
+	// connection ended without an error before the ResultMessage.
+	ErrStreamTruncated = NewCLIConnectionError("message stream ended before the result message", nil)
+
+	// ErrSessionClosed is returned by the methods of a Session after it is
+	// closed, and as the error of its query in flight.
+	ErrSessionClosed = NewCLIConnectionError("session is closed", nil)
+
+	// ErrShuttingDown is returned by queries sent after Shutdown was
+	// called.
+	ErrShuttingDown = NewCLIConnectionError("client is shutting down", nil)
+)
+
 // CLINotFoundError is returned when Claude Code CLI is not found or not installed.
 type CLINotFoundError struct {
 	*ClaudeSDKError
@@ -107,3 +139,259 @@ func NewMessageParseError(message string, data map[string]interface{}) *MessageP
 		Data:           data,
 	}
 }
+
+// OrphanedToolUseError is returned when a turn ends without a result for a tool use.
+// NotFound is true if the turn ended without the tool use itself.
+type OrphanedToolUseError struct {
+	*ClaudeSDKError
+	ToolUseID string
+	NotFound  bool
+}
+
+// NewOrphanedToolUseError creates a new OrphanedToolUseError.
+func NewOrphanedToolUseError(toolUseID string) *OrphanedToolUseError {
+	return &OrphanedToolUseError{
+		ClaudeSDKError: &ClaudeSDKError{Message: fmt.Sprintf("tool use %s ended without a result", toolUseID)},
+		ToolUseID:      toolUseID,
+	}
+}
+
+// NewToolUseNotFoundError creates an OrphanedToolUseError for a tool use
+// that was never seen in the turn.
+func NewToolUseNotFoundError(toolUseID string) *OrphanedToolUseError {
+	return &OrphanedToolUseError{
+		ClaudeSDKError: &ClaudeSDKError{Message: fmt.Sprintf("tool use %s was not found before the turn ended", toolUseID)},
+		ToolUseID:      toolUseID,
+		NotFound:       true,
+	}
+}
+
+// ControlRequestError is returned when a control request to the CLI fails or times out.
+// RequestID matches the request_id sent on the control protocol, for correlating with CLI logs.
+type ControlRequestError struct {
+	*ClaudeSDKError
+	RequestID string
+	Subtype   string
+}
+
+// NewControlRequestError creates a new ControlRequestError.
+func NewControlRequestError(requestID string, subtype string, message string, err error) *ControlRequestError {
+	return &ControlRequestError{
+		ClaudeSDKError: &ClaudeSDKError{
+			Message: fmt.Sprintf("%s (subtype: %s, request_id: %s)", message, subtype, requestID),
+			Err:     err,
+		},
+		RequestID: requestID,
+		Subtype:   subtype,
+	}
+}
+
+// CallbackTimeoutError is returned to the CLI, and reported as a warning,
+// when a CanUseTool or hook callback runs longer than
+// ClaudeAgentOptions.CanUseToolTimeout or HookTimeout. It wraps
+// context.DeadlineExceeded.
+type CallbackTimeoutError struct {
+	*ClaudeSDKError
+	Callback string        // "can_use_tool" or "hook_callback"
+	Timeout  time.Duration // The timeout it exceeded
+}
+
+// NewCallbackTimeoutError creates a new CallbackTimeoutError.
+func NewCallbackTimeoutError(callback string, timeout time.Duration) *CallbackTimeoutError {
+	return &CallbackTimeoutError{
+		ClaudeSDKError: &ClaudeSDKError{
+			Message: fmt.Sprintf("%s callback timed out after %s", callback, timeout),
+			Err:     context.DeadlineExceeded,
+		},
+		Callback: callback,
+		Timeout:  timeout,
+	}
+}
+
+// CorrelatedError wraps an error with the caller-supplied correlation ID of the
+// query that produced it. Use errors.As to recover the ID, or errors.Is/As to
+// inspect the underlying error.
+type CorrelatedError struct {
+	CorrelationID string
+	Err           error
+}
+
+func (e *CorrelatedError) Error() string {
+	return fmt.Sprintf("[correlation_id=%s] %v", e.CorrelationID, e.Err)
+}
+
+func (e *CorrelatedError) Unwrap() error {
+	return e.Err
+}
+
+// McpToolCollisionError is returned when two SDK MCP tools resolve to the same
+// fully-qualified tool name.
+type McpToolCollisionError struct {
+	*ClaudeSDKError
+	ToolName string   // Fully-qualified name, e.g. mcp__calc__add
+	Servers  []string // Servers defining the colliding tools
+}
+
+// NewMcpToolCollisionError creates a new McpToolCollisionError.
+func NewMcpToolCollisionError(toolName string, servers []string) *McpToolCollisionError {
+	return &McpToolCollisionError{
+		ClaudeSDKError: &ClaudeSDKError{
+			Message: fmt.Sprintf("MCP tool name collision: %s is defined more than once (servers: %v)", toolName, servers),
+		},
+		ToolName: toolName,
+		Servers:  servers,
+	}
+}
+
+// BudgetExceededError is returned when a session's cumulative cost reaches
+// BudgetStrategy.HardCapUSD, or a query, session, or day exceeds one of the
+// BudgetLimits.
+type BudgetExceededError struct {
+	*ClaudeSDKError
+	Scope   BudgetScope
+	CostUSD float64 // Cost within Scope
+	CapUSD  float64
+}
+
+// NewBudgetExceededError creates a new BudgetExceededError for
+// BudgetStrategy.HardCapUSD.
+func NewBudgetExceededError(costUSD, capUSD float64) *BudgetExceededError {
+	return &BudgetExceededError{
+		ClaudeSDKError: &ClaudeSDKError{
+			Message: fmt.Sprintf("budget exceeded: session cost $%.4f reached hard cap $%.2f", costUSD, capUSD),
+		},
+		Scope:   BudgetScopeSession,
+		CostUSD: costUSD,
+		CapUSD:  capUSD,
+	}
+}
+
+// NewScopedBudgetExceededError creates a new BudgetExceededError for one of
+// the BudgetLimits.
+func NewScopedBudgetExceededError(scope BudgetScope, costUSD, capUSD float64) *BudgetExceededError {
+	return &BudgetExceededError{
+		ClaudeSDKError: &ClaudeSDKError{
+			Message: fmt.Sprintf("budget exceeded: %s cost $%.4f reached limit $%.2f", scope, costUSD, capUSD),
+		},
+		Scope:   scope,
+		CostUSD: costUSD,
+		CapUSD:  capUSD,
+	}
+}
+
+// ResultSubtype returns a result subtype for the exceeded scope, in the style
+// of the CLI's "error_max_budget_usd": "error_query_budget_usd",
+// "error_session_budget_usd", or "error_daily_budget_usd".
+func (e *BudgetExceededError) ResultSubtype() string {
+	return "error_" + string(e.Scope) + "_budget_usd"
+}
+
+// ResultError is returned for an error ResultMessage (IsError set or an
+// "error_*" subtype) when ClaudeAgentOptions.ErrorsFromResults is set.
+type ResultError struct {
+	*ClaudeSDKError
+	Subtype   string         // e.g. "error_max_turns" or "error_during_execution"
+	SessionID string         // Session of the failed query
+	Result    *ResultMessage // The message, which is still delivered
+}
+
+// NewResultError creates a new ResultError for result.
+func NewResultError(result *ResultMessage) *ResultError {
+	message := fmt.Sprintf("query failed: %s", result.Subtype)
+	if result.Result != nil && *result.Result != "" {
+		message += ": " + *result.Result
+	}
+	return &ResultError{
+		ClaudeSDKError: &ClaudeSDKError{Message: message},
+		Subtype:        result.Subtype,
+		SessionID:      result.SessionID,
+		Result:         result,
+	}
+}
+
+// StreamingRequiredError is returned when an operation needs the control
+// protocol, e.g. Interrupt or SetModel, but the session runs in one-shot
+// mode: a ClaudeSDKClient connected with a string prompt.
+type StreamingRequiredError struct {
+	*ClaudeSDKError
+	Operation string      // e.g. "interrupt", "set_model", "query"
+	Mode      SessionMode // The session's mode
+}
+
+// NewStreamingRequiredError creates a new StreamingRequiredError.
+func NewStreamingRequiredError(operation string, mode SessionMode) *StreamingRequiredError {
+	return &StreamingRequiredError{
+		ClaudeSDKError: &ClaudeSDKError{
+			Message: fmt.Sprintf("%s requires an interactive session, but this session is %s; connect with Connect() or a message channel instead of a string prompt", operation, mode),
+		},
+		Operation: operation,
+		Mode:      mode,
+	}
+}
+
+// SessionStateError is returned by ExportState when there is no session to
+// export, and by ResumeFromState when a state blob is invalid or does not
+// match the options it is resumed with.
+type SessionStateError struct {
+	*ClaudeSDKError
+}
+
+// NewSessionStateError creates a new SessionStateError.
+func NewSessionStateError(message string) *SessionStateError {
+	return &SessionStateError{
+		ClaudeSDKError: &ClaudeSDKError{Message: message},
+	}
+}
+
+// EnvExpansionError is returned when ${VAR} or ~ in an MCP server config or
+// plugin path cannot be expanded, e.g. an unterminated ${ or a ~ without a
+// home directory. Unset variables are not an error; they are left for the
+// CLI to expand, as is an escaped $${ with or without a closing }.
+type EnvExpansionError struct {
+	*ClaudeSDKError
+	Field    string // The option holding the value, e.g. "McpServers[github].Args[1]"
+	Variable string // The variable that could not be expanded; empty for syntax errors
+}
+
+// NewEnvExpansionError creates a new EnvExpansionError. With an empty
+// detail, the error reports variable as undefined.
+func NewEnvExpansionError(field, variable, detail string) *EnvExpansionError {
+	if detail == "" {
+		detail = fmt.Sprintf("environment variable %s is not set; set it or use ${%s:-default}", variable, variable)
+	}
+	return &EnvExpansionError{
+		ClaudeSDKError: &ClaudeSDKError{Message: fmt.Sprintf("cannot expand %s: %s", field, detail)},
+		Field:          field,
+		Variable:       variable,
+	}
+}
+
+// Sources of an AuthenticationError.
+const (
+	AuthErrorSourceStderr    = "stderr"    // Detected in CLI stderr after the process exited
+	AuthErrorSourceAssistant = "assistant" // AssistantMessage.Error reported authentication_failed
+	AuthErrorSourceResult    = "result"    // Error ResultMessage text indicated missing credentials
+)
+
+// AuthenticationError is returned when Claude Code is not logged in or its
+// credentials were rejected.
+type AuthenticationError struct {
+	*ClaudeSDKError
+	Source    string // One of the AuthErrorSource constants
+	Detail    string // The CLI output that identified the failure
+	Recovered bool   // True if ClaudeAgentOptions.OnAuthenticationError refreshed credentials; retry the request
+}
+
+// NewAuthenticationError creates a new AuthenticationError.
+func NewAuthenticationError(source string, detail string, err error) *AuthenticationError {
+	message := "Claude Code is not authenticated"
+	if detail != "" {
+		message = fmt.Sprintf("%s (%s)", message, detail)
+	}
+	message += ". Run `claude /login` or set ANTHROPIC_API_KEY (e.g. via ClaudeAgentOptions.Env)"
+	return &AuthenticationError{
+		ClaudeSDKError: &ClaudeSDKError{Message: message, Err: err},
+		Source:         source,
+		Detail:         detail,
+	}
+}
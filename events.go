@@ -0,0 +1,262 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType identifies the kind of session event sent to an EventSink.
+type EventType string
+
+const (
+	EventTypeMessage            EventType = "message"             // Every message delivered to the caller
+	EventTypeToolUse            EventType = "tool_use"            // Each ToolUseBlock in an AssistantMessage
+	EventTypePermissionDecision EventType = "permission_decision" // Each CanUseTool decision
+	EventTypeResult             EventType = "result"              // Each ResultMessage
+	EventTypeBudgetDowngrade    EventType = "budget_downgrade"    // A BudgetStrategy step switched the model
+	EventTypeDecodingWarning    EventType = "decoding_warning"    // CLI output contained invalid UTF-8
+	EventTypePolicyReloaded     EventType = "policy_reloaded"     // A PolicyWatcher loaded or rejected a changed policy
+)
+
+// Event is a serializable session event.
+type Event struct {
+	Type          EventType   `json:"type"`
+	Time          time.Time   `json:"time"`
+	MessageType   string      `json:"message_type,omitempty"` // For message events: "assistant", "result", ...
+	SessionID     string      `json:"session_id,omitempty"`
+	CorrelationID string      `json:"correlation_id,omitempty"`
+	Data          interface{} `json:"data"`
+}
+
+// PermissionDecisionEvent is the Data of an EventTypePermissionDecision event.
+type PermissionDecisionEvent struct {
+	RequestID string                 `json:"request_id"`
+	ToolName  string                 `json:"tool_name"`
+	Input     map[string]interface{} `json:"input,omitempty"`
+	Behavior  string                 `json:"behavior"` // "allow" or "deny"
+	Message   string                 `json:"message,omitempty"`
+}
+
+// EventSink receives every session event. Emit is called synchronously from
+// the SDK's message loop and must not block; sinks that do I/O should queue
+// events and deliver them in the background, like HTTPEventSink.
+type EventSink interface {
+	Emit(event Event)
+}
+
+// eventSinkFor returns the configured sink, or nil.
+func eventSinkFor(options *ClaudeAgentOptions) EventSink {
+	if options == nil {
+		return nil
+	}
+	return options.EventSink
+}
+
+// emitEvent sends event to sink, stamping the time. No-op for a nil sink.
+func emitEvent(sink EventSink, event Event) {
+	if sink == nil {
+		return
+	}
+	event.Time = time.Now().UTC()
+	sink.Emit(event)
+}
+
+// emitMessageEvents emits the message event for msg and the tool use and
+// result events derived from it, with the correlation ID of the query msg
+// answers.
+func emitMessageEvents(sink EventSink, msg Message, correlationID string) {
+	if sink == nil {
+		return
+	}
+
+	sessionID, _, messageType := messageIdentity(msg)
+	emitEvent(sink, Event{
+		Type:          EventTypeMessage,
+		MessageType:   messageType,
+		SessionID:     sessionID,
+		CorrelationID: correlationID,
+		Data:          msg,
+	})
+
+	switch m := msg.(type) {
+	case *AssistantMessage:
+		for _, block := range m.Content {
+			if toolUse, ok := block.(ToolUseBlock); ok {
+				emitEvent(sink, Event{
+					Type:          EventTypeToolUse,
+					SessionID:     sessionID,
+					CorrelationID: correlationID,
+					Data:          toolUse,
+				})
+			}
+		}
+	case *ResultMessage:
+		emitEvent(sink, Event{
+			Type:          EventTypeResult,
+			SessionID:     sessionID,
+			CorrelationID: correlationID,
+			Data:          m,
+		})
+	}
+}
+
+// messageIdentity returns the session ID, correlation ID, and wire type of msg.
+func messageIdentity(msg Message) (sessionID, correlationID, messageType string) {
+	switch m := msg.(type) {
+	case *UserMessage:
+		return m.SessionID, m.CorrelationID, "user"
+	case *AssistantMessage:
+		return m.SessionID, m.CorrelationID, "assistant"
+	case *SystemMessage:
+		sessionID, _ := m.Data["session_id"].(string)
+		return sessionID, m.CorrelationID, "system"
+	case *ResultMessage:
+		return m.SessionID, m.CorrelationID, "result"
+	case *StreamEvent:
+		return m.SessionID, m.CorrelationID, "stream_event"
+	}
+	return "", "", ""
+}
+
+// HTTPEventSinkOptions configures an HTTPEventSink.
+type HTTPEventSinkOptions struct {
+	Client    *http.Client      // Default: a client with a 10s timeout
+	Headers   map[string]string // Extra request headers, e.g. Authorization
+	QueueSize int               // Events buffered before new ones are dropped (default 1000)
+	BatchSize int               // Maximum events per POST (default 100)
+	OnError   func(err error)   // Called when a POST fails; events in it are lost
+}
+
+// HTTPEventSink POSTs events as JSON arrays to a URL from a background
+// goroutine. Emit never blocks: when the queue is full the event is dropped
+// and counted in Dropped. Call Close to flush queued events.
+type HTTPEventSink struct {
+	url     string
+	opts    HTTPEventSinkOptions
+	queue   chan Event
+	done    chan struct{}
+	once    sync.Once
+	mu      sync.RWMutex // Guards queue against Emit after Close
+	closed  bool
+	dropped atomic.Int64
+}
+
+// NewHTTPEventSink creates a sink that delivers events to url and starts its worker.
+func NewHTTPEventSink(url string, opts HTTPEventSinkOptions) *HTTPEventSink {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1000
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	s := &HTTPEventSink{
+		url:   url,
+		opts:  opts,
+		queue: make(chan Event, opts.QueueSize),
+		done:  make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Emit queues event for delivery.
+func (s *HTTPEventSink) Emit(event Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.dropped.Add(1)
+		return
+	}
+	select {
+	case s.queue <- event:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped returns how many events were discarded because the queue was full
+// or the sink was closed.
+func (s *HTTPEventSink) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close stops accepting events and waits until queued events are delivered
+// or ctx is done.
+func (s *HTTPEventSink) Close(ctx context.Context) error {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		close(s.queue)
+		s.mu.Unlock()
+	})
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run delivers queued events in batches until the queue is closed.
+func (s *HTTPEventSink) run() {
+	defer close(s.done)
+
+	for event := range s.queue {
+		batch := []Event{event}
+	fill:
+		for len(batch) < s.opts.BatchSize {
+			select {
+			case next, ok := <-s.queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, next)
+			default:
+				break fill
+			}
+		}
+		if err := s.post(batch); err != nil && s.opts.OnError != nil {
+			s.opts.OnError(err)
+		}
+	}
+}
+
+// post sends one batch.
+func (s *HTTPEventSink) post(batch []Event) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode events: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create event request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post events: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("event sink returned status %d", resp.StatusCode)
+	}
+	return nil
+}
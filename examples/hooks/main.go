@@ -84,13 +84,7 @@ func examplePreToolUse() {
 		for _, pattern := range blockPatterns {
 			if strings.Contains(command, pattern) {
 				log.Printf("Blocked command: %s", command)
-				return claude.HookJSONOutput{
-					HookSpecificOutput: map[string]interface{}{
-						"hookEventName":            "PreToolUse",
-						"permissionDecision":       "deny",
-						"permissionDecisionReason": fmt.Sprintf("Command contains invalid pattern: %s", pattern),
-					},
-				}, nil
+				return claude.DenyToolUse(fmt.Sprintf("Command contains invalid pattern: %s", pattern)), nil
 			}
 		}
 
@@ -147,12 +141,7 @@ func exampleUserPromptSubmit() {
 	fmt.Println("This example shows how a UserPromptSubmit hook can add context.")
 
 	addCustomInstructions := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
-		return claude.HookJSONOutput{
-			HookSpecificOutput: map[string]interface{}{
-				"hookEventName":     "SessionStart",
-				"additionalContext": "My favorite color is hot pink",
-			},
-		}, nil
+		return claude.AddContext("My favorite color is hot pink"), nil
 	}
 
 	options := &claude.ClaudeAgentOptions{
@@ -193,17 +182,8 @@ func examplePostToolUse() {
 
 		// If the tool produced an error, add helpful context
 		if strings.Contains(strings.ToLower(fmt.Sprint(toolResponse)), "error") {
-			reason := "Tool execution failed - consider checking the command syntax"
-			systemMessage := "⚠️ The command produced an error"
-
-			return claude.HookJSONOutput{
-				SystemMessage: &systemMessage,
-				Reason:        &reason,
-				HookSpecificOutput: map[string]interface{}{
-					"hookEventName":     "PostToolUse",
-					"additionalContext": "The command encountered an error. You may want to try a different approach.",
-				},
-			}, nil
+			output := claude.AddContext("The command encountered an error. You may want to try a different approach.")
+			return output.WithSystemMessage("⚠️ The command produced an error"), nil
 		}
 
 		return claude.HookJSONOutput{}, nil
@@ -252,31 +232,13 @@ func exampleDecisionFields() {
 			filePath, _ := toolInput["file_path"].(string)
 			if strings.Contains(strings.ToLower(filePath), "important") {
 				log.Printf("Blocked Write to: %s", filePath)
-				reason := "Writes to files containing 'important' in the name are not allowed for safety"
-				systemMessage := "🚫 Write operation blocked by security policy"
-
-				return claude.HookJSONOutput{
-					Reason:        &reason,
-					SystemMessage: &systemMessage,
-					HookSpecificOutput: map[string]interface{}{
-						"hookEventName":            "PreToolUse",
-						"permissionDecision":       "deny",
-						"permissionDecisionReason": "Security policy blocks writes to important files",
-					},
-				}, nil
+				output := claude.DenyToolUse("Writes to files containing 'important' in the name are not allowed for safety")
+				return output.WithSystemMessage("🚫 Write operation blocked by security policy"), nil
 			}
 		}
 
 		// Allow everything else explicitly
-		reason := "Tool use approved after security review"
-		return claude.HookJSONOutput{
-			Reason: &reason,
-			HookSpecificOutput: map[string]interface{}{
-				"hookEventName":            "PreToolUse",
-				"permissionDecision":       "allow",
-				"permissionDecisionReason": "Tool passed security checks",
-			},
-		}, nil
+		return claude.AllowToolUse().WithSystemMessage("Tool passed security checks"), nil
 	}
 
 	model := "claude-sonnet-4-5-20250929"
@@ -298,20 +298,8 @@ func example7AsyncIterablePrompt() {
 	promptCh := make(chan map[string]interface{}, 2)
 	go func() {
 		defer close(promptCh)
-		promptCh <- map[string]interface{}{
-			"type": "user",
-			"message": map[string]interface{}{
-				"role":    "user",
-				"content": "Hello",
-			},
-		}
-		promptCh <- map[string]interface{}{
-			"type": "user",
-			"message": map[string]interface{}{
-				"role":    "user",
-				"content": "What is Go?",
-			},
-		}
+		promptCh <- claude.NewUserMessage("Hello")
+		promptCh <- claude.NewUserMessage("What is Go?")
 	}()
 
 	msgCh, errCh, err := claude.QueryStream(ctx, promptCh, nil, nil)
@@ -19,23 +19,10 @@ This example demonstrates how to use tool permission callbacks to control
 which tools Claude can use and modify their inputs.
 */
 
-// Track tool usage for demonstration
-type ToolUsageLog struct {
-	Tool        string                    `json:"tool"`
-	Input       map[string]interface{}    `json:"input"`
-	Suggestions []claude.PermissionUpdate `json:"suggestions"`
-}
-
-var toolUsageLog []ToolUsageLog
+// Audit entries of every tool invocation, for the summary
+var toolUsageLog []claude.AuditEntry
 
 func myPermissionCallback(ctx context.Context, toolName string, inputData map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
-	// Log the tool request
-	toolUsageLog = append(toolUsageLog, ToolUsageLog{
-		Tool:        toolName,
-		Input:       inputData,
-		Suggestions: permCtx.Suggestions,
-	})
-
 	inputJSON, _ := json.MarshalIndent(inputData, "   ", "  ")
 	fmt.Printf("\n🔧 Tool Permission Request: %s\n", toolName)
 	fmt.Printf("   Input: %s\n", string(inputJSON))
@@ -133,6 +120,11 @@ func main() {
 		CanUseTool:     myPermissionCallback,
 		PermissionMode: &permMode, // Ensure callbacks are invoked
 		Cwd:            &cwd,
+		// Record every tool invocation for the summary
+		AuditLog: &claude.AuditLog{Sink: claude.AuditFunc(func(entry claude.AuditEntry) error {
+			toolUsageLog = append(toolUsageLog, entry)
+			return nil
+		})},
 	}
 
 	// Create a query that will use multiple tools
@@ -186,8 +178,14 @@ func main() {
 	fmt.Println("Tool Usage Summary")
 	fmt.Println(strings.Repeat("=", 60))
 	for i, usage := range toolUsageLog {
-		fmt.Printf("%d. Tool: %s\n", i+1, usage.Tool)
+		fmt.Printf("%d. Tool: %s (%dms)\n", i+1, usage.ToolName, usage.DurationMS)
 		inputJSON, _ := json.MarshalIndent(usage.Input, "   ", "  ")
 		fmt.Printf("   Input: %s\n", string(inputJSON))
+		if usage.Decision != "" {
+			fmt.Printf("   Decision: %s %s\n", usage.Decision, usage.DecisionMessage)
+		}
+		if usage.IsError {
+			fmt.Println("   Result: error")
+		}
 	}
 }
@@ -0,0 +1,154 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Feature is an SDK option whose CLI flag needs a minimum CLI version.
+type Feature string
+
+const (
+	FeatureMaxBudget       Feature = "max_budget_usd"           // MaxBudgetUSD (--max-budget-usd)
+	FeaturePartialMessages Feature = "include_partial_messages" // IncludePartialMessages (--include-partial-messages)
+	FeaturePlugins         Feature = "plugins"                  // Plugins (--plugin-dir)
+	FeatureForkSession     Feature = "fork_session"             // ForkSession (--fork-session)
+	FeatureFallbackModel   Feature = "fallback_model"           // FallbackModel (--fallback-model)
+	FeatureAgents          Feature = "agents"                   // Agents (--agents)
+)
+
+// featureRequirements lists each feature's option, flag, and the first CLI
+// version known to accept it, in the order FeatureMatrix reports them.
+var featureRequirements = []FeatureSupport{
+	{Feature: FeatureFallbackModel, Option: "FallbackModel", Flag: "--fallback-model", MinVersion: "1.0.57"},
+	{Feature: FeaturePartialMessages, Option: "IncludePartialMessages", Flag: "--include-partial-messages", MinVersion: "1.0.86"},
+	{Feature: FeatureForkSession, Option: "ForkSession", Flag: "--fork-session", MinVersion: "1.0.94"},
+	{Feature: FeatureAgents, Option: "Agents", Flag: "--agents", MinVersion: "2.0.0"},
+	{Feature: FeaturePlugins, Option: "Plugins", Flag: "--plugin-dir", MinVersion: "2.0.12"},
+	{Feature: FeatureMaxBudget, Option: "MaxBudgetUSD", Flag: "--max-budget-usd", MinVersion: "2.0.28"},
+}
+
+// FeatureSupport describes whether one Feature works with a CLI version.
+type FeatureSupport struct {
+	Feature    Feature
+	Option     string // The ClaudeAgentOptions field
+	Flag       string // The CLI flag it is passed as
+	MinVersion string // First CLI version that accepts the flag
+	Supported  bool
+}
+
+// Features is the result of FeatureMatrix.
+type Features struct {
+	CLIVersion string           // As given; empty if unknown
+	Support    []FeatureSupport // One entry per Feature
+}
+
+// FeatureMatrix describes which version-dependent options work with the
+// given CLI version, e.g. "2.0.14" or the output of `claude -v`. An empty or
+// unparseable version is treated as the newest CLI, so every feature is
+// supported. Use DetectCLIVersion to find the installed version.
+//
+// The SDK uses the same matrix when it starts the CLI: flags for options the
+// detected CLI does not support are left out, with a warning logged to
+// ClaudeAgentOptions.Logger, instead of failing with an unknown flag.
+//
+// Example preflight check:
+//
+//	version, err := claude.DetectCLIVersion(ctx, "")
+//	if err != nil { ... }
+//	for _, f := range claude.FeatureMatrix(version).Unsupported(options) {
+//	    log.Printf("%s needs Claude Code %s or later", f.Option, f.MinVersion)
+//	}
+func FeatureMatrix(cliVersion string) Features {
+	version := parseCLIVersion(cliVersion)
+	features := Features{CLIVersion: cliVersion, Support: make([]FeatureSupport, len(featureRequirements))}
+	for i, req := range featureRequirements {
+		req.Supported = version == "" || compareVersions(version, req.MinVersion) >= 0
+		features.Support[i] = req
+	}
+	return features
+}
+
+// Supports reports whether feature works with the CLI version. Unknown
+// features are reported as supported.
+func (f Features) Supports(feature Feature) bool {
+	for _, s := range f.Support {
+		if s.Feature == feature {
+			return s.Supported
+		}
+	}
+	return true
+}
+
+// Unsupported returns the features options uses that the CLI version does
+// not support.
+func (f Features) Unsupported(options *ClaudeAgentOptions) []FeatureSupport {
+	if options == nil {
+		return nil
+	}
+	var unsupported []FeatureSupport
+	for _, s := range f.Support {
+		if !s.Supported && featureUsed(s.Feature, options) {
+			unsupported = append(unsupported, s)
+		}
+	}
+	return unsupported
+}
+
+// featureUsed reports whether options sets feature's option.
+func featureUsed(feature Feature, options *ClaudeAgentOptions) bool {
+	switch feature {
+	case FeatureMaxBudget:
+		return options.MaxBudgetUSD != nil
+	case FeaturePartialMessages:
+		return options.IncludePartialMessages
+	case FeaturePlugins:
+		return len(options.Plugins) > 0
+	case FeatureForkSession:
+		return options.ForkSession
+	case FeatureFallbackModel:
+		return options.FallbackModel != nil
+	case FeatureAgents:
+		return len(options.Agents) > 0
+	}
+	return false
+}
+
+// cliVersionPattern extracts the semantic version from `claude -v` output.
+var cliVersionPattern = regexp.MustCompile(`([0-9]+\.[0-9]+\.[0-9]+)`)
+
+// parseCLIVersion returns the x.y.z version in s, or "".
+func parseCLIVersion(s string) string {
+	match := cliVersionPattern.FindStringSubmatch(s)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// DetectCLIVersion runs `claude -v` and returns the CLI's version, e.g.
+// "2.0.14". An empty cliPath finds the CLI as Query does.
+func DetectCLIVersion(ctx context.Context, cliPath string) (string, error) {
+	if cliPath == "" {
+		var err error
+		if cliPath, err = findCLI(); err != nil {
+			return "", err
+		}
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(checkCtx, cliPath, "-v").Output()
+	if err != nil {
+		return "", NewCLIConnectionError("failed to run claude -v", err)
+	}
+	version := parseCLIVersion(strings.TrimSpace(string(output)))
+	if version == "" {
+		return "", NewCLIConnectionError(fmt.Sprintf("cannot parse CLI version from %q", strings.TrimSpace(string(output))), nil)
+	}
+	return version, nil
+}
@@ -0,0 +1,145 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultFinalSummaryMinToolUses is the number of tool calls that makes a
+// query tool-heavy when FinalSummaryMinToolUses is unset.
+const defaultFinalSummaryMinToolUses = 3
+
+// restoreModelTimeout bounds switching back from FinalSummaryModel.
+const restoreModelTimeout = 10 * time.Second
+
+// finalSummaryPrompt asks for the summary attached to FinalSummary.Text.
+const finalSummaryPrompt = "Summarize concisely what you just did: the actions you took and the files you changed. " +
+	"Reply with the summary only; do not use any tools."
+
+// FinalSummary is the report attached to a ResultMessage when
+// ClaudeAgentOptions.FinalSummary is enabled.
+type FinalSummary struct {
+	Text         string         // Claude's summary of the actions taken and files changed
+	ToolUses     int            // Tool calls made by the query, including subagents'
+	FilesChanged []string       // Paths passed to Write, Edit, MultiEdit, and NotebookEdit, in order of first use
+	Result       *ResultMessage // Result of the summary turn itself, e.g. for its cost
+}
+
+// fileEditTools are the built-in tools that write the file named in their
+// file_path or notebook_path input.
+var fileEditTools = []string{"Write", "Edit", "MultiEdit", "NotebookEdit"}
+
+// actionTracker records the tool calls of one query.
+type actionTracker struct {
+	toolUses int
+	files    []string
+}
+
+// observe records the tool calls in msg.
+func (a *actionTracker) observe(msg Message) {
+	assistant, ok := msg.(*AssistantMessage)
+	if !ok {
+		return
+	}
+	for _, block := range assistant.Content {
+		toolUse, ok := block.(ToolUseBlock)
+		if !ok {
+			continue
+		}
+		a.toolUses++
+		if !slices.Contains(fileEditTools, toolUse.Name) {
+			continue
+		}
+		path, _ := toolUse.Input["file_path"].(string)
+		if path == "" {
+			path, _ = toolUse.Input["notebook_path"].(string)
+		}
+		if path != "" && !slices.Contains(a.files, path) {
+			a.files = append(a.files, path)
+		}
+	}
+}
+
+// finalSummaryWanted reports whether a query ending in result, with the tool
+// calls in actions, gets a FinalSummary.
+func finalSummaryWanted(options *ClaudeAgentOptions, result *ResultMessage, actions *actionTracker) bool {
+	if !options.FinalSummary || result.IsError {
+		return false
+	}
+	minToolUses := options.FinalSummaryMinToolUses
+	if minToolUses <= 0 {
+		minToolUses = defaultFinalSummaryMinToolUses
+	}
+	return actions.toolUses >= minToolUses
+}
+
+// finalSummary runs the summary turn for a query with the tool calls in
+// actions. Its messages are not delivered to the query's caller.
+func (c *ClaudeSDKClient) finalSummary(ctx context.Context, actions *actionTracker) (*FinalSummary, error) {
+	if model := c.connection().options.FinalSummaryModel; model != "" {
+		previous := c.currentModel()
+		if err := c.SetModel(ctx, model); err != nil {
+			return nil, err
+		}
+		defer c.restoreModel(ctx, previous)
+	}
+	if err := c.QueryWithSession(ctx, finalSummaryPrompt, c.conversation()); err != nil {
+		return nil, err
+	}
+
+	summary := &FinalSummary{ToolUses: actions.toolUses, FilesChanged: actions.files}
+	var text strings.Builder
+	for msg := range c.ReceiveResponse(ctx) {
+		switch m := msg.(type) {
+		case *AssistantMessage:
+			for _, block := range m.Content {
+				if t, ok := block.(TextBlock); ok {
+					text.WriteString(t.Text)
+				}
+			}
+		case *ResultMessage:
+			summary.Result = m
+		}
+	}
+	if summary.Result == nil {
+		if err := c.Err(); err != nil {
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrClosed
+	}
+	summary.Text = strings.TrimSpace(text.String())
+	return summary, nil
+}
+
+// currentModel returns the model the session uses: the last one set with
+// SetModel, by the caller or a budget step, else the configured one.
+func (c *ClaudeSDKClient) currentModel() string {
+	conn := c.connection()
+	if model := conn.handler.currentModel(); model != "" {
+		return model
+	}
+	if model := c.budget.stepModel(); model != "" {
+		return model
+	}
+	if conn.options.Model != nil && *conn.options.Model != "" {
+		return *conn.options.Model
+	}
+	return "default"
+}
+
+// restoreModel switches back to model after a summary turn. It does not
+// use the query's ctx, which may be done by then; a failure is reported
+// through the error pipeline, as the session stays on FinalSummaryModel.
+func (c *ClaudeSDKClient) restoreModel(ctx context.Context, model string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), restoreModelTimeout)
+	defer cancel()
+	if err := c.SetModel(ctx, model); err != nil {
+		c.connection().errs.warn(fmt.Errorf("restoring model %q after the final summary: %w", model, err))
+	}
+}
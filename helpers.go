@@ -6,6 +6,7 @@ import "fmt"
 type hookMatcherInternal struct {
 	Matcher string
 	Hooks   []HookCallback
+	Agent   string
 }
 
 // convertHooksToInternal converts public hooks to internal format used by queryHandler
@@ -25,6 +26,7 @@ func convertHooksToInternal(hooks map[HookEvent][]HookMatcher) map[string][]hook
 			internal[i] = hookMatcherInternal{
 				Matcher: m.Matcher,
 				Hooks:   m.Hooks,
+				Agent:   m.Agent,
 			}
 		}
 		internalHooks[string(event)] = internal
@@ -32,6 +34,29 @@ func convertHooksToInternal(hooks map[HookEvent][]HookMatcher) map[string][]hook
 	return internalHooks
 }
 
+// Default channel buffer sizes. See ClaudeAgentOptions for the corresponding knobs.
+const (
+	defaultMessageChannelBufferSize   = 100
+	defaultOutputChannelBufferSize    = 10
+	defaultTransportChannelBufferSize = 10
+)
+
+// bufferSizeOrDefault returns *size if it is set and positive, otherwise def.
+func bufferSizeOrDefault(size *int, def int) int {
+	if size != nil && *size > 0 {
+		return *size
+	}
+	return def
+}
+
+// outputBufferSize returns the buffer size for channels handed to callers.
+func outputBufferSize(options *ClaudeAgentOptions) int {
+	if options == nil {
+		return defaultOutputChannelBufferSize
+	}
+	return bufferSizeOrDefault(options.OutputChannelBufferSize, defaultOutputChannelBufferSize)
+}
+
 // extractSdkMcpServers extracts SDK MCP servers from the McpServers map
 func extractSdkMcpServers(servers map[string]McpServerConfig) map[string]interface{} {
 	if servers == nil {
@@ -41,7 +66,12 @@ func extractSdkMcpServers(servers map[string]McpServerConfig) map[string]interfa
 	sdkMcpServers := make(map[string]interface{})
 	for name, config := range servers {
 		if sdkConfig, ok := config.(McpSdkServerConfig); ok {
-			sdkMcpServers[name] = sdkConfig.Instance
+			handler, isHandler := sdkConfig.Instance.(mcpRequestHandler)
+			if sdkConfig.Executor != nil && isHandler {
+				sdkMcpServers[name] = newMcpExecutor(handler, *sdkConfig.Executor)
+			} else {
+				sdkMcpServers[name] = sdkConfig.Instance
+			}
 		}
 	}
 
@@ -61,6 +91,38 @@ func validateAndConfigurePermissions(options *ClaudeAgentOptions, isStreaming bo
 		return &ClaudeAgentOptions{}, nil
 	}
 
+	// Before the options below add hooks of their own
+	var err error
+	if options, err = applyHookMatchers(options); err != nil {
+		return nil, err
+	}
+
+	if options.ReadOnly {
+		options = applyReadOnly(options, isStreaming)
+	}
+
+	if len(options.ToolLimits) > 0 {
+		var err error
+		if options, err = applyToolLimits(options, isStreaming); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.PolicyWatcher != nil {
+		var err error
+		if options, err = applyPolicy(options, isStreaming); err != nil {
+			return nil, err
+		}
+	}
+
+	// Applied last so the mocks answer before other hooks see the call
+	if options.ToolMocks != nil {
+		var err error
+		if options, err = applyToolMocks(options, isStreaming); err != nil {
+			return nil, err
+		}
+	}
+
 	if options.CanUseTool != nil {
 		// canUseTool requires streaming mode
 		if !isStreaming {
@@ -0,0 +1,139 @@
+package claude
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryEntry is a message retained in the client's history buffer.
+type HistoryEntry struct {
+	Seq        uint64    // Monotonic sequence number, starting at 1
+	Message    Message   // The parsed message
+	ReceivedAt time.Time // When the SDK received the message
+	// Compacted is set once the CLI has compacted the conversation after
+	// this message, replacing it in Claude's context with a summary; see
+	// ClaudeSDKClient.Compactions
+	Compacted bool
+}
+
+// messageHistory is a bounded, concurrency-safe buffer of parsed messages.
+type messageHistory struct {
+	mu      sync.RWMutex
+	entries []HistoryEntry
+	limit   int
+	nextSeq uint64
+	changed chan struct{} // Closed and replaced by each add
+}
+
+// newMessageHistory creates a history retaining at most limit messages.
+// Returns nil (history disabled) when limit is not positive.
+func newMessageHistory(limit int) *messageHistory {
+	if limit <= 0 {
+		return nil
+	}
+	return &messageHistory{limit: limit, nextSeq: 1, changed: make(chan struct{})}
+}
+
+// add appends msg, evicting the oldest entry when the buffer is full.
+func (h *messageHistory) add(msg Message) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if isCompactBoundary(msg) {
+		for i := range h.entries {
+			h.entries[i].Compacted = true
+		}
+	}
+	h.entries = append(h.entries, HistoryEntry{Seq: h.nextSeq, Message: msg, ReceivedAt: time.Now()})
+	h.nextSeq++
+	if len(h.entries) > h.limit {
+		// Copy to release the evicted entries' backing array over time
+		h.entries = append([]HistoryEntry(nil), h.entries[len(h.entries)-h.limit:]...)
+	}
+	close(h.changed)
+	h.changed = make(chan struct{})
+}
+
+// snapshot returns a copy of all retained entries.
+func (h *messageHistory) snapshot() []HistoryEntry {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	entries := make([]HistoryEntry, len(h.entries))
+	copy(entries, h.entries)
+	return entries
+}
+
+// History returns the messages retained in the client's history buffer, oldest first.
+//
+// History is disabled unless ClaudeAgentOptions.HistorySize is set, in which
+// case the most recent HistorySize messages received via ReceiveMessages(),
+// ReceiveResponse(), or Query() are retained. This lets late-joining
+// components (e.g. a UI opened mid-conversation) catch up without replaying
+// the transport.
+//
+// Returns nil if history is disabled.
+func (c *ClaudeSDKClient) History() []Message {
+	entries := c.history.snapshot()
+	if entries == nil {
+		return nil
+	}
+
+	messages := make([]Message, len(entries))
+	for i, entry := range entries {
+		messages[i] = entry.Message
+	}
+	return messages
+}
+
+// HistoryEntries returns the retained history with sequence numbers and receive times.
+func (c *ClaudeSDKClient) HistoryEntries() []HistoryEntry {
+	return c.history.snapshot()
+}
+
+// LastAssistantText returns the concatenated text blocks of the most recent
+// AssistantMessage in history, or an empty string if there is none.
+func (c *ClaudeSDKClient) LastAssistantText() string {
+	entries := c.history.snapshot()
+	for i := len(entries) - 1; i >= 0; i-- {
+		assistantMsg, ok := entries[i].Message.(*AssistantMessage)
+		if !ok {
+			continue
+		}
+
+		var text strings.Builder
+		for _, block := range assistantMsg.Content {
+			if textBlock, ok := block.(TextBlock); ok {
+				text.WriteString(textBlock.Text)
+			}
+		}
+		return text.String()
+	}
+	return ""
+}
+
+// ToolUses returns every tool use recorded in history, oldest first.
+func (c *ClaudeSDKClient) ToolUses() []ToolUseBlock {
+	var toolUses []ToolUseBlock
+	for _, entry := range c.history.snapshot() {
+		assistantMsg, ok := entry.Message.(*AssistantMessage)
+		if !ok {
+			continue
+		}
+		for _, block := range assistantMsg.Content {
+			if toolUse, ok := block.(ToolUseBlock); ok {
+				toolUses = append(toolUses, toolUse)
+			}
+		}
+	}
+	return toolUses
+}
@@ -0,0 +1,117 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Keys of HookJSONOutput.HookSpecificOutput understood by Claude Code.
+const (
+	HookOutputEventName                = "hookEventName"            // The HookEvent the output answers; filled in by the SDK if omitted
+	HookOutputPermissionDecision       = "permissionDecision"       // PreToolUse: a PermissionBehavior
+	HookOutputPermissionDecisionReason = "permissionDecisionReason" // PreToolUse: shown to Claude for deny, to the user otherwise
+	HookOutputAdditionalContext        = "additionalContext"        // PostToolUse, UserPromptSubmit, SessionStart: text added to the conversation
+	HookOutputDecision                 = "decision"                 // PermissionRequest: {"behavior": "allow" or "deny", "message": ...}
+)
+
+// hookDecisionBlock is HookJSONOutput.Decision for blocking.
+const hookDecisionBlock = "block"
+
+// AllowToolUse returns PreToolUse hook output that approves the tool call,
+// skipping the permission prompt.
+func AllowToolUse() HookJSONOutput {
+	return toolUseDecision(PermissionBehaviorAllow, "")
+}
+
+// DenyToolUse returns PreToolUse hook output that prevents the tool call.
+// reason is shown to Claude.
+//
+// Example:
+//
+//	if strings.Contains(command, "rm -rf") {
+//	    return claude.DenyToolUse("Recursive deletes are not allowed"), nil
+//	}
+func DenyToolUse(reason string) HookJSONOutput {
+	return toolUseDecision(PermissionBehaviorDeny, reason)
+}
+
+// AskToolUse returns PreToolUse hook output that asks the user to confirm
+// the tool call. reason is shown to the user.
+func AskToolUse(reason string) HookJSONOutput {
+	return toolUseDecision(PermissionBehaviorAsk, reason)
+}
+
+// toolUseDecision returns PreToolUse hook output with a permission decision.
+func toolUseDecision(behavior PermissionBehavior, reason string) HookJSONOutput {
+	output := map[string]interface{}{
+		HookOutputEventName:          string(HookEventPreToolUse),
+		HookOutputPermissionDecision: string(behavior),
+	}
+	if reason != "" {
+		output[HookOutputPermissionDecisionReason] = reason
+	}
+	return HookJSONOutput{HookSpecificOutput: output}
+}
+
+// AddContext returns hook output that adds text to the conversation for
+// Claude to consider, from a PostToolUse, UserPromptSubmit, or SessionStart
+// hook.
+//
+// Example:
+//
+//	return claude.AddContext("The user's timezone is Europe/Berlin"), nil
+func AddContext(text string) HookJSONOutput {
+	return HookJSONOutput{
+		HookSpecificOutput: map[string]interface{}{HookOutputAdditionalContext: text},
+	}
+}
+
+// Block returns hook output with decision "block": a PostToolUse hook
+// feeds reason back to Claude, and a Stop or SubagentStop hook makes Claude
+// continue working with reason as its instructions.
+func Block(reason string) HookJSONOutput {
+	decision := hookDecisionBlock
+	return HookJSONOutput{Decision: &decision, Reason: &reason}
+}
+
+// StopExecution returns hook output that stops Claude after the hook.
+// reason is shown to the user, not to Claude.
+func StopExecution(reason string) HookJSONOutput {
+	continueExecution := false
+	return HookJSONOutput{Continue: &continueExecution, StopReason: &reason}
+}
+
+// WithSystemMessage returns a copy of o that also shows message to the user.
+//
+// Example:
+//
+//	return claude.DenyToolUse("Writes to .env are blocked").WithSystemMessage("Blocked a write to .env"), nil
+func (o HookJSONOutput) WithSystemMessage(message string) HookJSONOutput {
+	o.SystemMessage = &message
+	return o
+}
+
+// fillHookEventName sets hookEventName in a hook's serialized output from
+// the event in its input, if the hook set event-specific output without it.
+func fillHookEventName(response, input map[string]interface{}) {
+	specific, ok := response["hookSpecificOutput"].(map[string]interface{})
+	if !ok || specific[HookOutputEventName] != nil {
+		return
+	}
+	if event, ok := input["hook_event_name"].(string); ok {
+		specific[HookOutputEventName] = event
+	}
+}
+
+// DecodeHookInput decodes a hook's raw input into target, the typed input
+// of its event, e.g. a *PreCompactHookInput.
+func DecodeHookInput(input map[string]interface{}, target interface{}) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode hook input: %w", err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to decode hook input: %w", err)
+	}
+	return nil
+}
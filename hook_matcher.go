@@ -0,0 +1,122 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// globMatcherPattern matches HookMatcher.Matcher values that are tool name
+// globs: name characters with at least one * or ?. Matchers with other
+// regular expression syntax, e.g. "mcp__.*", stay regular expressions.
+var globMatcherPattern = regexp.MustCompile(`^[A-Za-z0-9_\-*?]*[*?][A-Za-z0-9_\-*?]*$`)
+
+// isSDKHookMatcher reports whether matcher is a glob or a /regex/, which
+// the CLI does not understand and the SDK evaluates itself.
+func isSDKHookMatcher(matcher string) bool {
+	if matcher == "*" {
+		return false
+	}
+	return isRegexMatcher(matcher) || globMatcherPattern.MatchString(matcher)
+}
+
+// isRegexMatcher reports whether matcher is a /regex/.
+func isRegexMatcher(matcher string) bool {
+	return len(matcher) >= 2 && strings.HasPrefix(matcher, "/") && strings.HasSuffix(matcher, "/")
+}
+
+// compileHookMatcher compiles a hook matcher. "" and "*" match everything.
+// "/regex/" is searched for anywhere in the subject, so "/^mcp__/" matches
+// every MCP tool. A glob of name characters, * (any run of characters), and
+// ? (one character), such as "mcp__calc__*", must match the whole subject.
+// Anything else must match the whole subject as a regular expression, so
+// "Write|Edit" matches either tool.
+func compileHookMatcher(matcher string) (*regexp.Regexp, error) {
+	var expr string
+	switch {
+	case matcher == "" || matcher == "*":
+		return nil, nil
+	case isRegexMatcher(matcher):
+		expr = matcher[1 : len(matcher)-1]
+	case globMatcherPattern.MatchString(matcher):
+		expr = regexp.QuoteMeta(matcher)
+		expr = strings.ReplaceAll(expr, `\*`, ".*")
+		expr = strings.ReplaceAll(expr, `\?`, ".")
+		expr = "^" + expr + "$"
+	default:
+		expr = "^(?:" + matcher + ")$"
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hook matcher %q: %w", matcher, err)
+	}
+	return pattern, nil
+}
+
+// hookMatchSubject returns what a hook's matcher is matched against: the
+// tool name, or the source of SessionStart and the type of Notification.
+func hookMatchSubject(input map[string]interface{}) (string, bool) {
+	if toolName, ok := input["tool_name"].(string); ok {
+		return toolName, true
+	}
+	switch input["hook_event_name"] {
+	case string(HookEventSessionStart):
+		source, ok := input["source"].(string)
+		return source, ok
+	case string(HookEventNotification):
+		notificationType, ok := input["notification_type"].(string)
+		return notificationType, ok
+	}
+	return "", false
+}
+
+// applyHookMatchers returns a copy of options in which hooks with glob or
+// /regex/ matchers are registered with the CLI for everything and filtered
+// SDK-side, or options itself if there are none.
+func applyHookMatchers(options *ClaudeAgentOptions) (*ClaudeAgentOptions, error) {
+	needed := false
+	for _, matchers := range options.Hooks {
+		for _, matcher := range matchers {
+			needed = needed || isSDKHookMatcher(matcher.Matcher)
+		}
+	}
+	if !needed {
+		return options, nil
+	}
+
+	newOpts := *options
+	newOpts.Hooks = make(map[HookEvent][]HookMatcher, len(options.Hooks))
+	for event, matchers := range options.Hooks {
+		converted := make([]HookMatcher, len(matchers))
+		for i, matcher := range matchers {
+			converted[i] = matcher
+			if !isSDKHookMatcher(matcher.Matcher) {
+				continue
+			}
+			pattern, err := compileHookMatcher(matcher.Matcher)
+			if err != nil {
+				return nil, err
+			}
+			callbacks := make([]HookCallback, len(matcher.Hooks))
+			for j, callback := range matcher.Hooks {
+				callbacks[j] = filterHook(pattern, callback)
+			}
+			converted[i] = HookMatcher{Hooks: callbacks, Agent: matcher.Agent}
+		}
+		newOpts.Hooks[event] = converted
+	}
+	return &newOpts, nil
+}
+
+// filterHook wraps callback so it only runs when pattern matches the
+// subject of the call. Other calls get an empty output, which lets
+// execution continue; calls without a subject always run, as in the CLI.
+func filterHook(pattern *regexp.Regexp, callback HookCallback) HookCallback {
+	return func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
+		if subject, ok := hookMatchSubject(input); ok && !pattern.MatchString(subject) {
+			return HookJSONOutput{}, nil
+		}
+		return callback(ctx, input, toolUseID, hookCtx)
+	}
+}
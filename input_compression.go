@@ -0,0 +1,109 @@
+package claude
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+)
+
+// inputEncodingGzip is the input encoding for gzip-compressed messages. A CLI
+// that accepts it lists it in the "input_encodings" of its initialize
+// response; compressed messages are framed as
+//
+//	{"type":"compressed","encoding":"gzip","data":"<base64 of gzipped JSON>"}
+const inputEncodingGzip = "gzip"
+
+// inputCompressor gzips large user messages written to the CLI once the CLI
+// has advertised support during initialize. Until then, or if the CLI never
+// does, messages are written as plain JSON.
+type inputCompressor struct {
+	threshold int
+	enabled   atomic.Bool
+}
+
+// newInputCompressor returns a compressor if options.CompressInputThreshold
+// is set, or nil.
+func newInputCompressor(options *ClaudeAgentOptions) *inputCompressor {
+	if options == nil || options.CompressInputThreshold == nil || *options.CompressInputThreshold <= 0 {
+		return nil
+	}
+	return &inputCompressor{threshold: *options.CompressInputThreshold}
+}
+
+// negotiate enables compression if the initialize response lists gzip.
+func (c *inputCompressor) negotiate(initResponse map[string]interface{}) {
+	if c == nil {
+		return
+	}
+	encodings, _ := initResponse["input_encodings"].([]interface{})
+	for _, encoding := range encodings {
+		if encoding == inputEncodingGzip {
+			c.enabled.Store(true)
+			return
+		}
+	}
+}
+
+// encode returns data compressed and framed if it is a user message over the
+// threshold and the CLI supports compression; otherwise data unchanged.
+func (c *inputCompressor) encode(data string) string {
+	if c == nil || !c.enabled.Load() || len(data) <= c.threshold {
+		return data
+	}
+	payload := strings.TrimSuffix(data, "\n")
+	var header struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal([]byte(payload), &header) != nil || header.Type != "user" {
+		// Control messages stay readable and small
+		return data
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(payload)); err != nil {
+		return data
+	}
+	if err := gz.Close(); err != nil {
+		return data
+	}
+	framed, err := json.Marshal(map[string]string{
+		"type":     "compressed",
+		"encoding": inputEncodingGzip,
+		"data":     base64.StdEncoding.EncodeToString(buf.Bytes()),
+	})
+	if err != nil || len(framed) >= len(payload) {
+		// Incompressible input is cheaper to send as is
+		return data
+	}
+	return string(framed) + "\n"
+}
+
+// compressingTransport compresses outbound writes with an inputCompressor.
+type compressingTransport struct {
+	Transport
+	compressor *inputCompressor
+}
+
+func (t *compressingTransport) Write(ctx context.Context, data string) error {
+	return t.Transport.Write(ctx, t.compressor.encode(data))
+}
+
+// Unwrap returns the wrapped transport, for capability probing.
+func (t *compressingTransport) Unwrap() Transport {
+	return t.Transport
+}
+
+// withInputCompression wraps transport so large user messages are compressed
+// by compressor, if set. Transports that compress data themselves are not
+// wrapped.
+func withInputCompression(transport Transport, compressor *inputCompressor) Transport {
+	if compressor == nil || CapabilitiesOf(transport).Compression {
+		return transport
+	}
+	return &compressingTransport{Transport: transport, compressor: compressor}
+}
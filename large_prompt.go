@@ -0,0 +1,39 @@
+package claude
+
+import "sync"
+
+const (
+	// defaultLargePromptThreshold keeps prompts well under Linux's 128KB
+	// limit for a single argument (MAX_ARG_STRLEN).
+	defaultLargePromptThreshold = 32 * 1024
+
+	// windowsLargePromptThreshold leaves room for the rest of the command
+	// within windowsCmdLengthLimit.
+	windowsLargePromptThreshold = 4 * 1024
+)
+
+// largePromptThreshold returns the prompt size in bytes above which Query
+// sends the prompt over stdin instead of as a CLI argument.
+func largePromptThreshold(options *ClaudeAgentOptions) int {
+	if options != nil && options.LargePromptThreshold != nil && *options.LargePromptThreshold > 0 {
+		return *options.LargePromptThreshold
+	}
+	if isWindows() {
+		return windowsLargePromptThreshold
+	}
+	return defaultLargePromptThreshold
+}
+
+// streamedPrompt wraps prompt as a single user message for streaming input.
+// The returned end func closes the input; it is safe to call more than once.
+// Input stays open until end is called so hooks, CanUseTool, and SDK MCP
+// servers keep working over the control protocol until the result arrives.
+func streamedPrompt(prompt string) (<-chan map[string]interface{}, func()) {
+	input := make(chan map[string]interface{}, 1)
+	input <- NewUserMessage(prompt)
+
+	var once sync.Once
+	return input, func() {
+		once.Do(func() { close(input) })
+	}
+}
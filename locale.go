@@ -0,0 +1,60 @@
+package claude
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// localeContext returns the system prompt text describing
+// options.Locale and options.Timezone, or "" if neither is set.
+func localeContext(options *ClaudeAgentOptions) string {
+	if options == nil {
+		return ""
+	}
+	var parts []string
+	if options.Locale != "" {
+		parts = append(parts, fmt.Sprintf("The user's locale is %s: write dates, times, and numbers in its format.", options.Locale))
+	}
+	if options.Timezone != nil {
+		parts = append(parts, fmt.Sprintf("The user's time zone is %s: give times in it unless asked otherwise.", timezoneName(options.Timezone)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// timezoneName names loc for Claude. time.Local has no IANA name, so it is
+// described by its current abbreviation and offset.
+func timezoneName(loc *time.Location) string {
+	if name := loc.String(); name != "Local" {
+		return name
+	}
+	return time.Now().In(loc).Format("MST (UTC-07:00)")
+}
+
+// appendedSystemPrompt returns the --append-system-prompt value: the
+// preset's Append text, if any, followed by the locale context.
+func appendedSystemPrompt(options *ClaudeAgentOptions) string {
+	var parts []string
+	switch sp := options.SystemPrompt.(type) {
+	case map[string]interface{}:
+		if sp["type"] == "preset" && sp["append"] != nil {
+			parts = append(parts, sp["append"].(string))
+		}
+	case SystemPromptPreset:
+		if sp.Append != nil {
+			parts = append(parts, *sp.Append)
+		}
+	}
+	if text := localeContext(options); text != "" {
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// inTimezone returns t in loc, or t unchanged if loc is nil.
+func inTimezone(t time.Time, loc *time.Location) time.Time {
+	if loc == nil || t.IsZero() {
+		return t
+	}
+	return t.In(loc)
+}
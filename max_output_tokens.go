@@ -0,0 +1,98 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// maxOutputTokensEnv is the CLI setting for the maximum tokens per response.
+const maxOutputTokensEnv = "CLAUDE_CODE_MAX_OUTPUT_TOKENS"
+
+type maxOutputTokensKey struct{}
+
+// WithMaxOutputTokens returns a context that overrides
+// ClaudeAgentOptions.MaxOutputTokens for one query.
+//
+// It is meant for Query() and QueryStream(), which start the CLI with the
+// override, unless the caller passes its own transport, which was created
+// with its own options. The CLI reads the limit only at startup, so
+// ClaudeSDKClient.Query() applies an override that differs from the
+// session's limit with SetMaxOutputTokens, which restarts the session; the
+// new limit then stays in effect. Because the restart would abandon other
+// turns, the client rejects such an override while it has Sessions or
+// another query in flight; call SetMaxOutputTokens between queries instead.
+//
+// Example:
+//
+//	ctx = claude.WithMaxOutputTokens(ctx, 1024) // Short answer for a tooltip
+//	msgCh, errCh, err := claude.Query(ctx, prompt, options, nil)
+func WithMaxOutputTokens(ctx context.Context, tokens int) context.Context {
+	return context.WithValue(ctx, maxOutputTokensKey{}, tokens)
+}
+
+// MaxOutputTokensFromContext returns the override set by WithMaxOutputTokens.
+func MaxOutputTokensFromContext(ctx context.Context) (int, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	tokens, ok := ctx.Value(maxOutputTokensKey{}).(int)
+	return tokens, ok
+}
+
+// withMaxOutputTokensOverride returns options with the limit from ctx, or
+// options itself when ctx has no override.
+func withMaxOutputTokensOverride(ctx context.Context, options *ClaudeAgentOptions) *ClaudeAgentOptions {
+	tokens, ok := MaxOutputTokensFromContext(ctx)
+	if !ok {
+		return options
+	}
+	newOpts := *options
+	newOpts.MaxOutputTokens = &tokens
+	return &newOpts
+}
+
+// validateMaxOutputTokens rejects non-positive limits.
+func validateMaxOutputTokens(options *ClaudeAgentOptions) error {
+	if options.MaxOutputTokens != nil && *options.MaxOutputTokens <= 0 {
+		return fmt.Errorf("max output tokens must be positive, got %d", *options.MaxOutputTokens)
+	}
+	return nil
+}
+
+// checkExclusive returns an error if restarting the session for a
+// per-query override would disturb other users of the client: Sessions, or
+// queries in flight besides the caller's.
+func (c *ClaudeSDKClient) checkExclusive() error {
+	c.mu.Lock()
+	sessions := len(c.sessions)
+	c.mu.Unlock()
+	if sessions > 0 || c.pending.inFlight() > 1 {
+		return errors.New("a per-query max output tokens override restarts the CLI and cannot be used on a client shared with other sessions or queries; use SetMaxOutputTokens between queries")
+	}
+	return nil
+}
+
+// annotateResult records the output token limit in effect on a ResultMessage.
+func annotateResult(msg Message, maxOutputTokens *int) {
+	if result, ok := msg.(*ResultMessage); ok && maxOutputTokens != nil {
+		result.MaxOutputTokens = *maxOutputTokens
+	}
+}
+
+// SetMaxOutputTokens changes the maximum tokens per response mid-session.
+//
+// Like SetOutputStyle, this restarts the CLI session with the new limit and
+// resumes the current conversation. Call it between queries.
+func (c *ClaudeSDKClient) SetMaxOutputTokens(ctx context.Context, tokens int) error {
+	if err := c.checkConnected(); err != nil {
+		return err
+	}
+	if tokens <= 0 {
+		return fmt.Errorf("max output tokens must be positive, got %d", tokens)
+	}
+
+	return c.restart(ctx, func(options *ClaudeAgentOptions) {
+		options.MaxOutputTokens = &tokens
+	})
+}
@@ -0,0 +1,240 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Defaults for FetchLimits.
+const (
+	defaultFetchServerName   = "fetch"
+	DefaultFetchTimeout      = 30 * time.Second
+	DefaultFetchMaxBodyBytes = 1 << 20
+	defaultFetchMaxRedirects = 5
+)
+
+// FetchLimits configures the server from NewHTTPFetchServer. The zero value
+// uses defaults.
+type FetchLimits struct {
+	Timeout      time.Duration // Per request, including redirects and reading the body (default: 30s)
+	MaxBodyBytes int64         // Longer bodies are truncated (default: 1MB)
+	MaxRedirects int           // Redirects followed, each to an allowed host (default: 5)
+	// Headers are added to requests by host pattern, as in the allowlist, or
+	// "*" for every host, e.g. to authenticate to an internal API without
+	// showing Claude the credentials. They replace headers Claude sets.
+	Headers map[string]http.Header
+	// Transport sends the requests (default: http.DefaultTransport).
+	Transport http.RoundTripper
+}
+
+// fetcher implements the fetch tool.
+type fetcher struct {
+	allowlist []string
+	limits    FetchLimits
+	client    *http.Client
+}
+
+// NewHTTPFetchServer creates an SDK MCP server named "fetch" with one tool,
+// fetch, that GETs http and https URLs on the allowlisted hosts. It is an
+// alternative to the CLI's WebFetch tool for deployments that disallow it
+// for policy reasons, running in this process under these limits.
+//
+// Allowlist entries are host names, e.g. "docs.example.com", or
+// "*.example.com" for every subdomain of example.com; ports are ignored.
+// Redirects are only followed to allowed hosts.
+//
+// Example:
+//
+//	fetch, err := mcp.NewHTTPFetchServer([]string{"pkg.go.dev", "*.internal.example.com"}, mcp.FetchLimits{
+//	    Headers: map[string]http.Header{"*.internal.example.com": {"Authorization": {"Bearer " + token}}},
+//	})
+//	if err != nil { ... }
+//	options := &claude.ClaudeAgentOptions{
+//	    McpServers:      map[string]claude.McpServerConfig{"fetch": fetch.ToConfig()},
+//	    AllowedTools:    []string{"mcp__fetch__fetch"},
+//	    DisallowedTools: []string{"WebFetch"},
+//	}
+func NewHTTPFetchServer(allowlist []string, limits FetchLimits) (*SdkMcpServer, error) {
+	if len(allowlist) == 0 {
+		return nil, fmt.Errorf("fetch server needs at least one allowed host")
+	}
+	f := &fetcher{limits: limits}
+	for _, pattern := range allowlist {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		host := strings.TrimPrefix(pattern, "*.")
+		if host == "" || strings.ContainsAny(host, "/:*") {
+			return nil, fmt.Errorf("fetch server: invalid allowlist entry %q; use a host name or *.domain", pattern)
+		}
+		f.allowlist = append(f.allowlist, pattern)
+	}
+	if f.limits.Timeout <= 0 {
+		f.limits.Timeout = DefaultFetchTimeout
+	}
+	if f.limits.MaxBodyBytes <= 0 {
+		f.limits.MaxBodyBytes = DefaultFetchMaxBodyBytes
+	}
+	if f.limits.MaxRedirects <= 0 {
+		f.limits.MaxRedirects = defaultFetchMaxRedirects
+	}
+	f.client = &http.Client{Transport: f.limits.Transport, CheckRedirect: f.checkRedirect}
+
+	return CreateSdkMcpServer(defaultFetchServerName, "1.0.0", []*SdkMcpTool{
+		Tool("fetch", fmt.Sprintf("Fetch a URL with an HTTP GET and return the status, content type, and body. Only these hosts are allowed: %s.", strings.Join(f.allowlist, ", ")),
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url": map[string]interface{}{"type": "string", "description": "http or https URL"},
+					"headers": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Request headers, e.g. Accept",
+					},
+				},
+				"required": []string{"url"},
+			}, f.handleFetch),
+	}), nil
+}
+
+func (f *fetcher) handleFetch(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	rawURL, _ := args["url"].(string)
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrorContent(fmt.Sprintf("invalid URL %q: use an absolute http or https URL", rawURL)), nil
+	}
+	if !f.allowed(u) {
+		return ErrorContent(fmt.Sprintf("host %s is not allowed; allowed hosts: %s", u.Hostname(), strings.Join(f.allowlist, ", "))), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.limits.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return ErrorContent(err.Error()), nil
+	}
+	if headers, ok := args["headers"].(map[string]interface{}); ok {
+		for name, value := range headers {
+			if s, ok := value.(string); ok {
+				req.Header.Set(name, s)
+			}
+		}
+	}
+	f.injectHeaders(req)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return ErrorContent(fmt.Sprintf("fetching %s timed out after %s", u, f.limits.Timeout)), nil
+		}
+		return ErrorContent(fmt.Sprintf("fetching %s failed: %v", u, err)), nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.limits.MaxBodyBytes+1))
+	if err != nil {
+		return ErrorContent(fmt.Sprintf("reading the body of %s failed: %v", u, err)), nil
+	}
+	truncated := int64(len(body)) > f.limits.MaxBodyBytes
+	if truncated {
+		// Drop a rune split by the cut
+		body = []byte(strings.ToValidUTF8(string(body[:f.limits.MaxBodyBytes]), ""))
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	var b strings.Builder
+	fmt.Fprintf(&b, "GET %s\nStatus: %s\n", resp.Request.URL, resp.Status)
+	if contentType != "" {
+		fmt.Fprintf(&b, "Content-Type: %s\n", contentType)
+	}
+	b.WriteString("\n")
+	if !isTextContent(contentType, body) {
+		fmt.Fprintf(&b, "[%d-byte binary body not shown]", len(body))
+		return TextContent(b.String()), nil
+	}
+	b.Write(body)
+	if truncated {
+		fmt.Fprintf(&b, "\n\n[Body truncated at %d bytes]", f.limits.MaxBodyBytes)
+	}
+	return TextContent(b.String()), nil
+}
+
+// checkRedirect limits redirects to allowed hosts and swaps the injected
+// headers for those of the new host.
+func (f *fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > f.limits.MaxRedirects {
+		return fmt.Errorf("stopped after %d redirects", f.limits.MaxRedirects)
+	}
+	if !f.allowed(req.URL) {
+		return fmt.Errorf("redirect to %s is not allowed", req.URL.Hostname())
+	}
+	// Redirects copy the first request's headers
+	for name := range f.headersFor(via[0].URL.Hostname()) {
+		req.Header.Del(name)
+	}
+	f.injectHeaders(req)
+	return nil
+}
+
+// allowed reports whether u's host matches the allowlist.
+func (f *fetcher) allowed(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	for _, pattern := range f.allowlist {
+		if hostMatches(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// injectHeaders sets the configured headers for req's host.
+func (f *fetcher) injectHeaders(req *http.Request) {
+	for name, values := range f.headersFor(req.URL.Hostname()) {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+}
+
+// headersFor returns the configured headers for host.
+func (f *fetcher) headersFor(host string) http.Header {
+	headers := http.Header{}
+	host = strings.ToLower(host)
+	for pattern, values := range f.limits.Headers {
+		if pattern == "*" || hostMatches(strings.ToLower(pattern), host) {
+			for name, v := range values {
+				headers[http.CanonicalHeaderKey(name)] = v
+			}
+		}
+	}
+	return headers
+}
+
+// hostMatches reports whether host matches an allowlist pattern.
+func hostMatches(pattern, host string) bool {
+	if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+domain)
+	}
+	return host == pattern
+}
+
+// isTextContent reports whether a body should be shown as text.
+func isTextContent(contentType string, body []byte) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "json"), strings.HasSuffix(mediaType, "xml"),
+		mediaType == "application/javascript", mediaType == "application/x-www-form-urlencoded":
+		return true
+	case mediaType == "":
+		return utf8.Valid(body)
+	}
+	return false
+}
@@ -0,0 +1,315 @@
+package mcp
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// defaultHTTPPath is the endpoint path used when HTTPOptions.Path is empty.
+const defaultHTTPPath = "/mcp"
+
+// maxHTTPBodyBytes caps the size of a POSTed JSON-RPC message.
+const maxHTTPBodyBytes = 16 << 20
+
+// httpShutdownTimeout bounds how long ServeHTTP waits for in-flight
+// requests once its context is done.
+const httpShutdownTimeout = 10 * time.Second
+
+// HTTPOptions configures NewHTTPHandler and ServeHTTP.
+type HTTPOptions struct {
+	// Path is the MCP endpoint path (default: "/mcp").
+	Path string
+
+	// BearerToken, if set, must be sent by clients as
+	// "Authorization: Bearer <token>".
+	BearerToken string
+
+	// Authenticate, if set, checks each request after BearerToken, e.g. to
+	// verify an API key header or a signed token. Returning an error
+	// rejects the request with 401 Unauthorized.
+	Authenticate func(r *http.Request) error
+
+	// AllowedOrigins lists the browser origins, such as
+	// "https://app.example.com", allowed to call the server. Requests with
+	// any other Origin header are rejected with 403 Forbidden, which guards
+	// local servers against DNS rebinding. Requests without an Origin
+	// header, as sent by non-browser clients, are always allowed.
+	AllowedOrigins []string
+}
+
+// HTTPHandler exposes an SdkMcpServer over the MCP streamable HTTP
+// transport, so tools used in-process with the SDK can also be deployed as
+// a remote MCP server. Clients POST one JSON-RPC message or batch per
+// request to the endpoint path:
+//
+//   - Requests are answered as application/json, or as a server-sent event
+//     stream of "message" events when the client accepts only
+//     text/event-stream, or accepts it and asked for progress; the stream
+//     carries the handler's ReportProgress notifications, then the response
+//   - Bodies holding only notifications or responses are answered with
+//     202 Accepted
+//   - notifications/cancelled cancels the request it names, as does the
+//     client closing the connection
+//
+// The handler is stateless: it issues no Mcp-Session-Id and does not offer
+// a GET stream for server-initiated messages, which SdkMcpServer never
+// sends.
+type HTTPHandler struct {
+	server   *SdkMcpServer
+	options  HTTPOptions
+	origins  map[string]bool
+	mux      *http.ServeMux
+	inflight inflightCalls
+}
+
+// NewHTTPHandler returns an HTTPHandler for server. options may be nil.
+func NewHTTPHandler(server *SdkMcpServer, options *HTTPOptions) *HTTPHandler {
+	h := &HTTPHandler{server: server, origins: make(map[string]bool)}
+	if options != nil {
+		h.options = *options
+	}
+	if h.options.Path == "" {
+		h.options.Path = defaultHTTPPath
+	}
+	for _, origin := range h.options.AllowedOrigins {
+		h.origins[strings.TrimSuffix(origin, "/")] = true
+	}
+	h.mux = http.NewServeMux()
+	h.mux.HandleFunc(h.options.Path, h.handleMCP)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// ServeHTTP listens on addr and serves server with an HTTPHandler until
+// ctx is done. It then cancels in-flight requests, waits briefly for their
+// responses, and returns ctx.Err(). options may be nil.
+//
+// Example:
+//
+//	server := mcp.CreateSdkMcpServer("calc", "1.0.0", []*mcp.SdkMcpTool{addTool})
+//	err := mcp.ServeHTTP(ctx, ":8080", server, &mcp.HTTPOptions{BearerToken: os.Getenv("MCP_TOKEN")})
+//
+// Then configure the server in the CLI as claude.McpHTTPServerConfig
+// pointing at http://host:8080/mcp.
+func ServeHTTP(ctx context.Context, addr string, server *SdkMcpServer, options *HTTPOptions) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	serveCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	httpServer := &http.Server{
+		Handler:           NewHTTPHandler(server, options),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return serveCtx },
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("MCP HTTP server failed: %w", err)
+	case <-ctx.Done():
+		cancel()
+		shutdownCtx, stop := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer stop()
+		httpServer.Shutdown(shutdownCtx)
+		return ctx.Err()
+	}
+}
+
+// handleMCP serves the endpoint path.
+func (h *HTTPHandler) handleMCP(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" && !h.origins[origin] {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	if err := h.authenticate(r); err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHTTPBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	body = []byte(strings.TrimSpace(string(body)))
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []map[string]interface{}
+		if err := json.Unmarshal(body, &batch); err != nil {
+			h.writeMessage(w, r, http.StatusBadRequest, jsonRPCError(nil, -32700, fmt.Sprintf("Parse error: %v", err)))
+			return
+		}
+		if !hasRequest(batch) {
+			for _, message := range batch {
+				h.notify(r.Context(), message)
+			}
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		h.writeMessage(w, r, http.StatusOK, h.server.HandleBatch(r.Context(), batch))
+		return
+	}
+
+	var message map[string]interface{}
+	if err := json.Unmarshal(body, &message); err != nil {
+		h.writeMessage(w, r, http.StatusBadRequest, jsonRPCError(nil, -32700, fmt.Sprintf("Parse error: %v", err)))
+		return
+	}
+	if !hasRequest([]map[string]interface{}{message}) {
+		h.notify(r.Context(), message)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	ctx, done := h.inflight.start(r.Context(), message["id"])
+	defer done()
+	params, _ := message["params"].(map[string]interface{})
+	if _, sse := acceptedFormats(r.Header.Get("Accept")); !sse || progressToken(params) == nil {
+		h.writeMessage(w, r, http.StatusOK, h.server.HandleRequest(ctx, message))
+		return
+	}
+
+	// Stream the handler's notifications ahead of the response
+	stream := &eventStream{w: w, status: http.StatusOK}
+	ctx = claude.WithMcpNotifier(ctx, func(_ context.Context, notification map[string]interface{}) error {
+		return stream.send(notification)
+	})
+	stream.send(h.server.HandleRequest(ctx, message))
+}
+
+// authenticate checks BearerToken, then Authenticate.
+func (h *HTTPHandler) authenticate(r *http.Request) error {
+	if h.options.BearerToken != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.options.BearerToken)) != 1 {
+			return errors.New("invalid or missing bearer token")
+		}
+	}
+	if h.options.Authenticate != nil {
+		return h.options.Authenticate(r)
+	}
+	return nil
+}
+
+// notify handles a notification, or ignores a response to a request the
+// server never sent.
+func (h *HTTPHandler) notify(ctx context.Context, message map[string]interface{}) {
+	if _, isResponse := message["id"]; isResponse {
+		return
+	}
+	h.inflight.notify(ctx, h.server, message)
+}
+
+// writeMessage writes a JSON-RPC response in the format the client accepts.
+func (h *HTTPHandler) writeMessage(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(jsonRPCError(nil, -32603, fmt.Sprintf("Internal error: %v", err)))
+	}
+
+	if plain, sse := acceptedFormats(r.Header.Get("Accept")); plain || !sse {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(data)
+		return
+	}
+	stream := &eventStream{w: w, status: status}
+	stream.send(json.RawMessage(data))
+}
+
+// eventStream writes JSON-RPC messages to a response as server-sent
+// events, each one a "message" event.
+type eventStream struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	status  int
+	started bool
+}
+
+// send writes v as an event and flushes it to the client.
+func (s *eventStream) send(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode MCP message: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started {
+		s.w.Header().Set("Content-Type", "text/event-stream")
+		s.w.Header().Set("Cache-Control", "no-cache")
+		s.w.WriteHeader(s.status)
+		s.started = true
+	}
+	if _, err := fmt.Fprintf(s.w, "event: message\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// hasRequest reports whether messages include a request, which has both an
+// id and a method.
+func hasRequest(messages []map[string]interface{}) bool {
+	for _, message := range messages {
+		_, hasID := message["id"]
+		_, hasMethod := message["method"]
+		if hasID && hasMethod {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptedFormats reports whether an Accept header lists JSON and
+// text/event-stream. Streamable HTTP clients accept both, and get plain
+// JSON unless they asked for progress.
+func acceptedFormats(accept string) (plain, sse bool) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/event-stream":
+			sse = true
+		case "application/json", "application/*", "*/*":
+			plain = true
+		}
+	}
+	return plain, sse
+}
@@ -0,0 +1,254 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Decoders for JPEGResizer
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"slices"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// Defaults for ImageLimits, matching what the Anthropic API accepts.
+const (
+	DefaultMaxImageBytes   = 5 << 20  // Per decoded image
+	DefaultMaxContentBytes = 32 << 20 // Per tool result, base64-encoded as sent
+)
+
+// DefaultImageMIMETypes are the image types Claude can read.
+var DefaultImageMIMETypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// ImageLimits configures the image checks of NewImageContent,
+// NewMixedContent, and ValidateContent. The zero value uses defaults.
+type ImageLimits struct {
+	MaxImageBytes   int      // Largest decoded image (default: DefaultMaxImageBytes)
+	MaxContentBytes int      // Largest total of encoded image data in one result (default: DefaultMaxContentBytes)
+	MIMETypes       []string // Accepted image types (default: DefaultImageMIMETypes)
+	// Resizer, if set, shrinks images larger than MaxImageBytes instead of
+	// rejecting them. Only NewImageContent resizes; see JPEGResizer.
+	Resizer ImageResizer
+}
+
+// ImageResizer shrinks an image to at most maxBytes, possibly re-encoding it
+// in another format. It returns the new image data and MIME type.
+type ImageResizer interface {
+	Resize(data []byte, mimeType string, maxBytes int) ([]byte, string, error)
+}
+
+// ContentErrorReason says why ValidateContent rejected a content block.
+type ContentErrorReason string
+
+const (
+	ContentImageTooLarge    ContentErrorReason = "image_too_large"    // Decoded image exceeds MaxImageBytes
+	ContentTooLarge         ContentErrorReason = "content_too_large"  // Images together exceed MaxContentBytes
+	ContentUnsupportedType  ContentErrorReason = "unsupported_type"   // MIME type not in MIMETypes
+	ContentTypeMismatch     ContentErrorReason = "type_mismatch"      // Data is not of the declared MIME type
+	ContentInvalidImageData ContentErrorReason = "invalid_image_data" // Data is empty or not valid base64
+	ContentResizeFailed     ContentErrorReason = "resize_failed"      // The Resizer failed or did not shrink enough
+)
+
+// ContentError is returned when a tool result's image content would be
+// rejected by Claude.
+type ContentError struct {
+	*claude.ClaudeSDKError
+	Reason   ContentErrorReason
+	Block    int    // Index of the offending content block
+	MIMEType string // Declared MIME type of the block
+	Size     int    // Size that exceeded Limit, in bytes
+	Limit    int
+}
+
+func newContentError(reason ContentErrorReason, block int, mimeType string, size, limit int, err error, format string, args ...interface{}) *ContentError {
+	return &ContentError{
+		ClaudeSDKError: &claude.ClaudeSDKError{
+			Message: fmt.Sprintf("content block %d: ", block) + fmt.Sprintf(format, args...),
+			Err:     err,
+		},
+		Reason:   reason,
+		Block:    block,
+		MIMEType: mimeType,
+		Size:     size,
+		Limit:    limit,
+	}
+}
+
+func (l *ImageLimits) maxImageBytes() int {
+	if l == nil || l.MaxImageBytes <= 0 {
+		return DefaultMaxImageBytes
+	}
+	return l.MaxImageBytes
+}
+
+func (l *ImageLimits) maxContentBytes() int {
+	if l == nil || l.MaxContentBytes <= 0 {
+		return DefaultMaxContentBytes
+	}
+	return l.MaxContentBytes
+}
+
+func (l *ImageLimits) mimeTypes() []string {
+	if l == nil || len(l.MIMETypes) == 0 {
+		return DefaultImageMIMETypes
+	}
+	return l.MIMETypes
+}
+
+// NewImageContent creates a response with one image, like ImageContent, but
+// takes raw image bytes and checks them against limits (nil for defaults)
+// first. Images larger than the limit are shrunk by limits.Resizer if set.
+// Problems are reported as a *ContentError.
+//
+// Example:
+//
+//	result, err := mcp.NewImageContent(pngBytes, "image/png", &mcp.ImageLimits{Resizer: mcp.JPEGResizer{}})
+//	if err != nil {
+//	    return mcp.ErrorContent(err.Error()), nil
+//	}
+//	return result, nil
+func NewImageContent(data []byte, mimeType string, limits *ImageLimits) (map[string]interface{}, error) {
+	if limit := limits.maxImageBytes(); len(data) > limit && limits != nil && limits.Resizer != nil {
+		if err := checkImage(data, mimeType, 0, limits); err != nil && err.Reason != ContentImageTooLarge {
+			return nil, err
+		}
+		resized, resizedType, err := limits.Resizer.Resize(data, mimeType, limit)
+		if err != nil {
+			return nil, newContentError(ContentResizeFailed, 0, mimeType, len(data), limit, err,
+				"cannot shrink %d-byte %s image to %d bytes", len(data), mimeType, limit)
+		}
+		data, mimeType = resized, resizedType
+	}
+	result := ImageContent(base64.StdEncoding.EncodeToString(data), mimeType)
+	if err := ValidateContent(result, limits); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// NewMixedContent creates a response with multiple content blocks, like
+// MixedContent, and checks its images with ValidateContent.
+func NewMixedContent(limits *ImageLimits, blocks ...map[string]interface{}) (map[string]interface{}, error) {
+	result := MixedContent(blocks...)
+	if err := ValidateContent(result, limits); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ValidateContent checks the image blocks of a tool result, e.g. one built
+// with ImageContent or MixedContent, against limits (nil for defaults): each
+// must hold valid base64 data of an accepted MIME type that matches its
+// contents, within the size limits. It returns a *ContentError for the
+// first problem found.
+func ValidateContent(result map[string]interface{}, limits *ImageLimits) error {
+	blocks, _ := result["content"].([]map[string]interface{})
+	total := 0
+	for i, block := range blocks {
+		if block["type"] != "image" {
+			continue
+		}
+		encoded, _ := block["data"].(string)
+		mimeType, _ := block["mimeType"].(string)
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(data) == 0 {
+			return newContentError(ContentInvalidImageData, i, mimeType, 0, 0, err,
+				"image data must be non-empty standard base64")
+		}
+		if err := checkImage(data, mimeType, i, limits); err != nil {
+			return err
+		}
+		total += len(encoded)
+		if limit := limits.maxContentBytes(); total > limit {
+			return newContentError(ContentTooLarge, i, mimeType, total, limit, nil,
+				"images total %d bytes encoded, over the %d-byte limit; return fewer or smaller images", total, limit)
+		}
+	}
+	return nil
+}
+
+// checkImage checks one decoded image.
+func checkImage(data []byte, mimeType string, block int, limits *ImageLimits) *ContentError {
+	accepted := limits.mimeTypes()
+	if !slices.Contains(accepted, mimeType) {
+		return newContentError(ContentUnsupportedType, block, mimeType, 0, 0, nil,
+			"unsupported image type %q; use one of %v", mimeType, accepted)
+	}
+	if detected := http.DetectContentType(data); detected != mimeType && slices.Contains(DefaultImageMIMETypes, detected) {
+		return newContentError(ContentTypeMismatch, block, mimeType, 0, 0, nil,
+			"image is declared %s but contains %s data", mimeType, detected)
+	}
+	if limit := limits.maxImageBytes(); len(data) > limit {
+		return newContentError(ContentImageTooLarge, block, mimeType, len(data), limit, nil,
+			"%s image is %d bytes, over the %d-byte limit; downscale it or set ImageLimits.Resizer", mimeType, len(data), limit)
+	}
+	return nil
+}
+
+// JPEGResizer is an ImageResizer that re-encodes JPEG, PNG, and GIF images as
+// JPEG, halving their dimensions until they fit. Transparency is flattened
+// onto white.
+type JPEGResizer struct {
+	Quality int // JPEG quality, 1-100 (default: 85)
+}
+
+// Resize implements ImageResizer.
+func (r JPEGResizer) Resize(data []byte, mimeType string, maxBytes int) ([]byte, string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decode %s: %w", mimeType, err)
+	}
+	quality := r.Quality
+	if quality <= 0 || quality > 100 {
+		quality = 85
+	}
+	for {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: quality}); err != nil {
+			return nil, "", err
+		}
+		if buf.Len() <= maxBytes {
+			return buf.Bytes(), "image/jpeg", nil
+		}
+		bounds := img.Bounds()
+		if bounds.Dx() < 2 || bounds.Dy() < 2 {
+			return nil, "", fmt.Errorf("image does not fit in %d bytes", maxBytes)
+		}
+		img = halve(img)
+	}
+}
+
+// flatten draws img onto a white background.
+func flatten(img image.Image) image.Image {
+	bounds := img.Bounds()
+	out := image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			white := 0xffff - a
+			out.Set(x, y, color.RGBA64{R: uint16(r + white), G: uint16(g + white), B: uint16(b + white), A: 0xffff})
+		}
+	}
+	return out
+}
+
+// halve scales img to half its width and height, averaging 2x2 pixel boxes.
+func halve(img image.Image) image.Image {
+	bounds := img.Bounds()
+	out := image.NewRGBA64(image.Rect(0, 0, bounds.Dx()/2, bounds.Dy()/2))
+	for y := 0; y < bounds.Dy()/2; y++ {
+		for x := 0; x < bounds.Dx()/2; x++ {
+			var sum [4]uint32
+			for _, d := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				r, g, b, a := img.At(bounds.Min.X+2*x+d[0], bounds.Min.Y+2*y+d[1]).RGBA()
+				sum[0], sum[1], sum[2], sum[3] = sum[0]+r, sum[1]+g, sum[2]+b, sum[3]+a
+			}
+			out.SetRGBA64(x, y, color.RGBA64{R: uint16(sum[0] / 4), G: uint16(sum[1] / 4), B: uint16(sum[2] / 4), A: uint16(sum[3] / 4)})
+		}
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	"context"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+type progressTokenKey struct{}
+
+// ReportProgress sends an MCP progress notification for the tools/call
+// being handled with ctx, so a long-running tool can tell Claude Code how
+// far along it is. progress must increase with each report; total is its
+// final value, or 0 if unknown, and message is an optional status line.
+//
+// ReportProgress does nothing and returns nil if the client did not ask
+// for progress on the call, which it does by sending a progressToken, or if
+// ctx did not come from a tool handler. It returns an error only if the
+// notification could not be sent, which tools can usually ignore.
+//
+// Example:
+//
+//	for i, file := range files {
+//	    mcp.ReportProgress(ctx, float64(i), float64(len(files)), "Indexing "+file)
+//	    index(file)
+//	}
+func ReportProgress(ctx context.Context, progress, total float64, message string) error {
+	token := ctx.Value(progressTokenKey{})
+	notify := claude.McpNotifierFromContext(ctx)
+	if token == nil || notify == nil {
+		return nil
+	}
+	params := map[string]interface{}{"progressToken": token, "progress": progress}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	return notify(ctx, map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/progress",
+		"params":  params,
+	})
+}
+
+// withProgressToken returns ctx carrying the progressToken of a request's
+// params, if it has one.
+func withProgressToken(ctx context.Context, params map[string]interface{}) context.Context {
+	if token := progressToken(params); token != nil {
+		return context.WithValue(ctx, progressTokenKey{}, token)
+	}
+	return ctx
+}
+
+// progressToken returns the progressToken in params._meta, or nil.
+func progressToken(params map[string]interface{}) interface{} {
+	meta, _ := params["_meta"].(map[string]interface{})
+	return meta["progressToken"]
+}
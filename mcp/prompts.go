@@ -0,0 +1,208 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// SdkMcpPrompt is a reusable prompt template provided by an SDK MCP server.
+// Claude Code offers MCP prompts as slash commands, e.g.
+// /mcp__docs__summarize.
+type SdkMcpPrompt struct {
+	Name        string
+	Description string
+	// ArgsSchema declares the prompt's arguments, in the same formats as
+	// SdkMcpTool.InputSchema. Arguments are always passed as strings;
+	// descriptions are taken from the schema's properties, if present.
+	ArgsSchema interface{}
+	// Handler renders the prompt from its arguments into a prompts/get
+	// result, e.g. with PromptText. Required arguments are checked first.
+	Handler func(context.Context, map[string]string) (map[string]interface{}, error)
+}
+
+// Prompt creates a new SDK MCP prompt.
+//
+// Example:
+//
+//	review := mcp.Prompt("review", "Review a file", map[string]string{"path": "string"},
+//	    func(ctx context.Context, args map[string]string) (map[string]interface{}, error) {
+//	        return mcp.PromptText(fmt.Sprintf("Review %s for bugs and style issues.", args["path"])), nil
+//	    })
+//	server := mcp.CreateSdkMcpServer("team", "1.0.0", nil).WithPrompts(review)
+func Prompt(
+	name string,
+	description string,
+	argsSchema interface{},
+	handler func(context.Context, map[string]string) (map[string]interface{}, error),
+) *SdkMcpPrompt {
+	return &SdkMcpPrompt{
+		Name:        name,
+		Description: description,
+		ArgsSchema:  argsSchema,
+		Handler:     handler,
+	}
+}
+
+// WithPrompts adds prompts to the server and returns the server. Call it
+// before the server is used; a prompt replaces an earlier one with the same
+// name.
+func (s *SdkMcpServer) WithPrompts(prompts ...*SdkMcpPrompt) *SdkMcpServer {
+	if s.promptMap == nil {
+		s.promptMap = make(map[string]*SdkMcpPrompt)
+	}
+	for _, prompt := range prompts {
+		if _, exists := s.promptMap[prompt.Name]; exists {
+			for i, p := range s.Prompts {
+				if p.Name == prompt.Name {
+					s.Prompts[i] = prompt
+				}
+			}
+		} else {
+			s.Prompts = append(s.Prompts, prompt)
+		}
+		s.promptMap[prompt.Name] = prompt
+	}
+	return s
+}
+
+// PromptNames returns the names of the server's prompts, in registration
+// order.
+func (s *SdkMcpServer) PromptNames() []string {
+	names := make([]string, len(s.Prompts))
+	for i, prompt := range s.Prompts {
+		names[i] = prompt.Name
+	}
+	return names
+}
+
+// PromptText returns a prompts/get result with one user message of text.
+func PromptText(text string) map[string]interface{} {
+	return PromptMessages(map[string]interface{}{
+		"role":    "user",
+		"content": map[string]interface{}{"type": "text", "text": text},
+	})
+}
+
+// PromptMessages returns a prompts/get result with the given messages, each
+// a map with "role" ("user" or "assistant") and one "content" block.
+func PromptMessages(messages ...map[string]interface{}) map[string]interface{} {
+	if messages == nil {
+		messages = []map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"messages": messages,
+	}
+}
+
+// promptArgument is one argument of a prompt, as listed by prompts/list.
+type promptArgument struct {
+	name        string
+	description string
+	required    bool
+}
+
+// promptArguments returns the arguments declared by a prompt's schema,
+// sorted by name.
+func (s *SdkMcpServer) promptArguments(prompt *SdkMcpPrompt) []promptArgument {
+	if prompt.ArgsSchema == nil {
+		return nil
+	}
+	schema := s.convertSchema(prompt.ArgsSchema)
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := make(map[string]bool)
+	switch names := schema["required"].(type) {
+	case []string:
+		for _, name := range names {
+			required[name] = true
+		}
+	case []interface{}:
+		for _, name := range names {
+			if name, ok := name.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	args := make([]promptArgument, 0, len(properties))
+	for name, property := range properties {
+		arg := promptArgument{name: name, required: required[name]}
+		if property, ok := property.(map[string]interface{}); ok {
+			arg.description, _ = property["description"].(string)
+		}
+		args = append(args, arg)
+	}
+	sort.Slice(args, func(i, j int) bool { return args[i].name < args[j].name })
+	return args
+}
+
+func (s *SdkMcpServer) handleListPrompts(msgID interface{}) map[string]interface{} {
+	prompts := make([]map[string]interface{}, len(s.Prompts))
+	for i, prompt := range s.Prompts {
+		arguments := []map[string]interface{}{}
+		for _, arg := range s.promptArguments(prompt) {
+			argument := map[string]interface{}{
+				"name":     arg.name,
+				"required": arg.required,
+			}
+			if arg.description != "" {
+				argument["description"] = arg.description
+			}
+			arguments = append(arguments, argument)
+		}
+		prompts[i] = map[string]interface{}{
+			"name":        prompt.Name,
+			"description": prompt.Description,
+			"arguments":   arguments,
+		}
+	}
+
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      msgID,
+		"result": map[string]interface{}{
+			"prompts": prompts,
+		},
+	}
+}
+
+func (s *SdkMcpServer) handleGetPrompt(ctx context.Context, msgID interface{}, params map[string]interface{}) map[string]interface{} {
+	name, _ := params["name"].(string)
+	rawArgs, _ := params["arguments"].(map[string]interface{})
+
+	prompt, exists := s.promptMap[name]
+	if !exists || prompt.Handler == nil {
+		return jsonRPCError(msgID, -32602, fmt.Sprintf("Prompt '%s' not found", name))
+	}
+
+	args := make(map[string]string, len(rawArgs))
+	for key, value := range rawArgs {
+		if str, ok := value.(string); ok {
+			args[key] = str
+		} else {
+			args[key] = fmt.Sprint(value)
+		}
+	}
+	for _, arg := range s.promptArguments(prompt) {
+		if _, ok := args[arg.name]; arg.required && !ok {
+			return jsonRPCError(msgID, -32602, fmt.Sprintf("Missing required argument '%s' for prompt '%s'", arg.name, name))
+		}
+	}
+
+	result, err := prompt.Handler(ctx, args)
+	if err != nil {
+		return jsonRPCError(msgID, -32603, fmt.Sprintf("Error rendering prompt '%s': %v", name, err))
+	}
+	if result == nil {
+		result = PromptMessages()
+	}
+	if _, ok := result["description"]; !ok && prompt.Description != "" {
+		result["description"] = prompt.Description
+	}
+
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      msgID,
+		"result":  result,
+	}
+}
@@ -0,0 +1,160 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SdkMcpResource is a resource that Claude can read from an SDK MCP server,
+// with the CLI's ListMcpResourcesTool and ReadMcpResourceTool.
+type SdkMcpResource struct {
+	URI         string // Unique within the server, e.g. "docs://handbook"
+	Name        string
+	Description string
+	MimeType    string // e.g. "text/markdown"; empty if unknown
+	// Read returns the resource's contents. Text types (text/*, JSON, XML,
+	// YAML) that are valid UTF-8 are sent as text, anything else as base64
+	// blobs. Its context is cancelled if Claude Code cancels the read.
+	Read func(context.Context) ([]byte, error)
+}
+
+// Resource creates a new SDK MCP resource.
+//
+// Example:
+//
+//	handbook := mcp.Resource("docs://handbook", "Team handbook", "text/markdown",
+//	    func(ctx context.Context) ([]byte, error) {
+//	        return os.ReadFile("handbook.md")
+//	    })
+//	server := mcp.CreateSdkMcpServer("docs", "1.0.0", nil).WithResources(handbook)
+func Resource(uri string, name string, mimeType string, read func(context.Context) ([]byte, error)) *SdkMcpResource {
+	return &SdkMcpResource{
+		URI:      uri,
+		Name:     name,
+		MimeType: mimeType,
+		Read:     read,
+	}
+}
+
+// WithDescription sets the resource's description and returns the resource.
+func (r *SdkMcpResource) WithDescription(description string) *SdkMcpResource {
+	r.Description = description
+	return r
+}
+
+// WithResources adds resources to the server and returns the server. Call
+// it before the server is used; a resource replaces an earlier one with the
+// same URI.
+func (s *SdkMcpServer) WithResources(resources ...*SdkMcpResource) *SdkMcpServer {
+	if s.resourceMap == nil {
+		s.resourceMap = make(map[string]*SdkMcpResource)
+	}
+	for _, resource := range resources {
+		if _, exists := s.resourceMap[resource.URI]; exists {
+			for i, r := range s.Resources {
+				if r.URI == resource.URI {
+					s.Resources[i] = resource
+				}
+			}
+		} else {
+			s.Resources = append(s.Resources, resource)
+		}
+		s.resourceMap[resource.URI] = resource
+	}
+	return s
+}
+
+// ResourceURIs returns the URIs of the server's resources, in registration
+// order.
+func (s *SdkMcpServer) ResourceURIs() []string {
+	uris := make([]string, len(s.Resources))
+	for i, resource := range s.Resources {
+		uris[i] = resource.URI
+	}
+	return uris
+}
+
+func (s *SdkMcpServer) handleListResources(msgID interface{}) map[string]interface{} {
+	resources := make([]map[string]interface{}, len(s.Resources))
+	for i, resource := range s.Resources {
+		resources[i] = map[string]interface{}{
+			"uri":  resource.URI,
+			"name": resource.Name,
+		}
+		if resource.Description != "" {
+			resources[i]["description"] = resource.Description
+		}
+		if resource.MimeType != "" {
+			resources[i]["mimeType"] = resource.MimeType
+		}
+	}
+
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      msgID,
+		"result": map[string]interface{}{
+			"resources": resources,
+		},
+	}
+}
+
+func (s *SdkMcpServer) handleListResourceTemplates(msgID interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      msgID,
+		"result": map[string]interface{}{
+			"resourceTemplates": []map[string]interface{}{},
+		},
+	}
+}
+
+func (s *SdkMcpServer) handleReadResource(ctx context.Context, msgID interface{}, params map[string]interface{}) map[string]interface{} {
+	uri, _ := params["uri"].(string)
+
+	resource, exists := s.resourceMap[uri]
+	if !exists || resource.Read == nil {
+		return jsonRPCError(msgID, -32002, fmt.Sprintf("Resource '%s' not found", uri))
+	}
+
+	data, err := resource.Read(ctx)
+	if err != nil {
+		return jsonRPCError(msgID, -32603, fmt.Sprintf("Error reading resource '%s': %v", uri, err))
+	}
+
+	contents := map[string]interface{}{"uri": uri}
+	if resource.MimeType != "" {
+		contents["mimeType"] = resource.MimeType
+	}
+	if isTextMimeType(resource.MimeType) && utf8.Valid(data) {
+		contents["text"] = string(data)
+	} else {
+		contents["blob"] = base64.StdEncoding.EncodeToString(data)
+	}
+
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      msgID,
+		"result": map[string]interface{}{
+			"contents": []map[string]interface{}{contents},
+		},
+	}
+}
+
+// isTextMimeType reports whether contents of mimeType are text. An unknown
+// type counts as text; the contents decide.
+func isTextMimeType(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(strings.ToLower(mimeType), ";")
+	mimeType = strings.TrimSpace(mimeType)
+	if mimeType == "" || strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	for _, suffix := range []string{"json", "xml", "yaml", "javascript", "toml"} {
+		if strings.HasSuffix(mimeType, "/"+suffix) || strings.HasSuffix(mimeType, "+"+suffix) {
+			return true
+		}
+	}
+	return false
+}
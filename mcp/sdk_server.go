@@ -2,8 +2,12 @@ package mcp
 
 import (
 	"context"
+	"encoding"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 
 	claude "github.com/clsx524/claude-agent-sdk-go"
 )
@@ -13,7 +17,14 @@ type SdkMcpTool struct {
 	Name        string
 	Description string
 	InputSchema interface{} // Can be struct type, map, or JSON schema
-	Handler     func(context.Context, map[string]interface{}) (map[string]interface{}, error)
+	// OutputSchema declares the shape of the tool's structuredContent; same
+	// formats as InputSchema. Tools with an output schema must return
+	// structured results, see StructuredContent.
+	OutputSchema interface{}
+	// Handler runs the tool. Its context is cancelled when Claude Code
+	// cancels the call, e.g. because the user pressed escape; the call is
+	// answered as cancelled right away, so return promptly.
+	Handler func(context.Context, map[string]interface{}) (map[string]interface{}, error)
 }
 
 // Tool creates a new SDK MCP tool.
@@ -43,12 +54,39 @@ func Tool(
 	}
 }
 
+// WithOutputSchema sets the tool's output schema and returns the tool.
+//
+// Example:
+//
+//	type Forecast struct {
+//	    TempC   float64 `json:"temp_c"`
+//	    Summary string  `json:"summary"`
+//	}
+//	weather := mcp.Tool("weather", "Get the forecast", map[string]string{"city": "string"},
+//	    func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+//	        return mcp.StructuredContent(Forecast{TempC: 21, Summary: "Sunny"})
+//	    }).WithOutputSchema(Forecast{})
+func (t *SdkMcpTool) WithOutputSchema(schema interface{}) *SdkMcpTool {
+	t.OutputSchema = schema
+	return t
+}
+
 // SdkMcpServer represents an in-process MCP server.
 type SdkMcpServer struct {
-	Name    string
-	Version string
-	Tools   []*SdkMcpTool
-	toolMap map[string]*SdkMcpTool
+	Name        string
+	Version     string
+	Tools       []*SdkMcpTool
+	Resources   []*SdkMcpResource // Added with WithResources
+	Prompts     []*SdkMcpPrompt   // Added with WithPrompts
+	toolMap     map[string]*SdkMcpTool
+	resourceMap map[string]*SdkMcpResource
+	promptMap   map[string]*SdkMcpPrompt
+
+	// SkipArgumentValidation passes tools/call arguments to handlers without
+	// checking them against the tool's input schema. By default, arguments
+	// missing a required property or with the wrong type or enum value are
+	// rejected with a -32602 error before the handler runs.
+	SkipArgumentValidation bool
 }
 
 // CreateSdkMcpServer creates an in-process MCP server.
@@ -98,6 +136,15 @@ func CreateSdkMcpServer(name string, version string, tools []*SdkMcpTool) *SdkMc
 	}
 }
 
+// ToolNames returns the names of the server's tools, in registration order.
+func (s *SdkMcpServer) ToolNames() []string {
+	names := make([]string, len(s.Tools))
+	for i, tool := range s.Tools {
+		names[i] = tool.Name
+	}
+	return names
+}
+
 // ToConfig converts the server to a McpSdkServerConfig.
 func (s *SdkMcpServer) ToConfig() claude.McpSdkServerConfig {
 	return claude.McpSdkServerConfig{
@@ -115,11 +162,21 @@ func (s *SdkMcpServer) HandleRequest(ctx context.Context, message map[string]int
 
 	switch method {
 	case "initialize":
-		return s.handleInitialize(msgID)
+		return s.handleInitialize(msgID, params)
 	case "tools/list":
 		return s.handleListTools(msgID)
 	case "tools/call":
 		return s.handleCallTool(ctx, msgID, params)
+	case "resources/list":
+		return s.handleListResources(msgID)
+	case "resources/templates/list":
+		return s.handleListResourceTemplates(msgID)
+	case "resources/read":
+		return s.handleReadResource(ctx, msgID, params)
+	case "prompts/list":
+		return s.handleListPrompts(msgID)
+	case "prompts/get":
+		return s.handleGetPrompt(ctx, msgID, params)
 	case "notifications/initialized":
 		// Just acknowledge
 		return map[string]interface{}{
@@ -138,15 +195,78 @@ func (s *SdkMcpServer) HandleRequest(ctx context.Context, message map[string]int
 	}
 }
 
-func (s *SdkMcpServer) handleInitialize(msgID interface{}) map[string]interface{} {
+// jsonRPCError returns a JSON-RPC error response.
+func jsonRPCError(msgID interface{}, code int, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      msgID,
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+		},
+	}
+}
+
+// HandleBatch handles a JSON-RPC batch: the requests run concurrently and
+// their responses are returned in request order. Notifications (messages
+// without an id) get no response, so the result may be shorter than
+// messages, or empty.
+func (s *SdkMcpServer) HandleBatch(ctx context.Context, messages []map[string]interface{}) []map[string]interface{} {
+	responses := make([]map[string]interface{}, len(messages))
+	var wg sync.WaitGroup
+	for i, message := range messages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i] = s.HandleRequest(ctx, message)
+		}()
+	}
+	wg.Wait()
+
+	kept := responses[:0]
+	for i, response := range responses {
+		if _, isRequest := messages[i]["id"]; isRequest {
+			kept = append(kept, response)
+		}
+	}
+	return kept
+}
+
+// supportedProtocolVersions are the MCP versions the server speaks, newest
+// first. structuredContent and outputSchema need 2025-06-18.
+var supportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// defaultProtocolVersion is used when the client does not request a version.
+const defaultProtocolVersion = "2024-11-05"
+
+func (s *SdkMcpServer) handleInitialize(msgID interface{}, params map[string]interface{}) map[string]interface{} {
+	version := defaultProtocolVersion
+	if requested, ok := params["protocolVersion"].(string); ok {
+		// Use the client's version if supported, otherwise offer the newest
+		version = supportedProtocolVersions[0]
+		for _, supported := range supportedProtocolVersions {
+			if requested == supported {
+				version = requested
+			}
+		}
+	}
+
+	capabilities := map[string]interface{}{
+		"tools": map[string]interface{}{},
+	}
+	if len(s.Resources) > 0 {
+		capabilities["resources"] = map[string]interface{}{}
+	}
+	if len(s.Prompts) > 0 {
+		capabilities["prompts"] = map[string]interface{}{}
+	}
+
 	return map[string]interface{}{
 		"jsonrpc": "2.0",
 		"id":      msgID,
 		"result": map[string]interface{}{
-			"protocolVersion": "2024-11-05",
-			"capabilities": map[string]interface{}{
-				"tools": map[string]interface{}{},
-			},
+			"protocolVersion": version,
+			"capabilities":    capabilities,
 			"serverInfo": map[string]interface{}{
 				"name":    s.Name,
 				"version": s.Version,
@@ -163,6 +283,9 @@ func (s *SdkMcpServer) handleListTools(msgID interface{}) map[string]interface{}
 			"description": tool.Description,
 			"inputSchema": s.convertSchema(tool.InputSchema),
 		}
+		if tool.OutputSchema != nil {
+			tools[i]["outputSchema"] = s.convertSchema(tool.OutputSchema)
+		}
 	}
 
 	return map[string]interface{}{
@@ -190,8 +313,14 @@ func (s *SdkMcpServer) handleCallTool(ctx context.Context, msgID interface{}, pa
 		}
 	}
 
+	if !s.SkipArgumentValidation {
+		if err := validateArguments(s.convertSchema(tool.InputSchema), arguments); err != nil {
+			return jsonRPCError(msgID, -32602, fmt.Sprintf("Invalid arguments for tool '%s': %v", toolName, err))
+		}
+	}
+
 	// Call handler
-	result, err := tool.Handler(ctx, arguments)
+	result, err := tool.Handler(withProgressToken(ctx, params), arguments)
 	if err != nil {
 		return map[string]interface{}{
 			"jsonrpc": "2.0",
@@ -205,6 +334,14 @@ func (s *SdkMcpServer) handleCallTool(ctx context.Context, msgID interface{}, pa
 		}
 	}
 
+	if result, err = normalizeStructuredResult(tool, result); err != nil {
+		return map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      msgID,
+			"result":  ErrorContent(fmt.Sprintf("Error: %v", err)),
+		}
+	}
+
 	return map[string]interface{}{
 		"jsonrpc": "2.0",
 		"id":      msgID,
@@ -212,6 +349,39 @@ func (s *SdkMcpServer) handleCallTool(ctx context.Context, msgID interface{}, pa
 	}
 }
 
+// normalizeStructuredResult checks a tool result's structuredContent. Tools
+// with an output schema must return it unless the result is an error. It is
+// encoded as a JSON object, and a text block with the same JSON is added when
+// the result has no content, for clients that predate structured output.
+func normalizeStructuredResult(tool *SdkMcpTool, result map[string]interface{}) (map[string]interface{}, error) {
+	structured, ok := result["structuredContent"]
+	if !ok || structured == nil {
+		if tool.OutputSchema != nil && result["isError"] != true {
+			return nil, fmt.Errorf("tool %s declares an output schema but returned no structuredContent", tool.Name)
+		}
+		return result, nil
+	}
+
+	data, err := json.Marshal(structured)
+	if err != nil {
+		return nil, fmt.Errorf("tool %s returned structuredContent that cannot be encoded: %w", tool.Name, err)
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil || object == nil {
+		return nil, fmt.Errorf("tool %s returned structuredContent that is not a JSON object", tool.Name)
+	}
+
+	normalized := make(map[string]interface{}, len(result)+1)
+	for k, v := range result {
+		normalized[k] = v
+	}
+	normalized["structuredContent"] = object
+	if _, hasContent := result["content"]; !hasContent {
+		normalized["content"] = []map[string]interface{}{{"type": "text", "text": string(data)}}
+	}
+	return normalized, nil
+}
+
 // convertSchema converts various schema formats to JSON Schema.
 func (s *SdkMcpServer) convertSchema(schema interface{}) map[string]interface{} {
 	// Handle map[string]string (simple type map)
@@ -254,9 +424,14 @@ func (s *SdkMcpServer) convertSchema(schema interface{}) map[string]interface{}
 		}
 	}
 
-	// If it's a struct type, use reflection
-	if reflect.TypeOf(schema).Kind() == reflect.Struct {
-		return s.structToSchema(reflect.TypeOf(schema))
+	// If it's a struct type (or a pointer to one), use reflection
+	if t := reflect.TypeOf(schema); t != nil {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			return s.structToSchema(t, make(map[reflect.Type]bool))
+		}
 	}
 
 	// Default: empty object
@@ -281,72 +456,165 @@ func (s *SdkMcpServer) convertTypeToSchema(typeVal interface{}) map[string]inter
 		return map[string]interface{}{"type": "integer"}
 	case "boolean", "bool":
 		return map[string]interface{}{"type": "boolean"}
+	case "array":
+		return map[string]interface{}{"type": "array"}
+	case "object":
+		return map[string]interface{}{"type": "object"}
 	default:
 		return map[string]interface{}{"type": "string"}
 	}
 }
 
-func (s *SdkMcpServer) structToSchema(t reflect.Type) map[string]interface{} {
+// structToSchema describes a struct's exported fields by their json names,
+// as encoding/json decodes them: fields of embedded structs without a json
+// name are promoted, and fields of the outer struct win. A description tag
+// becomes the property's description, and fields are required unless they
+// are pointers, tagged omitempty or omitzero, or promoted through an
+// embedded pointer. seen holds the struct types being described, so
+// recursive types end in a bare object instead of recursing forever.
+func (s *SdkMcpServer) structToSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	if seen[t] {
+		return map[string]interface{}{"type": "object"}
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
 	properties := make(map[string]interface{})
 	required := make([]string, 0)
+	s.addFields(t, seen, properties, &required, false)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
 
+// addFields adds t's fields to properties, then the fields promoted from its
+// embedded structs, skipping names already present. optional marks fields
+// reached through an embedded pointer, which may stay nil.
+func (s *SdkMcpServer) addFields(t reflect.Type, seen map[reflect.Type]bool, properties map[string]interface{}, required *[]string, optional bool) {
+	var embedded []reflect.StructField
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		jsonTag := field.Tag.Get("json")
-		if jsonTag == "" {
-			jsonTag = field.Name
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
 		}
-
-		// Parse json tag
-		name := jsonTag
-		for idx := 0; idx < len(jsonTag); idx++ {
-			if jsonTag[idx] == ',' {
-				name = jsonTag[:idx]
-				break
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				if !field.IsExported() {
+					continue // encoding/json cannot allocate it
+				}
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				embedded = append(embedded, field)
+				continue
 			}
 		}
-
-		// Skip if "-"
-		if name == "-" {
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, exists := properties[name]; exists {
 			continue
 		}
 
-		properties[name] = s.typeToSchema(field.Type)
+		property := s.typeToSchema(field.Type, seen)
+		if field.Type.Kind() == reflect.Ptr {
+			nullable(property)
+		}
+		if description := field.Tag.Get("description"); description != "" {
+			property["description"] = description
+		}
+		properties[name] = property
 
-		// Check if required (not a pointer)
-		if field.Type.Kind() != reflect.Ptr {
-			required = append(required, name)
+		// Check if required (not a pointer or omitted when empty)
+		if !optional && field.Type.Kind() != reflect.Ptr && !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			*required = append(*required, name)
 		}
 	}
 
-	return map[string]interface{}{
-		"type":       "object",
-		"properties": properties,
-		"required":   required,
+	for _, field := range embedded {
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if seen[fieldType] {
+			continue
+		}
+		seen[fieldType] = true
+		s.addFields(fieldType, seen, properties, required, optional || field.Type.Kind() == reflect.Ptr)
+		delete(seen, fieldType)
+	}
+}
+
+// nullable lets property be null, as a pointer field may be. Properties
+// without a type accept null already.
+func nullable(property map[string]interface{}) {
+	if typ, ok := property["type"].(string); ok {
+		property["type"] = []string{typ, "null"}
 	}
 }
 
-func (s *SdkMcpServer) typeToSchema(t reflect.Type) map[string]interface{} {
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// typeToSchema describes the JSON values encoding/json decodes into t. Types
+// with their own text encoding, such as time.Time, are strings; types with
+// their own JSON encoding, interfaces, and types JSON cannot hold have no
+// type, so any value is accepted.
+func (s *SdkMcpServer) typeToSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return map[string]interface{}{"type": "string"}
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return map[string]interface{}{}
+	}
+
 	switch t.Kind() {
 	case reflect.String:
 		return map[string]interface{}{"type": "string"}
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return map[string]interface{}{"type": "integer"}
 	case reflect.Float32, reflect.Float64:
 		return map[string]interface{}{"type": "number"}
 	case reflect.Bool:
 		return map[string]interface{}{"type": "boolean"}
-	case reflect.Ptr:
-		return s.typeToSchema(t.Elem())
 	case reflect.Struct:
-		return s.structToSchema(t)
-	case reflect.Slice, reflect.Array:
+		return s.structToSchema(t, seen)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"} // Base64, as encoding/json writes []byte
+		}
 		return map[string]interface{}{
 			"type":  "array",
-			"items": s.typeToSchema(t.Elem()),
+			"items": s.typeToSchema(t.Elem(), seen),
+		}
+	case reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": s.typeToSchema(t.Elem(), seen),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": s.typeToSchema(t.Elem(), seen),
 		}
 	default:
-		return map[string]interface{}{"type": "string"}
+		// Interfaces hold any value; channels, funcs, and complex numbers
+		// cannot be decoded at all
+		return map[string]interface{}{}
 	}
 }
 
@@ -382,6 +650,7 @@ func ErrorContent(text string) map[string]interface{} {
 // ImageContent creates a response with image content.
 // The data parameter should be a base64-encoded string.
 // Common MIME types: "image/png", "image/jpeg", "image/gif", "image/webp"
+// The data is not checked; NewImageContent validates and can downscale.
 //
 // Example:
 //
@@ -395,7 +664,30 @@ func ImageContent(data string, mimeType string) map[string]interface{} {
 	}
 }
 
+// StructuredContent creates a response whose structuredContent is v, which
+// must encode to a JSON object (a struct or map), with the same JSON as text
+// content for clients that do not read structured results.
+//
+// Example:
+//
+//	return mcp.StructuredContent(Forecast{TempC: 21, Summary: "Sunny"})
+func StructuredContent(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil || object == nil {
+		return nil, fmt.Errorf("structured content must be a JSON object, got %s", data)
+	}
+	return map[string]interface{}{
+		"content":           []map[string]interface{}{{"type": "text", "text": string(data)}},
+		"structuredContent": object,
+	}, nil
+}
+
 // MixedContent creates a response with multiple content blocks (text, images, etc).
+// Images are not checked; see NewMixedContent.
 //
 // Example:
 //
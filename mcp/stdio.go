@@ -0,0 +1,217 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// ServeStdio runs server as an external stdio MCP server: it reads
+// JSON-RPC messages from stdin and writes responses to stdout, so the same
+// tools can be used by other MCP clients or configured in the CLI as a
+// command. Stdout carries the protocol, so log to stderr. See Serve.
+//
+// Example:
+//
+//	func main() {
+//	    server := mcp.CreateSdkMcpServer("calc", "1.0.0", []*mcp.SdkMcpTool{addTool})
+//	    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+//	    defer stop()
+//	    if err := mcp.ServeStdio(ctx, server); err != nil && !errors.Is(err, context.Canceled) {
+//	        log.Fatal(err)
+//	    }
+//	}
+func ServeStdio(ctx context.Context, server *SdkMcpServer) error {
+	return Serve(ctx, server, os.Stdin, os.Stdout)
+}
+
+// Serve runs server over newline-delimited JSON-RPC: each line read from r
+// is a request, notification, or batch, and each response is written to w
+// as one line. Requests run concurrently, and notifications/cancelled
+// cancels the context of the request it names, whose response is then
+// dropped. Notifications from handlers, such as ReportProgress, are
+// written to w as they are sent.
+//
+// Serve returns nil when r ends, once in-flight requests have answered.
+// When ctx is done it cancels in-flight requests, waits for their handlers
+// to return, and returns ctx.Err(). A failure to write a response is
+// returned after r ends.
+func Serve(ctx context.Context, server *SdkMcpServer, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s := &stdioSession{server: server, w: w}
+	ctx = claude.WithMcpNotifier(ctx, s.notify)
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(r)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.requests.Wait()
+			return ctx.Err()
+		case err := <-readErr:
+			s.requests.Wait()
+			if err != io.EOF {
+				return fmt.Errorf("failed to read MCP message: %w", err)
+			}
+			return s.err()
+		case line := <-lines:
+			s.handle(ctx, line)
+		}
+	}
+}
+
+// stdioSession is the state of one Serve call.
+type stdioSession struct {
+	server   *SdkMcpServer
+	requests sync.WaitGroup
+	inflight inflightCalls
+
+	mu       sync.Mutex // Guards w and writeErr
+	w        io.Writer
+	writeErr error
+}
+
+// handle dispatches one line read from the client.
+func (s *stdioSession) handle(ctx context.Context, line []byte) {
+	if line[0] == '[' {
+		var batch []map[string]interface{}
+		if err := json.Unmarshal(line, &batch); err != nil {
+			s.write(jsonRPCError(nil, -32700, fmt.Sprintf("Parse error: %v", err)))
+			return
+		}
+		s.requests.Add(1)
+		go func() {
+			defer s.requests.Done()
+			if responses := s.server.HandleBatch(ctx, batch); len(responses) > 0 {
+				s.write(responses)
+			}
+		}()
+		return
+	}
+
+	var message map[string]interface{}
+	if err := json.Unmarshal(line, &message); err != nil {
+		s.write(jsonRPCError(nil, -32700, fmt.Sprintf("Parse error: %v", err)))
+		return
+	}
+	id, isRequest := message["id"]
+	if !isRequest {
+		// Notifications get no response
+		s.inflight.notify(ctx, s.server, message)
+		return
+	}
+
+	requestCtx, done := s.inflight.start(ctx, id)
+	s.requests.Add(1)
+	go func() {
+		defer s.requests.Done()
+		response := s.server.HandleRequest(requestCtx, message)
+		cancelled := requestCtx.Err() != nil && ctx.Err() == nil
+		done()
+		if !cancelled {
+			s.write(response)
+		}
+	}()
+}
+
+// write sends v to the client as one line, remembering the first failure.
+func (s *stdioSession) write(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(jsonRPCError(nil, -32603, fmt.Sprintf("Internal error: %v", err)))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(data, '\n')); err != nil && s.writeErr == nil {
+		s.writeErr = fmt.Errorf("failed to write MCP response: %w", err)
+	}
+}
+
+// notify sends a notification from a handler, such as a progress report.
+func (s *stdioSession) notify(ctx context.Context, notification map[string]interface{}) error {
+	s.write(notification)
+	return s.err()
+}
+
+// err returns the first write failure, if any.
+func (s *stdioSession) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeErr
+}
+
+// inflightCalls tracks in-flight requests so that notifications/cancelled
+// can cancel them. The zero value is ready to use.
+type inflightCalls struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc // By encoded request ID
+}
+
+// start returns the context of request id and a function to call when it
+// has been answered.
+func (c *inflightCalls) start(ctx context.Context, id interface{}) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	key := requestKey(id)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancels == nil {
+		c.cancels = make(map[string]context.CancelFunc)
+	}
+	c.cancels[key] = cancel
+	return ctx, func() {
+		c.mu.Lock()
+		delete(c.cancels, key)
+		c.mu.Unlock()
+		cancel()
+	}
+}
+
+// notify handles a notification: notifications/cancelled cancels the
+// request it names, and others go to server.
+func (c *inflightCalls) notify(ctx context.Context, server *SdkMcpServer, message map[string]interface{}) {
+	if message["method"] != "notifications/cancelled" {
+		server.HandleRequest(ctx, message)
+		return
+	}
+	params, _ := message["params"].(map[string]interface{})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cancel, ok := c.cancels[requestKey(params["requestId"])]; ok {
+		cancel()
+	}
+}
+
+// requestKey encodes a JSON-RPC ID so that 7 and "7" stay distinct.
+func requestKey(id interface{}) string {
+	data, err := json.Marshal(id)
+	if err != nil {
+		return fmt.Sprint(id)
+	}
+	return string(data)
+}
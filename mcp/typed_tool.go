@@ -0,0 +1,63 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// TypedTool creates a tool whose arguments are decoded into T, a struct,
+// before handler runs. The input schema is derived from T's fields as
+// encoding/json decodes them: json tags name the properties, embedded
+// structs are flattened, a description tag describes them, and fields are
+// required unless they are pointers or tagged omitempty or omitzero.
+// Calls missing a required argument, or with arguments that do not decode
+// into T, fail without calling handler.
+//
+// Example:
+//
+//	type addArgs struct {
+//	    A float64 `json:"a" description:"First addend"`
+//	    B float64 `json:"b" description:"Second addend"`
+//	}
+//	add := mcp.TypedTool("add", "Add two numbers",
+//	    func(ctx context.Context, args addArgs) (map[string]interface{}, error) {
+//	        return mcp.TextContent(fmt.Sprintf("%g", args.A+args.B)), nil
+//	    })
+func TypedTool[T any](
+	name string,
+	description string,
+	handler func(context.Context, T) (map[string]interface{}, error),
+) *SdkMcpTool {
+	var zero T
+	var server SdkMcpServer
+	schema := server.convertSchema(zero)
+	required, _ := schema["required"].([]string)
+
+	return Tool(name, description, schema, func(ctx context.Context, arguments map[string]interface{}) (map[string]interface{}, error) {
+		for _, field := range required {
+			if _, ok := arguments[field]; !ok {
+				return nil, fmt.Errorf("missing required argument %q", field)
+			}
+		}
+		args, err := decodeArguments[T](arguments)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, args)
+	})
+}
+
+// decodeArguments converts tool call arguments into T through their JSON
+// encoding.
+func decodeArguments[T any](arguments map[string]interface{}) (T, error) {
+	var args T
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return args, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := json.Unmarshal(data, &args); err != nil {
+		return args, fmt.Errorf("invalid arguments: %w", err)
+	}
+	return args, nil
+}
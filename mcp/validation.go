@@ -0,0 +1,206 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// validateArguments checks tools/call arguments against a tool's JSON
+// schema and returns the first violation found. It checks required
+// properties, types, and enums, in nested objects and arrays too; other
+// keywords are not enforced. A null value is only accepted where the schema's
+// type includes "null", even for optional properties.
+func validateArguments(schema map[string]interface{}, arguments map[string]interface{}) error {
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	return validateValue(schema, arguments, "")
+}
+
+// validateValue checks value against schema. path names value in errors,
+// e.g. "filters.tags[0]"; it is empty for the arguments object itself.
+func validateValue(schema map[string]interface{}, value interface{}, path string) error {
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, typ := range types {
+			if hasJSONType(value, typ) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s must be %s, got %s", describePath(path), strings.Join(types, " or "), jsonTypeOf(value))
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		if !inEnum(enum, value) {
+			return fmt.Errorf("%s must be one of %s", describePath(path), encodeEnum(enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schemaStrings(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("missing required argument %q", joinPath(path, name))
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := properties[name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if err := validateValue(property, v[name], joinPath(path, name)); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		items, ok := schema["items"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range v {
+			if err := validateValue(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// schemaTypes returns the types allowed by a schema's "type" keyword.
+func schemaTypes(typ interface{}) []string {
+	if s, ok := typ.(string); ok {
+		return []string{s}
+	}
+	return schemaStrings(typ)
+}
+
+// schemaStrings returns a keyword's string list, written as []string or
+// decoded from JSON as []interface{}.
+func schemaStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		strs := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	}
+	return nil
+}
+
+// hasJSONType reports whether value is of JSON Schema type typ. Unknown
+// types match anything.
+func hasJSONType(value interface{}, typ string) bool {
+	switch typ {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := toNumber(value)
+		return ok
+	case "integer":
+		n, ok := toNumber(value)
+		return ok && n == math.Trunc(n)
+	case "object":
+		v := reflect.ValueOf(value)
+		return v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String
+	case "array":
+		kind := reflect.ValueOf(value).Kind()
+		return kind == reflect.Slice || kind == reflect.Array
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// jsonTypeOf names the JSON type of value, for errors.
+func jsonTypeOf(value interface{}) string {
+	for _, typ := range []string{"null", "boolean", "integer", "number", "string", "array", "object"} {
+		if hasJSONType(value, typ) {
+			return typ
+		}
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// toNumber returns value as a float64 if it is a number.
+func toNumber(value interface{}) (float64, bool) {
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	}
+	return 0, false
+}
+
+// inEnum reports whether value equals one of enum's values. Numbers are
+// compared by value, so 1 matches 1.0.
+func inEnum(enum interface{}, value interface{}) bool {
+	values := reflect.ValueOf(enum)
+	if values.Kind() != reflect.Slice && values.Kind() != reflect.Array {
+		return true
+	}
+	n, isNumber := toNumber(value)
+	for i := 0; i < values.Len(); i++ {
+		allowed := values.Index(i).Interface()
+		if m, ok := toNumber(allowed); ok && isNumber {
+			if m == n {
+				return true
+			}
+		} else if reflect.DeepEqual(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeEnum formats enum values for errors.
+func encodeEnum(enum interface{}) string {
+	data, err := json.Marshal(enum)
+	if err != nil {
+		return fmt.Sprint(enum)
+	}
+	return string(data)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return "arguments"
+	}
+	return fmt.Sprintf("argument %q", path)
+}
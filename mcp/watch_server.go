@@ -0,0 +1,603 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// Defaults for WatchServerOptions.
+const (
+	defaultWatchServerName   = "watch"
+	defaultWatchInterval     = 500 * time.Millisecond
+	defaultWatchMaxChanges   = 100
+	defaultWatchMaxFileBytes = 256 * 1024
+	maxDiffCells             = 4 << 20 // Line pairs compared before giving up on a diff
+)
+
+// defaultWatchIgnore are the base names skipped when walking directories.
+var defaultWatchIgnore = []string{".git", "node_modules", ".DS_Store"}
+
+// FileChangeKind describes what happened to a watched file.
+type FileChangeKind string
+
+const (
+	FileCreated  FileChangeKind = "created"
+	FileModified FileChangeKind = "modified"
+	FileRemoved  FileChangeKind = "removed"
+)
+
+// FileChange is one change observed by a WatchServer.
+type FileChange struct {
+	Path string         `json:"path"`
+	Kind FileChangeKind `json:"kind"`
+	Time time.Time      `json:"time"`
+	// Diff is a unified diff of the change, or a one-line summary for binary
+	// files and files larger than MaxFileBytes.
+	Diff string `json:"diff"`
+}
+
+// WatchServerOptions configures a WatchServer. The zero value uses defaults.
+type WatchServerOptions struct {
+	Name         string        // Server name, used in tool names (default: "watch")
+	Interval     time.Duration // How often files are checked (default: 500ms)
+	MaxChanges   int           // Changes kept for recent_changes (default: 100)
+	MaxFileBytes int           // Larger files are reported without a diff (default: 256KB)
+	// Ignore holds base-name globs skipped when walking directories
+	// (default: .git, node_modules, .DS_Store)
+	Ignore []string
+}
+
+// WatchServer is an SDK MCP server that watches files the human is editing
+// and lets Claude react to them. It exposes four tools:
+//
+//   - subscribe: push changes to the given paths (default: all watched
+//     paths) into the conversation
+//   - unsubscribe: stop pushing changes to the given paths (default: all)
+//   - recent_changes: list recent changes with their diffs
+//   - get_diff: the diffs of recent changes to one file
+//
+// Changes to subscribed paths are pushed by the hooks from Hooks, which add
+// them as context on the next prompt or tool result:
+//
+//	watch, err := mcp.NewWatchServer([]string{"./src"}, mcp.WatchServerOptions{})
+//	if err != nil { ... }
+//	defer watch.Close()
+//	options := &claude.ClaudeAgentOptions{
+//	    McpServers: map[string]claude.McpServerConfig{"watch": watch.ToConfig()},
+//	    Hooks:      watch.Hooks(),
+//	}
+type WatchServer struct {
+	*SdkMcpServer
+
+	paths    []string
+	options  WatchServerOptions
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu         sync.Mutex
+	files      map[string]watchedFile
+	changes    []FileChange // Newest last, at most MaxChanges
+	pending    []FileChange // Subscribed changes not yet pushed
+	subscribed []string     // Path prefixes; nil pushes nothing
+}
+
+// watchedFile is the last observed state of a file.
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+	content []byte // nil if binary or too large
+}
+
+// NewWatchServer creates a WatchServer for paths, which may be files or
+// directories (watched recursively), and starts polling them. Call Close to
+// stop.
+func NewWatchServer(paths []string, options WatchServerOptions) (*WatchServer, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("watch server needs at least one path")
+	}
+	if options.Name == "" {
+		options.Name = defaultWatchServerName
+	}
+	if options.Interval <= 0 {
+		options.Interval = defaultWatchInterval
+	}
+	if options.MaxChanges <= 0 {
+		options.MaxChanges = defaultWatchMaxChanges
+	}
+	if options.MaxFileBytes <= 0 {
+		options.MaxFileBytes = defaultWatchMaxFileBytes
+	}
+	if options.Ignore == nil {
+		options.Ignore = defaultWatchIgnore
+	}
+
+	s := &WatchServer{
+		options: options,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		files:   make(map[string]watchedFile),
+	}
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return nil, fmt.Errorf("watch server: %w", err)
+		}
+		s.paths = append(s.paths, abs)
+	}
+	s.files = s.scan(s.files)
+
+	s.SdkMcpServer = CreateSdkMcpServer(options.Name, "1.0.0", []*SdkMcpTool{
+		Tool("subscribe", "Start receiving changes the user makes to files under the given paths as conversation context. Omit paths to subscribe to everything watched.",
+			pathsSchema, s.handleSubscribe),
+		Tool("unsubscribe", "Stop receiving changes to files under the given paths. Omit paths to unsubscribe from everything.",
+			pathsSchema, s.handleUnsubscribe),
+		Tool("recent_changes", "List recent changes to watched files, oldest first, with unified diffs.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"limit": map[string]interface{}{"type": "integer", "description": "Most recent changes to return (default: 20)"},
+				},
+			}, s.handleRecentChanges),
+		Tool("get_diff", "Show the diffs of recent changes to one file.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{"type": "string", "description": "File path"},
+				},
+				"required": []string{"path"},
+			}, s.handleGetDiff),
+	})
+
+	go s.poll()
+	return s, nil
+}
+
+// pathsSchema is the input schema of subscribe and unsubscribe.
+var pathsSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"paths": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Files or directories; relative paths are resolved against the working directory",
+		},
+	},
+}
+
+// Close stops polling. The tools keep answering from the changes seen so far.
+func (s *WatchServer) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+	return nil
+}
+
+// Changes returns the recent changes, oldest first.
+func (s *WatchServer) Changes() []FileChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]FileChange(nil), s.changes...)
+}
+
+// Subscribe pushes changes under paths into the conversation, as the
+// subscribe tool does. With no paths, every watched path is subscribed.
+func (s *WatchServer) Subscribe(paths ...string) {
+	if len(paths) == 0 {
+		paths = s.paths
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			continue
+		}
+		s.subscribed = append(s.subscribed, abs)
+	}
+}
+
+// Hooks returns UserPromptSubmit and PostToolUse hooks that add the pending
+// changes to subscribed paths as additional context, so Claude sees edits
+// on its next turn or after its next tool call. Merge them into
+// ClaudeAgentOptions.Hooks.
+func (s *WatchServer) Hooks() map[claude.HookEvent][]claude.HookMatcher {
+	hook := []claude.HookCallback{s.contextHook}
+	return map[claude.HookEvent][]claude.HookMatcher{
+		claude.HookEventUserPromptSubmit: {{Hooks: hook}},
+		claude.HookEventPostToolUse:      {{Matcher: "*", Hooks: hook}},
+	}
+}
+
+// contextHook drains the pending changes into additionalContext.
+func (s *WatchServer) contextHook(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return claude.HookJSONOutput{}, nil
+	}
+
+	event, _ := input["hook_event_name"].(string)
+	var b strings.Builder
+	b.WriteString("The user changed watched files since you last looked:\n")
+	for _, change := range pending {
+		b.WriteString("\n")
+		b.WriteString(formatChange(change))
+	}
+	return claude.HookJSONOutput{
+		HookSpecificOutput: map[string]interface{}{
+			"hookEventName":     event,
+			"additionalContext": b.String(),
+		},
+	}, nil
+}
+
+func (s *WatchServer) handleSubscribe(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	paths := stringArgs(args["paths"])
+	s.Subscribe(paths...)
+	if len(paths) == 0 {
+		return TextContent("Subscribed to all watched paths: " + strings.Join(s.paths, ", ")), nil
+	}
+	return TextContent("Subscribed to " + strings.Join(paths, ", ")), nil
+}
+
+func (s *WatchServer) handleUnsubscribe(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	paths := stringArgs(args["paths"])
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(paths) == 0 {
+		s.subscribed = nil
+		s.pending = nil
+		return TextContent("Unsubscribed from all paths"), nil
+	}
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			continue
+		}
+		kept := s.subscribed[:0]
+		for _, sub := range s.subscribed {
+			if !underPath(sub, abs) {
+				kept = append(kept, sub)
+			}
+		}
+		s.subscribed = kept
+	}
+	return TextContent("Unsubscribed from " + strings.Join(paths, ", ")), nil
+}
+
+func (s *WatchServer) handleRecentChanges(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	limit := 20
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		limit = int(n)
+	}
+	changes := s.Changes()
+	if len(changes) > limit {
+		changes = changes[len(changes)-limit:]
+	}
+	if len(changes) == 0 {
+		return TextContent("No changes to watched files."), nil
+	}
+	return TextContent(formatChanges(changes)), nil
+}
+
+func (s *WatchServer) handleGetDiff(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	p, _ := args["path"].(string)
+	if p == "" {
+		return ErrorContent("path is required"), nil
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return ErrorContent(err.Error()), nil
+	}
+	var changes []FileChange
+	for _, change := range s.Changes() {
+		if change.Path == abs {
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) == 0 {
+		return TextContent("No recent changes to " + abs + "."), nil
+	}
+	return TextContent(formatChanges(changes)), nil
+}
+
+// poll rescans the watched paths every Interval until Close.
+func (s *WatchServer) poll() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			previous := s.files
+			s.mu.Unlock()
+			// Only poll writes files, so scanning without the lock is safe
+			current := s.scan(previous)
+			s.record(previous, current)
+		}
+	}
+}
+
+// scan returns the state of every watched file, reusing previous entries
+// whose modification time and size are unchanged.
+func (s *WatchServer) scan(previous map[string]watchedFile) map[string]watchedFile {
+	current := make(map[string]watchedFile, len(previous))
+	for _, root := range s.paths {
+		_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Removed while walking; reported as removed if it was known
+			}
+			if p != root && s.ignored(d.Name()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if old, ok := previous[p]; ok && old.modTime.Equal(info.ModTime()) && old.size == info.Size() {
+				current[p] = old
+				return nil
+			}
+			file := watchedFile{modTime: info.ModTime(), size: info.Size()}
+			if info.Size() <= int64(s.options.MaxFileBytes) {
+				if content, err := os.ReadFile(p); err == nil && !isBinary(content) {
+					file.content = content
+				}
+			}
+			current[p] = file
+			return nil
+		})
+	}
+	return current
+}
+
+// ignored reports whether a base name matches an Ignore glob.
+func (s *WatchServer) ignored(name string) bool {
+	for _, pattern := range s.options.Ignore {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// record diffs two scans and stores the changes.
+func (s *WatchServer) record(previous, current map[string]watchedFile) {
+	now := time.Now()
+	var changes []FileChange
+	for p, file := range current {
+		old, existed := previous[p]
+		switch {
+		case !existed:
+			changes = append(changes, FileChange{Path: p, Kind: FileCreated, Time: now, Diff: s.diff(p, nil, &file)})
+		case !old.modTime.Equal(file.modTime) || old.size != file.size:
+			if old.content != nil && file.content != nil && bytes.Equal(old.content, file.content) {
+				continue // Touched but not changed
+			}
+			changes = append(changes, FileChange{Path: p, Kind: FileModified, Time: now, Diff: s.diff(p, &old, &file)})
+		}
+	}
+	for p, old := range previous {
+		if _, ok := current[p]; !ok {
+			changes = append(changes, FileChange{Path: p, Kind: FileRemoved, Time: now, Diff: s.diff(p, &old, nil)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files = current
+	for _, change := range changes {
+		s.changes = append(s.changes, change)
+		if s.isSubscribed(change.Path) {
+			s.pending = append(s.pending, change)
+		}
+	}
+	if excess := len(s.changes) - s.options.MaxChanges; excess > 0 {
+		s.changes = append([]FileChange(nil), s.changes[excess:]...)
+	}
+	if excess := len(s.pending) - s.options.MaxChanges; excess > 0 {
+		s.pending = append([]FileChange(nil), s.pending[excess:]...)
+	}
+}
+
+// isSubscribed reports whether p is under a subscribed path. Callers hold mu.
+func (s *WatchServer) isSubscribed(p string) bool {
+	for _, sub := range s.subscribed {
+		if underPath(p, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// diff describes the change from old to cur; either may be nil for
+// creation and removal.
+func (s *WatchServer) diff(p string, old, cur *watchedFile) string {
+	var oldContent, newContent []byte
+	if old != nil {
+		if old.content == nil {
+			return fmt.Sprintf("%s: binary or large file changed (%d -> %s bytes)", p, old.size, sizeOf(cur))
+		}
+		oldContent = old.content
+	}
+	if cur != nil {
+		if cur.content == nil {
+			return fmt.Sprintf("%s: binary or large file (%d bytes)", p, cur.size)
+		}
+		newContent = cur.content
+	}
+	return unifiedDiff(p, splitLines(oldContent), splitLines(newContent))
+}
+
+func sizeOf(file *watchedFile) string {
+	if file == nil {
+		return "removed"
+	}
+	return fmt.Sprint(file.size)
+}
+
+// underPath reports whether p is dir or inside it.
+func underPath(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, dir+string(filepath.Separator))
+}
+
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+func stringArgs(v interface{}) []string {
+	items, _ := v.([]interface{})
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func formatChange(change FileChange) string {
+	return fmt.Sprintf("%s %s at %s\n%s", change.Kind, change.Path, change.Time.Format(time.TimeOnly), change.Diff)
+}
+
+func formatChanges(changes []FileChange) string {
+	parts := make([]string, len(changes))
+	for i, change := range changes {
+		parts[i] = formatChange(change)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// unifiedDiff returns a unified diff of two line slices with three lines of
+// context, computed from their longest common subsequence.
+func unifiedDiff(name string, a, b []string) string {
+	if len(a)*len(b) > maxDiffCells {
+		return fmt.Sprintf("%s: too large to diff (%d -> %d lines)", name, len(a), len(b))
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type op struct {
+		kind byte // ' ', '-', '+'
+		line string
+		ai   int // Line indexes before the op
+		bi   int
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', a[i], i, j})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	const contextLines = 3
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a%s\n+++ b%s\n", filepath.ToSlash(name), filepath.ToSlash(name))
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+		// Extend the hunk while changes are within 2*context lines
+		end := start
+		for k := start; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				end = k + 1
+			} else if k-end >= 2*contextLines {
+				break
+			}
+		}
+		from := max(start-contextLines, 0)
+		to := min(end+contextLines, len(ops))
+
+		aCount, bCount := 0, 0
+		for _, o := range ops[from:to] {
+			if o.kind != '+' {
+				aCount++
+			}
+			if o.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(ops[from].ai, aCount), hunkRange(ops[from].bi, bCount))
+		for _, o := range ops[from:to] {
+			out.WriteByte(o.kind)
+			out.WriteString(o.line)
+			if !strings.HasSuffix(o.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// hunkRange formats a unified diff range from a 0-based start.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
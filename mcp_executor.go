@@ -0,0 +1,146 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// mcpRequestHandler is implemented by in-process MCP servers such as mcp.SdkMcpServer.
+type mcpRequestHandler interface {
+	HandleRequest(ctx context.Context, message map[string]interface{}) map[string]interface{}
+}
+
+// mcpExecutor runs MCP requests for a single SDK server on a bounded worker pool.
+// It implements mcpRequestHandler so it can stand in for the server it wraps.
+type mcpExecutor struct {
+	handler mcpRequestHandler
+	config  McpServerExecutor
+	jobs    chan mcpJob
+	slots   chan struct{} // Held by each accepted request until a worker finishes it
+	done    chan struct{}
+	once    sync.Once
+}
+
+type mcpJob struct {
+	ctx      context.Context
+	message  map[string]interface{}
+	response chan map[string]interface{}
+}
+
+// newMcpExecutor creates an executor and starts its workers.
+func newMcpExecutor(handler mcpRequestHandler, config McpServerExecutor) *mcpExecutor {
+	if config.PoolSize <= 0 {
+		config.PoolSize = 1
+	}
+	if config.QueueDepth < 0 {
+		config.QueueDepth = 0
+	}
+
+	e := &mcpExecutor{
+		handler: handler,
+		config:  config,
+		jobs:    make(chan mcpJob, config.PoolSize+config.QueueDepth),
+		slots:   make(chan struct{}, config.PoolSize+config.QueueDepth),
+		done:    make(chan struct{}),
+	}
+
+	for i := 0; i < config.PoolSize; i++ {
+		go e.worker()
+	}
+
+	return e
+}
+
+// HandleRequest queues the request on the pool and waits for its response.
+// If every worker is busy and the queue is full, the request is rejected
+// immediately rather than blocking the control protocol. A request holds
+// its slot until a worker has finished it, even if the caller gave up, so
+// a worker stuck in a handler counts as busy.
+func (e *mcpExecutor) HandleRequest(ctx context.Context, message map[string]interface{}) map[string]interface{} {
+	if e.config.CallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.config.CallTimeout)
+		defer cancel()
+	}
+
+	job := mcpJob{
+		ctx:      ctx,
+		message:  message,
+		response: make(chan map[string]interface{}, 1),
+	}
+
+	select {
+	case <-e.done:
+		return mcpErrorResponse(message["id"], -32000, "MCP server executor is closed")
+	default:
+	}
+
+	select {
+	case e.slots <- struct{}{}:
+	default:
+		return mcpErrorResponse(message["id"], -32000, "MCP server is busy: request queue is full")
+	}
+	// Never blocks: jobs has room for every slot
+	e.jobs <- job
+
+	select {
+	case response := <-job.response:
+		return response
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return mcpErrorResponse(message["id"], -32000, fmt.Sprintf("MCP request timed out after %s", e.config.CallTimeout))
+		}
+		return mcpErrorResponse(message["id"], -32000, "MCP request cancelled")
+	case <-e.done:
+		return mcpErrorResponse(message["id"], -32000, "MCP server executor is closed")
+	}
+}
+
+// worker processes jobs until the executor is closed.
+func (e *mcpExecutor) worker() {
+	for {
+		select {
+		case <-e.done:
+			return
+		case job := <-e.jobs:
+			if job.ctx.Err() == nil {
+				job.response <- e.run(job)
+			}
+			// Otherwise the caller already gave up while the job was queued
+			<-e.slots
+		}
+	}
+}
+
+// run invokes the wrapped handler, optionally converting panics into JSON-RPC errors.
+func (e *mcpExecutor) run(job mcpJob) (response map[string]interface{}) {
+	if e.config.IsolatePanics {
+		defer func() {
+			if r := recover(); r != nil {
+				response = mcpErrorResponse(job.message["id"], -32603, fmt.Sprintf("MCP server panicked: %v", r))
+			}
+		}()
+	}
+	return e.handler.HandleRequest(job.ctx, job.message)
+}
+
+// Close stops the workers. In-flight handlers are not interrupted but their
+// responses are discarded.
+func (e *mcpExecutor) Close() {
+	e.once.Do(func() {
+		close(e.done)
+	})
+}
+
+// mcpErrorResponse builds a JSON-RPC error response.
+func mcpErrorResponse(id interface{}, code int, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+		},
+	}
+}
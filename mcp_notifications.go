@@ -0,0 +1,59 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// McpNotifyFunc sends a JSON-RPC notification, such as
+// notifications/progress, from an SDK MCP server to its client.
+type McpNotifyFunc func(ctx context.Context, notification map[string]interface{}) error
+
+type mcpNotifierKey struct{}
+
+// WithMcpNotifier returns a context through which an MCP server handling a
+// request can send notifications to the client that made it. The SDK sets
+// one on the context of every SDK MCP request it routes, forwarding the
+// notifications to Claude Code; mcp.Serve and mcp.NewHTTPHandler set their
+// own. Tool handlers normally use mcp.ReportProgress rather than this.
+func WithMcpNotifier(ctx context.Context, notify McpNotifyFunc) context.Context {
+	return context.WithValue(ctx, mcpNotifierKey{}, notify)
+}
+
+// McpNotifierFromContext returns the notifier set by WithMcpNotifier, or
+// nil.
+func McpNotifierFromContext(ctx context.Context) McpNotifyFunc {
+	if ctx == nil {
+		return nil
+	}
+	notify, _ := ctx.Value(mcpNotifierKey{}).(McpNotifyFunc)
+	return notify
+}
+
+// mcpNotifier returns a notifier forwarding notifications from SDK MCP
+// server serverName to the CLI, as mcp_message control requests. They are
+// not awaited: the CLI's acknowledgement is ignored, so a slow CLI does not
+// hold up the tool sending them.
+func (q *queryHandler) mcpNotifier(serverName string) McpNotifyFunc {
+	return func(ctx context.Context, notification map[string]interface{}) error {
+		q.mu.Lock()
+		q.requestCounter++
+		requestID := fmt.Sprintf("req_%d_%s", q.requestCounter, randomHex(4))
+		q.mu.Unlock()
+
+		data, err := json.Marshal(ControlRequest{
+			Type:      MessageTypeControlRequest,
+			RequestID: requestID,
+			Request: map[string]interface{}{
+				"subtype":     string(ControlSubtypeMcpMessage),
+				"server_name": serverName,
+				"message":     notification,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode MCP notification: %w", err)
+		}
+		return q.transport.Write(ctx, string(data)+"\n")
+	}
+}
@@ -0,0 +1,181 @@
+package claude
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// mcpToolLister is implemented by in-process MCP servers that can report their
+// tool names without a JSON-RPC round trip (e.g. mcp.SdkMcpServer).
+type mcpToolLister interface {
+	ToolNames() []string
+}
+
+// mcpResourceLister is implemented by in-process MCP servers that can report
+// their resource URIs (e.g. mcp.SdkMcpServer).
+type mcpResourceLister interface {
+	ResourceURIs() []string
+}
+
+// ResourceURIs returns the URIs of the resources the server provides, or nil
+// if its instance cannot list them.
+func (c McpSdkServerConfig) ResourceURIs() []string {
+	if lister, ok := c.Instance.(mcpResourceLister); ok {
+		return lister.ResourceURIs()
+	}
+	return nil
+}
+
+// McpToolName returns the fully-qualified name Claude Code uses for an MCP tool:
+// mcp__{server}__{tool}. Use it when building AllowedTools or matching hooks.
+func McpToolName(serverName, toolName string) string {
+	return fmt.Sprintf("mcp__%s__%s", serverName, toolName)
+}
+
+// McpAllowedTools returns the fully-qualified names of every tool exposed by
+// the SDK MCP servers in servers, sorted. External (stdio/SSE/HTTP) servers are
+// skipped because their tools are only known to the CLI. If any SDK server
+// has resources, the CLI's ListMcpResourcesTool and ReadMcpResourceTool are
+// included so Claude can read them.
+//
+// Example:
+//
+//	options.AllowedTools = append(options.AllowedTools, claude.McpAllowedTools(options.McpServers)...)
+func McpAllowedTools(servers map[string]McpServerConfig) []string {
+	var names []string
+	for serverName, toolNames := range sdkMcpTools(servers) {
+		for _, tool := range toolNames {
+			names = append(names, McpToolName(serverName, tool))
+		}
+	}
+	for _, config := range servers {
+		if sdkConfig, ok := config.(McpSdkServerConfig); ok && len(sdkConfig.ResourceURIs()) > 0 {
+			names = append(names, ListMcpResourcesInput{}.ToolName(), ReadMcpResourceInput{}.ToolName())
+			break
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateMcpTools checks the SDK MCP servers configured in options.
+//
+// It returns an error if two tools resolve to the same fully-qualified name
+// (including duplicate tool names within one server). It returns warnings for
+// tool names shared by several servers, and for tools not covered by
+// AllowedTools when AllowedTools is non-empty.
+//
+// Connect(), Query(), and QueryStream() run this automatically, returning the
+// error and logging warnings to ClaudeAgentOptions.Logger.
+func ValidateMcpTools(options *ClaudeAgentOptions) (warnings []string, err error) {
+	if options == nil {
+		return nil, nil
+	}
+
+	tools := sdkMcpTools(options.McpServers)
+
+	serverNames := make([]string, 0, len(tools))
+	for name := range tools {
+		serverNames = append(serverNames, name)
+	}
+	sort.Strings(serverNames)
+
+	qualified := make(map[string]string)    // Fully-qualified name -> server
+	bareOwners := make(map[string][]string) // Bare tool name -> servers
+
+	for _, serverName := range serverNames {
+		for _, tool := range tools[serverName] {
+			fullName := McpToolName(serverName, tool)
+			if owner, exists := qualified[fullName]; exists {
+				servers := []string{owner}
+				if owner != serverName {
+					servers = append(servers, serverName)
+				}
+				return nil, NewMcpToolCollisionError(fullName, servers)
+			}
+			qualified[fullName] = serverName
+			bareOwners[tool] = append(bareOwners[tool], serverName)
+		}
+	}
+
+	bareNames := make([]string, 0, len(bareOwners))
+	for tool := range bareOwners {
+		bareNames = append(bareNames, tool)
+	}
+	sort.Strings(bareNames)
+
+	for _, tool := range bareNames {
+		if owners := bareOwners[tool]; len(owners) > 1 {
+			warnings = append(warnings, fmt.Sprintf(
+				"MCP tool %q is defined by servers %s; reference it by its fully-qualified name (e.g. %s)",
+				tool, strings.Join(owners, ", "), McpToolName(owners[0], tool)))
+		}
+	}
+
+	if len(options.AllowedTools) > 0 {
+		fullNames := make([]string, 0, len(qualified))
+		for name := range qualified {
+			fullNames = append(fullNames, name)
+		}
+		sort.Strings(fullNames)
+
+		for _, fullName := range fullNames {
+			if !allowedToolsCover(options.AllowedTools, qualified[fullName], fullName) {
+				warnings = append(warnings, fmt.Sprintf(
+					"MCP tool %s is not covered by AllowedTools and will require permission", fullName))
+			}
+		}
+	}
+
+	return warnings, nil
+}
+
+// validateMcpToolsAtConnect runs ValidateMcpTools and logs warnings.
+func validateMcpToolsAtConnect(options *ClaudeAgentOptions) error {
+	warnings, err := ValidateMcpTools(options)
+	if err != nil {
+		return err
+	}
+	logger := loggerFor(options)
+	for _, warning := range warnings {
+		logger.Warn(warning)
+	}
+	return nil
+}
+
+// allowedToolsCover reports whether allowedTools permits the given MCP tool,
+// either by exact name or by a server-wide rule ("mcp__server" or "mcp__server__*").
+func allowedToolsCover(allowedTools []string, serverName, fullName string) bool {
+	serverRule := "mcp__" + serverName
+	for _, allowed := range allowedTools {
+		if allowed == fullName || allowed == serverRule || allowed == serverRule+"__*" {
+			return true
+		}
+	}
+	return false
+}
+
+// sdkMcpTools returns the tool names of each SDK MCP server that can list them.
+func sdkMcpTools(servers map[string]McpServerConfig) map[string][]string {
+	tools := make(map[string][]string)
+	for name, config := range servers {
+		sdkConfig, ok := config.(McpSdkServerConfig)
+		if !ok {
+			continue
+		}
+		if lister, ok := sdkConfig.Instance.(mcpToolLister); ok {
+			tools[name] = lister.ToolNames()
+		}
+	}
+	return tools
+}
+
+// loggerFor returns the configured logger, or a logger that discards everything.
+func loggerFor(options *ClaudeAgentOptions) *slog.Logger {
+	if options != nil && options.Logger != nil {
+		return options.Logger
+	}
+	return slog.New(slog.DiscardHandler)
+}
@@ -0,0 +1,145 @@
+package claude
+
+import (
+	"context"
+	"sync"
+)
+
+// Metadata holds per-request values, such as the ID of the user a query runs
+// for. It is made available to hooks (HookContext.Metadata), CanUseTool
+// (ToolPermissionContext.Metadata), and SDK MCP tool handlers
+// (MetadataFromContext) while the query or turn runs, so they can make
+// per-request decisions without global state. Metadata is never sent to the
+// CLI.
+type Metadata map[string]interface{}
+
+type metadataKey struct{}
+
+// WithMetadata returns a copy of ctx carrying md. Values already on ctx are
+// kept unless md overrides them. Pass the result to Query, QueryStream, or
+// ClaudeSDKClient.Query to attach md to that query or turn.
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, mergeMetadata(MetadataFromContext(ctx), md))
+}
+
+// MetadataFromContext returns the metadata carried by ctx, or nil if none.
+// Inside a tool handler it returns the metadata of the current query or turn.
+func MetadataFromContext(ctx context.Context) Metadata {
+	md, _ := ctx.Value(metadataKey{}).(Metadata)
+	return md
+}
+
+// mergeMetadata returns base overlaid with over, or nil if both are empty.
+// Neither argument is modified.
+func mergeMetadata(base, over Metadata) Metadata {
+	if len(base) == 0 && len(over) == 0 {
+		return nil
+	}
+	merged := make(Metadata, len(base)+len(over))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range over {
+		merged[k] = v
+	}
+	return merged
+}
+
+// turnMetadata holds the metadata of the queries in flight for control
+// requests, which are handled on the connection's context. Queries from
+// different Sessions can be in flight at once; the CLI answers them in the
+// order they were sent, so a control request belongs to the query with its
+// session ID, or else to the oldest one not yet answered.
+type turnMetadata struct {
+	mu    sync.Mutex
+	base  Metadata        // Used when no query is in flight
+	turns []*metadataTurn // Oldest first
+}
+
+// metadataTurn is the metadata of one query in flight.
+type metadataTurn struct {
+	sessionID string
+	md        Metadata
+}
+
+// set sets the metadata used when no query is in flight.
+func (t *turnMetadata) set(md Metadata) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.base = md
+}
+
+// begin records md for a query sent for sessionID, until its result. The
+// returned func forgets it, for a query that could not be sent.
+func (t *turnMetadata) begin(sessionID string, md Metadata) func() {
+	turn := &metadataTurn{sessionID: sessionID, md: md}
+	t.mu.Lock()
+	t.turns = append(t.turns, turn)
+	t.mu.Unlock()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.remove(turn)
+	}
+}
+
+// finish forgets the query answered by a result for sessionID.
+func (t *turnMetadata) finish(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if turn := t.find(sessionID); turn != nil {
+		t.remove(turn)
+	}
+}
+
+// attach returns ctx carrying the metadata of the query for sessionID.
+func (t *turnMetadata) attach(ctx context.Context, sessionID string) context.Context {
+	t.mu.Lock()
+	md := t.base
+	if turn := t.find(sessionID); turn != nil {
+		md = turn.md
+	}
+	t.mu.Unlock()
+	if len(md) == 0 {
+		return ctx
+	}
+	return WithMetadata(ctx, md)
+}
+
+// controlRequestSessionID returns the session ID a control request names,
+// which hook inputs carry, or "".
+func controlRequestSessionID(request map[string]interface{}) string {
+	if id, ok := request["session_id"].(string); ok {
+		return id
+	}
+	if request["subtype"] != string(ControlSubtypeHookCallback) {
+		return "" // Tool inputs are the tool's, not the CLI's
+	}
+	input, _ := request["input"].(map[string]interface{})
+	id, _ := input["session_id"].(string)
+	return id
+}
+
+// find returns the oldest query for sessionID, else the oldest query, or
+// nil. Callers hold mu.
+func (t *turnMetadata) find(sessionID string) *metadataTurn {
+	for _, turn := range t.turns {
+		if sessionID != "" && turn.sessionID == sessionID {
+			return turn
+		}
+	}
+	if len(t.turns) > 0 {
+		return t.turns[0]
+	}
+	return nil
+}
+
+// remove drops turn. Callers hold mu.
+func (t *turnMetadata) remove(turn *metadataTurn) {
+	for i, queued := range t.turns {
+		if queued == turn {
+			t.turns = append(t.turns[:i], t.turns[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric names reported to ClaudeAgentOptions.Metrics. They follow the
+// Prometheus naming convention for histograms of seconds.
+const (
+	// MetricParseDuration times JSON decoding in the transport (label
+	// "stage"="decode") and conversion to typed messages ("stage"="parse").
+	MetricParseDuration = "claude_sdk_parse_duration_seconds"
+
+	// MetricRouteDuration times routing one message from the transport to the
+	// message channel, including waiting for a slow consumer. Label "type" is
+	// the message type.
+	MetricRouteDuration = "claude_sdk_route_duration_seconds"
+
+	// MetricCallbackDuration times user callbacks run for control requests.
+	// Label "kind" is the request subtype: can_use_tool, hook_callback, or
+	// mcp_message.
+	MetricCallbackDuration = "claude_sdk_callback_duration_seconds"
+
+	// MetricBufferSize is a gauge of the current size, in messages, of a
+	// buffer tuned by AdaptiveBuffering. Label "buffer" names the buffer.
+	MetricBufferSize = "claude_sdk_buffer_size"
+
+	// MetricQueryDuration times a query, from sending the prompt to its
+	// ResultMessage.
+	MetricQueryDuration = "claude_sdk_query_duration_seconds"
+
+	// MetricToolUseDuration times a tool call, from its ToolUseBlock to its
+	// ToolResultBlock. Label "tool" names the tool.
+	MetricToolUseDuration = "claude_sdk_tool_use_duration_seconds"
+
+	// MetricTokens is a counter of tokens used. Label "type" is input,
+	// output, cache_read, or cache_creation.
+	MetricTokens = "claude_sdk_tokens_total"
+
+	// MetricCost is a counter of cost in USD.
+	MetricCost = "claude_sdk_cost_usd_total"
+)
+
+// Labels for the two MetricParseDuration stages and the MetricTokens types;
+// shared because labels are read-only.
+var (
+	decodeStageLabels = map[string]string{"stage": "decode"}
+	parseStageLabels  = map[string]string{"stage": "parse"}
+
+	inputTokenLabels         = map[string]string{"type": "input"}
+	outputTokenLabels        = map[string]string{"type": "output"}
+	cacheReadTokenLabels     = map[string]string{"type": "cache_read"}
+	cacheCreationTokenLabels = map[string]string{"type": "cache_creation"}
+)
+
+// Metrics receives timings of the SDK's own work, so operators can tell SDK
+// overhead from model latency, as well as query and tool call durations. Implementations must be safe for concurrent
+// use and must not block; labels must not be retained or modified.
+type Metrics interface {
+	ObserveDuration(name string, d time.Duration, labels map[string]string)
+}
+
+// GaugeMetrics is implemented by Metrics that also record current values,
+// such as buffer sizes chosen by AdaptiveBuffering. The same rules as for
+// Metrics apply.
+type GaugeMetrics interface {
+	SetGauge(name string, value float64, labels map[string]string)
+}
+
+// CounterMetrics is implemented by Metrics that also record running totals,
+// such as tokens and cost. The same rules as for Metrics apply.
+type CounterMetrics interface {
+	AddCounter(name string, value float64, labels map[string]string)
+}
+
+// setGauge reports value to m if it implements GaugeMetrics.
+func setGauge(m Metrics, name string, value float64, labels map[string]string) {
+	if g, ok := m.(GaugeMetrics); ok {
+		g.SetGauge(name, value, labels)
+	}
+}
+
+// addCounter adds value to m if it implements CounterMetrics.
+func addCounter(m Metrics, name string, value float64, labels map[string]string) {
+	if c, ok := m.(CounterMetrics); ok {
+		c.AddCounter(name, value, labels)
+	}
+}
+
+// observeSince reports the time since start to m, if set.
+func observeSince(m Metrics, name string, start time.Time, labels map[string]string) {
+	if m != nil {
+		m.ObserveDuration(name, time.Since(start), labels)
+	}
+}
+
+// metricsFor returns options.Metrics, or nil.
+func metricsFor(options *ClaudeAgentOptions) Metrics {
+	if options == nil {
+		return nil
+	}
+	return options.Metrics
+}
+
+// withProfileLabels runs fn with the given pprof labels on the goroutine when
+// enabled, so CPU and goroutine profiles attribute samples to SDK operations.
+// labels are key/value pairs, as for pprof.Labels.
+func withProfileLabels(ctx context.Context, enabled bool, fn func(context.Context), labels ...string) {
+	if !enabled {
+		fn(ctx)
+		return
+	}
+	pprof.Do(ctx, pprof.Labels(labels...), fn)
+}
+
+// profileLabelsEnabled reports whether options.ProfileLabels is set.
+func profileLabelsEnabled(options *ClaudeAgentOptions) bool {
+	return options != nil && options.ProfileLabels
+}
+
+// DefaultMetricBuckets are the histogram upper bounds, in seconds, used by
+// ExpvarMetrics when Buckets is empty.
+var DefaultMetricBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// ExpvarMetrics is a Metrics implementation that keeps a cumulative histogram
+// per metric name and label set. It implements expvar.Var, so it can be
+// exposed at /debug/vars:
+//
+//	metrics := claude.NewExpvarMetrics()
+//	expvar.Publish("claude_sdk", metrics)
+//	options := &claude.ClaudeAgentOptions{Metrics: metrics}
+type ExpvarMetrics struct {
+	buckets []float64
+
+	mu         sync.Mutex
+	histograms map[string]*MetricHistogram
+	gauges     map[string]*MetricGauge
+	counters   map[string]*MetricCounter
+}
+
+// MetricCounter is the running total of one counter.
+type MetricCounter struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+// MetricGauge is the last value set for one gauge.
+type MetricGauge struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+// MetricHistogram is a snapshot of one histogram. Buckets[i] counts the
+// observations of at most UpperBounds[i] seconds, Prometheus-style.
+type MetricHistogram struct {
+	Name        string            `json:"name"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Count       uint64            `json:"count"`
+	SumSeconds  float64           `json:"sum_seconds"`
+	UpperBounds []float64         `json:"upper_bounds"`
+	Buckets     []uint64          `json:"buckets"`
+}
+
+// NewExpvarMetrics creates an ExpvarMetrics with the given bucket upper
+// bounds in seconds, or DefaultMetricBuckets if none are given.
+func NewExpvarMetrics(buckets ...float64) *ExpvarMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultMetricBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &ExpvarMetrics{
+		buckets:    sorted,
+		histograms: make(map[string]*MetricHistogram),
+		gauges:     make(map[string]*MetricGauge),
+		counters:   make(map[string]*MetricCounter),
+	}
+}
+
+// ObserveDuration implements Metrics.
+func (m *ExpvarMetrics) ObserveDuration(name string, d time.Duration, labels map[string]string) {
+	key := metricKey(name, labels)
+	seconds := d.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.histograms[key]
+	if !ok {
+		h = &MetricHistogram{
+			Name:        name,
+			Labels:      copyLabels(labels),
+			UpperBounds: m.buckets,
+			Buckets:     make([]uint64, len(m.buckets)),
+		}
+		m.histograms[key] = h
+	}
+	h.Count++
+	h.SumSeconds += seconds
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.Buckets[i]++
+		}
+	}
+}
+
+// SetGauge implements GaugeMetrics.
+func (m *ExpvarMetrics) SetGauge(name string, value float64, labels map[string]string) {
+	key := metricKey(name, labels)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g, ok := m.gauges[key]
+	if !ok {
+		g = &MetricGauge{Name: name, Labels: copyLabels(labels)}
+		m.gauges[key] = g
+	}
+	g.Value = value
+}
+
+// AddCounter implements CounterMetrics.
+func (m *ExpvarMetrics) AddCounter(name string, value float64, labels map[string]string) {
+	key := metricKey(name, labels)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.counters[key]
+	if !ok {
+		c = &MetricCounter{Name: name, Labels: copyLabels(labels)}
+		m.counters[key] = c
+	}
+	c.Value += value
+}
+
+// Counters returns a copy of every counter, sorted by name and labels.
+func (m *ExpvarMetrics) Counters() []MetricCounter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.counters))
+	for key := range m.counters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	counters := make([]MetricCounter, 0, len(keys))
+	for _, key := range keys {
+		counters = append(counters, *m.counters[key])
+	}
+	return counters
+}
+
+// Gauges returns a copy of every gauge, sorted by name and labels.
+func (m *ExpvarMetrics) Gauges() []MetricGauge {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.gauges))
+	for key := range m.gauges {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	gauges := make([]MetricGauge, 0, len(keys))
+	for _, key := range keys {
+		gauges = append(gauges, *m.gauges[key])
+	}
+	return gauges
+}
+
+// Snapshot returns a copy of every histogram, sorted by name and labels.
+func (m *ExpvarMetrics) Snapshot() []MetricHistogram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.histograms))
+	for key := range m.histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	snapshot := make([]MetricHistogram, 0, len(keys))
+	for _, key := range keys {
+		h := *m.histograms[key]
+		h.Buckets = append([]uint64(nil), h.Buckets...)
+		snapshot = append(snapshot, h)
+	}
+	return snapshot
+}
+
+// String implements expvar.Var, returning the snapshot as JSON.
+func (m *ExpvarMetrics) String() string {
+	data, err := json.Marshal(m.Snapshot())
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+// metricKey identifies a histogram by name and sorted labels.
+func metricKey(name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteString("|" + k + "=" + labels[k])
+	}
+	return b.String()
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
+}
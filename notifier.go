@@ -0,0 +1,309 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for Notifier.
+const (
+	defaultDigestRecentTools = 5
+	digestTextLimit          = 200 // Runes of Digest.LastText
+)
+
+// Notifier sends a compact Digest of a long-running session periodically,
+// for lightweight human supervision of autonomous agents. Digests are built
+// from the messages received, without extra model turns. Set it as
+// ClaudeAgentOptions.Notifier; it applies to ClaudeSDKClient only.
+//
+// Example: a digest every 15 minutes and after every 10 turns, to a chat
+// webhook:
+//
+//	options := &claude.ClaudeAgentOptions{
+//	    Notifier: &claude.Notifier{
+//	        Interval:   15 * time.Minute,
+//	        EveryTurns: 10,
+//	        WebhookURL: "https://hooks.example.com/agent-status",
+//	    },
+//	}
+type Notifier struct {
+	Interval   time.Duration // Send a digest this often while connected (0 = not periodically)
+	EveryTurns int           // Send a digest after every N completed turns (0 = not by turns)
+
+	// OnDigest receives each digest. Digests are delivered one at a time
+	// from a background goroutine; a slow callback delays the next one.
+	OnDigest func(Digest)
+
+	// WebhookURL, if set, receives each digest as a JSON POST.
+	WebhookURL string
+	Client     *http.Client      // For WebhookURL (default: a client with a 10s timeout)
+	Headers    map[string]string // Extra webhook request headers, e.g. Authorization
+	OnError    func(err error)   // Called when a POST fails
+
+	RecentTools int // Tool calls listed in Digest.LastTools (default 5)
+}
+
+// Digest is a status report on a session.
+type Digest struct {
+	Time        time.Time     `json:"time"`
+	SessionID   string        `json:"session_id,omitempty"`
+	Elapsed     time.Duration `json:"elapsed_ns"`             // Since the client connected
+	Turns       int           `json:"turns"`                  // Completed turns
+	ToolUses    int           `json:"tool_uses"`              // Tool calls, including subagents'
+	CurrentTask string        `json:"current_task,omitempty"` // The in-progress TodoWrite item, if any
+	LastTools   []string      `json:"last_tools,omitempty"`   // Most recent tool calls, oldest first, e.g. "Bash: go test ./..."
+	LastText    string        `json:"last_text,omitempty"`    // Start of Claude's latest text
+	CostUSD     float64       `json:"cost_usd"`               // Spend so far
+}
+
+// String formats d as a few lines of text, e.g. for a chat message.
+func (d Digest) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Agent status after %s: %d turns, %d tool calls, $%.2f", d.Elapsed.Round(time.Second), d.Turns, d.ToolUses, d.CostUSD)
+	if d.CurrentTask != "" {
+		fmt.Fprintf(&b, "\nWorking on: %s", d.CurrentTask)
+	}
+	if len(d.LastTools) > 0 {
+		fmt.Fprintf(&b, "\nRecent tools: %s", strings.Join(d.LastTools, "; "))
+	}
+	if d.LastText != "" {
+		fmt.Fprintf(&b, "\nLast said: %s", d.LastText)
+	}
+	return b.String()
+}
+
+// digestTracker builds digests from a client's messages and delivers them
+// for a Notifier. It is kept across session restarts.
+type digestTracker struct {
+	notifier *Notifier
+	client   *http.Client
+	pending  chan Digest // Turn digests waiting for delivery
+
+	mu          sync.Mutex
+	started     time.Time
+	sessionID   string
+	turns       int
+	toolUses    int
+	lastTools   []string
+	task        string
+	lastText    string
+	costUSD     float64
+	lastCostUSD float64 // Cumulative cost the CLI last reported
+}
+
+// newDigestTracker returns a tracker if options.Notifier is set, or nil.
+func newDigestTracker(options *ClaudeAgentOptions) *digestTracker {
+	if options == nil || options.Notifier == nil {
+		return nil
+	}
+	d := &digestTracker{
+		notifier: options.Notifier,
+		client:   options.Notifier.Client,
+		pending:  make(chan Digest, 1),
+		started:  time.Now(),
+	}
+	if d.client == nil {
+		d.client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return d
+}
+
+// run delivers digests until ctx, a connection's context, is done.
+func (d *digestTracker) run(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	var tick <-chan time.Time
+	if d.notifier.Interval > 0 {
+		ticker := time.NewTicker(d.notifier.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			d.deliver(ctx, d.digest(time.Now()))
+		case digest := <-d.pending:
+			d.deliver(ctx, digest)
+		}
+	}
+}
+
+// observe records msg, queuing a digest when it completes every
+// Notifier.EveryTurns-th turn.
+func (d *digestTracker) observe(msg Message) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	switch m := msg.(type) {
+	case *AssistantMessage:
+		for _, block := range m.Content {
+			switch block := block.(type) {
+			case TextBlock:
+				if m.ParentToolUseID == nil && strings.TrimSpace(block.Text) != "" {
+					d.lastText = truncateRunes(strings.TrimSpace(block.Text), digestTextLimit)
+				}
+			case ToolUseBlock:
+				d.observeToolUse(block)
+			}
+		}
+	case *ResultMessage:
+		d.sessionID = m.SessionID
+		d.turns++
+		if m.TotalCostUSD != nil {
+			// Cumulative per CLI process, which starts over on restarts
+			delta := *m.TotalCostUSD - d.lastCostUSD
+			if *m.TotalCostUSD < d.lastCostUSD {
+				delta = *m.TotalCostUSD
+			}
+			d.lastCostUSD = *m.TotalCostUSD
+			d.costUSD += delta
+		}
+	}
+	due := false
+	if _, ok := msg.(*ResultMessage); ok && d.notifier.EveryTurns > 0 {
+		due = d.turns%d.notifier.EveryTurns == 0
+	}
+	d.mu.Unlock()
+
+	if due {
+		digest := d.digest(time.Now())
+		select {
+		case d.pending <- digest:
+		default:
+			// The previous turn digest is still waiting; send the newer one
+			select {
+			case <-d.pending:
+			default:
+			}
+			select {
+			case d.pending <- digest:
+			default:
+			}
+		}
+	}
+}
+
+// observeToolUse records a tool call. Callers hold mu.
+func (d *digestTracker) observeToolUse(block ToolUseBlock) {
+	d.toolUses++
+	recent := d.notifier.RecentTools
+	if recent <= 0 {
+		recent = defaultDigestRecentTools
+	}
+	d.lastTools = append(d.lastTools, describeToolUse(block))
+	if len(d.lastTools) > recent {
+		d.lastTools = d.lastTools[len(d.lastTools)-recent:]
+	}
+
+	if todos, ok := asToolInput[TodoWriteInput](block, "TodoWrite"); ok {
+		d.task = ""
+		for _, todo := range todos.Todos {
+			if todo.Status == "in_progress" {
+				d.task = todo.ActiveForm
+				if d.task == "" {
+					d.task = todo.Content
+				}
+				break
+			}
+		}
+	}
+}
+
+// digest returns the current digest.
+func (d *digestTracker) digest(at time.Time) Digest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return Digest{
+		Time:        at,
+		SessionID:   d.sessionID,
+		Elapsed:     at.Sub(d.started),
+		Turns:       d.turns,
+		ToolUses:    d.toolUses,
+		CurrentTask: d.task,
+		LastTools:   append([]string(nil), d.lastTools...),
+		LastText:    d.lastText,
+		CostUSD:     d.costUSD,
+	}
+}
+
+// deliver passes digest to the callback and webhook.
+func (d *digestTracker) deliver(ctx context.Context, digest Digest) {
+	if d.notifier.OnDigest != nil {
+		d.notifier.OnDigest(digest)
+	}
+	if d.notifier.WebhookURL == "" {
+		return
+	}
+	if err := d.post(ctx, digest); err != nil && d.notifier.OnError != nil {
+		d.notifier.OnError(err)
+	}
+}
+
+// post sends digest to the webhook.
+func (d *digestTracker) post(ctx context.Context, digest Digest) error {
+	body, err := json.Marshal(struct {
+		Digest
+		Text string `json:"text"` // For chat webhooks that display "text"
+	}{digest, digest.String()})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.notifier.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range d.notifier.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("digest webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("digest webhook: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// describeToolUse summarizes a tool call in a few words, e.g.
+// "Bash: go test ./...".
+func describeToolUse(block ToolUseBlock) string {
+	var detail string
+	switch input := block.Input; {
+	case input["command"] != nil:
+		detail, _ = input["command"].(string)
+	case input["file_path"] != nil:
+		detail, _ = input["file_path"].(string)
+	case input["url"] != nil:
+		detail, _ = input["url"].(string)
+	case input["pattern"] != nil:
+		detail, _ = input["pattern"].(string)
+	case input["description"] != nil:
+		detail, _ = input["description"].(string)
+	}
+	if detail == "" {
+		return block.Name
+	}
+	return block.Name + ": " + truncateRunes(detail, 80)
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with "…".
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,365 @@
+// Package openai serves a Claude agent over an OpenAI-compatible HTTP API,
+// so tools written for the OpenAI chat completions endpoint can talk to a
+// local Claude agent unchanged.
+package openai
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// defaultModel is the model name reported when ServerOptions.Model is empty.
+const defaultModel = "claude"
+
+// maxRequestBytes caps the size of a chat completion request body.
+const maxRequestBytes = 16 << 20
+
+// ServerOptions configures NewServer.
+type ServerOptions struct {
+	// Model is the model name listed by /v1/models and reported in
+	// responses. The model requested by callers is ignored; the agent
+	// options decide which model answers. Defaults to "claude".
+	Model string
+
+	// NewTransport, if set, returns the transport for each request's CLI,
+	// e.g. a WebSocketTransport. By default each request starts a CLI
+	// subprocess.
+	NewTransport func() (claude.Transport, error)
+
+	// BearerToken, if set, must be sent by clients as
+	// "Authorization: Bearer <token>", which OpenAI clients send as their
+	// API key.
+	BearerToken string
+
+	// Authenticate, if set, checks each request after BearerToken, e.g. to
+	// verify an API key header or a signed token. Returning an error
+	// rejects the request with 401 Unauthorized.
+	Authenticate func(r *http.Request) error
+
+	// AllowedOrigins lists the browser origins, such as
+	// "https://app.example.com", allowed to call the server. Requests with
+	// any other Origin header are rejected with 403 Forbidden, so web pages
+	// cannot drive the agent through a browser. Requests without an Origin
+	// header, as sent by non-browser clients, are always allowed.
+	AllowedOrigins []string
+}
+
+// Server implements the OpenAI chat completions API on top of a Claude
+// agent. It serves:
+//
+//   - POST /v1/chat/completions, with or without "stream": true (server-sent
+//     events, ending with "data: [DONE]")
+//   - GET /v1/models, listing ServerOptions.Model
+//
+// OpenAI clients resend the whole conversation with every request, so each
+// request is answered by a ClaudeSDKClient of its own, in a fresh CLI
+// conversation, from its full message history sent as a transcript. The
+// server keeps no state between requests, and no caller sees another's
+// messages; the "user" field is ignored, as callers can set it to anything.
+// The request's CLI is stopped when the request ends, including when the
+// caller disconnects. Only text content is supported.
+//
+// Example:
+//
+//	log.Fatal(http.ListenAndServe("localhost:8080", openai.NewServer(options, nil)))
+//
+// Then point an OpenAI client at http://localhost:8080/v1. The server runs
+// an agent with the options' tool permissions, so set BearerToken unless
+// only trusted local processes can reach it. Chat completion requests must
+// be sent as application/json, which browsers cannot do cross-origin
+// without the server's consent.
+type Server struct {
+	agent   *claude.ClaudeAgentOptions
+	options ServerOptions
+	origins map[string]bool
+	model   string
+	mux     *http.ServeMux
+	nextID  atomic.Uint64
+}
+
+// NewServer returns a Server answering with agents configured by agent.
+// Either may be nil.
+func NewServer(agent *claude.ClaudeAgentOptions, options *ServerOptions) *Server {
+	s := &Server{agent: agent, model: defaultModel, origins: make(map[string]bool)}
+	if options != nil {
+		s.options = *options
+	}
+	if s.options.Model != "" {
+		s.model = s.options.Model
+	}
+	for _, origin := range s.options.AllowedOrigins {
+		s.origins[strings.TrimSuffix(origin, "/")] = true
+	}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/v1/chat/completions", s.handleChatCompletions)
+	s.mux.HandleFunc("/v1/models", s.handleModels)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" && !s.origins[origin] {
+		writeError(w, http.StatusForbidden, "invalid_request_error", "origin not allowed")
+		return
+	}
+	if err := s.authenticate(r); err != nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "invalid_request_error", err.Error())
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authenticate checks BearerToken, then Authenticate.
+func (s *Server) authenticate(r *http.Request) error {
+	if s.options.BearerToken != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.options.BearerToken)) != 1 {
+			return errors.New("invalid or missing bearer token")
+		}
+	}
+	if s.options.Authenticate != nil {
+		return s.options.Authenticate(r)
+	}
+	return nil
+}
+
+// chatMessage is a message of a chat completion request. Content is a string
+// or an array of content parts.
+type chatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// chatCompletionRequest holds the request fields the server uses; others,
+// such as temperature, are accepted and ignored.
+type chatCompletionRequest struct {
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "use POST for chat completions")
+		return
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, "invalid_request_error", "chat completion requests must be sent as application/json")
+		return
+	}
+	var req chatCompletionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "invalid_request_error", "request body too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	prompt, err := prompt(req.Messages)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	client, err := s.connect(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	defer client.Close()
+
+	id := fmt.Sprintf("chatcmpl-%d", s.nextID.Add(1))
+	created := time.Now().Unix()
+	textCh, errCh := client.QueryText(r.Context(), prompt)
+	if req.Stream {
+		s.stream(w, id, created, textCh, errCh)
+		return
+	}
+
+	var text strings.Builder
+	for chunk := range textCh {
+		text.WriteString(chunk)
+	}
+	if err := <-errCh; err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":      id,
+		"object":  "chat.completion",
+		"created": created,
+		"model":   s.model,
+		"choices": []interface{}{map[string]interface{}{
+			"index":         0,
+			"message":       map[string]interface{}{"role": "assistant", "content": text.String()},
+			"finish_reason": "stop",
+		}},
+	})
+}
+
+// connect returns a client connected to a new CLI conversation, which lives
+// as long as ctx.
+func (s *Server) connect(ctx context.Context) (*claude.ClaudeSDKClient, error) {
+	var agent claude.ClaudeAgentOptions
+	if s.agent != nil {
+		agent = *s.agent
+	}
+	client := claude.NewClaudeSDKClient(&agent)
+	if s.options.NewTransport != nil {
+		transport, err := s.options.NewTransport()
+		if err != nil {
+			return nil, err
+		}
+		client = claude.NewClaudeSDKClientWithTransport(&agent, transport)
+	}
+	if err := client.Connect(ctx); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// stream writes the reply as server-sent chat.completion.chunk events.
+func (s *Server) stream(w http.ResponseWriter, id string, created int64, textCh <-chan string, errCh <-chan error) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	send := func(data interface{}) {
+		payload, _ := json.Marshal(data)
+		fmt.Fprintf(w, "data: %s\n\n", payload)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	chunk := func(delta map[string]interface{}, finishReason interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   s.model,
+			"choices": []interface{}{map[string]interface{}{
+				"index":         0,
+				"delta":         delta,
+				"finish_reason": finishReason,
+			}},
+		}
+	}
+
+	send(chunk(map[string]interface{}{"role": "assistant"}, nil))
+	for text := range textCh {
+		send(chunk(map[string]interface{}{"content": text}, nil))
+	}
+	if err := <-errCh; err != nil {
+		// The status was sent already; report the error in the stream
+		send(map[string]interface{}{"error": map[string]interface{}{"message": err.Error(), "type": "server_error"}})
+		return
+	}
+	send(chunk(map[string]interface{}{}, "stop"))
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// prompt returns the text to send for messages: the only user message, or
+// the whole conversation as a transcript.
+func prompt(messages []chatMessage) (string, error) {
+	if len(messages) == 0 {
+		return "", fmt.Errorf("messages must not be empty")
+	}
+	last := messages[len(messages)-1]
+	if last.Role != "user" {
+		return "", fmt.Errorf("the last message must have role user, got %q", last.Role)
+	}
+
+	if len(messages) == 1 {
+		return messageText(last)
+	}
+	var transcript strings.Builder
+	for i, msg := range messages {
+		text, err := messageText(msg)
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			transcript.WriteString("\n\n")
+		}
+		fmt.Fprintf(&transcript, "%s: %s", roleLabel(msg.Role), text)
+	}
+	return transcript.String(), nil
+}
+
+// messageText returns the text of msg's content.
+func messageText(msg chatMessage) (string, error) {
+	var text string
+	if err := json.Unmarshal(msg.Content, &text); err == nil {
+		return text, nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(msg.Content, &parts); err != nil {
+		return "", fmt.Errorf("%s message content must be a string or an array of content parts", msg.Role)
+	}
+	var b strings.Builder
+	for _, part := range parts {
+		if part.Type != "text" {
+			return "", fmt.Errorf("unsupported content part type %q", part.Type)
+		}
+		b.WriteString(part.Text)
+	}
+	return b.String(), nil
+}
+
+// roleLabel names role in a replayed transcript.
+func roleLabel(role string) string {
+	switch role {
+	case "system", "developer":
+		return "System"
+	case "assistant":
+		return "Assistant"
+	case "tool":
+		return "Tool"
+	}
+	return "User"
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "use GET to list models")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data": []interface{}{map[string]interface{}{
+			"id":       s.model,
+			"object":   "model",
+			"owned_by": "anthropic",
+		}},
+	})
+}
+
+// writeError writes an error in the OpenAI error format.
+func writeError(w http.ResponseWriter, status int, errType, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{"message": message, "type": errType},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
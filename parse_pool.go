@@ -0,0 +1,95 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// orderedDecoder decodes JSON messages on a pool of goroutines and delivers
+// them in the order they were submitted, so a large message does not hold up
+// reading while the messages of a session still arrive in order.
+type orderedDecoder struct {
+	jobs      chan decodeJob
+	pending   chan decodeJob // Submitted jobs, in order, for the emitter
+	emitted   chan struct{}  // Closed when the emitter has delivered everything
+	closeOnce sync.Once
+}
+
+type decodeJob struct {
+	data   []byte
+	result chan map[string]interface{} // Receives the message, or nil if data is not a JSON object
+}
+
+// newOrderedDecoder starts workers decoding goroutines that deliver to out.
+// If ctx is done, decoded messages are discarded instead.
+func newOrderedDecoder(ctx context.Context, workers int, out chan<- map[string]interface{}, metrics Metrics) *orderedDecoder {
+	d := &orderedDecoder{
+		jobs:    make(chan decodeJob, workers),
+		pending: make(chan decodeJob, 2*workers),
+		emitted: make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range d.jobs {
+				var data map[string]interface{}
+				decodeStart := time.Now()
+				if err := json.Unmarshal(job.data, &data); err == nil {
+					observeSince(metrics, MetricParseDuration, decodeStart, decodeStageLabels)
+				}
+				job.result <- data
+			}
+		}()
+	}
+	go func() {
+		defer close(d.emitted)
+		for job := range d.pending {
+			data := <-job.result
+			if data == nil {
+				continue
+			}
+			select {
+			case out <- data:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return d
+}
+
+// submit queues data, which must be valid JSON, for decoding. It returns
+// false if ctx is done first.
+func (d *orderedDecoder) submit(ctx context.Context, data []byte) bool {
+	job := decodeJob{data: data, result: make(chan map[string]interface{}, 1)}
+	select {
+	case d.pending <- job:
+	case <-ctx.Done():
+		return false
+	}
+	select {
+	case d.jobs <- job:
+		return true
+	case <-ctx.Done():
+		job.result <- nil
+		return false
+	}
+}
+
+// close stops the workers and waits until every submitted message has been
+// delivered. It may be called more than once.
+func (d *orderedDecoder) close() {
+	d.closeOnce.Do(func() {
+		close(d.jobs)
+		close(d.pending)
+	})
+	<-d.emitted
+}
+
+// parseConcurrency returns the number of decoding goroutines options asks for.
+func parseConcurrency(options *ClaudeAgentOptions) int {
+	if options == nil || options.ParseConcurrency < 1 {
+		return 1
+	}
+	return options.ParseConcurrency
+}
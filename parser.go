@@ -49,11 +49,18 @@ func parseUserMessage(data map[string]interface{}) (*UserMessage, error) {
 		parentToolUseID = &pid
 	}
 
+	uuid, _ := data["uuid"].(string)
+	sessionID, _ := data["session_id"].(string)
+	toolUseResult, _ := data["tool_use_result"].(map[string]interface{})
+
 	// Content can be string or []ContentBlock
 	if contentStr, ok := content.(string); ok {
 		return &UserMessage{
 			Content:         contentStr,
 			ParentToolUseID: parentToolUseID,
+			UUID:            uuid,
+			SessionID:       sessionID,
+			ToolUseResult:   toolUseResult,
 		}, nil
 	}
 
@@ -75,6 +82,9 @@ func parseUserMessage(data map[string]interface{}) (*UserMessage, error) {
 	return &UserMessage{
 		Content:         blocks,
 		ParentToolUseID: parentToolUseID,
+		UUID:            uuid,
+		SessionID:       sessionID,
+		ToolUseResult:   toolUseResult,
 	}, nil
 }
 
@@ -108,10 +118,17 @@ func parseAssistantMessage(data map[string]interface{}) (*AssistantMessage, erro
 		parentToolUseID = &pid
 	}
 
+	uuid, _ := data["uuid"].(string)
+	sessionID, _ := data["session_id"].(string)
+	errorType, _ := data["error"].(string)
+
 	return &AssistantMessage{
 		Content:         blocks,
 		Model:           model,
 		ParentToolUseID: parentToolUseID,
+		UUID:            uuid,
+		SessionID:       sessionID,
+		Error:           errorType,
 	}, nil
 }
 
@@ -249,11 +266,16 @@ func parseResultMessage(data map[string]interface{}) (*ResultMessage, error) {
 	if usage, ok := data["usage"].(map[string]interface{}); ok {
 		result.Usage = usage
 	}
+	if modelUsage, ok := data["modelUsage"].(map[string]interface{}); ok {
+		result.ModelUsage = modelUsage
+	}
 
 	if resultStr, ok := data["result"].(string); ok {
 		result.Result = &resultStr
 	}
 
+	result.UUID, _ = data["uuid"].(string)
+
 	return result, nil
 }
 
@@ -285,3 +307,101 @@ func parseStreamEvent(data map[string]interface{}) (*StreamEvent, error) {
 
 	return streamEvent, nil
 }
+
+// ParsePermissionUpdate parses a raw permission update (as sent by the CLI in
+// permission_suggestions) into a typed PermissionUpdate.
+func ParsePermissionUpdate(data map[string]interface{}) (PermissionUpdate, error) {
+	return parsePermissionUpdate(data)
+}
+
+// parsePermissionUpdate parses a raw permission update dictionary.
+func parsePermissionUpdate(data map[string]interface{}) (PermissionUpdate, error) {
+	if data == nil {
+		return PermissionUpdate{}, NewMessageParseError("permission update data is nil", nil)
+	}
+
+	updateType, ok := data["type"].(string)
+	if !ok {
+		return PermissionUpdate{}, NewMessageParseError("permission update missing 'type' field", data)
+	}
+
+	update := PermissionUpdate{Type: PermissionUpdateType(updateType)}
+
+	if dest, ok := data["destination"].(string); ok {
+		destination := PermissionUpdateDestination(dest)
+		update.Destination = &destination
+	}
+
+	switch update.Type {
+	case PermissionUpdateTypeAddRules, PermissionUpdateTypeReplaceRules, PermissionUpdateTypeRemoveRules:
+		rules, ok := data["rules"].([]interface{})
+		if !ok {
+			return PermissionUpdate{}, NewMessageParseError(fmt.Sprintf("%s permission update missing 'rules' field", updateType), data)
+		}
+		update.Rules = make([]PermissionRuleValue, 0, len(rules))
+		for _, item := range rules {
+			rule, err := parsePermissionRuleValue(item)
+			if err != nil {
+				return PermissionUpdate{}, NewMessageParseError(err.Error(), data)
+			}
+			update.Rules = append(update.Rules, rule)
+		}
+		if behavior, ok := data["behavior"].(string); ok {
+			b := PermissionBehavior(behavior)
+			update.Behavior = &b
+		}
+
+	case PermissionUpdateTypeSetMode:
+		mode, ok := data["mode"].(string)
+		if !ok {
+			return PermissionUpdate{}, NewMessageParseError("setMode permission update missing 'mode' field", data)
+		}
+		m := PermissionMode(mode)
+		update.Mode = &m
+
+	case PermissionUpdateTypeAddDirectories, PermissionUpdateTypeRemoveDirectories:
+		dirs, ok := data["directories"].([]interface{})
+		if !ok {
+			return PermissionUpdate{}, NewMessageParseError(fmt.Sprintf("%s permission update missing 'directories' field", updateType), data)
+		}
+		update.Directories = make([]string, 0, len(dirs))
+		for _, d := range dirs {
+			dir, ok := d.(string)
+			if !ok {
+				return PermissionUpdate{}, NewMessageParseError("permission update directories must be strings", data)
+			}
+			update.Directories = append(update.Directories, dir)
+		}
+
+	default:
+		return PermissionUpdate{}, NewMessageParseError(fmt.Sprintf("unknown permission update type: %s", updateType), data)
+	}
+
+	return update, nil
+}
+
+// parsePermissionRuleValue parses a single permission rule.
+// Both the CLI's camelCase keys and snake_case keys are accepted.
+func parsePermissionRuleValue(item interface{}) (PermissionRuleValue, error) {
+	rule, ok := item.(map[string]interface{})
+	if !ok {
+		return PermissionRuleValue{}, fmt.Errorf("permission rule must be object")
+	}
+
+	toolName, ok := rule["toolName"].(string)
+	if !ok {
+		toolName, ok = rule["tool_name"].(string)
+	}
+	if !ok {
+		return PermissionRuleValue{}, fmt.Errorf("permission rule missing 'toolName' field")
+	}
+
+	result := PermissionRuleValue{ToolName: toolName}
+	if content, ok := rule["ruleContent"].(string); ok {
+		result.RuleContent = &content
+	} else if content, ok := rule["rule_content"].(string); ok {
+		result.RuleContent = &content
+	}
+
+	return result, nil
+}
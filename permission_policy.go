@@ -0,0 +1,80 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// NewPermissionPolicy returns a policy of rules, or an error naming the
+// first invalid rule.
+//
+// Example:
+//
+//	policy, err := claude.NewPermissionPolicy(
+//	    claude.PolicyRule{Tool: "Bash", CommandRegex: `\brm\s+-rf\b`, Action: "deny"},
+//	    claude.PolicyRule{Tool: "Write", PathPrefix: "/srv/app", Action: "allow"},
+//	    claude.PolicyRule{Tool: "mcp__*", Action: "ask", Reason: "external service"},
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	canUseTool, _ := policy.CanUseTool(nil)
+//	options := &claude.ClaudeAgentOptions{CanUseTool: canUseTool}
+func NewPermissionPolicy(rules ...PolicyRule) (*PermissionPolicy, error) {
+	policy := &PermissionPolicy{Rules: rules}
+	if _, err := compilePolicy(*policy, 0); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// LoadPermissionPolicy reads and validates the policy file at path, decoded
+// with unmarshal (default: json.Unmarshal). Pass e.g. yaml.Unmarshal to
+// keep the policy in YAML. To pick up changes to the file while running,
+// use a PolicyWatcher instead.
+func LoadPermissionPolicy(path string, unmarshal func(data []byte, v interface{}) error) (*PermissionPolicy, error) {
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read permission policy: %w", err)
+	}
+	var policy PermissionPolicy
+	if err := unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("permission policy %s rejected: %w", path, err)
+	}
+	if _, err := compilePolicy(policy, 0); err != nil {
+		return nil, fmt.Errorf("permission policy %s rejected: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// CanUseTool compiles the policy into a CanUseTool callback. Deny and allow
+// decisions are final. Ask decisions and tool uses the policy leaves
+// undecided go to next; without next, ask returns a PermissionResultAsk for
+// ClaudeAgentOptions.Approver and undecided uses are denied, since the CLI
+// only calls CanUseTool for uses it would otherwise prompt for. Later
+// changes to the policy do not affect the callback.
+func (p *PermissionPolicy) CanUseTool(next CanUseTool) (CanUseTool, error) {
+	compiled, err := compilePolicy(*p, 0)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, toolName string, input map[string]interface{}, permCtx ToolPermissionContext) (PermissionResult, error) {
+		rule, ok := compiled.decide(toolName, input)
+		switch {
+		case ok && rule.Action == "deny":
+			return PermissionResultDeny{Behavior: "deny", Message: rule.reason(toolName)}, nil
+		case ok && rule.Action == "allow":
+			return PermissionResultAllow{Behavior: "allow"}, nil
+		case next != nil:
+			return next(ctx, toolName, input, permCtx)
+		case ok:
+			return PermissionResultAsk{Behavior: "ask", Message: rule.reason(toolName)}, nil
+		}
+		return PermissionResultDeny{Behavior: "deny", Message: fmt.Sprintf("%s: no permission policy rule allows it", toolName)}, nil
+	}, nil
+}
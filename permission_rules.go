@@ -0,0 +1,172 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// permissionRules holds permission rules changed at runtime with
+// ClaudeSDKClient.UpdatePermissions.
+//
+// The control protocol has no request for changing rules, so rules are
+// enforced SDK-side in front of the user's CanUseTool, and updates are
+// forwarded to the CLI in the updatedPermissions of the next allow decision.
+type permissionRules struct {
+	mu      sync.Mutex
+	rules   map[PermissionBehavior][]PermissionRuleValue
+	pending []PermissionUpdate // Not yet forwarded to the CLI
+}
+
+func newPermissionRules() *permissionRules {
+	return &permissionRules{rules: make(map[PermissionBehavior][]PermissionRuleValue)}
+}
+
+// update applies rule updates and queues every update for the CLI. setMode
+// updates are applied by the caller through the control protocol and are
+// ignored here.
+func (p *permissionRules) update(updates []PermissionUpdate) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, u := range updates {
+		behavior := PermissionBehaviorAllow
+		if u.Behavior != nil {
+			behavior = *u.Behavior
+		}
+		switch u.Type {
+		case PermissionUpdateTypeAddRules:
+			p.rules[behavior] = append(p.rules[behavior], u.Rules...)
+		case PermissionUpdateTypeReplaceRules:
+			p.rules[behavior] = append([]PermissionRuleValue(nil), u.Rules...)
+		case PermissionUpdateTypeRemoveRules:
+			p.rules[behavior] = removeRules(p.rules[behavior], u.Rules)
+		case PermissionUpdateTypeAddDirectories, PermissionUpdateTypeRemoveDirectories:
+			// Only the CLI can grant directory access
+		case PermissionUpdateTypeSetMode:
+			continue
+		default:
+			return fmt.Errorf("unsupported permission update type: %q", u.Type)
+		}
+		p.pending = append(p.pending, u)
+	}
+	return nil
+}
+
+// decide returns the behavior of the first matching rule, checking deny
+// rules before allow rules. ok is false if no deny or allow rule matches.
+func (p *permissionRules) decide(toolName string, input map[string]interface{}) (behavior PermissionBehavior, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, behavior := range []PermissionBehavior{PermissionBehaviorDeny, PermissionBehaviorAllow} {
+		for _, rule := range p.rules[behavior] {
+			if ruleMatches(rule, toolName, input) {
+				return behavior, true
+			}
+		}
+	}
+	return "", false
+}
+
+// takePending returns the updates not yet forwarded to the CLI and clears them.
+func (p *permissionRules) takePending() []PermissionUpdate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pending := p.pending
+	p.pending = nil
+	return pending
+}
+
+// wrap returns a copy of options whose CanUseTool applies the runtime rules
+// before the user's callback. Options without CanUseTool are returned as is.
+func (p *permissionRules) wrap(options *ClaudeAgentOptions) *ClaudeAgentOptions {
+	if options.CanUseTool == nil {
+		return options
+	}
+	next := options.CanUseTool
+	newOpts := *options
+	newOpts.CanUseTool = func(ctx context.Context, toolName string, input map[string]interface{}, permCtx ToolPermissionContext) (PermissionResult, error) {
+		var result PermissionResult
+		switch behavior, ok := p.decide(toolName, input); {
+		case ok && behavior == PermissionBehaviorDeny:
+			return PermissionResultDeny{Behavior: "deny", Message: fmt.Sprintf("%s denied by permission rule", toolName)}, nil
+		case ok && behavior == PermissionBehaviorAllow:
+			result = PermissionResultAllow{Behavior: "allow"}
+		default:
+			var err error
+			if result, err = next(ctx, toolName, input, permCtx); err != nil {
+				return nil, err
+			}
+		}
+
+		// Forward pending updates so the CLI applies them too
+		if allow, isAllow := result.(PermissionResultAllow); isAllow {
+			if pending := p.takePending(); len(pending) > 0 {
+				allow.UpdatedPermissions = append(allow.UpdatedPermissions, pending...)
+				result = allow
+			}
+		}
+		return result, nil
+	}
+	return &newOpts
+}
+
+// ruleMatches reports whether rule applies to a use of toolName with input.
+// A rule without content matches every use of the tool. For Bash the content
+// is a command, or a prefix when it ends in ":*" (e.g. "npm test:*"); prefix
+// rules never match commands that chain or redirect. For other tools the
+// content is compared with the input's file_path, path, or url.
+func ruleMatches(rule PermissionRuleValue, toolName string, input map[string]interface{}) bool {
+	if rule.ToolName != toolName {
+		return false
+	}
+	if rule.RuleContent == nil || *rule.RuleContent == "" {
+		return true
+	}
+	content := *rule.RuleContent
+
+	if toolName == "Bash" {
+		command := strings.TrimSpace(stringField(input, "command"))
+		if prefix, ok := strings.CutSuffix(content, ":*"); ok {
+			for _, op := range readOnlyShellOperators {
+				if strings.Contains(command, op) {
+					return false
+				}
+			}
+			return command == prefix || strings.HasPrefix(command, prefix+" ")
+		}
+		return command == content
+	}
+	for _, key := range []string{"file_path", "path", "url"} {
+		if stringField(input, key) == content {
+			return true
+		}
+	}
+	return false
+}
+
+// removeRules returns rules without any of remove.
+func removeRules(rules, remove []PermissionRuleValue) []PermissionRuleValue {
+	kept := rules[:0:0]
+	for _, rule := range rules {
+		drop := false
+		for _, r := range remove {
+			if r.ToolName == rule.ToolName && ruleContent(r) == ruleContent(rule) {
+				drop = true
+				break
+			}
+		}
+		if !drop {
+			kept = append(kept, rule)
+		}
+	}
+	return kept
+}
+
+func ruleContent(rule PermissionRuleValue) string {
+	if rule.RuleContent == nil {
+		return ""
+	}
+	return *rule.RuleContent
+}
@@ -0,0 +1,398 @@
+package claude
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PermissionPolicy is a declarative permission policy, typically kept in a
+// JSON file and loaded with LoadPermissionPolicy or a PolicyWatcher:
+//
+//	{
+//	  "rules": [
+//	    {"tool": "Bash", "content": "rm:*", "action": "deny", "reason": "no deletes"},
+//	    {"tool": "Bash", "command_regex": "\\bgit\\s+push\\b", "action": "ask"},
+//	    {"tool": "Bash", "content": "npm test:*", "action": "allow"},
+//	    {"tool": "Write", "path_prefix": "/srv/app/src", "action": "allow"},
+//	    {"tool": "mcp__github__*", "action": "ask"}
+//	  ],
+//	  "default": "deny"
+//	}
+//
+// Deny rules are checked first, then ask rules, then allow rules; a tool use
+// no rule matches gets the Default action, or without one is left to the
+// other permission layers.
+type PermissionPolicy struct {
+	Rules   []PolicyRule `json:"rules"`
+	Default string       `json:"default,omitempty"` // "allow", "deny", "ask", or empty
+}
+
+// PolicyRule is one rule of a PermissionPolicy. A rule applies to a tool use
+// when Tool matches and every condition that is set holds.
+type PolicyRule struct {
+	Tool    string `json:"tool"`              // Tool name or glob, e.g. "Bash" or "mcp__github__*"
+	Content string `json:"content,omitempty"` // As PermissionRuleValue.RuleContent; empty matches every use
+	// PathPrefix matches tool uses whose file_path, path, or notebook_path
+	// is this absolute path or inside it, e.g. "/srv/app/src"
+	PathPrefix string `json:"path_prefix,omitempty"`
+	// CommandRegex matches tool uses whose command contains a match of this
+	// regular expression, e.g. `\bgit\s+push\b`
+	CommandRegex string `json:"command_regex,omitempty"`
+	Action       string `json:"action"`           // "allow", "deny", or "ask"
+	Reason       string `json:"reason,omitempty"` // Shown to Claude for deny and ask
+}
+
+// policyActionOrder is the order in which rules are checked.
+var policyActionOrder = []string{"deny", "ask", "allow"}
+
+// compiledPolicy is a validated PermissionPolicy, grouped by action.
+type compiledPolicy struct {
+	policy  PermissionPolicy
+	rules   map[string][]compiledRule
+	version int
+}
+
+// compiledRule is a PolicyRule with its regular expression compiled.
+type compiledRule struct {
+	PolicyRule
+	command *regexp.Regexp
+}
+
+func compilePolicy(policy PermissionPolicy, version int) (*compiledPolicy, error) {
+	compiled := &compiledPolicy{policy: policy, rules: make(map[string][]compiledRule), version: version}
+	for i, rule := range policy.Rules {
+		if rule.Tool == "" {
+			return nil, fmt.Errorf("policy rule %d: tool is required", i)
+		}
+		if _, err := path.Match(rule.Tool, ""); err != nil {
+			return nil, fmt.Errorf("policy rule %d: invalid tool pattern %q: %w", i, rule.Tool, err)
+		}
+		if !isPolicyAction(rule.Action) {
+			return nil, fmt.Errorf("policy rule %d: action must be allow, deny, or ask, got %q", i, rule.Action)
+		}
+		if rule.PathPrefix != "" && !filepath.IsAbs(rule.PathPrefix) {
+			return nil, fmt.Errorf("policy rule %d: path prefix %q must be absolute", i, rule.PathPrefix)
+		}
+		c := compiledRule{PolicyRule: rule}
+		if rule.CommandRegex != "" {
+			re, err := regexp.Compile(rule.CommandRegex)
+			if err != nil {
+				return nil, fmt.Errorf("policy rule %d: invalid command regex: %w", i, err)
+			}
+			c.command = re
+		}
+		compiled.rules[rule.Action] = append(compiled.rules[rule.Action], c)
+	}
+	if policy.Default != "" && !isPolicyAction(policy.Default) {
+		return nil, fmt.Errorf("policy default must be allow, deny, or ask, got %q", policy.Default)
+	}
+	return compiled, nil
+}
+
+func isPolicyAction(action string) bool {
+	return action == "allow" || action == "deny" || action == "ask"
+}
+
+// decide returns the first matching rule in check order, a rule with the
+// policy's default action if none matches, or ok=false.
+func (p *compiledPolicy) decide(toolName string, input map[string]interface{}) (rule PolicyRule, ok bool) {
+	for _, action := range policyActionOrder {
+		for _, rule := range p.rules[action] {
+			if rule.matches(toolName, input) {
+				return rule.PolicyRule, true
+			}
+		}
+	}
+	if p.policy.Default != "" {
+		return PolicyRule{Tool: toolName, Action: p.policy.Default}, true
+	}
+	return PolicyRule{}, false
+}
+
+// matches reports whether the rule applies to a use of toolName with input.
+func (r compiledRule) matches(toolName string, input map[string]interface{}) bool {
+	if matched, _ := path.Match(r.Tool, toolName); !matched {
+		return false
+	}
+	if r.Content != "" {
+		value := PermissionRuleValue{ToolName: toolName, RuleContent: &r.Content}
+		if !ruleMatches(value, toolName, input) {
+			return false
+		}
+	}
+	if r.PathPrefix != "" && !inputUnderPath(input, r.PathPrefix) {
+		return false
+	}
+	if r.command != nil {
+		command, ok := input["command"].(string)
+		if !ok || !r.command.MatchString(command) {
+			return false
+		}
+	}
+	return true
+}
+
+// inputUnderPath reports whether a tool input's path is prefix or inside
+// it. Relative paths never match.
+func inputUnderPath(input map[string]interface{}, prefix string) bool {
+	prefix = filepath.Clean(prefix)
+	for _, key := range []string{"file_path", "path", "notebook_path"} {
+		p := stringField(input, key)
+		if p == "" || !filepath.IsAbs(p) {
+			continue
+		}
+		p = filepath.Clean(p)
+		if p == prefix || strings.HasPrefix(p, strings.TrimSuffix(prefix, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// reason returns the rule's reason, or a default naming the tool.
+func (r PolicyRule) reason(toolName string) string {
+	if r.Reason != "" {
+		return r.Reason
+	}
+	return fmt.Sprintf("%s: %s by permission policy", toolName, r.Action)
+}
+
+// PolicyReloadedEvent is the Data of an EventTypePolicyReloaded event.
+type PolicyReloadedEvent struct {
+	Path    string `json:"path"`
+	Version int    `json:"version"`         // Increments with each successful load
+	Rules   int    `json:"rules"`           // Rules in the policy now in effect
+	Error   string `json:"error,omitempty"` // Set if the file was rejected; the previous policy stays in effect
+}
+
+// PolicyWatcherOptions configures a PolicyWatcher.
+type PolicyWatcherOptions struct {
+	// Interval between checks of the file (default 2s).
+	Interval time.Duration
+
+	// Unmarshal decodes the file (default: json.Unmarshal). Pass e.g.
+	// yaml.Unmarshal to keep the policy in YAML.
+	Unmarshal func(data []byte, v interface{}) error
+
+	// OnError is called when a changed file cannot be read or compiled.
+	OnError func(err error)
+}
+
+// defaultPolicyWatchInterval is how often a PolicyWatcher checks its file.
+const defaultPolicyWatchInterval = 2 * time.Second
+
+// PolicyWatcher enforces a PermissionPolicy file and reloads it when the
+// file changes, so rules can be tightened without restarting the service.
+//
+// Set it as ClaudeAgentOptions.PolicyWatcher on any number of clients. Each
+// tool use is checked against the policy in effect at that moment; a reload
+// swaps the policy atomically for all of them and emits an
+// EventTypePolicyReloaded event to their EventSinks. A file that fails to
+// parse or validate is rejected and the previous policy stays in effect.
+//
+// Example:
+//
+//	watcher, err := claude.NewPolicyWatcher("/etc/agent/policy.json", claude.PolicyWatcherOptions{})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer watcher.Close()
+//	options := &claude.ClaudeAgentOptions{PolicyWatcher: watcher}
+type PolicyWatcher struct {
+	path    string
+	opts    PolicyWatcherOptions
+	current atomic.Pointer[compiledPolicy]
+
+	mu      sync.Mutex // Guards reloads, content, and sinks
+	content []byte
+	modTime time.Time
+	size    int64
+	sinks   map[int]EventSink
+	nextID  int
+
+	done chan struct{}
+	once sync.Once
+}
+
+// NewPolicyWatcher loads the policy at path and starts watching it. It fails
+// if the initial policy cannot be loaded.
+func NewPolicyWatcher(path string, opts PolicyWatcherOptions) (*PolicyWatcher, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultPolicyWatchInterval
+	}
+	if opts.Unmarshal == nil {
+		opts.Unmarshal = json.Unmarshal
+	}
+	w := &PolicyWatcher{path: path, opts: opts, sinks: make(map[int]EventSink), done: make(chan struct{})}
+	if _, err := w.reload(true); err != nil {
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// Policy returns the policy in effect.
+func (w *PolicyWatcher) Policy() PermissionPolicy {
+	return w.current.Load().policy
+}
+
+// Reload reads the file now, e.g. on SIGHUP, instead of waiting for the next
+// check. It returns the error if the file was rejected.
+func (w *PolicyWatcher) Reload() error {
+	_, err := w.reload(true)
+	return err
+}
+
+// Close stops watching the file. The last policy stays in effect.
+func (w *PolicyWatcher) Close() error {
+	w.once.Do(func() { close(w.done) })
+	return nil
+}
+
+func (w *PolicyWatcher) run() {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.reload(false)
+		}
+	}
+}
+
+// reload loads the file if it changed, or always if force is set. It
+// returns whether a new policy took effect.
+func (w *PolicyWatcher) reload(force bool) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	info, err := os.Stat(w.path)
+	if err == nil && !force && info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return false, nil
+	}
+	var data []byte
+	if err == nil {
+		data, err = os.ReadFile(w.path)
+	}
+	if err == nil && w.current.Load() != nil && bytes.Equal(data, w.content) {
+		w.modTime, w.size = info.ModTime(), info.Size()
+		return false, nil
+	}
+
+	var compiled *compiledPolicy
+	if err == nil {
+		var policy PermissionPolicy
+		if err = w.opts.Unmarshal(data, &policy); err == nil {
+			version := 1
+			if previous := w.current.Load(); previous != nil {
+				version = previous.version + 1
+			}
+			compiled, err = compilePolicy(policy, version)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("permission policy %s rejected: %w", w.path, err)
+		if previous := w.current.Load(); previous != nil {
+			if w.opts.OnError != nil {
+				w.opts.OnError(err)
+			}
+			w.emit(PolicyReloadedEvent{Path: w.path, Version: previous.version, Rules: len(previous.policy.Rules), Error: err.Error()})
+			// Don't report the same broken file again
+			if info != nil {
+				w.modTime, w.size = info.ModTime(), info.Size()
+			}
+		}
+		return false, err
+	}
+
+	w.current.Store(compiled)
+	w.content = data
+	w.modTime, w.size = info.ModTime(), info.Size()
+	w.emit(PolicyReloadedEvent{Path: w.path, Version: compiled.version, Rules: len(compiled.policy.Rules)})
+	return true, nil
+}
+
+// emit sends a reload event to every subscribed sink. Callers hold w.mu.
+func (w *PolicyWatcher) emit(data PolicyReloadedEvent) {
+	for _, sink := range w.sinks {
+		emitEvent(sink, Event{Type: EventTypePolicyReloaded, Data: data})
+	}
+}
+
+// subscribe sends reload events to sink until the returned function is
+// called. A nil watcher or sink is a no-op.
+func (w *PolicyWatcher) subscribe(sink EventSink) func() {
+	if w == nil || sink == nil {
+		return func() {}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := w.nextID
+	w.nextID++
+	w.sinks[id] = sink
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.sinks, id)
+	}
+}
+
+// applyPolicy returns a copy of options that enforces options.PolicyWatcher
+// ahead of the user's hooks and CanUseTool, like ReadOnly. Policies need the
+// control protocol, so they require streaming mode.
+func applyPolicy(options *ClaudeAgentOptions, isStreaming bool) (*ClaudeAgentOptions, error) {
+	if !isStreaming {
+		return nil, fmt.Errorf("permission policy requires streaming mode")
+	}
+	watcher := options.PolicyWatcher
+	newOpts := *options
+
+	if next := options.CanUseTool; next != nil {
+		newOpts.CanUseTool = func(ctx context.Context, toolName string, input map[string]interface{}, permCtx ToolPermissionContext) (PermissionResult, error) {
+			rule, ok := watcher.current.Load().decide(toolName, input)
+			switch {
+			case ok && rule.Action == "deny":
+				return PermissionResultDeny{Behavior: "deny", Message: rule.reason(toolName)}, nil
+			case ok && rule.Action == "allow":
+				return PermissionResultAllow{Behavior: "allow"}, nil
+			}
+			return next(ctx, toolName, input, permCtx)
+		}
+	}
+
+	guard := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
+		toolName, _ := input["tool_name"].(string)
+		toolInput, _ := input["tool_input"].(map[string]interface{})
+		rule, ok := watcher.current.Load().decide(toolName, toolInput)
+		if !ok {
+			return HookJSONOutput{}, nil
+		}
+		reason := ""
+		if rule.Action != "allow" {
+			reason = rule.reason(toolName)
+		}
+		return toolUseDecision(PermissionBehavior(rule.Action), reason), nil
+	}
+
+	newOpts.Hooks = make(map[HookEvent][]HookMatcher, len(options.Hooks)+1)
+	for event, matchers := range options.Hooks {
+		newOpts.Hooks[event] = matchers
+	}
+	newOpts.Hooks[HookEventPreToolUse] = append(
+		[]HookMatcher{{Hooks: []HookCallback{guard}}},
+		options.Hooks[HookEventPreToolUse]...,
+	)
+	return &newOpts, nil
+}
@@ -0,0 +1,111 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// MessagePage is one page of messages returned by PollMessages.
+type MessagePage struct {
+	Entries []HistoryEntry // Messages after the requested cursor, oldest first
+	Cursor  uint64         // Pass to the next PollMessages call
+	Missed  uint64         // Messages after the cursor already evicted from history
+}
+
+// after returns up to limit entries with Seq > cursor (all if limit <= 0),
+// and a channel closed when the next message is added.
+func (h *messageHistory) after(cursor uint64, limit int) (MessagePage, <-chan struct{}) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	page := MessagePage{Cursor: cursor}
+	i := sort.Search(len(h.entries), func(i int) bool { return h.entries[i].Seq > cursor })
+	if i < len(h.entries) && h.entries[i].Seq > cursor+1 {
+		page.Missed = h.entries[i].Seq - cursor - 1
+	}
+	entries := h.entries[i:]
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	if len(entries) > 0 {
+		page.Entries = append([]HistoryEntry(nil), entries...)
+		page.Cursor = entries[len(entries)-1].Seq
+	}
+	return page, h.changed
+}
+
+// PollMessages returns up to limit messages received after cursor, for web
+// backends that serve a session through HTTP long-polling or cursor-based
+// REST APIs instead of holding a stream open. Pass 0 to start from the
+// oldest retained message, then the returned page's Cursor.
+//
+// If no messages are available it waits until one arrives, the session's
+// message stream ends (returning Err()), or ctx is done (returning
+// ctx.Err()); the page then keeps the same cursor. A page with Missed > 0
+// means the caller fell behind HistorySize and messages were lost.
+//
+// PollMessages requires ClaudeAgentOptions.HistorySize. It reads messages
+// in the background into the history buffer, so don't also consume them
+// with Query, ReceiveMessages, or ReceiveResponse; send queries with
+// QueryWithSession.
+//
+// Example handler:
+//
+//	ctx, cancel := context.WithTimeout(r.Context(), 25*time.Second)
+//	defer cancel()
+//	page, err := client.PollMessages(ctx, cursor, 100)
+//	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+//	    http.Error(w, err.Error(), http.StatusBadGateway)
+//	    return
+//	}
+//	json.NewEncoder(w).Encode(page)
+func (c *ClaudeSDKClient) PollMessages(ctx context.Context, cursor uint64, limit int) (MessagePage, error) {
+	if c.history == nil {
+		return MessagePage{Cursor: cursor}, fmt.Errorf("PollMessages requires ClaudeAgentOptions.HistorySize")
+	}
+	if err := c.checkConnected(); err != nil {
+		return MessagePage{Cursor: cursor}, err
+	}
+
+	done := c.startPump()
+	for {
+		page, changed := c.history.after(cursor, limit)
+		if len(page.Entries) > 0 || page.Missed > 0 {
+			return page, nil
+		}
+		select {
+		case <-changed:
+		case <-done:
+			// Messages added just before the stream ended are still returned
+			if page, _ := c.history.after(cursor, limit); len(page.Entries) > 0 || page.Missed > 0 {
+				return page, nil
+			}
+			return page, c.Err()
+		case <-ctx.Done():
+			return page, ctx.Err()
+		}
+	}
+}
+
+// startPump starts reading the current connection's messages into history,
+// once per connection, and returns a channel closed when the stream ends.
+func (c *ClaudeSDKClient) startPump() <-chan struct{} {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pumpHandler == c.queryHandler {
+		return c.pumpDone
+	}
+	done := make(chan struct{})
+	c.pumpHandler, c.pumpDone = c.queryHandler, done
+	messages := c.receiveMessagesLocked(c.ctx, false)
+	go func() {
+		defer close(done)
+		for range messages {
+			// ReceiveMessages records each message in history
+		}
+	}()
+	return done
+}
@@ -1,9 +1,6 @@
 package claude
 
-import (
-	"context"
-	"os"
-)
+import "context"
 
 // Query performs a one-shot or unidirectional streaming query to Claude Code.
 //
@@ -44,8 +41,12 @@ func Query(
 	options *ClaudeAgentOptions,
 	trans Transport,
 ) (<-chan Message, <-chan error, error) {
-	os.Setenv("CLAUDE_CODE_ENTRYPOINT", "sdk-go")
-	return processQuery(ctx, prompt, options, trans)
+	// Prompts too large for a CLI argument are sent over stdin instead
+	if len(prompt) > largePromptThreshold(options) {
+		input, endInput := streamedPrompt(prompt)
+		return processQuery(ctx, input, options, trans, endInput)
+	}
+	return processQuery(ctx, prompt, options, trans, nil)
 }
 
 // QueryStream performs a streaming query with multiple input messages.
@@ -57,13 +58,7 @@ func Query(
 //
 //	go func() {
 //	    defer close(promptCh)
-//	    promptCh <- map[string]interface{}{
-//	        "type": "user",
-//	        "message": map[string]interface{}{
-//	            "role": "user",
-//	            "content": "Hello",
-//	        },
-//	    }
+//	    promptCh <- NewUserMessage("Hello")
 //	}()
 //
 //	msgCh, errCh, err := QueryStream(ctx, promptCh, nil, nil)
@@ -73,20 +68,32 @@ func QueryStream(
 	options *ClaudeAgentOptions,
 	trans Transport,
 ) (<-chan Message, <-chan error, error) {
-	os.Setenv("CLAUDE_CODE_ENTRYPOINT", "sdk-go")
-	return processQuery(ctx, prompts, options, trans)
+	return processQuery(ctx, prompts, options, trans, nil)
 }
 
-// processQuery is the internal implementation for Query and QueryStream
+// processQuery is the internal implementation for Query and QueryStream.
+// If endInput is set, it is called when the first ResultMessage arrives or
+// the query ends, to close a prompt channel owned by the caller.
 func processQuery(
 	ctx context.Context,
 	prompt interface{}, // string or <-chan map[string]interface{}
 	options *ClaudeAgentOptions,
 	trans Transport,
+	endInput func(),
 ) (<-chan Message, <-chan error, error) {
 	if options == nil {
 		options = &ClaudeAgentOptions{}
 	}
+	if endInput == nil {
+		endInput = func() {}
+	}
+	options = withMaxOutputTokensOverride(ctx, options)
+	if err := validateMaxOutputTokens(options); err != nil {
+		return nil, nil, err
+	}
+	if err := validateAutoCompact(options); err != nil {
+		return nil, nil, err
+	}
 
 	// Validate and configure permission settings
 	_, isStreaming := prompt.(<-chan map[string]interface{})
@@ -95,6 +102,16 @@ func processQuery(
 		return nil, nil, err
 	}
 
+	if err := validateMcpToolsAtConnect(configuredOptions); err != nil {
+		return nil, nil, err
+	}
+
+	// A Query() call is a single query and session for BudgetLimits
+	budget := newBudgetGuard(nil, configuredOptions.BudgetLimits, configuredOptions.MaxBudgetUSD)
+	if err := budget.begin(ctx); err != nil {
+		return nil, nil, err
+	}
+
 	// Use provided transport or create subprocess transport
 	chosenTransport := trans
 	if chosenTransport == nil {
@@ -105,8 +122,15 @@ func processQuery(
 		}
 	}
 
+	compressor := newInputCompressor(configuredOptions)
+	chosenTransport = withTranscript(withInputCompression(chosenTransport, compressor), configuredOptions)
+
+	// The CLI outlives a canceled ctx until its turn is interrupted; the
+	// goroutine below closes it when the query ends
+	runCtx := context.WithoutCancel(ctx)
+
 	// Connect transport
-	if err := chosenTransport.Connect(ctx); err != nil {
+	if err := chosenTransport.Connect(runCtx); err != nil {
 		return nil, nil, err
 	}
 
@@ -114,10 +138,7 @@ func processQuery(
 	sdkMcpServers := extractSdkMcpServers(configuredOptions.McpServers)
 
 	// Determine buffer size
-	bufferSize := 100 // default
-	if configuredOptions.MessageChannelBufferSize != nil && *configuredOptions.MessageChannelBufferSize > 0 {
-		bufferSize = *configuredOptions.MessageChannelBufferSize
-	}
+	bufferSize := bufferSizeOrDefault(configuredOptions.MessageChannelBufferSize, defaultMessageChannelBufferSize)
 
 	// Create queryHandler to handle control protocol
 	q := newQueryHandler(
@@ -128,15 +149,31 @@ func processQuery(
 		sdkMcpServers,
 		bufferSize,
 	)
+	q.setTranscript(configuredOptions)
+	q.setSDKInfo(configuredOptions)
+	q.setFilters(configuredOptions)
+	q.setMetrics(configuredOptions)
+	q.setBuffering(configuredOptions)
+	q.compressor = compressor
+	q.approver = configuredOptions.Approver
+	q.callbackTimeout = newCallbackTimeouts(configuredOptions)
+	q.audit = newAuditor(configuredOptions)
+	q.telemetry = newTelemetry(configuredOptions)
+	q.errs = newErrorPipeline(configuredOptions)
+	q.sink = eventSinkFor(configuredOptions)
+	q.metadata.set(mergeMetadata(configuredOptions.Metadata, MetadataFromContext(ctx)))
+	q.budget.setSession(ctx, budget)
 
 	// Start reading messages
-	if err := q.Start(ctx); err != nil {
+	if err := q.Start(runCtx); err != nil {
+		q.Close()
 		return nil, nil, err
 	}
 
 	// Initialize if streaming
 	if isStreaming {
 		if _, err := q.Initialize(ctx); err != nil {
+			q.Close()
 			return nil, nil, err
 		}
 
@@ -148,41 +185,106 @@ func processQuery(
 		}
 	}
 
+	q.telemetry.startQuery(ctx)
+	parser := newMessageParser(configuredOptions)
+
 	// Create output channels
-	msgCh := make(chan Message, 10)
+	msgCh := make(chan Message, outputBufferSize(configuredOptions))
 	errCh := make(chan error, 1)
 
+	correlationID := CorrelationIDFromContext(ctx)
+	auth := newAuthTracker(configuredOptions)
+	errs := q.errs
+
 	// Parse and yield messages
 	go func() {
 		defer close(msgCh)
 		defer close(errCh)
-		defer q.Close()
+		interrupt := false
+		defer func() {
+			if !interrupt {
+				q.Close()
+				return
+			}
+			// In the background, so the channels close promptly
+			go func() {
+				interruptCanceled(ctx, q)
+				q.Close()
+			}()
+		}()
+		defer endInput()
+		defer configuredOptions.PolicyWatcher.subscribe(q.sink)() // Unsubscribe when the query ends
+		defer func() {
+			// Deliver exactly one terminal error, or none
+			err := errs.err()
+			if err != nil {
+				errCh <- correlateError(err, correlationID)
+			}
+			q.telemetry.finish(err)
+		}()
 
+		canceled := func() {
+			errs.fail(ctx.Err())
+			interrupt = isStreaming && interruptOnCancel(configuredOptions)
+		}
+
+		sawResult := false
 		for {
 			select {
 			case <-ctx.Done():
-				errCh <- ctx.Err()
+				canceled()
 				return
 			case err := <-q.ReceiveErrors():
 				if err != nil {
-					errCh <- err
+					errs.fail(auth.resolve(ctx, err))
 					return
 				}
 			case data, ok := <-q.ReceiveMessages():
 				if !ok {
+					// The handler closes its error channel first, so a
+					// transport error raced with the close is still readable.
+					if err := <-q.ReceiveErrors(); err != nil {
+						errs.fail(auth.resolve(ctx, err))
+					} else if !sawResult {
+						errs.fail(ErrStreamTruncated)
+					}
 					return
 				}
-				msg, err := parseMessage(data)
+				msg, err := parseOrSkip(parser, data, errs)
 				if err != nil {
-					errCh <- err
+					errs.fail(err)
 					return
 				}
+				if msg == nil {
+					continue
+				}
+				if _, ok := msg.(*ResultMessage); ok {
+					sawResult = true
+					endInput()
+				}
+				tagMessage(msg, correlationID)
+				annotateResult(msg, configuredOptions.MaxOutputTokens)
+				q.telemetry.observe(msg)
+				emitMessageEvents(q.sink, msg, correlationID)
 				select {
 				case msgCh <- msg:
 				case <-ctx.Done():
-					errCh <- ctx.Err()
+					canceled()
 					return
 				}
+				// Authentication failures are terminal but the remaining
+				// messages (e.g. the ResultMessage) are still delivered
+				if err := auth.observe(ctx, msg); err != nil {
+					errs.fail(err)
+				}
+				if _, err := budget.observe(ctx, msg, errs); err != nil {
+					errs.fail(err)
+				} else if err := budget.queryErr(); err != nil {
+					errs.fail(err)
+				}
+				if err := errorFromResult(configuredOptions, msg); err != nil {
+					errs.fail(err)
+				}
 			}
 		}
 	}()
@@ -0,0 +1,90 @@
+package claude
+
+import (
+	"context"
+)
+
+// QueryEventHandlers receives the parts of a reply by kind, for
+// QueryWithEvents. Nil handlers are skipped. Subagent output (messages with
+// a ParentToolUseID) is not delivered.
+type QueryEventHandlers struct {
+	// OnText is called with each piece of Claude's text: each delta with
+	// IncludePartialMessages, otherwise each text block
+	OnText func(text string)
+	// OnThinking is called with each piece of thinking, like OnText
+	OnThinking func(thinking string)
+	// OnToolUse is called with each complete tool call, once its
+	// AssistantMessage arrives
+	OnToolUse func(toolUse ToolUseBlock)
+	// OnToolResult is called with the result of each tool call
+	OnToolResult func(result ToolResultBlock)
+	// OnResult is called with the query's ResultMessage
+	OnResult func(result *ResultMessage)
+}
+
+// QueryWithEvents sends prompt like Query and dispatches the reply to
+// handlers by kind, so UI code does not need a type switch over every
+// message. It returns when the query ends, with its ResultMessage. Handlers
+// run on the calling goroutine, in message order.
+//
+// Example:
+//
+//	result, err := client.QueryWithEvents(ctx, "Fix the failing test", claude.QueryEventHandlers{
+//	    OnText:    func(text string) { fmt.Print(text) },
+//	    OnToolUse: func(toolUse claude.ToolUseBlock) { fmt.Printf("\n[%s]\n", toolUse.Name) },
+//	    OnToolResult: func(result claude.ToolResultBlock) {
+//	        if result.IsError != nil && *result.IsError {
+//	            fmt.Println("[tool failed]")
+//	        }
+//	    },
+//	})
+func (c *ClaudeSDKClient) QueryWithEvents(ctx context.Context, prompt string, handlers QueryEventHandlers) (*ResultMessage, error) {
+	msgCh, errCh := c.Query(ctx, prompt)
+	return dispatchQueryEvents(msgCh, errCh, handlers)
+}
+
+// QueryWithEvents is ClaudeSDKClient.QueryWithEvents for this session.
+func (s *Session) QueryWithEvents(ctx context.Context, prompt string, handlers QueryEventHandlers) (*ResultMessage, error) {
+	msgCh, errCh := s.Query(ctx, prompt)
+	return dispatchQueryEvents(msgCh, errCh, handlers)
+}
+
+// dispatchQueryEvents reads a query's messages into handlers.
+func dispatchQueryEvents(msgCh <-chan Message, errCh <-chan error, handlers QueryEventHandlers) (*ResultMessage, error) {
+	// The accumulator already tells streamed text from repeated text
+	text := NewTextAccumulator()
+	text.OnTextDelta = handlers.OnText
+	text.OnThinkingDelta = handlers.OnThinking
+
+	var result *ResultMessage
+	for msg := range msgCh {
+		text.Observe(msg)
+		switch m := msg.(type) {
+		case *AssistantMessage:
+			if m.ParentToolUseID != nil || handlers.OnToolUse == nil {
+				continue
+			}
+			for _, block := range m.Content {
+				if toolUse, ok := block.(ToolUseBlock); ok {
+					handlers.OnToolUse(toolUse)
+				}
+			}
+		case *UserMessage:
+			blocks, ok := m.Content.([]ContentBlock)
+			if !ok || m.ParentToolUseID != nil || handlers.OnToolResult == nil {
+				continue
+			}
+			for _, block := range blocks {
+				if toolResult, ok := block.(ToolResultBlock); ok {
+					handlers.OnToolResult(toolResult)
+				}
+			}
+		case *ResultMessage:
+			result = m
+			if handlers.OnResult != nil {
+				handlers.OnResult(m)
+			}
+		}
+	}
+	return result, <-errCh
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
@@ -15,6 +16,8 @@ type queryHandler struct {
 	transport       Transport
 	isStreamingMode bool
 	canUseTool      CanUseTool
+	approver        Approver // Resolves PermissionResultAsk; may be nil
+	callbackTimeout callbackTimeouts
 	hooks           map[string][]hookMatcherInternal
 	sdkMcpServers   map[string]interface{} // Map of server name to MCP server instance
 
@@ -25,12 +28,62 @@ type queryHandler struct {
 	requestCounter          int
 	mu                      sync.Mutex
 
-	// Message streaming
+	// Message streaming; with AdaptiveBuffering, messages are delivered to
+	// buffer, which feeds messageChan
 	messageChan chan map[string]interface{}
+	buffer      *adaptiveBuffer
 	errorChan   chan error
 	cancelFunc  context.CancelFunc
 	initialized bool
 	initResult  map[string]interface{}
+	initRequest map[string]interface{} // Sent again after a reconnect; guarded by mu
+	model       string                 // Last model set with SetModel; guarded by mu
+
+	// Subagent attribution for agent-scoped hooks; nil if none are configured
+	agents *agentIndex
+
+	// Receives recoverable control protocol failures; may be nil
+	errs *errorPipeline
+
+	// Receives permission decision events; may be nil
+	sink EventSink
+
+	// Metadata passed to hooks, CanUseTool, and tool handlers
+	metadata turnMetadata
+
+	// SessionBudget passed to hooks, CanUseTool, and tool handlers
+	budget turnBudget
+
+	// Describes the SDK in the initialize request; see setSDKInfo
+	sdkInfo map[string]interface{}
+
+	// Control requests and SDK MCP calls the CLI may cancel
+	inflight inflightRequests
+
+	// Optional instrumentation
+	metrics       Metrics
+	profileLabels bool
+	telemetry     *telemetry // Shared with the client; nil if disabled
+
+	// Queries awaiting their result, shared with the client; nil for Query
+	queries *pendingQueries
+	done    chan struct{} // Closed when routing ends
+
+	// Enabled by the initialize response when the CLI accepts compressed
+	// input; nil if CompressInputThreshold is unset
+	compressor *inputCompressor
+
+	// Inbound filters; nil if unset
+	resultFilter ToolResultFilter
+	streamFilter *streamFilter // Only used by the routing goroutine
+
+	// Records tool invocations; nil if AuditLog is unset
+	audit *auditor
+
+	// Optional raw message tap
+	transcript         *TranscriptRecorder
+	transcriptLog      logFunc
+	transcriptTimezone *time.Location
 }
 
 type controlResult struct {
@@ -52,7 +105,16 @@ func newQueryHandler(
 
 	// Use default buffer size if not specified or invalid
 	if bufferSize <= 0 {
-		bufferSize = 100
+		bufferSize = defaultMessageChannelBufferSize
+	}
+
+	var agents *agentIndex
+	for _, matchers := range internalHooks {
+		for _, matcher := range matchers {
+			if matcher.Agent != "" {
+				agents = newAgentIndex()
+			}
+		}
 	}
 
 	return &queryHandler{
@@ -65,6 +127,54 @@ func newQueryHandler(
 		hookCallbacks:           make(map[string]HookCallback),
 		messageChan:             make(chan map[string]interface{}, bufferSize),
 		errorChan:               make(chan error, 1),
+		done:                    make(chan struct{}),
+		agents:                  agents,
+	}
+}
+
+// setTranscript records inbound messages to options.Transcript, if set.
+// Must be called before Start.
+func (q *queryHandler) setTranscript(options *ClaudeAgentOptions) {
+	if options == nil || options.Transcript == nil {
+		return
+	}
+	q.transcript = options.Transcript
+	q.transcriptLog = transcriptLogger(options)
+	q.transcriptTimezone = options.Timezone
+}
+
+// setSDKInfo describes the SDK and options.AppName in the initialize
+// request. Must be called before Initialize.
+func (q *queryHandler) setSDKInfo(options *ClaudeAgentOptions) {
+	q.sdkInfo = sdkInitializeInfo(options)
+}
+
+// setFilters applies options.ToolResultFilter and options.StreamEventFilter
+// to inbound messages. Must be called before Start.
+func (q *queryHandler) setFilters(options *ClaudeAgentOptions) {
+	if options == nil {
+		return
+	}
+	q.resultFilter = options.ToolResultFilter
+	q.streamFilter = newStreamFilter(options.StreamEventFilter)
+}
+
+// setMetrics instruments routing and callbacks as configured in options.
+// Must be called before Start.
+func (q *queryHandler) setMetrics(options *ClaudeAgentOptions) {
+	q.metrics = metricsFor(options)
+	q.profileLabels = profileLabelsEnabled(options)
+}
+
+// setBuffering replaces the fixed message buffer with an adaptive one if
+// options.AdaptiveBuffering is set. Call after setMetrics and before Start.
+func (q *queryHandler) setBuffering(options *ClaudeAgentOptions) {
+	if options == nil {
+		return
+	}
+	q.buffer = newAdaptiveBuffer(options.AdaptiveBuffering, cap(q.messageChan), q.metrics)
+	if q.buffer != nil {
+		q.messageChan = q.buffer.out
 	}
 }
 
@@ -75,48 +185,164 @@ func (q *queryHandler) Start(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	q.cancelFunc = cancel
 
+	if q.isStreamingMode {
+		for t := q.transport; t != nil; t = unwrapTransport(t) {
+			if notifier, ok := t.(ReconnectNotifier); ok {
+				notifier.SetReconnectHandler(func() { q.reconnected(ctx) })
+				break
+			}
+		}
+	}
+
+	if q.buffer != nil {
+		go q.buffer.run(ctx)
+	}
+
 	// Start message router
-	go q.routeMessages(ctx, msgCh, errCh)
+	go withProfileLabels(ctx, q.profileLabels, func(ctx context.Context) {
+		q.routeMessages(ctx, msgCh, errCh)
+	}, "claude_sdk", "route")
 
 	return nil
 }
 
 // routeMessages reads from transport and routes control vs regular messages.
 func (q *queryHandler) routeMessages(ctx context.Context, msgCh <-chan map[string]interface{}, errCh <-chan error) {
-	defer close(q.messageChan)
+	if q.buffer != nil {
+		// The buffer closes messageChan once drained
+		defer close(q.buffer.in)
+	} else {
+		defer close(q.messageChan)
+	}
 	defer close(q.errorChan)
+	defer close(q.done)
+	defer q.audit.flush()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case err := <-errCh:
+		case err, ok := <-errCh:
+			if !ok {
+				// Error channel closed without error; keep draining messages
+				errCh = nil
+				continue
+			}
 			if err != nil {
-				q.errorChan <- err
+				q.drainBuffered(ctx, msgCh)
+				q.sendError(ctx, err)
+				return
 			}
-			return
 		case msg, ok := <-msgCh:
 			if !ok {
+				// Deliver deltas still being merged
+				for _, pending := range q.streamFilter.flushAll() {
+					if !q.deliver(ctx, pending) {
+						return
+					}
+				}
+				// Transports may close msgCh right after queueing an error;
+				// don't lose it to select's random choice
+				if errCh != nil {
+					select {
+					case err, ok := <-errCh:
+						if ok && err != nil {
+							q.sendError(ctx, err)
+						}
+					default:
+					}
+				}
 				return
 			}
+			if !q.routeMessage(ctx, msg) {
+				return
+			}
+		}
+	}
+}
 
-			msgType, _ := msg["type"].(string)
-
-			switch msgType {
-			case "control_response":
-				q.handleControlResponse(msg)
-			case "control_request":
-				go q.handleControlRequest(ctx, msg)
-			case "control_cancel_request":
-				// TODO: Implement cancellation
-			default:
-				// Regular SDK message
-				select {
-				case q.messageChan <- msg:
-				case <-ctx.Done():
-					return
-				}
+// routeMessage dispatches a single transport message. Returns false if ctx is done.
+func (q *queryHandler) routeMessage(ctx context.Context, msg map[string]interface{}) bool {
+	msgType, _ := msg["type"].(string)
+	if q.metrics != nil {
+		defer observeSince(q.metrics, MetricRouteDuration, time.Now(), map[string]string{"type": msgType})
+	}
+
+	// Filter before anything records the message
+	filterToolResults(q.resultFilter, msg)
+	if q.transcript != nil {
+		q.transcript.recordInbound(msg, q.transcriptTimezone, q.transcriptLog)
+	}
+	if q.agents != nil {
+		q.agents.observe(msg)
+	}
+	q.audit.observe(msg)
+
+	switch msgType {
+	case MessageTypeControlResponse:
+		q.handleControlResponse(msg)
+	case MessageTypeControlRequest:
+		requestCtx, done := q.inflight.track(ctx, msg)
+		go func() {
+			defer done()
+			q.handleControlRequest(requestCtx, msg)
+		}()
+	case MessageTypeControlCancelRequest:
+		requestID, _ := msg["request_id"].(string)
+		q.inflight.cancel(requestID)
+	default:
+		// Regular SDK message
+		if msgType == "result" {
+			q.queries.finish()
+			sessionID, _ := msg["session_id"].(string)
+			q.metadata.finish(sessionID)
+		}
+		if q.streamFilter == nil {
+			return q.deliver(ctx, msg)
+		}
+		for _, out := range q.streamFilter.process(msg) {
+			if !q.deliver(ctx, out) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// deliver sends msg to the message channel. Returns false if ctx is done.
+func (q *queryHandler) deliver(ctx context.Context, msg map[string]interface{}) bool {
+	out := q.messageChan
+	if q.buffer != nil {
+		out = q.buffer.in
+	}
+	select {
+	case out <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sendError sends the error that ends routing. An adaptive buffer is
+// drained first so the error does not overtake messages it holds.
+func (q *queryHandler) sendError(ctx context.Context, err error) {
+	if q.buffer != nil {
+		q.buffer.wait(ctx)
+	}
+	q.errorChan <- err
+}
+
+// drainBuffered routes messages already buffered in msgCh so that messages
+// produced before a transport error are not lost.
+func (q *queryHandler) drainBuffered(ctx context.Context, msgCh <-chan map[string]interface{}) {
+	for {
+		select {
+		case msg, ok := <-msgCh:
+			if !ok || !q.routeMessage(ctx, msg) {
+				return
 			}
+		default:
+			return
 		}
 	}
 }
@@ -127,10 +353,62 @@ func (q *queryHandler) Initialize(ctx context.Context) (map[string]interface{},
 		return nil, nil
 	}
 
+	request := q.initializeRequest()
+	q.mu.Lock()
+	q.initRequest = request
+	q.mu.Unlock()
+	response, err := q.sendControlRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	q.initialized = true
+	q.initResult = response
+	q.compressor.negotiate(response)
+	return response, nil
+}
+
+// reconnected handles a reconnect of the transport. Control requests sent
+// on the old connection get no response, so they fail now; the initialize
+// request is sent again, as the server may have started a new CLI that
+// knows nothing of the session's hooks and SDK MCP servers.
+func (q *queryHandler) reconnected(ctx context.Context) {
+	q.mu.Lock()
+	for requestID, resultChan := range q.pendingControlResponses {
+		select {
+		case resultChan <- controlResult{err: fmt.Errorf("connection lost; the transport reconnected before a response arrived")}:
+		default: // Answered already
+		}
+		delete(q.pendingControlResponses, requestID) // A late response must not block routing
+	}
+	request := q.initRequest
+	q.mu.Unlock()
+
+	if request == nil {
+		return // Initialize has not been sent yet
+	}
+	go func() {
+		if _, err := q.sendControlRequest(ctx, request); err != nil {
+			q.errs.warn(err)
+		}
+	}()
+}
+
+// initializeRequest builds the initialize control request, registering hook
+// callbacks under the IDs it sends.
+func (q *queryHandler) initializeRequest() map[string]interface{} {
 	// Build hooks configuration
 	hooksConfig := make(map[string]interface{})
 	if len(q.hooks) > 0 {
-		for event, matchers := range q.hooks {
+		// In a stable order, so callback IDs match those in recorded
+		// transcripts
+		events := make([]string, 0, len(q.hooks))
+		for event := range q.hooks {
+			events = append(events, event)
+		}
+		sort.Strings(events)
+		for _, event := range events {
+			matchers := q.hooks[event]
 			if len(matchers) == 0 {
 				continue
 			}
@@ -139,6 +417,9 @@ func (q *queryHandler) Initialize(ctx context.Context) (map[string]interface{},
 			for i, matcher := range matchers {
 				callbackIDs := make([]string, len(matcher.Hooks))
 				for j, callback := range matcher.Hooks {
+					if matcher.Agent != "" {
+						callback = q.scopeHookToAgent(matcher.Agent, callback)
+					}
 					callbackID := fmt.Sprintf("hook_%d", q.nextCallbackID)
 					q.nextCallbackID++
 					q.hookCallbacks[callbackID] = callback
@@ -154,28 +435,22 @@ func (q *queryHandler) Initialize(ctx context.Context) (map[string]interface{},
 		}
 	}
 
-	request := map[string]interface{}{
-		"subtype": "initialize",
-	}
-	if len(hooksConfig) > 0 {
-		request["hooks"] = hooksConfig
-	}
-
-	response, err := q.sendControlRequest(ctx, request)
-	if err != nil {
-		return nil, err
-	}
-
-	q.initialized = true
-	q.initResult = response
-	return response, nil
+	request, _ := EncodeControlRequest(InitializeRequest{
+		Subtype: ControlSubtypeInitialize,
+		Hooks:   hooksConfig,
+		SDK:     q.sdkInfo,
+	})
+	return request
 }
 
 // sendControlRequest sends a control request and waits for response.
-func (q *queryHandler) sendControlRequest(ctx context.Context, request map[string]interface{}) (map[string]interface{}, error) {
+func (q *queryHandler) sendControlRequest(ctx context.Context, request map[string]interface{}) (response map[string]interface{}, err error) {
+	subtype, _ := request["subtype"].(string)
 	if !q.isStreamingMode {
-		return nil, fmt.Errorf("control requests require streaming mode")
+		return nil, NewStreamingRequiredError(subtype, SessionModeOneShot)
 	}
+	ctx, endSpan := q.telemetry.startControlRequest(ctx, subtype, "outbound")
+	defer func() { endSpan(err) }()
 
 	q.mu.Lock()
 	q.requestCounter++
@@ -191,10 +466,10 @@ func (q *queryHandler) sendControlRequest(ctx context.Context, request map[strin
 	}()
 
 	// Build and send request
-	controlRequest := map[string]interface{}{
-		"type":       "control_request",
-		"request_id": requestID,
-		"request":    request,
+	controlRequest := ControlRequest{
+		Type:      MessageTypeControlRequest,
+		RequestID: requestID,
+		Request:   request,
 	}
 
 	data, err := json.Marshal(controlRequest)
@@ -213,11 +488,22 @@ func (q *queryHandler) sendControlRequest(ctx context.Context, request map[strin
 	select {
 	case result := <-resultChan:
 		if result.err != nil {
-			return nil, result.err
+			return nil, NewControlRequestError(requestID, subtype, result.err.Error(), nil)
 		}
 		return result.response, nil
 	case <-timeoutCtx.Done():
-		return nil, fmt.Errorf("control request timeout: %s", request["subtype"])
+		return nil, NewControlRequestError(requestID, subtype, "control request timeout", timeoutCtx.Err())
+	case <-q.done:
+		// Closed, e.g. by a restart; no response can arrive now
+		select {
+		case result := <-resultChan:
+			if result.err != nil {
+				return nil, NewControlRequestError(requestID, subtype, result.err.Error(), nil)
+			}
+			return result.response, nil
+		default:
+			return nil, NewControlRequestError(requestID, subtype, "connection closed", ErrClosed)
+		}
 	}
 }
 
@@ -241,13 +527,17 @@ func (q *queryHandler) handleControlResponse(msg map[string]interface{}) {
 		return
 	}
 
+	var result controlResult
 	subtype, _ := response["subtype"].(string)
-	if subtype == "error" {
+	if ControlSubtype(subtype) == ControlSubtypeError {
 		errorMsg, _ := response["error"].(string)
-		resultChan <- controlResult{err: fmt.Errorf("%s", errorMsg)}
+		result.err = fmt.Errorf("%s", errorMsg)
 	} else {
-		responseData, _ := response["response"].(map[string]interface{})
-		resultChan <- controlResult{response: responseData}
+		result.response, _ = response["response"].(map[string]interface{})
+	}
+	select {
+	case resultChan <- result:
+	default: // Failed already by a reconnect
 	}
 }
 
@@ -256,74 +546,110 @@ func (q *queryHandler) handleControlRequest(ctx context.Context, msg map[string]
 	requestID, _ := msg["request_id"].(string)
 	request, _ := msg["request"].(map[string]interface{})
 	subtype, _ := request["subtype"].(string)
+	ctx = q.metadata.attach(ctx, controlRequestSessionID(request))
+	ctx = q.budget.attach(ctx)
+	// The response is sent even if the CLI cancelled the request
+	writeCtx := context.WithoutCancel(ctx)
 
 	var responseData map[string]interface{}
 	var err error
+	ctx, endSpan := q.telemetry.startControlRequest(ctx, subtype, "inbound")
+	defer func() { endSpan(err) }()
+
+	start := time.Now()
+	withProfileLabels(ctx, q.profileLabels, func(ctx context.Context) {
+		decoded, decodeErr := DecodeControlRequest(request)
+		switch r := decoded.(type) {
+		case *CanUseToolRequest:
+			responseData, err = q.handleCanUseTool(ctx, requestID, r)
+		case *HookCallbackRequest:
+			responseData, err = q.handleHookCallback(ctx, requestID, r)
+		case *McpMessageRequest:
+			responseData, err = q.handleMcpMessage(ctx, r)
+		case nil:
+			err = decodeErr
+		default:
+			err = fmt.Errorf("unsupported control request subtype: %s", subtype)
+		}
+	}, "claude_sdk", "callback", "claude_sdk_callback", subtype)
+	observeSince(q.metrics, MetricCallbackDuration, start, map[string]string{"kind": subtype})
+	if cancelledByCLI(ctx) && ControlSubtype(subtype) != ControlSubtypeMcpMessage {
+		// MCP calls report cancellation in their JSON-RPC response
+		err = errRequestCancelled
+	}
 
-	switch subtype {
-	case "can_use_tool":
-		responseData, err = q.handleCanUseTool(ctx, request)
-	case "hook_callback":
-		responseData, err = q.handleHookCallback(ctx, request)
-	case "mcp_message":
-		responseData, err = q.handleMcpMessage(ctx, request)
-	default:
-		err = fmt.Errorf("unsupported control request subtype: %s", subtype)
+	if ControlSubtype(subtype) == ControlSubtypeCanUseTool && err == nil {
+		q.emitPermissionDecision(requestID, request, responseData)
+		q.audit.permission(request, responseData)
 	}
 
 	// Send response
-	var controlResponse map[string]interface{}
+	controlResponse := ControlResponse{
+		Type: MessageTypeControlResponse,
+		Response: ControlResponseBody{
+			Subtype:   ControlSubtypeSuccess,
+			RequestID: requestID,
+			Response:  responseData,
+		},
+	}
 	if err != nil {
-		controlResponse = map[string]interface{}{
-			"type": "control_response",
-			"response": map[string]interface{}{
-				"subtype":    "error",
-				"request_id": requestID,
-				"error":      err.Error(),
-			},
+		if err != errRequestCancelled {
+			q.errs.warn(NewControlRequestError(requestID, subtype, "control request handler failed", err))
 		}
-	} else {
-		controlResponse = map[string]interface{}{
-			"type": "control_response",
-			"response": map[string]interface{}{
-				"subtype":    "success",
-				"request_id": requestID,
-				"response":   responseData,
-			},
+		controlResponse.Response = ControlResponseBody{
+			Subtype:   ControlSubtypeError,
+			RequestID: requestID,
+			Error:     err.Error(),
 		}
 	}
 
 	data, _ := json.Marshal(controlResponse)
-	q.transport.Write(ctx, string(data)+"\n")
+	if err := q.transport.Write(writeCtx, string(data)+"\n"); err != nil {
+		q.errs.warn(NewControlRequestError(requestID, subtype, "failed to send control response", err))
+	}
+}
+
+// emitPermissionDecision reports a CanUseTool decision to the event sink.
+func (q *queryHandler) emitPermissionDecision(requestID string, request, response map[string]interface{}) {
+	if q.sink == nil {
+		return
+	}
+	decision := PermissionDecisionEvent{RequestID: requestID}
+	decision.ToolName, _ = request["tool_name"].(string)
+	decision.Input, _ = request["input"].(map[string]interface{})
+	decision.Behavior, _ = response["behavior"].(string)
+	decision.Message, _ = response["message"].(string)
+	emitEvent(q.sink, Event{Type: EventTypePermissionDecision, Data: decision})
 }
 
 // handleCanUseTool processes tool permission requests.
-func (q *queryHandler) handleCanUseTool(ctx context.Context, request map[string]interface{}) (map[string]interface{}, error) {
+func (q *queryHandler) handleCanUseTool(ctx context.Context, requestID string, request *CanUseToolRequest) (map[string]interface{}, error) {
 	if q.canUseTool == nil {
 		return nil, fmt.Errorf("canUseTool callback is not provided")
 	}
 
-	toolName, _ := request["tool_name"].(string)
-	originalInput, _ := request["input"].(map[string]interface{})
-	suggestions, _ := request["permission_suggestions"].([]interface{})
-
-	// Convert suggestions
-	permSuggestions := make([]PermissionUpdate, 0)
-	for _, s := range suggestions {
-		if sMap, ok := s.(map[string]interface{}); ok {
-			// TODO: Properly unmarshal PermissionUpdate
-			_ = sMap
-		}
-	}
+	toolName, originalInput := request.ToolName, request.Input
+	// Suggestions the SDK does not understand were skipped when decoding,
+	// so newer CLI suggestion types don't break the permission callback.
+	permSuggestions := append([]PermissionUpdate{}, request.PermissionSuggestions...)
 
 	permCtx := ToolPermissionContext{
 		Suggestions: permSuggestions,
+		RequestID:   requestID,
+		Metadata:    MetadataFromContext(ctx),
 	}
 
-	result, err := q.canUseTool(ctx, toolName, originalInput, permCtx)
+	result, err := callWithTimeout(ctx, q.callbackTimeout.canUseTool, string(ControlSubtypeCanUseTool), func(ctx context.Context) (PermissionResult, error) {
+		return q.canUseTool(ctx, toolName, originalInput, permCtx)
+	})
 	if err != nil {
 		return nil, err
 	}
+	if ask, ok := result.(PermissionResultAsk); ok {
+		if result, err = resolveAsk(ctx, q.approver, toolName, originalInput, permCtx, ask); err != nil {
+			return nil, err
+		}
+	}
 
 	// Convert result to response format matching Python SDK
 	switch r := result.(type) {
@@ -357,22 +683,18 @@ func (q *queryHandler) handleCanUseTool(ctx context.Context, request map[string]
 }
 
 // handleHookCallback processes hook callback requests.
-func (q *queryHandler) handleHookCallback(ctx context.Context, request map[string]interface{}) (map[string]interface{}, error) {
-	callbackID, _ := request["callback_id"].(string)
-	input, _ := request["input"].(map[string]interface{})
-
-	var toolUseID *string
-	if tuid, ok := request["tool_use_id"].(string); ok {
-		toolUseID = &tuid
-	}
+func (q *queryHandler) handleHookCallback(ctx context.Context, requestID string, request *HookCallbackRequest) (map[string]interface{}, error) {
+	callbackID, input, toolUseID := request.CallbackID, request.Input, request.ToolUseID
 
 	callback, exists := q.hookCallbacks[callbackID]
 	if !exists {
 		return nil, fmt.Errorf("no hook callback found for ID: %s", callbackID)
 	}
 
-	hookCtx := HookContext{}
-	result, err := callback(ctx, input, toolUseID, hookCtx)
+	hookCtx := HookContext{RequestID: requestID, Metadata: MetadataFromContext(ctx)}
+	result, err := callWithTimeout(ctx, q.callbackTimeout.hook, string(ControlSubtypeHookCallback), func(ctx context.Context) (HookJSONOutput, error) {
+		return callback(ctx, input, toolUseID, hookCtx)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -387,18 +709,35 @@ func (q *queryHandler) handleHookCallback(ctx context.Context, request map[strin
 	if err := json.Unmarshal(resultJSON, &response); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal hook result: %w", err)
 	}
+	fillHookEventName(response, input)
+	q.audit.hook(input, toolUseID, response)
 
 	return response, nil
 }
 
+// scopeHookToAgent wraps callback so it only runs for calls attributed to agent.
+// Calls from other agents get an empty output, which lets execution continue.
+func (q *queryHandler) scopeHookToAgent(agent string, callback HookCallback) HookCallback {
+	return func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
+		if q.agents.agentFor(input, toolUseID) != agent {
+			return HookJSONOutput{}, nil
+		}
+		return callback(ctx, input, toolUseID, hookCtx)
+	}
+}
+
 // handleMcpMessage handles SDK MCP server requests.
-func (q *queryHandler) handleMcpMessage(ctx context.Context, request map[string]interface{}) (map[string]interface{}, error) {
-	serverName, _ := request["server_name"].(string)
-	message, _ := request["message"].(map[string]interface{})
+func (q *queryHandler) handleMcpMessage(ctx context.Context, request *McpMessageRequest) (map[string]interface{}, error) {
+	serverName := request.ServerName
+	if batch, ok := request.Message.([]interface{}); ok && serverName != "" {
+		return q.handleMcpBatch(ctx, serverName, batch), nil
+	}
+	message, _ := request.Message.(map[string]interface{})
 
 	if serverName == "" || message == nil {
 		return nil, fmt.Errorf("missing server_name or message for MCP request")
 	}
+	ctx = WithMcpNotifier(ctx, q.mcpNotifier(serverName))
 
 	server, exists := q.sdkMcpServers[serverName]
 	if !exists {
@@ -414,19 +753,89 @@ func (q *queryHandler) handleMcpMessage(ctx context.Context, request map[string]
 		}, nil
 	}
 
-	// Route MCP request to server
-	response := q.routeMcpRequest(ctx, server, message)
+	if message["method"] == "notifications/cancelled" {
+		params, _ := message["params"].(map[string]interface{})
+		q.inflight.cancelMcp(serverName, params["requestId"])
+		return map[string]interface{}{
+			"mcp_response": map[string]interface{}{"jsonrpc": "2.0", "result": map[string]interface{}{}},
+		}, nil
+	}
+
+	// Route MCP request to server. A cancelled call is answered right away;
+	// its handler sees its context cancelled and should return promptly.
+	responseCh := make(chan map[string]interface{}, 1)
+	go func() {
+		responseCh <- q.routeMcpRequest(ctx, server, message)
+	}()
+	var response map[string]interface{}
+	select {
+	case response = <-responseCh:
+	case <-ctx.Done():
+		if !cancelledByCLI(ctx) {
+			response = <-responseCh
+		}
+	}
+	if cancelledByCLI(ctx) {
+		response = mcpErrorResponse(message["id"], mcpRequestCancelledCode, "Request cancelled")
+	}
+	filterMcpResult(q.resultFilter, response)
 	return map[string]interface{}{"mcp_response": response}, nil
 }
 
+// handleMcpBatch handles a JSON-RPC batch for an SDK MCP server. The
+// requests run concurrently; their responses keep request order, and
+// notifications get none.
+func (q *queryHandler) handleMcpBatch(ctx context.Context, serverName string, batch []interface{}) map[string]interface{} {
+	server, exists := q.sdkMcpServers[serverName]
+	switch {
+	case !exists:
+		return map[string]interface{}{"mcp_response": mcpErrorResponse(nil, -32601, fmt.Sprintf("Server '%s' not found", serverName))}
+	case len(batch) == 0:
+		return map[string]interface{}{"mcp_response": mcpErrorResponse(nil, -32600, "Invalid Request: empty batch")}
+	}
+	ctx = WithMcpNotifier(ctx, q.mcpNotifier(serverName))
+
+	responses := make([]map[string]interface{}, len(batch))
+	var wg sync.WaitGroup
+	for i, item := range batch {
+		message, ok := item.(map[string]interface{})
+		switch {
+		case !ok:
+			responses[i] = mcpErrorResponse(nil, -32600, "Invalid Request: batch entries must be objects")
+		case message["method"] == "notifications/cancelled":
+			params, _ := message["params"].(map[string]interface{})
+			q.inflight.cancelMcp(serverName, params["requestId"])
+		default:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				response := q.routeMcpRequest(ctx, server, message)
+				if _, isRequest := message["id"]; isRequest {
+					responses[i] = response
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	kept := make([]interface{}, 0, len(responses))
+	for _, response := range responses {
+		if response != nil {
+			filterMcpResult(q.resultFilter, response)
+			kept = append(kept, response)
+		}
+	}
+	if len(kept) == 0 {
+		// Only notifications; acknowledged like a single one
+		return map[string]interface{}{"mcp_response": map[string]interface{}{"jsonrpc": "2.0", "result": map[string]interface{}{}}}
+	}
+	return map[string]interface{}{"mcp_response": kept}
+}
+
 // routeMcpRequest routes JSONRPC requests to MCP server.
 func (q *queryHandler) routeMcpRequest(ctx context.Context, server interface{}, message map[string]interface{}) map[string]interface{} {
 	// Check if it's an SDK MCP server
-	type McpServerHandler interface {
-		HandleRequest(ctx context.Context, message map[string]interface{}) map[string]interface{}
-	}
-
-	if handler, ok := server.(McpServerHandler); ok {
+	if handler, ok := server.(mcpRequestHandler); ok {
 		return handler.HandleRequest(ctx, message)
 	}
 
@@ -444,29 +853,41 @@ func (q *queryHandler) routeMcpRequest(ctx context.Context, server interface{},
 
 // Interrupt sends interrupt control request.
 func (q *queryHandler) Interrupt(ctx context.Context) error {
-	request := map[string]interface{}{"subtype": "interrupt"}
+	request, _ := EncodeControlRequest(InterruptRequest{Subtype: ControlSubtypeInterrupt})
 	_, err := q.sendControlRequest(ctx, request)
 	return err
 }
 
 // SetPermissionMode changes permission mode.
 func (q *queryHandler) SetPermissionMode(ctx context.Context, mode PermissionMode) error {
-	request := map[string]interface{}{
-		"subtype": "set_permission_mode",
-		"mode":    string(mode),
-	}
+	request, _ := EncodeControlRequest(SetPermissionModeRequest{
+		Subtype: ControlSubtypeSetPermissionMode,
+		Mode:    mode,
+	})
 	_, err := q.sendControlRequest(ctx, request)
 	return err
 }
 
 // SetModel changes the AI model.
 func (q *queryHandler) SetModel(ctx context.Context, model string) error {
-	request := map[string]interface{}{
-		"subtype": "set_model",
-		"model":   model,
+	request, _ := EncodeControlRequest(SetModelRequest{
+		Subtype: ControlSubtypeSetModel,
+		Model:   model,
+	})
+	if _, err := q.sendControlRequest(ctx, request); err != nil {
+		return err
 	}
-	_, err := q.sendControlRequest(ctx, request)
-	return err
+	q.mu.Lock()
+	q.model = model
+	q.mu.Unlock()
+	return nil
+}
+
+// currentModel returns the last model set with SetModel, or "" if none was.
+func (q *queryHandler) currentModel() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.model
 }
 
 // StreamInput streams input messages to transport.
@@ -511,6 +932,11 @@ func (q *queryHandler) Close() error {
 	if q.cancelFunc != nil {
 		q.cancelFunc()
 	}
+	for _, server := range q.sdkMcpServers {
+		if executor, ok := server.(*mcpExecutor); ok {
+			executor.Close()
+		}
+	}
 	return q.transport.Close()
 }
 
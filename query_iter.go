@@ -0,0 +1,80 @@
+package claude
+
+import (
+	"context"
+	"iter"
+)
+
+// QueryIter is Query as a sequence for range-over-func: it yields each
+// message with a nil error, then, if the query failed, a nil message with
+// the error. Errors starting the query are yielded the same way, so there is
+// no error channel to drain. The query starts when the range loop does, and
+// breaking out of the loop stops it.
+//
+// Example:
+//
+//	for msg, err := range claude.QueryIter(ctx, "What is 2+2?", nil, nil) {
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    fmt.Println(msg)
+//	}
+func QueryIter(ctx context.Context, prompt string, options *ClaudeAgentOptions, trans Transport) iter.Seq2[Message, error] {
+	return messageSeq(ctx, func(ctx context.Context) (<-chan Message, <-chan error, error) {
+		return Query(ctx, prompt, options, trans)
+	})
+}
+
+// QueryStreamIter is QueryStream as a sequence, like QueryIter.
+func QueryStreamIter(ctx context.Context, prompts <-chan map[string]interface{}, options *ClaudeAgentOptions, trans Transport) iter.Seq2[Message, error] {
+	return messageSeq(ctx, func(ctx context.Context) (<-chan Message, <-chan error, error) {
+		return QueryStream(ctx, prompts, options, trans)
+	})
+}
+
+// QueryIter is Query as a sequence, like the package's QueryIter. Breaking
+// out of the range loop abandons the rest of the response.
+//
+// Example:
+//
+//	for msg, err := range client.QueryIter(ctx, "Explain this repo") {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    ...
+//	}
+func (c *ClaudeSDKClient) QueryIter(ctx context.Context, prompt string) iter.Seq2[Message, error] {
+	return messageSeq(ctx, func(ctx context.Context) (<-chan Message, <-chan error, error) {
+		msgCh, errCh := c.Query(ctx, prompt)
+		return msgCh, errCh, nil
+	})
+}
+
+// messageSeq adapts a query returning message and error channels to a
+// sequence. The query runs with a context canceled when the loop ends, so
+// an early break stops it; the channels are then drained so its goroutines
+// exit.
+func messageSeq(ctx context.Context, start func(ctx context.Context) (<-chan Message, <-chan error, error)) iter.Seq2[Message, error] {
+	return func(yield func(Message, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		msgCh, errCh, err := start(ctx)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for msg := range msgCh {
+			if !yield(msg, nil) {
+				cancel()
+				for range msgCh {
+				}
+				<-errCh
+				return
+			}
+		}
+		if err := <-errCh; err != nil {
+			yield(nil, err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// readOnlyTools are the built-in tools ReadOnly mode allows besides Bash
+// commands in ReadOnlyBashCommands and ReadOnlyMcpTools. Every other tool is
+// denied, including ones added to the CLI later.
+var readOnlyTools = []string{"Read", "Grep", "Glob", "LS", "WebSearch", "WebFetch"}
+
+// readOnlyDisallowedTools are built-in tools that modify files or state, or
+// run other tools. Without the control protocol ReadOnly mode can only
+// disallow tools by name, so it disallows these and every configured MCP
+// server.
+var readOnlyDisallowedTools = []string{
+	"Write", "Edit", "MultiEdit", "NotebookEdit", "Bash", "BashOutput", "KillShell",
+	"Task", "TodoWrite", "SlashCommand", "ExitPlanMode",
+}
+
+// DefaultReadOnlyBashCommands are the Bash commands ReadOnly mode allows when
+// ClaudeAgentOptions.ReadOnlyBashCommands is empty. An entry matches a command
+// whose leading words equal the entry, e.g. "git log" matches "git log -5",
+// unless it uses a flag in readOnlyDeniedFlags.
+var DefaultReadOnlyBashCommands = []string{
+	"ls", "cat", "head", "tail", "wc", "pwd", "stat", "file", "tree", "du", "df", "which",
+	"grep", "rg",
+	"git status", "git diff", "git log", "git show", "git blame",
+}
+
+// readOnlyDeniedFlags are flags that make an allowed command write files or
+// run other programs, by command name. Long flags also match their
+// abbreviations, which git accepts; short flags also match inside a
+// cluster such as "-ao".
+var readOnlyDeniedFlags = map[string][]string{
+	"git":  {"--output", "--ext-diff", "--textconv"},
+	"rg":   {"--pre"},
+	"tree": {"-o"},
+	"file": {"-C", "--compile"},
+}
+
+// readOnlyShellOperators make a command unsafe to classify by its first words:
+// they chain, substitute, or redirect to other commands or files.
+var readOnlyShellOperators = []string{";", "&", "|", ">", "<", "`", "$(", "\n"}
+
+// applyReadOnly returns a copy of options that enforces ReadOnly mode.
+//
+// In streaming mode a PreToolUse hook denies every tool but the allowed
+// ones, so permissive CLI settings (e.g. bypassPermissions) cannot skip the
+// check, and a user CanUseTool callback is wrapped to apply it before the
+// callback runs. Without a user callback the CLI's own permission handling
+// decides the allowed tools. Without the control protocol,
+// readOnlyDisallowedTools and the configured MCP servers are disallowed
+// outright.
+func applyReadOnly(options *ClaudeAgentOptions, isStreaming bool) *ClaudeAgentOptions {
+	newOpts := *options
+	allowed := readOnlyAllowlist{commands: options.ReadOnlyBashCommands, tools: make(map[string]bool)}
+	if len(allowed.commands) == 0 {
+		allowed.commands = DefaultReadOnlyBashCommands
+	}
+	for _, tool := range readOnlyTools {
+		allowed.tools[tool] = true
+	}
+	for _, tool := range options.ReadOnlyMcpTools {
+		allowed.tools[tool] = true
+	}
+
+	if !isStreaming {
+		newOpts.DisallowedTools = append(append([]string{}, options.DisallowedTools...), readOnlyDisallowedTools...)
+		for name := range options.McpServers {
+			newOpts.DisallowedTools = append(newOpts.DisallowedTools, "mcp__"+name)
+		}
+		return &newOpts
+	}
+
+	if next := options.CanUseTool; next != nil {
+		newOpts.CanUseTool = func(ctx context.Context, toolName string, input map[string]interface{}, permCtx ToolPermissionContext) (PermissionResult, error) {
+			if reason := allowed.violation(toolName, input); reason != "" {
+				return PermissionResultDeny{Behavior: "deny", Message: reason}, nil
+			}
+			return next(ctx, toolName, input, permCtx)
+		}
+	}
+
+	guard := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
+		toolName, _ := input["tool_name"].(string)
+		toolInput, _ := input["tool_input"].(map[string]interface{})
+		reason := allowed.violation(toolName, toolInput)
+		if reason == "" {
+			return HookJSONOutput{}, nil
+		}
+		return DenyToolUse(reason), nil
+	}
+
+	newOpts.Hooks = make(map[HookEvent][]HookMatcher, len(options.Hooks)+1)
+	for event, matchers := range options.Hooks {
+		newOpts.Hooks[event] = matchers
+	}
+	newOpts.Hooks[HookEventPreToolUse] = append(
+		[]HookMatcher{{Hooks: []HookCallback{guard}}},
+		options.Hooks[HookEventPreToolUse]...,
+	)
+
+	return &newOpts
+}
+
+// readOnlyAllowlist is what ReadOnly mode allows.
+type readOnlyAllowlist struct {
+	tools    map[string]bool // readOnlyTools and ReadOnlyMcpTools
+	commands []string        // Bash command prefixes
+}
+
+// violation returns why toolName with input is not allowed in ReadOnly
+// mode, or "" if it is allowed.
+func (a readOnlyAllowlist) violation(toolName string, input map[string]interface{}) string {
+	if a.tools[toolName] {
+		return ""
+	}
+	if toolName != "Bash" {
+		return fmt.Sprintf("%s is not allowed in read-only mode", toolName)
+	}
+
+	command, _ := input["command"].(string)
+	if isReadOnlyCommand(command, a.commands) {
+		return ""
+	}
+	return fmt.Sprintf("Bash command %q is not allowed in read-only mode", command)
+}
+
+// isReadOnlyCommand reports whether command is a single whitelisted command.
+func isReadOnlyCommand(command string, allowedCommands []string) bool {
+	for _, op := range readOnlyShellOperators {
+		if strings.Contains(command, op) {
+			return false
+		}
+	}
+
+	words := strings.Fields(command)
+	if len(words) == 0 || hasDeniedFlag(words) {
+		return false
+	}
+	for _, allowed := range allowedCommands {
+		prefix := strings.Fields(allowed)
+		if len(prefix) == 0 || len(prefix) > len(words) {
+			continue
+		}
+		matched := true
+		for i := range prefix {
+			if words[i] != prefix[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDeniedFlag reports whether the arguments of command words use one of
+// readOnlyDeniedFlags. Quotes and backslashes are removed first, as the
+// shell would.
+func hasDeniedFlag(words []string) bool {
+	denied := readOnlyDeniedFlags[words[0]]
+	for _, word := range words[1:] {
+		arg := strings.NewReplacer(`'`, "", `"`, "", `\`, "").Replace(word)
+		if arg == "--" {
+			return false // Operands follow
+		}
+		for _, flag := range denied {
+			if strings.HasPrefix(flag, "--") {
+				name, _, _ := strings.Cut(arg, "=")
+				if len(name) > 2 && strings.HasPrefix(flag, name) {
+					return true
+				}
+			} else if !strings.HasPrefix(arg, "--") && strings.HasPrefix(arg, "-") && strings.Contains(arg[1:], flag[1:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// errRequestCancelled is the cancellation cause of control requests and MCP
+// calls the CLI cancelled, e.g. because the user pressed escape.
+var errRequestCancelled = errors.New("request cancelled by Claude Code")
+
+// mcpRequestCancelledCode is the JSON-RPC error code of a cancelled MCP call.
+const mcpRequestCancelledCode = -32800
+
+// inflightRequests tracks the contexts of control requests being handled so
+// the CLI can cancel them, either with control_cancel_request or, for SDK MCP
+// calls, with an MCP notifications/cancelled message.
+type inflightRequests struct {
+	mu      sync.Mutex
+	control map[string]context.CancelCauseFunc // By control request_id
+	mcp     map[string]context.CancelCauseFunc // By mcpCallKey
+}
+
+// track returns a context for handling control request msg that the CLI
+// can cancel, and a function to call when the request is done. It must be
+// called as msg is routed, so a cancellation that follows msg finds it.
+func (r *inflightRequests) track(ctx context.Context, msg map[string]interface{}) (context.Context, func()) {
+	ctx, cancelFunc := context.WithCancelCause(ctx)
+	requestID, _ := msg["request_id"].(string)
+	request, _ := msg["request"].(map[string]interface{})
+	var mcpKey string
+	if request["subtype"] == string(ControlSubtypeMcpMessage) {
+		server, _ := request["server_name"].(string)
+		message, _ := request["message"].(map[string]interface{})
+		if id, ok := message["id"]; ok && id != nil {
+			mcpKey = mcpCallKey(server, id)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.control == nil {
+		r.control = make(map[string]context.CancelCauseFunc)
+		r.mcp = make(map[string]context.CancelCauseFunc)
+	}
+	r.control[requestID] = cancelFunc
+	if mcpKey != "" {
+		r.mcp[mcpKey] = cancelFunc
+	}
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.control, requestID)
+		if mcpKey != "" {
+			delete(r.mcp, mcpKey)
+		}
+		r.mu.Unlock()
+		cancelFunc(nil)
+	}
+}
+
+// cancel cancels control request requestID, if it is in flight.
+func (r *inflightRequests) cancel(requestID string) {
+	r.mu.Lock()
+	cancelFunc := r.control[requestID]
+	r.mu.Unlock()
+	if cancelFunc != nil {
+		cancelFunc(errRequestCancelled)
+	}
+}
+
+// cancelMcp cancels the MCP call with JSON-RPC id to server, if it is in
+// flight.
+func (r *inflightRequests) cancelMcp(server string, id interface{}) {
+	r.mu.Lock()
+	cancelFunc := r.mcp[mcpCallKey(server, id)]
+	r.mu.Unlock()
+	if cancelFunc != nil {
+		cancelFunc(errRequestCancelled)
+	}
+}
+
+// mcpCallKey identifies an MCP call. JSON-RPC ids are strings or numbers;
+// numbers arrive as float64, so both forms format consistently.
+func mcpCallKey(server string, id interface{}) string {
+	return fmt.Sprintf("%s\x00%v", server, id)
+}
+
+// cancelledByCLI reports whether ctx was cancelled by the CLI.
+func cancelledByCLI(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errRequestCancelled)
+}
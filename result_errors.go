@@ -0,0 +1,18 @@
+package claude
+
+import "strings"
+
+// isErrorResult reports whether result reports a failed query.
+func isErrorResult(result *ResultMessage) bool {
+	return result.IsError || strings.HasPrefix(result.Subtype, "error")
+}
+
+// errorFromResult returns a *ResultError for an error ResultMessage if
+// options.ErrorsFromResults is set, and nil otherwise.
+func errorFromResult(options *ClaudeAgentOptions, msg Message) error {
+	result, ok := msg.(*ResultMessage)
+	if !ok || options == nil || !options.ErrorsFromResults || !isErrorResult(result) {
+		return nil
+	}
+	return NewResultError(result)
+}
@@ -0,0 +1,234 @@
+// Package schema generates JSON Schemas (draft 2020-12) from Go types.
+//
+// Schemas describe the JSON encoding of a type as produced by encoding/json:
+// property names come from json tags, fields tagged "-" and function or
+// channel fields are skipped, and a field is required unless it is tagged
+// omitempty or omitzero. Named struct types are emitted once under "$defs"
+// and referenced, so recursive types are supported.
+//
+// The cmd/schemagen devtool uses this package to emit schemas for the SDK's
+// message, option, and hook types.
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Draft is the JSON Schema dialect of generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// Generator builds schemas. The zero value is ready to use.
+type Generator struct {
+	// Enums lists the allowed values of named types, e.g. the constants of a
+	// string type, which reflection cannot discover.
+	Enums map[reflect.Type][]interface{}
+
+	// Implementations lists the concrete types an interface type may hold.
+	// Such interfaces become a oneOf of the implementations; other interfaces
+	// accept any value.
+	Implementations map[reflect.Type][]reflect.Type
+}
+
+// Of returns the schema of the type of v using a zero Generator.
+func Of(v interface{}) map[string]interface{} {
+	var g Generator
+	return g.Schema(reflect.TypeOf(v))
+}
+
+// Schema returns the schema of t. Named struct types other than t itself
+// are placed under "$defs".
+func (g *Generator) Schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	b := &builder{gen: g, root: t, defs: make(map[string]interface{}), names: make(map[reflect.Type]string)}
+	var root map[string]interface{}
+	if t.Kind() == reflect.Struct && t.Name() != "" {
+		root = b.structSchema(t)
+		root["title"] = t.Name()
+	} else {
+		root = b.typeSchema(t)
+	}
+
+	root["$schema"] = Draft
+	if len(b.defs) > 0 {
+		root["$defs"] = b.defs
+	}
+	return root
+}
+
+// builder holds the state of one Schema call.
+type builder struct {
+	gen   *Generator
+	root  reflect.Type
+	defs  map[string]interface{}
+	names map[reflect.Type]string // Named structs already assigned a $defs entry
+}
+
+func (b *builder) typeSchema(t reflect.Type) map[string]interface{} {
+	if values, ok := b.gen.Enums[t]; ok {
+		schema := b.kindSchema(t)
+		schema["enum"] = values
+		return schema
+	}
+
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]interface{}{"type": "integer", "description": "nanoseconds"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(b.typeSchema(t.Elem()))
+	case reflect.Interface:
+		impls := b.gen.Implementations[t]
+		if len(impls) == 0 {
+			return map[string]interface{}{}
+		}
+		oneOf := make([]interface{}, len(impls))
+		for i, impl := range impls {
+			oneOf[i] = b.typeSchema(impl)
+		}
+		return map[string]interface{}{"oneOf": oneOf}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		if t == b.root {
+			return map[string]interface{}{"$ref": "#"}
+		}
+		return b.ref(t)
+	}
+	return b.kindSchema(t)
+}
+
+// kindSchema returns the schema for t's underlying kind.
+func (b *builder) kindSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": b.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.typeSchema(t.Elem())}
+	}
+	return map[string]interface{}{}
+}
+
+// ref returns a reference to the $defs entry of a named struct, adding it
+// on first use.
+func (b *builder) ref(t reflect.Type) map[string]interface{} {
+	name, ok := b.names[t]
+	if !ok {
+		name = b.defName(t)
+		b.names[t] = name
+		b.defs[name] = map[string]interface{}{} // Placeholder for recursive types
+		b.defs[name] = b.structSchema(t)
+	}
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
+
+// defName returns a unique $defs name for t, qualifying it with its package
+// when another type already uses the plain name.
+func (b *builder) defName(t reflect.Type) string {
+	name := t.Name()
+	if _, taken := b.defs[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	return pkg + "." + name
+}
+
+func (b *builder) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	b.addFields(t, properties, &required)
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds the properties of t's fields, flattening untagged embedded
+// structs as encoding/json does.
+func (b *builder) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			embedded := fieldType
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		switch fieldType.Kind() {
+		case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.typeSchema(fieldType)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// nullable allows null in addition to schema.
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+		return schema
+	}
+	return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+}
@@ -0,0 +1,97 @@
+package claude
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SessionBudget is what remains of a session's time and money when a
+// callback runs. Long-running SDK MCP tool handlers can read it with
+// SessionBudgetFromContext to adapt, e.g. skip expensive work or return
+// partial results, when the session is nearly out of either.
+type SessionBudget struct {
+	// Deadline is the earliest deadline of the context the session was
+	// started with (Connect's, or Query's) and the current query's; zero
+	// if neither has one.
+	Deadline time.Time
+
+	// RemainingUSD is what can still be spent before the tightest of
+	// MaxBudgetUSD, BudgetStrategy.HardCapUSD, and BudgetLimits; nil if
+	// none is set. Cost is only reported at the end of each turn, so it
+	// does not include the turn in progress.
+	RemainingUSD *float64
+}
+
+// TimeRemaining returns the time left before Deadline, or false if there is
+// no deadline.
+func (b SessionBudget) TimeRemaining() (time.Duration, bool) {
+	if b.Deadline.IsZero() {
+		return 0, false
+	}
+	return time.Until(b.Deadline), true
+}
+
+type sessionBudgetKey struct{}
+
+// SessionBudgetFromContext returns the session budget passed to SDK MCP tool
+// handlers, hooks, and CanUseTool, or false outside of them.
+//
+// Example:
+//
+//	func search(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+//	    budget, _ := claude.SessionBudgetFromContext(ctx)
+//	    if left, ok := budget.TimeRemaining(); ok && left < time.Minute {
+//	        return quickSearch(ctx, args)
+//	    }
+//	    if budget.RemainingUSD != nil && *budget.RemainingUSD < 0.10 {
+//	        return quickSearch(ctx, args)
+//	    }
+//	    return thoroughSearch(ctx, args)
+//	}
+func SessionBudgetFromContext(ctx context.Context) (SessionBudget, bool) {
+	b, ok := ctx.Value(sessionBudgetKey{}).(SessionBudget)
+	return b, ok
+}
+
+// turnBudget holds the deadlines and budget guard of a session for control
+// requests, which are handled on the connection's context.
+type turnBudget struct {
+	mu              sync.Mutex
+	guard           *budgetGuard
+	sessionDeadline time.Time
+	queryDeadline   time.Time
+}
+
+// setSession records the session's budget guard and the deadline of the
+// context it was started with.
+func (t *turnBudget) setSession(ctx context.Context, guard *budgetGuard) {
+	deadline, _ := ctx.Deadline()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.guard = guard
+	t.sessionDeadline = deadline
+}
+
+// setQuery records the deadline of the current query's context.
+func (t *turnBudget) setQuery(ctx context.Context) {
+	deadline, _ := ctx.Deadline()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queryDeadline = deadline
+}
+
+// attach returns ctx carrying the current SessionBudget.
+func (t *turnBudget) attach(ctx context.Context) context.Context {
+	t.mu.Lock()
+	guard := t.guard
+	deadline := t.sessionDeadline
+	if deadline.IsZero() || (!t.queryDeadline.IsZero() && t.queryDeadline.Before(deadline)) {
+		deadline = t.queryDeadline
+	}
+	t.mu.Unlock()
+	return context.WithValue(ctx, sessionBudgetKey{}, SessionBudget{
+		Deadline:     deadline,
+		RemainingUSD: guard.remainingUSD(),
+	})
+}
@@ -0,0 +1,185 @@
+package claude
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StoredSession is a session the CLI saved to disk, which --resume and
+// --continue read back.
+type StoredSession struct {
+	ID      string
+	Project string    // Directory the CLI keeps the project's sessions in, named after its working directory
+	Path    string    // The session's .jsonl transcript
+	ModTime time.Time // Last write, i.e. when the session was last used
+	Size    int64     // Bytes on disk, including the session's other artifacts
+}
+
+// claudeConfigDir returns where the CLI keeps its state: $CLAUDE_CONFIG_DIR,
+// or ~/.claude.
+func claudeConfigDir() (string, error) {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".claude"), nil
+}
+
+// ListSessions returns the sessions the CLI has saved for every project,
+// oldest first. The CLI's state is read from $CLAUDE_CONFIG_DIR if set, as
+// the CLI does, otherwise from ~/.claude.
+func ListSessions() ([]StoredSession, error) {
+	configDir, err := claudeConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	projects, err := os.ReadDir(filepath.Join(configDir, "projects"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []StoredSession
+	for _, project := range projects {
+		if !project.IsDir() {
+			continue
+		}
+		projectDir := filepath.Join(configDir, "projects", project.Name())
+		entries, err := os.ReadDir(projectDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			id, ok := strings.CutSuffix(entry.Name(), ".jsonl")
+			if !ok || entry.IsDir() || !validSessionID(id) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue // Removed since ReadDir
+			}
+			session := StoredSession{
+				ID:      id,
+				Project: project.Name(),
+				Path:    filepath.Join(projectDir, entry.Name()),
+				ModTime: info.ModTime(),
+			}
+			for _, path := range sessionArtifacts(configDir, session) {
+				session.Size += diskUsage(path)
+			}
+			sessions = append(sessions, session)
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].ModTime.Before(sessions[j].ModTime)
+	})
+	return sessions, nil
+}
+
+// CleanupSessions deletes the sessions the CLI saved that have not been used
+// for olderThan, with their other artifacts (subagent transcripts, todo
+// lists, file history), and returns what it deleted. Project directories
+// left empty are removed too. Sessions that fail to delete are reported in
+// the error and not returned; the rest are still deleted.
+//
+// Deleted sessions can no longer be resumed. Sessions in use are written to
+// at every turn, so a retention period longer than any turn leaves them
+// alone; olderThan must be positive. This is meant for long-lived hosts,
+// e.g. run from a ticker:
+//
+//	removed, err := claude.CleanupSessions(30 * 24 * time.Hour)
+func CleanupSessions(olderThan time.Duration) ([]StoredSession, error) {
+	if olderThan <= 0 {
+		return nil, fmt.Errorf("session retention must be positive, got %v", olderThan)
+	}
+	configDir, err := claudeConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := ListSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	var removed []StoredSession
+	var errs []error
+	projects := make(map[string]bool)
+	for _, session := range sessions {
+		if !session.ModTime.Before(cutoff) {
+			break // Oldest first
+		}
+		failed := false
+		for _, path := range sessionArtifacts(configDir, session) {
+			if err := os.RemoveAll(path); err != nil {
+				errs = append(errs, err)
+				failed = true
+			}
+		}
+		if !failed {
+			removed = append(removed, session)
+			projects[filepath.Dir(session.Path)] = true
+		}
+	}
+	for dir := range projects {
+		os.Remove(dir) // Fails unless empty
+	}
+	return removed, errors.Join(errs...)
+}
+
+// sessionArtifacts returns the paths the CLI may have written for session:
+// its transcript, then any of the directories and files kept by session ID.
+// An invalid ID has none, so no path can point outside the session's own.
+func sessionArtifacts(configDir string, session StoredSession) []string {
+	if !validSessionID(session.ID) {
+		return nil
+	}
+	paths := []string{
+		session.Path,
+		strings.TrimSuffix(session.Path, ".jsonl"), // Subagent transcripts
+		filepath.Join(configDir, "file-history", session.ID),
+		filepath.Join(configDir, "session-env", session.ID),
+	}
+	todos, _ := filepath.Glob(filepath.Join(configDir, "todos", session.ID+"-*.json"))
+	return append(paths, todos...)
+}
+
+// validSessionID reports whether id can name a session's files: the CLI's
+// session IDs are UUIDs, so only letters, digits, '-', and '_' are allowed.
+func validSessionID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// diskUsage returns the size of the file or directory tree at path, or 0
+// if it does not exist.
+func diskUsage(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && !d.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,161 @@
+package claude
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// sessionStateVersion is the format version of ExportState blobs.
+const sessionStateVersion = 1
+
+// sessionState is the content of an ExportState blob. The JSON encoding is
+// an implementation detail; callers store the blob as opaque bytes.
+type sessionState struct {
+	Version      int    `json:"v"`
+	SessionID    string `json:"session_id"`
+	Fingerprint  string `json:"options_fingerprint"`
+	Cursor       uint64 `json:"cursor,omitempty"`       // Last history sequence number
+	Conversation string `json:"conversation,omitempty"` // QueryWithSession session ID
+}
+
+// ExportState returns an opaque blob with everything another process needs
+// to continue this conversation: the CLI session ID, a fingerprint of the
+// options that must match when resuming, and the history cursor, so
+// PollMessages cursors handed to clients stay valid. Pass it to
+// ResumeFromState, e.g. from the next worker in a job queue.
+//
+// ExportState fails until the CLI has reported a session ID, i.e. before the
+// first message of the first query. Callbacks, hooks, and other options that
+// cannot be serialized are not included; the resuming process supplies them.
+func (c *ClaudeSDKClient) ExportState() ([]byte, error) {
+	sessionID := c.SessionID()
+	if sessionID == "" {
+		return nil, NewSessionStateError("no session to export; the CLI has not reported a session ID yet")
+	}
+	c.mu.Lock()
+	conversation := c.conversationID
+	c.mu.Unlock()
+	state := sessionState{
+		Version:      sessionStateVersion,
+		SessionID:    sessionID,
+		Fingerprint:  optionsFingerprint(c.connection().options),
+		Cursor:       c.history.cursor(),
+		Conversation: conversation,
+	}
+	return json.Marshal(state)
+}
+
+// ResumeFromState creates a client that resumes the conversation exported
+// by ExportState. options must configure the conversation as the exporting
+// client did (same working directory, model, system prompt, tools, MCP
+// servers, and agents), otherwise a *SessionStateError is returned; the CLI
+// stores sessions per working directory, and a different setup would
+// silently change the conversation. Resume, ContinueConversation, and
+// ForkSession are set from the state. Call Connect as usual.
+func ResumeFromState(state []byte, options *ClaudeAgentOptions) (*ClaudeSDKClient, error) {
+	return ResumeFromStateWithTransport(state, options, nil)
+}
+
+// ResumeFromStateWithTransport is ResumeFromState with a custom transport,
+// as for NewClaudeSDKClientWithTransport.
+func ResumeFromStateWithTransport(state []byte, options *ClaudeAgentOptions, trans Transport) (*ClaudeSDKClient, error) {
+	var s sessionState
+	if err := json.Unmarshal(state, &s); err != nil {
+		return nil, NewSessionStateError(fmt.Sprintf("invalid session state: %v", err))
+	}
+	if s.Version != sessionStateVersion {
+		return nil, NewSessionStateError(fmt.Sprintf("unsupported session state version %d", s.Version))
+	}
+	if s.SessionID == "" {
+		return nil, NewSessionStateError("session state has no session ID")
+	}
+	if options == nil {
+		options = &ClaudeAgentOptions{}
+	}
+	if fingerprint := optionsFingerprint(options); fingerprint != s.Fingerprint {
+		return nil, NewSessionStateError(fmt.Sprintf(
+			"options do not match the exported session %s; resume it with the same working directory, model, system prompt, tools, MCP servers, and agents",
+			s.SessionID))
+	}
+
+	resumed := *options
+	resumed.Resume = &s.SessionID
+	resumed.ContinueConversation = false
+	resumed.ForkSession = false
+
+	var c *ClaudeSDKClient
+	if trans != nil {
+		c = NewClaudeSDKClientWithTransport(&resumed, trans)
+	} else {
+		c = NewClaudeSDKClient(&resumed)
+	}
+	c.sessionID = s.SessionID
+	c.conversationID = s.Conversation
+	if resumed.HistorySize != nil {
+		c.history = newMessageHistory(*resumed.HistorySize)
+		c.history.resumeAt(s.Cursor)
+	}
+	return c, nil
+}
+
+// optionsFingerprint hashes the options that define a conversation, so a
+// resumed session can be checked against the one that was exported.
+func optionsFingerprint(options *ClaudeAgentOptions) string {
+	if options == nil {
+		options = &ClaudeAgentOptions{}
+	}
+	mcpServers := make([]string, 0, len(options.McpServers))
+	for name := range options.McpServers {
+		mcpServers = append(mcpServers, name)
+	}
+	sort.Strings(mcpServers)
+	agents := make([]string, 0, len(options.Agents))
+	for name := range options.Agents {
+		agents = append(agents, name)
+	}
+	sort.Strings(agents)
+
+	data, _ := json.Marshal(struct {
+		Cwd             *string     `json:"cwd"`
+		Model           *string     `json:"model"`
+		SystemPrompt    interface{} `json:"system_prompt"`
+		AllowedTools    []string    `json:"allowed_tools"`
+		DisallowedTools []string    `json:"disallowed_tools"`
+		McpServers      []string    `json:"mcp_servers"`
+		Agents          []string    `json:"agents"`
+	}{
+		Cwd:             options.Cwd,
+		Model:           options.Model,
+		SystemPrompt:    options.SystemPrompt,
+		AllowedTools:    options.AllowedTools,
+		DisallowedTools: options.DisallowedTools,
+		McpServers:      mcpServers,
+		Agents:          agents,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cursor returns the sequence number of the newest message, or 0.
+func (h *messageHistory) cursor() uint64 {
+	if h == nil {
+		return 0
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.nextSeq - 1
+}
+
+// resumeAt continues numbering after cursor, for a history restored from
+// an exported state.
+func (h *messageHistory) resumeAt(cursor uint64) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nextSeq = cursor + 1
+}
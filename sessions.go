@@ -0,0 +1,572 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Session is one goroutine's handle for querying a ClaudeSDKClient shared by
+// several goroutines. Each goroutine uses its own Session, from Session or
+// NewSession; the client sends every session's queries over the same CLI
+// connection and routes the responses back by the session_id of incoming
+// messages.
+//
+// Sessions take turns on one conversation: the CLI process keeps a single
+// context, so each session's queries see what earlier queries of every
+// session said. Use a client per conversation that must stay separate.
+//
+// Sessions are safe for concurrent use. Queries on one Session run one at a
+// time: Query waits until the previous query's response has been consumed
+// or its context is done. Queries on different Sessions run concurrently.
+//
+// Example:
+//
+//	var wg sync.WaitGroup
+//	for _, user := range users {
+//	    wg.Add(1)
+//	    go func(user string) {
+//	        defer wg.Done()
+//	        session := client.Session(user)
+//	        msgCh, errCh := session.Query(ctx, "Summarize my open tickets")
+//	        for msg := range msgCh {
+//	            ...
+//	        }
+//	        if err := <-errCh; err != nil { ... }
+//	    }(user)
+//	}
+//	wg.Wait()
+type Session struct {
+	client *ClaudeSDKClient
+	id     string
+	turn   chan struct{}   // Holds a token while a query is in flight
+	done   <-chan struct{} // Closed when the session is closed
+	cancel context.CancelFunc
+	once   sync.Once // Releases the session once closed
+	shared bool      // Opened by Session, which returns it for its ID
+	err    error     // Why Session could not open it; see failedSession
+
+	mu            sync.Mutex
+	router        *sessionRouter
+	current       *sessionTurn // The query in flight, if any
+	sent          bool         // current was sent by Send and awaits ReceiveResponse
+	correlationID string       // Of the context current was sent with
+}
+
+// Session returns the open session with the given ID, opening it if there
+// is none. Sessions are cheap; calling Session twice with the same ID
+// returns handles that share a turn order. Like sessions from NewSession,
+// the session stays open until Close is called, so callers that use many
+// IDs should close them when done.
+//
+// Session never shares a session opened by NewSession: if id belongs to
+// one, or the client is not connected, the returned session is closed and
+// its queries fail with the reason.
+func (c *ClaudeSDKClient) Session(id string) *Session {
+	s, err := c.openSession(context.Background(), id, true)
+	if err != nil {
+		return failedSession(c, id, err)
+	}
+	return s
+}
+
+// NewSession opens a session on the connected client and returns its
+// handle. Unlike Session, it fails if a session with the same ID is already
+// open, so two callers never share a handle and its turn order by accident;
+// an empty id picks a unique one. Its queries still join the client's one
+// conversation, see Session. The session is closed when ctx is done or
+// Close is called.
+//
+// Example - one session per request:
+//
+//	session, err := client.NewSession(r.Context(), "")
+//	if err != nil { ... }
+//	defer session.Close()
+//	if err := session.Send(ctx, "Triage this bug report: ..."); err != nil { ... }
+//	msgCh, errCh := session.ReceiveResponse(ctx)
+func (c *ClaudeSDKClient) NewSession(ctx context.Context, id string) (*Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if id == "" {
+		id = "session_" + randomHex(8)
+	}
+	return c.openSession(ctx, id, false)
+}
+
+// openSession opens session id, closed when ctx is done. A shared session
+// is returned again for its ID; any other existing session is an error.
+func (c *ClaudeSDKClient) openSession(ctx context.Context, id string, shared bool) (*Session, error) {
+	conn, err := c.connected()
+	if err != nil {
+		return nil, err
+	}
+	if conn.oneShot {
+		return nil, NewStreamingRequiredError("session", SessionModeOneShot)
+	}
+	if _, err := c.sessionRouter(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sessions == nil {
+		c.sessions = make(map[string]*Session)
+	}
+	if existing, ok := c.sessions[id]; ok {
+		if shared && existing.shared {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("session %q already exists", id)
+	}
+	s := newSession(ctx, c, id)
+	s.shared = shared
+	c.sessions[id] = s
+	return s, nil
+}
+
+// failedSession returns a closed session whose queries fail with err.
+func failedSession(c *ClaudeSDKClient, id string, err error) *Session {
+	done := make(chan struct{})
+	close(done)
+	s := &Session{client: c, id: id, turn: make(chan struct{}, 1), done: done, cancel: func() {}, err: err}
+	s.once.Do(func() {}) // Never registered with the client
+	return s
+}
+
+// newSession returns a session that is closed when ctx is done.
+func newSession(ctx context.Context, c *ClaudeSDKClient, id string) *Session {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Session{client: c, id: id, turn: make(chan struct{}, 1), done: ctx.Done(), cancel: cancel}
+	context.AfterFunc(ctx, s.closed)
+	return s
+}
+
+// ID returns the session ID sent with this session's queries.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Query sends prompt in this session and returns its response: messages up
+// to and including the ResultMessage, and an error channel that receives at
+// most one error.
+//
+// Messages are routed by their session_id. Messages with no session_id, or
+// one no active query was sent with (the CLI may assign its own), go to the
+// query that has waited longest, as the CLI answers queries in order.
+// Consume the channel promptly: a stalled consumer holds up routing for every
+// session. Canceling ctx abandons the query; its remaining messages are
+// dropped.
+func (s *Session) Query(ctx context.Context, prompt string) (<-chan Message, <-chan error) {
+	router, t, err := s.send(ctx, prompt, false)
+	if err != nil {
+		return failedQuery(ctx, err)
+	}
+	return s.receive(ctx, router, t, CorrelationIDFromContext(ctx))
+}
+
+// Send sends prompt in this session without reading the response; read it
+// with ReceiveResponse. Like Query, Send waits until the session's previous
+// query has been answered.
+func (s *Session) Send(ctx context.Context, prompt string) error {
+	_, _, err := s.send(ctx, prompt, true)
+	return err
+}
+
+// ReceiveResponse returns the response to the query sent with Send, as
+// Query does. It fails if no query is waiting to be received.
+func (s *Session) ReceiveResponse(ctx context.Context) (<-chan Message, <-chan error) {
+	s.mu.Lock()
+	router, t, sent, correlationID := s.router, s.current, s.sent, s.correlationID
+	s.sent = false
+	s.mu.Unlock()
+	if !sent {
+		return failedQuery(ctx, fmt.Errorf("session %q has no query to receive; call Send first", s.id))
+	}
+	if correlationID == "" {
+		correlationID = CorrelationIDFromContext(ctx)
+	}
+	return s.receive(ctx, router, t, correlationID)
+}
+
+// Interrupt stops this session's query if the CLI is running it, and does
+// nothing if the session has no query in flight. The CLI answers one query
+// at a time, so a query still waiting behind another session's cannot be
+// interrupted; Interrupt returns an error for it instead of stopping the
+// other session's work.
+func (s *Session) Interrupt(ctx context.Context) error {
+	s.mu.Lock()
+	router, t := s.router, s.current
+	s.mu.Unlock()
+	if t == nil {
+		return nil
+	}
+	if !router.running(t) {
+		return fmt.Errorf("session %q is waiting for another session's query to finish and cannot be interrupted yet", s.id)
+	}
+	return s.client.Interrupt(ctx)
+}
+
+// Close closes the session: its query in flight is abandoned, later calls
+// fail with ErrSessionClosed, and its ID can be used again. The client stays
+// connected.
+func (s *Session) Close() error {
+	s.cancel()
+	s.closed()
+	return nil
+}
+
+// closed releases the session once it is closed.
+func (s *Session) closed() {
+	s.once.Do(s.release)
+}
+
+// release removes the session from its client and ends a query left for
+// ReceiveResponse.
+func (s *Session) release() {
+	c := s.client
+	c.mu.Lock()
+	if c.sessions[s.id] == s {
+		delete(c.sessions, s.id)
+	}
+	c.mu.Unlock()
+
+	// A query sent with Send but never received holds the turn and routing
+	s.mu.Lock()
+	router, t, sent := s.router, s.current, s.sent
+	if sent {
+		s.current, s.sent = nil, false
+	}
+	s.mu.Unlock()
+	if sent {
+		router.end(t)
+		<-s.turn
+	}
+}
+
+// closedErr returns why the session cannot be used once closed.
+func (s *Session) closedErr() error {
+	if s.err != nil {
+		return s.err
+	}
+	return ErrSessionClosed
+}
+
+// send waits for the session's turn and sends prompt, returning the query
+// in flight. The turn is held until the query's response has been received;
+// deferred marks the response as left for ReceiveResponse.
+func (s *Session) send(ctx context.Context, prompt string, deferred bool) (*sessionRouter, *sessionTurn, error) {
+	select {
+	case <-s.done:
+		return nil, nil, s.closedErr()
+	default:
+	}
+	select {
+	case s.turn <- struct{}{}:
+	case <-s.done:
+		return nil, nil, s.closedErr()
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	c := s.client
+	router, err := c.sessionRouter()
+	if err != nil {
+		<-s.turn
+		return nil, nil, err
+	}
+	t, err := router.begin(s.id)
+	if err != nil {
+		<-s.turn
+		return nil, nil, err
+	}
+	if err := c.QueryWithSession(ctx, prompt, s.id); err != nil {
+		router.discard(t)
+		<-s.turn
+		return nil, nil, err
+	}
+	s.mu.Lock()
+	s.router, s.current, s.sent = router, t, deferred
+	s.correlationID = CorrelationIDFromContext(ctx)
+	s.mu.Unlock()
+	return router, t, nil
+}
+
+// receive streams the response to t, then releases the session's turn.
+func (s *Session) receive(ctx context.Context, router *sessionRouter, t *sessionTurn, correlationID string) (<-chan Message, <-chan error) {
+	c := s.client
+	options := c.connection().options
+	msgCh := make(chan Message, outputBufferSize(options))
+	errCh := make(chan error, 1)
+	auth := newAuthTracker(options)
+	go func() {
+		defer close(errCh)
+		defer close(msgCh)
+		defer func() { <-s.turn }()
+		defer func() {
+			s.mu.Lock()
+			if s.current == t {
+				s.current = nil
+			}
+			s.mu.Unlock()
+		}()
+		defer router.end(t)
+
+		canceled := func() {
+			// Ending t leaves it queued so the router drops the rest of its
+			// reply, and interrupts it once it runs unless CancelDrain is set
+			errCh <- correlateError(ctx.Err(), correlationID)
+		}
+
+		var queryErr error
+		sawResult := false
+		for !sawResult {
+			var msg Message
+			select {
+			case m, ok := <-t.messages:
+				if !ok {
+					// The stream ended early: report why
+					if queryErr = c.Err(); queryErr == nil {
+						queryErr = ErrClosed
+					}
+					errCh <- correlateError(queryErr, correlationID)
+					return
+				}
+				msg = m
+			case <-ctx.Done():
+				canceled()
+				return
+			case <-s.done:
+				errCh <- correlateError(ErrSessionClosed, correlationID)
+				return
+			}
+
+			tagMessage(msg, correlationID)
+			select {
+			case msgCh <- msg:
+			case <-ctx.Done():
+				canceled()
+				return
+			case <-s.done:
+				errCh <- correlateError(ErrSessionClosed, correlationID)
+				return
+			}
+			if _, ok := msg.(*ResultMessage); ok {
+				sawResult = true
+				if err := c.budget.err(); err != nil && queryErr == nil {
+					queryErr = err
+				}
+			}
+			if err := auth.observe(ctx, msg); err != nil && queryErr == nil {
+				queryErr = err
+			}
+			if err := errorFromResult(options, msg); err != nil && queryErr == nil {
+				queryErr = err
+			}
+		}
+		if queryErr != nil {
+			errCh <- correlateError(queryErr, correlationID)
+		}
+	}()
+	return msgCh, errCh
+}
+
+// failedQuery returns closed channels reporting err, as Query does.
+func failedQuery(ctx context.Context, err error) (<-chan Message, <-chan error) {
+	msgCh := make(chan Message)
+	errCh := make(chan error, 1)
+	close(msgCh)
+	errCh <- correlateError(err, CorrelationIDFromContext(ctx))
+	close(errCh)
+	return msgCh, errCh
+}
+
+// sessionRouter returns the router for the current connection, starting it
+// on first use. Like PollMessages, it consumes the connection's messages.
+func (c *ClaudeSDKClient) sessionRouter() (*sessionRouter, error) {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	if err := c.checkConnected(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.router != nil && c.router.handler == c.queryHandler {
+		return c.router, nil
+	}
+	r := &sessionRouter{handler: c.queryHandler}
+	if interruptOnCancel(c.options) {
+		r.interrupt = c.interruptTurn
+	}
+	c.router = r
+	go r.run(c.receiveMessagesLocked(c.ctx, false))
+	return r, nil
+}
+
+// sessionRouter dispatches one connection's messages to session queries.
+type sessionRouter struct {
+	handler   *queryHandler // The connection it reads
+	interrupt func()        // Interrupts the running turn; nil with CancelDrain
+
+	mu     sync.Mutex
+	queue  []*sessionTurn // In the order queries were sent, until their ResultMessage
+	closed bool           // The connection's stream ended
+}
+
+// sessionTurn is one query in flight.
+type sessionTurn struct {
+	id       string
+	messages chan Message  // Closed after the ResultMessage, or when the stream ends
+	done     chan struct{} // Closed by end; later messages are dropped
+	once     sync.Once
+}
+
+// abandoned reports whether t was ended before its ResultMessage.
+func (t *sessionTurn) abandoned() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// begin registers a query for session id.
+func (r *sessionRouter) begin(id string) (*sessionTurn, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, ErrClosed
+	}
+	t := &sessionTurn{id: id, messages: make(chan Message), done: make(chan struct{})}
+	r.queue = append(r.queue, t)
+	return t, nil
+}
+
+// end stops delivering t's messages. A turn ended before its ResultMessage
+// stays queued, so the rest of the CLI's reply is dropped instead of
+// reaching the next query, and is interrupted once it runs unless
+// CancelDrain is set. Safe to call more than once.
+func (r *sessionRouter) end(t *sessionTurn) {
+	first := false
+	t.once.Do(func() {
+		close(t.done)
+		first = true
+	})
+	if !first {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.queue) > 0 && r.queue[0] == t {
+		r.interruptLocked()
+	}
+}
+
+// discard unregisters t, whose query was never sent.
+func (r *sessionRouter) discard(t *sessionTurn) {
+	t.once.Do(func() { close(t.done) })
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remove(t)
+}
+
+// remove drops t from the queue. Callers hold mu.
+func (r *sessionRouter) remove(t *sessionTurn) {
+	for i, queued := range r.queue {
+		if queued == t {
+			r.queue = append(r.queue[:i], r.queue[i+1:]...)
+			break
+		}
+	}
+}
+
+// interruptLocked interrupts the running turn, abandoned by its query, if
+// the router interrupts on cancel. Callers hold mu.
+func (r *sessionRouter) interruptLocked() {
+	if r.interrupt != nil {
+		go r.interrupt()
+	}
+}
+
+// running reports whether t is the query the CLI is answering: the oldest
+// one still waiting.
+func (r *sessionRouter) running(t *sessionTurn) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.queue) > 0 && r.queue[0] == t
+}
+
+// target returns the query msg belongs to, or nil: the oldest query sent
+// with msg's session_id, else the oldest query. A ResultMessage completes
+// its query, which is removed from the queue.
+func (r *sessionRouter) target(msg Message) *sessionTurn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var t *sessionTurn
+	if id := messageSessionID(msg); id != "" {
+		for _, queued := range r.queue {
+			if queued.id == id {
+				t = queued
+				break
+			}
+		}
+	}
+	if t == nil && len(r.queue) > 0 {
+		t = r.queue[0]
+	}
+	if t != nil {
+		if _, ok := msg.(*ResultMessage); ok {
+			r.remove(t)
+			if len(r.queue) > 0 && r.queue[0].abandoned() {
+				r.interruptLocked()
+			}
+		}
+	}
+	return t
+}
+
+// run routes messages until the stream ends, then fails the queries still
+// waiting.
+func (r *sessionRouter) run(messages <-chan Message) {
+	for msg := range messages {
+		t := r.target(msg)
+		if t == nil {
+			continue // No query is waiting, e.g. the init message
+		}
+		select {
+		case t.messages <- msg:
+		case <-t.done:
+			continue
+		}
+		if _, ok := msg.(*ResultMessage); ok {
+			close(t.messages)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	for _, t := range r.queue {
+		close(t.messages)
+	}
+	r.queue = nil
+}
+
+// messageSessionID returns the session_id carried by msg, or "".
+func messageSessionID(msg Message) string {
+	switch m := msg.(type) {
+	case *ResultMessage:
+		return m.SessionID
+	case *AssistantMessage:
+		return m.SessionID
+	case *UserMessage:
+		return m.SessionID
+	case *StreamEvent:
+		return m.SessionID
+	case *SystemMessage:
+		id, _ := m.Data["session_id"].(string)
+		return id
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package claude
+
+import (
+	"context"
+	"sync"
+)
+
+// pendingQueries counts the queries sent on a client whose ResultMessage
+// has not been received, so Shutdown can wait for them. A nil
+// *pendingQueries counts nothing.
+type pendingQueries struct {
+	mu       sync.Mutex
+	count    int
+	draining bool          // Shutdown was called; no new queries
+	idle     chan struct{} // Closed when count drops to zero
+}
+
+// start counts a query about to be sent. It returns false once Shutdown
+// was called.
+func (p *pendingQueries) start() bool {
+	if p == nil {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.draining {
+		return false
+	}
+	p.count++
+	if p.count == 1 {
+		p.idle = make(chan struct{})
+	}
+	return true
+}
+
+// finish counts a query as done, when its ResultMessage arrives or it
+// could not be sent.
+func (p *pendingQueries) finish() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.count == 0 {
+		return
+	}
+	p.count--
+	if p.count == 0 {
+		close(p.idle)
+		p.idle = nil
+	}
+}
+
+// reset forgets every query counted, when a restart tears down the
+// connection they were sent on and their ResultMessages can no longer
+// arrive.
+func (p *pendingQueries) reset() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.count > 0 {
+		p.count = 0
+		close(p.idle)
+		p.idle = nil
+	}
+}
+
+// inFlight returns the number of queries counted and not yet finished.
+func (p *pendingQueries) inFlight() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.count
+}
+
+// drain stops new queries and returns a channel closed once none are
+// pending.
+func (p *pendingQueries) drain() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draining = true
+	if p.count == 0 {
+		idle := make(chan struct{})
+		close(idle)
+		return idle
+	}
+	return p.idle
+}
+
+// Shutdown closes the connection gracefully, for servers that stop while
+// queries are running. Unlike Close, which stops the CLI at once, Shutdown:
+//
+//  1. makes new queries fail with ErrShuttingDown,
+//  2. waits for the ResultMessage of every query in flight,
+//  3. ends the CLI's input, so it exits on its own, and waits for its
+//     output to end,
+//  4. closes the connection.
+//
+// If ctx is done first, Shutdown closes the connection anyway and returns
+// ctx's error. Keep reading the queries' messages meanwhile; the CLI's
+// output is not read while they sit unread. On a client that is not
+// connected, Shutdown is Close.
+//
+// Example:
+//
+//	<-sigterm
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	if err := client.Shutdown(ctx); err != nil {
+//	    log.Printf("shutdown: %v", err)
+//	}
+func (c *ClaudeSDKClient) Shutdown(ctx context.Context) error {
+	conn, err := c.connected()
+	if err != nil {
+		return c.Disconnect()
+	}
+
+	// The stream ending also ends the queries in flight
+	done := conn.handler.done
+	select {
+	case <-c.pending.drain():
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err == nil {
+		if err = conn.transport.EndInput(); err == nil {
+			select {
+			case <-done:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+	}
+	if closeErr := c.Disconnect(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,191 @@
+package claude
+
+import (
+	"fmt"
+)
+
+// Types of the events in StreamEvent.Event, as streamed by the Messages API.
+const (
+	StreamEventMessageStart      = "message_start"
+	StreamEventContentBlockStart = "content_block_start"
+	StreamEventContentBlockDelta = "content_block_delta"
+	StreamEventContentBlockStop  = "content_block_stop"
+	StreamEventMessageDelta      = "message_delta"
+	StreamEventMessageStop       = "message_stop"
+	StreamEventPing              = "ping"
+	StreamEventError             = "error"
+)
+
+// Types of ContentBlockDeltaEvent.Delta.
+const (
+	DeltaTypeText      = "text_delta"
+	DeltaTypeInputJSON = "input_json_delta"
+	DeltaTypeThinking  = "thinking_delta"
+	DeltaTypeSignature = "signature_delta"
+)
+
+// StreamEventData is a decoded StreamEvent.Event; see DecodeStreamEvent.
+type StreamEventData interface {
+	EventType() string
+}
+
+// MessageStartEvent begins a streamed message.
+type MessageStartEvent struct {
+	ID    string
+	Model string
+	Role  string
+	Usage *Usage // Input tokens so far; output tokens arrive in MessageDeltaEvent
+}
+
+// ContentBlockStartEvent begins the content block at Index.
+type ContentBlockStartEvent struct {
+	Index int
+	Type  string // "text", "thinking", "tool_use", ...
+	ID    string // For tool_use blocks
+	Name  string // For tool_use blocks
+	// Block is the raw content block, for fields not decoded above
+	Block map[string]interface{}
+}
+
+// ContentBlockDeltaEvent adds to the content block at Index. Which field is
+// set depends on Type: Text for DeltaTypeText, PartialJSON (a fragment of
+// a tool's input) for DeltaTypeInputJSON, Thinking for DeltaTypeThinking,
+// and Signature for DeltaTypeSignature.
+type ContentBlockDeltaEvent struct {
+	Index       int
+	Type        string
+	Text        string
+	PartialJSON string
+	Thinking    string
+	Signature   string
+}
+
+// ContentBlockStopEvent ends the content block at Index.
+type ContentBlockStopEvent struct {
+	Index int
+}
+
+// MessageDeltaEvent updates the message at its end.
+type MessageDeltaEvent struct {
+	StopReason   string // e.g. "end_turn", "tool_use", "max_tokens"
+	StopSequence string
+	Usage        *Usage // Cumulative output tokens
+}
+
+// MessageStopEvent ends a streamed message.
+type MessageStopEvent struct{}
+
+// PingEvent keeps the stream alive.
+type PingEvent struct{}
+
+// StreamErrorEvent reports an error in the middle of a stream, e.g.
+// "overloaded_error".
+type StreamErrorEvent struct {
+	Type    string
+	Message string
+}
+
+// UnknownStreamEvent is an event type the SDK does not know, kept raw so
+// newer APIs do not break decoding.
+type UnknownStreamEvent struct {
+	Type  string
+	Event map[string]interface{}
+}
+
+func (MessageStartEvent) EventType() string      { return StreamEventMessageStart }
+func (ContentBlockStartEvent) EventType() string { return StreamEventContentBlockStart }
+func (ContentBlockDeltaEvent) EventType() string { return StreamEventContentBlockDelta }
+func (ContentBlockStopEvent) EventType() string  { return StreamEventContentBlockStop }
+func (MessageDeltaEvent) EventType() string      { return StreamEventMessageDelta }
+func (MessageStopEvent) EventType() string       { return StreamEventMessageStop }
+func (PingEvent) EventType() string              { return StreamEventPing }
+func (StreamErrorEvent) EventType() string       { return StreamEventError }
+func (e UnknownStreamEvent) EventType() string   { return e.Type }
+
+// DecodeStreamEvent decodes a raw event, as in StreamEvent.Event, into one
+// of the typed events above, e.g. *ContentBlockDeltaEvent. Unknown event
+// types return an *UnknownStreamEvent; fields of the wrong type are left
+// zero.
+func DecodeStreamEvent(event map[string]interface{}) (StreamEventData, error) {
+	eventType, _ := event["type"].(string)
+	switch eventType {
+	case "":
+		return nil, fmt.Errorf("stream event has no type")
+	case StreamEventContentBlockDelta:
+		delta, _ := event["delta"].(map[string]interface{})
+		e := &ContentBlockDeltaEvent{Index: intField(event, "index")}
+		e.Type, _ = delta["type"].(string)
+		e.Text, _ = delta["text"].(string)
+		e.PartialJSON, _ = delta["partial_json"].(string)
+		e.Thinking, _ = delta["thinking"].(string)
+		e.Signature, _ = delta["signature"].(string)
+		return e, nil
+	case StreamEventContentBlockStart:
+		block, _ := event["content_block"].(map[string]interface{})
+		e := &ContentBlockStartEvent{Index: intField(event, "index"), Block: block}
+		e.Type, _ = block["type"].(string)
+		e.ID, _ = block["id"].(string)
+		e.Name, _ = block["name"].(string)
+		return e, nil
+	case StreamEventContentBlockStop:
+		return &ContentBlockStopEvent{Index: intField(event, "index")}, nil
+	case StreamEventMessageStart:
+		message, _ := event["message"].(map[string]interface{})
+		e := &MessageStartEvent{}
+		e.ID, _ = message["id"].(string)
+		e.Model, _ = message["model"].(string)
+		e.Role, _ = message["role"].(string)
+		if usage, ok := message["usage"].(map[string]interface{}); ok {
+			parsed, err := ParseUsage(usage)
+			if err != nil {
+				return nil, err
+			}
+			e.Usage = parsed
+		}
+		return e, nil
+	case StreamEventMessageDelta:
+		delta, _ := event["delta"].(map[string]interface{})
+		e := &MessageDeltaEvent{}
+		e.StopReason, _ = delta["stop_reason"].(string)
+		e.StopSequence, _ = delta["stop_sequence"].(string)
+		if usage, ok := event["usage"].(map[string]interface{}); ok {
+			parsed, err := ParseUsage(usage)
+			if err != nil {
+				return nil, err
+			}
+			e.Usage = parsed
+		}
+		return e, nil
+	case StreamEventMessageStop:
+		return &MessageStopEvent{}, nil
+	case StreamEventPing:
+		return &PingEvent{}, nil
+	case StreamEventError:
+		errObj, _ := event["error"].(map[string]interface{})
+		e := &StreamErrorEvent{}
+		e.Type, _ = errObj["type"].(string)
+		e.Message, _ = errObj["message"].(string)
+		return e, nil
+	}
+	return &UnknownStreamEvent{Type: eventType, Event: event}, nil
+}
+
+// Decode returns the message's event decoded; see DecodeStreamEvent.
+//
+// Example: print text as it streams in
+//
+//	if m, ok := msg.(*claude.StreamEvent); ok {
+//	    event, _ := m.Decode()
+//	    if delta, ok := event.(*claude.ContentBlockDeltaEvent); ok && delta.Type == claude.DeltaTypeText {
+//	        fmt.Print(delta.Text)
+//	    }
+//	}
+func (m *StreamEvent) Decode() (StreamEventData, error) {
+	return DecodeStreamEvent(m.Event)
+}
+
+// intField returns a JSON number field as an int.
+func intField(m map[string]interface{}, key string) int {
+	n, _ := m[key].(float64)
+	return int(n)
+}
@@ -0,0 +1,205 @@
+package claude
+
+import "fmt"
+
+// StreamEventFilter reduces the StreamEvents delivered when
+// IncludePartialMessages is enabled, for consumers that only need coarse
+// updates. It is applied before messages reach the message channel; other
+// message types are never filtered.
+//
+// TextOnly and ToolProgress select event kinds; when both are set, events of
+// either kind are kept. EveryNthDelta and MinDeltaBytes merge consecutive
+// deltas of a content block into one, so no text is lost: a merged delta is
+// delivered once it combines at least EveryNthDelta deltas and
+// MinDeltaBytes bytes, or when its block ends.
+//
+// Filtering out content_block_start and content_block_stop events makes
+// Timeline spans less precise.
+//
+// Example: text only, in chunks of at least 200 bytes:
+//
+//	options := &claude.ClaudeAgentOptions{
+//	    IncludePartialMessages: true,
+//	    StreamEventFilter: &claude.StreamEventFilter{TextOnly: true, MinDeltaBytes: 200},
+//	}
+type StreamEventFilter struct {
+	TextOnly      bool // Keep only text deltas
+	ToolProgress  bool // Keep only tool use block starts and stops, and tool input deltas
+	EveryNthDelta int  // Merge this many deltas into each delivered delta
+	MinDeltaBytes int  // Merge deltas until they hold at least this many bytes
+}
+
+// mergeableDeltaFields maps delta types to the field holding their text.
+var mergeableDeltaFields = map[string]string{
+	"text_delta":       "text",
+	"thinking_delta":   "thinking",
+	"input_json_delta": "partial_json",
+}
+
+// toolBlockTypes are content block types that represent a tool call.
+var toolBlockTypes = map[string]bool{"tool_use": true, "server_tool_use": true, "mcp_tool_use": true}
+
+// streamFilter applies a StreamEventFilter to raw messages. A nil filter
+// passes everything through.
+type streamFilter struct {
+	config     StreamEventFilter
+	toolBlocks map[string]bool          // Blocks that are tool calls, by streamBlockKey
+	pending    map[string]*pendingDelta // Deltas being merged, by streamBlockKey
+	order      []string                 // Keys of pending, oldest first
+}
+
+type pendingDelta struct {
+	msg   map[string]interface{} // Copy of the first delta, holding the merged text
+	field string
+	count int
+}
+
+func newStreamFilter(config *StreamEventFilter) *streamFilter {
+	if config == nil {
+		return nil
+	}
+	return &streamFilter{config: *config, toolBlocks: make(map[string]bool), pending: make(map[string]*pendingDelta)}
+}
+
+// process returns the messages to deliver in place of msg.
+func (f *streamFilter) process(msg map[string]interface{}) []map[string]interface{} {
+	if f == nil {
+		return []map[string]interface{}{msg}
+	}
+	if msg["type"] != "stream_event" {
+		// Merged deltas precede the complete message
+		return append(f.flushAll(), msg)
+	}
+
+	event, _ := msg["event"].(map[string]interface{})
+	eventType, _ := event["type"].(string)
+	key := streamBlockKey(msg, event)
+	var out []map[string]interface{}
+
+	switch eventType {
+	case "content_block_start":
+		block, _ := event["content_block"].(map[string]interface{})
+		blockType, _ := block["type"].(string)
+		f.toolBlocks[key] = toolBlockTypes[blockType]
+	case "content_block_delta":
+		delta, _ := event["delta"].(map[string]interface{})
+		deltaType, _ := delta["type"].(string)
+		if !f.keepDelta(deltaType) {
+			return nil
+		}
+		if field, ok := mergeableDeltaFields[deltaType]; ok && (f.config.EveryNthDelta > 1 || f.config.MinDeltaBytes > 0) {
+			return f.merge(key, msg, field)
+		}
+		return []map[string]interface{}{msg}
+	case "content_block_stop":
+		out = f.flush(key)
+		defer delete(f.toolBlocks, key)
+	default:
+		out = f.flushAll()
+	}
+
+	if f.keepBoundary(eventType, key) {
+		out = append(out, msg)
+	}
+	return out
+}
+
+// keepDelta reports whether deltas of deltaType pass the kind filters.
+func (f *streamFilter) keepDelta(deltaType string) bool {
+	if !f.config.TextOnly && !f.config.ToolProgress {
+		return true
+	}
+	return (f.config.TextOnly && deltaType == "text_delta") ||
+		(f.config.ToolProgress && deltaType == "input_json_delta")
+}
+
+// keepBoundary reports whether a non-delta event passes the kind filters.
+func (f *streamFilter) keepBoundary(eventType, key string) bool {
+	if !f.config.TextOnly && !f.config.ToolProgress {
+		return true
+	}
+	return f.config.ToolProgress && (eventType == "content_block_start" || eventType == "content_block_stop") && f.toolBlocks[key]
+}
+
+// merge adds msg's delta to the pending delta of its block and returns the
+// merged delta once it is large enough.
+func (f *streamFilter) merge(key string, msg map[string]interface{}, field string) []map[string]interface{} {
+	event := msg["event"].(map[string]interface{})
+	delta := event["delta"].(map[string]interface{})
+	text, _ := delta[field].(string)
+
+	p, ok := f.pending[key]
+	if !ok {
+		p = &pendingDelta{msg: copyDeltaMessage(msg), field: field}
+		f.pending[key] = p
+		f.order = append(f.order, key)
+	} else {
+		pendingDelta := p.msg["event"].(map[string]interface{})["delta"].(map[string]interface{})
+		merged, _ := pendingDelta[field].(string)
+		pendingDelta[field] = merged + text
+	}
+	p.count++
+
+	merged := p.msg["event"].(map[string]interface{})["delta"].(map[string]interface{})[field].(string)
+	if p.count >= f.config.EveryNthDelta && len(merged) >= f.config.MinDeltaBytes {
+		return f.flush(key)
+	}
+	return nil
+}
+
+// flush returns the pending delta of a block, if any.
+func (f *streamFilter) flush(key string) []map[string]interface{} {
+	p, ok := f.pending[key]
+	if !ok {
+		return nil
+	}
+	delete(f.pending, key)
+	for i, k := range f.order {
+		if k == key {
+			f.order = append(f.order[:i], f.order[i+1:]...)
+			break
+		}
+	}
+	return []map[string]interface{}{p.msg}
+}
+
+// flushAll returns every pending delta, oldest first.
+func (f *streamFilter) flushAll() []map[string]interface{} {
+	if f == nil || len(f.order) == 0 {
+		return nil
+	}
+	out := make([]map[string]interface{}, 0, len(f.order))
+	for _, key := range f.order {
+		out = append(out, f.pending[key].msg)
+	}
+	clear(f.pending)
+	f.order = f.order[:0]
+	return out
+}
+
+// streamBlockKey identifies a content block; subagent streams are
+// interleaved, so the parent tool use ID is part of the key.
+func streamBlockKey(msg, event map[string]interface{}) string {
+	parent, _ := msg["parent_tool_use_id"].(string)
+	return fmt.Sprintf("%s/%v", parent, event["index"])
+}
+
+// copyDeltaMessage copies a stream_event message deeply enough to modify its
+// delta.
+func copyDeltaMessage(msg map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(msg))
+	for k, v := range msg {
+		out[k] = v
+	}
+	event := make(map[string]interface{})
+	for k, v := range msg["event"].(map[string]interface{}) {
+		event[k] = v
+	}
+	delta := make(map[string]interface{})
+	for k, v := range event["delta"].(map[string]interface{}) {
+		delta[k] = v
+	}
+	event["delta"] = delta
+	out["event"] = event
+	return out
+}
@@ -0,0 +1,52 @@
+package claude
+
+import (
+	"context"
+	"errors"
+)
+
+// StreamStatus is how a message stream ended.
+type StreamStatus string
+
+const (
+	StreamCompleted       StreamStatus = "completed"        // Every response ended with its ResultMessage
+	StreamInterrupted     StreamStatus = "interrupted"      // The context was canceled or timed out
+	StreamTransportClosed StreamStatus = "transport_closed" // The CLI connection failed or ended early
+	StreamParseError      StreamStatus = "parse_error"      // A message could not be decoded or parsed
+	StreamFailed          StreamStatus = "failed"           // Another terminal error, e.g. a BudgetExceededError
+)
+
+// StreamStatusOf returns the status of a stream that ended with err, the
+// value received from a query's error channel (nil if none was sent) or
+// ClaudeSDKClient.Err after a ReceiveMessages or ReceiveResponse channel
+// closed:
+//
+//	for msg := range msgCh { ... }
+//	err := <-errCh
+//	switch claude.StreamStatusOf(err) {
+//	case claude.StreamTransportClosed:
+//	    // Retry on a new connection
+//	}
+//
+// A stream never ends early without an error: if the CLI connection ends
+// cleanly before the ResultMessage, the error is ErrStreamTruncated.
+func StreamStatusOf(err error) StreamStatus {
+	var parseErr *MessageParseError
+	var decodeErr *CLIJSONDecodeError
+	var connErr *CLIConnectionError
+	var processErr *ProcessError
+	var authErr *AuthenticationError
+	switch {
+	case err == nil:
+		return StreamCompleted
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return StreamInterrupted
+	case errors.As(err, &parseErr), errors.As(err, &decodeErr):
+		return StreamParseError
+	case errors.As(err, &authErr):
+		return StreamFailed // May wrap the ProcessError it was diagnosed from
+	case errors.As(err, &connErr), errors.As(err, &processErr):
+		return StreamTransportClosed
+	}
+	return StreamFailed
+}
@@ -0,0 +1,140 @@
+package claude
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ParseWarning describes a protocol mismatch detected by strict parsing.
+type ParseWarning struct {
+	MessageType string                 // "assistant" or "result"
+	Field       string                 // Dotted field path, e.g. "message.usage"
+	Problem     string                 // "unexpected" or "missing"
+	Data        map[string]interface{} // The raw message
+}
+
+// String formats the warning for logging.
+func (w ParseWarning) String() string {
+	return fmt.Sprintf("%s message has %s field '%s'", w.MessageType, w.Problem, w.Field)
+}
+
+// ParseWarningCallback is called for each protocol mismatch found by strict parsing.
+type ParseWarningCallback func(warning ParseWarning)
+
+// knownMessageFields lists the fields this SDK understands, per message type and
+// nesting level. Fields outside these sets indicate CLI/SDK protocol skew.
+var knownMessageFields = map[string]map[string]bool{
+	"assistant": {
+		"type": true, "message": true, "parent_tool_use_id": true, "session_id": true,
+		"uuid": true, "error": true,
+	},
+	"assistant.message": {
+		"id": true, "type": true, "role": true, "model": true, "content": true,
+		"stop_reason": true, "stop_sequence": true, "usage": true, "container": true,
+		"context_management": true,
+	},
+	"result": {
+		"type": true, "subtype": true, "duration_ms": true, "duration_api_ms": true,
+		"is_error": true, "num_turns": true, "session_id": true, "total_cost_usd": true,
+		"usage": true, "result": true, "uuid": true, "permission_denials": true,
+		"modelUsage": true, "structured_output": true, "errors": true,
+	},
+}
+
+// expectedMessageFields lists optional fields that the CLI always sends today.
+// Their absence is silently tolerated in lenient mode.
+var expectedMessageFields = map[string][]string{
+	"assistant":         {"session_id"},
+	"assistant.message": {"usage"},
+	"result":            {"total_cost_usd", "usage"},
+}
+
+// ParseMessageStrict parses a raw message like ParseMessage, additionally
+// checking assistant and result messages for unexpected or missing fields.
+//
+// If onWarning is nil, the first mismatch fails parsing with a
+// *MessageParseError. Otherwise every mismatch is reported to onWarning and
+// the message is parsed normally.
+func ParseMessageStrict(data map[string]interface{}, onWarning ParseWarningCallback) (Message, error) {
+	return messageParser{strict: true, onWarning: onWarning}.parse(data)
+}
+
+// messageParser parses raw messages according to the configured strictness.
+type messageParser struct {
+	strict    bool
+	onWarning ParseWarningCallback
+	metrics   Metrics
+}
+
+// newMessageParser creates a parser from options.
+func newMessageParser(options *ClaudeAgentOptions) messageParser {
+	if options == nil {
+		return messageParser{}
+	}
+	return messageParser{strict: options.StrictParsing, onWarning: options.ParseWarning, metrics: options.Metrics}
+}
+
+// parse parses data, applying strict field checks when enabled.
+func (p messageParser) parse(data map[string]interface{}) (Message, error) {
+	if p.metrics != nil {
+		defer observeSince(p.metrics, MetricParseDuration, time.Now(), parseStageLabels)
+	}
+	msg, err := parseMessage(data)
+	if err != nil || !p.strict {
+		return msg, err
+	}
+
+	for _, warning := range checkMessageFields(data) {
+		if p.onWarning == nil {
+			return nil, NewMessageParseError("strict parsing: "+warning.String(), data)
+		}
+		p.onWarning(warning)
+	}
+
+	return msg, nil
+}
+
+// checkMessageFields returns all field mismatches for assistant and result messages.
+func checkMessageFields(data map[string]interface{}) []ParseWarning {
+	msgType, _ := data["type"].(string)
+
+	var warnings []ParseWarning
+	switch msgType {
+	case "assistant":
+		warnings = append(warnings, checkFieldLevel(msgType, "assistant", "", data, data)...)
+		if inner, ok := data["message"].(map[string]interface{}); ok {
+			warnings = append(warnings, checkFieldLevel(msgType, "assistant.message", "message.", inner, data)...)
+		}
+	case "result":
+		warnings = append(warnings, checkFieldLevel(msgType, "result", "", data, data)...)
+	}
+	return warnings
+}
+
+// checkFieldLevel compares one object level against the known and expected field sets.
+func checkFieldLevel(msgType, level, prefix string, fields, raw map[string]interface{}) []ParseWarning {
+	var warnings []ParseWarning
+
+	// Sort for deterministic reporting
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	known := knownMessageFields[level]
+	for _, key := range keys {
+		if !known[key] {
+			warnings = append(warnings, ParseWarning{MessageType: msgType, Field: prefix + key, Problem: "unexpected", Data: raw})
+		}
+	}
+
+	for _, key := range expectedMessageFields[level] {
+		if _, ok := fields[key]; !ok {
+			warnings = append(warnings, ParseWarning{MessageType: msgType, Field: prefix + key, Problem: "missing", Data: raw})
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,260 @@
+package claude
+
+import (
+	"sync"
+	"time"
+)
+
+// taskToolName is the built-in tool Claude uses to launch subagents.
+const taskToolName = "Task"
+
+// SubagentEventType identifies a subagent lifecycle transition.
+type SubagentEventType string
+
+const (
+	SubagentStarted SubagentEventType = "started"
+	SubagentStopped SubagentEventType = "stopped"
+)
+
+// SubagentEvent describes a subagent starting or stopping.
+type SubagentEvent struct {
+	Type        SubagentEventType
+	AgentName   string // Task subagent_type, e.g. "code-reviewer"
+	Description string // Task description
+	ToolUseID   string // ID of the Task tool use that launched the subagent
+	StartedAt   time.Time
+
+	// Populated for SubagentStopped only.
+	Duration    time.Duration          // CLI-reported duration when available, otherwise observed
+	IsError     bool                   // The Task tool returned an error
+	TotalTokens int                    // Tokens used by the subagent, when reported
+	Usage       map[string]interface{} // Raw token usage, when reported
+	CostUSD     *float64               // Cost, when reported by the CLI
+}
+
+// SubagentTracker derives subagent lifecycle events from the message stream.
+//
+// A subagent starts when an AssistantMessage contains a Task tool use and
+// stops when the matching tool result arrives. Feed every message received
+// from Query() or ReceiveMessages() into Observe.
+//
+// Only messages are observed, not SubagentStop hooks: their input does not
+// say which Task launched the subagent, and the tool result follows them
+// with the subagent's usage. A Task whose result never arrives, e.g. because
+// the turn was interrupted, stays in Active until the ResultMessage that
+// ends its turn, which clears it without a Stopped event.
+//
+// Example:
+//
+//	tracker := claude.NewSubagentTracker()
+//	for msg := range msgCh {
+//	    for _, event := range tracker.Observe(msg) {
+//	        if event.Type == claude.SubagentStopped {
+//	            log.Printf("%s finished in %s (%d tokens)", event.AgentName, event.Duration, event.TotalTokens)
+//	        }
+//	    }
+//	}
+//
+// SubagentTracker is safe for concurrent use.
+type SubagentTracker struct {
+	mu     sync.Mutex
+	active map[string]SubagentEvent // Started events by Task tool use ID
+}
+
+// NewSubagentTracker creates an empty SubagentTracker.
+func NewSubagentTracker() *SubagentTracker {
+	return &SubagentTracker{
+		active: make(map[string]SubagentEvent),
+	}
+}
+
+// Observe returns the lifecycle events contained in msg, in order.
+func (t *SubagentTracker) Observe(msg Message) []SubagentEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var events []SubagentEvent
+	switch m := msg.(type) {
+	case *ResultMessage:
+		// Subagents end with the turn that launched them
+		clear(t.active)
+	case *AssistantMessage:
+		for _, block := range m.Content {
+			toolUse, ok := block.(ToolUseBlock)
+			if !ok || toolUse.Name != taskToolName {
+				continue
+			}
+			started := SubagentEvent{
+				Type:        SubagentStarted,
+				AgentName:   stringField(toolUse.Input, "subagent_type"),
+				Description: stringField(toolUse.Input, "description"),
+				ToolUseID:   toolUse.ID,
+				StartedAt:   time.Now(),
+			}
+			t.active[toolUse.ID] = started
+			events = append(events, started)
+		}
+	case *UserMessage:
+		blocks, ok := m.Content.([]ContentBlock)
+		if !ok {
+			break
+		}
+		for _, block := range blocks {
+			result, ok := block.(ToolResultBlock)
+			if !ok {
+				continue
+			}
+			started, exists := t.active[result.ToolUseID]
+			if !exists {
+				continue
+			}
+			delete(t.active, result.ToolUseID)
+			events = append(events, t.stopped(started, result, m.ToolUseResult))
+		}
+	}
+	return events
+}
+
+// Active returns the Started events of subagents that have not stopped yet.
+func (t *SubagentTracker) Active() []SubagentEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	active := make([]SubagentEvent, 0, len(t.active))
+	for _, event := range t.active {
+		active = append(active, event)
+	}
+	return active
+}
+
+// stopped builds the Stopped event for a finished Task tool use.
+func (t *SubagentTracker) stopped(started SubagentEvent, result ToolResultBlock, toolUseResult map[string]interface{}) SubagentEvent {
+	stopped := started
+	stopped.Type = SubagentStopped
+	stopped.Duration = time.Now().Sub(started.StartedAt)
+	stopped.IsError = result.IsError != nil && *result.IsError
+
+	if ms, ok := toolUseResult["totalDurationMs"].(float64); ok {
+		stopped.Duration = time.Duration(ms) * time.Millisecond
+	}
+	if tokens, ok := toolUseResult["totalTokens"].(float64); ok {
+		stopped.TotalTokens = int(tokens)
+	}
+	if usage, ok := toolUseResult["usage"].(map[string]interface{}); ok {
+		stopped.Usage = usage
+	}
+	if cost, ok := toolUseResult["totalCostUsd"].(float64); ok {
+		stopped.CostUSD = &cost
+	}
+	return stopped
+}
+
+// stringField returns m[key] if it is a string.
+func stringField(m map[string]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// agentIndex maps tool use IDs to the subagent that issued them, so hooks can
+// be scoped with HookMatcher.Agent. It is fed raw messages in stream order,
+// which guarantees a tool use is indexed before its hooks run, and that its
+// hooks have run when its result arrives, so the entry can be dropped then.
+// Entries left by tool uses without results are dropped at the end of the
+// turn.
+type agentIndex struct {
+	mu        sync.Mutex
+	taskAgent map[string]string // Task tool use ID -> subagent_type it launched
+	toolAgent map[string]string // Tool use ID -> subagent that issued it
+}
+
+func newAgentIndex() *agentIndex {
+	return &agentIndex{
+		taskAgent: make(map[string]string),
+		toolAgent: make(map[string]string),
+	}
+}
+
+// observe indexes the tool uses in a raw assistant message, and forgets
+// them when their results arrive.
+func (a *agentIndex) observe(data map[string]interface{}) {
+	switch data["type"] {
+	case "assistant":
+	case "user":
+		a.forget(data)
+		return
+	case "result":
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		clear(a.taskAgent)
+		clear(a.toolAgent)
+		return
+	default:
+		return
+	}
+	message, _ := data["message"].(map[string]interface{})
+	content, _ := message["content"].([]interface{})
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	agent := MainAgent
+	if parent, ok := data["parent_tool_use_id"].(string); ok {
+		if name, exists := a.taskAgent[parent]; exists {
+			agent = name
+		}
+	}
+
+	for _, item := range content {
+		block, _ := item.(map[string]interface{})
+		if block["type"] != "tool_use" {
+			continue
+		}
+		id, _ := block["id"].(string)
+		if id == "" {
+			continue
+		}
+		a.toolAgent[id] = agent
+		if block["name"] == taskToolName {
+			input, _ := block["input"].(map[string]interface{})
+			a.taskAgent[id] = stringField(input, "subagent_type")
+		}
+	}
+}
+
+// forget drops the tool uses answered by the tool results in a raw user
+// message.
+func (a *agentIndex) forget(data map[string]interface{}) {
+	message, _ := data["message"].(map[string]interface{})
+	content, _ := message["content"].([]interface{})
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, item := range content {
+		block, _ := item.(map[string]interface{})
+		if block["type"] != "tool_result" {
+			continue
+		}
+		id, _ := block["tool_use_id"].(string)
+		delete(a.toolAgent, id)
+		delete(a.taskAgent, id)
+	}
+}
+
+// agentFor returns the subagent a hook invocation belongs to. Hook inputs
+// that name their agent (e.g. SubagentStop's agent_type) take precedence;
+// otherwise the tool use ID is looked up. Unknown calls belong to MainAgent.
+func (a *agentIndex) agentFor(input map[string]interface{}, toolUseID *string) string {
+	if name := stringField(input, "agent_type"); name != "" {
+		return name
+	}
+	if toolUseID == nil {
+		return MainAgent
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if name, ok := a.toolAgent[*toolUseID]; ok {
+		return name
+	}
+	return MainAgent
+}
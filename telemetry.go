@@ -0,0 +1,307 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// InstrumentationName is the instrumentation scope the SDK passes to
+// TracerProvider.Tracer.
+const InstrumentationName = "github.com/clsx524/claude-agent-sdk-go"
+
+// Names of the spans reported to ClaudeAgentOptions.TracerProvider.
+const (
+	// SpanQuery covers one query, from sending the prompt to its
+	// ResultMessage. Its parent is the span in the query's context.
+	SpanQuery = "claude.query"
+
+	// SpanToolUse covers one tool call, from its ToolUseBlock to its
+	// ToolResultBlock. Its parent is the query, or for subagent tool calls
+	// the Task tool call that started the subagent.
+	SpanToolUse = "claude.tool_use"
+
+	// SpanControlRequest covers one control request, sent by the SDK
+	// (e.g. interrupt) or by the CLI (e.g. can_use_tool, whose callbacks run
+	// with the span in their context).
+	SpanControlRequest = "claude.control_request"
+)
+
+// Attribute is a key/value pair annotating a span. Values are strings, ints,
+// float64s, or bools.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// TracerProvider creates Tracers. It mirrors the part of OpenTelemetry's
+// trace.TracerProvider the SDK uses, so an OpenTelemetry provider can be
+// adapted in a few lines without the SDK depending on it.
+type TracerProvider interface {
+	Tracer(name string) Tracer
+}
+
+// Tracer starts spans; ctx carries the parent span, and the returned
+// context carries the new one. ContextWithSpan returns ctx carrying span
+// instead of any span it has, like OpenTelemetry's trace.ContextWithSpan.
+type Tracer interface {
+	Start(ctx context.Context, spanName string, attrs ...Attribute) (context.Context, Span)
+	ContextWithSpan(ctx context.Context, span Span) context.Context
+}
+
+// Span is an operation being traced. The SDK calls End exactly once.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// telemetry reports the spans above, and the query, tool call, token, and
+// cost metrics to ClaudeAgentOptions.Metrics. A nil *telemetry does nothing.
+type telemetry struct {
+	tracer  Tracer  // nil without a TracerProvider
+	metrics Metrics // nil without Metrics
+
+	mu      sync.Mutex
+	queries []*openSpan          // Queries awaiting their ResultMessage, oldest first
+	tools   map[string]*openSpan // Tool calls awaiting their result, by tool use ID
+	lastUSD float64              // Cumulative cost the CLI last reported
+}
+
+// openSpan is a span that has not ended. span is nil without a tracer.
+type openSpan struct {
+	ctx   context.Context
+	span  Span
+	start time.Time
+	name  string // Tool name, for tool calls
+}
+
+// end ends the span, recording err if set.
+func (s *openSpan) end(err error, attrs ...Attribute) {
+	if s.span == nil {
+		return
+	}
+	if len(attrs) > 0 {
+		s.span.SetAttributes(attrs...)
+	}
+	if err != nil {
+		s.span.RecordError(err)
+	}
+	s.span.End()
+}
+
+// newTelemetry returns the instrumentation configured in options, or nil.
+func newTelemetry(options *ClaudeAgentOptions) *telemetry {
+	if options == nil || (options.TracerProvider == nil && options.Metrics == nil) {
+		return nil
+	}
+	t := &telemetry{tools: make(map[string]*openSpan), metrics: options.Metrics}
+	if options.TracerProvider != nil {
+		t.tracer = options.TracerProvider.Tracer(InstrumentationName)
+	}
+	return t
+}
+
+// start starts a span under parent.
+func (t *telemetry) start(parent context.Context, name string, attrs ...Attribute) *openSpan {
+	s := &openSpan{ctx: parent, start: time.Now()}
+	if t.tracer != nil {
+		s.ctx, s.span = t.tracer.Start(parent, name, attrs...)
+	}
+	return s
+}
+
+// startQuery starts the span of a query sent with ctx.
+func (t *telemetry) startQuery(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	var attrs []Attribute
+	if id := CorrelationIDFromContext(ctx); id != "" {
+		attrs = append(attrs, Attribute{"claude.correlation_id", id})
+	}
+	s := t.start(ctx, SpanQuery, attrs...)
+	t.mu.Lock()
+	t.queries = append(t.queries, s)
+	t.mu.Unlock()
+}
+
+// queryContextLocked returns the context of the oldest open query. Callers
+// hold mu.
+func (t *telemetry) queryContextLocked() (context.Context, bool) {
+	if len(t.queries) == 0 {
+		return nil, false
+	}
+	return t.queries[0].ctx, true
+}
+
+// observe starts and ends spans, and records metrics, for msg.
+func (t *telemetry) observe(msg Message) {
+	if t == nil {
+		return
+	}
+	switch m := msg.(type) {
+	case *AssistantMessage:
+		t.observeToolUses(m)
+	case *UserMessage:
+		if blocks, ok := m.Content.([]ContentBlock); ok {
+			t.observeToolResults(blocks)
+		}
+	case *ResultMessage:
+		t.observeResult(m)
+	}
+}
+
+func (t *telemetry) observeToolUses(m *AssistantMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent, ok := t.queryContextLocked()
+	if !ok {
+		parent = context.Background()
+	}
+	if m.ParentToolUseID != nil {
+		if task, ok := t.tools[*m.ParentToolUseID]; ok {
+			parent = task.ctx
+		}
+	}
+	for _, block := range m.Content {
+		toolUse, ok := block.(ToolUseBlock)
+		if !ok {
+			continue
+		}
+		if _, open := t.tools[toolUse.ID]; open {
+			continue
+		}
+		s := t.start(parent, SpanToolUse,
+			Attribute{"claude.tool.name", toolUse.Name},
+			Attribute{"claude.tool.use_id", toolUse.ID},
+			Attribute{"claude.model", m.Model},
+		)
+		s.name = toolUse.Name
+		t.tools[toolUse.ID] = s
+	}
+}
+
+func (t *telemetry) observeToolResults(blocks []ContentBlock) {
+	for _, block := range blocks {
+		result, ok := block.(ToolResultBlock)
+		if !ok {
+			continue
+		}
+		t.mu.Lock()
+		s, open := t.tools[result.ToolUseID]
+		delete(t.tools, result.ToolUseID)
+		t.mu.Unlock()
+		if !open {
+			continue
+		}
+
+		isError := result.IsError != nil && *result.IsError
+		var err error
+		if isError {
+			err = fmt.Errorf("tool %s failed", s.name)
+		}
+		s.end(err, Attribute{"claude.tool.is_error", isError})
+		observeSince(t.metrics, MetricToolUseDuration, s.start, map[string]string{"tool": s.name})
+	}
+}
+
+func (t *telemetry) observeResult(m *ResultMessage) {
+	t.mu.Lock()
+	var query *openSpan
+	if len(t.queries) > 0 {
+		query = t.queries[0]
+		t.queries = t.queries[1:]
+	}
+	// Tool calls without a result never get one
+	var orphaned []*openSpan
+	for id, s := range t.tools {
+		orphaned = append(orphaned, s)
+		delete(t.tools, id)
+	}
+	var costUSD float64
+	if m.TotalCostUSD != nil {
+		// Cumulative per CLI process, as in CostTracker
+		costUSD = *m.TotalCostUSD - t.lastUSD
+		if *m.TotalCostUSD < t.lastUSD {
+			costUSD = *m.TotalCostUSD
+		}
+		t.lastUSD = *m.TotalCostUSD
+	}
+	t.mu.Unlock()
+
+	for _, s := range orphaned {
+		s.end(nil, Attribute{"claude.tool.orphaned", true})
+	}
+
+	usage, err := ParseUsage(m.Usage)
+	if err != nil {
+		usage = &Usage{}
+	}
+	addCounter(t.metrics, MetricTokens, float64(usage.InputTokens), inputTokenLabels)
+	addCounter(t.metrics, MetricTokens, float64(usage.OutputTokens), outputTokenLabels)
+	addCounter(t.metrics, MetricTokens, float64(usage.CacheReadInputTokens), cacheReadTokenLabels)
+	addCounter(t.metrics, MetricTokens, float64(usage.CacheCreationInputTokens), cacheCreationTokenLabels)
+	addCounter(t.metrics, MetricCost, costUSD, nil)
+	if query == nil {
+		return
+	}
+	observeSince(t.metrics, MetricQueryDuration, query.start, nil)
+
+	var resultErr error
+	if m.IsError {
+		resultErr = fmt.Errorf("query ended with %s", m.Subtype)
+	}
+	query.end(resultErr,
+		Attribute{"claude.session_id", m.SessionID},
+		Attribute{"claude.result.subtype", m.Subtype},
+		Attribute{"claude.num_turns", m.NumTurns},
+		Attribute{"claude.cost_usd", costUSD},
+		Attribute{"claude.tokens.input", usage.InputTokens},
+		Attribute{"claude.tokens.output", usage.OutputTokens},
+	)
+}
+
+// startControlRequest starts the span of a control request; direction is
+// "outbound" for requests the SDK sends and "inbound" for requests from the
+// CLI. Requests from the CLI are parented to the current query. It returns
+// ctx carrying the span, and a function to call with the request's error
+// when it completes.
+func (t *telemetry) startControlRequest(ctx context.Context, subtype string, direction string) (context.Context, func(error)) {
+	if t == nil || t.tracer == nil {
+		return ctx, func(error) {}
+	}
+	parent := ctx
+	if direction == "inbound" {
+		t.mu.Lock()
+		if query, ok := t.queryContextLocked(); ok {
+			parent = query
+		}
+		t.mu.Unlock()
+	}
+	s := t.start(parent, SpanControlRequest,
+		Attribute{"claude.control.subtype", subtype},
+		Attribute{"claude.control.direction", direction},
+	)
+	// Keep ctx's cancellation and values, such as the request's metadata
+	return t.tracer.ContextWithSpan(ctx, s.span), func(err error) { s.end(err) }
+}
+
+// finish ends every open span with err, e.g. when the client is closed.
+func (t *telemetry) finish(err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	spans := t.queries
+	t.queries = nil
+	for id, s := range t.tools {
+		spans = append(spans, s)
+		delete(t.tools, id)
+	}
+	t.mu.Unlock()
+	for _, s := range spans {
+		s.end(err)
+	}
+}
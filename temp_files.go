@@ -0,0 +1,90 @@
+package claude
+
+import (
+	"fmt"
+	"os"
+)
+
+// TempFileCleanup selects when temporary files passed to the CLI, such as
+// agent definitions too long for the command line, are deleted.
+type TempFileCleanup string
+
+const (
+	// TempFileCleanupOnClose deletes temp files when the transport closes.
+	TempFileCleanupOnClose TempFileCleanup = ""
+
+	// TempFileCleanupAfterStart deletes temp files as soon as the CLI has
+	// started. On Unix the CLI reads the file through an inherited file
+	// descriptor, so its contents stay readable after the path is removed.
+	// Windows cannot remove open files and falls back to
+	// TempFileCleanupOnClose.
+	TempFileCleanupAfterStart TempFileCleanup = "after_start"
+)
+
+// TempFileSpill describes a CLI argument the SDK is about to pass through a
+// temp file because the command line would be too long.
+type TempFileSpill struct {
+	Arg           string // The flag whose value is moved, e.g. "--agents"
+	Size          int    // Size of the value in bytes
+	Path          string // The temp file, readable only by the current user
+	CommandLength int    // Length of the command line with the value inline
+	Limit         int    // The platform's command line limit
+}
+
+// TempFileSpillCallback is called before the CLI starts with a value passed
+// through a temp file. Returning an error vetoes it: the temp file is
+// removed and Connect fails with a CLIConnectionError wrapping the error.
+type TempFileSpillCallback func(spill TempFileSpill) error
+
+// tempFilePerm restricts temp files to the current user; agent definitions
+// can contain sensitive prompts.
+const tempFilePerm = 0o600
+
+// createTempFile writes data to a new file readable only by the current
+// user, in options.TempDir or the default temp directory. The returned file
+// is open and positioned at its start.
+func createTempFile(options *ClaudeAgentOptions, pattern string, data string) (*os.File, error) {
+	dir := ""
+	if options != nil && options.TempDir != "" {
+		dir = options.TempDir
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTempFile(f, data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+func writeTempFile(f *os.File, data string) error {
+	// CreateTemp already uses 0600 on Unix; this also covers a umask or
+	// filesystem that widened it
+	if err := f.Chmod(tempFilePerm); err != nil && !isWindows() {
+		return err
+	}
+	if _, err := f.WriteString(data); err != nil {
+		return err
+	}
+	_, err := f.Seek(0, 0)
+	return err
+}
+
+// inheritTempFiles reports whether temp files are passed to the CLI as
+// inherited file descriptors so they can be removed right after it starts.
+func inheritTempFiles(options *ClaudeAgentOptions) bool {
+	return options != nil && options.TempFileCleanup == TempFileCleanupAfterStart && !isWindows()
+}
+
+// inheritedFilePath is the path through which the child process reads the
+// n-th file in exec.Cmd.ExtraFiles; descriptors 0-2 are stdio.
+func inheritedFilePath(n int) string {
+	return fmt.Sprintf("/dev/fd/%d", 3+n)
+}
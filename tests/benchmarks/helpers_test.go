@@ -0,0 +1,94 @@
+package benchmarks
+
+import (
+	"context"
+	"strings"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// replayTransport emits a fixed sequence of messages as fast as the consumer accepts them.
+type replayTransport struct {
+	messages   []map[string]interface{}
+	bufferSize int
+}
+
+func (r *replayTransport) Connect(ctx context.Context) error            { return nil }
+func (r *replayTransport) Write(ctx context.Context, data string) error { return nil }
+func (r *replayTransport) Close() error                                 { return nil }
+func (r *replayTransport) IsReady() bool                                { return true }
+func (r *replayTransport) EndInput() error                              { return nil }
+
+func (r *replayTransport) ReadMessages(ctx context.Context) (<-chan map[string]interface{}, <-chan error) {
+	msgCh := make(chan map[string]interface{}, r.bufferSize)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(msgCh)
+		defer close(errCh)
+		for _, msg := range r.messages {
+			select {
+			case msgCh <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return msgCh, errCh
+}
+
+func assistantMessage(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "assistant",
+		"session_id": "bench",
+		"message": map[string]interface{}{
+			"model": "claude-sonnet-4-5",
+			"content": []interface{}{
+				map[string]interface{}{"type": "text", "text": text},
+				map[string]interface{}{
+					"type":  "tool_use",
+					"id":    "toolu_1",
+					"name":  "Read",
+					"input": map[string]interface{}{"file_path": "/tmp/file.go"},
+				},
+			},
+		},
+	}
+}
+
+func resultMessage() map[string]interface{} {
+	return map[string]interface{}{
+		"type":            "result",
+		"subtype":         "success",
+		"duration_ms":     float64(1000),
+		"duration_api_ms": float64(800),
+		"is_error":        false,
+		"num_turns":       float64(1),
+		"session_id":      "bench",
+		"total_cost_usd":  0.01,
+	}
+}
+
+// conversation builds n assistant messages followed by a result message.
+func conversation(n int, textSize int) []map[string]interface{} {
+	text := strings.Repeat("x", textSize)
+	messages := make([]map[string]interface{}, 0, n+1)
+	for i := 0; i < n; i++ {
+		messages = append(messages, assistantMessage(text))
+	}
+	return append(messages, resultMessage())
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// drain consumes a query's channels, returning the number of messages received.
+func drain(msgCh <-chan claude.Message, errCh <-chan error) (int, error) {
+	count := 0
+	for range msgCh {
+		count++
+	}
+	return count, <-errCh
+}
@@ -0,0 +1,75 @@
+package benchmarks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// BenchmarkSubprocessParseConcurrency measures reading and decoding a
+// session's output from a CLI process, with large messages, as
+// ParseConcurrency varies.
+func BenchmarkSubprocessParseConcurrency(b *testing.B) {
+	if runtime.GOOS == "windows" {
+		b.Skip("fake CLI scripts require a POSIX shell")
+	}
+	const messagesPerQuery = 200
+	cliPath := writeReplayCLI(b, conversation(messagesPerQuery, 256*1024))
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			options := &claude.ClaudeAgentOptions{ParseConcurrency: workers}
+
+			for i := 0; i < b.N; i++ {
+				transport, err := claude.NewSubprocessCLITransport("bench", options, cliPath)
+				if err != nil {
+					b.Fatal(err)
+				}
+				msgCh, errCh, err := claude.Query(context.Background(), "bench", options, transport)
+				if err != nil {
+					b.Fatal(err)
+				}
+				count, err := drain(msgCh, errCh)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if count != messagesPerQuery+1 {
+					b.Fatalf("expected %d messages, got %d", messagesPerQuery+1, count)
+				}
+			}
+			b.ReportMetric(float64((messagesPerQuery+1)*b.N)/b.Elapsed().Seconds(), "msgs/s")
+		})
+	}
+}
+
+// writeReplayCLI writes a fake CLI that prints messages as JSON lines.
+func writeReplayCLI(b *testing.B, messages []map[string]interface{}) string {
+	b.Helper()
+	dir := b.TempDir()
+	output, err := os.Create(filepath.Join(dir, "output.jsonl"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	encoder := json.NewEncoder(output)
+	for _, msg := range messages {
+		if err := encoder.Encode(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := output.Close(); err != nil {
+		b.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "claude")
+	script := "#!/bin/sh\nif [ \"$1\" = \"-v\" ]; then echo \"2.0.0 (Claude Code)\"; exit 0; fi\nexec cat " + filepath.Join(dir, "output.jsonl") + "\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
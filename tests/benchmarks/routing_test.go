@@ -0,0 +1,113 @@
+package benchmarks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// BenchmarkParseMessage measures converting raw JSON maps into typed messages.
+func BenchmarkParseMessage(b *testing.B) {
+	cases := map[string]map[string]interface{}{
+		"assistant_small": assistantMessage("hello"),
+		"assistant_64KB":  assistantMessage(string(make([]byte, 64*1024))),
+		"result":          resultMessage(),
+	}
+
+	for name, data := range cases {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := claude.ParseMessage(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkDecodeJSON measures the raw JSON decode cost that precedes parsing.
+func BenchmarkDecodeJSON(b *testing.B) {
+	for _, size := range []int{256, 16 * 1024, 256 * 1024} {
+		raw, _ := json.Marshal(assistantMessage(string(make([]byte, size))))
+		b.Run(fmt.Sprintf("%dB", size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(raw)))
+			for i := 0; i < b.N; i++ {
+				var data map[string]interface{}
+				if err := json.Unmarshal(raw, &data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkQueryRouting measures end-to-end message routing through Query():
+// transport channel -> queryHandler routing -> parsing -> caller channel.
+// Sub-benchmarks vary the channel buffer knobs exposed in ClaudeAgentOptions.
+func BenchmarkQueryRouting(b *testing.B) {
+	const messagesPerQuery = 1000
+
+	for _, bufferSize := range []int{1, 10, 100, 1000} {
+		b.Run(fmt.Sprintf("buffers=%d", bufferSize), func(b *testing.B) {
+			messages := conversation(messagesPerQuery, 128)
+			options := &claude.ClaudeAgentOptions{
+				MessageChannelBufferSize: intPtr(bufferSize),
+				OutputChannelBufferSize:  intPtr(bufferSize),
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				transport := &replayTransport{messages: messages, bufferSize: bufferSize}
+				msgCh, errCh, err := claude.Query(context.Background(), "bench", options, transport)
+				if err != nil {
+					b.Fatal(err)
+				}
+				count, err := drain(msgCh, errCh)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if count != len(messages) {
+					b.Fatalf("expected %d messages, got %d", len(messages), count)
+				}
+			}
+			b.ReportMetric(float64(messagesPerQuery*b.N)/b.Elapsed().Seconds(), "msgs/s")
+		})
+	}
+}
+
+// BenchmarkQueryRoutingSlowConsumer measures how buffering absorbs a consumer
+// that does a small amount of work per message.
+func BenchmarkQueryRoutingSlowConsumer(b *testing.B) {
+	const messagesPerQuery = 200
+
+	for _, bufferSize := range []int{1, 100} {
+		b.Run(fmt.Sprintf("buffers=%d", bufferSize), func(b *testing.B) {
+			messages := conversation(messagesPerQuery, 128)
+			options := &claude.ClaudeAgentOptions{
+				MessageChannelBufferSize: intPtr(bufferSize),
+				OutputChannelBufferSize:  intPtr(bufferSize),
+			}
+
+			for i := 0; i < b.N; i++ {
+				transport := &replayTransport{messages: messages, bufferSize: bufferSize}
+				msgCh, errCh, err := claude.Query(context.Background(), "bench", options, transport)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for msg := range msgCh {
+					// Simulate per-message consumer work
+					_, _ = json.Marshal(msg)
+				}
+				if err := <-errCh; err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
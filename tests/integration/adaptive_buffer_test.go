@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// bufferSize returns the last MetricBufferSize reported to metrics, or 0.
+func bufferSize(metrics *claude.ExpvarMetrics) int {
+	for _, g := range metrics.Gauges() {
+		if g.Name == claude.MetricBufferSize && g.Labels["buffer"] == "messages" {
+			return int(g.Value)
+		}
+	}
+	return 0
+}
+
+// waitForBufferSize polls metrics until the buffer size satisfies ok.
+func waitForBufferSize(t *testing.T, metrics *claude.ExpvarMetrics, what string, ok func(int) bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !ok(bufferSize(metrics)) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for buffer to %s, size is %d", what, bufferSize(metrics))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAdaptiveBuffering(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	metrics := claude.NewExpvarMetrics()
+	initial := 8
+	options := &claude.ClaudeAgentOptions{
+		Metrics:                  metrics,
+		MessageChannelBufferSize: &initial,
+		AdaptiveBuffering:        &claude.AdaptiveBuffering{Min: 4, Max: 64, Interval: 10 * time.Millisecond},
+	}
+	transport := NewAdvancedMockTransport()
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	cc := claude.NewControlClient(transport, options)
+	defer cc.Close()
+	if err := cc.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	waitForBufferSize(t, metrics, "report its initial size", func(n int) bool { return n == 8 })
+
+	// A burst the consumer does not keep up with grows the buffer to Max
+	const total = 200
+	go func() {
+		for i := 0; i < total; i++ {
+			transport.QueueResponse(CreateAssistantTextMessage("msg"))
+		}
+	}()
+	waitForBufferSize(t, metrics, "grow", func(n int) bool { return n == 64 })
+
+	for i := 0; i < total; i++ {
+		select {
+		case raw, ok := <-cc.Messages():
+			if !ok {
+				t.Fatalf("message channel closed after %d messages", i)
+			}
+			if raw["type"] != "assistant" {
+				t.Fatalf("unexpected message %v", raw)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out after %d messages", i)
+		}
+	}
+
+	// Once idle, the buffer shrinks back to Min
+	waitForBufferSize(t, metrics, "shrink", func(n int) bool { return n == 4 })
+}
+
+func TestAdaptiveBufferingDeliversBeforeError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	options := &claude.ClaudeAgentOptions{
+		AdaptiveBuffering: &claude.AdaptiveBuffering{Min: 4, Max: 16},
+	}
+	transport := NewAdvancedMockTransport()
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	cc := claude.NewControlClient(transport, options)
+	defer cc.Close()
+	if err := cc.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		transport.QueueResponse(CreateAssistantTextMessage("msg"))
+	}
+	// Let the messages reach the buffer before the error ends the stream
+	time.Sleep(50 * time.Millisecond)
+	transport.QueueError(claude.NewCLIConnectionError("stream broke", nil))
+
+	select {
+	case err := <-cc.Errors():
+		t.Fatalf("error overtook buffered messages: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	received := 0
+	for raw := range cc.Messages() {
+		if raw["type"] == "assistant" {
+			received++
+		}
+	}
+	if received != 3 {
+		t.Errorf("expected 3 messages, got %d", received)
+	}
+	if err := <-cc.Errors(); err == nil {
+		t.Error("expected the transport error after the messages")
+	}
+}
@@ -0,0 +1,135 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/claudetest"
+)
+
+// askPermission sends a can_use_tool request for command and returns the
+// SDK's response.
+func askPermission(t *testing.T, transport *claudetest.MockTransport, requestID, command string) map[string]interface{} {
+	t.Helper()
+	transport.SendControlRequest(requestID, map[string]interface{}{
+		"subtype":   "can_use_tool",
+		"tool_name": "Bash",
+		"input":     map[string]interface{}{"command": command},
+	})
+	response, ok := transport.WaitForControlResponse(requestID, time.Second)
+	if !ok {
+		t.Fatalf("expected a response to %s", requestID)
+	}
+	return response
+}
+
+func TestApproverResolvesAsk(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var requests []claude.ApprovalRequest
+	options := &claude.ClaudeAgentOptions{
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			return claude.PermissionResultAsk{Behavior: "ask", Message: "Run " + input["command"].(string) + "?"}, nil
+		},
+		Approver: claude.ApproverFunc(func(ctx context.Context, request claude.ApprovalRequest) (claude.ApprovalDecision, error) {
+			requests = append(requests, request)
+			if request.Input["command"] == "git push" {
+				return claude.ApprovalDecision{Approved: true, UpdatedInput: map[string]interface{}{"command": "git push --dry-run"}}, nil
+			}
+			return claude.ApprovalDecision{Message: "not today", Interrupt: true}, nil
+		}),
+	}
+	transport := claudetest.NewMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	allowed, _ := askPermission(t, transport, "perm_1", "git push")["response"].(map[string]interface{})
+	input, _ := allowed["updatedInput"].(map[string]interface{})
+	if allowed["behavior"] != "allow" || input["command"] != "git push --dry-run" {
+		t.Errorf("expected an allow with the approver's input, got %v", allowed)
+	}
+	denied, _ := askPermission(t, transport, "perm_2", "rm -rf build")["response"].(map[string]interface{})
+	if denied["behavior"] != "deny" || denied["message"] != "not today" || denied["interrupt"] != true {
+		t.Errorf("expected the approver's denial, got %v", denied)
+	}
+	if len(requests) != 2 || requests[0].Message != "Run git push?" || requests[0].RequestID != "perm_1" {
+		t.Errorf("unexpected approval requests: %+v", requests)
+	}
+}
+
+func TestAskWithoutApprover(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	options := &claude.ClaudeAgentOptions{
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			return claude.PermissionResultAsk{Behavior: "ask"}, nil
+		},
+	}
+	transport := claudetest.NewMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	response := askPermission(t, transport, "perm_1", "ls")
+	if response["subtype"] != "error" || !strings.Contains(response["error"].(string), "Approver") {
+		t.Errorf("expected an error naming the Approver option, got %v", response)
+	}
+}
+
+func TestTerminalApprover(t *testing.T) {
+	var out bytes.Buffer
+	approver := &claude.TerminalApprover{In: strings.NewReader("y\nno\n"), Out: &out}
+	request := claude.ApprovalRequest{ToolName: "Bash", Input: map[string]interface{}{"command": "make"}, Message: "Build?"}
+
+	first, err := approver.Approve(context.Background(), request)
+	if err != nil || !first.Approved {
+		t.Errorf("expected the first answer to approve, got %+v, %v", first, err)
+	}
+	second, err := approver.Approve(context.Background(), request)
+	if err != nil || second.Approved {
+		t.Errorf("expected the second answer to deny, got %+v, %v", second, err)
+	}
+	if !strings.Contains(out.String(), `Bash {"command":"make"}`) || !strings.Contains(out.String(), "Build?") {
+		t.Errorf("expected the prompt to show the tool use, got %q", out.String())
+	}
+	if _, err := approver.Approve(context.Background(), request); err == nil {
+		t.Error("expected an error once input ends")
+	}
+}
+
+func TestWebhookApprover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request claude.ApprovalRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || r.Header.Get("Authorization") != "Bearer t" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(claude.ApprovalDecision{Approved: request.ToolName == "Read"})
+	}))
+	defer server.Close()
+
+	approver := &claude.WebhookApprover{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer t"}}
+	decision, err := approver.Approve(context.Background(), claude.ApprovalRequest{ToolName: "Read"})
+	if err != nil || !decision.Approved {
+		t.Errorf("expected the webhook's approval, got %+v, %v", decision, err)
+	}
+
+	approver.Headers = nil
+	if _, err := approver.Approve(context.Background(), claude.ApprovalRequest{ToolName: "Read"}); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("expected the status to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/claudetest"
+)
+
+func TestAuditLogRecordsToolInvocations(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var entries []claude.AuditEntry
+	options := &claude.ClaudeAgentOptions{
+		AuditLog: &claude.AuditLog{
+			MaxResultBytes: 8,
+			Sink: claude.AuditFunc(func(entry claude.AuditEntry) error {
+				mu.Lock()
+				defer mu.Unlock()
+				entries = append(entries, entry)
+				return nil
+			}),
+		},
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			return claude.PermissionResultAllow{Behavior: "allow"}, nil
+		},
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventPreToolUse: {{Matcher: "Write", Hooks: []claude.HookCallback{
+				func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+					return claude.DenyToolUse("read-only session"), nil
+				},
+			}}},
+		},
+	}
+	transport := claudetest.NewMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+	callbackID := initializeCallbackID(t, transport, claude.HookEventPreToolUse)
+
+	msgCh, errCh := client.Query(ctx, "Tidy up")
+	bash := CreateAssistantToolUseMessage("", "tool-1", "Bash", map[string]interface{}{"command": "ls"})
+	bash["session_id"] = "s"
+	transport.QueueResponse(bash)
+	transport.SendControlRequest("perm_1", map[string]interface{}{
+		"subtype":   "can_use_tool",
+		"tool_name": "Bash",
+		"input":     map[string]interface{}{"command": "ls"},
+	})
+	if _, ok := transport.WaitForControlResponse("perm_1", time.Second); !ok {
+		t.Fatal("no response to the permission request")
+	}
+	transport.QueueResponse(claudetest.ToolResult("tool-1", "a.go\nb.go\nc.go", false))
+
+	transport.QueueResponse(CreateAssistantToolUseMessage("", "tool-2", "Write", map[string]interface{}{"file_path": "/x"}))
+	transport.SendControlRequest("hook_1", map[string]interface{}{
+		"subtype":     "hook_callback",
+		"callback_id": callbackID,
+		"tool_use_id": "tool-2",
+		"input":       map[string]interface{}{"hook_event_name": "PreToolUse", "tool_name": "Write", "tool_input": map[string]interface{}{"file_path": "/x"}},
+	})
+	if _, ok := transport.WaitForControlResponse("hook_1", time.Second); !ok {
+		t.Fatal("no response to the hook callback")
+	}
+	transport.QueueResponse(CreateResultMessage("s", 0.01, 100))
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %+v", entries)
+	}
+	bashEntry, writeEntry := entries[0], entries[1]
+	if bashEntry.ToolName != "Bash" || bashEntry.SessionID != "s" || bashEntry.Input["command"] != "ls" || bashEntry.Decision != "allow" {
+		t.Errorf("unexpected Bash entry: %+v", bashEntry)
+	}
+	if bashEntry.Result != "a.go\nb.g" || !bashEntry.ResultTruncated || bashEntry.Incomplete {
+		t.Errorf("expected a truncated result, got %q (truncated %v)", bashEntry.Result, bashEntry.ResultTruncated)
+	}
+	want := claude.AuditHookOutcome{Event: "PreToolUse", Decision: "deny", Reason: "read-only session"}
+	if writeEntry.ToolName != "Write" || !writeEntry.Incomplete || len(writeEntry.Hooks) != 1 || writeEntry.Hooks[0] != want {
+		t.Errorf("expected the hook's denial on an incomplete entry, got %+v", writeEntry)
+	}
+}
+
+func TestAuditWriterAndFile(t *testing.T) {
+	var buf bytes.Buffer
+	entry := claude.AuditEntry{ToolUseID: "tool-1", ToolName: "Read", DurationMS: 12}
+	if err := claude.NewAuditWriter(&buf).Record(entry); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	var decoded claude.AuditEntry
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.ToolName != "Read" || decoded.DurationMS != 12 {
+		t.Errorf("expected a JSON line, got %q (%v)", buf.String(), err)
+	}
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	for i := 0; i < 2; i++ {
+		file, err := claude.OpenAuditFile(path)
+		if err != nil {
+			t.Fatalf("OpenAuditFile failed: %v", err)
+		}
+		if err := file.Record(entry); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+		file.Close()
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 {
+		t.Errorf("expected entries to be appended, got %q", data)
+	}
+}
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func authFailureMessages() []map[string]interface{} {
+	assistant := CreateAssistantTextMessage("Invalid API key · Please run /login")
+	assistant["error"] = "authentication_failed"
+	result := CreateResultMessage("s1", 0, 10)
+	result["is_error"] = true
+	result["result"] = "Invalid API key · Please run /login"
+	return []map[string]interface{}{assistant, result}
+}
+
+func TestQueryReturnsAuthenticationError(t *testing.T) {
+	ctx := context.Background()
+
+	msgCh, errCh, err := claude.Query(ctx, "Hello", nil, NewMockTransport(authFailureMessages()))
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	messages, err := CollectMessages(msgCh, errCh)
+	if len(messages) != 2 {
+		t.Errorf("Expected both messages to be delivered, got %d", len(messages))
+	}
+
+	var authErr *claude.AuthenticationError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("Expected AuthenticationError, got %v", err)
+	}
+	if authErr.Source != claude.AuthErrorSourceAssistant {
+		t.Errorf("Expected source %q, got %q", claude.AuthErrorSourceAssistant, authErr.Source)
+	}
+	if authErr.Recovered {
+		t.Error("Expected Recovered to be false without a callback")
+	}
+}
+
+func TestQueryAuthenticationCallback(t *testing.T) {
+	ctx := context.Background()
+
+	calls := 0
+	options := &claude.ClaudeAgentOptions{
+		OnAuthenticationError: func(ctx context.Context, err *claude.AuthenticationError) error {
+			calls++
+			return nil
+		},
+	}
+
+	msgCh, errCh, err := claude.Query(ctx, "Hello", options, NewMockTransport(authFailureMessages()))
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	_, err = CollectMessages(msgCh, errCh)
+	var authErr *claude.AuthenticationError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("Expected AuthenticationError, got %v", err)
+	}
+	if !authErr.Recovered {
+		t.Error("Expected Recovered after successful callback")
+	}
+	if calls != 1 {
+		t.Errorf("Expected callback to run once, ran %d times", calls)
+	}
+}
+
+func TestQueryWithoutAuthFailureHasNoError(t *testing.T) {
+	ctx := context.Background()
+
+	result := CreateResultMessage("s1", 0, 10)
+	result["is_error"] = true
+	result["result"] = "Tool execution failed"
+
+	msgCh, errCh, err := claude.Query(ctx, "Hello", nil, NewMockTransport([]map[string]interface{}{result}))
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Errorf("Expected no error for non-auth failure, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// waitForSetModel returns the model of the first set_model request written
+// to transport, or "" on timeout.
+func waitForSetModel(transport *AdvancedMockTransport, timeout time.Duration) string {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		for _, data := range transport.GetWrittenMessages() {
+			var msg map[string]interface{}
+			if json.Unmarshal([]byte(data), &msg) != nil {
+				continue
+			}
+			request, _ := msg["request"].(map[string]interface{})
+			if request["subtype"] == "set_model" {
+				model, _ := request["model"].(string)
+				return model
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return ""
+}
+
+func TestBudgetStrategyDowngradesThenStops(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sink := &memorySink{}
+	options := &claude.ClaudeAgentOptions{
+		EventSink: sink,
+		BudgetStrategy: &claude.BudgetStrategy{
+			Steps: []claude.BudgetStep{
+				{AtUSD: 10, Model: "claude-haiku-4-5"},
+				{AtUSD: 5, Model: "claude-sonnet-4-5"},
+			},
+			HardCapUSD: 15,
+		},
+	}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	msgCh := client.ReceiveMessages(ctx)
+
+	// Crossing the first step switches to its model
+	transport.QueueResponse(CreateResultMessage("s", 6, 1000))
+	if _, ok := (<-msgCh).(*claude.ResultMessage); !ok {
+		t.Fatal("expected ResultMessage")
+	}
+	if model := waitForSetModel(transport, 2*time.Second); model != "claude-sonnet-4-5" {
+		t.Fatalf("expected set_model to claude-sonnet-4-5, got %q", model)
+	}
+	if downgrades := sink.byType(claude.EventTypeBudgetDowngrade); len(downgrades) != 1 {
+		t.Errorf("expected one budget downgrade event, got %d", len(downgrades))
+	}
+
+	// Reaching the hard cap ends the stream after the ResultMessage
+	transport.QueueResponse(CreateResultMessage("s", 15.5, 1000))
+	if _, ok := (<-msgCh).(*claude.ResultMessage); !ok {
+		t.Fatal("expected the ResultMessage that crossed the cap")
+	}
+	if _, ok := <-msgCh; ok {
+		t.Fatal("expected the stream to end at the hard cap")
+	}
+
+	var budgetErr *claude.BudgetExceededError
+	if !errors.As(client.Err(), &budgetErr) || budgetErr.CapUSD != 15 {
+		t.Fatalf("expected BudgetExceededError from Err(), got %v", client.Err())
+	}
+	if err := client.QueryWithSession(ctx, "more", "default"); !errors.As(err, &budgetErr) {
+		t.Errorf("expected further queries to be refused, got %v", err)
+	}
+}
+
+func TestBudgetDowngradeSurvivesRestart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	options := &claude.ClaudeAgentOptions{
+		BudgetStrategy: &claude.BudgetStrategy{
+			Steps: []claude.BudgetStep{{AtUSD: 5, Model: "claude-haiku-4-5"}},
+		},
+	}
+	transport := &reconnectableTransport{}
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	msgCh, errCh := client.Query(ctx, "Hello")
+	transport.active().QueueResponse(CreateResultMessage("s", 6, 1000))
+	if err := drainQuery(msgCh, errCh); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if model := waitForSetModel(transport.active(), 2*time.Second); model != "claude-haiku-4-5" {
+		t.Fatalf("expected set_model to claude-haiku-4-5, got %q", model)
+	}
+
+	// The restarted CLI starts on the configured model; switch it back
+	if err := client.SetSettingSources(ctx, []claude.SettingSource{claude.SettingSourceProject}); err != nil {
+		t.Fatalf("SetSettingSources failed: %v", err)
+	}
+	if model := waitForSetModel(transport.active(), 2*time.Second); model != "claude-haiku-4-5" {
+		t.Errorf("expected the restarted session to be set to claude-haiku-4-5, got %q", model)
+	}
+}
+
+// drainQuery consumes a query's messages and returns its error.
+func drainQuery(msgCh <-chan claude.Message, errCh <-chan error) error {
+	for range msgCh {
+	}
+	return <-errCh
+}
+
+func TestBudgetLimitsPerQueryAndSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var warnings []error
+	options := &claude.ClaudeAgentOptions{
+		BudgetLimits: &claude.BudgetLimits{QueryUSD: 1, SessionUSD: 2},
+		OnError: func(err error, severity claude.ErrorSeverity) {
+			if severity == claude.ErrorSeverityWarning {
+				warnings = append(warnings, err)
+			}
+		},
+	}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// The first query exceeds the per-query limit but the session goes on
+	msgCh, errCh := client.Query(ctx, "one")
+	transport.QueueResponse(CreateResultMessage("s", 1.5, 1000))
+	var budgetErr *claude.BudgetExceededError
+	if err := drainQuery(msgCh, errCh); !errors.As(err, &budgetErr) || budgetErr.Scope != claude.BudgetScopeQuery {
+		t.Fatalf("expected query budget error, got %v", err)
+	}
+	if budgetErr.ResultSubtype() != "error_query_budget_usd" {
+		t.Errorf("unexpected result subtype %q", budgetErr.ResultSubtype())
+	}
+	if len(warnings) != 1 {
+		t.Errorf("expected the query budget error as a warning, got %v", warnings)
+	}
+
+	// The CLI reports cumulative cost; this query costs $0.60
+	msgCh, errCh = client.Query(ctx, "two")
+	transport.QueueResponse(CreateResultMessage("s", 2.1, 1000))
+	if err := drainQuery(msgCh, errCh); !errors.As(err, &budgetErr) || budgetErr.Scope != claude.BudgetScopeSession {
+		t.Fatalf("expected session budget error, got %v", err)
+	}
+	if err := client.QueryWithSession(ctx, "three", "default"); !errors.As(err, &budgetErr) {
+		t.Errorf("expected further queries to be refused, got %v", err)
+	}
+}
+
+func TestBudgetLimitsDailyAcrossQueries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	options := &claude.ClaudeAgentOptions{
+		BudgetLimits: &claude.BudgetLimits{DailyUSD: 1, Store: claude.NewMemoryBudgetStore()},
+	}
+	runQuery := func(cost float64) error {
+		transport := NewMockTransport([]map[string]interface{}{CreateResultMessage("s", cost, 1000)})
+		msgCh, errCh, err := claude.Query(ctx, "hi", options, transport)
+		if err != nil {
+			return err
+		}
+		return drainQuery(msgCh, errCh)
+	}
+
+	if err := runQuery(0.6); err != nil {
+		t.Fatalf("first query failed: %v", err)
+	}
+	var budgetErr *claude.BudgetExceededError
+	if err := runQuery(0.6); !errors.As(err, &budgetErr) || budgetErr.Scope != claude.BudgetScopeDaily {
+		t.Fatalf("expected daily budget error, got %v", err)
+	}
+	if err := runQuery(0.1); !errors.As(err, &budgetErr) || budgetErr.CostUSD < 1.2 {
+		t.Errorf("expected new queries to be refused for the day, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestCanUseToolTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// The callback ignores its context, as a stalled one would
+	stalled := make(chan struct{})
+	defer close(stalled)
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		CanUseToolTimeout: 50 * time.Millisecond,
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			if toolName == "Bash" {
+				<-stalled
+			}
+			return claude.PermissionResultAllow{}, nil
+		},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	permissionRequest := func(requestID, toolName string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":       "control_request",
+			"request_id": requestID,
+			"request": map[string]interface{}{
+				"subtype":   "can_use_tool",
+				"tool_name": toolName,
+				"input":     map[string]interface{}{},
+			},
+		}
+	}
+
+	transport.QueueResponse(permissionRequest("perm_slow", "Bash"))
+	response, ok := transport.WaitForControlResponse("perm_slow", time.Second)
+	if !ok {
+		t.Fatal("Expected a control response despite the stalled callback")
+	}
+	if message, _ := response["error"].(string); response["subtype"] != "error" || !strings.Contains(message, "can_use_tool callback timed out after 50ms") {
+		t.Errorf("Expected a timeout error, got %v", response)
+	}
+
+	transport.QueueResponse(permissionRequest("perm_fast", "Read"))
+	response, ok = transport.WaitForControlResponse("perm_fast", time.Second)
+	if !ok || response["subtype"] != "success" {
+		t.Errorf("Expected callbacks within the timeout to succeed, got %v", response)
+	}
+}
+
+func TestHookTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		HookTimeout: 50 * time.Millisecond,
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventPreToolUse: {{Hooks: []claude.HookCallback{
+				func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+					<-ctx.Done()
+					return claude.HookJSONOutput{}, ctx.Err()
+				},
+			}}},
+		},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	transport.QueueResponse(dynamicHookRequest("hook_slow", initializeCallbackID(t, transport, claude.HookEventPreToolUse), "Bash"))
+	response, ok := transport.WaitForControlResponse("hook_slow", time.Second)
+	if !ok {
+		t.Fatal("Expected a control response for the timed out hook")
+	}
+	if message, _ := response["error"].(string); response["subtype"] != "error" || !strings.Contains(message, "hook_callback callback timed out") {
+		t.Errorf("Expected a timeout error, got %v", response)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"sync"
 	"testing"
+	"time"
 
 	claude "github.com/clsx524/claude-agent-sdk-go"
 )
@@ -561,3 +562,76 @@ func TestCallbackExceptionHandling(t *testing.T) {
 		t.Errorf("Expected error message 'Hook callback error', got '%s'", hookErr.Message)
 	}
 }
+
+func TestPermissionCallbackReceivesSuggestions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	suggestionsCh := make(chan []claude.PermissionUpdate, 1)
+	options := &claude.ClaudeAgentOptions{
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			suggestionsCh <- permCtx.Suggestions
+			return claude.PermissionResultAllow{
+				Behavior:           "allow",
+				UpdatedPermissions: permCtx.Suggestions,
+			}, nil
+		},
+	}
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	transport.QueueResponse(map[string]interface{}{
+		"type":       "control_request",
+		"request_id": "perm_1",
+		"request": map[string]interface{}{
+			"subtype":   "can_use_tool",
+			"tool_name": "Bash",
+			"input":     map[string]interface{}{"command": "git status"},
+			"permission_suggestions": []interface{}{
+				map[string]interface{}{
+					"type":        "addRules",
+					"behavior":    "allow",
+					"destination": "localSettings",
+					"rules": []interface{}{
+						map[string]interface{}{"toolName": "Bash", "ruleContent": "git status"},
+					},
+				},
+				map[string]interface{}{"type": "somethingNew"},
+			},
+		},
+	})
+
+	select {
+	case suggestions := <-suggestionsCh:
+		if len(suggestions) != 1 {
+			t.Fatalf("Expected 1 parsed suggestion, got %d", len(suggestions))
+		}
+		if suggestions[0].Type != claude.PermissionUpdateTypeAddRules {
+			t.Errorf("Expected addRules, got %s", suggestions[0].Type)
+		}
+		if suggestions[0].Rules[0].ToolName != "Bash" {
+			t.Errorf("Expected Bash rule, got %+v", suggestions[0].Rules[0])
+		}
+	case <-ctx.Done():
+		t.Fatal("Timeout waiting for permission callback")
+	}
+
+	response, ok := transport.WaitForControlResponse("perm_1", time.Second)
+	if !ok {
+		t.Fatal("Expected control response for permission request")
+	}
+	inner, _ := response["response"].(map[string]interface{})
+	updates, _ := inner["updatedPermissions"].([]interface{})
+	if len(updates) != 1 {
+		t.Fatalf("Expected suggestion echoed as updatedPermissions, got %v", inner["updatedPermissions"])
+	}
+	rule := updates[0].(map[string]interface{})["rules"].([]interface{})[0].(map[string]interface{})
+	if rule["toolName"] != "Bash" || rule["ruleContent"] != "git status" {
+		t.Errorf("Expected camelCase rule keys on the wire, got %v", rule)
+	}
+}
@@ -0,0 +1,241 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// sdkGoroutines returns the stacks of running goroutines in SDK code, by
+// goroutine header.
+func sdkGoroutines() map[string]string {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	goroutines := make(map[string]string)
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		header, _, _ := strings.Cut(stack, "\n")
+		fields := strings.Fields(header)
+		if len(fields) > 1 && strings.Contains(stack, "claude-agent-sdk-go.") {
+			goroutines[fields[1]] = stack
+		}
+	}
+	return goroutines
+}
+
+// verifyNoLeaks fails t if SDK goroutines started during the test are still
+// running once it and its deferred calls are done.
+func verifyNoLeaks(t *testing.T) {
+	t.Helper()
+	before := sdkGoroutines()
+	t.Cleanup(func() {
+		var leaked []string
+		for deadline := time.Now().Add(2 * time.Second); ; {
+			leaked = leaked[:0]
+			for id, stack := range sdkGoroutines() {
+				if _, ok := before[id]; !ok {
+					leaked = append(leaked, stack)
+				}
+			}
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		for _, stack := range leaked {
+			t.Errorf("leaked goroutine:\n%s", stack)
+		}
+	})
+}
+
+// interruptsSent counts the interrupt requests written to transport.
+func interruptsSent(transport *AdvancedMockTransport) int {
+	n := 0
+	for _, data := range transport.GetWrittenMessages() {
+		if strings.Contains(data, `"subtype":"interrupt"`) {
+			n++
+		}
+	}
+	return n
+}
+
+// waitClosed collects the messages left on a canceled query's channels,
+// failing t if they do not close promptly.
+func waitClosed(t *testing.T, msgCh <-chan claude.Message, errCh <-chan error) error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		_, err := CollectMessages(msgCh, errCh)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("the canceled query's channels did not close")
+		return nil
+	}
+}
+
+func TestQueryCancelInterruptsTurn(t *testing.T) {
+	for _, behavior := range []claude.CancelBehavior{claude.CancelInterrupt, claude.CancelDrain} {
+		t.Run(string(behavior), func(t *testing.T) {
+			verifyNoLeaks(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			prompts := make(chan map[string]interface{}, 1)
+			prompts <- claude.NewUserMessage("Analyze the whole repository")
+			transport := NewAdvancedMockTransport()
+			options := &claude.ClaudeAgentOptions{CancelBehavior: behavior}
+			msgCh, errCh, err := claude.QueryStream(ctx, prompts, options, transport)
+			if err != nil {
+				t.Fatalf("QueryStream failed: %v", err)
+			}
+			transport.QueueResponse(CreateAssistantTextMessage("Reading files..."))
+			if msg := <-msgCh; msg == nil {
+				t.Fatal("expected the first message")
+			}
+
+			cancel()
+			if err := waitClosed(t, msgCh, errCh); !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled, got %v", err)
+			}
+
+			// The turn is interrupted, then the CLI stopped, in the background
+			for deadline := time.Now().Add(time.Second); transport.IsReady() && time.Now().Before(deadline); {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if transport.IsReady() {
+				t.Error("expected the transport to be closed")
+			}
+			want := 1
+			if behavior == claude.CancelDrain {
+				want = 0
+			}
+			if got := interruptsSent(transport); got != want {
+				t.Errorf("expected %d interrupt requests, got %d", want, got)
+			}
+		})
+	}
+}
+
+func TestClientQueryCancelDiscardsTurn(t *testing.T) {
+	verifyNoLeaks(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	queryCtx, cancelQuery := context.WithCancel(ctx)
+	msgCh, errCh := client.Query(queryCtx, "Analyze the whole repository")
+	transport.QueueResponse(CreateAssistantTextMessage("Reading files..."))
+	<-msgCh
+	cancelQuery()
+	if err := waitClosed(t, msgCh, errCh); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// A query canceled before its turn comes is discarded too
+	waitingCtx, cancelWaiting := context.WithCancel(ctx)
+	waitingMsgCh, waitingErrCh := client.Query(waitingCtx, "Then write a report")
+	cancelWaiting()
+	if err := waitClosed(t, waitingMsgCh, waitingErrCh); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// The rest of both turns arrives after the cancellations
+	transport.QueueResponse(CreateAssistantTextMessage("stale"))
+	transport.QueueResponse(CreateResultMessage("s1", 0.01, 100))
+	transport.QueueResponse(CreateResultMessage("s1", 0.02, 100))
+	transport.QueueResponse(CreateAssistantTextMessage("fresh"))
+	transport.QueueResponse(CreateResultMessage("s1", 0.03, 100))
+
+	messages, err := CollectMessages(client.Query(ctx, "Summarize"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected the next query's 2 messages, got %d: %+v", len(messages), messages[0])
+	}
+	if text := messages[0].(*claude.AssistantMessage).Content[0].(claude.TextBlock).Text; text != "fresh" {
+		t.Errorf("expected the next query's reply, got %q", text)
+	}
+	if got := interruptsSent(transport); got != 2 {
+		t.Errorf("expected both canceled turns to be interrupted, got %d", got)
+	}
+}
+
+func TestSessionQueryCancelDiscardsTurn(t *testing.T) {
+	verifyNoLeaks(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// The running query is interrupted as soon as it is canceled
+	runningCtx, cancelRunning := context.WithCancel(ctx)
+	runningMsgCh, runningErrCh := client.Session("running").Query(runningCtx, "Analyze the whole repository")
+	waitingCtx, cancelWaiting := context.WithCancel(ctx)
+	waitingMsgCh, waitingErrCh := client.Session("waiting").Query(waitingCtx, "Then write a report")
+	nextMsgCh, nextErrCh := client.Session("next").Query(ctx, "Summarize")
+	cancelRunning()
+	if err := waitClosed(t, runningMsgCh, runningErrCh); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	waitForInterrupts(t, transport, 1)
+
+	// A query canceled before its turn comes is interrupted once it runs
+	cancelWaiting()
+	if err := waitClosed(t, waitingMsgCh, waitingErrCh); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if got := interruptsSent(transport); got != 1 {
+		t.Errorf("expected a queued turn not to be interrupted yet, got %d interrupts", got)
+	}
+
+	// Interrupted turns still end with a ResultMessage, untagged here
+	transport.QueueResponse(CreateAssistantTextMessage("stale"))
+	transport.QueueResponse(CreateResultMessageWithSubtype("cli-session", "error_during_execution", 0.01, 100))
+	waitForInterrupts(t, transport, 2)
+	transport.QueueResponse(CreateResultMessageWithSubtype("cli-session", "error_during_execution", 0.02, 100))
+	transport.QueueResponse(CreateAssistantTextMessage("fresh"))
+	transport.QueueResponse(CreateResultMessage("cli-session", 0.03, 100))
+
+	messages, err := CollectMessages(nextMsgCh, nextErrCh)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected the next query's 2 messages, got %d: %+v", len(messages), messages[0])
+	}
+	if text := messages[0].(*claude.AssistantMessage).Content[0].(claude.TextBlock).Text; text != "fresh" {
+		t.Errorf("expected the next query's reply, got %q", text)
+	}
+}
+
+// waitForInterrupts waits until n interrupt requests were written to
+// transport, failing t if they are not sent promptly.
+func waitForInterrupts(t *testing.T, transport *AdvancedMockTransport, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); interruptsSent(transport) < n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d interrupt requests, got %d", n, interruptsSent(transport))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
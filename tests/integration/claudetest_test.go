@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/claudetest"
+)
+
+func TestClaudetestConversation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := claudetest.NewMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+	transport.RequireControlRequest(t, "initialize")
+
+	msgCh, errCh := client.Query(ctx, "List the files")
+	transport.QueueResponse(claudetest.AssistantToolUse("Listing", "tool_1", "Bash", map[string]interface{}{"command": "ls"}))
+	transport.QueueResponse(claudetest.ToolResult("tool_1", "main.go", false))
+	transport.QueueResponse(claudetest.AssistantText("One file: main.go"))
+	transport.QueueResponse(claudetest.Result("session-1", 0.02, 900))
+
+	messages, err := CollectMessages(msgCh, errCh)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(messages))
+	}
+	user, ok := messages[1].(*claude.UserMessage)
+	if !ok {
+		t.Fatalf("expected the tool result as a UserMessage, got %T", messages[1])
+	}
+	blocks, _ := user.Content.([]claude.ContentBlock)
+	if result, ok := blocks[0].(claude.ToolResultBlock); !ok || result.ToolUseID != "tool_1" {
+		t.Errorf("expected a result for tool_1, got %+v", user.Content)
+	}
+	if result, ok := messages[3].(*claude.ResultMessage); !ok || result.SessionID != "session-1" {
+		t.Errorf("expected the result message last, got %+v", messages[3])
+	}
+
+	prompts := transport.UserMessages()
+	if len(prompts) != 1 || !strings.Contains(prompts[0]["message"].(map[string]interface{})["content"].(string), "List the files") {
+		t.Errorf("expected the prompt to be written, got %v", prompts)
+	}
+}
+
+func TestClaudetestSimulatedControlResponses(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := claudetest.NewMockTransport()
+	transport.SetControlError("set_model", "unknown model")
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.SetModel(ctx, "claude-nonexistent"); err == nil || !strings.Contains(err.Error(), "unknown model") {
+		t.Errorf("expected the simulated error, got %v", err)
+	}
+	request := transport.RequireControlRequest(t, "set_model")
+	if inner, _ := request["request"].(map[string]interface{}); inner["model"] != "claude-nonexistent" {
+		t.Errorf("expected the model in the request, got %v", request)
+	}
+
+	if err := client.Interrupt(ctx); err != nil {
+		t.Errorf("expected interrupts to be answered automatically, got %v", err)
+	}
+	if len(transport.ControlRequests("")) != 3 {
+		t.Errorf("expected initialize, set_model, and interrupt, got %v", transport.ControlRequests(""))
+	}
+}
+
+func TestClaudetestSendControlRequest(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	options := &claude.ClaudeAgentOptions{
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			return claude.PermissionResultDeny{Behavior: "deny", Message: "no " + toolName}, nil
+		},
+	}
+	transport := claudetest.NewMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	transport.SendControlRequest("perm_1", map[string]interface{}{
+		"subtype":   "can_use_tool",
+		"tool_name": "Bash",
+		"input":     map[string]interface{}{"command": "rm -rf /"},
+	})
+	response, ok := transport.WaitForControlResponse("perm_1", time.Second)
+	if !ok {
+		t.Fatal("expected a response to the permission request")
+	}
+	inner, _ := response["response"].(map[string]interface{})
+	if inner["behavior"] != "deny" || inner["message"] != "no Bash" {
+		t.Errorf("expected the callback's denial, got %v", response)
+	}
+}
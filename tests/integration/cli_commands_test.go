@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// cliCommandsFakeCLI answers the auxiliary commands with the CLI's output.
+const cliCommandsFakeCLI = `case "$*" in
+"mcp list")
+  echo "Checking MCP server health..."
+  echo
+  echo "github: npx -y @modelcontextprotocol/server-github - ✓ Connected"
+  echo "sentry: https://mcp.sentry.dev/mcp (HTTP) - ✗ Failed to connect"
+  ;;
+doctor)
+  printf '\033[1m Diagnostics\033[0m\n'
+  echo " └ Currently running: npm-global (2.0.14)"
+  echo " └ Path: /usr/local/bin/node"
+  echo " └ Auto-updates: default (true)"
+  echo " ⚠ Multiple installations found"
+  ;;
+"config get --global theme")
+  echo "dark"
+  ;;
+*)
+  echo "unknown command: $*" >&2
+  exit 2
+  ;;
+esac
+`
+
+func TestListMCPServers(t *testing.T) {
+	cliPath := writeFakeCLI(t, cliCommandsFakeCLI)
+	servers, err := claude.ListMCPServers(context.Background(), cliPath)
+	if err != nil {
+		t.Fatalf("ListMCPServers failed: %v", err)
+	}
+	want := []claude.MCPServerStatus{
+		{Name: "github", Target: "npx -y @modelcontextprotocol/server-github", Transport: "stdio", Status: "Connected", Connected: true},
+		{Name: "sentry", Target: "https://mcp.sentry.dev/mcp", Transport: "http", Status: "Failed to connect"},
+	}
+	if len(servers) != len(want) {
+		t.Fatalf("expected %d servers, got %+v", len(want), servers)
+	}
+	for i := range want {
+		if servers[i] != want[i] {
+			t.Errorf("server %d: expected %+v, got %+v", i, want[i], servers[i])
+		}
+	}
+}
+
+func TestRunDoctor(t *testing.T) {
+	cliPath := writeFakeCLI(t, cliCommandsFakeCLI)
+	report, err := claude.RunDoctor(context.Background(), cliPath)
+	if err != nil {
+		t.Fatalf("RunDoctor failed: %v", err)
+	}
+	if report.Version != "2.0.14" {
+		t.Errorf("expected version 2.0.14, got %q", report.Version)
+	}
+	if got := report.Get("auto-updates"); got != "default (true)" {
+		t.Errorf("unexpected Auto-updates entry %q", got)
+	}
+	if len(report.Entries) != 3 {
+		t.Errorf("expected 3 entries, got %+v", report.Entries)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0] != "Multiple installations found" {
+		t.Errorf("unexpected warnings %q", report.Warnings)
+	}
+}
+
+func TestGetCLIConfig(t *testing.T) {
+	cliPath := writeFakeCLI(t, cliCommandsFakeCLI)
+	value, err := claude.GetCLIConfig(context.Background(), cliPath, "theme", true)
+	if err != nil || value != "dark" {
+		t.Fatalf("expected dark, got %q, %v", value, err)
+	}
+
+	_, err = claude.GetCLIConfig(context.Background(), cliPath, "theme", false)
+	var processErr *claude.ProcessError
+	if !errors.As(err, &processErr) || processErr.ExitCode != 2 || processErr.Stderr != "unknown command: config get theme" {
+		t.Errorf("expected a ProcessError with the CLI's stderr, got %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestClientTracksCompaction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	historySize := 10
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		HistorySize:    &historySize,
+		RecordTimeline: true,
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.QueryWithSession(ctx, "first", "default"); err != nil {
+		t.Fatalf("QueryWithSession failed: %v", err)
+	}
+	transport.QueueResponse(CreateAssistantTextMessage("first answer"))
+	transport.QueueResponse(CreateResultMessage("s", 0.01, 1000))
+	for range client.ReceiveResponse(ctx) {
+	}
+
+	if _, ok := client.CompactionSummary(); ok {
+		t.Error("expected no compaction summary before compacting")
+	}
+
+	if err := client.QueryWithSession(ctx, "/compact", "default"); err != nil {
+		t.Fatalf("QueryWithSession failed: %v", err)
+	}
+	transport.QueueResponse(map[string]interface{}{
+		"type":             "system",
+		"subtype":          "compact_boundary",
+		"session_id":       "s",
+		"compact_metadata": map[string]interface{}{"trigger": "manual", "pre_tokens": 12000.0},
+	})
+	transport.QueueResponse(map[string]interface{}{
+		"type":       "user",
+		"session_id": "s",
+		"message":    map[string]interface{}{"role": "user", "content": "Summary: the user asked a question."},
+	})
+	transport.QueueResponse(CreateResultMessage("s", 0.01, 1000))
+	for range client.ReceiveResponse(ctx) {
+	}
+
+	compactions := client.Compactions()
+	if len(compactions) != 1 || compactions[0].Trigger != "manual" || compactions[0].PreTokens != 12000 {
+		t.Fatalf("unexpected compactions: %+v", compactions)
+	}
+	if summary, ok := client.CompactionSummary(); !ok || summary != "Summary: the user asked a question." {
+		t.Errorf("unexpected summary %q (ok=%v)", summary, ok)
+	}
+
+	entries := client.HistoryEntries()
+	if len(entries) != 5 {
+		t.Fatalf("expected 5 history entries, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		if want := i < 2; entry.Compacted != want {
+			t.Errorf("entry %d (%T): expected Compacted=%v", i, entry.Message, want)
+		}
+	}
+	if context := client.ContextHistory(); len(context) != 3 {
+		t.Errorf("expected 3 messages since the compaction, got %d", len(context))
+	}
+
+	found := false
+	for _, event := range client.Timeline().Events {
+		if event.Kind == claude.TimelineEventCompaction {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected a compaction timeline event")
+	}
+}
+
+func TestClientCompact(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	hookInputs := make(chan *claude.PreCompactHookInput, 1)
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventPreCompact: {{Hooks: []claude.HookCallback{
+				claude.PreCompactHook(func(ctx context.Context, input *claude.PreCompactHookInput, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+					hookInputs <- input
+					return claude.HookJSONOutput{}, nil
+				}),
+			}}},
+		},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+	callbackID := initializeCallbackID(t, transport, claude.HookEventPreCompact)
+
+	compacted := make(chan *claude.Compaction, 1)
+	compactErr := make(chan error, 1)
+	go func() {
+		compaction, err := client.Compact(ctx, "Keep the failing tests")
+		compacted <- compaction
+		compactErr <- err
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for len(transport.UserMessages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	prompts := transport.UserMessages()
+	if len(prompts) != 1 {
+		t.Fatalf("expected the compact command to be sent, got %v", prompts)
+	}
+	if content := prompts[0]["message"].(map[string]interface{})["content"]; content != "/compact Keep the failing tests" {
+		t.Errorf("unexpected command %q", content)
+	}
+
+	transport.SendControlRequest("hook_1", map[string]interface{}{
+		"subtype":     "hook_callback",
+		"callback_id": callbackID,
+		"input": map[string]interface{}{
+			"hook_event_name":     "PreCompact",
+			"session_id":          "s",
+			"trigger":             "manual",
+			"custom_instructions": "Keep the failing tests",
+		},
+	})
+	select {
+	case input := <-hookInputs:
+		if input.Trigger != "manual" || input.CustomInstructions == nil || *input.CustomInstructions != "Keep the failing tests" || input.SessionID != "s" {
+			t.Errorf("unexpected typed hook input: %+v", input)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the PreCompact hook")
+	}
+
+	transport.QueueResponse(map[string]interface{}{
+		"type":             "system",
+		"subtype":          "compact_boundary",
+		"session_id":       "s",
+		"compact_metadata": map[string]interface{}{"trigger": "manual", "pre_tokens": 90000.0},
+	})
+	transport.QueueResponse(map[string]interface{}{
+		"type":       "user",
+		"session_id": "s",
+		"message":    map[string]interface{}{"role": "user", "content": "Summary: two tests fail."},
+	})
+	transport.QueueResponse(CreateResultMessage("s", 0.01, 1000))
+
+	compaction := <-compacted
+	if err := <-compactErr; err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if compaction.Trigger != "manual" || compaction.PreTokens != 90000 || compaction.Summary != "Summary: two tests fail." {
+		t.Errorf("unexpected compaction: %+v", compaction)
+	}
+
+	// A command that does not compact
+	go func() {
+		compaction, err := client.Compact(ctx, "")
+		compacted <- compaction
+		compactErr <- err
+	}()
+	transport.QueueResponse(CreateResultMessage("s", 0.01, 1000))
+	if <-compacted != nil || !errors.Is(<-compactErr, claude.ErrNotCompacted) {
+		t.Error("expected ErrNotCompacted")
+	}
+	prompts = transport.UserMessages()
+	if content := prompts[len(prompts)-1]["message"].(map[string]interface{})["content"]; content != "/compact" {
+		t.Errorf("expected a bare /compact, got %q", content)
+	}
+}
+
+func TestAutoCompactEnvironment(t *testing.T) {
+	cliPath := writeFakeCLI(t, `echo "{\"type\":\"result\",\"subtype\":\"success\",\"duration_ms\":1,\"duration_api_ms\":1,\"is_error\":false,\"num_turns\":1,\"session_id\":\"s\",\"result\":\"$DISABLE_AUTO_COMPACT:$CLAUDE_AUTOCOMPACT_PCT_OVERRIDE\"}"`+"\n")
+	t.Setenv("PATH", filepath.Dir(cliPath)+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	run := func(config *claude.AutoCompactConfig) (string, error) {
+		t.Helper()
+		msgCh, errCh, err := claude.Query(context.Background(), "hi", &claude.ClaudeAgentOptions{AutoCompact: config}, nil)
+		if err != nil {
+			return "", err
+		}
+		messages, err := CollectMessages(msgCh, errCh)
+		if err != nil || len(messages) != 1 {
+			t.Fatalf("unexpected query outcome: %v, %d messages", err, len(messages))
+		}
+		return *messages[0].(*claude.ResultMessage).Result, nil
+	}
+
+	if env, err := run(&claude.AutoCompactConfig{ThresholdPercent: 60}); err != nil || env != ":60" {
+		t.Errorf("expected the threshold, got %q (%v)", env, err)
+	}
+	if env, err := run(&claude.AutoCompactConfig{Disabled: true}); err != nil || env != "1:" {
+		t.Errorf("expected auto-compaction disabled, got %q (%v)", env, err)
+	}
+	if _, err := run(&claude.AutoCompactConfig{ThresholdPercent: 150}); err == nil || !strings.Contains(err.Error(), "percentage") {
+		t.Errorf("expected an invalid threshold to be rejected, got %v", err)
+	}
+}
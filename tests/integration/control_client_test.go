@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestControlClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var permissionCalls atomic.Int32
+	options := &claude.ClaudeAgentOptions{
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			permissionCalls.Add(1)
+			return claude.PermissionResultAllow{Behavior: "allow"}, nil
+		},
+	}
+	transport := NewAdvancedMockTransport()
+	transport.InitResponse = map[string]interface{}{"commands": []interface{}{}}
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	cc := claude.NewControlClient(transport, options)
+	defer cc.Close()
+	if err := cc.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if _, err := cc.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if cc.InitResult() == nil {
+		t.Error("expected InitResult after Initialize")
+	}
+
+	if _, err := cc.SendControlRequest(ctx, map[string]interface{}{"subtype": "set_model", "model": "claude-haiku-4-5"}); err != nil {
+		t.Fatalf("SendControlRequest failed: %v", err)
+	}
+	if _, err := cc.SendControlRequest(ctx, map[string]interface{}{}); err == nil {
+		t.Error("expected a request without subtype to fail")
+	}
+
+	// Control requests from the CLI are answered by the client
+	transport.QueueResponse(permissionRequest("req_perm", "Read", map[string]interface{}{"file_path": "a.go"}))
+	if _, ok := transport.WaitForControlResponse("req_perm", time.Second); !ok {
+		t.Fatal("no response to permission request")
+	}
+	if permissionCalls.Load() != 1 {
+		t.Errorf("expected CanUseTool to be called once, got %d", permissionCalls.Load())
+	}
+
+	// Other messages are routed raw
+	transport.QueueResponse(CreateResultMessage("s", 0.01, 1000))
+	select {
+	case raw := <-cc.Messages():
+		msg, err := claude.ParseMessage(raw)
+		if err != nil {
+			t.Fatalf("ParseMessage failed: %v", err)
+		}
+		if _, ok := msg.(*claude.ResultMessage); !ok {
+			t.Errorf("expected ResultMessage, got %T", msg)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for message")
+	}
+}
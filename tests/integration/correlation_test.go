@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestQueryCorrelationIDOnMessages(t *testing.T) {
+	ctx := claude.WithCorrelationID(context.Background(), "req-42")
+
+	assistant := CreateAssistantTextMessage("Hi")
+	assistant["uuid"] = "msg-uuid-1"
+	assistant["session_id"] = "s1"
+	result := CreateResultMessage("s1", 0.001, 100)
+	result["uuid"] = "msg-uuid-2"
+
+	msgCh, errCh, err := claude.Query(ctx, "Hello", nil, NewMockTransport([]map[string]interface{}{assistant, result}))
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	messages, err := CollectMessages(msgCh, errCh)
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+
+	assistantMsg := messages[0].(*claude.AssistantMessage)
+	if assistantMsg.CorrelationID != "req-42" || assistantMsg.UUID != "msg-uuid-1" || assistantMsg.SessionID != "s1" {
+		t.Errorf("Unexpected assistant identifiers: %+v", assistantMsg)
+	}
+	resultMsg := messages[1].(*claude.ResultMessage)
+	if resultMsg.CorrelationID != "req-42" || resultMsg.UUID != "msg-uuid-2" {
+		t.Errorf("Unexpected result identifiers: %+v", resultMsg)
+	}
+}
+
+func TestQueryCorrelationIDOnErrors(t *testing.T) {
+	ctx := claude.WithCorrelationID(context.Background(), "req-7")
+
+	transport := NewMockTransport(nil)
+	transport.ReadMessagesFunc = func(ctx context.Context) (<-chan map[string]interface{}, <-chan error) {
+		msgCh := make(chan map[string]interface{})
+		errCh := make(chan error, 1)
+		errCh <- claude.NewCLIConnectionError("pipe broken", nil)
+		return msgCh, errCh
+	}
+
+	msgCh, errCh, err := claude.Query(ctx, "Hello", nil, transport)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	_, err = CollectMessages(msgCh, errCh)
+
+	var correlated *claude.CorrelatedError
+	if !errors.As(err, &correlated) || correlated.CorrelationID != "req-7" {
+		t.Fatalf("Expected CorrelatedError with ID req-7, got %v", err)
+	}
+	var connErr *claude.CLIConnectionError
+	if !errors.As(err, &connErr) {
+		t.Errorf("Expected underlying CLIConnectionError, got %v", err)
+	}
+}
+
+func TestClientQueryCorrelationID(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	msgCh, errCh := client.Query(claude.WithCorrelationID(ctx, "turn-1"), "Hello")
+	transport.QueueResponse(CreateAssistantTextMessage("Hi"))
+	transport.QueueResponse(CreateResultMessage("s1", 0.001, 100))
+
+	messages, err := CollectMessages(msgCh, errCh)
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	for _, msg := range messages {
+		switch m := msg.(type) {
+		case *claude.AssistantMessage:
+			if m.CorrelationID != "turn-1" {
+				t.Errorf("Expected correlation ID on assistant message, got %q", m.CorrelationID)
+			}
+		case *claude.ResultMessage:
+			if m.CorrelationID != "turn-1" {
+				t.Errorf("Expected correlation ID on result message, got %q", m.CorrelationID)
+			}
+		}
+	}
+}
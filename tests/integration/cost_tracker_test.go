@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestClientCostTracker(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	alerts := make(chan claude.CostSummary, 1)
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		CostAlert: &claude.CostAlert{
+			ThresholdUSD: 0.25,
+			OnCross:      func(summary claude.CostSummary) { alerts <- summary },
+		},
+	}, transport)
+	if summary := client.CostTracker().Summary(); summary.Queries != 0 || summary.TotalUSD != 0 {
+		t.Errorf("expected an empty summary before connecting, got %+v", summary)
+	}
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// The CLI reports cumulative cost per process
+	for _, turn := range []struct {
+		session string
+		costUSD float64
+	}{
+		{"s1", 0.10},
+		{"s2", 0.30},
+		{"s1", 0.45},
+	} {
+		msgCh, errCh := client.Query(ctx, "work")
+		result := CreateResultMessage(turn.session, turn.costUSD, 100)
+		result["usage"] = map[string]interface{}{"input_tokens": 10.0, "output_tokens": 100.0, "cache_read_input_tokens": 1000.0}
+		transport.QueueResponse(result)
+		if _, err := CollectMessages(msgCh, errCh); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	summary := client.CostTracker().Summary()
+	if math.Abs(summary.TotalUSD-0.45) > 1e-9 || summary.Queries != 3 {
+		t.Errorf("expected $0.45 over 3 queries, got %+v", summary)
+	}
+	if summary.Tokens.OutputTokens != 300 || summary.Tokens.CacheReadInputTokens != 3000 {
+		t.Errorf("unexpected token totals: %+v", summary.Tokens)
+	}
+	s1, s2 := summary.Sessions["s1"], summary.Sessions["s2"]
+	if math.Abs(s1.CostUSD-0.25) > 1e-9 || s1.Queries != 2 || s1.Tokens.InputTokens != 20 {
+		t.Errorf("unexpected s1 breakdown: %+v", s1)
+	}
+	if math.Abs(s2.CostUSD-0.20) > 1e-9 || s2.Queries != 1 {
+		t.Errorf("unexpected s2 breakdown: %+v", s2)
+	}
+
+	select {
+	case alert := <-alerts:
+		if alert.Queries != 2 || math.Abs(alert.TotalUSD-0.30) > 1e-9 {
+			t.Errorf("expected the alert after the second query, got %+v", alert)
+		}
+	case <-ctx.Done():
+		t.Fatal("cost alert was not called")
+	}
+	select {
+	case alert := <-alerts:
+		t.Errorf("expected a single alert, got another: %+v", alert)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,55 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestSubprocessDebugEvents(t *testing.T) {
+	// The debug log is only written when the SDK asks for it
+	cliPath := writeFakeCLI(t, `case "$*" in *--debug-to-stderr*)
+  echo '[DEBUG] MCP server "github": Connection established' >&2
+  echo 'plain stderr output' >&2
+esac
+echo '{"type":"result","subtype":"success","duration_ms":1,"duration_api_ms":1,"is_error":false,"num_turns":1,"session_id":"s"}'
+`)
+
+	var mu sync.Mutex
+	var events []claude.DebugEvent
+	var stderrLines []string
+	options := &claude.ClaudeAgentOptions{
+		DebugEvents: func(event claude.DebugEvent) {
+			mu.Lock()
+			events = append(events, event)
+			mu.Unlock()
+		},
+		Stderr: func(line string) {
+			mu.Lock()
+			stderrLines = append(stderrLines, line)
+			mu.Unlock()
+		},
+	}
+	trans, err := claude.NewSubprocessCLITransport("hi", options, cliPath)
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	msgCh, errCh, err := claude.Query(context.Background(), "hi", options, trans)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 || events[0].Kind != claude.DebugEventMCP || events[0].Fields["server"] != "github" {
+		t.Errorf("expected one MCP debug event, got %+v", events)
+	}
+	if len(stderrLines) != 2 {
+		t.Errorf("expected Stderr to still receive every line, got %q", stderrLines)
+	}
+}
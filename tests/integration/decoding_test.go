@@ -0,0 +1,55 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestSubprocessSanitizesInvalidUTF8(t *testing.T) {
+	cliPath := writeFakeCLI(t, `printf 'bad \377 byte\n' >&2
+printf '{"type":"result","subtype":"success","duration_ms":1,"duration_api_ms":1,"is_error":false,"num_turns":1,"session_id":"s","result":"%s \376\377"}\n' "$LC_ALL"
+`)
+
+	var mu sync.Mutex
+	var warnings []claude.DecodingWarning
+	options := &claude.ClaudeAgentOptions{
+		ForceUTF8Output: true,
+		DecodingWarning: func(w claude.DecodingWarning) {
+			mu.Lock()
+			warnings = append(warnings, w)
+			mu.Unlock()
+		},
+	}
+	trans, err := claude.NewSubprocessCLITransport("hi", options, cliPath)
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	msgCh, errCh, err := claude.Query(context.Background(), "hi", options, trans)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	messages, err := CollectMessages(msgCh, errCh)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("unexpected query outcome: %v, %d messages", err, len(messages))
+	}
+
+	if got := *messages[0].(*claude.ResultMessage).Result; got != "C.UTF-8 ��" {
+		t.Errorf("expected sanitized result with pinned locale, got %q", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	byStream := map[string]claude.DecodingWarning{}
+	for _, w := range warnings {
+		byStream[w.Stream] = w
+	}
+	if w := byStream["stdout"]; w.Line != 1 || w.InvalidBytes != 2 {
+		t.Errorf("unexpected stdout warning: %+v", w)
+	}
+	if w := byStream["stderr"]; w.InvalidBytes != 1 || w.Sample != "bad � byte" {
+		t.Errorf("unexpected stderr warning: %+v", w)
+	}
+}
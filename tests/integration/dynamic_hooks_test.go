@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// initializeCallbackID returns the last hook callback ID registered for event
+// in the initialize request written to transport.
+func initializeCallbackID(t *testing.T, transport *AdvancedMockTransport, event claude.HookEvent) string {
+	t.Helper()
+	for _, data := range transport.GetWrittenMessages() {
+		var msg map[string]interface{}
+		if json.Unmarshal([]byte(data), &msg) != nil {
+			continue
+		}
+		request, _ := msg["request"].(map[string]interface{})
+		if request["subtype"] != "initialize" {
+			continue
+		}
+		hooks, _ := request["hooks"].(map[string]interface{})
+		matchers, _ := hooks[string(event)].([]interface{})
+		if len(matchers) == 0 {
+			break
+		}
+		matcher, _ := matchers[len(matchers)-1].(map[string]interface{})
+		ids, _ := matcher["hookCallbackIds"].([]interface{})
+		if len(ids) == 1 {
+			id, _ := ids[0].(string)
+			return id
+		}
+	}
+	t.Fatalf("no hook registered for %s in the initialize request", event)
+	return ""
+}
+
+func dynamicHookRequest(requestID, callbackID, toolName string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "control_request",
+		"request_id": requestID,
+		"request": map[string]interface{}{
+			"subtype":     "hook_callback",
+			"callback_id": callbackID,
+			"tool_use_id": "tool_1",
+			"input":       map[string]interface{}{"hook_event_name": "PreToolUse", "tool_name": toolName},
+		},
+	}
+}
+
+func TestAddAndRemoveHook(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{DynamicHooks: true}, transport)
+
+	var bashCalls, anyCalls atomic.Int32
+	bashHandle, err := client.AddHook(claude.HookEventPreToolUse, "Bash", func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+		bashCalls.Add(1)
+		return claude.HookJSONOutput{}, nil
+	})
+	if err != nil {
+		t.Fatalf("AddHook failed: %v", err)
+	}
+	if bashHandle.Event() != claude.HookEventPreToolUse || bashHandle.Matcher() != "Bash" {
+		t.Errorf("unexpected handle: %v %q", bashHandle.Event(), bashHandle.Matcher())
+	}
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+	callbackID := initializeCallbackID(t, transport, claude.HookEventPreToolUse)
+
+	// Hooks can be added while connected
+	if _, err := client.AddHook(claude.HookEventPreToolUse, "*", func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+		anyCalls.Add(1)
+		return claude.HookJSONOutput{}, nil
+	}); err != nil {
+		t.Fatalf("AddHook failed: %v", err)
+	}
+
+	transport.QueueResponse(dynamicHookRequest("req_bash", callbackID, "Bash"))
+	if _, ok := transport.WaitForControlResponse("req_bash", time.Second); !ok {
+		t.Fatal("no response to hook callback")
+	}
+	transport.QueueResponse(dynamicHookRequest("req_read", callbackID, "Read"))
+	if _, ok := transport.WaitForControlResponse("req_read", time.Second); !ok {
+		t.Fatal("no response to hook callback")
+	}
+	if bashCalls.Load() != 1 || anyCalls.Load() != 2 {
+		t.Fatalf("expected 1 Bash and 2 catch-all calls, got %d and %d", bashCalls.Load(), anyCalls.Load())
+	}
+
+	if !client.RemoveHook(bashHandle) {
+		t.Fatal("RemoveHook reported the hook as unregistered")
+	}
+	if client.RemoveHook(bashHandle) {
+		t.Error("removing a hook twice should report false")
+	}
+	transport.QueueResponse(dynamicHookRequest("req_bash_2", callbackID, "Bash"))
+	if _, ok := transport.WaitForControlResponse("req_bash_2", time.Second); !ok {
+		t.Fatal("no response to hook callback")
+	}
+	if bashCalls.Load() != 1 {
+		t.Errorf("removed hook was called")
+	}
+}
+
+func TestAddHookRequiresDynamicHooks(t *testing.T) {
+	client := claude.NewClaudeSDKClientWithTransport(nil, NewAdvancedMockTransport())
+	_, err := client.AddHook(claude.HookEventPreToolUse, "Bash", func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+		return claude.HookJSONOutput{}, nil
+	})
+	if err == nil {
+		t.Error("expected AddHook to fail without DynamicHooks")
+	}
+}
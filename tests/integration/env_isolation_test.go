@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// writeFakeCLI writes a shell script standing in for the claude binary.
+func writeFakeCLI(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake CLI scripts require a POSIX shell")
+	}
+	path := filepath.Join(t.TempDir(), "claude")
+	script := "#!/bin/sh\nif [ \"$1\" = \"-v\" ]; then echo \"2.0.0 (Claude Code)\"; exit 0; fi\n" + body
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake CLI: %v", err)
+	}
+	return path
+}
+
+func TestSubprocessEnvIsIsolatedPerClient(t *testing.T) {
+	cliPath := writeFakeCLI(t, `echo "{\"type\":\"result\",\"subtype\":\"success\",\"duration_ms\":1,\"duration_api_ms\":1,\"is_error\":false,\"num_turns\":1,\"session_id\":\"s\",\"result\":\"$CLAUDE_CODE_ENTRYPOINT:$SDK_TEST_VALUE\"}"`+"\n")
+
+	before, hadBefore := os.LookupEnv("CLAUDE_CODE_ENTRYPOINT")
+
+	var wg sync.WaitGroup
+	results := make([]string, 4)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			options := &claude.ClaudeAgentOptions{
+				Env: map[string]string{"SDK_TEST_VALUE": string(rune('a' + i))},
+			}
+			trans, err := claude.NewSubprocessCLITransport("hi", options, cliPath)
+			if err != nil {
+				t.Errorf("failed to create transport: %v", err)
+				return
+			}
+			msgCh, errCh, err := claude.Query(context.Background(), "hi", options, trans)
+			if err != nil {
+				t.Errorf("Query failed: %v", err)
+				return
+			}
+			messages, err := CollectMessages(msgCh, errCh)
+			if err != nil || len(messages) != 1 {
+				t.Errorf("unexpected query outcome: %v, %d messages", err, len(messages))
+				return
+			}
+			results[i] = *messages[0].(*claude.ResultMessage).Result
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		want := "sdk-go:" + string(rune('a'+i))
+		if result != want {
+			t.Errorf("client %d: expected %q, got %q", i, want, result)
+		}
+	}
+
+	after, hadAfter := os.LookupEnv("CLAUDE_CODE_ENTRYPOINT")
+	if before != after || hadBefore != hadAfter {
+		t.Errorf("process environment was modified: %q -> %q", before, after)
+	}
+}
+
+func TestSubprocessAuthFailureFromStderr(t *testing.T) {
+	cliPath := writeFakeCLI(t, "echo 'Invalid API key · Please run /login' >&2\nexit 1\n")
+
+	trans, err := claude.NewSubprocessCLITransport("hi", nil, cliPath)
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	msgCh, errCh, err := claude.Query(context.Background(), "hi", nil, trans)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	_, err = CollectMessages(msgCh, errCh)
+	var authErr *claude.AuthenticationError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected AuthenticationError, got %v", err)
+	}
+	if authErr.Source != claude.AuthErrorSourceStderr {
+		t.Errorf("expected source %q, got %q", claude.AuthErrorSourceStderr, authErr.Source)
+	}
+}
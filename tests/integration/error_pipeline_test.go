@@ -0,0 +1,170 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+type recordedError struct {
+	err      error
+	severity claude.ErrorSeverity
+}
+
+type errorRecorder struct {
+	mu     sync.Mutex
+	errors []recordedError
+}
+
+func (r *errorRecorder) record(err error, severity claude.ErrorSeverity) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, recordedError{err, severity})
+}
+
+func (r *errorRecorder) bySeverity(severity claude.ErrorSeverity) []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for _, e := range r.errors {
+		if e.severity == severity {
+			errs = append(errs, e.err)
+		}
+	}
+	return errs
+}
+
+func TestQuerySkipsUnknownMessageTypesAsWarnings(t *testing.T) {
+	recorder := &errorRecorder{}
+	options := &claude.ClaudeAgentOptions{OnError: recorder.record}
+
+	messages := []map[string]interface{}{
+		CreateAssistantTextMessage("Hi"),
+		{"type": "future_message_type", "payload": "x"},
+		CreateResultMessage("s1", 0.001, 10),
+	}
+
+	msgCh, errCh, err := claude.Query(context.Background(), "Hello", options, NewMockTransport(messages))
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	received, err := CollectMessages(msgCh, errCh)
+	if err != nil {
+		t.Fatalf("Expected no terminal error, got %v", err)
+	}
+	if len(received) != 2 {
+		t.Errorf("Expected 2 messages, got %d", len(received))
+	}
+
+	warnings := recorder.bySeverity(claude.ErrorSeverityWarning)
+	var parseErr *claude.MessageParseError
+	if len(warnings) != 1 || !errors.As(warnings[0], &parseErr) {
+		t.Errorf("Expected one MessageParseError warning, got %v", warnings)
+	}
+	if fatal := recorder.bySeverity(claude.ErrorSeverityFatal); len(fatal) != 0 {
+		t.Errorf("Expected no fatal errors, got %v", fatal)
+	}
+}
+
+func TestQueryDeliversExactlyOneTerminalError(t *testing.T) {
+	recorder := &errorRecorder{}
+	options := &claude.ClaudeAgentOptions{OnError: recorder.record}
+
+	transport := NewMockTransport(nil)
+	transport.ReadMessagesFunc = func(ctx context.Context) (<-chan map[string]interface{}, <-chan error) {
+		msgCh := make(chan map[string]interface{}, 1)
+		errCh := make(chan error, 2)
+		msgCh <- CreateAssistantTextMessage("partial")
+		errCh <- claude.NewCLIConnectionError("pipe broken", nil)
+		errCh <- claude.NewCLIConnectionError("second failure", nil)
+		return msgCh, errCh
+	}
+
+	msgCh, errCh, err := claude.Query(context.Background(), "Hello", options, transport)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	for range msgCh {
+	}
+
+	var terminal []error
+	for err := range errCh {
+		terminal = append(terminal, err)
+	}
+	if len(terminal) != 1 {
+		t.Fatalf("Expected exactly one terminal error, got %v", terminal)
+	}
+	fatal := recorder.bySeverity(claude.ErrorSeverityFatal)
+	if len(fatal) != 1 || fatal[0] != terminal[0] {
+		t.Errorf("Expected OnError fatal to match terminal error, got %v", fatal)
+	}
+}
+
+func TestClientQueryReportsStreamError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	msgCh, errCh := client.Query(ctx, "Hello")
+	transport.QueueResponse(CreateAssistantTextMessage("partial"))
+	transport.QueueError(claude.NewCLIConnectionError("pipe broken", nil))
+
+	_, err := CollectMessages(msgCh, errCh)
+	var connErr *claude.CLIConnectionError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("Expected CLIConnectionError from client query, got %v", err)
+	}
+	if !errors.As(client.Err(), &connErr) {
+		t.Errorf("Expected Err() to report the stream error, got %v", client.Err())
+	}
+}
+
+func TestControlRequestFailuresAreWarnings(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	recorder := &errorRecorder{}
+	options := &claude.ClaudeAgentOptions{
+		OnError: recorder.record,
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventPreToolUse: {{
+				Hooks: []claude.HookCallback{
+					func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+						return claude.HookJSONOutput{}, errors.New("hook exploded")
+					},
+				},
+			}},
+		},
+	}
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	transport.QueueResponse(hookCallbackRequest("hook_fail", "tool_1"))
+	if _, ok := transport.WaitForControlResponse("hook_fail", 2*time.Second); !ok {
+		t.Fatal("No control response for failing hook")
+	}
+
+	warnings := recorder.bySeverity(claude.ErrorSeverityWarning)
+	var ctrlErr *claude.ControlRequestError
+	if len(warnings) != 1 || !errors.As(warnings[0], &ctrlErr) || ctrlErr.RequestID != "hook_fail" {
+		t.Errorf("Expected one ControlRequestError warning, got %v", warnings)
+	}
+	if client.Err() != nil {
+		t.Errorf("Expected stream to stay healthy, got %v", client.Err())
+	}
+}
@@ -0,0 +1,173 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+type memorySink struct {
+	mu     sync.Mutex
+	events []claude.Event
+}
+
+func (s *memorySink) Emit(event claude.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *memorySink) byType(eventType claude.EventType) []claude.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []claude.Event
+	for _, event := range s.events {
+		if event.Type == eventType {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func TestQueryEmitsEventsToHTTPSink(t *testing.T) {
+	var mu sync.Mutex
+	var received []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var batch []map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, batch...)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	sink := claude.NewHTTPEventSink(server.URL, claude.HTTPEventSinkOptions{
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		OnError: func(err error) { t.Errorf("sink error: %v", err) },
+	})
+
+	messages := []map[string]interface{}{
+		CreateAssistantToolUseMessage("Reading", "tool_1", "Read", map[string]interface{}{"file_path": "a.go"}),
+		CreateResultMessage("s1", 0.01, 100),
+	}
+	ctx := claude.WithCorrelationID(context.Background(), "req-9")
+	msgCh, errCh, err := claude.Query(ctx, "Hello", &claude.ClaudeAgentOptions{EventSink: sink}, NewMockTransport(messages))
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := sink.Close(closeCtx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	counts := map[string]int{}
+	for _, event := range received {
+		counts[event["type"].(string)]++
+		if event["correlation_id"] != "req-9" {
+			t.Errorf("expected correlation ID on event, got %v", event)
+		}
+	}
+	want := map[string]int{"message": 2, "tool_use": 1, "result": 1}
+	for eventType, n := range want {
+		if counts[eventType] != n {
+			t.Errorf("expected %d %s events, got %d (%v)", n, eventType, counts[eventType], counts)
+		}
+	}
+	if sink.Dropped() != 0 {
+		t.Errorf("expected no dropped events, got %d", sink.Dropped())
+	}
+}
+
+func TestClientEmitsPermissionDecisionEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	sink := &memorySink{}
+	options := &claude.ClaudeAgentOptions{
+		EventSink: sink,
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			return claude.PermissionResultDeny{Behavior: "deny", Message: "no writes"}, nil
+		},
+	}
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	transport.QueueResponse(permissionRequest("perm_evt", "Write", map[string]interface{}{"file_path": "x"}))
+	if _, ok := transport.WaitForControlResponse("perm_evt", 2*time.Second); !ok {
+		t.Fatal("No control response")
+	}
+
+	events := sink.byType(claude.EventTypePermissionDecision)
+	if len(events) != 1 {
+		t.Fatalf("expected one permission decision event, got %d", len(events))
+	}
+	decision := events[0].Data.(claude.PermissionDecisionEvent)
+	if decision.ToolName != "Write" || decision.Behavior != "deny" || decision.Message != "no writes" || decision.RequestID != "perm_evt" {
+		t.Errorf("unexpected decision: %+v", decision)
+	}
+}
+
+func TestClientEventsCarryCorrelationID(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	sink := &memorySink{}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{EventSink: sink}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	for _, correlationID := range []string{"req-1", "req-2"} {
+		msgCh, errCh := client.Query(claude.WithCorrelationID(ctx, correlationID), "Read a.go")
+		transport.QueueResponse(CreateAssistantToolUseMessage("Reading", "tool_"+correlationID, "Read", map[string]interface{}{"file_path": "a.go"}))
+		transport.QueueResponse(CreateResultMessage("s1", 0.01, 100))
+		if _, err := CollectMessages(msgCh, errCh); err != nil {
+			t.Fatalf("Query error: %v", err)
+		}
+	}
+
+	want := map[claude.EventType][]string{
+		claude.EventTypeMessage: {"req-1", "req-1", "req-2", "req-2"},
+		claude.EventTypeToolUse: {"req-1", "req-2"},
+		claude.EventTypeResult:  {"req-1", "req-2"},
+	}
+	for eventType, ids := range want {
+		events := sink.byType(eventType)
+		if len(events) != len(ids) {
+			t.Errorf("expected %d %s events, got %d", len(ids), eventType, len(events))
+			continue
+		}
+		for i, event := range events {
+			if event.CorrelationID != ids[i] {
+				t.Errorf("%s event %d: expected correlation ID %q, got %q", eventType, i, ids[i], event.CorrelationID)
+			}
+		}
+	}
+}
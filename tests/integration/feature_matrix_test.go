@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestUnsupportedFlagsAreDropped(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake CLI scripts require a POSIX shell")
+	}
+	// An older CLI that echoes its arguments as the result
+	cliPath := filepath.Join(t.TempDir(), "claude")
+	script := "#!/bin/sh\nif [ \"$1\" = \"-v\" ]; then echo \"2.0.5 (Claude Code)\"; exit 0; fi\n" +
+		`echo "{\"type\":\"result\",\"subtype\":\"success\",\"duration_ms\":1,\"duration_api_ms\":1,\"is_error\":false,\"num_turns\":1,\"session_id\":\"s\",\"result\":\"$*\"}"` + "\n"
+	if err := os.WriteFile(cliPath, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := claude.DetectCLIVersion(context.Background(), cliPath)
+	if err != nil || version != "2.0.5" {
+		t.Fatalf("expected version 2.0.5, got %q, %v", version, err)
+	}
+
+	var logs bytes.Buffer
+	options := &claude.ClaudeAgentOptions{
+		Plugins:                []claude.SdkPluginConfig{{Type: "local", Path: t.TempDir()}},
+		IncludePartialMessages: true,
+		Logger:                 slog.New(slog.NewTextHandler(&logs, nil)),
+	}
+	transport, err := claude.NewSubprocessCLITransport("hi", options, cliPath)
+	if err != nil {
+		t.Fatalf("NewSubprocessCLITransport failed: %v", err)
+	}
+	msgCh, errCh, err := claude.Query(context.Background(), "hi", options, transport)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	messages, err := CollectMessages(msgCh, errCh)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("unexpected query outcome: %v, %d messages", err, len(messages))
+	}
+
+	args := *messages[0].(*claude.ResultMessage).Result
+	if strings.Contains(args, "--plugin-dir") {
+		t.Errorf("expected --plugin-dir to be dropped for an old CLI, got %q", args)
+	}
+	if !strings.Contains(args, "--include-partial-messages") {
+		t.Errorf("expected supported flags to be kept, got %q", args)
+	}
+	if !strings.Contains(logs.String(), "Plugins requires Claude Code 2.0.12 or later") {
+		t.Errorf("expected a warning about Plugins, got %q", logs.String())
+	}
+}
@@ -0,0 +1,236 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// scriptedTransport answers the nth user message with the nth reply.
+type scriptedTransport struct {
+	*AdvancedMockTransport
+	mu      sync.Mutex
+	replies [][]map[string]interface{}
+	prompts []string
+}
+
+func (m *scriptedTransport) Write(ctx context.Context, data string) error {
+	if err := m.AdvancedMockTransport.Write(ctx, data); err != nil {
+		return err
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &msg); err != nil || msg["type"] != "user" {
+		return nil
+	}
+	prompt, _ := msg["message"].(map[string]interface{})["content"].(string)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompts = append(m.prompts, prompt)
+	if len(m.replies) == 0 {
+		return nil
+	}
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+	go func() {
+		for _, r := range reply {
+			m.QueueResponse(r)
+		}
+	}()
+	return nil
+}
+
+func toolHeavyReply() []map[string]interface{} {
+	return []map[string]interface{}{
+		CreateAssistantToolUseMessage("Reading.", "t1", "Read", map[string]interface{}{"file_path": "a.go"}),
+		CreateAssistantToolUseMessage("Fixing.", "t2", "Edit", map[string]interface{}{"file_path": "a.go"}),
+		CreateAssistantToolUseMessage("Adding a test.", "t3", "Write", map[string]interface{}{"file_path": "a_test.go"}),
+		CreateAssistantTextMessage("Done."),
+		CreateResultMessage("s", 0.05, 1000),
+	}
+}
+
+func TestFinalSummary(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := &scriptedTransport{
+		AdvancedMockTransport: NewAdvancedMockTransport(),
+		replies: [][]map[string]interface{}{
+			toolHeavyReply(),
+			{CreateAssistantTextMessage("Fixed a bug in a.go and added a_test.go. "), CreateResultMessage("s", 0.06, 200)},
+		},
+	}
+	options := &claude.ClaudeAgentOptions{FinalSummary: true, FinalSummaryModel: "haiku"}
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	messages, err := CollectMessages(client.Query(ctx, "Fix the bug"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(messages) != 5 {
+		t.Fatalf("expected the query's 5 messages only, got %d", len(messages))
+	}
+	result, ok := messages[4].(*claude.ResultMessage)
+	if !ok || result.Summary == nil {
+		t.Fatalf("expected a result with a summary, got %+v", messages[4])
+	}
+	summary := result.Summary
+	if summary.Text != "Fixed a bug in a.go and added a_test.go." || summary.ToolUses != 3 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if !reflect.DeepEqual(summary.FilesChanged, []string{"a.go", "a_test.go"}) {
+		t.Errorf("unexpected files changed %q", summary.FilesChanged)
+	}
+	if summary.Result == nil || *summary.Result.TotalCostUSD != 0.06 {
+		t.Errorf("expected the summary turn's result, got %+v", summary.Result)
+	}
+
+	// The summary turn ran on FinalSummaryModel, then switched back
+	var models []string
+	for _, data := range transport.GetWrittenMessages() {
+		var msg map[string]interface{}
+		json.Unmarshal([]byte(data), &msg)
+		if request, ok := msg["request"].(map[string]interface{}); ok && request["subtype"] == "set_model" {
+			models = append(models, request["model"].(string))
+		}
+	}
+	if !reflect.DeepEqual(models, []string{"haiku", "default"}) {
+		t.Errorf("expected set_model haiku then default, got %q", models)
+	}
+}
+
+func TestFinalSummarySkipsLightQueries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := &scriptedTransport{
+		AdvancedMockTransport: NewAdvancedMockTransport(),
+		replies:               [][]map[string]interface{}{toolHeavyReply()},
+	}
+	options := &claude.ClaudeAgentOptions{FinalSummary: true, FinalSummaryMinToolUses: 4}
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	messages, err := CollectMessages(client.Query(ctx, "Fix the bug"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result, ok := messages[len(messages)-1].(*claude.ResultMessage); !ok || result.Summary != nil {
+		t.Errorf("expected a result without a summary, got %+v", messages[len(messages)-1])
+	}
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.prompts) != 1 || strings.Contains(transport.prompts[0], "Summarize") {
+		t.Errorf("expected no summary turn, got prompts %q", transport.prompts)
+	}
+}
+
+// setModels returns the models of the set_model requests written to
+// transport, in order.
+func setModels(transport *AdvancedMockTransport) []string {
+	var models []string
+	for _, request := range transport.ControlRequests("set_model") {
+		models = append(models, request["request"].(map[string]interface{})["model"].(string))
+	}
+	return models
+}
+
+// failSetModelDuringSummary answers set_model requests with an error once
+// the summary prompt is sent, after the switch to FinalSummaryModel.
+type failSetModelDuringSummary struct {
+	*scriptedTransport
+}
+
+func (m *failSetModelDuringSummary) Write(ctx context.Context, data string) error {
+	if strings.Contains(data, "Summarize concisely") {
+		m.SetControlError("set_model", "model switch rejected")
+	}
+	return m.scriptedTransport.Write(ctx, data)
+}
+
+func TestFinalSummaryRestoresCurrentModel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := &scriptedTransport{
+		AdvancedMockTransport: NewAdvancedMockTransport(),
+		replies: [][]map[string]interface{}{
+			toolHeavyReply(),
+			{CreateAssistantTextMessage("Fixed a bug."), CreateResultMessage("s", 0.06, 200)},
+		},
+	}
+	options := &claude.ClaudeAgentOptions{FinalSummary: true, FinalSummaryModel: "haiku"}
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.SetModel(ctx, "opus"); err != nil {
+		t.Fatalf("SetModel failed: %v", err)
+	}
+	if _, err := CollectMessages(client.Query(ctx, "Fix the bug")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The model the caller chose is restored, not the configured one
+	if models := setModels(transport.AdvancedMockTransport); !reflect.DeepEqual(models, []string{"opus", "haiku", "opus"}) {
+		t.Errorf("expected set_model opus, haiku, then opus again, got %q", models)
+	}
+}
+
+func TestFinalSummaryReportsFailedModelRestore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := &failSetModelDuringSummary{&scriptedTransport{
+		AdvancedMockTransport: NewAdvancedMockTransport(),
+		replies: [][]map[string]interface{}{
+			toolHeavyReply(),
+			{CreateAssistantTextMessage("Fixed a bug."), CreateResultMessage("s", 0.06, 200)},
+		},
+	}}
+	var mu sync.Mutex
+	var warnings []error
+	options := &claude.ClaudeAgentOptions{
+		FinalSummary:      true,
+		FinalSummaryModel: "haiku",
+		OnError: func(err error, severity claude.ErrorSeverity) {
+			mu.Lock()
+			defer mu.Unlock()
+			if severity == claude.ErrorSeverityWarning {
+				warnings = append(warnings, err)
+			}
+		},
+	}
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	messages, err := CollectMessages(client.Query(ctx, "Fix the bug"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result, ok := messages[len(messages)-1].(*claude.ResultMessage); !ok || result.Summary == nil {
+		t.Errorf("expected the summary to survive a failed restore, got %+v", messages[len(messages)-1])
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), `restoring model "default"`) {
+		t.Errorf("expected a warning about the failed restore, got %v", warnings)
+	}
+}
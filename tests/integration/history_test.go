@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestClientHistoryDisabledByDefault(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	msgCh, errCh := client.Query(ctx, "Hello")
+	transport.QueueResponse(CreateAssistantTextMessage("Hi"))
+	transport.QueueResponse(CreateResultMessage("s1", 0.001, 100))
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if history := client.History(); history != nil {
+		t.Errorf("Expected nil history when disabled, got %d messages", len(history))
+	}
+}
+
+func TestClientHistoryAccessors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	historySize := 3
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{HistorySize: &historySize}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	msgCh, errCh := client.Query(ctx, "Read the file")
+	transport.QueueResponse(CreateAssistantTextMessage("Let me look"))
+	transport.QueueResponse(CreateAssistantToolUseMessage("Reading", "tool_1", "Read", map[string]interface{}{"file_path": "/a.go"}))
+	transport.QueueResponse(CreateAssistantTextMessage("The file is empty"))
+	transport.QueueResponse(CreateResultMessage("s1", 0.001, 100))
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	history := client.History()
+	if len(history) != 3 {
+		t.Fatalf("Expected history bounded to 3 messages, got %d", len(history))
+	}
+	if _, ok := history[2].(*claude.ResultMessage); !ok {
+		t.Errorf("Expected newest entry to be ResultMessage, got %T", history[2])
+	}
+
+	entries := client.HistoryEntries()
+	if entries[0].Seq != 2 || entries[2].Seq != 4 {
+		t.Errorf("Expected sequence numbers 2..4 after eviction, got %d..%d", entries[0].Seq, entries[2].Seq)
+	}
+
+	if text := client.LastAssistantText(); text != "The file is empty" {
+		t.Errorf("Expected last assistant text, got %q", text)
+	}
+
+	toolUses := client.ToolUses()
+	if len(toolUses) != 1 || toolUses[0].Name != "Read" {
+		t.Errorf("Expected one Read tool use, got %+v", toolUses)
+	}
+}
@@ -0,0 +1,47 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestHookOutputEventNameFilledFromInput(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	addContext := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+		return claude.AddContext("The user prefers tabs"), nil
+	}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventUserPromptSubmit: {{Hooks: []claude.HookCallback{addContext}}},
+		},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	transport.QueueResponse(map[string]interface{}{
+		"type":       "control_request",
+		"request_id": "hook_1",
+		"request": map[string]interface{}{
+			"subtype":     "hook_callback",
+			"callback_id": initializeCallbackID(t, transport, claude.HookEventUserPromptSubmit),
+			"input":       map[string]interface{}{"hook_event_name": "UserPromptSubmit", "prompt": "hi"},
+		},
+	})
+	response, ok := transport.WaitForControlResponse("hook_1", time.Second)
+	if !ok {
+		t.Fatal("Expected a hook response")
+	}
+	output, _ := response["response"].(map[string]interface{})
+	specific, _ := output["hookSpecificOutput"].(map[string]interface{})
+	if specific["hookEventName"] != "UserPromptSubmit" || specific["additionalContext"] != "The user prefers tabs" {
+		t.Errorf("unexpected hookSpecificOutput %v", specific)
+	}
+}
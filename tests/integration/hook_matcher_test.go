@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// initializeMatchers returns the matchers registered for event in the
+// initialize request written to transport.
+func initializeMatchers(transport *AdvancedMockTransport, event claude.HookEvent) []string {
+	var matchers []string
+	for _, data := range transport.GetWrittenMessages() {
+		var msg map[string]interface{}
+		if json.Unmarshal([]byte(data), &msg) != nil {
+			continue
+		}
+		request, _ := msg["request"].(map[string]interface{})
+		if request["subtype"] != "initialize" {
+			continue
+		}
+		hooks, _ := request["hooks"].(map[string]interface{})
+		configs, _ := hooks[string(event)].([]interface{})
+		for _, config := range configs {
+			config, _ := config.(map[string]interface{})
+			matcher, _ := config["matcher"].(string)
+			matchers = append(matchers, matcher)
+		}
+	}
+	return matchers
+}
+
+func TestHookMatcherGlobsAndRegexps(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	calls := make(map[string][]string)
+	record := func(name string) claude.HookCallback {
+		return func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls[name] = append(calls[name], input["tool_name"].(string))
+			return claude.HookJSONOutput{}, nil
+		}
+	}
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventPreToolUse: {
+				{Matcher: "mcp__calc__*", Hooks: []claude.HookCallback{record("glob")}},
+				{Matcher: "/Write|Edit/", Hooks: []claude.HookCallback{record("regexp")}},
+				{Matcher: "Bash", Hooks: []claude.HookCallback{record("plain")}},
+			},
+		},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// Globs and /regexps/ are registered as catch-alls; plain matchers are left to the CLI
+	matchers := initializeMatchers(transport, claude.HookEventPreToolUse)
+	if len(matchers) != 3 || matchers[0] != "" || matchers[1] != "" || matchers[2] != "Bash" {
+		t.Fatalf("initialize registered matchers %q", matchers)
+	}
+
+	// The CLI calls catch-all hooks for every tool
+	for i, tool := range []string{"mcp__calc__add", "mcp__calc__multiply", "mcp__search__query", "NotebookEdit", "Write", "Read"} {
+		for j, callbackID := range []string{"hook_0", "hook_1"} {
+			requestID := tool + "_" + callbackID
+			transport.QueueResponse(map[string]interface{}{
+				"type":       "control_request",
+				"request_id": requestID,
+				"request": map[string]interface{}{
+					"subtype":     "hook_callback",
+					"callback_id": callbackID,
+					"input":       map[string]interface{}{"hook_event_name": "PreToolUse", "tool_name": tool},
+				},
+			})
+			if _, ok := transport.WaitForControlResponse(requestID, time.Second); !ok {
+				t.Fatalf("no response to hook call %d.%d", i, j)
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := calls["glob"]; len(got) != 2 || got[0] != "mcp__calc__add" || got[1] != "mcp__calc__multiply" {
+		t.Errorf("glob hook called for %q", got)
+	}
+	if got := calls["regexp"]; len(got) != 2 || got[0] != "NotebookEdit" || got[1] != "Write" {
+		t.Errorf("regexp hook called for %q", got)
+	}
+}
+
+func TestHookMatcherInvalidRegexp(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventPreToolUse: {{Matcher: "/(unclosed/", Hooks: []claude.HookCallback{
+				func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+					return claude.HookJSONOutput{}, nil
+				},
+			}}},
+		},
+	}, NewAdvancedMockTransport())
+	if err := client.Connect(ctx); err == nil {
+		client.Close()
+		t.Fatal("expected Connect to reject an invalid /regexp/ matcher")
+	}
+}
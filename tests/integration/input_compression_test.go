@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// lastWrittenMessage decodes the last message written to transport.
+func lastWrittenMessage(t *testing.T, transport *AdvancedMockTransport) map[string]interface{} {
+	t.Helper()
+	written := transport.GetWrittenMessages()
+	var msg map[string]interface{}
+	if err := json.Unmarshal([]byte(written[len(written)-1]), &msg); err != nil {
+		t.Fatalf("invalid message written: %v", err)
+	}
+	return msg
+}
+
+func TestInputCompression(t *testing.T) {
+	threshold := 1024
+	prompt := strings.Repeat("2024-01-01 INFO request served in 12ms\n", 200)
+
+	for _, tc := range []struct {
+		name       string
+		encodings  []interface{}
+		compressed bool
+	}{
+		{"supported", []interface{}{"gzip"}, true},
+		{"unsupported", nil, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+
+			transport := NewAdvancedMockTransport()
+			transport.InitResponse = map[string]interface{}{"input_encodings": tc.encodings}
+			client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{CompressInputThreshold: &threshold}, transport)
+			if err := client.Connect(ctx); err != nil {
+				t.Fatalf("Connect failed: %v", err)
+			}
+			defer client.Close()
+
+			// Small messages are never compressed
+			if err := client.QueryWithSession(ctx, "hello", "default"); err != nil {
+				t.Fatalf("QueryWithSession failed: %v", err)
+			}
+			if msg := lastWrittenMessage(t, transport); msg["type"] != "user" {
+				t.Errorf("expected a plain user message, got %v", msg["type"])
+			}
+
+			if err := client.QueryWithSession(ctx, prompt, "default"); err != nil {
+				t.Fatalf("QueryWithSession failed: %v", err)
+			}
+			msg := lastWrittenMessage(t, transport)
+			if !tc.compressed {
+				if msg["type"] != "user" {
+					t.Errorf("expected plain JSON without CLI support, got %v", msg["type"])
+				}
+				return
+			}
+			if msg["type"] != "compressed" || msg["encoding"] != "gzip" {
+				t.Fatalf("expected a gzip-compressed message, got %v", msg["type"])
+			}
+
+			raw, err := base64.StdEncoding.DecodeString(msg["data"].(string))
+			if err != nil {
+				t.Fatalf("invalid base64: %v", err)
+			}
+			gz, err := gzip.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatalf("invalid gzip: %v", err)
+			}
+			plain, _ := io.ReadAll(gz)
+			var inner map[string]interface{}
+			if err := json.Unmarshal(plain, &inner); err != nil || inner["type"] != "user" {
+				t.Fatalf("expected the user message inside, got %s", plain)
+			}
+			if content := inner["message"].(map[string]interface{})["content"]; content != prompt {
+				t.Error("prompt did not survive compression")
+			}
+		})
+	}
+}
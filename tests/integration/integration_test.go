@@ -393,3 +393,31 @@ func intPtr(i int) *int {
 func stringPtr(s string) *string {
 	return &s
 }
+
+// TestQueryDeliversAllBufferedMessages ensures messages buffered in the transport
+// are not dropped when the transport closes its channels.
+func TestQueryDeliversAllBufferedMessages(t *testing.T) {
+	ctx := context.Background()
+
+	messages := make([]map[string]interface{}, 0, 201)
+	for i := 0; i < 200; i++ {
+		messages = append(messages, CreateAssistantTextMessage("chunk"))
+	}
+	messages = append(messages, CreateResultMessage("test-session", 0.001, 500))
+
+	msgCh, errCh, err := claude.Query(ctx, "Hello", nil, NewMockTransport(messages))
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	received, err := CollectMessages(msgCh, errCh)
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if len(received) != len(messages) {
+		t.Fatalf("Expected %d messages, got %d", len(messages), len(received))
+	}
+	if _, ok := received[len(received)-1].(*claude.ResultMessage); !ok {
+		t.Errorf("Expected final message to be ResultMessage, got %T", received[len(received)-1])
+	}
+}
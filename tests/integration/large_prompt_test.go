@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// writtenUserPrompts returns the content of user messages written to transport.
+func writtenUserPrompts(transport *AdvancedMockTransport) []string {
+	var prompts []string
+	for _, data := range transport.GetWrittenMessages() {
+		var msg map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &msg); err != nil || msg["type"] != "user" {
+			continue
+		}
+		message, _ := msg["message"].(map[string]interface{})
+		content, _ := message["content"].(string)
+		prompts = append(prompts, content)
+	}
+	return prompts
+}
+
+func TestQueryLargePromptUsesStdin(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	prompt := strings.Repeat("x", 300*1024)
+	transport := NewAdvancedMockTransport()
+
+	msgCh, _, err := claude.Query(ctx, prompt, nil, transport)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	// The prompt is written after the initialize handshake
+	deadline := time.Now().Add(2 * time.Second)
+	for len(writtenUserPrompts(transport)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	prompts := writtenUserPrompts(transport)
+	if len(prompts) != 1 || prompts[0] != prompt {
+		t.Fatalf("expected the prompt to be written once over stdin, got %d prompts", len(prompts))
+	}
+
+	transport.QueueResponse(CreateResultMessage("s", 0.01, 1000))
+	msg := <-msgCh
+	if _, ok := msg.(*claude.ResultMessage); !ok {
+		t.Fatalf("expected ResultMessage, got %T", msg)
+	}
+}
+
+func TestQueryLargePromptThresholdOption(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	threshold := 10
+	options := &claude.ClaudeAgentOptions{LargePromptThreshold: &threshold}
+
+	// At the threshold the prompt is still passed to the transport as an argument
+	written := make(chan string, 10)
+	small := NewMockTransport([]map[string]interface{}{CreateResultMessage("s", 0.01, 1000)})
+	small.WriteFunc = func(ctx context.Context, data string) error {
+		written <- data
+		return nil
+	}
+	msgCh, errCh, err := claude.Query(ctx, strings.Repeat("x", threshold), options, small)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	CollectMessages(msgCh, errCh)
+	if len(written) != 0 {
+		t.Errorf("expected no writes for a prompt at the threshold, got %d", len(written))
+	}
+
+	// Above it the prompt is streamed
+	large := NewAdvancedMockTransport()
+	if _, _, err := claude.Query(ctx, strings.Repeat("x", threshold+1), options, large); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(writtenUserPrompts(large)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(writtenUserPrompts(large)) != 1 {
+		t.Error("expected a prompt above the threshold to be written over stdin")
+	}
+}
@@ -0,0 +1,101 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// flakyConnectTransport fails its first Connect.
+type flakyConnectTransport struct {
+	*AdvancedMockTransport
+	attempts atomic.Int32
+}
+
+func (f *flakyConnectTransport) Connect(ctx context.Context) error {
+	if f.attempts.Add(1) == 1 {
+		return errors.New("spawn failed")
+	}
+	return f.AdvancedMockTransport.Connect(ctx)
+}
+
+func TestClientLifecycleErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client := claude.NewClaudeSDKClientWithTransport(nil, NewAdvancedMockTransport())
+
+	// Before Connect
+	_, errCh := client.Query(ctx, "hi")
+	if err := <-errCh; !errors.Is(err, claude.ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected from Query, got %v", err)
+	}
+	if err := client.Interrupt(ctx); !errors.Is(err, claude.ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected from Interrupt, got %v", err)
+	}
+	var connErr *claude.CLIConnectionError
+	if err := client.SetModel(ctx, "claude-haiku-4-5"); !errors.As(err, &connErr) {
+		t.Errorf("expected lifecycle errors to stay CLIConnectionErrors, got %T", err)
+	}
+
+	// Connected
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := client.Connect(ctx); !errors.Is(err, claude.ErrAlreadyConnected) {
+		t.Errorf("expected ErrAlreadyConnected, got %v", err)
+	}
+	if err := client.Interrupt(ctx); err != nil {
+		t.Errorf("Interrupt failed while connected: %v", err)
+	}
+
+	// Closed, idempotently
+	if err := client.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+	if err := client.Interrupt(ctx); !errors.Is(err, claude.ErrClosed) {
+		t.Errorf("expected ErrClosed from Interrupt, got %v", err)
+	}
+	if err := client.Connect(ctx); !errors.Is(err, claude.ErrClosed) {
+		t.Errorf("expected ErrClosed from Connect, got %v", err)
+	}
+}
+
+func TestClientCloseBeforeConnect(t *testing.T) {
+	client := claude.NewClaudeSDKClient(nil)
+	if err := client.Close(); err != nil {
+		t.Errorf("Close of an unconnected client failed: %v", err)
+	}
+	if err := client.Connect(context.Background()); !errors.Is(err, claude.ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestClientConnectRetryAfterFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	transport := &flakyConnectTransport{AdvancedMockTransport: NewAdvancedMockTransport()}
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	defer client.Close()
+
+	if err := client.Connect(ctx); err == nil || errors.Is(err, claude.ErrAlreadyConnected) {
+		t.Fatalf("expected the transport's error, got %v", err)
+	}
+	if err := client.Interrupt(ctx); !errors.Is(err, claude.ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected after a failed Connect, got %v", err)
+	}
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("retrying Connect failed: %v", err)
+	}
+	if err := client.Interrupt(ctx); err != nil {
+		t.Errorf("Interrupt failed after retry: %v", err)
+	}
+}
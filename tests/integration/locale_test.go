@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestTimezoneAppliesToTranscriptAndTimeline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	zone := time.FixedZone("UTC+05:30", 5*3600+1800)
+	rec, err := claude.NewTranscriptRecorder(claude.TranscriptOptions{Path: filepath.Join(t.TempDir(), "session.jsonl")})
+	if err != nil {
+		t.Fatalf("NewTranscriptRecorder failed: %v", err)
+	}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		Timezone:       zone,
+		Transcript:     rec,
+		RecordTimeline: true,
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.QueryWithSession(ctx, "hi", "default"); err != nil {
+		t.Fatalf("QueryWithSession failed: %v", err)
+	}
+	transport.QueueResponse(CreateAssistantTextMessage("hello"))
+	transport.QueueResponse(CreateResultMessage("s", 0.01, 1000))
+	for range client.ReceiveResponse(ctx) {
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	files, _ := rec.Files()
+	if len(files) != 1 {
+		t.Fatalf("expected one transcript file, got %v", files)
+	}
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("failed to open transcript: %v", err)
+	}
+	defer file.Close()
+	records := 0
+	for scanner := bufio.NewScanner(file); scanner.Scan(); records++ {
+		var record claude.TranscriptRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid transcript line %q: %v", scanner.Text(), err)
+		}
+		if _, offset := record.Time.Zone(); offset != 5*3600+1800 {
+			t.Errorf("expected +05:30 timestamps, got %s", record.Time)
+		}
+	}
+	if records == 0 {
+		t.Fatal("expected transcript records")
+	}
+
+	timeline := client.Timeline()
+	if timeline == nil || len(timeline.Events) == 0 {
+		t.Fatal("expected timeline events")
+	}
+	for _, event := range timeline.Events {
+		if event.Start.Location() != zone || event.End.Location() != zone {
+			t.Errorf("expected times in %s, got %s - %s", zone, event.Start, event.End)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestMaxOutputTokensPerQuery(t *testing.T) {
+	cliPath := writeFakeCLI(t, `echo "{\"type\":\"result\",\"subtype\":\"success\",\"duration_ms\":1,\"duration_api_ms\":1,\"is_error\":false,\"num_turns\":1,\"session_id\":\"s\",\"result\":\"$CLAUDE_CODE_MAX_OUTPUT_TOKENS\"}"`+"\n")
+
+	// Let Query create the transport so it can apply the override
+	t.Setenv("PATH", filepath.Dir(cliPath)+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	limit := 4096
+	options := &claude.ClaudeAgentOptions{MaxOutputTokens: &limit}
+	run := func(ctx context.Context) *claude.ResultMessage {
+		t.Helper()
+		msgCh, errCh, err := claude.Query(ctx, "hi", options, nil)
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		messages, err := CollectMessages(msgCh, errCh)
+		if err != nil || len(messages) != 1 {
+			t.Fatalf("unexpected query outcome: %v, %d messages", err, len(messages))
+		}
+		return messages[0].(*claude.ResultMessage)
+	}
+
+	if result := run(context.Background()); *result.Result != "4096" || result.MaxOutputTokens != 4096 {
+		t.Errorf("expected the option's limit, got env %q and MaxOutputTokens %d", *result.Result, result.MaxOutputTokens)
+	}
+	if result := run(claude.WithMaxOutputTokens(context.Background(), 256)); *result.Result != "256" || result.MaxOutputTokens != 256 {
+		t.Errorf("expected the per-query override, got env %q and MaxOutputTokens %d", *result.Result, result.MaxOutputTokens)
+	}
+
+	if _, _, err := claude.Query(claude.WithMaxOutputTokens(context.Background(), 0), "hi", options, nil); err == nil {
+		t.Error("expected a non-positive limit to be rejected")
+	}
+}
+
+func TestMaxOutputTokensOverrideOnSharedClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	transport := &reconnectableTransport{}
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// Alone on the client, an override restarts the session with it
+	if err := client.QueryWithSession(claude.WithMaxOutputTokens(ctx, 256), "hi", "default"); err != nil {
+		t.Fatalf("Query with an override failed: %v", err)
+	}
+	if transport.connects != 2 {
+		t.Fatalf("expected the override to restart the session, got %d connects", transport.connects)
+	}
+	transport.active().QueueResponse(CreateResultMessage("s", 0.001, 100))
+	for range client.ReceiveResponse(ctx) {
+	}
+
+	// With Sessions open, a restart would abandon their turns
+	session, err := client.NewSession(ctx, "other")
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	defer session.Close()
+	if err := session.Send(claude.WithMaxOutputTokens(ctx, 512), "hi"); err == nil {
+		t.Error("expected an override on a shared client to be rejected")
+	}
+	if transport.connects != 2 {
+		t.Errorf("expected no restart, got %d connects", transport.connects)
+	}
+}
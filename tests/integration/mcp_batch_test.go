@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/mcp"
+)
+
+func TestMcpBatchRequest(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	server := mcp.CreateSdkMcpServer("calc", "1.0.0", []*mcp.SdkMcpTool{
+		mcp.Tool("echo", "Echoes its input", map[string]string{"text": "string"}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			text, _ := args["text"].(string)
+			return mcp.TextContent(text), nil
+		}),
+	})
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		McpServers: map[string]claude.McpServerConfig{"calc": server.ToConfig()},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	call := func(id float64, text string) map[string]interface{} {
+		return map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      id,
+			"method":  "tools/call",
+			"params": map[string]interface{}{
+				"name":      "echo",
+				"arguments": map[string]interface{}{"text": text},
+			},
+		}
+	}
+	transport.QueueResponse(map[string]interface{}{
+		"type":       "control_request",
+		"request_id": "mcp_batch",
+		"request": map[string]interface{}{
+			"subtype":     "mcp_message",
+			"server_name": "calc",
+			"message": []interface{}{
+				call(1, "one"),
+				map[string]interface{}{"jsonrpc": "2.0", "method": "notifications/initialized"},
+				"not a request",
+				call(2, "two"),
+			},
+		},
+	})
+
+	response, ok := transport.WaitForControlResponse("mcp_batch", time.Second)
+	if !ok {
+		t.Fatal("Expected control response for batch")
+	}
+	inner, _ := response["response"].(map[string]interface{})
+	responses, _ := inner["mcp_response"].([]interface{})
+	if len(responses) != 3 {
+		t.Fatalf("Expected 3 responses (none for the notification), got %v", inner["mcp_response"])
+	}
+
+	first, _ := responses[0].(map[string]interface{})
+	if first["id"] != float64(1) || first["result"] == nil {
+		t.Errorf("Expected the result for id 1 first, got %v", first)
+	}
+	invalid, _ := responses[1].(map[string]interface{})
+	if rpcErr, _ := invalid["error"].(map[string]interface{}); rpcErr["code"] != float64(-32600) {
+		t.Errorf("Expected an invalid request error for the non-object entry, got %v", invalid)
+	}
+	last, _ := responses[2].(map[string]interface{})
+	if last["id"] != float64(2) || last["result"] == nil {
+		t.Errorf("Expected the result for id 2 last, got %v", last)
+	}
+}
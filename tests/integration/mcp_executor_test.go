@@ -0,0 +1,164 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/mcp"
+)
+
+func queueToolCall(transport *AdvancedMockTransport, requestID, server, tool string) {
+	transport.QueueResponse(map[string]interface{}{
+		"type":       "control_request",
+		"request_id": requestID,
+		"request": map[string]interface{}{
+			"subtype":     "mcp_message",
+			"server_name": server,
+			"message": map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      float64(1),
+				"method":  "tools/call",
+				"params": map[string]interface{}{
+					"name":      tool,
+					"arguments": map[string]interface{}{},
+				},
+			},
+		},
+	})
+}
+
+func mcpErrorCode(t *testing.T, response map[string]interface{}) float64 {
+	t.Helper()
+	inner, _ := response["response"].(map[string]interface{})
+	mcpResponse, _ := inner["mcp_response"].(map[string]interface{})
+	rpcErr, ok := mcpResponse["error"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected JSON-RPC error, got %v", mcpResponse)
+	}
+	code, _ := rpcErr["code"].(float64)
+	return code
+}
+
+func TestMcpExecutorIsolatesPanics(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	server := mcp.CreateSdkMcpServer("flaky", "1.0.0", []*mcp.SdkMcpTool{
+		mcp.Tool("explode", "Panics", map[string]string{}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			panic("boom")
+		}),
+	})
+	cfg := server.ToConfig()
+	cfg.Executor = &claude.McpServerExecutor{PoolSize: 1, IsolatePanics: true}
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		McpServers: map[string]claude.McpServerConfig{"flaky": cfg},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	queueToolCall(transport, "mcp_1", "flaky", "explode")
+
+	response, ok := transport.WaitForControlResponse("mcp_1", time.Second)
+	if !ok {
+		t.Fatal("Expected control response for panicking tool")
+	}
+	if code := mcpErrorCode(t, response); code != -32603 {
+		t.Errorf("Expected internal error code -32603, got %v", code)
+	}
+}
+
+func TestMcpExecutorCallTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	server := mcp.CreateSdkMcpServer("slow", "1.0.0", []*mcp.SdkMcpTool{
+		mcp.Tool("wait", "Blocks", map[string]string{}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			<-release
+			return mcp.TextContent("done"), nil
+		}),
+	})
+	cfg := server.ToConfig()
+	cfg.Executor = &claude.McpServerExecutor{PoolSize: 1, CallTimeout: 50 * time.Millisecond}
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		McpServers: map[string]claude.McpServerConfig{"slow": cfg},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	queueToolCall(transport, "mcp_1", "slow", "wait")
+	response, ok := transport.WaitForControlResponse("mcp_1", time.Second)
+	if !ok {
+		t.Fatal("Expected control response after call timeout")
+	}
+	if code := mcpErrorCode(t, response); code != -32000 {
+		t.Errorf("Expected timeout error code -32000, got %v", code)
+	}
+
+	// The only worker is still stuck and there is no queue, so the next call is rejected
+	queueToolCall(transport, "mcp_2", "slow", "wait")
+	response, ok = transport.WaitForControlResponse("mcp_2", time.Second)
+	if !ok {
+		t.Fatal("Expected control response for rejected call")
+	}
+	if code := mcpErrorCode(t, response); code != -32000 {
+		t.Errorf("Expected busy error code -32000, got %v", code)
+	}
+}
+
+func TestMcpExecutorAcceptsCallsUpToPoolSize(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	// Each call waits until both are running, so both must get a worker
+	var arrived sync.WaitGroup
+	server := mcp.CreateSdkMcpServer("pair", "1.0.0", []*mcp.SdkMcpTool{
+		mcp.Tool("meet", "Waits for the other call", map[string]string{}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			arrived.Done()
+			arrived.Wait()
+			return mcp.TextContent("met"), nil
+		}),
+	})
+	cfg := server.ToConfig()
+	cfg.Executor = &claude.McpServerExecutor{PoolSize: 2}
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		McpServers: map[string]claude.McpServerConfig{"pair": cfg},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	for round := 0; round < 10; round++ {
+		first, second := fmt.Sprintf("mcp_%d_a", round), fmt.Sprintf("mcp_%d_b", round)
+		arrived.Add(2)
+		queueToolCall(transport, first, "pair", "meet")
+		queueToolCall(transport, second, "pair", "meet")
+		for _, requestID := range []string{first, second} {
+			response, ok := transport.WaitForControlResponse(requestID, time.Second)
+			if !ok {
+				t.Fatalf("Expected control response for %s", requestID)
+			}
+			inner, _ := response["response"].(map[string]interface{})
+			if mcpResponse, _ := inner["mcp_response"].(map[string]interface{}); mcpResponse["error"] != nil {
+				t.Fatalf("Expected %s to run with a free worker, got %v", requestID, mcpResponse)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/mcp"
+)
+
+func TestMcpProgressNotifications(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	server := mcp.CreateSdkMcpServer("indexer", "1.0.0", []*mcp.SdkMcpTool{
+		mcp.Tool("index", "Indexes files", map[string]string{}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			for i := 1; i <= 2; i++ {
+				if err := mcp.ReportProgress(ctx, float64(i), 2, "Indexing"); err != nil {
+					return nil, err
+				}
+			}
+			return mcp.TextContent("done"), nil
+		}),
+	})
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		McpServers: map[string]claude.McpServerConfig{"indexer": server.ToConfig()},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	call := func(requestID string, params map[string]interface{}) {
+		transport.QueueResponse(map[string]interface{}{
+			"type":       "control_request",
+			"request_id": requestID,
+			"request": map[string]interface{}{
+				"subtype":     "mcp_message",
+				"server_name": "indexer",
+				"message":     map[string]interface{}{"jsonrpc": "2.0", "id": 1.0, "method": "tools/call", "params": params},
+			},
+		})
+		if _, ok := transport.WaitForControlResponse(requestID, time.Second); !ok {
+			t.Fatalf("Expected control response for %s", requestID)
+		}
+	}
+
+	// Without a progressToken, ReportProgress sends nothing
+	call("mcp_plain", map[string]interface{}{"name": "index", "arguments": map[string]interface{}{}})
+	if sent := transport.ControlRequests("mcp_message"); len(sent) != 0 {
+		t.Fatalf("Expected no notifications without a progress token, got %v", sent)
+	}
+
+	call("mcp_progress", map[string]interface{}{
+		"name":      "index",
+		"arguments": map[string]interface{}{},
+		"_meta":     map[string]interface{}{"progressToken": "tok-1"},
+	})
+	sent := transport.ControlRequests("mcp_message")
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 progress notifications, got %v", sent)
+	}
+	request, _ := sent[1]["request"].(map[string]interface{})
+	message, _ := request["message"].(map[string]interface{})
+	params, _ := message["params"].(map[string]interface{})
+	if request["server_name"] != "indexer" || message["method"] != "notifications/progress" {
+		t.Errorf("Expected a progress notification from indexer, got %v", request)
+	}
+	if params["progressToken"] != "tok-1" || params["progress"] != 2.0 || params["total"] != 2.0 || params["message"] != "Indexing" {
+		t.Errorf("Unexpected progress params: %v", params)
+	}
+	if _, hasID := message["id"]; hasID {
+		t.Errorf("Expected a notification without an id, got %v", message)
+	}
+}
@@ -0,0 +1,168 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestMetadataReachesHooksAndPermissions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var permMetadata, hookMetadata claude.Metadata
+	options := &claude.ClaudeAgentOptions{
+		Metadata: claude.Metadata{"tenant": "acme", "user": "default"},
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			permMetadata = permCtx.Metadata
+			if claude.MetadataFromContext(ctx)["user"] != permCtx.Metadata["user"] {
+				t.Error("expected the callback context to carry the same metadata")
+			}
+			return claude.PermissionResultAllow{Behavior: "allow"}, nil
+		},
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventPreToolUse: {{
+				Hooks: []claude.HookCallback{
+					func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+						mu.Lock()
+						defer mu.Unlock()
+						hookMetadata = hookCtx.Metadata
+						return claude.HookJSONOutput{}, nil
+					},
+				},
+			}},
+		},
+	}
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// Metadata on the turn's context overrides the options
+	turnCtx := claude.WithMetadata(ctx, claude.Metadata{"user": "u-42"})
+	if err := client.QueryWithSession(turnCtx, "hello", "default"); err != nil {
+		t.Fatalf("QueryWithSession failed: %v", err)
+	}
+	transport.QueueResponse(permissionRequest("req_perm", "Write", map[string]interface{}{"file_path": "a.go"}))
+	transport.QueueResponse(hookCallbackRequest("req_hook", "tool_1"))
+	for _, id := range []string{"req_perm", "req_hook"} {
+		if _, ok := transport.WaitForControlResponse(id, time.Second); !ok {
+			t.Fatalf("no response to %s", id)
+		}
+	}
+
+	mu.Lock()
+	if permMetadata["user"] != "u-42" || permMetadata["tenant"] != "acme" {
+		t.Errorf("unexpected CanUseTool metadata: %v", permMetadata)
+	}
+	if hookMetadata["user"] != "u-42" || hookMetadata["tenant"] != "acme" {
+		t.Errorf("unexpected hook metadata: %v", hookMetadata)
+	}
+	mu.Unlock()
+	transport.QueueResponse(CreateResultMessage("s", 0.001, 100))
+	for range client.ReceiveResponse(ctx) {
+	}
+
+	// The next turn without metadata falls back to the options
+	if err := client.QueryWithSession(ctx, "again", "default"); err != nil {
+		t.Fatalf("QueryWithSession failed: %v", err)
+	}
+	transport.QueueResponse(permissionRequest("req_perm_2", "Write", map[string]interface{}{"file_path": "b.go"}))
+	if _, ok := transport.WaitForControlResponse("req_perm_2", time.Second); !ok {
+		t.Fatal("no response to req_perm_2")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if permMetadata["user"] != "default" {
+		t.Errorf("expected the options metadata on the next turn, got %v", permMetadata)
+	}
+}
+
+func TestMetadataPerSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	users := make(chan interface{}, 4)
+	options := &claude.ClaudeAgentOptions{
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			users <- permCtx.Metadata["user"]
+			return claude.PermissionResultAllow{Behavior: "allow"}, nil
+		},
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventPreToolUse: {{
+				Hooks: []claude.HookCallback{
+					func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+						users <- hookCtx.Metadata["user"]
+						return claude.HookJSONOutput{}, nil
+					},
+				},
+			}},
+		},
+	}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"a", "b"} {
+		session, err := client.NewSession(ctx, id)
+		if err != nil {
+			t.Fatalf("NewSession failed: %v", err)
+		}
+		defer session.Close()
+		if err := session.Send(claude.WithMetadata(ctx, claude.Metadata{"user": "user-" + id}), "hello"); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := CollectMessages(session.ReceiveResponse(ctx)); err != nil {
+				t.Errorf("session %s failed: %v", session.ID(), err)
+			}
+		}()
+	}
+
+	expect := func(requestID, user string) {
+		t.Helper()
+		if _, ok := transport.WaitForControlResponse(requestID, time.Second); !ok {
+			t.Fatalf("no response to %s", requestID)
+		}
+		if got := <-users; got != user {
+			t.Errorf("%s: expected metadata of %s, got %v", requestID, user, got)
+		}
+	}
+
+	// The CLI answers the sessions' turns in order
+	transport.QueueResponse(permissionRequest("req_perm_a", "Write", map[string]interface{}{"file_path": "a.go"}))
+	expect("req_perm_a", "user-a")
+
+	// Hook inputs name their session
+	transport.QueueResponse(map[string]interface{}{
+		"type":       "control_request",
+		"request_id": "req_hook_b",
+		"request": map[string]interface{}{
+			"subtype":     "hook_callback",
+			"callback_id": "hook_0",
+			"input":       map[string]interface{}{"hook_event_name": "PreToolUse", "tool_name": "Bash", "session_id": "b"},
+		},
+	})
+	expect("req_hook_b", "user-b")
+
+	transport.QueueResponse(CreateResultMessage("a", 0.001, 100))
+	transport.QueueResponse(permissionRequest("req_perm_b", "Write", map[string]interface{}{"file_path": "b.go"}))
+	expect("req_perm_b", "user-b")
+	transport.QueueResponse(CreateResultMessage("b", 0.001, 100))
+	wg.Wait()
+}
@@ -0,0 +1,56 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestMetricsObserveParsingRoutingAndCallbacks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	metrics := claude.NewExpvarMetrics()
+	options := &claude.ClaudeAgentOptions{
+		Metrics:       metrics,
+		ProfileLabels: true,
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			return claude.PermissionResultAllow{Behavior: "allow"}, nil
+		},
+	}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	msgCh := client.ReceiveMessages(ctx)
+	transport.QueueResponse(permissionRequest("req_perm", "Read", map[string]interface{}{"file_path": "a.go"}))
+	if _, ok := transport.WaitForControlResponse("req_perm", time.Second); !ok {
+		t.Fatal("no response to permission request")
+	}
+	transport.QueueResponse(CreateResultMessage("s", 0.01, 1000))
+	if _, ok := (<-msgCh).(*claude.ResultMessage); !ok {
+		t.Fatal("expected ResultMessage")
+	}
+
+	seen := make(map[string]uint64)
+	for _, h := range metrics.Snapshot() {
+		for k, v := range h.Labels {
+			seen[h.Name+" "+k+"="+v] += h.Count
+		}
+	}
+	for _, want := range []string{
+		claude.MetricRouteDuration + " type=result",
+		claude.MetricRouteDuration + " type=control_request",
+		claude.MetricCallbackDuration + " kind=can_use_tool",
+		claude.MetricParseDuration + " stage=parse",
+	} {
+		if seen[want] == 0 {
+			t.Errorf("expected an observation for %s, got %v", want, seen)
+		}
+	}
+}
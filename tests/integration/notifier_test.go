@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestNotifierEveryTurns(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	posted := make(chan map[string]interface{}, 4)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		body["authorization"] = r.Header.Get("Authorization")
+		posted <- body
+	}))
+	defer webhook.Close()
+
+	digests := make(chan claude.Digest, 4)
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		Notifier: &claude.Notifier{
+			EveryTurns: 2,
+			OnDigest:   func(d claude.Digest) { digests <- d },
+			WebhookURL: webhook.URL,
+			Headers:    map[string]string{"Authorization": "Bearer t"},
+		},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	turns := []struct {
+		tool  string
+		input map[string]interface{}
+		cost  float64
+	}{
+		{"TodoWrite", map[string]interface{}{"todos": []interface{}{
+			map[string]interface{}{"content": "Fix tests", "status": "completed", "activeForm": "Fixing tests"},
+			map[string]interface{}{"content": "Update docs", "status": "in_progress", "activeForm": "Updating docs"},
+		}}, 0.10},
+		{"Bash", map[string]interface{}{"command": "go test ./..."}, 0.25}, // Cumulative
+	}
+	for i, turn := range turns {
+		msgCh, errCh := client.Query(ctx, "continue")
+		transport.QueueResponse(CreateAssistantToolUseMessage("Step done", "tool_"+turn.tool, turn.tool, turn.input))
+		transport.QueueResponse(CreateResultMessage("s1", turn.cost, 100))
+		if _, err := CollectMessages(msgCh, errCh); err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		if i == 0 {
+			select {
+			case d := <-digests:
+				t.Fatalf("expected no digest after one turn, got %+v", d)
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+	}
+
+	var digest claude.Digest
+	select {
+	case digest = <-digests:
+	case <-ctx.Done():
+		t.Fatal("expected a digest after two turns")
+	}
+	if digest.Turns != 2 || digest.ToolUses != 2 || digest.SessionID != "s1" || digest.CurrentTask != "Updating docs" || digest.LastText != "Step done" {
+		t.Errorf("unexpected digest: %+v", digest)
+	}
+	if len(digest.LastTools) != 2 || digest.LastTools[1] != "Bash: go test ./..." {
+		t.Errorf("unexpected recent tools: %v", digest.LastTools)
+	}
+	if digest.CostUSD < 0.2499 || digest.CostUSD > 0.2501 {
+		t.Errorf("expected the cumulative cost $0.25, got %v", digest.CostUSD)
+	}
+
+	select {
+	case body := <-posted:
+		if body["turns"] != float64(2) || body["authorization"] != "Bearer t" || !strings.Contains(body["text"].(string), "Working on: Updating docs") {
+			t.Errorf("unexpected webhook body: %v", body)
+		}
+	case <-ctx.Done():
+		t.Fatal("expected the digest to be posted")
+	}
+}
+
+func TestNotifierInterval(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	digests := make(chan claude.Digest, 16)
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		Notifier: &claude.Notifier{
+			Interval: 20 * time.Millisecond,
+			OnDigest: func(d claude.Digest) {
+				select {
+				case digests <- d:
+				default:
+				}
+			},
+		},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	// Digests arrive while nothing happens, e.g. during a long tool call
+	for i := 0; i < 2; i++ {
+		select {
+		case d := <-digests:
+			if d.Turns != 0 || d.Elapsed <= 0 {
+				t.Errorf("unexpected digest: %+v", d)
+			}
+		case <-ctx.Done():
+			t.Fatal("expected periodic digests")
+		}
+	}
+
+	client.Close()
+	time.Sleep(30 * time.Millisecond)
+	for len(digests) > 0 {
+		<-digests
+	}
+	select {
+	case d := <-digests:
+		t.Errorf("expected no digests after Close, got %+v", d)
+	case <-time.After(60 * time.Millisecond):
+	}
+}
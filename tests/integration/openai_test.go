@@ -0,0 +1,349 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/openai"
+)
+
+// newEchoTransport returns a transport echoing each prompt, for
+// ServerOptions.NewTransport.
+func newEchoTransport() (claude.Transport, error) {
+	return &sessionEchoTransport{NewAdvancedMockTransport()}, nil
+}
+
+func newOpenAIServer(t *testing.T, options *openai.ServerOptions) *httptest.Server {
+	if options == nil {
+		options = &openai.ServerOptions{Model: "local-claude"}
+	}
+	if options.NewTransport == nil {
+		options.NewTransport = newEchoTransport
+	}
+	server := httptest.NewServer(openai.NewServer(nil, options))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func postChat(t *testing.T, url, body string) *http.Response {
+	resp, err := http.Post(url+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestOpenAIChatCompletions(t *testing.T) {
+	server := newOpenAIServer(t, nil)
+
+	// The request's conversation is sent as a transcript; the echo
+	// transport answers with the prompt it was sent
+	resp := postChat(t, server.URL, `{"model":"gpt-4o","user":"u1","messages":[
+		{"role":"system","content":"Be brief."},
+		{"role":"user","content":[{"type":"text","text":"Hi"}]}]}`)
+	var completion struct {
+		Object  string `json:"object"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected response %d: %v", resp.StatusCode, err)
+	}
+	if completion.Object != "chat.completion" || completion.Model != "local-claude" || len(completion.Choices) != 1 {
+		t.Fatalf("unexpected completion %+v", completion)
+	}
+	choice := completion.Choices[0]
+	if choice.Message.Role != "assistant" || choice.Message.Content != "System: Be brief.\n\nUser: Hi" || choice.FinishReason != "stop" {
+		t.Errorf("unexpected choice %+v", choice)
+	}
+
+	// Later requests replay their whole conversation too, streamed
+	resp = postChat(t, server.URL, `{"stream":true,"user":"u1","messages":[
+		{"role":"user","content":"Hi"},
+		{"role":"assistant","content":"Hello!"},
+		{"role":"user","content":"Again. Please."}]}`)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %q", ct)
+	}
+	var content strings.Builder
+	var finishReason string
+	done := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+		var chunk struct {
+			Object  string `json:"object"`
+			Choices []struct {
+				Delta        struct{ Content string } `json:"delta"`
+				FinishReason *string                  `json:"finish_reason"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil || chunk.Object != "chat.completion.chunk" {
+			t.Fatalf("unexpected event %q: %v", data, err)
+		}
+		content.WriteString(chunk.Choices[0].Delta.Content)
+		if chunk.Choices[0].FinishReason != nil {
+			finishReason = *chunk.Choices[0].FinishReason
+		}
+	}
+	if !done || content.String() != "User: Hi\n\nAssistant: Hello!\n\nUser: Again. Please." || finishReason != "stop" {
+		t.Errorf("unexpected stream: done=%v content=%q finish_reason=%q", done, content.String(), finishReason)
+	}
+}
+
+func TestOpenAIErrors(t *testing.T) {
+	server := newOpenAIServer(t, nil)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", `{`},
+		{"no messages", `{"messages":[]}`},
+		{"last message not from user", `{"messages":[{"role":"assistant","content":"hi"}]}`},
+		{"image content", `{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"x"}}]}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := postChat(t, server.URL, tt.body)
+			var body struct {
+				Error struct {
+					Message string `json:"message"`
+					Type    string `json:"type"`
+				} `json:"error"`
+			}
+			json.NewDecoder(resp.Body).Decode(&body)
+			if resp.StatusCode != http.StatusBadRequest || body.Error.Type != "invalid_request_error" || body.Error.Message == "" {
+				t.Errorf("expected an invalid_request_error, got %d %+v", resp.StatusCode, body)
+			}
+		})
+	}
+
+	resp, err := http.Get(server.URL + "/v1/models")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var models struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&models); err != nil || len(models.Data) != 1 || models.Data[0].ID != "local-claude" {
+		t.Errorf("unexpected models %+v: %v", models, err)
+	}
+}
+
+func TestOpenAIRequestChecks(t *testing.T) {
+	server := newOpenAIServer(t, &openai.ServerOptions{
+		BearerToken:    "secret",
+		AllowedOrigins: []string{"https://app.example.com"},
+	})
+	body := `{"messages":[{"role":"user","content":"hi"}]}`
+
+	tests := []struct {
+		name        string
+		contentType string
+		header      http.Header
+		body        string
+		status      int
+	}{
+		{"missing token", "application/json", nil, body, http.StatusUnauthorized},
+		{"wrong token", "application/json", http.Header{"Authorization": {"Bearer nope"}}, body, http.StatusUnauthorized},
+		{"foreign origin", "application/json", http.Header{"Authorization": {"Bearer secret"}, "Origin": {"https://evil.example"}}, body, http.StatusForbidden},
+		{"simple cross-origin request", "text/plain", http.Header{"Authorization": {"Bearer secret"}}, body, http.StatusUnsupportedMediaType},
+		{"oversized body", "application/json", http.Header{"Authorization": {"Bearer secret"}}, `{"user":"` + strings.Repeat("x", 17<<20) + `"}`, http.StatusRequestEntityTooLarge},
+		{"allowed origin", "application/json; charset=utf-8", http.Header{"Authorization": {"Bearer secret"}, "Origin": {"https://app.example.com"}}, body, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/chat/completions", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, resp.StatusCode)
+			}
+		})
+	}
+}
+
+// chatReply posts a conversation ending with last and returns the reply's
+// content, or its status if it failed.
+func chatReply(t *testing.T, url, last string) string {
+	t.Helper()
+	resp := postChat(t, url, `{"user":"u1","messages":[
+		{"role":"user","content":"Hi"},
+		{"role":"assistant","content":"Hello!"},
+		{"role":"user","content":"`+last+`"}]}`)
+	var completion struct {
+		Choices []struct {
+			Message struct{ Content string } `json:"message"`
+		} `json:"choices"`
+	}
+	json.NewDecoder(resp.Body).Decode(&completion)
+	if resp.StatusCode != http.StatusOK || len(completion.Choices) != 1 {
+		return fmt.Sprintf("status %d", resp.StatusCode)
+	}
+	return completion.Choices[0].Message.Content
+}
+
+func TestOpenAIRequestsUseSeparateConversations(t *testing.T) {
+	var mu sync.Mutex
+	var transports []*AdvancedMockTransport
+	server := newOpenAIServer(t, &openai.ServerOptions{
+		NewTransport: func() (claude.Transport, error) {
+			transport := NewAdvancedMockTransport()
+			mu.Lock()
+			transports = append(transports, transport)
+			mu.Unlock()
+			return &sessionEchoTransport{transport}, nil
+		},
+	})
+
+	var wg sync.WaitGroup
+	replies := make([]string, 2)
+	for i, last := range []string{"Again", "Something else"} {
+		wg.Add(1)
+		go func(i int, last string) {
+			defer wg.Done()
+			replies[i] = chatReply(t, server.URL, last)
+		}(i, last)
+	}
+	wg.Wait()
+	for i, last := range []string{"Again", "Something else"} {
+		if want := "User: Hi\n\nAssistant: Hello!\n\nUser: " + last; replies[i] != want {
+			t.Errorf("expected %q, got %q", want, replies[i])
+		}
+	}
+
+	// Each request had a CLI of its own, stopped once it was answered
+	mu.Lock()
+	defer mu.Unlock()
+	if len(transports) != 2 {
+		t.Fatalf("expected a transport per request, got %d", len(transports))
+	}
+	for _, transport := range transports {
+		prompts := 0
+		for _, data := range transport.GetWrittenMessages() {
+			if strings.Contains(data, `"type":"user"`) {
+				prompts++
+			}
+		}
+		for deadline := time.Now().Add(time.Second); transport.IsReady() && time.Now().Before(deadline); {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if prompts != 1 || transport.IsReady() {
+			t.Errorf("expected one prompt on a closed transport, got %d prompts, ready=%v", prompts, transport.IsReady())
+		}
+	}
+}
+
+func TestOpenAIDisconnectedCallerDoesNotLeakReply(t *testing.T) {
+	stalled := NewAdvancedMockTransport()
+	var requests atomic.Int32
+	server := newOpenAIServer(t, &openai.ServerOptions{
+		NewTransport: func() (claude.Transport, error) {
+			if requests.Add(1) == 1 {
+				return stalled, nil
+			}
+			return newEchoTransport()
+		},
+	})
+
+	// The first caller disconnects in the middle of its streamed reply
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/v1/chat/completions",
+		strings.NewReader(`{"stream":true,"messages":[{"role":"user","content":"Write a long story"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	stalled.QueueResponse(CreateAssistantTextMessage("Once upon a time."))
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && !strings.Contains(scanner.Text(), "Once upon a time.") {
+	}
+	cancel()
+
+	// Its CLI is stopped, and the rest of its reply reaches no one
+	for deadline := time.Now().Add(time.Second); stalled.IsReady() && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stalled.IsReady() {
+		t.Error("expected the disconnected caller's CLI to be stopped")
+	}
+	stalled.QueueResponse(CreateAssistantTextMessage("The end."))
+	stalled.QueueResponse(CreateResultMessage("s1", 0.01, 100))
+	if got, want := chatReply(t, server.URL, "Again"), "User: Hi\n\nAssistant: Hello!\n\nUser: Again"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+// failFirstPromptTransport fails to send the first prompt and echoes the
+// rest.
+type failFirstPromptTransport struct {
+	sessionEchoTransport
+	failed *atomic.Bool
+}
+
+func (m *failFirstPromptTransport) Write(ctx context.Context, data string) error {
+	if strings.Contains(data, `"type":"user"`) && m.failed.CompareAndSwap(false, true) {
+		return errors.New("write failed")
+	}
+	return m.sessionEchoTransport.Write(ctx, data)
+}
+
+func TestOpenAIStatelessRequests(t *testing.T) {
+	var failed atomic.Bool
+	server := newOpenAIServer(t, &openai.ServerOptions{
+		NewTransport: func() (claude.Transport, error) {
+			return &failFirstPromptTransport{sessionEchoTransport{NewAdvancedMockTransport()}, &failed}, nil
+		},
+	})
+
+	if got := chatReply(t, server.URL, "Again"); got != "status 500" {
+		t.Fatalf("expected the first query to fail, got %q", got)
+	}
+	// Every request replays its own conversation, whatever its user field
+	// and whether an earlier request failed
+	for _, last := range []string{"Again", "Something else"} {
+		want := "User: Hi\n\nAssistant: Hello!\n\nUser: " + last
+		if got := chatReply(t, server.URL, last); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestGetOutputStyles(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	transport.InitResponse = map[string]interface{}{
+		"output_style":            "default",
+		"available_output_styles": []interface{}{"default", "Explanatory", "Learning"},
+	}
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+
+	if client.GetOutputStyles() != nil {
+		t.Error("Expected nil output styles before Connect")
+	}
+
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	styles := client.GetOutputStyles()
+	if styles == nil {
+		t.Fatal("Expected output styles after Connect")
+	}
+	if styles.Current != "default" {
+		t.Errorf("Expected current style 'default', got %q", styles.Current)
+	}
+	if len(styles.Available) != 3 || styles.Available[1] != "Explanatory" {
+		t.Errorf("Unexpected available styles: %v", styles.Available)
+	}
+
+	// Unknown styles are rejected without restarting
+	if err := client.SetOutputStyle(ctx, "Pirate"); err == nil {
+		t.Error("Expected error for unknown output style")
+	}
+}
+
+func TestSetOutputStyleRestartsSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	transport := &reconnectableTransport{}
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.SetOutputStyle(ctx, "Explanatory"); err != nil {
+		t.Fatalf("SetOutputStyle failed: %v", err)
+	}
+	if transport.connects != 2 {
+		t.Errorf("Expected session restart, got %d connects", transport.connects)
+	}
+}
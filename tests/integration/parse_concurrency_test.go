@@ -0,0 +1,57 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestSubprocessParseConcurrencyPreservesOrder(t *testing.T) {
+	// Every tenth message is large, so the workers finish out of order; one
+	// message spans two lines
+	cliPath := writeFakeCLI(t, `pad=$(printf '%04096d' 0)
+i=0
+while [ $i -lt 200 ]; do
+  text=$i
+  if [ $((i % 10)) -eq 0 ]; then text="$i $pad$pad$pad$pad"; fi
+  echo "{\"type\":\"assistant\",\"message\":{\"model\":\"m\",\"content\":[{\"type\":\"text\",\"text\":\"$text\"}]}}"
+  i=$((i + 1))
+done
+echo '{"type":"result","subtype":"success",'
+echo '"duration_ms":1,"duration_api_ms":1,"is_error":false,"num_turns":1,"session_id":"s"}'
+`)
+
+	options := &claude.ClaudeAgentOptions{ParseConcurrency: 4}
+	trans, err := claude.NewSubprocessCLITransport("hi", options, cliPath)
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	msgCh, errCh, err := claude.Query(context.Background(), "hi", options, trans)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	messages, err := CollectMessages(msgCh, errCh)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(messages) != 201 {
+		t.Fatalf("expected 201 messages, got %d", len(messages))
+	}
+	for i, msg := range messages[:200] {
+		assistant, ok := msg.(*claude.AssistantMessage)
+		if !ok {
+			t.Fatalf("message %d: expected AssistantMessage, got %T", i, msg)
+		}
+		text := assistant.Content[0].(claude.TextBlock).Text
+		var n int
+		if _, err := fmt.Sscanf(text, "%d", &n); err != nil || n != i {
+			t.Fatalf("message %d out of order: text starts %.10q", i, text)
+		}
+	}
+	if _, ok := messages[200].(*claude.ResultMessage); !ok {
+		t.Errorf("expected ResultMessage last, got %T", messages[200])
+	}
+}
@@ -0,0 +1,103 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestUpdatePermissionsGrantsToolMidSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var userCalls atomic.Int32
+	options := &claude.ClaudeAgentOptions{
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			userCalls.Add(1)
+			return claude.PermissionResultDeny{Behavior: "deny", Message: "ask the user first"}, nil
+		},
+	}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	npmTest := "npm test:*"
+	allow := claude.PermissionBehaviorAllow
+	acceptEdits := claude.PermissionModeAcceptEdits
+	err := client.UpdatePermissions(ctx, []claude.PermissionUpdate{
+		{
+			Type:     claude.PermissionUpdateTypeAddRules,
+			Rules:    []claude.PermissionRuleValue{{ToolName: "Bash", RuleContent: &npmTest}},
+			Behavior: &allow,
+		},
+		{Type: claude.PermissionUpdateTypeSetMode, Mode: &acceptEdits},
+	})
+	if err != nil {
+		t.Fatalf("UpdatePermissions failed: %v", err)
+	}
+
+	sawSetMode := false
+	for _, data := range transport.GetWrittenMessages() {
+		var msg map[string]interface{}
+		if json.Unmarshal([]byte(data), &msg) == nil {
+			request, _ := msg["request"].(map[string]interface{})
+			sawSetMode = sawSetMode || (request["subtype"] == "set_permission_mode" && request["mode"] == "acceptEdits")
+		}
+	}
+	if !sawSetMode {
+		t.Error("expected setMode to be sent as set_permission_mode")
+	}
+
+	// The granted command is allowed without asking, and the rule is forwarded
+	transport.QueueResponse(permissionRequest("req_allowed", "Bash", map[string]interface{}{"command": "npm test -- --watch=false"}))
+	resp, ok := transport.WaitForControlResponse("req_allowed", time.Second)
+	if !ok {
+		t.Fatal("no response to permission request")
+	}
+	inner, _ := resp["response"].(map[string]interface{})
+	if inner["behavior"] != "allow" {
+		t.Fatalf("expected allow, got %v", inner)
+	}
+	if updates, _ := inner["updatedPermissions"].([]interface{}); len(updates) != 1 {
+		t.Errorf("expected the rule to be forwarded to the CLI, got %v", inner["updatedPermissions"])
+	}
+
+	// Chained commands still go to the user's callback
+	transport.QueueResponse(permissionRequest("req_chained", "Bash", map[string]interface{}{"command": "npm test && rm -rf /"}))
+	resp, ok = transport.WaitForControlResponse("req_chained", time.Second)
+	if !ok {
+		t.Fatal("no response to permission request")
+	}
+	if inner, _ := resp["response"].(map[string]interface{}); inner["behavior"] != "deny" {
+		t.Errorf("expected deny for a chained command, got %v", inner)
+	}
+	if userCalls.Load() != 1 {
+		t.Errorf("expected exactly one CanUseTool call, got %d", userCalls.Load())
+	}
+}
+
+func TestUpdatePermissionsRulesRequireCanUseTool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client := claude.NewClaudeSDKClientWithTransport(nil, NewAdvancedMockTransport())
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	err := client.UpdatePermissions(ctx, []claude.PermissionUpdate{{
+		Type:  claude.PermissionUpdateTypeAddRules,
+		Rules: []claude.PermissionRuleValue{{ToolName: "Write"}},
+	}})
+	if err == nil {
+		t.Error("expected rule updates without CanUseTool to fail")
+	}
+}
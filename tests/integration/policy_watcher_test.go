@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// waitForEvents polls sink until it has n events of eventType.
+func waitForEvents(t *testing.T, sink *memorySink, eventType claude.EventType, n int) []claude.Event {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		events := sink.byType(eventType)
+		if len(events) >= n {
+			return events
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d %s events, got %d", n, eventType, len(events))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPolicyWatcherHotReload(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	writePolicy := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write policy: %v", err)
+		}
+	}
+	writePolicy(`{"rules": [{"tool": "Bash", "content": "rm:*", "action": "deny", "reason": "no deletes"}]}`)
+
+	watcher, err := claude.NewPolicyWatcher(path, claude.PolicyWatcherOptions{Interval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewPolicyWatcher failed: %v", err)
+	}
+	defer watcher.Close()
+
+	sink := &memorySink{}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{PolicyWatcher: watcher, EventSink: sink}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	seq := 0
+	// decide sends a PreToolUse hook callback for a Bash command and returns
+	// the permission decision, or "" if the policy made none
+	decide := func(command string) string {
+		t.Helper()
+		seq++
+		requestID := "req_" + string(rune('a'+seq))
+		transport.QueueResponse(map[string]interface{}{
+			"type":       "control_request",
+			"request_id": requestID,
+			"request": map[string]interface{}{
+				"subtype":     "hook_callback",
+				"callback_id": "hook_0",
+				"input": map[string]interface{}{
+					"hook_event_name": "PreToolUse",
+					"tool_name":       "Bash",
+					"tool_input":      map[string]interface{}{"command": command},
+				},
+			},
+		})
+		resp, ok := transport.WaitForControlResponse(requestID, time.Second)
+		if !ok {
+			t.Fatalf("no response to %s", requestID)
+		}
+		inner, _ := resp["response"].(map[string]interface{})
+		specific, _ := inner["hookSpecificOutput"].(map[string]interface{})
+		decision, _ := specific["permissionDecision"].(string)
+		return decision
+	}
+
+	if got := decide("rm -rf build"); got != "deny" {
+		t.Errorf("expected rm to be denied, got %q", got)
+	}
+	if got := decide("git push"); got != "" {
+		t.Errorf("expected no decision for git push, got %q", got)
+	}
+
+	// Tightening the file applies to the running client
+	writePolicy(`{"rules": [
+		{"tool": "Bash", "content": "rm:*", "action": "deny"},
+		{"tool": "Bash", "content": "git push", "action": "deny"},
+		{"tool": "Bash", "content": "git status", "action": "allow"}
+	]}`)
+	events := waitForEvents(t, sink, claude.EventTypePolicyReloaded, 1)
+	if reloaded := events[0].Data.(claude.PolicyReloadedEvent); reloaded.Version != 2 || reloaded.Rules != 3 {
+		t.Errorf("unexpected reload event: %+v", reloaded)
+	}
+	if got := decide("git push"); got != "deny" {
+		t.Errorf("expected git push to be denied after reload, got %q", got)
+	}
+	if got := decide("git status"); got != "allow" {
+		t.Errorf("expected git status to be allowed, got %q", got)
+	}
+
+	// A broken file is rejected and the previous policy stays in effect
+	writePolicy(`{"rules": [{"tool": "Bash", "action": "sometimes"}]}`)
+	events = waitForEvents(t, sink, claude.EventTypePolicyReloaded, 2)
+	if rejected := events[1].Data.(claude.PolicyReloadedEvent); rejected.Error == "" || rejected.Version != 2 {
+		t.Errorf("expected a rejection event for version 2, got %+v", rejected)
+	}
+	if got := decide("git push"); got != "deny" {
+		t.Errorf("expected the previous policy to stay in effect, got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestPollMessages(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	historySize := 3
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{HistorySize: &historySize}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	poll := func(timeout time.Duration, cursor uint64, limit int) (claude.MessagePage, error) {
+		pollCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return client.PollMessages(pollCtx, cursor, limit)
+	}
+
+	// Nothing yet: the long poll times out with the same cursor
+	page, err := poll(50*time.Millisecond, 0, 10)
+	if !errors.Is(err, context.DeadlineExceeded) || page.Cursor != 0 || len(page.Entries) != 0 {
+		t.Fatalf("expected an empty page at the deadline, got %+v, %v", page, err)
+	}
+
+	// A waiting poll returns as soon as a message arrives
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		transport.QueueResponse(CreateAssistantTextMessage("one"))
+		transport.QueueResponse(CreateResultMessage("s", 0.01, 100))
+	}()
+	page, err = poll(2*time.Second, 0, 1)
+	if err != nil || len(page.Entries) != 1 || page.Cursor != 1 {
+		t.Fatalf("expected the first message, got %+v, %v", page, err)
+	}
+	if _, ok := page.Entries[0].Message.(*claude.AssistantMessage); !ok {
+		t.Errorf("expected AssistantMessage, got %T", page.Entries[0].Message)
+	}
+	page, err = poll(2*time.Second, page.Cursor, 10)
+	if err != nil || len(page.Entries) != 1 || page.Cursor != 2 {
+		t.Fatalf("expected the second message, got %+v, %v", page, err)
+	}
+
+	// A client that falls behind HistorySize is told how many it missed
+	for i := 0; i < 4; i++ {
+		transport.QueueResponse(CreateResultMessage("s", 0.01, 100))
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(client.HistoryEntries()) == 0 || client.HistoryEntries()[len(client.HistoryEntries())-1].Seq < 6 {
+		if time.Now().After(deadline) {
+			t.Fatal("messages were not read into history")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	page, err = poll(time.Second, 2, 10)
+	if err != nil || page.Missed != 1 || len(page.Entries) != 3 || page.Cursor != 6 {
+		t.Errorf("expected 1 missed and 3 messages, got missed=%d entries=%d cursor=%d, %v", page.Missed, len(page.Entries), page.Cursor, err)
+	}
+}
@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/claudetest"
+)
+
+func TestQueryWithEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	thinking := CreateAssistantTextMessage("")
+	thinking["message"].(map[string]interface{})["content"] = []interface{}{
+		map[string]interface{}{"type": "thinking", "thinking": "Check the file first.", "signature": "sig"},
+	}
+	subagent := CreateAssistantToolUseMessage("Inside", "tool-sub", "Grep", map[string]interface{}{"pattern": "x"})
+	subagent["parent_tool_use_id"] = "tool-1"
+	for _, msg := range []map[string]interface{}{
+		thinking,
+		CreateAssistantToolUseMessage("Let me look.", "tool-1", "Read", map[string]interface{}{"file_path": "a.go"}),
+		subagent,
+		claudetest.ToolResult("tool-1", "package a", false),
+		CreateAssistantTextMessage("It is package a."),
+		CreateResultMessage("s", 0.01, 100),
+	} {
+		transport.QueueResponse(msg)
+	}
+
+	var events []string
+	var resultSeen *claude.ResultMessage
+	result, err := client.QueryWithEvents(ctx, "What package?", claude.QueryEventHandlers{
+		OnText:     func(text string) { events = append(events, "text:"+text) },
+		OnThinking: func(thinking string) { events = append(events, "thinking:"+thinking) },
+		OnToolUse: func(toolUse claude.ToolUseBlock) {
+			events = append(events, "tool_use:"+toolUse.Name+":"+toolUse.Input["file_path"].(string))
+		},
+		OnToolResult: func(result claude.ToolResultBlock) { events = append(events, "tool_result:"+result.ToolUseID) },
+		OnResult:     func(result *claude.ResultMessage) { resultSeen = result },
+	})
+	if err != nil {
+		t.Fatalf("QueryWithEvents failed: %v", err)
+	}
+	want := []string{
+		"thinking:Check the file first.",
+		"text:Let me look.",
+		"tool_use:Read:a.go",
+		"tool_result:tool-1",
+		"text:It is package a.",
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("got events %q, want %q", events, want)
+	}
+	if result == nil || result != resultSeen || result.SessionID != "s" {
+		t.Errorf("expected the ResultMessage returned and passed to OnResult, got %+v and %+v", result, resultSeen)
+	}
+}
+
+func TestQueryWithEventsStreamed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{IncludePartialMessages: true}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	for _, msg := range partialMessages() {
+		transport.QueueResponse(msg)
+	}
+	var texts []string
+	// Nil handlers are skipped
+	if _, err := client.QueryWithEvents(ctx, "hi", claude.QueryEventHandlers{
+		OnText: func(text string) { texts = append(texts, text) },
+	}); err != nil {
+		t.Fatalf("QueryWithEvents failed: %v", err)
+	}
+	if want := []string{"ab", "cd", "ef", "g"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("got %q, want the deltas %q without the repeated message", texts, want)
+	}
+}
@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestQueryIter(t *testing.T) {
+	cliPath := writeFakeCLI(t, `echo '{"type":"assistant","message":{"role":"assistant","model":"m","content":[{"type":"text","text":"4"}]}}'
+echo '{"type":"result","subtype":"error_max_turns","duration_ms":1,"duration_api_ms":1,"is_error":true,"num_turns":1,"session_id":"s1"}'
+`)
+	options := &claude.ClaudeAgentOptions{ErrorsFromResults: true}
+	trans, err := claude.NewSubprocessCLITransport("hi", options, cliPath)
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+
+	var messages []claude.Message
+	var errs []error
+	for msg, err := range claude.QueryIter(context.Background(), "hi", options, trans) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	if len(messages) != 2 {
+		t.Errorf("expected 2 messages, got %d", len(messages))
+	}
+	var resultErr *claude.ResultError
+	if len(errs) != 1 || !errors.As(errs[0], &resultErr) {
+		t.Errorf("expected the query's error last, got %v", errs)
+	}
+
+	// Errors starting the query are yielded too
+	badOptions := &claude.ClaudeAgentOptions{ToolMocks: claude.NewToolMocks()}
+	yielded := 0
+	for msg, err := range claude.QueryIter(context.Background(), "hi", badOptions, trans) {
+		yielded++
+		if msg != nil || err == nil {
+			t.Errorf("expected only a setup error, got %v, %v", msg, err)
+		}
+	}
+	if yielded != 1 {
+		t.Errorf("expected the setup error to be yielded once, got %d values", yielded)
+	}
+}
+
+func TestClientQueryIterBreak(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	transport.QueueResponse(CreateAssistantTextMessage("first"))
+	transport.QueueResponse(CreateAssistantTextMessage("second"))
+	seen := 0
+	for msg, err := range client.QueryIter(ctx, "hello") {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := msg.(*claude.AssistantMessage); ok {
+			seen++
+			break // The response never finishes; breaking must not hang
+		}
+	}
+	if seen != 1 {
+		t.Errorf("expected to stop after the first message, got %d", seen)
+	}
+
+	// The client stays usable once the abandoned turn ends
+	transport.QueueResponse(CreateResultMessage("s1", 0.01, 100))
+	transport.QueueResponse(CreateAssistantTextMessage("again"))
+	transport.QueueResponse(CreateResultMessage("s1", 0.02, 100))
+	var texts []string
+	for msg, err := range client.QueryIter(ctx, "again") {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if assistant, ok := msg.(*claude.AssistantMessage); ok {
+			texts = append(texts, assistant.Content[0].(claude.TextBlock).Text)
+		}
+	}
+	if len(texts) != 1 || texts[0] != "again" {
+		t.Errorf("expected only the second query's reply, got %q", texts)
+	}
+}
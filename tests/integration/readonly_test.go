@@ -0,0 +1,241 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func permissionRequest(requestID, toolName string, input map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "control_request",
+		"request_id": requestID,
+		"request": map[string]interface{}{
+			"subtype":   "can_use_tool",
+			"tool_name": toolName,
+			"input":     input,
+		},
+	}
+}
+
+func TestReadOnlyDeniesMutatingTools(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var userCalls atomic.Int32
+	options := &claude.ClaudeAgentOptions{
+		ReadOnly: true,
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			userCalls.Add(1)
+			return claude.PermissionResultAllow{Behavior: "allow"}, nil
+		},
+	}
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	cases := []struct {
+		requestID string
+		toolName  string
+		input     map[string]interface{}
+		behavior  string
+	}{
+		{"p_write", "Write", map[string]interface{}{"file_path": "/tmp/x"}, "deny"},
+		{"p_edit", "NotebookEdit", map[string]interface{}{}, "deny"},
+		{"p_rm", "Bash", map[string]interface{}{"command": "rm -rf build"}, "deny"},
+		{"p_chain", "Bash", map[string]interface{}{"command": "ls; rm x"}, "deny"},
+		{"p_redirect", "Bash", map[string]interface{}{"command": "cat a > b"}, "deny"},
+		{"p_git", "Bash", map[string]interface{}{"command": "git status -s"}, "allow"},
+		{"p_read", "Read", map[string]interface{}{"file_path": "/tmp/x"}, "allow"},
+	}
+
+	for _, tc := range cases {
+		transport.QueueResponse(permissionRequest(tc.requestID, tc.toolName, tc.input))
+	}
+	for _, tc := range cases {
+		response, ok := transport.WaitForControlResponse(tc.requestID, 2*time.Second)
+		if !ok {
+			t.Fatalf("No control response for %s", tc.requestID)
+		}
+		result, _ := response["response"].(map[string]interface{})
+		if result["behavior"] != tc.behavior {
+			t.Errorf("%s: expected %s, got %v", tc.requestID, tc.behavior, result)
+		}
+	}
+
+	if calls := userCalls.Load(); calls != 2 {
+		t.Errorf("Expected user callback only for allowed tools (2), got %d", calls)
+	}
+}
+
+func TestReadOnlyDeniesUnlistedTools(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		ReadOnly:         true,
+		ReadOnlyMcpTools: []string{"mcp__docs__search"},
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			return claude.PermissionResultAllow{Behavior: "allow"}, nil
+		},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	cases := []struct {
+		toolName string
+		behavior string
+	}{
+		{"KillShell", "deny"},
+		{"Task", "deny"},
+		{"mcp__docs__delete", "deny"},
+		{"mcp__db__query", "deny"},
+		{"SomeFutureTool", "deny"},
+		{"mcp__docs__search", "allow"},
+		{"Grep", "allow"},
+		{"WebSearch", "allow"},
+	}
+	for i, tc := range cases {
+		transport.QueueResponse(permissionRequest(fmt.Sprintf("p_%d", i), tc.toolName, map[string]interface{}{}))
+	}
+	for i, tc := range cases {
+		response, ok := transport.WaitForControlResponse(fmt.Sprintf("p_%d", i), 2*time.Second)
+		if !ok {
+			t.Fatalf("No control response for %s", tc.toolName)
+		}
+		result, _ := response["response"].(map[string]interface{})
+		if result["behavior"] != tc.behavior {
+			t.Errorf("%s: expected %s, got %v", tc.toolName, tc.behavior, result)
+		}
+	}
+}
+
+func TestReadOnlyPreToolUseGuard(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{ReadOnly: true}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	transport.QueueResponse(map[string]interface{}{
+		"type":       "control_request",
+		"request_id": "hook_write",
+		"request": map[string]interface{}{
+			"subtype":     "hook_callback",
+			"callback_id": "hook_0",
+			"input": map[string]interface{}{
+				"hook_event_name": "PreToolUse",
+				"tool_name":       "Edit",
+				"tool_input":      map[string]interface{}{"file_path": "main.go"},
+			},
+		},
+	})
+
+	response, ok := transport.WaitForControlResponse("hook_write", 2*time.Second)
+	if !ok {
+		t.Fatal("No control response for hook")
+	}
+	result, _ := response["response"].(map[string]interface{})
+	specific, _ := result["hookSpecificOutput"].(map[string]interface{})
+	if specific["permissionDecision"] != "deny" {
+		t.Errorf("Expected deny decision from read-only guard, got %v", result)
+	}
+}
+
+func TestReadOnlyBashFlagBypasses(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{ReadOnly: true}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	cases := []struct {
+		command string
+		denied  bool
+	}{
+		{"git branch -D main", true},
+		{"git branch newname", true},
+		{"git diff --output=/tmp/x", true},
+		{"git log --output /tmp/x", true},
+		{"git log --outp=/tmp/x", true},
+		{"git diff '--output=/tmp/x'", true},
+		{"git show --ext-diff HEAD", true},
+		{"rg --pre=sh pattern", true},
+		{"rg --pre sh pattern", true},
+		{"tree -o /tmp/x", true},
+		{"tree -ao /tmp/x", true},
+		{"file -C -m /tmp/magic", true},
+		{"git log --oneline -5", false},
+		{"git diff -- --output", false},
+		{"rg -o pattern", false},
+		{"grep -o pattern file", false},
+		{"tree -a", false},
+	}
+	for i, tc := range cases {
+		transport.QueueResponse(map[string]interface{}{
+			"type":       "control_request",
+			"request_id": fmt.Sprintf("hook_%d", i),
+			"request": map[string]interface{}{
+				"subtype":     "hook_callback",
+				"callback_id": "hook_0",
+				"input": map[string]interface{}{
+					"hook_event_name": "PreToolUse",
+					"tool_name":       "Bash",
+					"tool_input":      map[string]interface{}{"command": tc.command},
+				},
+			},
+		})
+	}
+	for i, tc := range cases {
+		response, ok := transport.WaitForControlResponse(fmt.Sprintf("hook_%d", i), 2*time.Second)
+		if !ok {
+			t.Fatalf("No control response for %q", tc.command)
+		}
+		result, _ := response["response"].(map[string]interface{})
+		specific, _ := result["hookSpecificOutput"].(map[string]interface{})
+		if denied := specific["permissionDecision"] == "deny"; denied != tc.denied {
+			t.Errorf("%q: expected denied=%v, got %v", tc.command, tc.denied, result)
+		}
+	}
+}
+
+func TestReadOnlyWithoutCanUseToolKeepsCLIPermissions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{ReadOnly: true}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// ReadOnly only removes permissions; it must not answer for the CLI
+	transport.QueueResponse(permissionRequest("p_read", "WebFetch", map[string]interface{}{"url": "https://example.com"}))
+	response, ok := transport.WaitForControlResponse("p_read", 2*time.Second)
+	if !ok {
+		t.Fatal("No control response for p_read")
+	}
+	if result, _ := response["response"].(map[string]interface{}); result["behavior"] == "allow" {
+		t.Errorf("expected ReadOnly not to allow tools on its own, got %v", response)
+	}
+}
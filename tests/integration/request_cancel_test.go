@@ -0,0 +1,178 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/mcp"
+)
+
+// connectBlockingTool connects a client with an SDK MCP tool "slow/wait"
+// that blocks until its context is cancelled, which it reports on the
+// returned channel.
+func connectBlockingTool(t *testing.T, ctx context.Context) (*AdvancedMockTransport, <-chan error) {
+	t.Helper()
+	cancelled := make(chan error, 1)
+	server := mcp.CreateSdkMcpServer("slow", "1.0.0", []*mcp.SdkMcpTool{
+		mcp.Tool("wait", "Blocks", map[string]string{}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return nil, ctx.Err()
+		}),
+	})
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		McpServers: map[string]claude.McpServerConfig{"slow": server.ToConfig()},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return transport, cancelled
+}
+
+func TestControlCancelRequestCancelsMcpTool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	transport, cancelled := connectBlockingTool(t, ctx)
+	queueToolCall(transport, "mcp_1", "slow", "wait")
+	if _, ok := transport.WaitForControlResponse("mcp_1", 100*time.Millisecond); ok {
+		t.Fatal("Expected the tool call to be in flight")
+	}
+
+	transport.QueueResponse(map[string]interface{}{"type": "control_cancel_request", "request_id": "mcp_1"})
+	response, ok := transport.WaitForControlResponse("mcp_1", time.Second)
+	if !ok {
+		t.Fatal("Expected a control response for the cancelled call")
+	}
+	if code := mcpErrorCode(t, response); code != -32800 {
+		t.Errorf("Expected request cancelled code -32800, got %v", code)
+	}
+	select {
+	case err := <-cancelled:
+		if err != context.Canceled {
+			t.Errorf("Expected the handler's context to be cancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the handler's context to be cancelled")
+	}
+}
+
+func TestMcpCancelledNotificationCancelsMcpTool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	transport, cancelled := connectBlockingTool(t, ctx)
+	queueToolCall(transport, "mcp_1", "slow", "wait")
+	transport.QueueResponse(map[string]interface{}{
+		"type":       "control_request",
+		"request_id": "mcp_2",
+		"request": map[string]interface{}{
+			"subtype":     "mcp_message",
+			"server_name": "slow",
+			"message": map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "notifications/cancelled",
+				"params":  map[string]interface{}{"requestId": float64(1), "reason": "User interrupted"},
+			},
+		},
+	})
+
+	response, ok := transport.WaitForControlResponse("mcp_1", time.Second)
+	if !ok {
+		t.Fatal("Expected a control response for the cancelled call")
+	}
+	if code := mcpErrorCode(t, response); code != -32800 {
+		t.Errorf("Expected request cancelled code -32800, got %v", code)
+	}
+	if _, ok := transport.WaitForControlResponse("mcp_2", time.Second); !ok {
+		t.Error("Expected the notification to be acknowledged")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("Expected the handler's context to be cancelled")
+	}
+}
+
+func TestControlCancelRequestCancelsPermissionCallback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		CanUseTool: func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	transport.QueueResponse(map[string]interface{}{
+		"type":       "control_request",
+		"request_id": "perm_1",
+		"request": map[string]interface{}{
+			"subtype":   "can_use_tool",
+			"tool_name": "Bash",
+			"input":     map[string]interface{}{"command": "sleep 100"},
+		},
+	})
+	transport.QueueResponse(map[string]interface{}{"type": "control_cancel_request", "request_id": "perm_1"})
+
+	response, ok := transport.WaitForControlResponse("perm_1", time.Second)
+	if !ok {
+		t.Fatal("Expected a control response for the cancelled callback")
+	}
+	if response["subtype"] != "error" {
+		t.Errorf("Expected an error response, got %v", response)
+	}
+}
+
+func TestControlCancelRequestCancelsHookCallback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	cancelled := make(chan error, 1)
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventPreToolUse: {{Hooks: []claude.HookCallback{
+				func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+					<-ctx.Done()
+					cancelled <- ctx.Err()
+					return claude.HookJSONOutput{}, ctx.Err()
+				},
+			}}},
+		},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	transport.QueueResponse(dynamicHookRequest("hook_1", initializeCallbackID(t, transport, claude.HookEventPreToolUse), "Bash"))
+	transport.QueueResponse(map[string]interface{}{"type": "control_cancel_request", "request_id": "hook_1"})
+
+	select {
+	case err := <-cancelled:
+		if err != context.Canceled {
+			t.Errorf("Expected the hook's context to be cancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Hook callback was not cancelled")
+	}
+	response, ok := transport.WaitForControlResponse("hook_1", time.Second)
+	if !ok {
+		t.Fatal("Expected a control response for the cancelled hook")
+	}
+	if response["subtype"] != "error" {
+		t.Errorf("Expected an error response, got %v", response)
+	}
+}
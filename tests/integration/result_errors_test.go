@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestErrorsFromResultsQuery(t *testing.T) {
+	cliPath := writeFakeCLI(t, `echo '{"type":"result","subtype":"error_max_turns","duration_ms":1,"duration_api_ms":1,"is_error":true,"num_turns":3,"session_id":"s1"}'`+"\n")
+	for _, enabled := range []bool{false, true} {
+		options := &claude.ClaudeAgentOptions{ErrorsFromResults: enabled}
+		trans, err := claude.NewSubprocessCLITransport("hi", options, cliPath)
+		if err != nil {
+			t.Fatalf("failed to create transport: %v", err)
+		}
+		msgCh, errCh, err := claude.Query(context.Background(), "hi", options, trans)
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		messages, err := CollectMessages(msgCh, errCh)
+		if len(messages) != 1 {
+			t.Fatalf("expected the ResultMessage to be delivered, got %d messages", len(messages))
+		}
+		if !enabled {
+			if err != nil {
+				t.Errorf("expected no error by default, got %v", err)
+			}
+			continue
+		}
+		var resultErr *claude.ResultError
+		if !errors.As(err, &resultErr) {
+			t.Fatalf("expected a ResultError, got %v", err)
+		}
+		if resultErr.Subtype != "error_max_turns" || resultErr.SessionID != "s1" || resultErr.Result != messages[0] {
+			t.Errorf("unexpected ResultError: %+v", resultErr)
+		}
+	}
+}
+
+func TestErrorsFromResultsClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{ErrorsFromResults: true}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	msgCh, errCh := client.Query(ctx, "first")
+	transport.QueueResponse(CreateResultMessageWithSubtype("s1", "error_during_execution", 0.01, 100))
+	messages, err := CollectMessages(msgCh, errCh)
+	var resultErr *claude.ResultError
+	if len(messages) != 1 || !errors.As(err, &resultErr) || resultErr.Subtype != "error_during_execution" {
+		t.Fatalf("expected the result and a ResultError, got %d messages and %v", len(messages), err)
+	}
+
+	// Only the failed query fails; the connection goes on
+	msgCh, errCh = client.Query(ctx, "second")
+	transport.QueueResponse(CreateResultMessage("s1", 0.01, 100))
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Errorf("expected the next query to succeed, got %v", err)
+	}
+	if client.Err() != nil {
+		t.Errorf("expected a healthy connection, got %v", client.Err())
+	}
+}
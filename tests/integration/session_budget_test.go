@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/mcp"
+)
+
+func TestSessionBudgetInToolHandlers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	budgets := make(chan claude.SessionBudget, 1)
+	server := mcp.CreateSdkMcpServer("work", "1.0.0", []*mcp.SdkMcpTool{
+		mcp.Tool("crawl", "Crawls the docs", map[string]string{}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			budget, ok := claude.SessionBudgetFromContext(ctx)
+			if !ok {
+				t.Error("expected a session budget in the handler's context")
+			}
+			budgets <- budget
+			return mcp.TextContent("done"), nil
+		}),
+	})
+	maxBudget := 1.0
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		McpServers:   map[string]claude.McpServerConfig{"work": server.ToConfig()},
+		MaxBudgetUSD: &maxBudget,
+		BudgetLimits: &claude.BudgetLimits{QueryUSD: 0.5},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	msgCh, errCh := client.Query(ctx, "first")
+	transport.QueueResponse(CreateResultMessage("s1", 0.3, 100))
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The second query has its own, earlier deadline
+	queryCtx, cancelQuery := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelQuery()
+	if err := client.QueryWithSession(queryCtx, "second", "default"); err != nil {
+		t.Fatalf("QueryWithSession failed: %v", err)
+	}
+	transport.QueueResponse(map[string]interface{}{
+		"type":       "control_request",
+		"request_id": "mcp_call",
+		"request": map[string]interface{}{
+			"subtype":     "mcp_message",
+			"server_name": "work",
+			"message": map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      float64(1),
+				"method":  "tools/call",
+				"params":  map[string]interface{}{"name": "crawl", "arguments": map[string]interface{}{}},
+			},
+		},
+	})
+
+	var budget claude.SessionBudget
+	select {
+	case budget = <-budgets:
+	case <-ctx.Done():
+		t.Fatal("the tool was not called")
+	}
+	// The query limit is tighter than MaxBudgetUSD: 0.5 for this query vs 1.0 - 0.3
+	if budget.RemainingUSD == nil || math.Abs(*budget.RemainingUSD-0.5) > 1e-9 {
+		t.Errorf("expected $0.50 remaining, got %v", budget.RemainingUSD)
+	}
+	wantDeadline, _ := queryCtx.Deadline()
+	if !budget.Deadline.Equal(wantDeadline) {
+		t.Errorf("expected the query's deadline %v, got %v", wantDeadline, budget.Deadline)
+	}
+	if left, ok := budget.TimeRemaining(); !ok || left <= 0 || left > 5*time.Second {
+		t.Errorf("unexpected time remaining %v, %v", left, ok)
+	}
+}
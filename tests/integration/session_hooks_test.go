@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func eventHookRequest(requestID, callbackID string, input map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "control_request",
+		"request_id": requestID,
+		"request": map[string]interface{}{
+			"subtype":     "hook_callback",
+			"callback_id": callbackID,
+			"input":       input,
+		},
+	}
+}
+
+func TestSessionStartHook(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var source string
+	onStart := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+		data, _ := json.Marshal(input)
+		var typed claude.SessionStartHookInput
+		if err := json.Unmarshal(data, &typed); err != nil {
+			return claude.HookJSONOutput{}, err
+		}
+		source = typed.Source
+		return claude.AddContext("Deploys are frozen this week"), nil
+	}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventSessionStart: {{Matcher: "startup", Hooks: []claude.HookCallback{onStart}}},
+		},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	transport.QueueResponse(eventHookRequest("start_1", initializeCallbackID(t, transport, claude.HookEventSessionStart),
+		map[string]interface{}{"hook_event_name": "SessionStart", "session_id": "s1", "source": "startup"}))
+	response, ok := transport.WaitForControlResponse("start_1", time.Second)
+	if !ok {
+		t.Fatal("Expected a hook response")
+	}
+	output, _ := response["response"].(map[string]interface{})
+	specific, _ := output["hookSpecificOutput"].(map[string]interface{})
+	if specific["hookEventName"] != "SessionStart" || specific["additionalContext"] != "Deploys are frozen this week" {
+		t.Errorf("unexpected hookSpecificOutput %v", specific)
+	}
+	if source != "startup" {
+		t.Errorf("hook decoded source %q", source)
+	}
+}
+
+func TestDynamicHooksMatchNotificationType(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{DynamicHooks: true}, transport)
+	var idle, ended atomic.Int32
+	if _, err := client.AddHook(claude.HookEventNotification, "idle_prompt", func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+		idle.Add(1)
+		return claude.HookJSONOutput{}, nil
+	}); err != nil {
+		t.Fatalf("AddHook failed: %v", err)
+	}
+	if _, err := client.AddHook(claude.HookEventSessionEnd, "ignored", func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+		ended.Add(1)
+		return claude.HookJSONOutput{}, nil
+	}); err != nil {
+		t.Fatalf("AddHook failed: %v", err)
+	}
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	notificationID := initializeCallbackID(t, transport, claude.HookEventNotification)
+	for i, notificationType := range []string{"idle_prompt", "permission_prompt"} {
+		requestID := "notify_" + notificationType
+		transport.QueueResponse(eventHookRequest(requestID, notificationID, map[string]interface{}{
+			"hook_event_name": "Notification", "message": "Claude is waiting", "notification_type": notificationType,
+		}))
+		if _, ok := transport.WaitForControlResponse(requestID, time.Second); !ok {
+			t.Fatalf("no response to notification %d", i)
+		}
+	}
+	transport.QueueResponse(eventHookRequest("end_1", initializeCallbackID(t, transport, claude.HookEventSessionEnd),
+		map[string]interface{}{"hook_event_name": "SessionEnd", "reason": "other"}))
+	if _, ok := transport.WaitForControlResponse("end_1", time.Second); !ok {
+		t.Fatal("no response to SessionEnd hook")
+	}
+	initializeCallbackID(t, transport, claude.HookEventPermissionRequest)
+
+	if idle.Load() != 1 {
+		t.Errorf("idle_prompt hook called %d times, want 1", idle.Load())
+	}
+	if ended.Load() != 1 {
+		t.Errorf("SessionEnd hook called %d times, want 1 (its matcher is ignored)", ended.Load())
+	}
+}
@@ -0,0 +1,51 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestOneShotClientRejectsControlMethods(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.ConnectWithPrompt(ctx, "What is 2+2?"); err != nil {
+		t.Fatalf("ConnectWithPrompt failed: %v", err)
+	}
+	defer client.Close()
+
+	if client.Mode() != claude.SessionModeOneShot {
+		t.Errorf("expected one-shot mode, got %q", client.Mode())
+	}
+	for _, data := range transport.GetWrittenMessages() {
+		if strings.Contains(data, `"initialize"`) {
+			t.Error("one-shot sessions should not send an initialize request")
+		}
+	}
+
+	calls := map[string]func() error{
+		"interrupt": func() error { return client.Interrupt(ctx) },
+		"set_model": func() error { return client.SetModel(ctx, "claude-haiku-4-5") },
+		"query":     func() error { return client.QueryWithSession(ctx, "and 3+3?", "default") },
+	}
+	for operation, call := range calls {
+		var modeErr *claude.StreamingRequiredError
+		if err := call(); !errors.As(err, &modeErr) || modeErr.Operation != operation || modeErr.Mode != claude.SessionModeOneShot {
+			t.Errorf("%s: expected StreamingRequiredError, got %v", operation, err)
+		}
+	}
+
+	// The answer to the prompt is still delivered
+	transport.QueueResponse(CreateResultMessage("s", 0.01, 100))
+	msgs := client.ReceiveResponse(ctx)
+	if _, ok := (<-msgs).(*claude.ResultMessage); !ok {
+		t.Error("expected the ResultMessage of the one-shot prompt")
+	}
+}
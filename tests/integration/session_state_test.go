@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestExportAndResumeState(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	historySize := 10
+	model := "claude-sonnet-4-5"
+	cwd := t.TempDir()
+	newOptions := func() *claude.ClaudeAgentOptions {
+		return &claude.ClaudeAgentOptions{HistorySize: &historySize, Model: &model, Cwd: &cwd}
+	}
+
+	// The first worker runs a turn and exports the conversation
+	transport := NewAdvancedMockTransport()
+	first := claude.NewClaudeSDKClientWithTransport(newOptions(), transport)
+	if _, err := first.ExportState(); err == nil {
+		t.Error("expected ExportState to fail before a session exists")
+	}
+	if err := first.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	msgCh, errCh := first.Query(ctx, "start the job")
+	transport.QueueResponse(CreateAssistantTextMessage("working"))
+	transport.QueueResponse(CreateResultMessage("sess-abc", 0.01, 100))
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Fatalf("first turn failed: %v", err)
+	}
+	state, err := first.ExportState()
+	if err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+	cursor := first.HistoryEntries()[1].Seq
+	first.Close()
+
+	// Resuming with a different setup is refused
+	otherModel := "claude-haiku-4-5"
+	mismatched := newOptions()
+	mismatched.Model = &otherModel
+	var stateErr *claude.SessionStateError
+	if _, err := claude.ResumeFromState(state, mismatched); !errors.As(err, &stateErr) {
+		t.Errorf("expected SessionStateError for different options, got %v", err)
+	}
+	if _, err := claude.ResumeFromState([]byte("not a state"), newOptions()); !errors.As(err, &stateErr) {
+		t.Errorf("expected SessionStateError for a corrupt blob, got %v", err)
+	}
+
+	// The next worker picks up the session and the history cursor
+	transport = NewAdvancedMockTransport()
+	second, err := claude.ResumeFromStateWithTransport(state, newOptions(), transport)
+	if err != nil {
+		t.Fatalf("ResumeFromState failed: %v", err)
+	}
+	if second.SessionID() != "sess-abc" {
+		t.Errorf("expected the exported session ID, got %q", second.SessionID())
+	}
+	if err := second.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer second.Close()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		transport.QueueResponse(CreateResultMessage("sess-abc", 0.01, 100))
+	}()
+	page, err := second.PollMessages(ctx, cursor, 10)
+	if err != nil || len(page.Entries) != 1 || page.Entries[0].Seq != cursor+1 {
+		t.Errorf("expected cursors to continue after %d, got %+v, %v", cursor, page, err)
+	}
+}
@@ -0,0 +1,335 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// sessionEchoTransport answers each user message with an assistant message
+// echoing the prompt and a result, both tagged with the message's session_id.
+type sessionEchoTransport struct {
+	*AdvancedMockTransport
+}
+
+func (m *sessionEchoTransport) Write(ctx context.Context, data string) error {
+	if err := m.AdvancedMockTransport.Write(ctx, data); err != nil {
+		return err
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &msg); err != nil || msg["type"] != "user" {
+		return nil
+	}
+	sessionID, _ := msg["session_id"].(string)
+	prompt, _ := msg["message"].(map[string]interface{})["content"].(string)
+	go func() {
+		reply := CreateAssistantTextMessage(prompt)
+		reply["session_id"] = sessionID
+		m.QueueResponse(reply)
+		m.QueueResponse(CreateResultMessage(sessionID, 0.01, 100))
+	}()
+	return nil
+}
+
+func TestSessionsConcurrentQueries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	transport := &sessionEchoTransport{NewAdvancedMockTransport()}
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	const sessions, queries = 8, 5
+	var wg sync.WaitGroup
+	errs := make(chan error, sessions*queries)
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			session := client.Session(id)
+			for q := 0; q < queries; q++ {
+				prompt := fmt.Sprintf("%s-%d", id, q)
+				messages, err := CollectMessages(session.Query(ctx, prompt))
+				if err != nil {
+					errs <- fmt.Errorf("%s: %w", prompt, err)
+					return
+				}
+				if len(messages) != 2 {
+					errs <- fmt.Errorf("%s: expected 2 messages, got %d", prompt, len(messages))
+					return
+				}
+				assistant, ok := messages[0].(*claude.AssistantMessage)
+				if !ok || assistant.SessionID != id {
+					errs <- fmt.Errorf("%s: expected an assistant message for this session, got %+v", prompt, messages[0])
+					return
+				}
+				if text, ok := assistant.Content[0].(claude.TextBlock); !ok || text.Text != prompt {
+					errs <- fmt.Errorf("%s: received another query's reply %+v", prompt, assistant.Content[0])
+					return
+				}
+				if result, ok := messages[1].(*claude.ResultMessage); !ok || result.SessionID != id {
+					errs <- fmt.Errorf("%s: expected a result for this session, got %+v", prompt, messages[1])
+					return
+				}
+			}
+		}(fmt.Sprintf("session-%d", i))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestSessionsUntaggedMessagesGoToOldestQuery(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// The CLI assigned its own session ID, so neither query's ID matches
+	msgCh, errCh := client.Session("first").Query(ctx, "one")
+	transport.QueueResponse(CreateAssistantTextMessage("one"))
+	transport.QueueResponse(CreateResultMessage("cli-session", 0.01, 100))
+	messages, err := CollectMessages(msgCh, errCh)
+	if err != nil || len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d, %v", len(messages), err)
+	}
+}
+
+func TestSessionsCanceledQueryDrainsItsTurn(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{CancelBehavior: claude.CancelDrain}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	firstCtx, cancelFirst := context.WithCancel(ctx)
+	firstMsgCh, firstErrCh := client.Session("first").Query(firstCtx, "one")
+	secondMsgCh, secondErrCh := client.Session("second").Query(ctx, "two")
+	cancelFirst()
+	if _, err := CollectMessages(firstMsgCh, firstErrCh); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	// The CLI still answers the first query, with its own session ID
+	transport.QueueResponse(CreateAssistantTextMessage("answer to one"))
+	transport.QueueResponse(CreateResultMessage("cli-session", 0.01, 100))
+	transport.QueueResponse(CreateAssistantTextMessage("answer to two"))
+	transport.QueueResponse(CreateResultMessage("cli-session", 0.02, 200))
+	messages, err := CollectMessages(secondMsgCh, secondErrCh)
+	if err != nil || len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d, %v", len(messages), err)
+	}
+	if assistant, ok := messages[0].(*claude.AssistantMessage); !ok || assistant.Content[0].(claude.TextBlock).Text != "answer to two" {
+		t.Errorf("expected the second query's reply, got %+v", messages[0])
+	}
+	if result, ok := messages[1].(*claude.ResultMessage); !ok || result.DurationMS != 200 {
+		t.Errorf("expected the second query's result, got %+v", messages[1])
+	}
+
+	// Later queries get their own replies too
+	msgCh, errCh := client.Session("first").Query(ctx, "three")
+	transport.QueueResponse(CreateAssistantTextMessage("answer to three"))
+	transport.QueueResponse(CreateResultMessage("cli-session", 0.03, 300))
+	messages, err = CollectMessages(msgCh, errCh)
+	if err != nil || len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d, %v", len(messages), err)
+	}
+	if assistant, ok := messages[0].(*claude.AssistantMessage); !ok || assistant.Content[0].(claude.TextBlock).Text != "answer to three" {
+		t.Errorf("expected the third query's reply, got %+v", messages[0])
+	}
+}
+
+func TestSessionsClosedClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	msgCh, errCh := client.Session("waiting").Query(ctx, "never answered")
+	client.Close()
+	if _, err := CollectMessages(msgCh, errCh); err == nil {
+		t.Error("expected the pending query to fail when the client closes")
+	}
+
+	_, err := CollectMessages(client.Session("late").Query(ctx, "hello"))
+	if !errors.Is(err, claude.ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := &sessionEchoTransport{NewAdvancedMockTransport()}
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if _, err := client.NewSession(ctx, "early"); !errors.Is(err, claude.ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected before Connect, got %v", err)
+	}
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession(ctx, "triage")
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	if _, err := client.NewSession(ctx, "triage"); err == nil {
+		t.Error("expected a duplicate session ID to be rejected")
+	}
+	generated, err := client.NewSession(ctx, "")
+	if err != nil || generated.ID() == "" || generated.ID() == session.ID() {
+		t.Fatalf("expected a generated unique ID, got %q, %v", generated.ID(), err)
+	}
+
+	// Send and ReceiveResponse, as Query does
+	if _, err := CollectMessages(session.ReceiveResponse(ctx)); err == nil {
+		t.Error("expected ReceiveResponse without Send to fail")
+	}
+	if err := session.Send(ctx, "hello"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	messages, err := CollectMessages(session.ReceiveResponse(ctx))
+	if err != nil || len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d, %v", len(messages), err)
+	}
+	if assistant, ok := messages[0].(*claude.AssistantMessage); !ok || assistant.SessionID != "triage" {
+		t.Errorf("expected this session's reply, got %+v", messages[0])
+	}
+
+	// Closing frees the ID and fails later calls
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := session.Send(ctx, "again"); !errors.Is(err, claude.ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed, got %v", err)
+	}
+	if _, err := client.NewSession(ctx, "triage"); err != nil {
+		t.Errorf("expected a closed session's ID to be reusable, got %v", err)
+	}
+
+	// So does canceling the session's context
+	sessionCtx, cancelSession := context.WithCancel(ctx)
+	scoped, err := client.NewSession(sessionCtx, "scoped")
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	cancelSession()
+	_, err = CollectMessages(scoped.Query(ctx, "hello"))
+	if !errors.Is(err, claude.ErrSessionClosed) {
+		t.Errorf("expected ErrSessionClosed after the session's context ended, got %v", err)
+	}
+}
+
+func TestSessionInterrupt(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	first, _ := client.NewSession(ctx, "first")
+	second, _ := client.NewSession(ctx, "second")
+	if err := first.Interrupt(ctx); err != nil {
+		t.Errorf("expected Interrupt without a query to do nothing, got %v", err)
+	}
+	if err := first.Send(ctx, "long task"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if err := second.Send(ctx, "queued task"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	// The CLI is answering the first session's query, not the second's
+	if err := second.Interrupt(ctx); err == nil {
+		t.Error("expected interrupting a queued query to fail")
+	}
+	if err := first.Interrupt(ctx); err != nil {
+		t.Fatalf("Interrupt failed: %v", err)
+	}
+	interrupts := 0
+	for _, data := range transport.GetWrittenMessages() {
+		if strings.Contains(data, `"subtype":"interrupt"`) {
+			interrupts++
+		}
+	}
+	if interrupts != 1 {
+		t.Errorf("expected one interrupt request, got %d", interrupts)
+	}
+
+	transport.QueueResponse(CreateResultMessage("first", 0.01, 100))
+	if _, err := CollectMessages(first.ReceiveResponse(ctx)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := second.Interrupt(ctx); err != nil {
+		t.Errorf("expected the second query to be interruptible once running, got %v", err)
+	}
+}
+
+func TestSessionAndNewSessionShareOneLifecycle(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := &sessionEchoTransport{NewAdvancedMockTransport()}
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Session("early").Send(ctx, "hello"); !errors.Is(err, claude.ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected before Connect, got %v", err)
+	}
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// Session never hands out a session opened by NewSession
+	owned, err := client.NewSession(ctx, "owned")
+	if err != nil {
+		t.Fatalf("NewSession failed: %v", err)
+	}
+	if _, err := CollectMessages(client.Session("owned").Query(ctx, "hello")); err == nil {
+		t.Error("expected Session to refuse a session opened by NewSession")
+	}
+	owned.Close()
+
+	// Nor does NewSession reuse one opened by Session, until it is closed
+	shared := client.Session("shared")
+	if client.Session("shared") != shared {
+		t.Error("expected Session to return the open session for its ID")
+	}
+	if _, err := client.NewSession(ctx, "shared"); err == nil {
+		t.Error("expected NewSession to reject the ID of an open session")
+	}
+	shared.Close()
+	if _, err := client.NewSession(ctx, "shared"); err != nil {
+		t.Errorf("expected a closed session's ID to be reusable, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// reconnectableTransport creates a fresh AdvancedMockTransport on every Connect,
+// emulating a transport that supports Connect after Close.
+type reconnectableTransport struct {
+	mu       sync.Mutex
+	current  *AdvancedMockTransport
+	connects int
+}
+
+func (r *reconnectableTransport) active() *AdvancedMockTransport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+func (r *reconnectableTransport) Connect(ctx context.Context) error {
+	r.mu.Lock()
+	r.current = NewAdvancedMockTransport()
+	r.connects++
+	r.mu.Unlock()
+	return r.active().Connect(ctx)
+}
+
+func (r *reconnectableTransport) Write(ctx context.Context, data string) error {
+	return r.active().Write(ctx, data)
+}
+
+func (r *reconnectableTransport) ReadMessages(ctx context.Context) (<-chan map[string]interface{}, <-chan error) {
+	return r.active().ReadMessages(ctx)
+}
+
+func (r *reconnectableTransport) Close() error    { return r.active().Close() }
+func (r *reconnectableTransport) IsReady() bool   { return r.active().IsReady() }
+func (r *reconnectableTransport) EndInput() error { return r.active().EndInput() }
+
+func TestSetSettingSourcesRequiresConnection(t *testing.T) {
+	client := claude.NewClaudeSDKClient(nil)
+	err := client.SetSettingSources(context.Background(), []claude.SettingSource{claude.SettingSourceProject})
+	if err == nil {
+		t.Fatal("Expected error when not connected")
+	}
+}
+
+func TestSetSettingSourcesRestartsSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	transport := &reconnectableTransport{}
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	msgCh, errCh := client.Query(ctx, "Hello")
+	transport.active().QueueResponse(CreateResultMessage("session_abc", 0.001, 100))
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if client.SessionID() != "session_abc" {
+		t.Fatalf("Expected session ID to be tracked, got %q", client.SessionID())
+	}
+
+	if err := client.SetSettingSources(ctx, []claude.SettingSource{claude.SettingSourceProject}); err != nil {
+		t.Fatalf("SetSettingSources failed: %v", err)
+	}
+
+	if transport.connects != 2 {
+		t.Errorf("Expected transport to be reconnected, got %d connects", transport.connects)
+	}
+
+	// The restarted session must be usable
+	msgCh, errCh = client.Query(ctx, "Still there?")
+	transport.active().QueueResponse(CreateAssistantTextMessage("Yes"))
+	transport.active().QueueResponse(CreateResultMessage("session_abc", 0.001, 100))
+	messages, err := CollectMessages(msgCh, errCh)
+	if err != nil {
+		t.Fatalf("Query after restart failed: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Errorf("Expected 2 messages after restart, got %d", len(messages))
+	}
+}
+
+func TestRestartConcurrentWithControlMethods(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := &reconnectableTransport{}
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// Control calls racing a restart may fail on the old connection, but
+	// must not find it half torn down
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for _, call := range []func() error{
+		func() error { return client.SetModel(ctx, "claude-sonnet-4-5") },
+		func() error { return client.SetPermissionMode(ctx, claude.PermissionModeAcceptEdits) },
+		func() error { return client.Interrupt(ctx) },
+		func() error { return client.Liveness(ctx) },
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					call()
+					client.GetServerInfo()
+				}
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		if err := client.SetSettingSources(ctx, []claude.SettingSource{claude.SettingSourceProject}); err != nil {
+			t.Errorf("SetSettingSources failed: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if err := client.SetModel(ctx, "claude-opus-4-1"); err != nil {
+		t.Errorf("SetModel after restarts failed: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// exitingTransport ends its output when input ends, like the CLI.
+type exitingTransport struct {
+	*AdvancedMockTransport
+	inputEnded atomic.Bool
+}
+
+func (e *exitingTransport) EndInput() error {
+	if !e.inputEnded.Swap(true) {
+		e.CloseStream()
+	}
+	return nil
+}
+
+func TestClientShutdownDrainsQuery(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := &exitingTransport{AdvancedMockTransport: NewAdvancedMockTransport()}
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	msgCh, errCh := client.Query(ctx, "Finish the report")
+	transport.QueueResponse(CreateAssistantTextMessage("Working on it"))
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- client.Shutdown(ctx) }()
+
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned before the result: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if transport.inputEnded.Load() || transport.Closed() {
+		t.Fatal("expected the connection to stay open while the query runs")
+	}
+	if err := client.QueryWithSession(ctx, "Another", "default"); !errors.Is(err, claude.ErrShuttingDown) {
+		t.Errorf("expected ErrShuttingDown for a new query, got %v", err)
+	}
+
+	transport.QueueResponse(CreateResultMessage("s", 0.01, 1000))
+	messages, err := CollectMessages(msgCh, errCh)
+	if err != nil || len(messages) != 2 {
+		t.Errorf("expected the query to finish, got %d messages and %v", len(messages), err)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("Shutdown failed: %v", err)
+	}
+	if !transport.inputEnded.Load() || !transport.Closed() {
+		t.Error("expected input to end and the transport to close")
+	}
+	if err := client.Interrupt(ctx); !errors.Is(err, claude.ErrClosed) {
+		t.Errorf("expected ErrClosed after Shutdown, got %v", err)
+	}
+}
+
+func TestClientShutdownDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := &exitingTransport{AdvancedMockTransport: NewAdvancedMockTransport()}
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := client.QueryWithSession(ctx, "Never answered", "default"); err != nil {
+		t.Fatalf("QueryWithSession failed: %v", err)
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer shutdownCancel()
+	if err := client.Shutdown(shutdownCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to end Shutdown, got %v", err)
+	}
+	if transport.inputEnded.Load() || !transport.Closed() {
+		t.Error("expected the transport to be closed without ending input")
+	}
+}
+
+func TestClientShutdownNotConnected(t *testing.T) {
+	client := claude.NewClaudeSDKClientWithTransport(nil, NewAdvancedMockTransport())
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Errorf("expected Shutdown of an unconnected client to succeed, got %v", err)
+	}
+}
+
+// exitingReconnectableTransport is a reconnectableTransport whose output
+// ends when input ends, like the CLI.
+type exitingReconnectableTransport struct {
+	reconnectableTransport
+}
+
+func (e *exitingReconnectableTransport) EndInput() error {
+	e.active().CloseStream()
+	return nil
+}
+
+func TestClientShutdownAfterRestart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	transport := &exitingReconnectableTransport{}
+	client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	if err := client.QueryWithSession(ctx, "Dropped by the restart", "default"); err != nil {
+		t.Fatalf("QueryWithSession failed: %v", err)
+	}
+	if err := client.SetSettingSources(ctx, []claude.SettingSource{claude.SettingSourceProject}); err != nil {
+		t.Fatalf("SetSettingSources failed: %v", err)
+	}
+
+	// The query ended with the old connection, so nothing is left to wait for
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, time.Second)
+	defer shutdownCancel()
+	if err := client.Shutdown(shutdownCtx); err != nil {
+		t.Errorf("expected Shutdown to finish without waiting for the dropped query, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// partialMessages returns the stream of a turn with a text block streamed
+// in four deltas and a tool use block streamed in two.
+func partialMessages() []map[string]interface{} {
+	delta := func(index int, deltaType, field, text string) map[string]interface{} {
+		return streamEventMessage(map[string]interface{}{
+			"type": "content_block_delta", "index": float64(index),
+			"delta": map[string]interface{}{"type": deltaType, field: text},
+		})
+	}
+	block := func(eventType string, index int, blockType string) map[string]interface{} {
+		event := map[string]interface{}{"type": eventType, "index": float64(index)}
+		if blockType != "" {
+			event["content_block"] = map[string]interface{}{"type": blockType}
+		}
+		return streamEventMessage(event)
+	}
+	return []map[string]interface{}{
+		streamEventMessage(map[string]interface{}{"type": "message_start"}),
+		block("content_block_start", 0, "text"),
+		delta(0, "text_delta", "text", "ab"),
+		delta(0, "text_delta", "text", "cd"),
+		delta(0, "text_delta", "text", "ef"),
+		delta(0, "text_delta", "text", "g"),
+		block("content_block_stop", 0, ""),
+		block("content_block_start", 1, "tool_use"),
+		delta(1, "input_json_delta", "partial_json", `{"a":`),
+		delta(1, "input_json_delta", "partial_json", `1}`),
+		block("content_block_stop", 1, ""),
+		streamEventMessage(map[string]interface{}{"type": "message_stop"}),
+		CreateAssistantTextMessage("abcdefg"),
+		CreateResultMessage("s", 0.01, 100),
+	}
+}
+
+// describeStream summarizes messages as "type" or "type:text" for deltas.
+func describeStream(messages []claude.Message) []string {
+	var out []string
+	for _, msg := range messages {
+		event, ok := msg.(*claude.StreamEvent)
+		if !ok {
+			out = append(out, fmt.Sprintf("%T", msg))
+			continue
+		}
+		eventType, _ := event.Event["type"].(string)
+		if delta, ok := event.Event["delta"].(map[string]interface{}); ok {
+			text, _ := delta["text"].(string)
+			if json, ok := delta["partial_json"].(string); ok {
+				text = json
+			}
+			eventType += ":" + text
+		}
+		out = append(out, eventType)
+	}
+	return out
+}
+
+func TestStreamEventFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter claude.StreamEventFilter
+		want   []string
+	}{
+		{
+			name:   "text in pairs of deltas",
+			filter: claude.StreamEventFilter{TextOnly: true, EveryNthDelta: 2},
+			want:   []string{"content_block_delta:abcd", "content_block_delta:efg", "*claude.AssistantMessage", "*claude.ResultMessage"},
+		},
+		{
+			name:   "minimum size",
+			filter: claude.StreamEventFilter{TextOnly: true, MinDeltaBytes: 5},
+			want:   []string{"content_block_delta:abcdef", "content_block_delta:g", "*claude.AssistantMessage", "*claude.ResultMessage"},
+		},
+		{
+			name:   "tool progress",
+			filter: claude.StreamEventFilter{ToolProgress: true},
+			want: []string{
+				"content_block_start", `content_block_delta:{"a":`, "content_block_delta:1}", "content_block_stop",
+				"*claude.AssistantMessage", "*claude.ResultMessage",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			options := &claude.ClaudeAgentOptions{IncludePartialMessages: true, StreamEventFilter: &filter}
+			msgCh, errCh, err := claude.Query(context.Background(), "hi", options, NewMockTransport(partialMessages()))
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			messages, err := CollectMessages(msgCh, errCh)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := describeStream(messages); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
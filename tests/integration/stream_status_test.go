@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+const assistantLine = `echo '{"type":"assistant","message":{"role":"assistant","model":"m","content":[{"type":"text","text":"Working"}]}}'`
+
+func TestQueryStreamStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		cli    string
+		status claude.StreamStatus
+	}{
+		{
+			name:   "completed",
+			cli:    assistantLine + "\n" + `echo '{"type":"result","subtype":"success","duration_ms":1,"duration_api_ms":1,"is_error":false,"num_turns":1,"session_id":"s"}'`,
+			status: claude.StreamCompleted,
+		},
+		{
+			// Exits cleanly mid-response: previously indistinguishable from success
+			name:   "truncated",
+			cli:    assistantLine,
+			status: claude.StreamTransportClosed,
+		},
+		{
+			name:   "parse error",
+			cli:    assistantLine + "\n" + `echo '{"type":"assistant","message":"garbled"}'`,
+			status: claude.StreamParseError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cliPath := writeFakeCLI(t, tt.cli+"\n")
+			options := &claude.ClaudeAgentOptions{}
+			trans, err := claude.NewSubprocessCLITransport("hi", options, cliPath)
+			if err != nil {
+				t.Fatalf("failed to create transport: %v", err)
+			}
+			msgCh, errCh, err := claude.Query(context.Background(), "hi", options, trans)
+			if err != nil {
+				t.Fatalf("Query failed: %v", err)
+			}
+			messages, err := CollectMessages(msgCh, errCh)
+			if len(messages) == 0 {
+				t.Errorf("expected the messages before the end to be delivered")
+			}
+			if status := claude.StreamStatusOf(err); status != tt.status {
+				t.Errorf("expected status %s, got %s (err: %v)", tt.status, status, err)
+			}
+			if tt.name == "truncated" && !errors.Is(err, claude.ErrStreamTruncated) {
+				t.Errorf("expected ErrStreamTruncated, got %v", err)
+			}
+		})
+	}
+}
+
+func TestClientResponseTruncated(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	msgCh, errCh := client.Query(ctx, "hi")
+	transport.QueueResponse(CreateAssistantTextMessage("Working"))
+	// The CLI's output ends without an error and without a ResultMessage
+	transport.CloseStream()
+
+	messages, err := CollectMessages(msgCh, errCh)
+	if len(messages) != 1 {
+		t.Errorf("expected the assistant message, got %d messages", len(messages))
+	}
+	if !errors.Is(err, claude.ErrStreamTruncated) || claude.StreamStatusOf(err) != claude.StreamTransportClosed {
+		t.Errorf("expected ErrStreamTruncated, got %v", err)
+	}
+	if !errors.Is(client.Err(), claude.ErrStreamTruncated) {
+		t.Errorf("expected Err to report the truncation, got %v", client.Err())
+	}
+}
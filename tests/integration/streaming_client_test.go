@@ -2,177 +2,21 @@ package integration
 
 import (
 	"context"
-	"encoding/json"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/claudetest"
 )
 
-// AdvancedMockTransport provides a more complete mock for streaming tests
-type AdvancedMockTransport struct {
-	connected       bool
-	closed          bool
-	writtenMessages []string
-	responseCh      chan map[string]interface{}
-	errorCh         chan error
-	mu              sync.Mutex
-	ctx             context.Context
-	cancel          context.CancelFunc
-}
+// AdvancedMockTransport is the public mock transport; the alias keeps the
+// name the integration tests were written against.
+type AdvancedMockTransport = claudetest.MockTransport
 
 func NewAdvancedMockTransport() *AdvancedMockTransport {
-	ctx, cancel := context.WithCancel(context.Background())
-	return &AdvancedMockTransport{
-		writtenMessages: make([]string, 0),
-		responseCh:      make(chan map[string]interface{}, 10),
-		errorCh:         make(chan error, 1),
-		ctx:             ctx,
-		cancel:          cancel,
-	}
-}
-
-func (m *AdvancedMockTransport) Connect(ctx context.Context) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	m.connected = true
-	return nil
-}
-
-func (m *AdvancedMockTransport) Write(ctx context.Context, data string) error {
-	m.mu.Lock()
-	m.writtenMessages = append(m.writtenMessages, data)
-	m.mu.Unlock()
-
-	// Auto-respond to control requests
-	go m.handleControlRequest(data)
-
-	return nil
-}
-
-func (m *AdvancedMockTransport) handleControlRequest(data string) {
-	var msg map[string]interface{}
-	if err := json.Unmarshal([]byte(data), &msg); err != nil {
-		return
-	}
-
-	if msg["type"] == "control_request" {
-		request, _ := msg["request"].(map[string]interface{})
-		requestID, _ := msg["request_id"].(string)
-		subtype, _ := request["subtype"].(string)
-
-		switch subtype {
-		case "initialize":
-			m.responseCh <- map[string]interface{}{
-				"type": "control_response",
-				"response": map[string]interface{}{
-					"request_id":   requestID,
-					"subtype":      "success",
-					"commands":     []interface{}{},
-					"output_style": "default",
-				},
-			}
-		case "interrupt":
-			m.responseCh <- map[string]interface{}{
-				"type": "control_response",
-				"response": map[string]interface{}{
-					"request_id": requestID,
-					"subtype":    "success",
-				},
-			}
-		case "set_permission_mode":
-			m.responseCh <- map[string]interface{}{
-				"type": "control_response",
-				"response": map[string]interface{}{
-					"request_id": requestID,
-					"subtype":    "success",
-				},
-			}
-		case "set_model":
-			m.responseCh <- map[string]interface{}{
-				"type": "control_response",
-				"response": map[string]interface{}{
-					"request_id": requestID,
-					"subtype":    "success",
-				},
-			}
-		}
-	}
-}
-
-func (m *AdvancedMockTransport) ReadMessages(ctx context.Context) (<-chan map[string]interface{}, <-chan error) {
-	msgCh := make(chan map[string]interface{}, 10)
-	errCh := make(chan error, 1)
-
-	go func() {
-		defer close(msgCh)
-		defer close(errCh)
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-m.ctx.Done():
-				return
-			case msg, ok := <-m.responseCh:
-				if !ok {
-					return
-				}
-				select {
-				case msgCh <- msg:
-				case <-ctx.Done():
-					return
-				}
-			case err, ok := <-m.errorCh:
-				if ok && err != nil {
-					errCh <- err
-					return
-				}
-			}
-		}
-	}()
-
-	return msgCh, errCh
-}
-
-func (m *AdvancedMockTransport) Close() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	if !m.closed {
-		m.closed = true
-		m.cancel()
-		close(m.responseCh)
-		close(m.errorCh)
-	}
-	return nil
-}
-
-func (m *AdvancedMockTransport) IsReady() bool {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	return m.connected && !m.closed
-}
-
-func (m *AdvancedMockTransport) EndInput() error {
-	return nil
-}
-
-func (m *AdvancedMockTransport) GetWrittenMessages() []string {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	result := make([]string, len(m.writtenMessages))
-	copy(result, m.writtenMessages)
-	return result
-}
-
-func (m *AdvancedMockTransport) QueueResponse(msg map[string]interface{}) {
-	m.responseCh <- msg
-}
-
-func (m *AdvancedMockTransport) QueueError(err error) {
-	m.errorCh <- err
+	return claudetest.NewMockTransport()
 }
 
 // TestStreamingClientManualConnectDisconnect tests manual connection lifecycle
@@ -208,7 +52,7 @@ func TestStreamingClientManualConnectDisconnect(t *testing.T) {
 		t.Errorf("Disconnect failed: %v", err)
 	}
 
-	if !transport.closed {
+	if !transport.Closed() {
 		t.Error("Expected transport to be closed")
 	}
 }
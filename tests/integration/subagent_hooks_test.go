@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func toolUseMessage(parentToolUseID interface{}, id, name string, input map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "assistant",
+		"message": map[string]interface{}{
+			"role":    "assistant",
+			"model":   "claude-sonnet-4-5",
+			"content": []interface{}{map[string]interface{}{"type": "tool_use", "id": id, "name": name, "input": input}},
+		},
+		"parent_tool_use_id": parentToolUseID,
+	}
+}
+
+func hookCallbackRequest(requestID, toolUseID string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "control_request",
+		"request_id": requestID,
+		"request": map[string]interface{}{
+			"subtype":     "hook_callback",
+			"callback_id": "hook_0",
+			"tool_use_id": toolUseID,
+			"input":       map[string]interface{}{"hook_event_name": "PreToolUse", "tool_name": "Bash"},
+		},
+	}
+}
+
+func TestAgentScopedHooks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	var called []string
+	options := &claude.ClaudeAgentOptions{
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventPreToolUse: {{
+				Matcher: "Bash",
+				Agent:   "reviewer",
+				Hooks: []claude.HookCallback{
+					func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+						mu.Lock()
+						called = append(called, *toolUseID)
+						mu.Unlock()
+						return claude.HookJSONOutput{}, nil
+					},
+				},
+			}},
+		},
+	}
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	transport.QueueResponse(toolUseMessage(nil, "task_1", "Task", map[string]interface{}{"subagent_type": "reviewer", "description": "Review"}))
+	transport.QueueResponse(toolUseMessage("task_1", "bash_sub", "Bash", map[string]interface{}{"command": "ls"}))
+	transport.QueueResponse(toolUseMessage(nil, "bash_main", "Bash", map[string]interface{}{"command": "ls"}))
+	transport.QueueResponse(hookCallbackRequest("hook_req_1", "bash_sub"))
+	transport.QueueResponse(hookCallbackRequest("hook_req_2", "bash_main"))
+
+	for _, id := range []string{"hook_req_1", "hook_req_2"} {
+		if _, ok := transport.WaitForControlResponse(id, 2*time.Second); !ok {
+			t.Fatalf("No control response for %s", id)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(called) != 1 || called[0] != "bash_sub" {
+		t.Errorf("Expected hook to run only for the reviewer's tool use, ran for %v", called)
+	}
+}
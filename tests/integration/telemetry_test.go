@@ -0,0 +1,179 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+type spanKey struct{}
+
+// recordingTracer is a TracerProvider, Tracer, and CounterMetrics that
+// keeps everything reported to it.
+type recordingTracer struct {
+	mu       sync.Mutex
+	spans    []*recordedSpan
+	counters map[string]float64
+	records  map[string]int
+}
+
+type recordedSpan struct {
+	tracer *recordingTracer
+	name   string
+	parent *recordedSpan
+	attrs  map[string]interface{}
+	err    error
+	ended  int
+}
+
+func newRecordingTracer() *recordingTracer {
+	return &recordingTracer{counters: make(map[string]float64), records: make(map[string]int)}
+}
+
+func (r *recordingTracer) Tracer(name string) claude.Tracer { return r }
+
+func (r *recordingTracer) Start(ctx context.Context, name string, attrs ...claude.Attribute) (context.Context, claude.Span) {
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	span := &recordedSpan{tracer: r, name: name, parent: parent, attrs: make(map[string]interface{})}
+	span.SetAttributes(attrs...)
+	r.mu.Lock()
+	r.spans = append(r.spans, span)
+	r.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (r *recordingTracer) ContextWithSpan(ctx context.Context, span claude.Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+func (r *recordingTracer) AddCounter(name string, value float64, labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if typ, ok := labels["type"]; ok {
+		name += "/" + typ
+	}
+	r.counters[name] += value
+}
+
+func (r *recordingTracer) ObserveDuration(name string, d time.Duration, labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[name]++
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...claude.Attribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) { s.err = err }
+
+func (s *recordedSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.ended++
+}
+
+// find returns the spans named name with attribute key set to value.
+func (r *recordingTracer) find(name string, key string, value interface{}) []*recordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found []*recordedSpan
+	for _, span := range r.spans {
+		if span.name == name && span.attrs[key] == value {
+			found = append(found, span)
+		}
+	}
+	return found
+}
+
+func TestTelemetrySpansAndMetrics(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	recorder := newRecordingTracer()
+	var hookSpan *recordedSpan
+	hook := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+		hookSpan, _ = ctx.Value(spanKey{}).(*recordedSpan)
+		return claude.HookJSONOutput{}, nil
+	}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{
+		TracerProvider: recorder,
+		Metrics:        recorder,
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventPreToolUse: {{Hooks: []claude.HookCallback{hook}}},
+		},
+	}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	parentCtx, parent := recorder.Start(ctx, "handler")
+	msgCh, errCh := client.Query(parentCtx, "Read the file")
+
+	transport.QueueResponse(CreateAssistantToolUseMessage("Reading", "toolu_1", "Read", map[string]interface{}{"file_path": "a.go"}))
+	transport.QueueResponse(eventHookRequest("hook_1", initializeCallbackID(t, transport, claude.HookEventPreToolUse),
+		map[string]interface{}{"hook_event_name": "PreToolUse", "tool_name": "Read"}))
+	if _, ok := transport.WaitForControlResponse("hook_1", time.Second); !ok {
+		t.Fatal("no response to hook callback")
+	}
+	transport.QueueResponse(map[string]interface{}{
+		"type": "user",
+		"message": map[string]interface{}{
+			"role": "user",
+			"content": []interface{}{
+				map[string]interface{}{"type": "tool_result", "tool_use_id": "toolu_1", "content": "package main"},
+			},
+		},
+	})
+	result := CreateResultMessage("s1", 0.02, 500)
+	result["usage"] = map[string]interface{}{"input_tokens": 10.0, "output_tokens": 200.0}
+	transport.QueueResponse(result)
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	queries := recorder.find(claude.SpanQuery, "claude.session_id", "s1")
+	if len(queries) != 1 || queries[0].parent != parent || queries[0].ended != 1 {
+		t.Fatalf("expected one ended query span under the caller's span, got %+v", queries)
+	}
+	query := queries[0]
+	if query.attrs["claude.tokens.output"] != 200 || query.attrs["claude.num_turns"] != 1 {
+		t.Errorf("unexpected query attributes %v", query.attrs)
+	}
+
+	tools := recorder.find(claude.SpanToolUse, "claude.tool.name", "Read")
+	if len(tools) != 1 || tools[0].parent != query || tools[0].ended != 1 || tools[0].attrs["claude.tool.is_error"] != false {
+		t.Errorf("expected one ended Read span under the query, got %+v", tools)
+	}
+
+	inbound := recorder.find(claude.SpanControlRequest, "claude.control.subtype", "hook_callback")
+	if len(inbound) != 1 || inbound[0].parent != query || inbound[0].ended != 1 {
+		t.Errorf("expected one ended hook_callback span under the query, got %+v", inbound)
+	} else if hookSpan != inbound[0] {
+		t.Error("hook did not run with the control request span in its context")
+	}
+	if outbound := recorder.find(claude.SpanControlRequest, "claude.control.subtype", "initialize"); len(outbound) != 1 || outbound[0].ended != 1 {
+		t.Errorf("expected one ended initialize span, got %+v", outbound)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if recorder.counters[claude.MetricTokens+"/output"] != 200 || recorder.counters[claude.MetricTokens+"/input"] != 10 {
+		t.Errorf("unexpected token counters %v", recorder.counters)
+	}
+	if cost := recorder.counters[claude.MetricCost]; cost < 0.0199 || cost > 0.0201 {
+		t.Errorf("cost counter = %v, want 0.02", cost)
+	}
+	if recorder.records[claude.MetricQueryDuration] != 1 || recorder.records[claude.MetricToolUseDuration] != 1 {
+		t.Errorf("unexpected histograms %v", recorder.records)
+	}
+}
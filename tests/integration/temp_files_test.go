@@ -0,0 +1,140 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// agentsFakeCLI reports the --agents file path, its permissions, whether it
+// is readable, and how many files remain in $SDK_TEMP_DIR while running.
+const agentsFakeCLI = `while [ $# -gt 0 ]; do
+  if [ "$1" = "--agents" ]; then f="${2#@}"; fi
+  shift
+done
+perm=$(ls -lL "$f" | cut -c1-10)
+size=$(wc -c < "$f" | tr -d ' ')
+sleep 0.2 # let the SDK finish cleaning up after start
+left=$(ls "$SDK_TEMP_DIR" | wc -l | tr -d ' ')
+echo "{\"type\":\"result\",\"subtype\":\"success\",\"duration_ms\":1,\"duration_api_ms\":1,\"is_error\":false,\"num_turns\":1,\"session_id\":\"s\",\"result\":\"$f|$perm|$size|$left\"}"
+`
+
+func runAgentsFakeCLI(t *testing.T, cleanup claude.TempFileCleanup) (fields []string, tempDir string) {
+	t.Helper()
+	cliPath := writeFakeCLI(t, agentsFakeCLI)
+	tempDir = t.TempDir() + "/agents"
+
+	options := &claude.ClaudeAgentOptions{
+		Agents: map[string]claude.AgentDefinition{
+			"reviewer": {Description: "Reviews code", Prompt: strings.Repeat("Be thorough. ", 10000)},
+		},
+		TempDir:         tempDir,
+		TempFileCleanup: cleanup,
+		Env:             map[string]string{"SDK_TEMP_DIR": tempDir},
+	}
+	trans, err := claude.NewSubprocessCLITransport("hi", options, cliPath)
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	msgCh, errCh, err := claude.Query(context.Background(), "hi", options, trans)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	messages, err := CollectMessages(msgCh, errCh)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("unexpected query outcome: %v, %d messages", err, len(messages))
+	}
+	return strings.Split(*messages[0].(*claude.ResultMessage).Result, "|"), tempDir
+}
+
+func TestAgentsTempFileIsPrivate(t *testing.T) {
+	fields, tempDir := runAgentsFakeCLI(t, claude.TempFileCleanupOnClose)
+
+	if !strings.HasPrefix(fields[0], tempDir+"/") {
+		t.Errorf("expected the agents file in TempDir, got %q", fields[0])
+	}
+	if fields[1] != "-rw-------" {
+		t.Errorf("expected 0600 permissions, got %q", fields[1])
+	}
+	if fields[2] == "0" || fields[2] == "" {
+		t.Errorf("expected the agents JSON in the file, got size %q", fields[2])
+	}
+	if fields[3] != "1" {
+		t.Errorf("expected the file to exist while the CLI runs, got %s files", fields[3])
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("expected the file to be removed on close, found %d", len(entries))
+	}
+}
+
+func TestAgentsTempFileRemovedAfterStart(t *testing.T) {
+	fields, _ := runAgentsFakeCLI(t, claude.TempFileCleanupAfterStart)
+
+	if !strings.HasPrefix(fields[0], "/dev/fd/") {
+		t.Fatalf("expected an inherited descriptor, got %q", fields[0])
+	}
+	if fields[2] == "0" || fields[2] == "" {
+		t.Errorf("expected the agents JSON to be readable, got size %q", fields[2])
+	}
+	if fields[3] != "0" {
+		t.Errorf("expected the path to be removed once the CLI started, got %s files", fields[3])
+	}
+}
+
+func TestAgentsTempFileSpillCallback(t *testing.T) {
+	cliPath := writeFakeCLI(t, agentsFakeCLI)
+	tempDir := t.TempDir()
+	agents := map[string]claude.AgentDefinition{
+		"reviewer": {Description: "Reviews code", Prompt: strings.Repeat("Be thorough. ", 10000)},
+	}
+
+	var spills []claude.TempFileSpill
+	options := &claude.ClaudeAgentOptions{
+		Agents:  agents,
+		TempDir: tempDir,
+		Env:     map[string]string{"SDK_TEMP_DIR": tempDir},
+		TempFileSpill: func(spill claude.TempFileSpill) error {
+			spills = append(spills, spill)
+			return nil
+		},
+	}
+	trans, err := claude.NewSubprocessCLITransport("hi", options, cliPath)
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	msgCh, errCh, err := claude.Query(context.Background(), "hi", options, trans)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(spills) != 1 {
+		t.Fatalf("expected one spill, got %+v", spills)
+	}
+	spill := spills[0]
+	if spill.Arg != "--agents" || spill.Size < 130000 || !strings.HasPrefix(spill.Path, tempDir+"/") || spill.CommandLength <= spill.Limit {
+		t.Errorf("unexpected spill: %+v", spill)
+	}
+
+	// A veto fails the connection and leaves no file behind
+	options.TempFileSpill = func(spill claude.TempFileSpill) error {
+		return errors.New("agents must fit on the command line")
+	}
+	trans, err = claude.NewSubprocessCLITransport("hi", options, cliPath)
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	_, _, err = claude.Query(context.Background(), "hi", options, trans)
+	var connErr *claude.CLIConnectionError
+	if !errors.As(err, &connErr) || !strings.Contains(err.Error(), "agents must fit on the command line") {
+		t.Errorf("expected a CLIConnectionError wrapping the veto, got %v", err)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("expected the vetoed file to be removed, found %d", len(entries))
+	}
+}
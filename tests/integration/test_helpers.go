@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/claudetest"
 )
 
 // MockTransport implements the Transport interface for testing
@@ -88,60 +89,19 @@ func (m *MockTransport) Close() error {
 // Helper functions for creating test messages
 
 func CreateAssistantTextMessage(text string) map[string]interface{} {
-	return map[string]interface{}{
-		"type": "assistant",
-		"message": map[string]interface{}{
-			"role": "assistant",
-			"content": []interface{}{
-				map[string]interface{}{
-					"type": "text",
-					"text": text,
-				},
-			},
-			"model": "claude-sonnet-4-5",
-		},
-	}
+	return claudetest.AssistantText(text)
 }
 
 func CreateAssistantToolUseMessage(text string, toolID string, toolName string, toolInput map[string]interface{}) map[string]interface{} {
-	return map[string]interface{}{
-		"type": "assistant",
-		"message": map[string]interface{}{
-			"role": "assistant",
-			"content": []interface{}{
-				map[string]interface{}{
-					"type": "text",
-					"text": text,
-				},
-				map[string]interface{}{
-					"type":  "tool_use",
-					"id":    toolID,
-					"name":  toolName,
-					"input": toolInput,
-				},
-			},
-			"model": "claude-sonnet-4-5",
-		},
-	}
+	return claudetest.AssistantToolUse(text, toolID, toolName, toolInput)
 }
 
 func CreateResultMessage(sessionID string, costUSD float64, durationMS int) map[string]interface{} {
-	return map[string]interface{}{
-		"type":            "result",
-		"subtype":         "success",
-		"duration_ms":     float64(durationMS),
-		"duration_api_ms": float64(durationMS - 200),
-		"is_error":        false,
-		"num_turns":       float64(1),
-		"session_id":      sessionID,
-		"total_cost_usd":  costUSD,
-	}
+	return claudetest.Result(sessionID, costUSD, durationMS)
 }
 
 func CreateResultMessageWithSubtype(sessionID string, subtype string, costUSD float64, durationMS int) map[string]interface{} {
-	msg := CreateResultMessage(sessionID, costUSD, durationMS)
-	msg["subtype"] = subtype
-	return msg
+	return claudetest.ResultWithSubtype(sessionID, subtype, costUSD, durationMS)
 }
 
 // CollectMessages is a helper to collect all messages from a query
@@ -0,0 +1,61 @@
+package integration
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestQueryText(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []map[string]interface{}
+		want     []string
+	}{
+		{
+			name:     "text deltas",
+			messages: partialMessages(),
+			want:     []string{"ab", "cd", "ef", "g"},
+		},
+		{
+			name: "sentences of complete messages",
+			messages: []map[string]interface{}{
+				CreateAssistantToolUseMessage("Let me check.", "tool-1", "Read", map[string]interface{}{"file_path": "a.go"}),
+				CreateAssistantTextMessage("It compiles! Two tests fail.\nShall I fix them?"),
+				CreateResultMessage("s", 0.01, 100),
+			},
+			want: []string{"Let me check.", "It compiles! ", "Two tests fail.\n", "Shall I fix them?"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			transport := NewAdvancedMockTransport()
+			client := claude.NewClaudeSDKClientWithTransport(nil, transport)
+			if err := client.Connect(ctx); err != nil {
+				t.Fatalf("Connect failed: %v", err)
+			}
+			defer client.Close()
+
+			textCh, errCh := client.QueryText(ctx, "hi")
+			for _, msg := range tt.messages {
+				transport.QueueResponse(msg)
+			}
+			var got []string
+			for chunk := range textCh {
+				got = append(got, chunk)
+			}
+			if err := <-errCh; err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func streamEventMessage(event map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "stream_event",
+		"uuid":       "evt",
+		"session_id": "s",
+		"event":      event,
+	}
+}
+
+func TestClientTimeline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{RecordTimeline: true}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	msgCh := client.ReceiveMessages(ctx)
+	next := func(raw map[string]interface{}) {
+		t.Helper()
+		transport.QueueResponse(raw)
+		select {
+		case <-msgCh:
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for message")
+		}
+	}
+
+	if err := client.QueryWithSession(ctx, "list files", "default"); err != nil {
+		t.Fatalf("QueryWithSession failed: %v", err)
+	}
+	next(toolUseMessage(nil, "tool_1", "Bash", map[string]interface{}{"command": "ls"}))
+	time.Sleep(30 * time.Millisecond)
+	next(map[string]interface{}{
+		"type": "user",
+		"message": map[string]interface{}{
+			"role":    "user",
+			"content": []interface{}{map[string]interface{}{"type": "tool_result", "tool_use_id": "tool_1", "content": "a.go"}},
+		},
+	})
+
+	// A streamed text block is timed by its events and not repeated for the
+	// AssistantMessage that follows
+	next(streamEventMessage(map[string]interface{}{"type": "content_block_start", "index": 0.0, "content_block": map[string]interface{}{"type": "text"}}))
+	time.Sleep(20 * time.Millisecond)
+	next(streamEventMessage(map[string]interface{}{"type": "content_block_stop", "index": 0.0}))
+	next(CreateAssistantTextMessage("There is one file."))
+	next(CreateResultMessage("s", 0.01, 1000))
+
+	timeline := client.Timeline()
+	if timeline == nil {
+		t.Fatal("expected a timeline")
+	}
+	kinds := make(map[claude.TimelineEventKind][]claude.TimelineEvent)
+	for i, event := range timeline.Events {
+		kinds[event.Kind] = append(kinds[event.Kind], event)
+		if i > 0 && event.Start.Before(timeline.Events[i-1].Start) {
+			t.Errorf("events not ordered by start: %v", timeline.Events)
+		}
+	}
+
+	tools := kinds[claude.TimelineEventToolCall]
+	if len(tools) != 1 || tools[0].ToolName != "Bash" || tools[0].End.IsZero() || tools[0].DurationMS < 25 {
+		t.Errorf("unexpected tool call events: %+v", tools)
+	}
+	texts := kinds[claude.TimelineEventText]
+	if len(texts) != 1 || texts[0].DurationMS < 15 {
+		t.Errorf("expected one streamed text event, got %+v", texts)
+	}
+	turns := kinds[claude.TimelineEventTurn]
+	if len(turns) != 1 || turns[0].DurationMS != 1000 {
+		t.Errorf("expected one 1s turn event, got %+v", turns)
+	}
+
+	data, err := timeline.JSON()
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+	var decoded claude.Timeline
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Events) != len(timeline.Events) {
+		t.Errorf("timeline does not round-trip: %v", err)
+	}
+}
+
+func TestClientTimelineDisabled(t *testing.T) {
+	if claude.NewClaudeSDKClient(nil).Timeline() != nil {
+		t.Error("expected nil timeline when RecordTimeline is not set")
+	}
+}
@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestToolLimits(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	options := &claude.ClaudeAgentOptions{
+		ToolLimits: map[string]claude.ToolLimit{
+			"Bash":     {MaxCalls: 2, Window: time.Minute},
+			"WebFetch": {MaxConcurrent: 1},
+		},
+	}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	preID := initializeCallbackID(t, transport, claude.HookEventPreToolUse)
+	postID := initializeCallbackID(t, transport, claude.HookEventPostToolUse)
+	seq := 0
+	// call sends a hook callback and returns the PreToolUse deny reason, if any
+	call := func(callbackID, event, toolName, toolUseID string) string {
+		t.Helper()
+		seq++
+		requestID := "req_" + string(rune('a'+seq))
+		transport.QueueResponse(map[string]interface{}{
+			"type":       "control_request",
+			"request_id": requestID,
+			"request": map[string]interface{}{
+				"subtype":     "hook_callback",
+				"callback_id": callbackID,
+				"tool_use_id": toolUseID,
+				"input":       map[string]interface{}{"hook_event_name": event, "tool_name": toolName},
+			},
+		})
+		resp, ok := transport.WaitForControlResponse(requestID, time.Second)
+		if !ok {
+			t.Fatalf("no response to %s", requestID)
+		}
+		inner, _ := resp["response"].(map[string]interface{})
+		specific, _ := inner["hookSpecificOutput"].(map[string]interface{})
+		reason, _ := specific["permissionDecisionReason"].(string)
+		return reason
+	}
+
+	// Rate limit: the third Bash call within a minute is denied
+	for i, id := range []string{"bash_1", "bash_2"} {
+		if reason := call(preID, "PreToolUse", "Bash", id); reason != "" {
+			t.Fatalf("Bash call %d denied: %s", i+1, reason)
+		}
+		call(postID, "PostToolUse", "Bash", id)
+	}
+	if reason := call(preID, "PreToolUse", "Bash", "bash_3"); !strings.Contains(reason, "rate limit") {
+		t.Errorf("expected the third Bash call to be rate limited, got %q", reason)
+	}
+
+	// Concurrency: a second WebFetch is denied while the first runs
+	if reason := call(preID, "PreToolUse", "WebFetch", "fetch_1"); reason != "" {
+		t.Fatalf("first WebFetch denied: %s", reason)
+	}
+	if reason := call(preID, "PreToolUse", "WebFetch", "fetch_2"); !strings.Contains(reason, "concurrency limit") {
+		t.Errorf("expected a concurrent WebFetch to be denied, got %q", reason)
+	}
+	call(postID, "PostToolUse", "WebFetch", "fetch_1")
+	if reason := call(preID, "PreToolUse", "WebFetch", "fetch_3"); reason != "" {
+		t.Errorf("expected WebFetch to be allowed after the first finished, got %q", reason)
+	}
+}
+
+func TestToolLimitsRequireStreaming(t *testing.T) {
+	options := &claude.ClaudeAgentOptions{ToolLimits: map[string]claude.ToolLimit{"Bash": {MaxCalls: 1}}}
+	_, _, err := claude.Query(context.Background(), "hi", options, NewMockTransport(nil))
+	if err == nil {
+		t.Error("expected tool limits without streaming mode to fail")
+	}
+}
@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestToolMocks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	mocks := claude.NewToolMocks().
+		Return("WebFetch", "<title>Release notes</title>").
+		Handle("Bash", func(ctx context.Context, input map[string]interface{}) string {
+			return "ran: " + input["command"].(string)
+		}).
+		DenyUnmocked()
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{ToolMocks: mocks}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	preID := initializeCallbackID(t, transport, claude.HookEventPreToolUse)
+	// call sends a PreToolUse hook callback and returns its decision and reason
+	call := func(requestID, toolName string, toolInput map[string]interface{}) (string, string) {
+		t.Helper()
+		transport.QueueResponse(map[string]interface{}{
+			"type":       "control_request",
+			"request_id": requestID,
+			"request": map[string]interface{}{
+				"subtype":     "hook_callback",
+				"callback_id": preID,
+				"tool_use_id": requestID,
+				"input": map[string]interface{}{
+					"hook_event_name": "PreToolUse",
+					"tool_name":       toolName,
+					"tool_input":      toolInput,
+				},
+			},
+		})
+		resp, ok := transport.WaitForControlResponse(requestID, time.Second)
+		if !ok {
+			t.Fatalf("no response to %s", requestID)
+		}
+		inner, _ := resp["response"].(map[string]interface{})
+		specific, _ := inner["hookSpecificOutput"].(map[string]interface{})
+		decision, _ := specific["permissionDecision"].(string)
+		reason, _ := specific["permissionDecisionReason"].(string)
+		return decision, reason
+	}
+
+	if decision, reason := call("fetch_1", "WebFetch", map[string]interface{}{"url": "https://example.com"}); decision != "deny" || reason != "<title>Release notes</title>" {
+		t.Errorf("expected the canned WebFetch result, got %s %q", decision, reason)
+	}
+	if _, reason := call("bash_1", "Bash", map[string]interface{}{"command": "go test ./..."}); reason != "ran: go test ./..." {
+		t.Errorf("expected the Bash handler's result, got %q", reason)
+	}
+	if decision, reason := call("write_1", "Write", map[string]interface{}{"file_path": "/tmp/x"}); decision != "deny" || reason != "Write is not mocked in this test" {
+		t.Errorf("expected the unmocked Write to be denied, got %s %q", decision, reason)
+	}
+
+	calls := mocks.Calls("")
+	if len(calls) != 2 || calls[0].ToolName != "WebFetch" || calls[0].ToolUseID != "fetch_1" || calls[1].Input["command"] != "go test ./..." {
+		t.Errorf("unexpected recorded calls: %+v", calls)
+	}
+	if bash := mocks.Calls("Bash"); len(bash) != 1 || bash[0].Result != "ran: go test ./..." {
+		t.Errorf("unexpected Bash calls: %+v", bash)
+	}
+}
+
+func TestToolMocksRequireStreaming(t *testing.T) {
+	options := &claude.ClaudeAgentOptions{ToolMocks: claude.NewToolMocks().Return("Bash", "ok")}
+	_, _, err := claude.Query(context.Background(), "hi", options, NewMockTransport(nil))
+	if err == nil {
+		t.Error("expected tool mocks without streaming mode to fail")
+	}
+}
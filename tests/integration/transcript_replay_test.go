@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// replyTexts returns the text of the assistant messages in messages.
+func replyTexts(messages []claude.Message) []string {
+	var texts []string
+	for _, msg := range messages {
+		if m, ok := msg.(*claude.AssistantMessage); ok {
+			for _, block := range m.Content {
+				if text, ok := block.(claude.TextBlock); ok {
+					texts = append(texts, text.Text)
+				}
+			}
+		}
+	}
+	return texts
+}
+
+func TestTranscriptReplay(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Record two queries against a scripted CLI
+	rec, err := claude.NewTranscriptRecorder(claude.TranscriptOptions{
+		Path:        filepath.Join(t.TempDir(), "session.jsonl"),
+		Compression: claude.TranscriptCompressionGzip,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{Transcript: rec}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	for _, reply := range []string{"first answer", "second answer"} {
+		msgCh, errCh := client.Query(ctx, "question")
+		transport.QueueResponse(CreateAssistantTextMessage(reply))
+		transport.QueueResponse(CreateResultMessage("s1", 0.01, 100))
+		if _, err := CollectMessages(msgCh, errCh); err != nil {
+			t.Fatalf("recording failed: %v", err)
+		}
+	}
+	client.Close()
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := rec.Files()
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one transcript file, got %v (%v)", files, err)
+	}
+	records, err := claude.ReadTranscriptFiles(files...)
+	if err != nil {
+		t.Fatalf("ReadTranscriptFiles failed: %v", err)
+	}
+
+	// Each replayed query gets its own recorded response
+	replay := claude.NewReplayTransport(records)
+	client = claude.NewClaudeSDKClientWithTransport(nil, replay)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect with replay failed: %v", err)
+	}
+	defer client.Close()
+	for _, want := range []string{"first answer", "second answer"} {
+		messages, err := CollectMessages(client.Query(ctx, "question"))
+		if err != nil {
+			t.Fatalf("replayed query failed: %v", err)
+		}
+		if texts := replyTexts(messages); len(texts) != 1 || texts[0] != want {
+			t.Errorf("replayed texts %q, want %q", texts, want)
+		}
+		if _, ok := messages[len(messages)-1].(*claude.ResultMessage); !ok {
+			t.Errorf("replayed query did not end with a ResultMessage")
+		}
+	}
+
+	if err := replay.Connect(ctx); err == nil {
+		t.Error("expected a second Connect to fail")
+	}
+}
+
+func TestTranscriptReplayOneShot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var records []claude.TranscriptRecord
+	for _, msg := range []string{
+		`{"type": "system", "subtype": "init", "session_id": "s1"}`,
+		`{"type": "assistant", "message": {"role": "assistant", "model": "claude-sonnet-4-5", "content": [{"type": "text", "text": "Hello"}]}}`,
+		`{"type": "result", "subtype": "success", "duration_ms": 10, "duration_api_ms": 8, "is_error": false, "num_turns": 1, "session_id": "s1"}`,
+	} {
+		records = append(records, claude.TranscriptRecord{Direction: claude.TranscriptInbound, Message: []byte(msg)})
+	}
+
+	msgCh, errCh, err := claude.Query(ctx, "Say hello", nil, claude.NewReplayTransport(records))
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	messages, err := CollectMessages(msgCh, errCh)
+	if err != nil {
+		t.Fatalf("replayed query failed: %v", err)
+	}
+	if len(messages) != 3 || replyTexts(messages)[0] != "Hello" {
+		t.Errorf("unexpected replayed messages %+v", messages)
+	}
+}
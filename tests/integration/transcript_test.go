@@ -0,0 +1,73 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestClientRecordsTranscript(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rec, err := claude.NewTranscriptRecorder(claude.TranscriptOptions{
+		Path: filepath.Join(t.TempDir(), "transcript.jsonl"),
+	})
+	if err != nil {
+		t.Fatalf("NewTranscriptRecorder failed: %v", err)
+	}
+
+	transport := NewAdvancedMockTransport()
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{Transcript: rec}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	transport.QueueResponse(CreateAssistantTextMessage("Hi"))
+	transport.QueueResponse(CreateResultMessage("s1", 0.001, 10))
+
+	msgCh, errCh := client.Query(ctx, "Hello")
+	if _, err := CollectMessages(msgCh, errCh); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	client.Disconnect()
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	files, _ := rec.Files()
+	if len(files) != 1 {
+		t.Fatalf("expected one transcript file, got %v", files)
+	}
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer file.Close()
+
+	counts := map[string]map[string]int{claude.TranscriptInbound: {}, claude.TranscriptOutbound: {}}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record claude.TranscriptRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid record: %v", err)
+		}
+		var msg map[string]interface{}
+		json.Unmarshal(record.Message, &msg)
+		msgType, _ := msg["type"].(string)
+		counts[record.Direction][msgType]++
+	}
+
+	if counts[claude.TranscriptOutbound]["user"] != 1 || counts[claude.TranscriptOutbound]["control_request"] == 0 {
+		t.Errorf("expected outbound user message and control requests, got %v", counts[claude.TranscriptOutbound])
+	}
+	if counts[claude.TranscriptInbound]["assistant"] != 1 || counts[claude.TranscriptInbound]["result"] != 1 {
+		t.Errorf("expected inbound assistant and result messages, got %v", counts[claude.TranscriptInbound])
+	}
+}
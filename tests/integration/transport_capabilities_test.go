@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// capableTransport advertises optional capabilities on top of the mock.
+type capableTransport struct {
+	*AdvancedMockTransport
+	reconnect   bool
+	compression bool
+	alive       error
+}
+
+func (c *capableTransport) SupportsReconnect() bool            { return c.reconnect }
+func (c *capableTransport) SupportsCompression() bool          { return c.compression }
+func (c *capableTransport) Liveness(ctx context.Context) error { return c.alive }
+
+func TestCapabilitiesOf(t *testing.T) {
+	plain := claude.CapabilitiesOf(NewAdvancedMockTransport())
+	if plain.Reconnect != nil || plain.Compression || plain.Liveness {
+		t.Errorf("expected defaults for a transport without capabilities, got %+v", plain)
+	}
+
+	caps := claude.CapabilitiesOf(&capableTransport{AdvancedMockTransport: NewAdvancedMockTransport(), compression: true})
+	if caps.Reconnect == nil || *caps.Reconnect || !caps.Compression || !caps.Liveness {
+		t.Errorf("unexpected capabilities: %+v", caps)
+	}
+
+	subprocess, err := claude.NewSubprocessCLITransport("hi", nil, "/bin/true")
+	if err != nil {
+		t.Fatalf("failed to create transport: %v", err)
+	}
+	if caps := claude.CapabilitiesOf(subprocess); caps.Reconnect == nil || !*caps.Reconnect || !caps.Liveness {
+		t.Errorf("expected the subprocess transport to reconnect and probe liveness, got %+v", caps)
+	}
+	if err := claude.CheckLiveness(context.Background(), subprocess); err == nil {
+		t.Error("expected an unstarted subprocess to be reported as not alive")
+	}
+}
+
+func TestClientUsesTransportCapabilities(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	down := errors.New("peer went away")
+	transport := &capableTransport{AdvancedMockTransport: NewAdvancedMockTransport(), compression: true}
+	transport.InitResponse = map[string]interface{}{"input_encodings": []interface{}{"gzip"}}
+	threshold := 16
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{CompressInputThreshold: &threshold}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Liveness(ctx); err != nil {
+		t.Errorf("expected a live transport, got %v", err)
+	}
+	transport.alive = down
+	if err := client.Liveness(ctx); !errors.Is(err, down) {
+		t.Errorf("expected the transport's liveness error, got %v", err)
+	}
+
+	// A transport that compresses itself gets plain JSON
+	if err := client.QueryWithSession(ctx, strings.Repeat("log line\n", 100), "default"); err != nil {
+		t.Fatalf("QueryWithSession failed: %v", err)
+	}
+	if msg := lastWrittenMessage(t, transport.AdvancedMockTransport); msg["type"] != "user" {
+		t.Errorf("expected SDK compression to be skipped, got %v", msg["type"])
+	}
+
+	// A transport that cannot reconnect refuses session restarts
+	if err := client.SetSettingSources(ctx, []claude.SettingSource{claude.SettingSourceProject}); err == nil {
+		t.Error("expected restart without reconnect support to fail")
+	}
+}
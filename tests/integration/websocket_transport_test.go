@@ -0,0 +1,407 @@
+package integration
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// wsTestServer is a WebSocket server speaking the CLI's stream-json
+// protocol: it answers control requests and replies to each user message
+// with an assistant message and a result. dropFirst closes the first
+// connection, without a close frame, once it is initialized, and dropOn
+// closes it instead of answering a control request of that subtype;
+// ignorePings leaves pings unanswered, as a half-open connection does.
+type wsTestServer struct {
+	t           *testing.T
+	dropFirst   bool
+	dropOn      string
+	ignorePings bool
+	connections atomic.Int32
+	initialized atomic.Int32 // Initialize requests received
+	auth        atomic.Value // Authorization header of the last handshake
+}
+
+func (s *wsTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		http.Error(w, "expected a WebSocket upgrade", http.StatusBadRequest)
+		return
+	}
+	s.auth.Store(r.Header.Get("Authorization"))
+	sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		s.t.Errorf("hijack failed: %v", err)
+		return
+	}
+	defer conn.Close()
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	rw.Flush()
+
+	n := s.connections.Add(1)
+	for {
+		payload, err := wsServerRead(rw.Reader, conn, !s.ignorePings)
+		if err != nil {
+			return
+		}
+		for _, line := range bytes.Split(payload, []byte("\n")) {
+			var msg map[string]interface{}
+			if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &msg) != nil {
+				continue
+			}
+			switch msg["type"] {
+			case "control_request":
+				request, _ := msg["request"].(map[string]interface{})
+				if request["subtype"] == "initialize" {
+					s.initialized.Add(1)
+				}
+				if request["subtype"] == s.dropOn && n == 1 {
+					return
+				}
+				wsServerSend(conn, map[string]interface{}{
+					"type": "control_response",
+					"response": map[string]interface{}{
+						"request_id": msg["request_id"],
+						"subtype":    "success",
+					},
+				})
+				if request["subtype"] == "initialize" && s.dropFirst && n == 1 {
+					return
+				}
+			case "user":
+				// Both messages in one WebSocket message
+				wsServerSend(conn, CreateAssistantTextMessage("Hello over WebSocket"), CreateResultMessage("ws-session", 0.01, 300))
+			}
+		}
+	}
+}
+
+// wsServerRead reads a client message, answering pings if answerPings.
+func wsServerRead(r *bufio.Reader, conn net.Conn, answerPings bool) ([]byte, error) {
+	var message []byte
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			return nil, err
+		}
+		length := uint64(head[1] & 0x7F)
+		switch length {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return nil, err
+			}
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return nil, err
+			}
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+		var mask [4]byte
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return nil, err
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, err
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+		switch opcode := head[0] & 0x0F; opcode {
+		case 0x8:
+			return nil, io.EOF
+		case 0x9:
+			if answerPings {
+				wsServerFrame(conn, 0xA, payload)
+			}
+			continue
+		}
+		message = append(message, payload...)
+		if head[0]&0x80 != 0 {
+			return message, nil
+		}
+	}
+}
+
+// wsServerSend sends msgs as JSON lines in one text message.
+func wsServerSend(conn net.Conn, msgs ...map[string]interface{}) {
+	var payload []byte
+	for _, msg := range msgs {
+		data, _ := json.Marshal(msg)
+		payload = append(append(payload, data...), '\n')
+	}
+	wsServerFrame(conn, 0x1, payload)
+}
+
+// wsServerFrame writes an unmasked frame.
+func wsServerFrame(conn net.Conn, opcode byte, payload []byte) {
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = binary.BigEndian.AppendUint16(append(header, 126), uint16(n))
+	default:
+		header = binary.BigEndian.AppendUint64(append(header, 127), uint64(n))
+	}
+	conn.Write(append(header, payload...))
+}
+
+// queryOverWebSocket runs one query through a client on transport.
+func queryOverWebSocket(t *testing.T, ctx context.Context, client *claude.ClaudeSDKClient) {
+	t.Helper()
+	msgCh, errCh := client.Query(ctx, "hello")
+	messages, err := CollectMessages(msgCh, errCh)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected an assistant message and a result, got %d messages", len(messages))
+	}
+	assistant, ok := messages[0].(*claude.AssistantMessage)
+	if !ok || assistant.Content[0].(claude.TextBlock).Text != "Hello over WebSocket" {
+		t.Errorf("unexpected first message: %#v", messages[0])
+	}
+	if result, ok := messages[1].(*claude.ResultMessage); !ok || result.SessionID != "ws-session" {
+		t.Errorf("unexpected result: %#v", messages[1])
+	}
+}
+
+func TestWebSocketTransport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	handler := &wsTestServer{t: t}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	transport, err := claude.NewWebSocketTransport("ws"+strings.TrimPrefix(server.URL, "http"), &claude.WebSocketOptions{
+		Header: http.Header{"Authorization": {"Bearer secret"}},
+	})
+	if err != nil {
+		t.Fatalf("NewWebSocketTransport failed: %v", err)
+	}
+	if r := claude.CapabilitiesOf(transport).Reconnect; r == nil || !*r {
+		t.Error("expected the transport to support reconnecting")
+	}
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	if auth := handler.auth.Load(); auth != "Bearer secret" {
+		t.Errorf("expected the Authorization header in the handshake, got %v", auth)
+	}
+	// Connecting again keeps the open connection
+	if err := transport.Connect(ctx); err != nil || handler.connections.Load() != 1 {
+		t.Errorf("expected a second Connect to keep the connection, got %v with %d connections", err, handler.connections.Load())
+	}
+	queryOverWebSocket(t, ctx, client)
+
+	if err := transport.Liveness(ctx); err != nil {
+		t.Errorf("Liveness failed: %v", err)
+	}
+}
+
+func TestWebSocketTransportTLS(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	server := httptest.NewTLSServer(&wsTestServer{t: t})
+	defer server.Close()
+	url := "wss" + strings.TrimPrefix(server.URL, "https")
+
+	// The server's certificate is not trusted by default
+	untrusted, err := claude.NewWebSocketTransport(url, nil)
+	if err != nil {
+		t.Fatalf("NewWebSocketTransport failed: %v", err)
+	}
+	var connErr *claude.CLIConnectionError
+	if err := untrusted.Connect(ctx); !errors.As(err, &connErr) {
+		t.Fatalf("expected a CLIConnectionError for an untrusted certificate, got %v", err)
+	}
+
+	roots := server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	transport, err := claude.NewWebSocketTransport(url, &claude.WebSocketOptions{
+		TLSConfig: &tls.Config{RootCAs: roots},
+	})
+	if err != nil {
+		t.Fatalf("NewWebSocketTransport failed: %v", err)
+	}
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+	queryOverWebSocket(t, ctx, client)
+}
+
+func TestWebSocketTransportReconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	handler := &wsTestServer{t: t, dropFirst: true}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	reconnected := make(chan int, 1)
+	transport, err := claude.NewWebSocketTransport("ws"+strings.TrimPrefix(server.URL, "http"), &claude.WebSocketOptions{
+		Reconnect: &claude.WebSocketReconnect{
+			MaxAttempts:    3,
+			InitialBackoff: 10 * time.Millisecond,
+			OnReconnect:    func(attempt int) { reconnected <- attempt },
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewWebSocketTransport failed: %v", err)
+	}
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	select {
+	case attempt := <-reconnected:
+		if attempt != 1 {
+			t.Errorf("expected to reconnect on the first attempt, took %d", attempt)
+		}
+	case <-ctx.Done():
+		t.Fatal("transport did not reconnect")
+	}
+	queryOverWebSocket(t, ctx, client)
+	if n := handler.connections.Load(); n != 2 {
+		t.Errorf("expected 2 connections, got %d", n)
+	}
+	// The new connection is initialized too, in case it reached a new CLI
+	for handler.initialized.Load() != 2 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("expected the new connection to be initialized, got %d initialize requests", handler.initialized.Load())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestWebSocketTransportReconnectFailsPendingRequests(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	server := httptest.NewServer(&wsTestServer{t: t, dropOn: "set_model"})
+	defer server.Close()
+
+	transport, err := claude.NewWebSocketTransport("ws"+strings.TrimPrefix(server.URL, "http"), &claude.WebSocketOptions{
+		Reconnect: &claude.WebSocketReconnect{InitialBackoff: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewWebSocketTransport failed: %v", err)
+	}
+	client := claude.NewClaudeSDKClientWithTransport(&claude.ClaudeAgentOptions{}, transport)
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer client.Close()
+
+	// The request sent on the dropped connection fails at the reconnect,
+	// long before the control request timeout
+	start := time.Now()
+	if err := client.SetModel(ctx, "claude-sonnet-4-5"); err == nil || !strings.Contains(err.Error(), "reconnected") {
+		t.Fatalf("expected SetModel to fail with the reconnect, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected SetModel to fail promptly, took %v", elapsed)
+	}
+	queryOverWebSocket(t, ctx, client)
+}
+
+func TestWebSocketTransportPongTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	server := httptest.NewServer(&wsTestServer{t: t, ignorePings: true})
+	defer server.Close()
+
+	transport, err := claude.NewWebSocketTransport("ws"+strings.TrimPrefix(server.URL, "http"), &claude.WebSocketOptions{
+		PingInterval: 20 * time.Millisecond,
+		PongTimeout:  20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewWebSocketTransport failed: %v", err)
+	}
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer transport.Close()
+
+	// Without pongs the connection counts as dropped
+	msgCh, errCh := transport.ReadMessages(ctx)
+	for range msgCh {
+	}
+	var connErr *claude.CLIConnectionError
+	if err := <-errCh; !errors.As(err, &connErr) || !strings.Contains(err.Error(), "no pong") {
+		t.Fatalf("expected a CLIConnectionError for the missing pong, got %v", err)
+	}
+}
+
+func TestWebSocketTransportWithoutReconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	server := httptest.NewServer(&wsTestServer{t: t, dropFirst: true})
+	defer server.Close()
+
+	transport, err := claude.NewWebSocketTransport("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("NewWebSocketTransport failed: %v", err)
+	}
+	if err := transport.Connect(ctx); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer transport.Close()
+
+	msgCh, errCh := transport.ReadMessages(ctx)
+	if err := transport.Write(ctx, `{"type":"control_request","request_id":"req_1","request":{"subtype":"initialize"}}`+"\n"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for range msgCh {
+	}
+	var connErr *claude.CLIConnectionError
+	if err := <-errCh; !errors.As(err, &connErr) {
+		t.Fatalf("expected a CLIConnectionError when the connection drops, got %v", err)
+	}
+	if transport.IsReady() {
+		t.Error("expected the transport not to be ready after the connection dropped")
+	}
+	if err := transport.Write(ctx, "{}\n"); err == nil {
+		t.Error("expected Write to fail after the connection dropped")
+	}
+}
+
+func TestNewWebSocketTransportRejectsOtherSchemes(t *testing.T) {
+	for _, url := range []string{"http://example.com/cli", "example.com", "://"} {
+		if _, err := claude.NewWebSocketTransport(url, nil); err == nil {
+			t.Errorf("expected an error for %q", url)
+		}
+	}
+}
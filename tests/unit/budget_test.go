@@ -0,0 +1,33 @@
+package unit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestFileBudgetStorePersists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "spend", "budget.json")
+
+	store := claude.NewFileBudgetStore(path)
+	if spent, err := store.Spend(ctx, "2026-01-02"); err != nil || spent != 0 {
+		t.Fatalf("expected no spend before first use, got %v, %v", spent, err)
+	}
+	if _, err := store.AddSpend(ctx, "2026-01-02", 1.25); err != nil {
+		t.Fatalf("AddSpend failed: %v", err)
+	}
+	if total, err := store.AddSpend(ctx, "2026-01-02", 0.5); err != nil || total != 1.75 {
+		t.Fatalf("expected total 1.75, got %v, %v", total, err)
+	}
+
+	reopened := claude.NewFileBudgetStore(path)
+	if spent, err := reopened.Spend(ctx, "2026-01-02"); err != nil || spent != 1.75 {
+		t.Errorf("expected spend to survive reopening, got %v, %v", spent, err)
+	}
+	if spent, _ := reopened.Spend(ctx, "2026-01-03"); spent != 0 {
+		t.Errorf("expected days to be tracked separately, got %v", spent)
+	}
+}
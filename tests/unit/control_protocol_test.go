@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestDecodeControlRequest(t *testing.T) {
+	var envelope claude.ControlRequest
+	if err := json.Unmarshal([]byte(`{
+		"type": "control_request", "request_id": "req_1",
+		"request": {
+			"subtype": "can_use_tool", "tool_name": "Write",
+			"input": {"file_path": "/tmp/x"}, "blocked_path": "/tmp",
+			"permission_suggestions": [
+				{"type": "setMode", "mode": "acceptEdits", "destination": "session"},
+				{"type": "somethingNew"}
+			]
+		}
+	}`), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if envelope.Type != claude.MessageTypeControlRequest || envelope.Subtype() != claude.ControlSubtypeCanUseTool {
+		t.Fatalf("unexpected envelope: %+v", envelope)
+	}
+
+	decoded, err := claude.DecodeControlRequest(envelope.Request)
+	if err != nil {
+		t.Fatalf("DecodeControlRequest failed: %v", err)
+	}
+	request, ok := decoded.(*claude.CanUseToolRequest)
+	if !ok {
+		t.Fatalf("expected *CanUseToolRequest, got %T", decoded)
+	}
+	if request.ToolName != "Write" || request.Input["file_path"] != "/tmp/x" || request.BlockedPath == nil || *request.BlockedPath != "/tmp" {
+		t.Errorf("unexpected request: %+v", request)
+	}
+	if len(request.PermissionSuggestions) != 1 || request.PermissionSuggestions[0].Type != claude.PermissionUpdateTypeSetMode {
+		t.Errorf("expected the unknown suggestion to be skipped, got %+v", request.PermissionSuggestions)
+	}
+
+	hook, err := claude.DecodeControlRequest(map[string]interface{}{
+		"subtype": "hook_callback", "callback_id": "hook_0", "input": map[string]interface{}{}, "tool_use_id": "tu_1",
+	})
+	if err != nil {
+		t.Fatalf("DecodeControlRequest failed: %v", err)
+	}
+	if h := hook.(*claude.HookCallbackRequest); h.CallbackID != "hook_0" || h.ToolUseID == nil || *h.ToolUseID != "tu_1" {
+		t.Errorf("unexpected hook request: %+v", h)
+	}
+
+	batch, err := claude.DecodeControlRequest(map[string]interface{}{
+		"subtype": "mcp_message", "server_name": "tools", "message": []interface{}{map[string]interface{}{"id": 1.0}},
+	})
+	if err != nil {
+		t.Fatalf("DecodeControlRequest failed: %v", err)
+	}
+	if m := batch.(*claude.McpMessageRequest); m.ServerName != "tools" || len(m.Message.([]interface{})) != 1 {
+		t.Errorf("unexpected MCP request: %+v", m)
+	}
+
+	if _, err := claude.DecodeControlRequest(map[string]interface{}{"subtype": "rewind_files"}); err == nil {
+		t.Error("expected an error for an unknown subtype")
+	}
+}
+
+func TestEncodeControlRequest(t *testing.T) {
+	request, err := claude.EncodeControlRequest(claude.SetPermissionModeRequest{
+		Subtype: claude.ControlSubtypeSetPermissionMode,
+		Mode:    claude.PermissionModePlan,
+	})
+	if err != nil {
+		t.Fatalf("EncodeControlRequest failed: %v", err)
+	}
+	if request["subtype"] != "set_permission_mode" || request["mode"] != "plan" {
+		t.Errorf("unexpected request: %v", request)
+	}
+
+	decoded, err := claude.DecodeControlRequest(request)
+	if err != nil {
+		t.Fatalf("DecodeControlRequest failed: %v", err)
+	}
+	if r := decoded.(*claude.SetPermissionModeRequest); r.Mode != claude.PermissionModePlan {
+		t.Errorf("round trip changed the mode: %+v", r)
+	}
+
+	if _, err := claude.EncodeControlRequest(claude.InterruptRequest{}); err == nil {
+		t.Error("expected an error for a request without a subtype")
+	}
+}
+
+func TestControlResponseJSON(t *testing.T) {
+	data, err := json.Marshal(claude.ControlResponse{
+		Type: claude.MessageTypeControlResponse,
+		Response: claude.ControlResponseBody{
+			Subtype:   claude.ControlSubtypeError,
+			RequestID: "req_1",
+			Error:     "denied",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"control_response","response":{"subtype":"error","request_id":"req_1","error":"denied"}}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
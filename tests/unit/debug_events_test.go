@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestParseDebugLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   claude.DebugEvent
+		wantOK bool
+	}{
+		{
+			line: `[DEBUG] MCP server "github": Successfully connected to stdio server in 523ms`,
+			want: claude.DebugEvent{
+				Level:   claude.DebugLevelDebug,
+				Kind:    claude.DebugEventMCP,
+				Message: `MCP server "github": Successfully connected to stdio server in 523ms`,
+				Fields:  map[string]string{"server": "github"},
+			},
+			wantOK: true,
+		},
+		{
+			line: `2025-01-02T15:04:05.123Z [ERROR] MCP server "db" Connection failed: ECONNREFUSED`,
+			want: claude.DebugEvent{
+				Time:    time.Date(2025, 1, 2, 15, 4, 5, 123000000, time.UTC),
+				Level:   claude.DebugLevelError,
+				Kind:    claude.DebugEventMCP,
+				Message: `MCP server "db" Connection failed: ECONNREFUSED`,
+				Fields:  map[string]string{"server": "db"},
+			},
+			wantOK: true,
+		},
+		{
+			line: `[DEBUG] Getting matching hook commands for PreToolUse with query: Bash`,
+			want: claude.DebugEvent{
+				Level:   claude.DebugLevelDebug,
+				Kind:    claude.DebugEventHook,
+				Message: "Getting matching hook commands for PreToolUse with query: Bash",
+				Fields:  map[string]string{"event": "PreToolUse", "query": "Bash"},
+			},
+			wantOK: true,
+		},
+		{
+			line: `[DEBUG] Executing hooks for PostToolUse:Write`,
+			want: claude.DebugEvent{
+				Level:   claude.DebugLevelDebug,
+				Kind:    claude.DebugEventHook,
+				Message: "Executing hooks for PostToolUse:Write",
+				Fields:  map[string]string{"event": "PostToolUse", "query": "Write"},
+			},
+			wantOK: true,
+		},
+		{
+			line: `[WARN] Stream started - received first chunk`,
+			want: claude.DebugEvent{
+				Level:   claude.DebugLevelWarn,
+				Kind:    claude.DebugEventGeneral,
+				Message: "Stream started - received first chunk",
+			},
+			wantOK: true,
+		},
+		{line: "    at processTicksAndRejections (node:internal/process/task_queues:95:5)"},
+		{line: "Error: something went wrong"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := claude.ParseDebugLine(tt.line)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			tt.want.Raw = tt.line
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestDryRunStringPrompt(t *testing.T) {
+	model := "claude-sonnet-4-5"
+	cwd := t.TempDir()
+	result, err := claude.DryRun("hello world", &claude.ClaudeAgentOptions{
+		Model: &model,
+		Cwd:   &cwd,
+		Env:   map[string]string{"API_TOKEN": "secret"},
+	})
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+
+	args := strings.Join(result.Args, " ")
+	if !strings.Contains(args, "--model claude-sonnet-4-5") {
+		t.Errorf("expected --model in args, got %v", result.Args)
+	}
+	if !strings.HasSuffix(args, "--print -- hello world") {
+		t.Errorf("expected the prompt as an argument, got %v", result.Args)
+	}
+	if result.Dir != cwd || result.Env["PWD"] != cwd {
+		t.Errorf("expected working directory %s, got %q (PWD %q)", cwd, result.Dir, result.Env["PWD"])
+	}
+	if result.Env["API_TOKEN"] != "secret" || result.Env["CLAUDE_CODE_ENTRYPOINT"] != "sdk-go" {
+		t.Errorf("unexpected env: %v", result.Env)
+	}
+	if len(result.Stdin) != 0 {
+		t.Errorf("expected no stdin for a short string prompt, got %v", result.Stdin)
+	}
+}
+
+func TestDryRunStreamingIncludesInitialize(t *testing.T) {
+	hook := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx claude.HookContext) (claude.HookJSONOutput, error) {
+		return claude.HookJSONOutput{}, nil
+	}
+	result, err := claude.DryRun(nil, &claude.ClaudeAgentOptions{
+		Hooks: map[claude.HookEvent][]claude.HookMatcher{
+			claude.HookEventPreToolUse: {{Matcher: "Bash", Hooks: []claude.HookCallback{hook}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+
+	if !strings.Contains(strings.Join(result.Args, " "), "--input-format stream-json") {
+		t.Errorf("expected streaming input, got %v", result.Args)
+	}
+	if result.Env["CLAUDE_CODE_ENTRYPOINT"] != "sdk-go-client" {
+		t.Errorf("expected client entrypoint, got %q", result.Env["CLAUDE_CODE_ENTRYPOINT"])
+	}
+	if len(result.Stdin) != 1 {
+		t.Fatalf("expected the initialize request on stdin, got %v", result.Stdin)
+	}
+	var initialize map[string]interface{}
+	if err := json.Unmarshal([]byte(result.Stdin[0]), &initialize); err != nil {
+		t.Fatalf("invalid stdin line: %v", err)
+	}
+	request, _ := initialize["request"].(map[string]interface{})
+	if request["subtype"] != "initialize" || request["hooks"] == nil {
+		t.Errorf("expected initialize request with hooks, got %v", request)
+	}
+}
+
+func TestDryRunLargePromptUsesStdin(t *testing.T) {
+	prompt := strings.Repeat("x", 100)
+	threshold := 10
+	result, err := claude.DryRun(prompt, &claude.ClaudeAgentOptions{LargePromptThreshold: &threshold})
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if strings.Contains(strings.Join(result.Args, " "), prompt) {
+		t.Error("expected a large prompt not to be passed as an argument")
+	}
+	if len(result.Stdin) != 2 || !strings.Contains(result.Stdin[1], prompt) {
+		t.Errorf("expected the prompt after the initialize request, got %d lines", len(result.Stdin))
+	}
+}
+
+func TestDryRunShellCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX quoting")
+	}
+	prompt := "it's a test"
+	result := &claude.DryRunResult{CLIPath: "/usr/bin/claude", Args: []string{"--print", "--", prompt, "--setting-sources", ""}}
+	want := `/usr/bin/claude --print -- 'it'\''s a test' --setting-sources ''`
+	if got := result.ShellCommand(); got != want {
+		t.Errorf("ShellCommand() = %s, want %s", got, want)
+	}
+}
+
+func TestDryRunReadOnlyDisallowsMcpServers(t *testing.T) {
+	result, err := claude.DryRun("hello", &claude.ClaudeAgentOptions{
+		ReadOnly:   true,
+		McpServers: map[string]claude.McpServerConfig{"db": claude.McpStdioServerConfig{Command: "db-mcp"}},
+	})
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	var disallowed []string
+	for i, arg := range result.Args {
+		if arg == "--disallowedTools" && i+1 < len(result.Args) {
+			disallowed = strings.Split(result.Args[i+1], ",")
+		}
+	}
+	for _, tool := range []string{"Write", "Bash", "KillShell", "mcp__db"} {
+		if !slices.Contains(disallowed, tool) {
+			t.Errorf("expected %s to be disallowed, got %v", tool, result.Args)
+		}
+	}
+}
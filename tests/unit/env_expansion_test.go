@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// mcpConfigArg returns the servers passed to the CLI with --mcp-config.
+func mcpConfigArg(t *testing.T, args []string) map[string]map[string]interface{} {
+	t.Helper()
+	for i, arg := range args {
+		if arg == "--mcp-config" && i+1 < len(args) {
+			var config struct {
+				McpServers map[string]map[string]interface{} `json:"mcpServers"`
+			}
+			if err := json.Unmarshal([]byte(args[i+1]), &config); err != nil {
+				t.Fatalf("invalid --mcp-config: %v", err)
+			}
+			return config.McpServers
+		}
+	}
+	t.Fatalf("no --mcp-config in %v", args)
+	return nil
+}
+
+func TestEnvExpansionInMcpAndPluginConfig(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	t.Setenv("SDK_EXPAND_TOOLS", "/opt/tools")
+
+	servers := map[string]claude.McpServerConfig{
+		"github": claude.McpStdioServerConfig{
+			Command: "${SDK_EXPAND_TOOLS}/github-mcp",
+			Args:    []string{"--config", "~/.github-mcp.json", "--level=${LOG_LEVEL:-info}"},
+			Env:     map[string]string{"GITHUB_TOKEN": "${GH_TOKEN}"},
+		},
+		"api": claude.McpHTTPServerConfig{
+			Type:    "http",
+			URL:     "https://${API_HOST}/mcp",
+			Headers: map[string]string{"Authorization": "Bearer ${GH_TOKEN}"},
+		},
+	}
+	options := &claude.ClaudeAgentOptions{
+		McpServers: servers,
+		Plugins:    []claude.SdkPluginConfig{{Type: "local", Path: "~/plugins/${PLUGIN}"}},
+		// Options.Env takes precedence over the SDK's environment
+		Env: map[string]string{"GH_TOKEN": "ghp_test", "API_HOST": "api.example.com", "PLUGIN": "lint", "SDK_EXPAND_TOOLS": "/usr/local/tools"},
+	}
+	result, err := claude.DryRun("hi", options)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+
+	config := mcpConfigArg(t, result.Args)
+	github := config["github"]
+	if github["command"] != "/usr/local/tools/github-mcp" {
+		t.Errorf("expected the command to use options.Env, got %v", github["command"])
+	}
+	args, _ := github["args"].([]interface{})
+	if len(args) != 3 || args[1] != filepath.Join(home, ".github-mcp.json") || args[2] != "--level=info" {
+		t.Errorf("expected ~ and defaults expanded in args, got %v", args)
+	}
+	if env, _ := github["env"].(map[string]interface{}); env["GITHUB_TOKEN"] != "ghp_test" {
+		t.Errorf("expected env expanded, got %v", github["env"])
+	}
+	api := config["api"]
+	if api["url"] != "https://api.example.com/mcp" {
+		t.Errorf("expected URL expanded, got %v", api["url"])
+	}
+
+	wantPlugin := filepath.Join(home, "plugins", "lint")
+	found := false
+	for i, arg := range result.Args {
+		if arg == "--plugin-dir" && i+1 < len(result.Args) && result.Args[i+1] == wantPlugin {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected --plugin-dir %s, got %v", wantPlugin, result.Args)
+	}
+
+	// The caller's options are not modified
+	if servers["github"].(claude.McpStdioServerConfig).Command != "${SDK_EXPAND_TOOLS}/github-mcp" {
+		t.Error("expansion must not modify the caller's config")
+	}
+}
+
+func TestEnvExpansionUnsetAndEscapedVariables(t *testing.T) {
+	options := &claude.ClaudeAgentOptions{
+		McpServers: map[string]claude.McpServerConfig{
+			"db": claude.McpStdioServerConfig{Command: "db-mcp", Args: []string{
+				"--url", "${SDK_EXPAND_UNSET_DB_URL}",
+				"--template", "$${NAME}-${SDK_EXPAND_UNSET_SUFFIX:-x}",
+				"--prefix", "$${PREFIX",
+			}},
+		},
+		// Escaped references are not expanded even when the variable is set
+		Env: map[string]string{"NAME": "set"},
+	}
+	result, err := claude.DryRun("hi", options)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	// Unset variables are left for the CLI, and so is $${, passed on as ${;
+	// an escape without a closing } is not a syntax error
+	args, _ := mcpConfigArg(t, result.Args)["db"]["args"].([]interface{})
+	if len(args) != 6 || args[1] != "${SDK_EXPAND_UNSET_DB_URL}" || args[3] != "${NAME}-x" || args[5] != "${PREFIX" {
+		t.Errorf("unexpected args %v", args)
+	}
+
+	var expansionErr *claude.EnvExpansionError
+	options.McpServers["db"] = claude.McpStdioServerConfig{Command: "${UNTERMINATED"}
+	if _, err := claude.DryRun("hi", options); !errors.As(err, &expansionErr) || expansionErr.Field != "McpServers[db].Command" {
+		t.Errorf("expected a syntax error, got %v", err)
+	}
+}
@@ -103,3 +103,34 @@ func TestErrorWrapping(t *testing.T) {
 		t.Error("errors.Unwrap should return wrapped error")
 	}
 }
+
+func TestControlRequestError(t *testing.T) {
+	cause := errors.New("deadline exceeded")
+	err := claude.NewControlRequestError("req_1_abcd", "set_model", "control request timeout", cause)
+
+	if err.RequestID != "req_1_abcd" || err.Subtype != "set_model" {
+		t.Errorf("unexpected fields: %+v", err)
+	}
+	if !strings.Contains(err.Error(), "req_1_abcd") || !strings.Contains(err.Error(), "set_model") {
+		t.Errorf("error message should include request ID and subtype, got: %s", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Error("ControlRequestError should wrap the original error")
+	}
+}
+
+func TestAuthenticationError(t *testing.T) {
+	err := claude.NewAuthenticationError(claude.AuthErrorSourceStderr, "Invalid API key", nil)
+
+	if err.Source != claude.AuthErrorSourceStderr || err.Detail != "Invalid API key" {
+		t.Errorf("unexpected fields: %+v", err)
+	}
+	if !strings.Contains(err.Error(), "Invalid API key") || !strings.Contains(err.Error(), "/login") {
+		t.Errorf("error message should include detail and guidance, got: %s", err.Error())
+	}
+
+	var target *claude.AuthenticationError
+	if !errors.As(error(err), &target) {
+		t.Error("expected errors.As to match AuthenticationError")
+	}
+}
@@ -0,0 +1,37 @@
+package unit
+
+import (
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestFeatureMatrix(t *testing.T) {
+	old := claude.FeatureMatrix("2.0.5 (Claude Code)")
+	if old.Supports(claude.FeaturePlugins) || old.Supports(claude.FeatureMaxBudget) {
+		t.Error("expected plugins and budget flags to need a newer CLI")
+	}
+	if !old.Supports(claude.FeaturePartialMessages) || !old.Supports(claude.FeatureAgents) {
+		t.Error("expected partial messages and agents to be supported")
+	}
+
+	budget := 1.0
+	options := &claude.ClaudeAgentOptions{
+		MaxBudgetUSD:           &budget,
+		IncludePartialMessages: true,
+		Plugins:                []claude.SdkPluginConfig{{Type: "local", Path: "/p"}},
+	}
+	unsupported := old.Unsupported(options)
+	if len(unsupported) != 2 || unsupported[0].Option != "Plugins" || unsupported[1].Option != "MaxBudgetUSD" {
+		t.Errorf("expected Plugins and MaxBudgetUSD to be unsupported, got %+v", unsupported)
+	}
+	if unsupported[1].Flag != "--max-budget-usd" || unsupported[1].MinVersion == "" {
+		t.Errorf("expected the flag and minimum version, got %+v", unsupported[1])
+	}
+
+	for _, version := range []string{"", "unknown", "99.0.0"} {
+		if got := claude.FeatureMatrix(version).Unsupported(options); len(got) != 0 {
+			t.Errorf("version %q: expected every feature to be supported, got %+v", version, got)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestHookDecisionHelpers(t *testing.T) {
+	tests := []struct {
+		name   string
+		output claude.HookJSONOutput
+		want   string
+	}{
+		{
+			name:   "allow",
+			output: claude.AllowToolUse(),
+			want:   `{"hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"allow"}}`,
+		},
+		{
+			name:   "deny",
+			output: claude.DenyToolUse("no"),
+			want:   `{"hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"deny","permissionDecisionReason":"no"}}`,
+		},
+		{
+			name:   "ask",
+			output: claude.AskToolUse("sure?"),
+			want:   `{"hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"ask","permissionDecisionReason":"sure?"}}`,
+		},
+		{
+			name:   "add context",
+			output: claude.AddContext("note"),
+			want:   `{"hookSpecificOutput":{"additionalContext":"note"}}`,
+		},
+		{
+			name:   "block",
+			output: claude.Block("keep going"),
+			want:   `{"decision":"block","reason":"keep going"}`,
+		},
+		{
+			name:   "stop",
+			output: claude.StopExecution("done"),
+			want:   `{"continue":false,"stopReason":"done"}`,
+		},
+		{
+			name:   "with system message",
+			output: claude.DenyToolUse("no").WithSystemMessage("blocked"),
+			want:   `{"systemMessage":"blocked","hookSpecificOutput":{"hookEventName":"PreToolUse","permissionDecision":"deny","permissionDecisionReason":"no"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.output)
+			if err != nil {
+				t.Fatalf("marshal failed: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestLocaleAppendedToSystemPrompt(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	extra := "Be brief."
+	tests := []struct {
+		name    string
+		options *claude.ClaudeAgentOptions
+		want    []string
+	}{
+		{
+			name:    "locale only",
+			options: &claude.ClaudeAgentOptions{Locale: "de-DE"},
+			want:    []string{"--append-system-prompt", "The user's locale is de-DE: write dates, times, and numbers in its format."},
+		},
+		{
+			name: "after preset append",
+			options: &claude.ClaudeAgentOptions{
+				SystemPrompt: claude.SystemPromptPreset{Type: "preset", Preset: "claude_code", Append: &extra},
+				Timezone:     berlin,
+			},
+			want: []string{"--append-system-prompt", "Be brief.\n\nThe user's time zone is Europe/Berlin: give times in it unless asked otherwise."},
+		},
+		{
+			name: "with custom system prompt",
+			options: &claude.ClaudeAgentOptions{
+				SystemPrompt: "You are terse.",
+				Locale:       "ja-JP",
+			},
+			want: []string{"--system-prompt", "You are terse.", "--append-system-prompt", "The user's locale is ja-JP: write dates, times, and numbers in its format."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := claude.DryRun("hi", tt.options)
+			if err != nil {
+				t.Fatalf("DryRun failed: %v", err)
+			}
+			if !containsSequence(result.Args, tt.want) {
+				t.Errorf("expected %q in args, got %q", tt.want, result.Args)
+			}
+		})
+	}
+}
+
+func TestLocaleNotSetLeavesSystemPrompt(t *testing.T) {
+	result, err := claude.DryRun("hi", &claude.ClaudeAgentOptions{})
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	for _, arg := range result.Args {
+		if arg == "--append-system-prompt" {
+			t.Errorf("expected no appended system prompt, got %q", result.Args)
+		}
+	}
+}
+
+// containsSequence reports whether want appears in args as a contiguous run.
+func containsSequence(args, want []string) bool {
+	for i := 0; i+len(want) <= len(args); i++ {
+		match := true
+		for j := range want {
+			if args[i+j] != want[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
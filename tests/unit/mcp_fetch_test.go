@@ -0,0 +1,139 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clsx524/claude-agent-sdk-go/mcp"
+)
+
+// callFetch calls the fetch tool and returns its text and whether it failed.
+func callFetch(t *testing.T, server *mcp.SdkMcpServer, args map[string]interface{}) (string, bool) {
+	t.Helper()
+	resp := server.HandleRequest(context.Background(), map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": "fetch", "arguments": args},
+	})
+	result, ok := resp["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("fetch failed: %v", resp)
+	}
+	content := result["content"].([]map[string]interface{})
+	isError, _ := result["isError"].(bool)
+	return content[0]["text"].(string), isError
+}
+
+func newFetchTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprintf(w, "token=%s accept=%s", r.Header.Get("X-Token"), r.Header.Get("Accept"))
+		case "/big":
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, strings.Repeat("a", 100))
+		case "/binary":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte{0, 1, 2})
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/redirect":
+			http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestFetchServerAllowlist(t *testing.T) {
+	ts := newFetchTestServer(t)
+	server, err := mcp.NewHTTPFetchServer([]string{"127.0.0.1"}, mcp.FetchLimits{})
+	if err != nil {
+		t.Fatalf("NewHTTPFetchServer failed: %v", err)
+	}
+
+	text, isError := callFetch(t, server, map[string]interface{}{"url": ts.URL + "/echo", "headers": map[string]interface{}{"Accept": "text/plain"}})
+	if isError || !strings.Contains(text, "Status: 200 OK") || !strings.Contains(text, "accept=text/plain") {
+		t.Errorf("unexpected response: %q", text)
+	}
+
+	localhost := strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)
+	if text, isError := callFetch(t, server, map[string]interface{}{"url": localhost + "/echo"}); !isError || !strings.Contains(text, "not allowed") {
+		t.Errorf("expected a disallowed host to fail, got %q", text)
+	}
+	if text, isError := callFetch(t, server, map[string]interface{}{"url": ts.URL + "/redirect?to=" + url.QueryEscape(localhost+"/echo")}); !isError || !strings.Contains(text, "redirect to localhost is not allowed") {
+		t.Errorf("expected a redirect to a disallowed host to fail, got %q", text)
+	}
+	if text, isError := callFetch(t, server, map[string]interface{}{"url": "file:///etc/passwd"}); !isError || !strings.Contains(text, "invalid URL") {
+		t.Errorf("expected a file URL to fail, got %q", text)
+	}
+}
+
+func TestFetchServerHeaderInjection(t *testing.T) {
+	ts := newFetchTestServer(t)
+	server, err := mcp.NewHTTPFetchServer([]string{"127.0.0.1", "localhost"}, mcp.FetchLimits{
+		Headers: map[string]http.Header{"127.0.0.1": {"X-Token": {"secret"}}},
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPFetchServer failed: %v", err)
+	}
+
+	// Injected headers replace Claude's
+	text, _ := callFetch(t, server, map[string]interface{}{"url": ts.URL + "/echo", "headers": map[string]interface{}{"X-Token": "guess"}})
+	if !strings.Contains(text, "token=secret") {
+		t.Errorf("expected the injected token, got %q", text)
+	}
+
+	// ...and do not follow a redirect to another host
+	localhost := strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)
+	text, isError := callFetch(t, server, map[string]interface{}{"url": ts.URL + "/redirect?to=" + url.QueryEscape(localhost+"/echo")})
+	if isError || !strings.Contains(text, "token= ") {
+		t.Errorf("expected no token after redirecting to another host, got %q", text)
+	}
+}
+
+func TestFetchServerLimits(t *testing.T) {
+	ts := newFetchTestServer(t)
+	server, err := mcp.NewHTTPFetchServer([]string{"127.0.0.1"}, mcp.FetchLimits{
+		MaxBodyBytes: 10,
+		Timeout:      50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPFetchServer failed: %v", err)
+	}
+
+	text, _ := callFetch(t, server, map[string]interface{}{"url": ts.URL + "/big"})
+	if !strings.HasSuffix(text, "\n\naaaaaaaaaa\n\n[Body truncated at 10 bytes]") {
+		t.Errorf("expected a truncated body, got %q", text)
+	}
+	text, _ = callFetch(t, server, map[string]interface{}{"url": ts.URL + "/binary"})
+	if !strings.Contains(text, "[3-byte binary body not shown]") {
+		t.Errorf("expected the binary body to be summarized, got %q", text)
+	}
+	if text, isError := callFetch(t, server, map[string]interface{}{"url": ts.URL + "/slow"}); !isError || !strings.Contains(text, "timed out") {
+		t.Errorf("expected a timeout, got %q", text)
+	}
+}
+
+func TestFetchServerValidation(t *testing.T) {
+	if _, err := mcp.NewHTTPFetchServer(nil, mcp.FetchLimits{}); err == nil {
+		t.Error("expected an error for an empty allowlist")
+	}
+	for _, entry := range []string{"https://example.com", "example.com/path", "*", "example.com:8080"} {
+		if _, err := mcp.NewHTTPFetchServer([]string{entry}, mcp.FetchLimits{}); err == nil {
+			t.Errorf("expected an error for allowlist entry %q", entry)
+		}
+	}
+	if _, err := mcp.NewHTTPFetchServer([]string{"*.example.com"}, mcp.FetchLimits{}); err != nil {
+		t.Errorf("unexpected error for a wildcard entry: %v", err)
+	}
+}
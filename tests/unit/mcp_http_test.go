@@ -0,0 +1,176 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clsx524/claude-agent-sdk-go/mcp"
+)
+
+func postMCP(t *testing.T, url, body string, header http.Header) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+func TestMcpHTTPHandler(t *testing.T) {
+	started := make(chan struct{}, 1)
+	handler := mcp.NewHTTPHandler(stdioTestServer(started), &mcp.HTTPOptions{
+		BearerToken:    "secret",
+		AllowedOrigins: []string{"https://app.example.com"},
+	})
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	url := ts.URL + "/mcp"
+	auth := http.Header{"Authorization": {"Bearer secret"}}
+
+	resp, body := postMCP(t, url, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`, auth)
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &response); err != nil || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("expected a JSON response, got %s %q", resp.Header.Get("Content-Type"), body)
+	}
+	if result, _ := response["result"].(map[string]interface{}); result["protocolVersion"] != "2025-06-18" {
+		t.Errorf("unexpected initialize result: %v", response)
+	}
+
+	if resp, _ := postMCP(t, url, `{"jsonrpc":"2.0","method":"notifications/initialized"}`, auth); resp.StatusCode != http.StatusAccepted {
+		t.Errorf("expected 202 for a notification, got %d", resp.StatusCode)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		header http.Header
+		status int
+	}{
+		{"missing token", http.MethodPost, nil, http.StatusUnauthorized},
+		{"wrong token", http.MethodPost, http.Header{"Authorization": {"Bearer nope"}}, http.StatusUnauthorized},
+		{"foreign origin", http.MethodPost, http.Header{"Authorization": {"Bearer secret"}, "Origin": {"https://evil.example"}}, http.StatusForbidden},
+		{"allowed origin", http.MethodPost, http.Header{"Authorization": {"Bearer secret"}, "Origin": {"https://app.example.com"}}, http.StatusOK},
+		{"GET", http.MethodGet, auth, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, url, strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`))
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, resp.StatusCode)
+			}
+		})
+	}
+
+	// Clients accepting only event streams get one SSE message
+	resp, body = postMCP(t, url, `{"jsonrpc":"2.0","id":"call-1","method":"tools/call","params":{"name":"add","arguments":{"a":1,"b":2}}}`,
+		http.Header{"Authorization": {"Bearer secret"}, "Accept": {"text/event-stream"}})
+	if resp.Header.Get("Content-Type") != "text/event-stream" || !strings.HasPrefix(body, "event: message\ndata: {") {
+		t.Errorf("expected an SSE message, got %q", body)
+	}
+
+	resp, body = postMCP(t, url, `[{"jsonrpc":"2.0","id":3,"method":"tools/list"},{"jsonrpc":"2.0","method":"notifications/initialized"}]`, auth)
+	var batch []map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &batch); err != nil || len(batch) != 1 {
+		t.Errorf("expected one batch response, got %q", body)
+	}
+
+	resp, body = postMCP(t, url, `{not json`, auth)
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(body, "-32700") {
+		t.Errorf("expected a parse error, got %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestMcpHTTPHandlerCancellation(t *testing.T) {
+	started := make(chan struct{}, 1)
+	ts := httptest.NewServer(mcp.NewHTTPHandler(stdioTestServer(started), nil))
+	defer ts.Close()
+
+	answered := make(chan string, 1)
+	go func() {
+		resp, err := http.Post(ts.URL+"/mcp", "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"wait","arguments":{}}}`))
+		if err != nil {
+			answered <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		answered <- string(body)
+	}()
+	<-started
+	postMCP(t, ts.URL+"/mcp", `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7}}`, nil)
+	select {
+	case body := <-answered:
+		if !strings.Contains(body, `"id":7`) {
+			t.Errorf("expected the cancelled call to be answered, got %q", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("notifications/cancelled did not cancel the call")
+	}
+}
+
+func TestServeHTTPContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- mcp.ServeHTTP(ctx, "127.0.0.1:0", stdioTestServer(nil), nil)
+	}()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ServeHTTP did not return after cancellation")
+	}
+}
+
+func TestMcpHTTPHandlerProgress(t *testing.T) {
+	server := mcp.CreateSdkMcpServer("indexer", "1.0.0", []*mcp.SdkMcpTool{
+		mcp.Tool("index", "Indexes", map[string]string{}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			mcp.ReportProgress(ctx, 1, 2, "Indexing")
+			return mcp.TextContent("done"), nil
+		}),
+	})
+	ts := httptest.NewServer(mcp.NewHTTPHandler(server, nil))
+	defer ts.Close()
+
+	// Clients accepting event streams that ask for progress get it streamed
+	resp, body := postMCP(t, ts.URL+"/mcp", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"index","arguments":{},"_meta":{"progressToken":"p"}}}`, nil)
+	events := strings.Split(strings.TrimSpace(body), "\n\n")
+	if resp.Header.Get("Content-Type") != "text/event-stream" || len(events) != 2 ||
+		!strings.Contains(events[0], "notifications/progress") || !strings.Contains(events[1], `"id":1`) {
+		t.Errorf("expected a progress event then the response, got %q", body)
+	}
+
+	resp, body = postMCP(t, ts.URL+"/mcp", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"index","arguments":{}}}`, nil)
+	if resp.Header.Get("Content-Type") != "application/json" || strings.Contains(body, "progress") {
+		t.Errorf("expected a plain JSON response without a progress token, got %q", body)
+	}
+}
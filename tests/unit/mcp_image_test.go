@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"github.com/clsx524/claude-agent-sdk-go/mcp"
+)
+
+// noisyPNG returns a PNG of random pixels, which compresses poorly.
+func noisyPNG(t *testing.T, size int) []byte {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, color.RGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func contentErrorReason(t *testing.T, err error) mcp.ContentErrorReason {
+	t.Helper()
+	var contentErr *mcp.ContentError
+	if !errors.As(err, &contentErr) {
+		t.Fatalf("expected a *mcp.ContentError, got %v", err)
+	}
+	return contentErr.Reason
+}
+
+func TestNewImageContent(t *testing.T) {
+	pngData := noisyPNG(t, 64)
+
+	result, err := mcp.NewImageContent(pngData, "image/png", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	block := result["content"].([]map[string]interface{})[0]
+	if block["data"] != base64.StdEncoding.EncodeToString(pngData) || block["mimeType"] != "image/png" {
+		t.Errorf("unexpected image block %v", block["mimeType"])
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		mimeType string
+		limits   *mcp.ImageLimits
+		want     mcp.ContentErrorReason
+	}{
+		{"unsupported type", pngData, "image/bmp", nil, mcp.ContentUnsupportedType},
+		{"declared type does not match data", pngData, "image/jpeg", nil, mcp.ContentTypeMismatch},
+		{"empty", nil, "image/png", nil, mcp.ContentInvalidImageData},
+		{"too large", pngData, "image/png", &mcp.ImageLimits{MaxImageBytes: 1000}, mcp.ContentImageTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mcp.NewImageContent(tt.data, tt.mimeType, tt.limits)
+			if got := contentErrorReason(t, err); got != tt.want {
+				t.Errorf("expected reason %s, got %s (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestNewImageContentResizes(t *testing.T) {
+	pngData := noisyPNG(t, 128)
+	limits := &mcp.ImageLimits{MaxImageBytes: len(pngData) / 4, Resizer: mcp.JPEGResizer{Quality: 80}}
+
+	result, err := mcp.NewImageContent(pngData, "image/png", limits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	block := result["content"].([]map[string]interface{})[0]
+	data, _ := base64.StdEncoding.DecodeString(block["data"].(string))
+	if block["mimeType"] != "image/jpeg" || len(data) > limits.MaxImageBytes {
+		t.Errorf("expected a JPEG of at most %d bytes, got %v of %d bytes", limits.MaxImageBytes, block["mimeType"], len(data))
+	}
+	if _, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("resized image does not decode: %v", err)
+	}
+
+	// A resizer cannot save an image of the wrong type
+	if _, err := mcp.NewImageContent(pngData, "image/bmp", limits); contentErrorReason(t, err) != mcp.ContentUnsupportedType {
+		t.Errorf("expected unsupported_type, got %v", err)
+	}
+}
+
+func TestNewMixedContent(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(noisyPNG(t, 32))
+	imageBlock := map[string]interface{}{"type": "image", "data": encoded, "mimeType": "image/png"}
+	text := map[string]interface{}{"type": "text", "text": "Here's the chart:"}
+
+	if _, err := mcp.NewMixedContent(nil, text, imageBlock, imageBlock); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Each image fits, but not both
+	limits := &mcp.ImageLimits{MaxContentBytes: len(encoded) + 1}
+	_, err := mcp.NewMixedContent(limits, text, imageBlock, imageBlock)
+	var contentErr *mcp.ContentError
+	if !errors.As(err, &contentErr) || contentErr.Reason != mcp.ContentTooLarge || contentErr.Block != 2 {
+		t.Errorf("expected content_too_large at block 2, got %v", err)
+	}
+
+	invalid := map[string]interface{}{"type": "image", "data": "not base64!", "mimeType": "image/png"}
+	if _, err := mcp.NewMixedContent(nil, text, invalid); contentErrorReason(t, err) != mcp.ContentInvalidImageData {
+		t.Errorf("expected invalid_image_data, got %v", err)
+	}
+}
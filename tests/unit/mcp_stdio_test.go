@@ -0,0 +1,162 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/clsx524/claude-agent-sdk-go/mcp"
+)
+
+// stdioClient drives mcp.Serve over pipes.
+type stdioClient struct {
+	t         *testing.T
+	in        *io.PipeWriter
+	responses chan map[string]interface{}
+	done      chan error
+}
+
+func startStdioServer(t *testing.T, ctx context.Context, server *mcp.SdkMcpServer) *stdioClient {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	c := &stdioClient{t: t, in: inWriter, responses: make(chan map[string]interface{}, 10), done: make(chan error, 1)}
+	go func() {
+		err := mcp.Serve(ctx, server, inReader, outWriter)
+		outWriter.Close()
+		c.done <- err
+	}()
+	go func() {
+		scanner := bufio.NewScanner(outReader)
+		for scanner.Scan() {
+			var response map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &response); err != nil {
+				response = map[string]interface{}{"raw": scanner.Text()}
+			}
+			c.responses <- response
+		}
+		close(c.responses)
+	}()
+	return c
+}
+
+func (c *stdioClient) send(line string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, line+"\n"); err != nil {
+		c.t.Fatalf("write failed: %v", err)
+	}
+}
+
+func (c *stdioClient) receive() map[string]interface{} {
+	c.t.Helper()
+	select {
+	case response := <-c.responses:
+		return response
+	case <-time.After(2 * time.Second):
+		c.t.Fatal("timed out waiting for a response")
+		return nil
+	}
+}
+
+func stdioTestServer(started chan<- struct{}) *mcp.SdkMcpServer {
+	return mcp.CreateSdkMcpServer("calc", "1.0.0", []*mcp.SdkMcpTool{
+		mcp.Tool("add", "Adds", map[string]string{"a": "number", "b": "number"}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			return mcp.TextContent("3"), nil
+		}),
+		mcp.Tool("wait", "Blocks", map[string]string{}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			started <- struct{}{}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+	})
+}
+
+func TestServeStdio(t *testing.T) {
+	started := make(chan struct{}, 1)
+	c := startStdioServer(t, context.Background(), stdioTestServer(started))
+
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`)
+	if result, _ := c.receive()["result"].(map[string]interface{}); result["protocolVersion"] != "2025-06-18" {
+		t.Errorf("unexpected initialize result: %v", result)
+	}
+
+	// Notifications are not answered, so the next response is the call's
+	c.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	c.send(`{"jsonrpc":"2.0","id":"call-1","method":"tools/call","params":{"name":"add","arguments":{"a":1,"b":2}}}`)
+	if response := c.receive(); response["id"] != "call-1" || response["result"] == nil {
+		t.Errorf("unexpected tools/call response: %v", response)
+	}
+
+	c.send(`{not json`)
+	if rpcErr, _ := c.receive()["error"].(map[string]interface{}); rpcErr["code"] != -32700.0 {
+		t.Errorf("expected a parse error, got %v", rpcErr)
+	}
+
+	// A cancelled request gets no response
+	c.send(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"wait","arguments":{}}}`)
+	<-started
+	c.send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7}}`)
+	c.send(`[{"jsonrpc":"2.0","id":8,"method":"tools/list"},{"jsonrpc":"2.0","method":"notifications/initialized"}]`)
+	if response := c.receive(); response["raw"] == nil {
+		t.Errorf("expected the batch's array response next, got %v", response)
+	}
+
+	c.in.Close()
+	select {
+	case err := <-c.done:
+		if err != nil {
+			t.Errorf("expected a clean shutdown at end of input, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return at end of input")
+	}
+	if response, ok := <-c.responses; ok {
+		t.Errorf("unexpected response after shutdown: %v", response)
+	}
+}
+
+func TestServeStdioContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{}, 1)
+	c := startStdioServer(t, ctx, stdioTestServer(started))
+
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"wait","arguments":{}}}`)
+	<-started
+	cancel()
+	select {
+	case err := <-c.done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return after cancellation")
+	}
+	c.in.Close()
+}
+
+func TestServeStdioProgress(t *testing.T) {
+	server := mcp.CreateSdkMcpServer("indexer", "1.0.0", []*mcp.SdkMcpTool{
+		mcp.Tool("index", "Indexes", map[string]string{}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			mcp.ReportProgress(ctx, 1, 0, "")
+			return mcp.TextContent("done"), nil
+		}),
+	})
+	c := startStdioServer(t, context.Background(), server)
+	defer c.in.Close()
+
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"index","arguments":{},"_meta":{"progressToken":5}}}`)
+	notification := c.receive()
+	params, _ := notification["params"].(map[string]interface{})
+	if notification["method"] != "notifications/progress" || params["progressToken"] != 5.0 || params["progress"] != 1.0 {
+		t.Errorf("expected a progress notification first, got %v", notification)
+	}
+	if _, hasTotal := params["total"]; hasTotal {
+		t.Errorf("expected no total when it is unknown, got %v", params)
+	}
+	if response := c.receive(); response["id"] != 1.0 || response["result"] == nil {
+		t.Errorf("expected the call's response next, got %v", response)
+	}
+}
@@ -3,7 +3,9 @@ package unit
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/clsx524/claude-agent-sdk-go/mcp"
 )
@@ -528,7 +530,8 @@ func TestMcpServerContextCancellation(t *testing.T) {
 	}
 }
 
-// TestMcpServerMissingArguments tests calling tool with missing required arguments
+// TestMcpServerMissingArguments tests calling tool with missing required
+// arguments, with and without argument validation
 func TestMcpServerMissingArguments(t *testing.T) {
 	tool := mcp.Tool(
 		"require_args",
@@ -558,6 +561,15 @@ func TestMcpServerMissingArguments(t *testing.T) {
 
 	response := server.HandleRequest(context.Background(), request)
 
+	rpcErr, ok := response["error"].(map[string]interface{})
+	if !ok || rpcErr["code"] != -32602 || !strings.Contains(rpcErr["message"].(string), `missing required argument "required_param"`) {
+		t.Fatalf("expected an invalid params error, got %v", response)
+	}
+
+	// Without validation the handler sees the call
+	server.SkipArgumentValidation = true
+	response = server.HandleRequest(context.Background(), request)
+
 	result, ok := response["result"].(map[string]interface{})
 	if !ok {
 		t.Fatal("expected result to be map")
@@ -569,7 +581,8 @@ func TestMcpServerMissingArguments(t *testing.T) {
 	}
 }
 
-// TestMcpServerInvalidArgumentTypes tests calling tool with wrong argument types
+// TestMcpServerInvalidArgumentTypes tests calling tool with wrong argument
+// types, with and without argument validation
 func TestMcpServerInvalidArgumentTypes(t *testing.T) {
 	tool := mcp.Tool(
 		"expect_number",
@@ -600,6 +613,15 @@ func TestMcpServerInvalidArgumentTypes(t *testing.T) {
 
 	response := server.HandleRequest(context.Background(), request)
 
+	rpcErr, ok := response["error"].(map[string]interface{})
+	if !ok || rpcErr["code"] != -32602 || !strings.Contains(rpcErr["message"].(string), `argument "value" must be number, got string`) {
+		t.Fatalf("expected an invalid params error, got %v", response)
+	}
+
+	// Without validation the handler sees the call
+	server.SkipArgumentValidation = true
+	response = server.HandleRequest(context.Background(), request)
+
 	result, ok := response["result"].(map[string]interface{})
 	if !ok {
 		t.Fatal("expected result to be map")
@@ -611,6 +633,69 @@ func TestMcpServerInvalidArgumentTypes(t *testing.T) {
 	}
 }
 
+// TestMcpServerArgumentValidation tests enums, integers, and nested
+// properties in declared JSON schemas
+func TestMcpServerArgumentValidation(t *testing.T) {
+	tool := mcp.Tool(
+		"deploy",
+		"Deploys a service",
+		map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"env":      map[string]interface{}{"type": "string", "enum": []string{"staging", "production"}},
+				"replicas": map[string]interface{}{"type": "integer"},
+				"region":   map[string]interface{}{"type": []string{"string", "null"}},
+				"limits": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"cpu": map[string]interface{}{"type": "number"}},
+					"required":   []string{"cpu"},
+				},
+				"tags": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			},
+			"required": []string{"env"},
+		},
+		func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			return mcp.TextContent("deployed"), nil
+		},
+	)
+	server := mcp.CreateSdkMcpServer("test", "1.0.0", []*mcp.SdkMcpTool{tool})
+
+	testCases := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      string // Empty if the call is valid
+	}{
+		{"valid", map[string]interface{}{"env": "staging", "replicas": 3.0, "limits": map[string]interface{}{"cpu": 0.5}, "tags": []interface{}{"a"}}, ""},
+		{"null optional", map[string]interface{}{"env": "production", "replicas": nil}, `argument "replicas" must be integer, got null`},
+		{"nullable", map[string]interface{}{"env": "production", "region": nil}, ""},
+		{"null required", map[string]interface{}{"env": nil}, `argument "env" must be string, got null`},
+		{"not in enum", map[string]interface{}{"env": "dev"}, `argument "env" must be one of ["staging","production"]`},
+		{"fractional integer", map[string]interface{}{"env": "staging", "replicas": 1.5}, `argument "replicas" must be integer, got number`},
+		{"nested required", map[string]interface{}{"env": "staging", "limits": map[string]interface{}{}}, `missing required argument "limits.cpu"`},
+		{"array item", map[string]interface{}{"env": "staging", "tags": []interface{}{"a", 1.0}}, `argument "tags[1]" must be string, got integer`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			response := server.HandleRequest(context.Background(), map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      1,
+				"method":  "tools/call",
+				"params":  map[string]interface{}{"name": "deploy", "arguments": tc.arguments},
+			})
+			rpcErr, isErr := response["error"].(map[string]interface{})
+			if tc.want == "" {
+				if isErr {
+					t.Errorf("expected a valid call, got %v", rpcErr)
+				}
+				return
+			}
+			if !isErr || rpcErr["code"] != -32602 || !strings.Contains(rpcErr["message"].(string), tc.want) {
+				t.Errorf("expected an invalid params error containing %q, got %v", tc.want, response)
+			}
+		})
+	}
+}
+
 // TestMcpServerComplexSchemaConversion tests complex input schema conversion
 func TestMcpServerComplexSchemaConversion(t *testing.T) {
 	tool := mcp.Tool(
@@ -821,3 +906,271 @@ func TestMcpServerNilResponse(t *testing.T) {
 		t.Error("should not return error for nil result")
 	}
 }
+
+type forecastOutput struct {
+	TempC   float64 `json:"temp_c"`
+	Summary string  `json:"summary"`
+}
+
+func callTool(server *mcp.SdkMcpServer, name string) map[string]interface{} {
+	response := server.HandleRequest(context.Background(), map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": name, "arguments": map[string]interface{}{}},
+	})
+	result, _ := response["result"].(map[string]interface{})
+	return result
+}
+
+func TestMcpServerStructuredContent(t *testing.T) {
+	weather := mcp.Tool("weather", "Get the forecast", map[string]string{},
+		func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			return mcp.StructuredContent(forecastOutput{TempC: 21, Summary: "Sunny"})
+		}).WithOutputSchema(forecastOutput{})
+	raw := mcp.Tool("raw", "Structured map without text", map[string]string{},
+		func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"structuredContent": forecastOutput{TempC: 3}}, nil
+		})
+	missing := mcp.Tool("missing", "Forgets structured output", map[string]string{},
+		func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			return mcp.TextContent("cold"), nil
+		}).WithOutputSchema(forecastOutput{})
+	server := mcp.CreateSdkMcpServer("test", "1.0.0", []*mcp.SdkMcpTool{weather, raw, missing})
+
+	list := server.HandleRequest(context.Background(), map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "tools/list"})
+	tools := list["result"].(map[string]interface{})["tools"].([]map[string]interface{})
+	outputSchema, ok := tools[0]["outputSchema"].(map[string]interface{})
+	if !ok || outputSchema["properties"].(map[string]interface{})["temp_c"] == nil {
+		t.Errorf("expected outputSchema in tools/list, got %v", tools[0])
+	}
+	if _, ok := tools[1]["outputSchema"]; ok {
+		t.Error("tools without an output schema should not list one")
+	}
+
+	result := callTool(server, "weather")
+	structured, _ := result["structuredContent"].(map[string]interface{})
+	if structured["temp_c"] != 21.0 || structured["summary"] != "Sunny" {
+		t.Errorf("unexpected structuredContent: %v", result["structuredContent"])
+	}
+	content := result["content"].([]map[string]interface{})
+	if content[0]["text"] != `{"temp_c":21,"summary":"Sunny"}` {
+		t.Errorf("expected the JSON as text content, got %v", content[0]["text"])
+	}
+
+	// Structs are encoded as objects and text content is added
+	result = callTool(server, "raw")
+	if _, ok := result["structuredContent"].(map[string]interface{}); !ok {
+		t.Errorf("expected structuredContent as an object, got %T", result["structuredContent"])
+	}
+	if _, ok := result["content"]; !ok {
+		t.Error("expected text content to be added")
+	}
+
+	if result = callTool(server, "missing"); result["isError"] != true {
+		t.Errorf("expected an error result for missing structuredContent, got %v", result)
+	}
+
+	if _, err := mcp.StructuredContent([]int{1}); err == nil {
+		t.Error("expected non-object structured content to fail")
+	}
+}
+
+func TestMcpServerNegotiatesProtocolVersion(t *testing.T) {
+	server := mcp.CreateSdkMcpServer("test", "1.0.0", nil)
+	for requested, want := range map[string]string{"2025-06-18": "2025-06-18", "2024-11-05": "2024-11-05", "2099-01-01": "2025-06-18"} {
+		response := server.HandleRequest(context.Background(), map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "initialize",
+			"params":  map[string]interface{}{"protocolVersion": requested},
+		})
+		if got := response["result"].(map[string]interface{})["protocolVersion"]; got != want {
+			t.Errorf("requested %s: expected %s, got %v", requested, want, got)
+		}
+	}
+}
+
+func TestMcpServerHandleBatch(t *testing.T) {
+	// first only finishes once second has started, so they must run concurrently
+	started := make(chan struct{})
+	server := mcp.CreateSdkMcpServer("test", "1.0.0", []*mcp.SdkMcpTool{
+		mcp.Tool("first", "Waits for second", map[string]string{}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			select {
+			case <-started:
+				return mcp.TextContent("first"), nil
+			case <-time.After(time.Second):
+				return nil, fmt.Errorf("second never started")
+			}
+		}),
+		mcp.Tool("second", "Unblocks first", map[string]string{}, func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			close(started)
+			return mcp.TextContent("second"), nil
+		}),
+	})
+
+	call := func(id int, tool string) map[string]interface{} {
+		return map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      id,
+			"method":  "tools/call",
+			"params":  map[string]interface{}{"name": tool, "arguments": map[string]interface{}{}},
+		}
+	}
+	responses := server.HandleBatch(context.Background(), []map[string]interface{}{
+		call(1, "first"),
+		{"jsonrpc": "2.0", "method": "notifications/initialized"},
+		call(2, "second"),
+	})
+
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses (none for the notification), got %d: %v", len(responses), responses)
+	}
+	for i, want := range []string{"first", "second"} {
+		if responses[i]["id"] != i+1 {
+			t.Errorf("response %d: expected id %d, got %v", i, i+1, responses[i]["id"])
+		}
+		result, _ := responses[i]["result"].(map[string]interface{})
+		content, _ := result["content"].([]map[string]interface{})
+		if len(content) != 1 || content[0]["text"] != want {
+			t.Errorf("response %d: expected text %q, got %v", i, want, responses[i])
+		}
+	}
+}
+
+func TestMcpServerResources(t *testing.T) {
+	server := mcp.CreateSdkMcpServer("docs", "1.0.0", nil).WithResources(
+		mcp.Resource("docs://handbook", "Handbook", "text/markdown", func(ctx context.Context) ([]byte, error) {
+			return []byte("# Handbook"), nil
+		}).WithDescription("The team handbook"),
+		mcp.Resource("docs://logo", "Logo", "image/png", func(ctx context.Context) ([]byte, error) {
+			return []byte{0x89, 'P', 'N', 'G'}, nil
+		}),
+		mcp.Resource("docs://broken", "Broken", "", func(ctx context.Context) ([]byte, error) {
+			return nil, fmt.Errorf("disk on fire")
+		}),
+	)
+	request := func(method string, params map[string]interface{}) map[string]interface{} {
+		return server.HandleRequest(context.Background(), map[string]interface{}{
+			"jsonrpc": "2.0", "id": 1, "method": method, "params": params,
+		})
+	}
+
+	initialize := request("initialize", nil)["result"].(map[string]interface{})
+	if _, ok := initialize["capabilities"].(map[string]interface{})["resources"]; !ok {
+		t.Error("expected the resources capability")
+	}
+
+	list := request("resources/list", nil)["result"].(map[string]interface{})["resources"].([]map[string]interface{})
+	if len(list) != 3 || list[0]["uri"] != "docs://handbook" || list[0]["description"] != "The team handbook" || list[0]["mimeType"] != "text/markdown" {
+		t.Errorf("unexpected resource list: %v", list)
+	}
+	if _, ok := list[2]["mimeType"]; ok {
+		t.Errorf("expected no mimeType for a resource without one: %v", list[2])
+	}
+
+	read := func(uri string) map[string]interface{} {
+		result := request("resources/read", map[string]interface{}{"uri": uri})["result"].(map[string]interface{})
+		return result["contents"].([]map[string]interface{})[0]
+	}
+	if handbook := read("docs://handbook"); handbook["text"] != "# Handbook" || handbook["uri"] != "docs://handbook" {
+		t.Errorf("unexpected handbook contents: %v", handbook)
+	}
+	if logo := read("docs://logo"); logo["blob"] != "iVBORw==" || logo["mimeType"] != "image/png" {
+		t.Errorf("expected the logo as a base64 blob, got %v", logo)
+	}
+
+	for uri, code := range map[string]int{"docs://missing": -32002, "docs://broken": -32603} {
+		response := request("resources/read", map[string]interface{}{"uri": uri})
+		errObj, ok := response["error"].(map[string]interface{})
+		if !ok || errObj["code"] != code {
+			t.Errorf("%s: expected error code %d, got %v", uri, code, response)
+		}
+	}
+
+	config := server.ToConfig()
+	if uris := config.ResourceURIs(); len(uris) != 3 || uris[1] != "docs://logo" {
+		t.Errorf("expected the config to list the resources, got %v", uris)
+	}
+}
+
+func TestMcpServerWithoutResourcesCapability(t *testing.T) {
+	server := mcp.CreateSdkMcpServer("test", "1.0.0", nil)
+	response := server.HandleRequest(context.Background(), map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "initialize"})
+	capabilities := response["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	if _, ok := capabilities["resources"]; ok {
+		t.Error("expected no resources capability for a server without resources")
+	}
+}
+
+func TestMcpServerPrompts(t *testing.T) {
+	type reviewArgs struct {
+		Path  string  `json:"path"`
+		Focus *string `json:"focus"`
+	}
+	server := mcp.CreateSdkMcpServer("team", "1.0.0", nil).WithPrompts(
+		mcp.Prompt("review", "Review a file", reviewArgs{}, func(ctx context.Context, args map[string]string) (map[string]interface{}, error) {
+			text := "Review " + args["path"]
+			if focus, ok := args["focus"]; ok {
+				text += " focusing on " + focus
+			}
+			return mcp.PromptText(text), nil
+		}),
+		mcp.Prompt("standup", "", nil, func(ctx context.Context, args map[string]string) (map[string]interface{}, error) {
+			return nil, fmt.Errorf("no standup today")
+		}),
+	)
+	request := func(method string, params map[string]interface{}) map[string]interface{} {
+		return server.HandleRequest(context.Background(), map[string]interface{}{
+			"jsonrpc": "2.0", "id": 1, "method": method, "params": params,
+		})
+	}
+
+	initialize := request("initialize", nil)["result"].(map[string]interface{})
+	if _, ok := initialize["capabilities"].(map[string]interface{})["prompts"]; !ok {
+		t.Error("expected the prompts capability")
+	}
+
+	prompts := request("prompts/list", nil)["result"].(map[string]interface{})["prompts"].([]map[string]interface{})
+	if len(prompts) != 2 || prompts[0]["name"] != "review" || prompts[0]["description"] != "Review a file" {
+		t.Fatalf("unexpected prompt list: %v", prompts)
+	}
+	args := prompts[0]["arguments"].([]map[string]interface{})
+	if len(args) != 2 || args[0]["name"] != "focus" || args[0]["required"] != false || args[1]["name"] != "path" || args[1]["required"] != true {
+		t.Errorf("unexpected arguments: %v", args)
+	}
+	if args := prompts[1]["arguments"].([]map[string]interface{}); len(args) != 0 {
+		t.Errorf("expected no arguments, got %v", args)
+	}
+
+	result := request("prompts/get", map[string]interface{}{
+		"name": "review", "arguments": map[string]interface{}{"path": "main.go", "focus": "errors"},
+	})["result"].(map[string]interface{})
+	messages := result["messages"].([]map[string]interface{})
+	content := messages[0]["content"].(map[string]interface{})
+	if len(messages) != 1 || messages[0]["role"] != "user" || content["text"] != "Review main.go focusing on errors" {
+		t.Errorf("unexpected messages: %v", messages)
+	}
+	if result["description"] != "Review a file" {
+		t.Errorf("expected the prompt's description, got %v", result["description"])
+	}
+
+	for _, tc := range []struct {
+		params map[string]interface{}
+		code   int
+	}{
+		{map[string]interface{}{"name": "review", "arguments": map[string]interface{}{}}, -32602},
+		{map[string]interface{}{"name": "missing"}, -32602},
+		{map[string]interface{}{"name": "standup"}, -32603},
+	} {
+		response := request("prompts/get", tc.params)
+		errObj, ok := response["error"].(map[string]interface{})
+		if !ok || errObj["code"] != tc.code {
+			t.Errorf("%v: expected error code %d, got %v", tc.params, tc.code, response)
+		}
+	}
+
+	if names := server.PromptNames(); len(names) != 2 || names[1] != "standup" {
+		t.Errorf("unexpected prompt names: %v", names)
+	}
+}
@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/mcp"
+)
+
+func namedServer(name string, toolNames ...string) claude.McpSdkServerConfig {
+	tools := make([]*mcp.SdkMcpTool, len(toolNames))
+	for i, toolName := range toolNames {
+		tools[i] = mcp.Tool(toolName, "test tool", map[string]string{}, nil)
+	}
+	return mcp.CreateSdkMcpServer(name, "1.0.0", tools).ToConfig()
+}
+
+func TestMcpToolName(t *testing.T) {
+	if got := claude.McpToolName("calc", "add"); got != "mcp__calc__add" {
+		t.Errorf("expected mcp__calc__add, got %s", got)
+	}
+}
+
+func TestMcpAllowedTools(t *testing.T) {
+	servers := map[string]claude.McpServerConfig{
+		"calc":     namedServer("calc", "add", "subtract"),
+		"weather":  namedServer("weather", "forecast"),
+		"external": claude.McpStdioServerConfig{Command: "some-server"},
+	}
+
+	got := claude.McpAllowedTools(servers)
+	want := []string{"mcp__calc__add", "mcp__calc__subtract", "mcp__weather__forecast"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestMcpAllowedToolsWithResources(t *testing.T) {
+	docs := mcp.CreateSdkMcpServer("docs", "1.0.0", nil).WithResources(mcp.Resource("docs://a", "A", "text/plain", nil))
+	servers := map[string]claude.McpServerConfig{
+		"calc": namedServer("calc", "add"),
+		"docs": docs.ToConfig(),
+	}
+
+	got := claude.McpAllowedTools(servers)
+	want := []string{"ListMcpResourcesTool", "ReadMcpResourceTool", "mcp__calc__add"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestValidateMcpTools_DuplicateToolInServer(t *testing.T) {
+	options := &claude.ClaudeAgentOptions{
+		McpServers: map[string]claude.McpServerConfig{
+			"calc": namedServer("calc", "add", "add"),
+		},
+	}
+
+	_, err := claude.ValidateMcpTools(options)
+	var collision *claude.McpToolCollisionError
+	if !errors.As(err, &collision) {
+		t.Fatalf("expected McpToolCollisionError, got %v", err)
+	}
+	if collision.ToolName != "mcp__calc__add" {
+		t.Errorf("expected ToolName mcp__calc__add, got %s", collision.ToolName)
+	}
+}
+
+func TestValidateMcpTools_QualifiedNameCollision(t *testing.T) {
+	options := &claude.ClaudeAgentOptions{
+		McpServers: map[string]claude.McpServerConfig{
+			"a":     namedServer("a", "b__c"),
+			"a__b":  namedServer("a__b", "c"),
+			"other": namedServer("other", "d"),
+		},
+	}
+
+	_, err := claude.ValidateMcpTools(options)
+	var collision *claude.McpToolCollisionError
+	if !errors.As(err, &collision) {
+		t.Fatalf("expected McpToolCollisionError, got %v", err)
+	}
+	if len(collision.Servers) != 2 {
+		t.Errorf("expected two servers in collision, got %v", collision.Servers)
+	}
+}
+
+func TestValidateMcpTools_Warnings(t *testing.T) {
+	options := &claude.ClaudeAgentOptions{
+		McpServers: map[string]claude.McpServerConfig{
+			"calc":    namedServer("calc", "add", "search"),
+			"docs":    namedServer("docs", "search"),
+			"weather": namedServer("weather", "forecast"),
+		},
+		AllowedTools: []string{"mcp__calc__add", "mcp__docs", "mcp__weather__*"},
+	}
+
+	warnings, err := claude.ValidateMcpTools(options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %d: %v", len(warnings), warnings)
+	}
+	if !strings.Contains(warnings[0], `"search"`) || !strings.Contains(warnings[0], "calc, docs") {
+		t.Errorf("expected shared-name warning, got %s", warnings[0])
+	}
+	if !strings.Contains(warnings[1], "mcp__calc__search") {
+		t.Errorf("expected uncovered-tool warning for mcp__calc__search, got %s", warnings[1])
+	}
+}
+
+func TestValidateMcpTools_NoAllowedToolsNoCoverageWarnings(t *testing.T) {
+	options := &claude.ClaudeAgentOptions{
+		McpServers: map[string]claude.McpServerConfig{
+			"calc": namedServer("calc", "add"),
+		},
+	}
+
+	warnings, err := claude.ValidateMcpTools(options)
+	if err != nil || len(warnings) != 0 {
+		t.Errorf("expected no warnings or error, got %v, %v", warnings, err)
+	}
+}
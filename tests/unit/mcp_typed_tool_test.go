@@ -0,0 +1,201 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clsx524/claude-agent-sdk-go/mcp"
+)
+
+type searchArgs struct {
+	Query   string            `json:"query" description:"Text to search for"`
+	Limit   int               `json:"limit,omitempty" description:"Maximum results"`
+	Paths   []string          `json:"paths,omitempty"`
+	Exact   *bool             `json:"exact"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Ignored string            `json:"-"`
+	cursor  string
+}
+
+func callTypedTool(server *mcp.SdkMcpServer, name string, arguments map[string]interface{}) map[string]interface{} {
+	response := server.HandleRequest(context.Background(), map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": name, "arguments": arguments},
+	})
+	result, _ := response["result"].(map[string]interface{})
+	return result
+}
+
+func TestTypedToolSchema(t *testing.T) {
+	tool := mcp.TypedTool("search", "Search the docs", func(ctx context.Context, args searchArgs) (map[string]interface{}, error) {
+		return mcp.TextContent(args.Query), nil
+	})
+	schema, ok := tool.InputSchema.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a JSON schema, got %T", tool.InputSchema)
+	}
+	properties := schema["properties"].(map[string]interface{})
+	if len(properties) != 5 {
+		t.Errorf("expected the 5 exported json fields, got %v", properties)
+	}
+	query := properties["query"].(map[string]interface{})
+	if query["type"] != "string" || query["description"] != "Text to search for" {
+		t.Errorf("unexpected query property: %v", query)
+	}
+	if paths := properties["paths"].(map[string]interface{}); paths["type"] != "array" {
+		t.Errorf("unexpected paths property: %v", paths)
+	}
+	if labels := properties["labels"].(map[string]interface{}); labels["type"] != "object" {
+		t.Errorf("unexpected labels property: %v", labels)
+	}
+	if required := schema["required"]; !reflect.DeepEqual(required, []string{"query"}) {
+		t.Errorf("expected only query to be required, got %v", required)
+	}
+}
+
+func TestTypedToolDecodesArguments(t *testing.T) {
+	var got searchArgs
+	tool := mcp.TypedTool("search", "Search the docs", func(ctx context.Context, args searchArgs) (map[string]interface{}, error) {
+		got = args
+		return mcp.TextContent(fmt.Sprintf("%s:%d", args.Query, args.Limit)), nil
+	})
+	server := mcp.CreateSdkMcpServer("docs", "1.0.0", []*mcp.SdkMcpTool{tool})
+
+	result := callTypedTool(server, "search", map[string]interface{}{
+		"query": "retry", "limit": 3.0, "paths": []interface{}{"a.md"}, "exact": true,
+	})
+	content := result["content"].([]map[string]interface{})
+	if content[0]["text"] != "retry:3" || len(got.Paths) != 1 || got.Exact == nil || !*got.Exact {
+		t.Errorf("expected typed arguments, got %+v and %v", got, result)
+	}
+
+	// Validation against the derived schema runs first
+	response := server.HandleRequest(context.Background(), map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": "search", "arguments": map[string]interface{}{"limit": 3.0}},
+	})
+	if rpcErr, ok := response["error"].(map[string]interface{}); !ok || rpcErr["code"] != -32602 {
+		t.Errorf("expected an invalid params error, got %v", response)
+	}
+
+	// TypedTool checks arguments itself when the server does not
+	server.SkipArgumentValidation = true
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      string
+	}{
+		{"missing required", map[string]interface{}{"limit": 3.0}, `missing required argument "query"`},
+		{"wrong type", map[string]interface{}{"query": "retry", "limit": "three"}, "invalid arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = searchArgs{}
+			result := callTypedTool(server, "search", tt.arguments)
+			content := result["content"].([]map[string]interface{})
+			if result["isError"] != true || !strings.Contains(content[0]["text"].(string), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, result)
+			}
+			if got.Query != "" {
+				t.Error("expected the handler not to run")
+			}
+		})
+	}
+}
+
+type auditFields struct {
+	RequestID string `json:"request_id"`
+}
+
+type Paging struct {
+	Cursor string `json:"cursor"`
+}
+
+type treeNode struct {
+	Name     string     `json:"name"`
+	Children []treeNode `json:"children,omitempty"`
+}
+
+type reportArgs struct {
+	auditFields
+	*Paging
+	Since   time.Time       `json:"since"`
+	Payload []byte          `json:"payload,omitempty"`
+	Extra   interface{}     `json:"extra,omitempty"`
+	Raw     json.RawMessage `json:"raw,omitempty"`
+	Tree    treeNode        `json:"tree,omitempty"`
+	Note    *string         `json:"note"`
+}
+
+func TestTypedToolSchemaFollowsEncodingJSON(t *testing.T) {
+	var got reportArgs
+	tool := mcp.TypedTool("report", "File a report", func(ctx context.Context, args reportArgs) (map[string]interface{}, error) {
+		got = args
+		return mcp.TextContent("filed"), nil
+	})
+	schema := tool.InputSchema.(map[string]interface{})
+	properties := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// Embedded structs are flattened, not properties of their own
+	if want := []string{"cursor", "extra", "note", "payload", "raw", "request_id", "since", "tree"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected properties %v, got %v", want, names)
+	}
+	required := append([]string(nil), schema["required"].([]string)...)
+	sort.Strings(required)
+	if want := []string{"request_id", "since"}; !reflect.DeepEqual(required, want) {
+		t.Errorf("expected %v to be required, got %v", want, required)
+	}
+
+	tests := []struct {
+		property string
+		want     map[string]interface{}
+	}{
+		{"since", map[string]interface{}{"type": "string"}},
+		{"payload", map[string]interface{}{"type": "string"}},
+		{"extra", map[string]interface{}{}},
+		{"raw", map[string]interface{}{}},
+		{"note", map[string]interface{}{"type": []string{"string", "null"}}},
+	}
+	for _, tt := range tests {
+		if got := properties[tt.property]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.property, tt.want, got)
+		}
+	}
+	// Recursive types stop at the first repetition
+	tree := properties["tree"].(map[string]interface{})
+	children := tree["properties"].(map[string]interface{})["children"].(map[string]interface{})
+	if items := children["items"]; !reflect.DeepEqual(items, map[string]interface{}{"type": "object"}) {
+		t.Errorf("expected the recursive items to be a bare object, got %v", items)
+	}
+
+	server := mcp.CreateSdkMcpServer("reports", "1.0.0", []*mcp.SdkMcpTool{tool})
+	result := callTypedTool(server, "report", map[string]interface{}{
+		"request_id": "r1",
+		"since":      "2026-01-02T03:04:05Z",
+		"payload":    "aGk=",
+		"extra":      map[string]interface{}{"a": 1.0},
+		"raw":        []interface{}{1.0},
+		"tree":       map[string]interface{}{"name": "root", "children": []interface{}{map[string]interface{}{"name": "leaf"}}},
+		"note":       nil,
+	})
+	if result == nil || result["isError"] == true {
+		t.Fatalf("expected the call to succeed, got %v", result)
+	}
+	if got.RequestID != "r1" || got.Since.Year() != 2026 || string(got.Payload) != "hi" || got.Tree.Children[0].Name != "leaf" || got.Note != nil {
+		t.Errorf("unexpected arguments %+v", got)
+	}
+}
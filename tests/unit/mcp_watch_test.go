@@ -0,0 +1,132 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/mcp"
+)
+
+// callWatchTool calls a tool on server and returns its text content.
+func callWatchTool(t *testing.T, server *mcp.WatchServer, name string, args map[string]interface{}) string {
+	t.Helper()
+	resp := server.HandleRequest(context.Background(), map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": name, "arguments": args},
+	})
+	result, ok := resp["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("%s failed: %v", name, resp)
+	}
+	content := result["content"].([]map[string]interface{})
+	return content[0]["text"].(string)
+}
+
+// waitForChanges polls server until it has recorded n changes.
+func waitForChanges(t *testing.T, server *mcp.WatchServer, n int) []mcp.FileChange {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		changes := server.Changes()
+		if len(changes) >= n {
+			return changes
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d changes, got %v", n, changes)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWatchServer(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(file, []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := mcp.NewWatchServer([]string{dir}, mcp.WatchServerOptions{Interval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewWatchServer failed: %v", err)
+	}
+	defer server.Close()
+
+	if got := server.ToolNames(); strings.Join(got, ",") != "subscribe,unsubscribe,recent_changes,get_diff" {
+		t.Errorf("unexpected tools %v", got)
+	}
+	if text := callWatchTool(t, server, "subscribe", map[string]interface{}{}); !strings.Contains(text, dir) {
+		t.Errorf("expected subscribe to list the watched path, got %q", text)
+	}
+
+	// Ignored directories are not reported
+	if err := os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changes := waitForChanges(t, server, 1)
+	if len(changes) != 1 || changes[0].Path != file || changes[0].Kind != mcp.FileModified {
+		t.Fatalf("expected one modification of %s, got %+v", file, changes)
+	}
+	for _, want := range []string{"-func main() {}\n", "+func main() {\n", "+\tprintln(\"hi\")\n", "@@ -1,3 +1,5 @@"} {
+		if !strings.Contains(changes[0].Diff, want) {
+			t.Errorf("expected diff to contain %q, got:\n%s", want, changes[0].Diff)
+		}
+	}
+	if text := callWatchTool(t, server, "get_diff", map[string]interface{}{"path": file}); !strings.Contains(text, "println") {
+		t.Errorf("expected get_diff to return the diff, got %q", text)
+	}
+
+	// The hook pushes pending changes once
+	hook := server.Hooks()[claude.HookEventUserPromptSubmit][0].Hooks[0]
+	input := map[string]interface{}{"hook_event_name": "UserPromptSubmit"}
+	out, err := hook(context.Background(), input, nil, claude.HookContext{})
+	if err != nil {
+		t.Fatalf("hook failed: %v", err)
+	}
+	if ctxText, _ := out.HookSpecificOutput["additionalContext"].(string); !strings.Contains(ctxText, "modified "+file) {
+		t.Errorf("expected additionalContext to describe the change, got %v", out.HookSpecificOutput)
+	}
+	if out.HookSpecificOutput["hookEventName"] != "UserPromptSubmit" {
+		t.Errorf("expected hookEventName to echo the event, got %v", out.HookSpecificOutput)
+	}
+	if out, _ := hook(context.Background(), input, nil, claude.HookContext{}); out.HookSpecificOutput != nil {
+		t.Errorf("expected no context once pushed, got %v", out.HookSpecificOutput)
+	}
+
+	// After unsubscribing, changes are recorded but not pushed
+	callWatchTool(t, server, "unsubscribe", map[string]interface{}{})
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	changes = waitForChanges(t, server, 2)
+	if changes[1].Kind != mcp.FileRemoved {
+		t.Errorf("expected removal, got %+v", changes[1])
+	}
+	if out, _ := hook(context.Background(), input, nil, claude.HookContext{}); out.HookSpecificOutput != nil {
+		t.Errorf("expected no context after unsubscribe, got %v", out.HookSpecificOutput)
+	}
+	if text := callWatchTool(t, server, "recent_changes", map[string]interface{}{"limit": float64(1)}); !strings.HasPrefix(text, "removed "+file) {
+		t.Errorf("expected recent_changes to honor limit, got %q", text)
+	}
+}
+
+func TestWatchServerRequiresExistingPaths(t *testing.T) {
+	if _, err := mcp.NewWatchServer(nil, mcp.WatchServerOptions{}); err == nil {
+		t.Error("expected an error without paths")
+	}
+	if _, err := mcp.NewWatchServer([]string{filepath.Join(t.TempDir(), "missing")}, mcp.WatchServerOptions{}); err == nil {
+		t.Error("expected an error for a missing path")
+	}
+}
@@ -0,0 +1,26 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestWithMetadataMerges(t *testing.T) {
+	if claude.MetadataFromContext(context.Background()) != nil {
+		t.Error("expected nil metadata on a bare context")
+	}
+
+	base := claude.Metadata{"tenant": "acme", "user": "a"}
+	ctx := claude.WithMetadata(context.Background(), base)
+	ctx = claude.WithMetadata(ctx, claude.Metadata{"user": "b"})
+
+	md := claude.MetadataFromContext(ctx)
+	if md["tenant"] != "acme" || md["user"] != "b" {
+		t.Errorf("unexpected merged metadata: %v", md)
+	}
+	if base["user"] != "a" {
+		t.Error("WithMetadata must not modify its argument")
+	}
+}
@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestExpvarMetricsHistogram(t *testing.T) {
+	m := claude.NewExpvarMetrics(0.01, 0.001, 0.1)
+	labels := map[string]string{"kind": "hook_callback"}
+	m.ObserveDuration(claude.MetricCallbackDuration, 500*time.Microsecond, labels)
+	m.ObserveDuration(claude.MetricCallbackDuration, 50*time.Millisecond, labels)
+	m.ObserveDuration(claude.MetricCallbackDuration, time.Second, map[string]string{"kind": "can_use_tool"})
+	labels["kind"] = "changed"
+
+	snapshot := m.Snapshot()
+	if len(snapshot) != 2 {
+		t.Fatalf("expected 2 histograms, got %d", len(snapshot))
+	}
+	// Sorted by labels: can_use_tool before hook_callback
+	hook := snapshot[1]
+	if hook.Labels["kind"] != "hook_callback" {
+		t.Fatalf("labels must be copied, got %v", hook.Labels)
+	}
+	if hook.Count != 2 {
+		t.Errorf("expected count 2, got %d", hook.Count)
+	}
+	// Buckets are cumulative over sorted bounds 0.001, 0.01, 0.1
+	if want := []uint64{1, 1, 2}; len(hook.Buckets) != 3 || hook.Buckets[0] != want[0] || hook.Buckets[1] != want[1] || hook.Buckets[2] != want[2] {
+		t.Errorf("expected buckets %v, got %v", want, hook.Buckets)
+	}
+	if snapshot[0].Buckets[2] != 0 {
+		t.Error("observation above every bound should only be counted")
+	}
+
+	var decoded []claude.MetricHistogram
+	if err := json.Unmarshal([]byte(m.String()), &decoded); err != nil || len(decoded) != 2 {
+		t.Errorf("expected String to return the snapshot as JSON, got %q (%v)", m.String(), err)
+	}
+}
+
+func TestExpvarMetricsGauge(t *testing.T) {
+	m := claude.NewExpvarMetrics()
+	var _ claude.GaugeMetrics = m
+	labels := map[string]string{"buffer": "messages"}
+	m.SetGauge(claude.MetricBufferSize, 16, labels)
+	m.SetGauge(claude.MetricBufferSize, 32, labels)
+	labels["buffer"] = "changed"
+
+	gauges := m.Gauges()
+	if len(gauges) != 1 {
+		t.Fatalf("expected 1 gauge, got %d", len(gauges))
+	}
+	if gauges[0].Value != 32 || gauges[0].Labels["buffer"] != "messages" {
+		t.Errorf("expected the last value with copied labels, got %+v", gauges[0])
+	}
+	if len(m.Snapshot()) != 0 {
+		t.Error("gauges must not appear as histograms")
+	}
+}
+
+func TestExpvarMetricsCounter(t *testing.T) {
+	m := claude.NewExpvarMetrics()
+	var _ claude.CounterMetrics = m
+	labels := map[string]string{"type": "output"}
+	m.AddCounter(claude.MetricTokens, 100, labels)
+	m.AddCounter(claude.MetricTokens, 50, labels)
+	m.AddCounter(claude.MetricCost, 0.25, nil)
+	labels["type"] = "changed"
+
+	counters := m.Counters()
+	if len(counters) != 2 {
+		t.Fatalf("expected 2 counters, got %d", len(counters))
+	}
+	// Sorted by name: cost before tokens
+	if counters[0].Name != claude.MetricCost || counters[0].Value != 0.25 {
+		t.Errorf("unexpected cost counter %+v", counters[0])
+	}
+	if counters[1].Value != 150 || counters[1].Labels["type"] != "output" {
+		t.Errorf("expected the total with copied labels, got %+v", counters[1])
+	}
+	if len(m.Snapshot()) != 0 || len(m.Gauges()) != 0 {
+		t.Error("counters must not appear as histograms or gauges")
+	}
+}
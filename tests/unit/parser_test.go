@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"encoding/json"
 	"testing"
 
 	claude "github.com/clsx524/claude-agent-sdk-go"
@@ -413,3 +414,148 @@ func TestParseMessagePreservesErrorData(t *testing.T) {
 		t.Error("error message should not be empty")
 	}
 }
+
+func TestParsePermissionUpdate(t *testing.T) {
+	t.Run("addRules with camelCase rule keys", func(t *testing.T) {
+		data := map[string]interface{}{
+			"type":        "addRules",
+			"behavior":    "allow",
+			"destination": "session",
+			"rules": []interface{}{
+				map[string]interface{}{"toolName": "Bash", "ruleContent": "git status"},
+				map[string]interface{}{"toolName": "Read"},
+			},
+		}
+
+		update, err := claude.ParsePermissionUpdate(data)
+		if err != nil {
+			t.Fatalf("ParsePermissionUpdate failed: %v", err)
+		}
+
+		if update.Type != claude.PermissionUpdateTypeAddRules {
+			t.Errorf("expected type addRules, got %s", update.Type)
+		}
+		if update.Behavior == nil || *update.Behavior != claude.PermissionBehaviorAllow {
+			t.Errorf("expected behavior allow, got %v", update.Behavior)
+		}
+		if update.Destination == nil || *update.Destination != claude.PermissionUpdateDestinationSession {
+			t.Errorf("expected destination session, got %v", update.Destination)
+		}
+		if len(update.Rules) != 2 {
+			t.Fatalf("expected 2 rules, got %d", len(update.Rules))
+		}
+		if update.Rules[0].ToolName != "Bash" || update.Rules[0].RuleContent == nil || *update.Rules[0].RuleContent != "git status" {
+			t.Errorf("unexpected first rule: %+v", update.Rules[0])
+		}
+		if update.Rules[1].ToolName != "Read" || update.Rules[1].RuleContent != nil {
+			t.Errorf("unexpected second rule: %+v", update.Rules[1])
+		}
+	})
+
+	t.Run("snake_case rule keys", func(t *testing.T) {
+		data := map[string]interface{}{
+			"type": "removeRules",
+			"rules": []interface{}{
+				map[string]interface{}{"tool_name": "Write", "rule_content": "/tmp/**"},
+			},
+		}
+
+		update, err := claude.ParsePermissionUpdate(data)
+		if err != nil {
+			t.Fatalf("ParsePermissionUpdate failed: %v", err)
+		}
+		if len(update.Rules) != 1 || update.Rules[0].ToolName != "Write" {
+			t.Errorf("unexpected rules: %+v", update.Rules)
+		}
+	})
+
+	t.Run("setMode", func(t *testing.T) {
+		data := map[string]interface{}{
+			"type":        "setMode",
+			"mode":        "acceptEdits",
+			"destination": "localSettings",
+		}
+
+		update, err := claude.ParsePermissionUpdate(data)
+		if err != nil {
+			t.Fatalf("ParsePermissionUpdate failed: %v", err)
+		}
+		if update.Mode == nil || *update.Mode != claude.PermissionModeAcceptEdits {
+			t.Errorf("expected mode acceptEdits, got %v", update.Mode)
+		}
+	})
+
+	t.Run("addDirectories", func(t *testing.T) {
+		data := map[string]interface{}{
+			"type":        "addDirectories",
+			"directories": []interface{}{"/repo", "/data"},
+		}
+
+		update, err := claude.ParsePermissionUpdate(data)
+		if err != nil {
+			t.Fatalf("ParsePermissionUpdate failed: %v", err)
+		}
+		if len(update.Directories) != 2 || update.Directories[1] != "/data" {
+			t.Errorf("unexpected directories: %v", update.Directories)
+		}
+	})
+
+	t.Run("invalid updates", func(t *testing.T) {
+		cases := []map[string]interface{}{
+			{},
+			{"type": "unknownType"},
+			{"type": "addRules"},
+			{"type": "setMode"},
+			{"type": "addDirectories", "directories": []interface{}{1}},
+			{"type": "addRules", "rules": []interface{}{map[string]interface{}{"ruleContent": "x"}}},
+		}
+		for _, data := range cases {
+			if _, err := claude.ParsePermissionUpdate(data); err == nil {
+				t.Errorf("expected error for %v", data)
+			}
+		}
+	})
+}
+
+func TestPermissionUpdateJSONRoundTrip(t *testing.T) {
+	content := "npm test"
+	behavior := claude.PermissionBehaviorAllow
+	update := claude.PermissionUpdate{
+		Type:     claude.PermissionUpdateTypeAddRules,
+		Behavior: &behavior,
+		Rules:    []claude.PermissionRuleValue{{ToolName: "Bash", RuleContent: &content}},
+	}
+
+	data, err := json.Marshal(update)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	parsed, err := claude.ParsePermissionUpdate(raw)
+	if err != nil {
+		t.Fatalf("ParsePermissionUpdate failed: %v", err)
+	}
+	if parsed.Rules[0].ToolName != "Bash" || *parsed.Rules[0].RuleContent != content {
+		t.Errorf("round trip lost rule data: %+v", parsed.Rules[0])
+	}
+}
+
+func TestPermissionRuleValueAcceptsLegacyKeys(t *testing.T) {
+	for _, data := range []string{
+		`{"toolName":"Bash","ruleContent":"npm test"}`,
+		`{"tool_name":"Bash","rule_content":"npm test"}`,
+	} {
+		var rule claude.PermissionRuleValue
+		if err := json.Unmarshal([]byte(data), &rule); err != nil {
+			t.Fatalf("unmarshal %s failed: %v", data, err)
+		}
+		if rule.ToolName != "Bash" || rule.RuleContent == nil || *rule.RuleContent != "npm test" {
+			t.Errorf("unexpected rule from %s: %+v", data, rule)
+		}
+	}
+}
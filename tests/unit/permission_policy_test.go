@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestPermissionPolicyCanUseTool(t *testing.T) {
+	policy, err := claude.NewPermissionPolicy(
+		claude.PolicyRule{Tool: "Bash", CommandRegex: `\brm\s+-rf\b`, Action: "deny", Reason: "no recursive deletes"},
+		claude.PolicyRule{Tool: "Bash", Content: "npm test:*", Action: "allow"},
+		claude.PolicyRule{Tool: "Write", PathPrefix: "/srv/app", Action: "allow"},
+		claude.PolicyRule{Tool: "mcp__*", Action: "ask", Reason: "external service"},
+	)
+	if err != nil {
+		t.Fatalf("NewPermissionPolicy failed: %v", err)
+	}
+	canUseTool, err := policy.CanUseTool(nil)
+	if err != nil {
+		t.Fatalf("CanUseTool failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		tool     string
+		input    map[string]interface{}
+		behavior string
+		message  string
+	}{
+		{"command regex", "Bash", map[string]interface{}{"command": "cd /tmp && rm -rf build"}, "deny", "no recursive deletes"},
+		{"command prefix", "Bash", map[string]interface{}{"command": "npm test --watch=false"}, "allow", ""},
+		{"inside path prefix", "Write", map[string]interface{}{"file_path": "/srv/app/src/main.go"}, "allow", ""},
+		{"path prefix itself", "Write", map[string]interface{}{"file_path": "/srv/app"}, "allow", ""},
+		{"sibling of path prefix", "Write", map[string]interface{}{"file_path": "/srv/apple/main.go"}, "deny", "Write: no permission policy rule allows it"},
+		{"escaping path prefix", "Write", map[string]interface{}{"file_path": "/srv/app/../etc/passwd"}, "deny", "Write: no permission policy rule allows it"},
+		{"relative path", "Write", map[string]interface{}{"file_path": "src/main.go"}, "deny", "Write: no permission policy rule allows it"},
+		{"ask without next", "mcp__github__create_issue", map[string]interface{}{}, "ask", "external service"},
+		{"undecided", "WebFetch", map[string]interface{}{"url": "https://example.com"}, "deny", "WebFetch: no permission policy rule allows it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := canUseTool(context.Background(), tt.tool, tt.input, claude.ToolPermissionContext{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var behavior, message string
+			switch r := result.(type) {
+			case claude.PermissionResultAllow:
+				behavior = r.Behavior
+			case claude.PermissionResultDeny:
+				behavior, message = r.Behavior, r.Message
+			case claude.PermissionResultAsk:
+				behavior, message = r.Behavior, r.Message
+			}
+			if behavior != tt.behavior || message != tt.message {
+				t.Errorf("got %s %q, want %s %q", behavior, message, tt.behavior, tt.message)
+			}
+		})
+	}
+}
+
+func TestPermissionPolicyNextAndDefault(t *testing.T) {
+	policy := &claude.PermissionPolicy{Rules: []claude.PolicyRule{
+		{Tool: "Bash", CommandRegex: `^git\s+push`, Action: "ask"},
+	}}
+	var asked []string
+	canUseTool, err := policy.CanUseTool(func(ctx context.Context, toolName string, input map[string]interface{}, permCtx claude.ToolPermissionContext) (claude.PermissionResult, error) {
+		asked = append(asked, input["command"].(string))
+		return claude.PermissionResultAllow{Behavior: "allow"}, nil
+	})
+	if err != nil {
+		t.Fatalf("CanUseTool failed: %v", err)
+	}
+	for _, command := range []string{"git push origin main", "ls"} {
+		if _, err := canUseTool(context.Background(), "Bash", map[string]interface{}{"command": command}, claude.ToolPermissionContext{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(asked) != 2 {
+		t.Errorf("expected ask and undecided uses to go to next, got %q", asked)
+	}
+
+	policy.Default = "allow"
+	canUseTool, err = policy.CanUseTool(nil)
+	if err != nil {
+		t.Fatalf("CanUseTool failed: %v", err)
+	}
+	result, _ := canUseTool(context.Background(), "Read", map[string]interface{}{"file_path": "/etc/hosts"}, claude.ToolPermissionContext{})
+	if _, ok := result.(claude.PermissionResultAllow); !ok {
+		t.Errorf("expected the default action, got %#v", result)
+	}
+}
+
+func TestPermissionPolicyValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		rule claude.PolicyRule
+		want string
+	}{
+		{"invalid regex", claude.PolicyRule{Tool: "Bash", CommandRegex: "(", Action: "deny"}, "invalid command regex"},
+		{"relative path prefix", claude.PolicyRule{Tool: "Write", PathPrefix: "src", Action: "allow"}, "must be absolute"},
+		{"unknown action", claude.PolicyRule{Tool: "Write", Action: "maybe"}, "action must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := claude.NewPermissionPolicy(tt.rule); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+	if _, err := (&claude.PermissionPolicy{Default: "sometimes"}).CanUseTool(nil); err == nil {
+		t.Error("expected an invalid default to be rejected")
+	}
+}
+
+func TestLoadPermissionPolicy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.json")
+	content := `{"rules": [{"tool": "Bash", "command_regex": "\\bsudo\\b", "action": "deny"}], "default": "ask"}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := claude.LoadPermissionPolicy(path, nil)
+	if err != nil {
+		t.Fatalf("LoadPermissionPolicy failed: %v", err)
+	}
+	if len(policy.Rules) != 1 || policy.Rules[0].CommandRegex != `\bsudo\b` || policy.Default != "ask" {
+		t.Errorf("unexpected policy: %+v", policy)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"rules": [{"tool": "Bash", "command_regex": "[", "action": "deny"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := claude.LoadPermissionPolicy(invalid, nil); err == nil || !strings.Contains(err.Error(), "invalid.json") {
+		t.Errorf("expected the invalid policy to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+	"github.com/clsx524/claude-agent-sdk-go/schema"
+)
+
+type schemaNode struct {
+	Name     string        `json:"name"`
+	Children []*schemaNode `json:"children,omitempty"`
+	Parent   *schemaNode   `json:"-"`
+}
+
+func TestSchemaRequiredAndEmbedded(t *testing.T) {
+	s := schema.Of(claude.PreToolUseHookInput{})
+
+	if s["title"] != "PreToolUseHookInput" || s["$schema"] != schema.Draft {
+		t.Errorf("unexpected title or dialect: %v %v", s["title"], s["$schema"])
+	}
+	properties := s["properties"].(map[string]interface{})
+	// BaseHookInput is flattened
+	for _, name := range []string{"session_id", "cwd", "tool_name", "tool_input", "permission_mode"} {
+		if _, ok := properties[name]; !ok {
+			t.Errorf("missing property %s", name)
+		}
+	}
+	required := s["required"].([]string)
+	for _, name := range required {
+		if name == "permission_mode" {
+			t.Error("omitempty field should not be required")
+		}
+	}
+	if mode := properties["permission_mode"].(map[string]interface{}); !reflect.DeepEqual(mode["type"], []string{"string", "null"}) {
+		t.Errorf("expected nullable string for pointer field, got %v", mode["type"])
+	}
+}
+
+func TestSchemaRecursiveAndSkippedFields(t *testing.T) {
+	s := schema.Of(schemaNode{})
+	properties := s["properties"].(map[string]interface{})
+	if _, ok := properties["Parent"]; ok {
+		t.Error(`field tagged "-" should be skipped`)
+	}
+	children := properties["children"].(map[string]interface{})
+	items := children["items"].(map[string]interface{})
+	anyOf := items["anyOf"].([]interface{})
+	if ref := anyOf[0].(map[string]interface{})["$ref"]; ref != "#" {
+		t.Errorf("expected recursive reference to the root, got %v", ref)
+	}
+
+	// Generated schemas must be valid JSON
+	if _, err := json.Marshal(s); err != nil {
+		t.Fatalf("schema does not encode: %v", err)
+	}
+}
+
+func TestSchemaEnumsAndImplementations(t *testing.T) {
+	gen := &schema.Generator{
+		Enums: map[reflect.Type][]interface{}{
+			reflect.TypeOf(claude.PermissionUpdateType("")): {claude.PermissionUpdateTypeAddRules, claude.PermissionUpdateTypeSetMode},
+		},
+		Implementations: map[reflect.Type][]reflect.Type{
+			reflect.TypeOf((*claude.ContentBlock)(nil)).Elem(): {reflect.TypeOf(claude.TextBlock{}), reflect.TypeOf(claude.ToolUseBlock{})},
+		},
+	}
+
+	update := gen.Schema(reflect.TypeOf(claude.PermissionUpdate{}))
+	typeSchema := update["properties"].(map[string]interface{})["type"].(map[string]interface{})
+	if enum := typeSchema["enum"].([]interface{}); len(enum) != 2 {
+		t.Errorf("expected 2 enum values, got %v", enum)
+	}
+
+	assistant := gen.Schema(reflect.TypeOf(claude.AssistantMessage{}))
+	content := assistant["properties"].(map[string]interface{})["content"].(map[string]interface{})
+	oneOf := content["items"].(map[string]interface{})["oneOf"].([]interface{})
+	if len(oneOf) != 2 {
+		t.Fatalf("expected oneOf with 2 content blocks, got %v", oneOf)
+	}
+	defs := assistant["$defs"].(map[string]interface{})
+	if _, ok := defs["ToolUseBlock"]; !ok {
+		t.Error("expected ToolUseBlock in $defs")
+	}
+}
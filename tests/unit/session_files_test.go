@@ -0,0 +1,134 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+// writeSession writes a session transcript and its artifacts last used at
+// modTime under configDir.
+func writeSession(t *testing.T, configDir, project, id string, modTime time.Time) {
+	t.Helper()
+	files := map[string]string{
+		filepath.Join("projects", project, id+".jsonl"):                      `{"type":"user"}` + "\n",
+		filepath.Join("projects", project, id, "subagents", "agent-1.jsonl"): `{"type":"user"}` + "\n",
+		filepath.Join("todos", id+"-agent-"+id+".json"):                      "[]",
+		filepath.Join("file-history", id, "edit-1"):                          "before",
+	}
+	for name, content := range files {
+		path := filepath.Join(configDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chtimes(filepath.Join(configDir, "projects", project, id+".jsonl"), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCleanupSessions(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", configDir)
+
+	now := time.Now()
+	writeSession(t, configDir, "-srv-app", "old", now.Add(-48*time.Hour))
+	writeSession(t, configDir, "-srv-app", "recent", now.Add(-time.Hour))
+	writeSession(t, configDir, "-srv-other", "older", now.Add(-72*time.Hour))
+
+	sessions, err := claude.ListSessions()
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 3 || sessions[0].ID != "older" || sessions[1].ID != "old" || sessions[2].ID != "recent" {
+		t.Fatalf("expected sessions oldest first, got %+v", sessions)
+	}
+	if sessions[0].Project != "-srv-other" || sessions[0].Size != int64(2*len(`{"type":"user"}`+"\n")+len("[]")+len("before")) {
+		t.Errorf("unexpected session: %+v", sessions[0])
+	}
+
+	removed, err := claude.CleanupSessions(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("CleanupSessions failed: %v", err)
+	}
+	if len(removed) != 2 || removed[0].ID != "older" || removed[1].ID != "old" {
+		t.Fatalf("expected the two old sessions removed, got %+v", removed)
+	}
+	for _, gone := range []string{
+		"projects/-srv-app/old.jsonl",
+		"projects/-srv-app/old",
+		"todos/old-agent-old.json",
+		"file-history/old",
+		"projects/-srv-other",
+	} {
+		if _, err := os.Stat(filepath.Join(configDir, gone)); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", gone)
+		}
+	}
+	for _, kept := range []string{
+		"projects/-srv-app/recent.jsonl",
+		"projects/-srv-app/recent",
+		"todos/recent-agent-recent.json",
+		"file-history/recent",
+	} {
+		if _, err := os.Stat(filepath.Join(configDir, kept)); err != nil {
+			t.Errorf("expected %s to be kept: %v", kept, err)
+		}
+	}
+
+	sessions, err = claude.ListSessions()
+	if err != nil || len(sessions) != 1 || sessions[0].ID != "recent" {
+		t.Errorf("expected only the recent session left, got %+v, %v", sessions, err)
+	}
+}
+
+func TestCleanupSessionsRejectsUnsafeInput(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", configDir)
+
+	old := time.Now().Add(-48 * time.Hour)
+	writeSession(t, configDir, "-srv-app", "kept", time.Now())
+	for _, name := range []string{".jsonl", "a b.jsonl", "*.jsonl"} {
+		path := filepath.Join(configDir, "projects", "-srv-app", name)
+		if err := os.WriteFile(path, []byte("{}\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, old, old)
+	}
+
+	for _, olderThan := range []time.Duration{0, -time.Hour} {
+		if _, err := claude.CleanupSessions(olderThan); err == nil {
+			t.Errorf("expected an error for retention %v", olderThan)
+		}
+	}
+
+	// Transcripts without a valid ID are skipped, so their artifact paths
+	// cannot name the project or state directories themselves
+	sessions, err := claude.ListSessions()
+	if err != nil || len(sessions) != 1 || sessions[0].ID != "kept" {
+		t.Fatalf("expected only the valid session listed, got %+v, %v", sessions, err)
+	}
+	removed, err := claude.CleanupSessions(time.Hour)
+	if err != nil || len(removed) != 0 {
+		t.Fatalf("expected nothing removed, got %+v, %v", removed, err)
+	}
+	for _, kept := range []string{"projects/-srv-app/kept.jsonl", "projects/-srv-app/.jsonl", "file-history/kept", "todos"} {
+		if _, err := os.Stat(filepath.Join(configDir, kept)); err != nil {
+			t.Errorf("expected %s to be kept: %v", kept, err)
+		}
+	}
+}
+
+func TestListSessionsWithoutConfigDir(t *testing.T) {
+	t.Setenv("CLAUDE_CONFIG_DIR", filepath.Join(t.TempDir(), "missing"))
+	sessions, err := claude.ListSessions()
+	if err != nil || len(sessions) != 0 {
+		t.Errorf("expected no sessions and no error, got %+v, %v", sessions, err)
+	}
+}
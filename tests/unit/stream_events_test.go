@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func decodeEvent(t *testing.T, raw string) claude.StreamEventData {
+	t.Helper()
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"type": "stream_event", "uuid": "u1", "session_id": "s1", "event": `+raw+`}`), &data); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := claude.ParseMessage(data)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	event, err := msg.(*claude.StreamEvent).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	return event
+}
+
+func TestDecodeStreamEvent(t *testing.T) {
+	start, ok := decodeEvent(t, `{"type": "message_start", "message": {"id": "msg_1", "model": "claude-sonnet-4-5",
+		"role": "assistant", "usage": {"input_tokens": 25, "output_tokens": 1}}}`).(*claude.MessageStartEvent)
+	if !ok || start.ID != "msg_1" || start.Model != "claude-sonnet-4-5" || start.Usage == nil || start.Usage.InputTokens != 25 {
+		t.Errorf("message_start decoded as %+v", start)
+	}
+
+	block, ok := decodeEvent(t, `{"type": "content_block_start", "index": 1,
+		"content_block": {"type": "tool_use", "id": "toolu_1", "name": "Read", "input": {}}}`).(*claude.ContentBlockStartEvent)
+	if !ok || block.Index != 1 || block.Type != "tool_use" || block.ID != "toolu_1" || block.Name != "Read" || block.Block == nil {
+		t.Errorf("content_block_start decoded as %+v", block)
+	}
+
+	text, ok := decodeEvent(t, `{"type": "content_block_delta", "index": 0,
+		"delta": {"type": "text_delta", "text": "Hello"}}`).(*claude.ContentBlockDeltaEvent)
+	if !ok || text.Type != claude.DeltaTypeText || text.Text != "Hello" {
+		t.Errorf("text delta decoded as %+v", text)
+	}
+	input, ok := decodeEvent(t, `{"type": "content_block_delta", "index": 1,
+		"delta": {"type": "input_json_delta", "partial_json": "{\"path\": "}}`).(*claude.ContentBlockDeltaEvent)
+	if !ok || input.Index != 1 || input.Type != claude.DeltaTypeInputJSON || input.PartialJSON != `{"path": ` {
+		t.Errorf("input_json delta decoded as %+v", input)
+	}
+
+	if stop, ok := decodeEvent(t, `{"type": "content_block_stop", "index": 2}`).(*claude.ContentBlockStopEvent); !ok || stop.Index != 2 {
+		t.Errorf("content_block_stop decoded as %+v", stop)
+	}
+
+	delta, ok := decodeEvent(t, `{"type": "message_delta", "delta": {"stop_reason": "tool_use", "stop_sequence": null},
+		"usage": {"output_tokens": 42}}`).(*claude.MessageDeltaEvent)
+	if !ok || delta.StopReason != "tool_use" || delta.Usage == nil || delta.Usage.OutputTokens != 42 {
+		t.Errorf("message_delta decoded as %+v", delta)
+	}
+
+	if _, ok := decodeEvent(t, `{"type": "message_stop"}`).(*claude.MessageStopEvent); !ok {
+		t.Error("message_stop not decoded")
+	}
+
+	errEvent, ok := decodeEvent(t, `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`).(*claude.StreamErrorEvent)
+	if !ok || errEvent.Type != "overloaded_error" || errEvent.Message != "Overloaded" {
+		t.Errorf("error decoded as %+v", errEvent)
+	}
+
+	unknown, ok := decodeEvent(t, `{"type": "future_event", "x": 1}`).(*claude.UnknownStreamEvent)
+	if !ok || unknown.EventType() != "future_event" || unknown.Event["x"] != float64(1) {
+		t.Errorf("unknown event decoded as %+v", unknown)
+	}
+
+	if _, err := claude.DecodeStreamEvent(map[string]interface{}{"index": 0}); err == nil {
+		t.Error("expected an error for an event without a type")
+	}
+}
@@ -0,0 +1,32 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestStreamStatusOf(t *testing.T) {
+	tests := []struct {
+		err    error
+		status claude.StreamStatus
+	}{
+		{nil, claude.StreamCompleted},
+		{context.Canceled, claude.StreamInterrupted},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), claude.StreamInterrupted},
+		{claude.NewMessageParseError("bad message", nil), claude.StreamParseError},
+		{claude.NewCLIJSONDecodeError("{", fmt.Errorf("unexpected EOF")), claude.StreamParseError},
+		{claude.ErrStreamTruncated, claude.StreamTransportClosed},
+		{claude.ErrClosed, claude.StreamTransportClosed},
+		{claude.NewProcessError("exited", 1, ""), claude.StreamTransportClosed},
+		{claude.NewAuthenticationError("cli", "not logged in", claude.NewProcessError("exited", 1, "")), claude.StreamFailed},
+		{claude.NewBudgetExceededError(2, 1), claude.StreamFailed},
+	}
+	for _, tt := range tests {
+		if status := claude.StreamStatusOf(tt.err); status != tt.status {
+			t.Errorf("StreamStatusOf(%v) = %s, want %s", tt.err, status, tt.status)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func strictResultMessage() map[string]interface{} {
+	return map[string]interface{}{
+		"type":            "result",
+		"subtype":         "success",
+		"duration_ms":     float64(1000),
+		"duration_api_ms": float64(800),
+		"is_error":        false,
+		"num_turns":       float64(1),
+		"session_id":      "session_123",
+		"total_cost_usd":  0.01,
+		"usage":           map[string]interface{}{"input_tokens": float64(10)},
+	}
+}
+
+func TestStrictParsingAcceptsKnownFields(t *testing.T) {
+	msg, err := claude.ParseMessageStrict(strictResultMessage(), nil)
+	if err != nil {
+		t.Fatalf("ParseMessageStrict failed: %v", err)
+	}
+	if _, ok := msg.(*claude.ResultMessage); !ok {
+		t.Errorf("expected *ResultMessage, got %T", msg)
+	}
+}
+
+func TestStrictParsingRejectsUnexpectedField(t *testing.T) {
+	data := strictResultMessage()
+	data["total_cost_cents"] = float64(1)
+
+	_, err := claude.ParseMessageStrict(data, nil)
+	if err == nil {
+		t.Fatal("expected error for unexpected field")
+	}
+	if _, ok := err.(*claude.MessageParseError); !ok {
+		t.Errorf("expected *MessageParseError, got %T", err)
+	}
+
+	// Lenient parsing still accepts it
+	if _, err := claude.ParseMessage(data); err != nil {
+		t.Errorf("lenient parsing should accept unexpected field, got %v", err)
+	}
+}
+
+func TestStrictParsingWarnsViaCallback(t *testing.T) {
+	data := map[string]interface{}{
+		"type": "assistant",
+		"message": map[string]interface{}{
+			"model":   "claude-sonnet-4-5",
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": "hi"}},
+			"mystery": true,
+		},
+	}
+
+	var warnings []claude.ParseWarning
+	msg, err := claude.ParseMessageStrict(data, func(w claude.ParseWarning) {
+		warnings = append(warnings, w)
+	})
+	if err != nil {
+		t.Fatalf("ParseMessageStrict with callback should not fail, got %v", err)
+	}
+	if _, ok := msg.(*claude.AssistantMessage); !ok {
+		t.Errorf("expected *AssistantMessage, got %T", msg)
+	}
+
+	expected := map[string]string{
+		"session_id":      "missing",
+		"message.mystery": "unexpected",
+		"message.usage":   "missing",
+	}
+	if len(warnings) != len(expected) {
+		t.Fatalf("expected %d warnings, got %d: %v", len(expected), len(warnings), warnings)
+	}
+	for _, w := range warnings {
+		if expected[w.Field] != w.Problem {
+			t.Errorf("unexpected warning: %s", w)
+		}
+		if w.MessageType != "assistant" {
+			t.Errorf("expected message type assistant, got %s", w.MessageType)
+		}
+	}
+}
+
+func TestStrictParsingIgnoresOtherMessageTypes(t *testing.T) {
+	data := map[string]interface{}{
+		"type":    "system",
+		"subtype": "init",
+		"extra":   "ignored",
+	}
+	if _, err := claude.ParseMessageStrict(data, nil); err != nil {
+		t.Errorf("system messages are not checked, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestSubagentTracker(t *testing.T) {
+	tracker := claude.NewSubagentTracker()
+
+	started := tracker.Observe(&claude.AssistantMessage{
+		Content: []claude.ContentBlock{
+			claude.TextBlock{Text: "Delegating"},
+			claude.ToolUseBlock{ID: "task_1", Name: "Task", Input: map[string]interface{}{
+				"subagent_type": "code-reviewer",
+				"description":   "Review the diff",
+			}},
+			claude.ToolUseBlock{ID: "read_1", Name: "Read"},
+		},
+	})
+	if len(started) != 1 || started[0].Type != claude.SubagentStarted {
+		t.Fatalf("expected one started event, got %+v", started)
+	}
+	if started[0].AgentName != "code-reviewer" || started[0].Description != "Review the diff" {
+		t.Errorf("unexpected started event: %+v", started[0])
+	}
+	if len(tracker.Active()) != 1 {
+		t.Errorf("expected one active subagent")
+	}
+
+	stopped := tracker.Observe(&claude.UserMessage{
+		Content: []claude.ContentBlock{
+			claude.ToolResultBlock{ToolUseID: "read_1"},
+			claude.ToolResultBlock{ToolUseID: "task_1"},
+		},
+		ToolUseResult: map[string]interface{}{
+			"totalDurationMs": float64(1500),
+			"totalTokens":     float64(4200),
+			"usage":           map[string]interface{}{"output_tokens": float64(300)},
+		},
+	})
+	if len(stopped) != 1 || stopped[0].Type != claude.SubagentStopped {
+		t.Fatalf("expected one stopped event, got %+v", stopped)
+	}
+	event := stopped[0]
+	if event.AgentName != "code-reviewer" || event.Duration != 1500*time.Millisecond || event.TotalTokens != 4200 {
+		t.Errorf("unexpected stopped event: %+v", event)
+	}
+	if event.Usage == nil || event.CostUSD != nil || event.IsError {
+		t.Errorf("unexpected usage/cost/error fields: %+v", event)
+	}
+	if len(tracker.Active()) != 0 {
+		t.Errorf("expected no active subagents")
+	}
+}
+
+func TestSubagentTrackerClearsAtResult(t *testing.T) {
+	tracker := claude.NewSubagentTracker()
+	tracker.Observe(&claude.AssistantMessage{
+		Content: []claude.ContentBlock{
+			claude.ToolUseBlock{ID: "task_1", Name: "Task", Input: map[string]interface{}{"subagent_type": "explorer"}},
+		},
+	})
+	if len(tracker.Active()) != 1 {
+		t.Fatalf("expected one active subagent")
+	}
+
+	// An interrupted turn ends without the Task's result
+	if events := tracker.Observe(&claude.ResultMessage{Subtype: "error_during_execution"}); len(events) != 0 {
+		t.Errorf("expected no events at the result, got %+v", events)
+	}
+	if len(tracker.Active()) != 0 {
+		t.Errorf("expected the result to clear active subagents, got %+v", tracker.Active())
+	}
+}
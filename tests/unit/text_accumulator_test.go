@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"reflect"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func streamEvent(event map[string]interface{}) *claude.StreamEvent {
+	return &claude.StreamEvent{UUID: "u", SessionID: "s", Event: event}
+}
+
+func blockDelta(deltaType string, key string, value string) *claude.StreamEvent {
+	return streamEvent(map[string]interface{}{
+		"type": "content_block_delta", "index": float64(0),
+		"delta": map[string]interface{}{"type": deltaType, key: value},
+	})
+}
+
+func TestTextAccumulatorStreamed(t *testing.T) {
+	acc := claude.NewTextAccumulator()
+	var texts, thinking, tools []string
+	acc.OnTextDelta = func(delta string) { texts = append(texts, delta) }
+	acc.OnThinkingDelta = func(delta string) { thinking = append(thinking, delta) }
+	acc.OnToolUseStart = func(id, name string) { tools = append(tools, id+":"+name) }
+
+	parent := "toolu_parent"
+	subagent := blockDelta("text_delta", "text", "subagent text")
+	subagent.ParentToolUseID = &parent
+
+	for _, msg := range []claude.Message{
+		blockDelta("thinking_delta", "thinking", "Let me "),
+		blockDelta("thinking_delta", "thinking", "check."),
+		&claude.AssistantMessage{Content: []claude.ContentBlock{claude.ThinkingBlock{Thinking: "Let me check."}}},
+		blockDelta("text_delta", "text", "Hello, "),
+		blockDelta("text_delta", "text", "world."),
+		subagent,
+		&claude.AssistantMessage{Content: []claude.ContentBlock{claude.TextBlock{Text: "Hello, world."}}},
+		streamEvent(map[string]interface{}{
+			"type": "content_block_start", "index": float64(1),
+			"content_block": map[string]interface{}{"type": "tool_use", "id": "toolu_1", "name": "Read"},
+		}),
+		blockDelta("input_json_delta", "partial_json", `{"file_path":`),
+		&claude.AssistantMessage{Content: []claude.ContentBlock{claude.ToolUseBlock{ID: "toolu_1", Name: "Read"}}},
+	} {
+		acc.Observe(msg)
+	}
+
+	if acc.Text() != "Hello, world." || acc.Thinking() != "Let me check." {
+		t.Errorf("accumulated text %q, thinking %q", acc.Text(), acc.Thinking())
+	}
+	if !reflect.DeepEqual(texts, []string{"Hello, ", "world."}) {
+		t.Errorf("text deltas = %q", texts)
+	}
+	if !reflect.DeepEqual(thinking, []string{"Let me ", "check."}) {
+		t.Errorf("thinking deltas = %q", thinking)
+	}
+	if !reflect.DeepEqual(tools, []string{"toolu_1:Read"}) {
+		t.Errorf("tool use starts = %q", tools)
+	}
+
+	acc.Reset()
+	if acc.Text() != "" || acc.Thinking() != "" {
+		t.Error("Reset did not clear the accumulator")
+	}
+}
+
+func TestTextAccumulatorWholeMessages(t *testing.T) {
+	acc := claude.NewTextAccumulator()
+	var texts, tools []string
+	acc.OnTextDelta = func(delta string) { texts = append(texts, delta) }
+	acc.OnToolUseStart = func(id, name string) { tools = append(tools, name) }
+
+	acc.Observe(&claude.AssistantMessage{Content: []claude.ContentBlock{
+		claude.TextBlock{Text: "Reading the file. "},
+		claude.ToolUseBlock{ID: "toolu_1", Name: "Read"},
+	}})
+	acc.Observe(&claude.AssistantMessage{Content: []claude.ContentBlock{claude.TextBlock{Text: "Done."}}})
+	acc.Observe(&claude.ResultMessage{Subtype: "success"})
+
+	if acc.Text() != "Reading the file. Done." {
+		t.Errorf("Text() = %q", acc.Text())
+	}
+	if !reflect.DeepEqual(texts, []string{"Reading the file. ", "Done."}) || !reflect.DeepEqual(tools, []string{"Read"}) {
+		t.Errorf("callbacks got texts %q, tools %q", texts, tools)
+	}
+}
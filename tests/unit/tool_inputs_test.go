@@ -0,0 +1,127 @@
+package unit
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestToolUseBlockTypedInputs(t *testing.T) {
+	bash := claude.ToolUseBlock{Name: "Bash", Input: map[string]interface{}{
+		"command": "go test ./...",
+		"timeout": float64(60000),
+	}}
+	input, ok := bash.AsBash()
+	if !ok {
+		t.Fatal("expected Bash input to decode")
+	}
+	if input.Command != "go test ./..." || input.Timeout == nil || *input.Timeout != 60000 {
+		t.Errorf("unexpected Bash input: %+v", input)
+	}
+	if _, ok := bash.AsRead(); ok {
+		t.Error("AsRead should not match a Bash tool use")
+	}
+
+	read := claude.ToolUseBlock{Name: "Read", Input: map[string]interface{}{
+		"file_path": "/tmp/a.go",
+		"offset":    float64(10),
+	}}
+	readInput, ok := read.AsRead()
+	if !ok || readInput.FilePath != "/tmp/a.go" || *readInput.Offset != 10 || readInput.Limit != nil {
+		t.Errorf("unexpected Read input: %+v", readInput)
+	}
+
+	edit := claude.ToolUseBlock{Name: "Edit", Input: map[string]interface{}{
+		"file_path":   "a.go",
+		"old_string":  "foo",
+		"new_string":  "bar",
+		"replace_all": true,
+	}}
+	editInput, ok := edit.AsEdit()
+	if !ok || editInput.OldString != "foo" || editInput.NewString != "bar" || !editInput.ReplaceAll {
+		t.Errorf("unexpected Edit input: %+v", editInput)
+	}
+
+	grep := claude.ToolUseBlock{Name: "Grep", Input: map[string]interface{}{"pattern": "TODO", "-i": true}}
+	if grepInput, ok := grep.AsGrep(); !ok || !grepInput.CaseInsensitive {
+		t.Errorf("unexpected Grep input: %+v", grepInput)
+	}
+}
+
+func TestToolUseBlockMalformedInput(t *testing.T) {
+	bash := claude.ToolUseBlock{Name: "Bash", Input: map[string]interface{}{"command": 42}}
+	if _, ok := bash.AsBash(); ok {
+		t.Error("expected malformed Bash input not to decode")
+	}
+}
+
+func TestDecodeToolInput(t *testing.T) {
+	var input claude.WriteInput
+	err := claude.DecodeToolInput(map[string]interface{}{"file_path": "out.txt", "content": "hi"}, &input)
+	if err != nil {
+		t.Fatalf("DecodeToolInput failed: %v", err)
+	}
+	if input.FilePath != "out.txt" || input.Content != "hi" {
+		t.Errorf("unexpected Write input: %+v", input)
+	}
+}
+
+func TestParseToolInputRoundTrip(t *testing.T) {
+	timeout, limit, lines, yes := 5000, 20, 3, true
+	inputs := []claude.ToolInput{
+		&claude.BashInput{Command: "make", Timeout: &timeout, Description: "Build", RunInBackground: true, DangerouslyDisableSandbox: true},
+		&claude.BashOutputInput{BashID: "bash_1", Filter: "error"},
+		&claude.KillShellInput{ShellID: "bash_1"},
+		&claude.ReadInput{FilePath: "/src/main.go", Limit: &limit},
+		&claude.WriteInput{FilePath: "out.txt", Content: "hi\n"},
+		&claude.EditInput{FilePath: "a.go", OldString: "foo", NewString: "bar", ReplaceAll: true},
+		&claude.MultiEditInput{FilePath: "a.go", Edits: []claude.MultiEditEdit{{OldString: "a", NewString: "b"}, {OldString: "c", NewString: "d", ReplaceAll: true}}},
+		&claude.GlobInput{Pattern: "**/*.go", Path: "src"},
+		&claude.GrepInput{Pattern: "TODO", OutputMode: "content", CaseInsensitive: true, LineNumbers: &yes, Context: &lines, HeadLimit: &limit, Multiline: true},
+		&claude.NotebookEditInput{NotebookPath: "nb.ipynb", CellID: "c1", NewSource: "print(1)", CellType: "code", EditMode: "insert"},
+		&claude.WebFetchInput{URL: "https://go.dev", Prompt: "Latest release?"},
+		&claude.WebSearchInput{Query: "go generics", AllowedDomains: []string{"go.dev"}},
+		&claude.TodoWriteInput{Todos: []claude.TodoItem{{Content: "Write tests", Status: "in_progress", ActiveForm: "Writing tests"}}},
+		&claude.TaskInput{Description: "Review", Prompt: "Review the diff", SubagentType: "reviewer"},
+		&claude.ExitPlanModeInput{Plan: "1. Refactor"},
+		&claude.ListMcpResourcesInput{Server: "docs"},
+		&claude.ReadMcpResourceInput{Server: "docs", URI: "docs://intro"},
+	}
+
+	for _, want := range inputs {
+		t.Run(want.ToolName(), func(t *testing.T) {
+			// As the CLI sends it: JSON decoded into a map
+			data, err := json.Marshal(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var raw map[string]interface{}
+			if err := json.Unmarshal(data, &raw); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := claude.ParseToolInput(want.ToolName(), raw)
+			if err != nil {
+				t.Fatalf("ParseToolInput failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip changed the input:\n got %+v\nwant %+v", got, want)
+			}
+			block := claude.ToolUseBlock{Name: want.ToolName(), Input: raw}
+			if parsed, err := block.ParseInput(); err != nil || !reflect.DeepEqual(parsed, want) {
+				t.Errorf("ParseInput = %+v, %v", parsed, err)
+			}
+		})
+	}
+}
+
+func TestParseToolInputErrors(t *testing.T) {
+	if _, err := claude.ParseToolInput("mcp__docs__search", map[string]interface{}{"q": "x"}); err == nil {
+		t.Error("expected an error for a tool without a typed input")
+	}
+	if _, err := claude.ParseToolInput("Read", map[string]interface{}{"file_path": 7}); err == nil {
+		t.Error("expected an error for malformed input")
+	}
+}
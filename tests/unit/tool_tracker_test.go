@@ -0,0 +1,150 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func toolUseMessage(id, name string) *claude.AssistantMessage {
+	return &claude.AssistantMessage{
+		Model: "claude-sonnet-4-5",
+		Content: []claude.ContentBlock{
+			claude.ToolUseBlock{ID: id, Name: name, Input: map[string]interface{}{}},
+		},
+	}
+}
+
+func toolResultMessage(id, content string) *claude.UserMessage {
+	return &claude.UserMessage{
+		Content: []claude.ContentBlock{
+			claude.ToolResultBlock{ToolUseID: id, Content: content},
+		},
+	}
+}
+
+func TestToolTrackerCorrelatesResults(t *testing.T) {
+	tracker := claude.NewToolTracker()
+
+	tracker.Observe(toolUseMessage("tool_1", "Read"))
+	tracker.Observe(toolUseMessage("tool_2", "Bash"))
+
+	if pending := tracker.Pending(); len(pending) != 2 || pending[0].ID != "tool_1" {
+		t.Fatalf("expected 2 pending tool uses in order, got %+v", pending)
+	}
+
+	tracker.Observe(toolResultMessage("tool_1", "file contents"))
+
+	result, ok := tracker.Result("tool_1")
+	if !ok || result.Content != "file contents" {
+		t.Errorf("expected result for tool_1, got %+v (ok=%v)", result, ok)
+	}
+	if pending := tracker.Pending(); len(pending) != 1 || pending[0].ID != "tool_2" {
+		t.Errorf("expected only tool_2 pending, got %+v", pending)
+	}
+	if toolUse, ok := tracker.ToolUse("tool_2"); !ok || toolUse.Name != "Bash" {
+		t.Errorf("expected tool_2 to be Bash, got %+v", toolUse)
+	}
+}
+
+func TestToolTrackerWaitForToolResult(t *testing.T) {
+	tracker := claude.NewToolTracker()
+	tracker.Observe(toolUseMessage("tool_1", "Read"))
+
+	done := make(chan claude.ToolResultBlock, 1)
+	go func() {
+		result, err := tracker.WaitForToolResult(context.Background(), "tool_1")
+		if err != nil {
+			t.Errorf("WaitForToolResult failed: %v", err)
+		}
+		done <- result
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	tracker.Observe(toolResultMessage("tool_1", "ok"))
+
+	select {
+	case result := <-done:
+		if result.ToolUseID != "tool_1" {
+			t.Errorf("expected result for tool_1, got %s", result.ToolUseID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for tool result")
+	}
+
+	// Already-observed results return immediately
+	if _, err := tracker.WaitForToolResult(context.Background(), "tool_1"); err != nil {
+		t.Errorf("expected immediate result, got %v", err)
+	}
+}
+
+func TestToolTrackerWaitHonorsContext(t *testing.T) {
+	tracker := claude.NewToolTracker()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := tracker.WaitForToolResult(ctx, "missing")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestToolTrackerDetectsOrphans(t *testing.T) {
+	tracker := claude.NewToolTracker()
+	tracker.Observe(toolUseMessage("tool_1", "Read"))
+	tracker.Observe(toolUseMessage("tool_2", "WebFetch"))
+	tracker.Observe(toolResultMessage("tool_1", "ok"))
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := tracker.WaitForToolResult(context.Background(), "tool_2")
+		errCh <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	tracker.Observe(&claude.ResultMessage{Subtype: "success", SessionID: "s"})
+
+	select {
+	case err := <-errCh:
+		var orphanErr *claude.OrphanedToolUseError
+		if !errors.As(err, &orphanErr) || orphanErr.ToolUseID != "tool_2" || orphanErr.NotFound {
+			t.Errorf("expected OrphanedToolUseError for tool_2, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for orphan notification")
+	}
+
+	orphaned := tracker.Orphaned()
+	if len(orphaned) != 1 || orphaned[0].ID != "tool_2" {
+		t.Errorf("expected tool_2 orphaned, got %+v", orphaned)
+	}
+	if len(tracker.Pending()) != 0 {
+		t.Errorf("expected no pending tool uses after result, got %+v", tracker.Pending())
+	}
+}
+
+func TestToolTrackerWakesWaitersForUnseenToolUses(t *testing.T) {
+	tracker := claude.NewToolTracker()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := tracker.WaitForToolResult(context.Background(), "never_used")
+		errCh <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	tracker.Observe(&claude.ResultMessage{Subtype: "success", SessionID: "s"})
+
+	select {
+	case err := <-errCh:
+		var orphanErr *claude.OrphanedToolUseError
+		if !errors.As(err, &orphanErr) || orphanErr.ToolUseID != "never_used" || !orphanErr.NotFound {
+			t.Errorf("expected a not-found OrphanedToolUseError, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the end of the turn to wake the waiter")
+	}
+}
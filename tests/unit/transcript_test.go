@@ -0,0 +1,165 @@
+package unit
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func readTranscriptFile(t *testing.T, path string, gzipped bool) []claude.TranscriptRecord {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if gzipped {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("failed to open gzip reader: %v", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	var records []claude.TranscriptRecord
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var record claude.TranscriptRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid transcript line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestTranscriptRecorderGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	rec, err := claude.NewTranscriptRecorder(claude.TranscriptOptions{
+		Path:        path,
+		Compression: claude.TranscriptCompressionGzip,
+	})
+	if err != nil {
+		t.Fatalf("NewTranscriptRecorder failed: %v", err)
+	}
+
+	if err := rec.Record(claude.TranscriptOutbound, []byte(`{"type":"user"}`+"\n")); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := rec.Record(claude.TranscriptInbound, []byte(`{"type":"result"}`)); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	files, _ := rec.Files()
+	if len(files) != 1 || !strings.HasSuffix(files[0], ".jsonl.gz") {
+		t.Fatalf("expected one .jsonl.gz file, got %v", files)
+	}
+
+	records := readTranscriptFile(t, files[0], true)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Direction != claude.TranscriptOutbound || string(records[0].Message) != `{"type":"user"}` {
+		t.Errorf("unexpected first record: %+v", records[0])
+	}
+	if records[1].Direction != claude.TranscriptInbound {
+		t.Errorf("unexpected second record: %+v", records[1])
+	}
+
+	if err := rec.Record(claude.TranscriptInbound, []byte(`{}`)); err == nil {
+		t.Error("expected error recording after Close")
+	}
+}
+
+func TestTranscriptRecorderRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	rec, err := claude.NewTranscriptRecorder(claude.TranscriptOptions{
+		Path:     path,
+		MaxBytes: 1, // Rotate after every record
+		MaxFiles: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewTranscriptRecorder failed: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := rec.Record(claude.TranscriptInbound, []byte(`{"n":`+string(rune('0'+i))+`}`)); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	rec.Close()
+
+	files, _ := rec.Files()
+	if len(files) != 2 {
+		t.Fatalf("expected 2 retained files, got %v", files)
+	}
+	last := readTranscriptFile(t, files[1], false)
+	if len(last) != 1 || string(last[0].Message) != `{"n":3}` {
+		t.Errorf("expected newest file to hold the last record, got %+v", last)
+	}
+}
+
+type nopCompressor struct{ io.Writer }
+
+func (nopCompressor) Close() error { return nil }
+
+func TestTranscriptRecorderCustomCompressor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	rec, err := claude.NewTranscriptRecorder(claude.TranscriptOptions{
+		Path:       path,
+		Compressor: func(w io.Writer) (io.WriteCloser, error) { return nopCompressor{w}, nil },
+		Extension:  ".zst",
+	})
+	if err != nil {
+		t.Fatalf("NewTranscriptRecorder failed: %v", err)
+	}
+	rec.Record(claude.TranscriptInbound, []byte(`{}`))
+	rec.Close()
+
+	files, _ := rec.Files()
+	if len(files) != 1 || !strings.HasSuffix(files[0], ".jsonl.zst") {
+		t.Errorf("expected one .jsonl.zst file, got %v", files)
+	}
+}
+
+func TestTranscriptRecorderValidation(t *testing.T) {
+	if _, err := claude.NewTranscriptRecorder(claude.TranscriptOptions{}); err == nil {
+		t.Error("expected error for missing path")
+	}
+	_, err := claude.NewTranscriptRecorder(claude.TranscriptOptions{
+		Path:        filepath.Join(t.TempDir(), "x.jsonl"),
+		Compression: "lz4",
+	})
+	if err == nil {
+		t.Error("expected error for unsupported compression")
+	}
+}
+
+func TestReadTranscript(t *testing.T) {
+	records, err := claude.ReadTranscript(strings.NewReader(
+		`{"ts":"2025-01-02T03:04:05Z","dir":"out","message":{"type":"user"}}` + "\n\n" +
+			`{"ts":"2025-01-02T03:04:06Z","dir":"in","message":{"type":"result"}}` + "\n"))
+	if err != nil {
+		t.Fatalf("ReadTranscript failed: %v", err)
+	}
+	if len(records) != 2 || records[0].Direction != claude.TranscriptOutbound || string(records[1].Message) != `{"type":"result"}` {
+		t.Errorf("unexpected records %+v", records)
+	}
+
+	if _, err := claude.ReadTranscript(strings.NewReader("{\"dir\":\"in\"}\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected an error naming line 2, got %v", err)
+	}
+}
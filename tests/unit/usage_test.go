@@ -0,0 +1,64 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestResultMessageParseUsage(t *testing.T) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"type": "result", "subtype": "success", "duration_ms": 1, "duration_api_ms": 1,
+		"is_error": false, "num_turns": 2, "session_id": "s1", "total_cost_usd": 0.05,
+		"usage": {
+			"input_tokens": 12, "output_tokens": 340,
+			"cache_creation_input_tokens": 2000, "cache_read_input_tokens": 15000,
+			"service_tier": "standard", "server_tool_use": {"web_search_requests": 1},
+			"future_field": {"x": 1}
+		},
+		"modelUsage": {
+			"claude-sonnet-4-5": {"inputTokens": 10, "outputTokens": 300, "cacheReadInputTokens": 15000,
+				"cacheCreationInputTokens": 2000, "webSearchRequests": 1, "costUSD": 0.045, "contextWindow": 200000},
+			"claude-haiku-4-5": {"inputTokens": 2, "outputTokens": 40, "costUSD": 0.005}
+		}
+	}`), &data); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := claude.ParseMessage(data)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	result := msg.(*claude.ResultMessage)
+
+	usage, err := result.ParseUsage()
+	if err != nil {
+		t.Fatalf("ParseUsage failed: %v", err)
+	}
+	if usage.InputTokens != 12 || usage.OutputTokens != 340 || usage.CacheCreationInputTokens != 2000 ||
+		usage.CacheReadInputTokens != 15000 || usage.ServiceTier != "standard" || usage.ServerToolUse.WebSearchRequests != 1 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+	if got := usage.TotalInputTokens(); got != 17012 {
+		t.Errorf("expected 17012 total input tokens, got %d", got)
+	}
+	sonnet := usage.Models["claude-sonnet-4-5"]
+	if len(usage.Models) != 2 || sonnet.OutputTokens != 300 || sonnet.CostUSD != 0.045 || sonnet.ContextWindow != 200000 {
+		t.Errorf("unexpected per-model usage: %+v", usage.Models)
+	}
+
+	// The raw maps keep fields the SDK does not know
+	if _, ok := result.Usage["future_field"]; !ok {
+		t.Error("expected the raw usage to be kept")
+	}
+
+	// No usage at all is a zero Usage
+	empty, err := (&claude.ResultMessage{}).ParseUsage()
+	if err != nil || empty.InputTokens != 0 || len(empty.Models) != 0 {
+		t.Errorf("expected a zero Usage, got %+v, %v", empty, err)
+	}
+	if _, err := claude.ParseUsage(map[string]interface{}{"input_tokens": "many"}); err == nil {
+		t.Error("expected an error for a malformed usage")
+	}
+}
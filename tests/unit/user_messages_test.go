@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestUserMessageHelpers(t *testing.T) {
+	isError := true
+	tests := []struct {
+		name    string
+		message map[string]interface{}
+		want    string
+	}{
+		{
+			name:    "text",
+			message: claude.NewUserMessage("hi"),
+			want:    `{"message":{"content":"hi","role":"user"},"parent_tool_use_id":null,"session_id":"default","type":"user"}`,
+		},
+		{
+			name:    "blocks",
+			message: claude.NewUserMessage([]map[string]interface{}{claude.NewTextBlock("look"), claude.NewImageBlock([]byte("png"), "image/png")}),
+			want:    `{"message":{"content":[{"text":"look","type":"text"},{"source":{"data":"cG5n","media_type":"image/png","type":"base64"},"type":"image"}],"role":"user"},"parent_tool_use_id":null,"session_id":"default","type":"user"}`,
+		},
+		{
+			name:    "tool result",
+			message: claude.NewToolResultMessage("toolu_1", "42", false),
+			want:    `{"message":{"content":[{"content":"42","tool_use_id":"toolu_1","type":"tool_result"}],"role":"user"},"parent_tool_use_id":null,"session_id":"default","type":"user"}`,
+		},
+		{
+			name:    "tool error",
+			message: claude.NewToolResultMessage("toolu_1", "not found", true),
+			want:    `{"message":{"content":[{"content":"not found","is_error":true,"tool_use_id":"toolu_1","type":"tool_result"}],"role":"user"},"parent_tool_use_id":null,"session_id":"default","type":"user"}`,
+		},
+		{
+			name: "parallel results",
+			message: claude.NewToolResultsMessage(
+				claude.ToolResultBlock{ToolUseID: "toolu_1", Content: []map[string]interface{}{claude.NewTextBlock("a")}},
+				claude.ToolResultBlock{ToolUseID: "toolu_2", IsError: &isError},
+			),
+			want: `{"message":{"content":[{"content":[{"text":"a","type":"text"}],"tool_use_id":"toolu_1","type":"tool_result"},{"is_error":true,"tool_use_id":"toolu_2","type":"tool_result"}],"role":"user"},"parent_tool_use_id":null,"session_id":"default","type":"user"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.message)
+			if err != nil {
+				t.Fatalf("marshal failed: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got  %s\nwant %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolResultMessageParsesAsUserMessage(t *testing.T) {
+	data, err := json.Marshal(claude.NewToolResultMessage("toolu_1", "42", true))
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	msg, err := claude.ParseMessage(raw)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	user, ok := msg.(*claude.UserMessage)
+	if !ok {
+		t.Fatalf("expected *UserMessage, got %T", msg)
+	}
+	blocks, ok := user.Content.([]claude.ContentBlock)
+	if !ok || len(blocks) != 1 {
+		t.Fatalf("expected one content block, got %#v", user.Content)
+	}
+	result, ok := blocks[0].(claude.ToolResultBlock)
+	if !ok || result.ToolUseID != "toolu_1" || result.Content != "42" || result.IsError == nil || !*result.IsError {
+		t.Errorf("unexpected tool result %#v", blocks[0])
+	}
+}
@@ -0,0 +1,56 @@
+package unit
+
+import (
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+
+	claude "github.com/clsx524/claude-agent-sdk-go"
+)
+
+func TestBuildInfo(t *testing.T) {
+	info := claude.ReadBuildInfo()
+	if info.Version != claude.Version() || info.Version == "" {
+		t.Errorf("expected Version %q, got %q", claude.Version(), info.Version)
+	}
+	if info.MinimumCLIVersion == "" || info.GoVersion != runtime.Version() {
+		t.Errorf("unexpected build info: %+v", info)
+	}
+}
+
+func TestAppIdentification(t *testing.T) {
+	result, err := claude.DryRun(nil, &claude.ClaudeAgentOptions{AppName: "billing bot", AppVersion: "2.3"})
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+
+	if got := result.Env["CLAUDE_CODE_ENTRYPOINT"]; got != "sdk-go-client billing-bot/2.3" {
+		t.Errorf("expected the app in the entrypoint, got %q", got)
+	}
+	ua := result.Env["CLAUDE_AGENT_SDK_USER_AGENT"]
+	if !strings.HasPrefix(ua, "claude-agent-sdk-go/"+claude.Version()+" (") || !strings.HasSuffix(ua, ") billing-bot/2.3") {
+		t.Errorf("unexpected user agent %q", ua)
+	}
+
+	var initialize map[string]interface{}
+	if err := json.Unmarshal([]byte(result.Stdin[0]), &initialize); err != nil {
+		t.Fatalf("invalid stdin line: %v", err)
+	}
+	request, _ := initialize["request"].(map[string]interface{})
+	sdk, _ := request["sdk"].(map[string]interface{})
+	if sdk["name"] != "claude-agent-sdk-go" || sdk["version"] != claude.Version() || sdk["user_agent"] != ua ||
+		sdk["app_name"] != "billing bot" || sdk["app_version"] != "2.3" {
+		t.Errorf("unexpected SDK info in the initialize request: %v", sdk)
+	}
+}
+
+func TestUserAgentWithoutApp(t *testing.T) {
+	result, err := claude.DryRun("hi", &claude.ClaudeAgentOptions{})
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if result.Env["CLAUDE_CODE_ENTRYPOINT"] != "sdk-go" || !strings.HasSuffix(result.Env["CLAUDE_AGENT_SDK_USER_AGENT"], "/"+runtime.GOARCH+")") {
+		t.Errorf("unexpected env: %v", result.Env)
+	}
+}
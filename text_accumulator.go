@@ -0,0 +1,172 @@
+package claude
+
+import (
+	"strings"
+	"sync"
+)
+
+// TextAccumulator stitches Claude's reply together from the messages of a
+// query, for UIs that render text as it arrives.
+//
+// Feed every message received from Query() or ReceiveMessages() into
+// Observe. With IncludePartialMessages the text grows with each text delta;
+// otherwise it grows by each assistant message's text. Either way the
+// complete AssistantMessage that follows its deltas is not counted twice.
+// Subagent output (messages with a ParentToolUseID) is ignored.
+//
+// Example:
+//
+//	acc := claude.NewTextAccumulator()
+//	acc.OnTextDelta = func(delta string) { fmt.Print(delta) }
+//	acc.OnToolUseStart = func(id, name string) { fmt.Printf("\n[%s]\n", name) }
+//	for msg := range msgCh {
+//	    acc.Observe(msg)
+//	}
+//	saveReply(acc.Text())
+//
+// TextAccumulator is safe for concurrent use. Set the callbacks before the
+// first Observe.
+type TextAccumulator struct {
+	// OnTextDelta is called with each piece of text appended to Text
+	OnTextDelta func(delta string)
+	// OnThinkingDelta is called with each piece of thinking appended to
+	// Thinking
+	OnThinkingDelta func(delta string)
+	// OnToolUseStart is called once per tool use, when its block starts
+	// streaming or, without partial messages, when its message arrives
+	OnToolUseStart func(id string, name string)
+
+	mu       sync.Mutex
+	text     strings.Builder
+	thinking strings.Builder
+	streamed bool // Deltas were seen for the current assistant message
+	started  map[string]bool
+}
+
+// NewTextAccumulator creates an empty TextAccumulator.
+func NewTextAccumulator() *TextAccumulator {
+	return &TextAccumulator{started: make(map[string]bool)}
+}
+
+// accumulated is one callback Observe owes its caller.
+type accumulated struct {
+	text, thinking string
+	toolID         string
+	toolName       string
+}
+
+// Observe adds the text, thinking, and tool uses in msg. Messages of other
+// types are ignored. Callbacks run on the calling goroutine before Observe
+// returns.
+func (a *TextAccumulator) Observe(msg Message) {
+	var pending []accumulated
+	a.mu.Lock()
+	switch m := msg.(type) {
+	case *StreamEvent:
+		if m.ParentToolUseID == nil {
+			pending = a.observeStreamEvent(m)
+		}
+	case *AssistantMessage:
+		// The complete message repeats the streamed deltas
+		if m.ParentToolUseID == nil && !a.streamed {
+			pending = a.observeBlocks(m.Content)
+		}
+		a.streamed = false
+	}
+	a.mu.Unlock()
+
+	for _, p := range pending {
+		switch {
+		case p.text != "" && a.OnTextDelta != nil:
+			a.OnTextDelta(p.text)
+		case p.thinking != "" && a.OnThinkingDelta != nil:
+			a.OnThinkingDelta(p.thinking)
+		case p.toolID != "" && a.OnToolUseStart != nil:
+			a.OnToolUseStart(p.toolID, p.toolName)
+		}
+	}
+}
+
+// observeStreamEvent adds a delta or tool use start. Callers hold mu.
+func (a *TextAccumulator) observeStreamEvent(m *StreamEvent) []accumulated {
+	event, err := m.Decode()
+	if err != nil {
+		return nil
+	}
+	switch e := event.(type) {
+	case *ContentBlockStartEvent:
+		a.streamed = true
+		if e.Type == "tool_use" {
+			return a.startToolUse(e.ID, e.Name)
+		}
+	case *ContentBlockDeltaEvent:
+		a.streamed = true
+		switch {
+		case e.Type == DeltaTypeText && e.Text != "":
+			a.text.WriteString(e.Text)
+			return []accumulated{{text: e.Text}}
+		case e.Type == DeltaTypeThinking && e.Thinking != "":
+			a.thinking.WriteString(e.Thinking)
+			return []accumulated{{thinking: e.Thinking}}
+		}
+	}
+	return nil
+}
+
+// observeBlocks adds the blocks of a message that was not streamed. Callers
+// hold mu.
+func (a *TextAccumulator) observeBlocks(blocks []ContentBlock) []accumulated {
+	var pending []accumulated
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case TextBlock:
+			if b.Text != "" {
+				a.text.WriteString(b.Text)
+				pending = append(pending, accumulated{text: b.Text})
+			}
+		case ThinkingBlock:
+			if b.Thinking != "" {
+				a.thinking.WriteString(b.Thinking)
+				pending = append(pending, accumulated{thinking: b.Thinking})
+			}
+		case ToolUseBlock:
+			pending = append(pending, a.startToolUse(b.ID, b.Name)...)
+		}
+	}
+	return pending
+}
+
+// startToolUse records a tool use the first time it is seen. Callers hold
+// mu.
+func (a *TextAccumulator) startToolUse(id string, name string) []accumulated {
+	if id == "" || a.started[id] {
+		return nil
+	}
+	a.started[id] = true
+	return []accumulated{{toolID: id, toolName: name}}
+}
+
+// Text returns the text accumulated so far.
+func (a *TextAccumulator) Text() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.text.String()
+}
+
+// Thinking returns the thinking accumulated so far.
+func (a *TextAccumulator) Thinking() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.thinking.String()
+}
+
+// Reset clears the accumulated text and thinking, e.g. between queries.
+// Callbacks are kept.
+func (a *TextAccumulator) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.text.Reset()
+	a.thinking.Reset()
+	a.streamed = false
+	a.started = make(map[string]bool)
+}
@@ -0,0 +1,114 @@
+package claude
+
+import (
+	"context"
+	"strings"
+)
+
+// QueryText sends prompt like Query and returns only the text of Claude's
+// reply, for UIs that print a response as it arrives. Tool calls, thinking,
+// subagent output, and the ResultMessage are not delivered.
+//
+// With IncludePartialMessages the channel receives each text delta as it is
+// streamed. Otherwise each assistant message's text is split into sentences,
+// so the reply still arrives in small chunks. Either way, concatenating the
+// chunks yields the complete text.
+//
+// Example:
+//
+//	textCh, errCh := client.QueryText(ctx, "Tell me a story")
+//	for chunk := range textCh {
+//	    fmt.Print(chunk)
+//	}
+//	if err := <-errCh; err != nil {
+//	    log.Fatal(err)
+//	}
+func (c *ClaudeSDKClient) QueryText(ctx context.Context, prompt string) (<-chan string, <-chan error) {
+	msgCh, errCh := c.Query(ctx, prompt)
+	return textChunks(ctx, msgCh, outputBufferSize(c.connection().options)), errCh
+}
+
+// QueryText is ClaudeSDKClient.QueryText for this session.
+func (s *Session) QueryText(ctx context.Context, prompt string) (<-chan string, <-chan error) {
+	msgCh, errCh := s.Query(ctx, prompt)
+	return textChunks(ctx, msgCh, outputBufferSize(s.client.options)), errCh
+}
+
+// textChunks returns the text of msgCh's reply, as QueryText describes.
+func textChunks(ctx context.Context, msgCh <-chan Message, bufferSize int) <-chan string {
+	textCh := make(chan string, bufferSize)
+	go func() {
+		defer close(textCh)
+		streamed := false // Text deltas were seen for the current assistant message
+		for msg := range msgCh {
+			var chunks []string
+			switch m := msg.(type) {
+			case *StreamEvent:
+				if text, ok := streamEventText(m); ok {
+					streamed = true
+					chunks = []string{text}
+				}
+			case *AssistantMessage:
+				// The complete message repeats the streamed deltas
+				if !streamed && m.ParentToolUseID == nil {
+					for _, block := range m.Content {
+						if text, ok := block.(TextBlock); ok {
+							chunks = append(chunks, splitSentences(text.Text)...)
+						}
+					}
+				}
+				streamed = false
+			}
+			for _, chunk := range chunks {
+				select {
+				case textCh <- chunk:
+				case <-ctx.Done():
+					// Drain so the query can finish and report ctx.Err()
+					for range msgCh {
+					}
+					return
+				}
+			}
+		}
+	}()
+	return textCh
+}
+
+// streamEventText returns the text of a top-level text_delta event.
+func streamEventText(event *StreamEvent) (string, bool) {
+	if event.ParentToolUseID != nil || event.Event["type"] != "content_block_delta" {
+		return "", false
+	}
+	delta, _ := event.Event["delta"].(map[string]interface{})
+	if delta["type"] != "text_delta" {
+		return "", false
+	}
+	text, _ := delta["text"].(string)
+	return text, text != ""
+}
+
+// splitSentences splits text after each sentence end or line break. Trailing
+// whitespace stays with its sentence, so the chunks join back into text.
+func splitSentences(text string) []string {
+	var chunks []string
+	start := 0
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\n' && (strings.IndexByte(".!?", text[i]) < 0 || i+1 == len(text) || !isSentenceSpace(text[i+1])) {
+			continue
+		}
+		// Keep the following whitespace with this sentence
+		for i+1 < len(text) && isSentenceSpace(text[i+1]) {
+			i++
+		}
+		chunks = append(chunks, text[start:i+1])
+		start = i + 1
+	}
+	if start < len(text) {
+		chunks = append(chunks, text[start:])
+	}
+	return chunks
+}
+
+func isSentenceSpace(b byte) bool {
+	return b == ' ' || b == '\n'
+}
@@ -0,0 +1,225 @@
+package claude
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// TimelineEventKind identifies what the agent was doing during a TimelineEvent.
+type TimelineEventKind string
+
+const (
+	TimelineEventThinking   TimelineEventKind = "thinking"   // Extended thinking
+	TimelineEventText       TimelineEventKind = "text"       // Text output
+	TimelineEventToolCall   TimelineEventKind = "tool_call"  // From tool use to its result
+	TimelineEventTurn       TimelineEventKind = "turn"       // A whole turn, ending at its ResultMessage
+	TimelineEventCompaction TimelineEventKind = "compaction" // Where the CLI compacted the conversation (zero duration)
+)
+
+// TimelineEvent is one span of agent activity.
+type TimelineEvent struct {
+	Kind            TimelineEventKind `json:"kind"`
+	Start           time.Time         `json:"start"`
+	End             time.Time         `json:"end,omitzero"` // Zero while a tool call is running
+	DurationMS      int64             `json:"duration_ms"`
+	ToolName        string            `json:"tool_name,omitempty"`
+	ToolUseID       string            `json:"tool_use_id,omitempty"`
+	IsError         bool              `json:"is_error,omitempty"`
+	ParentToolUseID *string           `json:"parent_tool_use_id,omitempty"` // Set for subagent activity
+}
+
+// Timeline is an ordered record of what the agent did and where time went,
+// for rendering Gantt-style views. Events are ordered by start time.
+type Timeline struct {
+	Events []TimelineEvent `json:"events"`
+}
+
+// JSON returns the timeline encoded as JSON.
+func (t Timeline) JSON() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// timelineBuilder derives a Timeline from messages as they are received.
+//
+// With ClaudeAgentOptions.IncludePartialMessages, thinking and text spans are
+// timed by their stream events. Otherwise a block spans from the previous
+// message (or the prompt) to the message that contains it. Tool calls span
+// from the tool use to its result.
+type timelineBuilder struct {
+	mu     sync.Mutex
+	events []TimelineEvent
+	cursor time.Time            // End of the last observed activity
+	tools  map[string]int       // Running tool calls by tool use ID, as indexes into events
+	blocks map[blockKey]blockAt // Streamed content blocks that have not stopped
+	// Content streamed since the last AssistantMessage, which then repeats it
+	streamed bool
+	timezone *time.Location // Of snapshot times; nil for local time
+}
+
+type blockKey struct {
+	parent string
+	index  int
+}
+
+type blockAt struct {
+	kind  TimelineEventKind
+	start time.Time
+}
+
+// newTimelineBuilder returns a builder if options.RecordTimeline is set, or nil.
+func newTimelineBuilder(options *ClaudeAgentOptions) *timelineBuilder {
+	if options == nil || !options.RecordTimeline {
+		return nil
+	}
+	return &timelineBuilder{tools: make(map[string]int), blocks: make(map[blockKey]blockAt), timezone: options.Timezone}
+}
+
+// markInput records that a prompt was sent at.
+func (b *timelineBuilder) markInput(at time.Time) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cursor = at
+}
+
+// observe records msg, received at.
+func (b *timelineBuilder) observe(msg Message, at time.Time) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch m := msg.(type) {
+	case *StreamEvent:
+		b.observeStreamEvent(m, at)
+		return
+	case *AssistantMessage:
+		for _, block := range m.Content {
+			switch block := block.(type) {
+			case ThinkingBlock:
+				if !b.streamed {
+					b.add(TimelineEvent{Kind: TimelineEventThinking, ParentToolUseID: m.ParentToolUseID}, b.since(at), at)
+				}
+			case TextBlock:
+				if !b.streamed {
+					b.add(TimelineEvent{Kind: TimelineEventText, ParentToolUseID: m.ParentToolUseID}, b.since(at), at)
+				}
+			case ToolUseBlock:
+				if _, running := b.tools[block.ID]; !running {
+					b.tools[block.ID] = len(b.events)
+					b.events = append(b.events, TimelineEvent{
+						Kind: TimelineEventToolCall, Start: at, ToolName: block.Name,
+						ToolUseID: block.ID, ParentToolUseID: m.ParentToolUseID,
+					})
+				}
+			}
+		}
+		b.streamed = false
+	case *UserMessage:
+		blocks, _ := m.Content.([]ContentBlock)
+		for _, block := range blocks {
+			if result, ok := block.(ToolResultBlock); ok {
+				if i, running := b.tools[result.ToolUseID]; running {
+					delete(b.tools, result.ToolUseID)
+					b.events[i].End = at
+					b.events[i].DurationMS = at.Sub(b.events[i].Start).Milliseconds()
+					b.events[i].IsError = result.IsError != nil && *result.IsError
+				}
+			}
+		}
+	case *SystemMessage:
+		if isCompactBoundary(m) {
+			b.add(TimelineEvent{Kind: TimelineEventCompaction}, at, at)
+		}
+	case *ResultMessage:
+		start := at.Add(-time.Duration(m.DurationMS) * time.Millisecond)
+		b.add(TimelineEvent{Kind: TimelineEventTurn, IsError: m.IsError}, start, at)
+	}
+	b.cursor = at
+}
+
+// observeStreamEvent times thinking and text blocks from their start and
+// stop events.
+func (b *timelineBuilder) observeStreamEvent(m *StreamEvent, at time.Time) {
+	index, _ := m.Event["index"].(float64)
+	key := blockKey{index: int(index)}
+	if m.ParentToolUseID != nil {
+		key.parent = *m.ParentToolUseID
+	}
+
+	switch m.Event["type"] {
+	case "content_block_start":
+		block, _ := m.Event["content_block"].(map[string]interface{})
+		switch block["type"] {
+		case "thinking":
+			b.blocks[key] = blockAt{kind: TimelineEventThinking, start: at}
+		case "text":
+			b.blocks[key] = blockAt{kind: TimelineEventText, start: at}
+		}
+	case "content_block_stop":
+		if open, ok := b.blocks[key]; ok {
+			delete(b.blocks, key)
+			b.add(TimelineEvent{Kind: open.kind, ParentToolUseID: m.ParentToolUseID}, open.start, at)
+			b.streamed = true
+		}
+	}
+}
+
+// since returns the start of a span ending at at: the end of the last
+// activity, or at itself if there was none.
+func (b *timelineBuilder) since(at time.Time) time.Time {
+	if b.cursor.IsZero() || b.cursor.After(at) {
+		return at
+	}
+	return b.cursor
+}
+
+// add appends a finished event, keeping events ordered by start time.
+func (b *timelineBuilder) add(event TimelineEvent, start, end time.Time) {
+	event.Start, event.End = start, end
+	event.DurationMS = end.Sub(start).Milliseconds()
+
+	i := len(b.events)
+	for i > 0 && b.events[i-1].Start.After(start) {
+		i--
+	}
+	b.events = append(b.events, TimelineEvent{})
+	copy(b.events[i+1:], b.events[i:])
+	b.events[i] = event
+	// Inserting before running tool calls shifts their indexes
+	for id, j := range b.tools {
+		if j >= i {
+			b.tools[id] = j + 1
+		}
+	}
+}
+
+// snapshot returns a copy of the timeline.
+func (b *timelineBuilder) snapshot() *Timeline {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	events := append([]TimelineEvent{}, b.events...)
+	for i := range events {
+		events[i].Start = inTimezone(events[i].Start, b.timezone)
+		events[i].End = inTimezone(events[i].End, b.timezone)
+	}
+	return &Timeline{Events: events}
+}
+
+// Timeline returns what the agent has done so far in this client's session:
+// thinking, text, and tool calls with their start and end times, plus one
+// event per completed turn. Tool calls still running have a zero End.
+//
+// Recording is disabled unless ClaudeAgentOptions.RecordTimeline is set, in
+// which case Timeline covers messages received via ReceiveMessages(),
+// ReceiveResponse(), or Query(). Returns nil if recording is disabled.
+func (c *ClaudeSDKClient) Timeline() *Timeline {
+	return c.timeline.snapshot()
+}
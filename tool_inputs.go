@@ -0,0 +1,277 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ToolInput is the typed input of a built-in tool, as returned by
+// ParseToolInput.
+type ToolInput interface {
+	// ToolName returns the name of the tool the input belongs to.
+	ToolName() string
+}
+
+// BashInput is the input of the built-in Bash tool.
+type BashInput struct {
+	Command                   string `json:"command"`
+	Timeout                   *int   `json:"timeout,omitempty"` // Milliseconds
+	Description               string `json:"description,omitempty"`
+	RunInBackground           bool   `json:"run_in_background,omitempty"`
+	DangerouslyDisableSandbox bool   `json:"dangerouslyDisableSandbox,omitempty"`
+}
+
+// BashOutputInput is the input of the built-in BashOutput tool, which reads
+// the output of a background shell.
+type BashOutputInput struct {
+	BashID string `json:"bash_id"`
+	Filter string `json:"filter,omitempty"` // Regular expression selecting lines
+}
+
+// KillShellInput is the input of the built-in KillShell tool.
+type KillShellInput struct {
+	ShellID string `json:"shell_id"`
+}
+
+// ReadInput is the input of the built-in Read tool.
+type ReadInput struct {
+	FilePath string `json:"file_path"`
+	Offset   *int   `json:"offset,omitempty"` // First line to read
+	Limit    *int   `json:"limit,omitempty"`  // Number of lines to read
+}
+
+// WriteInput is the input of the built-in Write tool.
+type WriteInput struct {
+	FilePath string `json:"file_path"`
+	Content  string `json:"content"`
+}
+
+// EditInput is the input of the built-in Edit tool.
+type EditInput struct {
+	FilePath   string `json:"file_path"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// MultiEditInput is the input of the built-in MultiEdit tool, which applies
+// edits to one file in order.
+type MultiEditInput struct {
+	FilePath string          `json:"file_path"`
+	Edits    []MultiEditEdit `json:"edits"`
+}
+
+// MultiEditEdit is one edit of a MultiEditInput.
+type MultiEditEdit struct {
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// GlobInput is the input of the built-in Glob tool.
+type GlobInput struct {
+	Pattern string `json:"pattern"`
+	Path    string `json:"path,omitempty"`
+}
+
+// GrepInput is the input of the built-in Grep tool.
+type GrepInput struct {
+	Pattern         string `json:"pattern"`
+	Path            string `json:"path,omitempty"`
+	Glob            string `json:"glob,omitempty"`
+	Type            string `json:"type,omitempty"`
+	OutputMode      string `json:"output_mode,omitempty"` // "content", "files_with_matches", or "count"
+	CaseInsensitive bool   `json:"-i,omitempty"`
+	LineNumbers     *bool  `json:"-n,omitempty"` // Content mode only
+	After           *int   `json:"-A,omitempty"` // Context lines after each match
+	Before          *int   `json:"-B,omitempty"` // Context lines before each match
+	Context         *int   `json:"-C,omitempty"` // Context lines around each match
+	HeadLimit       *int   `json:"head_limit,omitempty"`
+	Multiline       bool   `json:"multiline,omitempty"`
+}
+
+// NotebookEditInput is the input of the built-in NotebookEdit tool.
+type NotebookEditInput struct {
+	NotebookPath string `json:"notebook_path"`
+	CellID       string `json:"cell_id,omitempty"`
+	NewSource    string `json:"new_source"`
+	CellType     string `json:"cell_type,omitempty"` // "code" or "markdown"
+	EditMode     string `json:"edit_mode,omitempty"` // "replace", "insert", or "delete"
+}
+
+// WebFetchInput is the input of the built-in WebFetch tool.
+type WebFetchInput struct {
+	URL    string `json:"url"`
+	Prompt string `json:"prompt"` // What to extract from the page
+}
+
+// WebSearchInput is the input of the built-in WebSearch tool.
+type WebSearchInput struct {
+	Query          string   `json:"query"`
+	AllowedDomains []string `json:"allowed_domains,omitempty"`
+	BlockedDomains []string `json:"blocked_domains,omitempty"`
+}
+
+// TodoWriteInput is the input of the built-in TodoWrite tool: the whole
+// updated todo list.
+type TodoWriteInput struct {
+	Todos []TodoItem `json:"todos"`
+}
+
+// TodoItem is one entry of a TodoWriteInput.
+type TodoItem struct {
+	Content    string `json:"content"`
+	Status     string `json:"status"` // "pending", "in_progress", or "completed"
+	ActiveForm string `json:"activeForm"`
+}
+
+// TaskInput is the input of the built-in Task tool, which runs a subagent.
+type TaskInput struct {
+	Description  string `json:"description"`
+	Prompt       string `json:"prompt"`
+	SubagentType string `json:"subagent_type"`
+}
+
+// ExitPlanModeInput is the input of the built-in ExitPlanMode tool.
+type ExitPlanModeInput struct {
+	Plan string `json:"plan"`
+}
+
+// ListMcpResourcesInput is the input of the built-in ListMcpResourcesTool.
+type ListMcpResourcesInput struct {
+	Server string `json:"server,omitempty"` // All servers if empty
+}
+
+// ReadMcpResourceInput is the input of the built-in ReadMcpResourceTool.
+type ReadMcpResourceInput struct {
+	Server string `json:"server"`
+	URI    string `json:"uri"`
+}
+
+func (BashInput) ToolName() string             { return "Bash" }
+func (BashOutputInput) ToolName() string       { return "BashOutput" }
+func (KillShellInput) ToolName() string        { return "KillShell" }
+func (ReadInput) ToolName() string             { return "Read" }
+func (WriteInput) ToolName() string            { return "Write" }
+func (EditInput) ToolName() string             { return "Edit" }
+func (MultiEditInput) ToolName() string        { return "MultiEdit" }
+func (GlobInput) ToolName() string             { return "Glob" }
+func (GrepInput) ToolName() string             { return "Grep" }
+func (NotebookEditInput) ToolName() string     { return "NotebookEdit" }
+func (WebFetchInput) ToolName() string         { return "WebFetch" }
+func (WebSearchInput) ToolName() string        { return "WebSearch" }
+func (TodoWriteInput) ToolName() string        { return "TodoWrite" }
+func (TaskInput) ToolName() string             { return "Task" }
+func (ExitPlanModeInput) ToolName() string     { return "ExitPlanMode" }
+func (ListMcpResourcesInput) ToolName() string { return "ListMcpResourcesTool" }
+func (ReadMcpResourceInput) ToolName() string  { return "ReadMcpResourceTool" }
+
+// builtinToolInputs creates an empty typed input by built-in tool name.
+var builtinToolInputs = map[string]func() ToolInput{
+	"Bash":                 func() ToolInput { return &BashInput{} },
+	"BashOutput":           func() ToolInput { return &BashOutputInput{} },
+	"KillShell":            func() ToolInput { return &KillShellInput{} },
+	"Read":                 func() ToolInput { return &ReadInput{} },
+	"Write":                func() ToolInput { return &WriteInput{} },
+	"Edit":                 func() ToolInput { return &EditInput{} },
+	"MultiEdit":            func() ToolInput { return &MultiEditInput{} },
+	"Glob":                 func() ToolInput { return &GlobInput{} },
+	"Grep":                 func() ToolInput { return &GrepInput{} },
+	"NotebookEdit":         func() ToolInput { return &NotebookEditInput{} },
+	"WebFetch":             func() ToolInput { return &WebFetchInput{} },
+	"WebSearch":            func() ToolInput { return &WebSearchInput{} },
+	"TodoWrite":            func() ToolInput { return &TodoWriteInput{} },
+	"Task":                 func() ToolInput { return &TaskInput{} },
+	"ExitPlanMode":         func() ToolInput { return &ExitPlanModeInput{} },
+	"ListMcpResourcesTool": func() ToolInput { return &ListMcpResourcesInput{} },
+	"ReadMcpResourceTool":  func() ToolInput { return &ReadMcpResourceInput{} },
+}
+
+// ParseToolInput decodes the raw input of a built-in tool, as passed to
+// CanUseTool and in hook inputs' "tool_input", into its typed input, e.g. a
+// *BashInput for "Bash". It fails for MCP and other tools without a typed
+// input, and for input that does not decode.
+//
+// Example:
+//
+//	input, err := claude.ParseToolInput(toolName, rawInput)
+//	if err != nil { ... }
+//	switch input := input.(type) {
+//	case *claude.BashInput:
+//	    if strings.Contains(input.Command, "rm -rf") { ... }
+//	case *claude.WriteInput, *claude.EditInput:
+//	    ...
+//	}
+func ParseToolInput(toolName string, input map[string]interface{}) (ToolInput, error) {
+	newInput, ok := builtinToolInputs[toolName]
+	if !ok {
+		return nil, fmt.Errorf("no typed input for tool %q", toolName)
+	}
+	target := newInput()
+	if err := DecodeToolInput(input, target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// DecodeToolInput decodes a raw tool input, as passed to CanUseTool and in
+// hook inputs' "tool_input", into target, e.g. a *BashInput.
+func DecodeToolInput(input map[string]interface{}, target interface{}) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode tool input: %w", err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to decode tool input: %w", err)
+	}
+	return nil
+}
+
+// AsBash returns the input of a Bash tool use. ok is false if the block is
+// not a Bash tool use or its input does not decode.
+func (b ToolUseBlock) AsBash() (input *BashInput, ok bool) {
+	return asToolInput[BashInput](b, "Bash")
+}
+
+// AsRead returns the input of a Read tool use.
+func (b ToolUseBlock) AsRead() (input *ReadInput, ok bool) {
+	return asToolInput[ReadInput](b, "Read")
+}
+
+// AsWrite returns the input of a Write tool use.
+func (b ToolUseBlock) AsWrite() (input *WriteInput, ok bool) {
+	return asToolInput[WriteInput](b, "Write")
+}
+
+// AsEdit returns the input of an Edit tool use.
+func (b ToolUseBlock) AsEdit() (input *EditInput, ok bool) {
+	return asToolInput[EditInput](b, "Edit")
+}
+
+// AsGlob returns the input of a Glob tool use.
+func (b ToolUseBlock) AsGlob() (input *GlobInput, ok bool) {
+	return asToolInput[GlobInput](b, "Glob")
+}
+
+// AsGrep returns the input of a Grep tool use.
+func (b ToolUseBlock) AsGrep() (input *GrepInput, ok bool) {
+	return asToolInput[GrepInput](b, "Grep")
+}
+
+// ParseInput returns the typed input of a built-in tool use; see
+// ParseToolInput.
+func (b ToolUseBlock) ParseInput() (ToolInput, error) {
+	return ParseToolInput(b.Name, b.Input)
+}
+
+// asToolInput decodes b's input into T if b is a use of toolName.
+func asToolInput[T any](b ToolUseBlock, toolName string) (*T, bool) {
+	if b.Name != toolName {
+		return nil, false
+	}
+	var input T
+	if err := DecodeToolInput(b.Input, &input); err != nil {
+		return nil, false
+	}
+	return &input, true
+}
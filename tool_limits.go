@@ -0,0 +1,178 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ToolLimit caps how often and how many times at once a tool may run, to
+// protect external systems from runaway agent loops. Zero fields are
+// unlimited. Calls over a limit are denied with a reason Claude can act on.
+//
+// Example: at most 2 Bash calls per minute and 1 concurrent WebFetch:
+//
+//	options := &claude.ClaudeAgentOptions{
+//	    ToolLimits: map[string]claude.ToolLimit{
+//	        "Bash":     {MaxCalls: 2, Window: time.Minute},
+//	        "WebFetch": {MaxConcurrent: 1},
+//	    },
+//	}
+type ToolLimit struct {
+	MaxCalls      int           // Calls allowed per Window
+	Window        time.Duration // Rate limit window (default: 1 minute)
+	MaxConcurrent int           // Calls allowed to run at the same time
+}
+
+// defaultToolLimitWindow is the rate limit window when ToolLimit.Window is unset.
+const defaultToolLimitWindow = time.Minute
+
+// toolLimiter enforces ToolLimits. A call takes a concurrency slot in its
+// PreToolUse hook and gives it back in PostToolUse; slots of calls that never
+// ran (e.g. denied by another hook or CanUseTool) are given back when the turn
+// stops.
+type toolLimiter struct {
+	mu      sync.Mutex
+	limits  map[string]ToolLimit
+	calls   map[string][]time.Time // Recent call times per tool, oldest first
+	running map[string]string      // Tool name by tool use ID
+	now     func() time.Time
+}
+
+func newToolLimiter(limits map[string]ToolLimit) *toolLimiter {
+	return &toolLimiter{
+		limits:  limits,
+		calls:   make(map[string][]time.Time),
+		running: make(map[string]string),
+		now:     time.Now,
+	}
+}
+
+// acquire records a call of toolName and returns "" if it is within limits,
+// or the reason it must be denied.
+func (l *toolLimiter) acquire(toolName, toolUseID string) string {
+	limit, ok := l.limits[toolName]
+	if !ok {
+		return ""
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+
+	if limit.MaxConcurrent > 0 {
+		inFlight := 0
+		for _, name := range l.running {
+			if name == toolName {
+				inFlight++
+			}
+		}
+		if inFlight >= limit.MaxConcurrent {
+			return fmt.Sprintf("%s concurrency limit reached (%d running); wait for a running call to finish", toolName, inFlight)
+		}
+	}
+
+	if limit.MaxCalls > 0 {
+		window := limit.Window
+		if window <= 0 {
+			window = defaultToolLimitWindow
+		}
+		recent := l.calls[toolName]
+		for len(recent) > 0 && now.Sub(recent[0]) >= window {
+			recent = recent[1:]
+		}
+		l.calls[toolName] = recent
+		if len(recent) >= limit.MaxCalls {
+			retry := window - now.Sub(recent[0])
+			return fmt.Sprintf("%s rate limit reached (%d calls per %s); retry in %s",
+				toolName, limit.MaxCalls, window, retry.Round(time.Second))
+		}
+		l.calls[toolName] = append(recent, now)
+	}
+
+	if toolUseID != "" {
+		l.running[toolUseID] = toolName
+	}
+	return ""
+}
+
+// release gives back the concurrency slot of toolUseID.
+func (l *toolLimiter) release(toolUseID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.running, toolUseID)
+}
+
+// releaseAll gives back every concurrency slot.
+func (l *toolLimiter) releaseAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.running)
+}
+
+// matcher returns a hook matcher pattern for the limited tools.
+func (l *toolLimiter) matcher() string {
+	names := make([]string, 0, len(l.limits))
+	for name := range l.limits {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+// applyToolLimits returns a copy of options whose hooks enforce
+// options.ToolLimits ahead of the user's hooks. Limits need the control
+// protocol, so they require streaming mode.
+func applyToolLimits(options *ClaudeAgentOptions, isStreaming bool) (*ClaudeAgentOptions, error) {
+	if !isStreaming {
+		return nil, fmt.Errorf("tool limits require streaming mode")
+	}
+	limiter := newToolLimiter(options.ToolLimits)
+
+	pre := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
+		toolName, _ := input["tool_name"].(string)
+		reason := limiter.acquire(toolName, stringValue(toolUseID))
+		if reason == "" {
+			return HookJSONOutput{}, nil
+		}
+		return DenyToolUse(reason), nil
+	}
+	post := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
+		limiter.release(stringValue(toolUseID))
+		return HookJSONOutput{}, nil
+	}
+	stop := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
+		limiter.releaseAll()
+		return HookJSONOutput{}, nil
+	}
+
+	newOpts := *options
+	newOpts.Hooks = make(map[HookEvent][]HookMatcher, len(options.Hooks)+3)
+	for event, matchers := range options.Hooks {
+		newOpts.Hooks[event] = matchers
+	}
+	matcher := limiter.matcher()
+	newOpts.Hooks[HookEventPreToolUse] = append(
+		[]HookMatcher{{Matcher: matcher, Hooks: []HookCallback{pre}}},
+		options.Hooks[HookEventPreToolUse]...,
+	)
+	newOpts.Hooks[HookEventPostToolUse] = append(
+		[]HookMatcher{{Matcher: matcher, Hooks: []HookCallback{post}}},
+		options.Hooks[HookEventPostToolUse]...,
+	)
+	newOpts.Hooks[HookEventStop] = append(
+		[]HookMatcher{{Hooks: []HookCallback{stop}}},
+		options.Hooks[HookEventStop]...,
+	)
+	return &newOpts, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
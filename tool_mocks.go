@@ -0,0 +1,158 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ToolMockHandler returns the canned result of a mocked tool call.
+type ToolMockHandler func(ctx context.Context, input map[string]interface{}) string
+
+// ToolMockCall is a tool call answered by ToolMocks.
+type ToolMockCall struct {
+	ToolName  string
+	ToolUseID string
+	Input     map[string]interface{}
+	Result    string
+}
+
+// ToolMocks answers calls of chosen tools with canned results instead of
+// running them, so tests of agent behavior run deterministically and
+// offline against the real CLI. Set it as ClaudeAgentOptions.ToolMocks.
+//
+// Mocks are enforced with a PreToolUse hook that runs before any other hook
+// or CanUseTool, so they require streaming mode. The hook prevents the call
+// and gives Claude the result in its place; the CLI reports it as an error
+// tool result, since the tool did not run, but Claude reads its text as the
+// tool's output.
+//
+// Example:
+//
+//	mocks := claude.NewToolMocks().
+//	    Return("WebFetch", "<html><title>Release notes</title>v2.1 adds batching</html>").
+//	    Handle("Bash", func(ctx context.Context, input map[string]interface{}) string {
+//	        if input["command"] == "go test ./..." {
+//	            return "ok  example.com/app 0.2s"
+//	        }
+//	        return "command not found"
+//	    }).
+//	    DenyUnmocked()
+//	options := &claude.ClaudeAgentOptions{ToolMocks: mocks}
+//	...
+//	if calls := mocks.Calls("WebFetch"); len(calls) != 1 { ... }
+type ToolMocks struct {
+	mu           sync.Mutex
+	handlers     map[string]ToolMockHandler
+	denyUnmocked bool
+	calls        []ToolMockCall
+}
+
+// NewToolMocks returns ToolMocks with no tools mocked.
+func NewToolMocks() *ToolMocks {
+	return &ToolMocks{handlers: make(map[string]ToolMockHandler)}
+}
+
+// Return mocks toolName with a fixed result.
+func (m *ToolMocks) Return(toolName, result string) *ToolMocks {
+	return m.Handle(toolName, func(context.Context, map[string]interface{}) string { return result })
+}
+
+// Handle mocks toolName with handler, which computes each call's result
+// from its input. Handlers may be called concurrently.
+func (m *ToolMocks) Handle(toolName string, handler ToolMockHandler) *ToolMocks {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[toolName] = handler
+	return m
+}
+
+// DenyUnmocked makes calls of tools without a mock fail instead of running,
+// so a test cannot reach the network or the file system by accident.
+func (m *ToolMocks) DenyUnmocked() *ToolMocks {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.denyUnmocked = true
+	return m
+}
+
+// Calls returns the mocked calls of toolName in the order they were made,
+// or of every tool if toolName is "".
+func (m *ToolMocks) Calls(toolName string) []ToolMockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var calls []ToolMockCall
+	for _, call := range m.calls {
+		if toolName == "" || call.ToolName == toolName {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// answer returns the hook output for a call of toolName: a denial carrying
+// the canned result, or empty output to let the call run.
+func (m *ToolMocks) answer(ctx context.Context, toolName, toolUseID string, input map[string]interface{}) HookJSONOutput {
+	m.mu.Lock()
+	handler, mocked := m.handlers[toolName]
+	denyUnmocked := m.denyUnmocked
+	m.mu.Unlock()
+
+	if !mocked {
+		if denyUnmocked {
+			return DenyToolUse(fmt.Sprintf("%s is not mocked in this test", toolName))
+		}
+		return HookJSONOutput{}
+	}
+	result := handler(ctx, input)
+	m.mu.Lock()
+	m.calls = append(m.calls, ToolMockCall{ToolName: toolName, ToolUseID: toolUseID, Input: input, Result: result})
+	m.mu.Unlock()
+	return DenyToolUse(result)
+}
+
+// matcher returns a hook matcher pattern for the mocked tools, or "" for
+// every tool.
+func (m *ToolMocks) matcher() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.denyUnmocked {
+		return ""
+	}
+	names := make([]string, 0, len(m.handlers))
+	for name := range m.handlers {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+// applyToolMocks returns a copy of options whose first PreToolUse hook
+// answers options.ToolMocks. Tools mocked after this are only matched if
+// DenyUnmocked is set, so configure mocks before connecting.
+func applyToolMocks(options *ClaudeAgentOptions, isStreaming bool) (*ClaudeAgentOptions, error) {
+	if !isStreaming {
+		return nil, fmt.Errorf("tool mocks require streaming mode")
+	}
+	mocks := options.ToolMocks
+
+	mock := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
+		toolName, _ := input["tool_name"].(string)
+		toolInput, _ := input["tool_input"].(map[string]interface{})
+		return mocks.answer(ctx, toolName, stringValue(toolUseID), toolInput), nil
+	}
+
+	newOpts := *options
+	newOpts.Hooks = make(map[HookEvent][]HookMatcher, len(options.Hooks)+1)
+	for event, matchers := range options.Hooks {
+		newOpts.Hooks[event] = matchers
+	}
+	newOpts.Hooks[HookEventPreToolUse] = append(
+		[]HookMatcher{{Matcher: mocks.matcher(), Hooks: []HookCallback{mock}}},
+		options.Hooks[HookEventPreToolUse]...,
+	)
+	return &newOpts, nil
+}
@@ -0,0 +1,149 @@
+package claude
+
+import "regexp"
+
+// ToolResultFilter rewrites the text of a tool result, e.g. to mask secrets.
+// It receives one text value at a time and returns it unchanged if nothing
+// matched. It must be safe for concurrent use.
+type ToolResultFilter func(text string) string
+
+// RedactedPlaceholder replaces content masked by RedactSecrets.
+const RedactedPlaceholder = "[REDACTED]"
+
+// DefaultSecretPatterns match common credential formats. For assignments
+// such as API_KEY=... only the value is masked.
+var DefaultSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`),             // Anthropic
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{32,}`),                 // OpenAI-style
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`),           // AWS access key ID
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),          // GitHub
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),          // Slack
+	regexp.MustCompile(`(?i)\bBearer\s+[A-Za-z0-9._~+/-]{20,}=*`), // Authorization headers
+	regexp.MustCompile(`(?im)^\s*(?:export\s+)?[A-Z0-9_]*(?:SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|PRIVATE_KEY|CREDENTIALS?)[A-Z0-9_]*\s*[=:]\s*(\S.*)$`),
+}
+
+// RedactSecrets returns a ToolResultFilter that replaces matches of patterns
+// (DefaultSecretPatterns if none are given) with RedactedPlaceholder. For
+// patterns with a capture group, only the first group is replaced.
+//
+// Example:
+//
+//	options := &claude.ClaudeAgentOptions{ToolResultFilter: claude.RedactSecrets()}
+func RedactSecrets(patterns ...*regexp.Regexp) ToolResultFilter {
+	if len(patterns) == 0 {
+		patterns = DefaultSecretPatterns
+	}
+	return func(text string) string {
+		for _, pattern := range patterns {
+			text = redactMatches(pattern, text)
+		}
+		return text
+	}
+}
+
+func redactMatches(pattern *regexp.Regexp, text string) string {
+	if pattern.NumSubexp() == 0 {
+		return pattern.ReplaceAllLiteralString(text, RedactedPlaceholder)
+	}
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+	var out []byte
+	last := 0
+	for _, m := range matches {
+		start, end := m[2], m[3]
+		if start < 0 {
+			continue
+		}
+		out = append(out, text[last:start]...)
+		out = append(out, RedactedPlaceholder...)
+		last = end
+	}
+	out = append(out, text[last:]...)
+	return string(out)
+}
+
+// filterToolResults applies filter to the tool results of a raw user
+// message from the CLI, in place: string content and text blocks of every
+// tool_result, and string values in tool_use_result.
+func filterToolResults(filter ToolResultFilter, msg map[string]interface{}) {
+	if filter == nil || msg["type"] != "user" {
+		return
+	}
+	if structured, ok := msg["tool_use_result"]; ok {
+		msg["tool_use_result"] = filterStrings(filter, structured)
+	}
+	inner, _ := msg["message"].(map[string]interface{})
+	blocks, _ := inner["content"].([]interface{})
+	for _, block := range blocks {
+		result, ok := block.(map[string]interface{})
+		if !ok || result["type"] != "tool_result" {
+			continue
+		}
+		result["content"] = filterContent(filter, result["content"])
+	}
+}
+
+// filterMcpResult applies filter to the content of an SDK MCP tools/call
+// response before it is returned to the CLI, so the model never sees the
+// unfiltered text.
+func filterMcpResult(filter ToolResultFilter, response map[string]interface{}) {
+	if filter == nil {
+		return
+	}
+	result, _ := response["result"].(map[string]interface{})
+	if result == nil {
+		return
+	}
+	if content, ok := result["content"]; ok {
+		result["content"] = filterContent(filter, content)
+	}
+	if structured, ok := result["structuredContent"]; ok {
+		result["structuredContent"] = filterStrings(filter, structured)
+	}
+}
+
+// filterContent filters tool result content: a string, or a list of blocks
+// whose text is filtered.
+func filterContent(filter ToolResultFilter, content interface{}) interface{} {
+	switch c := content.(type) {
+	case string:
+		return filter(c)
+	case []interface{}:
+		for _, block := range c {
+			if m, ok := block.(map[string]interface{}); ok {
+				filterTextBlock(filter, m)
+			}
+		}
+	case []map[string]interface{}:
+		for _, m := range c {
+			filterTextBlock(filter, m)
+		}
+	}
+	return content
+}
+
+func filterTextBlock(filter ToolResultFilter, block map[string]interface{}) {
+	if text, ok := block["text"].(string); ok {
+		block["text"] = filter(text)
+	}
+}
+
+// filterStrings filters every string in a decoded JSON value.
+func filterStrings(filter ToolResultFilter, value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return filter(v)
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = filterStrings(filter, item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = filterStrings(filter, item)
+		}
+	}
+	return value
+}
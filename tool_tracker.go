@@ -0,0 +1,221 @@
+package claude
+
+import (
+	"context"
+	"sync"
+)
+
+// ToolTracker correlates ToolUseBlocks with their ToolResultBlocks across messages.
+//
+// Feed every message received from Query() or ReceiveMessages() into Observe.
+// The tracker records each tool use until its result arrives, lets callers
+// block on a specific result with WaitForToolResult, and marks tool uses that
+// never received a result as orphaned when the ResultMessage for the turn
+// arrives.
+//
+// Example:
+//
+//	tracker := claude.NewToolTracker()
+//	for msg := range msgCh {
+//	    tracker.Observe(msg)
+//	    if m, ok := msg.(*claude.AssistantMessage); ok {
+//	        for _, block := range m.Content {
+//	            if toolUse, ok := block.(claude.ToolUseBlock); ok {
+//	                showSpinner(toolUse.ID, toolUse.Name)
+//	            }
+//	        }
+//	    }
+//	}
+//	for _, orphan := range tracker.Orphaned() {
+//	    log.Printf("tool %s (%s) never completed", orphan.Name, orphan.ID)
+//	}
+//
+// ToolTracker is safe for concurrent use.
+type ToolTracker struct {
+	mu       sync.Mutex
+	toolUses map[string]ToolUseBlock
+	results  map[string]ToolResultBlock
+	pending  []string // Tool use IDs awaiting results, in arrival order
+	orphaned []ToolUseBlock
+	waiters  map[string][]chan struct{}
+}
+
+// NewToolTracker creates an empty ToolTracker.
+func NewToolTracker() *ToolTracker {
+	return &ToolTracker{
+		toolUses: make(map[string]ToolUseBlock),
+		results:  make(map[string]ToolResultBlock),
+		waiters:  make(map[string][]chan struct{}),
+	}
+}
+
+// Observe records tool uses and tool results contained in msg.
+// Messages of other types are ignored, except ResultMessage which marks every
+// still-pending tool use as orphaned.
+func (t *ToolTracker) Observe(msg Message) {
+	switch m := msg.(type) {
+	case *AssistantMessage:
+		t.observeBlocks(m.Content)
+	case *UserMessage:
+		if blocks, ok := m.Content.([]ContentBlock); ok {
+			t.observeBlocks(blocks)
+		}
+	case *ResultMessage:
+		t.markOrphaned()
+	}
+}
+
+func (t *ToolTracker) observeBlocks(blocks []ContentBlock) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case ToolUseBlock:
+			if _, seen := t.toolUses[b.ID]; seen {
+				continue
+			}
+			t.toolUses[b.ID] = b
+			if _, done := t.results[b.ID]; !done {
+				t.pending = append(t.pending, b.ID)
+			}
+		case ToolResultBlock:
+			t.results[b.ToolUseID] = b
+			t.removePending(b.ToolUseID)
+			for _, ch := range t.waiters[b.ToolUseID] {
+				close(ch)
+			}
+			delete(t.waiters, b.ToolUseID)
+		}
+	}
+}
+
+// removePending removes id from the pending list. Caller must hold t.mu.
+func (t *ToolTracker) removePending(id string) {
+	for i, pid := range t.pending {
+		if pid == id {
+			t.pending = append(t.pending[:i], t.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// markOrphaned moves all pending tool uses to the orphaned list and wakes
+// every waiter, including those for tool uses the turn never contained.
+func (t *ToolTracker) markOrphaned() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, id := range t.pending {
+		t.orphaned = append(t.orphaned, t.toolUses[id])
+	}
+	t.pending = nil
+	for id, chs := range t.waiters {
+		for _, ch := range chs {
+			close(ch)
+		}
+		delete(t.waiters, id)
+	}
+}
+
+// WaitForToolResult blocks until the result for toolUseID is observed, the
+// tool use is orphaned by a ResultMessage, or ctx is done.
+//
+// Returns an *OrphanedToolUseError if the turn ended without a result, with
+// NotFound set if it also ended without the tool use. The tool use does not
+// need to have been observed yet when this is called.
+func (t *ToolTracker) WaitForToolResult(ctx context.Context, toolUseID string) (ToolResultBlock, error) {
+	t.mu.Lock()
+	if result, ok := t.results[toolUseID]; ok {
+		t.mu.Unlock()
+		return result, nil
+	}
+	if t.isOrphaned(toolUseID) {
+		t.mu.Unlock()
+		return ToolResultBlock{}, NewOrphanedToolUseError(toolUseID)
+	}
+	ch := make(chan struct{})
+	t.waiters[toolUseID] = append(t.waiters[toolUseID], ch)
+	t.mu.Unlock()
+
+	select {
+	case <-ch:
+	case <-ctx.Done():
+		t.removeWaiter(toolUseID, ch)
+		return ToolResultBlock{}, ctx.Err()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if result, ok := t.results[toolUseID]; ok {
+		return result, nil
+	}
+	if _, seen := t.toolUses[toolUseID]; !seen {
+		return ToolResultBlock{}, NewToolUseNotFoundError(toolUseID)
+	}
+	return ToolResultBlock{}, NewOrphanedToolUseError(toolUseID)
+}
+
+// removeWaiter drops ch from the waiters for id, if it is still there.
+func (t *ToolTracker) removeWaiter(id string, ch chan struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	chs := t.waiters[id]
+	for i, waiter := range chs {
+		if waiter == ch {
+			chs = append(chs[:i], chs[i+1:]...)
+			break
+		}
+	}
+	if len(chs) == 0 {
+		delete(t.waiters, id)
+	} else {
+		t.waiters[id] = chs
+	}
+}
+
+// isOrphaned reports whether id was marked orphaned. Caller must hold t.mu.
+func (t *ToolTracker) isOrphaned(id string) bool {
+	for _, o := range t.orphaned {
+		if o.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ToolUse returns the observed tool use with the given ID.
+func (t *ToolTracker) ToolUse(toolUseID string) (ToolUseBlock, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	block, ok := t.toolUses[toolUseID]
+	return block, ok
+}
+
+// Result returns the observed result for the given tool use ID.
+func (t *ToolTracker) Result(toolUseID string) (ToolResultBlock, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result, ok := t.results[toolUseID]
+	return result, ok
+}
+
+// Pending returns tool uses still awaiting a result, in the order they were observed.
+func (t *ToolTracker) Pending() []ToolUseBlock {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pending := make([]ToolUseBlock, len(t.pending))
+	for i, id := range t.pending {
+		pending[i] = t.toolUses[id]
+	}
+	return pending
+}
+
+// Orphaned returns tool uses whose turn ended without a matching result.
+func (t *ToolTracker) Orphaned() []ToolUseBlock {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	orphaned := make([]ToolUseBlock, len(t.orphaned))
+	copy(orphaned, t.orphaned)
+	return orphaned
+}
@@ -0,0 +1,325 @@
+package claude
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transcript record directions.
+const (
+	TranscriptInbound  = "in"  // Message read from the CLI
+	TranscriptOutbound = "out" // Data written to the CLI
+)
+
+// TranscriptCompression selects how transcript files are compressed.
+type TranscriptCompression string
+
+const (
+	TranscriptCompressionNone TranscriptCompression = ""
+	TranscriptCompressionGzip TranscriptCompression = "gzip"
+)
+
+// TranscriptOptions configures a TranscriptRecorder.
+type TranscriptOptions struct {
+	// Path is the base file path, e.g. "logs/claude.jsonl". Each file is named
+	// "{base}-{timestamp}-{seq}{ext}", so rotation never renames files.
+	Path string
+
+	// Compression applies built-in compression (default: none).
+	Compression TranscriptCompression
+
+	// Compressor, if set, wraps each file in a custom compressor (e.g. zstd)
+	// and takes precedence over Compression. Extension is appended to file
+	// names, e.g. ".zst".
+	Compressor func(w io.Writer) (io.WriteCloser, error)
+	Extension  string
+
+	// MaxBytes rotates to a new file after this many uncompressed bytes (0 = no limit).
+	MaxBytes int64
+
+	// MaxAge rotates to a new file after it has been open this long (0 = no limit).
+	MaxAge time.Duration
+
+	// MaxFiles deletes the oldest files beyond this count on rotation (0 = keep all).
+	MaxFiles int
+}
+
+// TranscriptRecord is one line of a transcript file.
+type TranscriptRecord struct {
+	Time      time.Time       `json:"ts"`
+	Direction string          `json:"dir"`
+	Message   json.RawMessage `json:"message"`
+}
+
+// TranscriptRecorder writes every raw message exchanged with the CLI as
+// JSON lines, with optional compression and size/time based rotation.
+//
+// Set it on ClaudeAgentOptions.Transcript to record a session. A recorder may
+// be shared by several clients; it is safe for concurrent use. Call Close when
+// done to flush the current file.
+//
+// Example:
+//
+//	rec, err := claude.NewTranscriptRecorder(claude.TranscriptOptions{
+//	    Path:        "/var/log/agent/transcript.jsonl",
+//	    Compression: claude.TranscriptCompressionGzip,
+//	    MaxBytes:    64 << 20,
+//	    MaxFiles:    10,
+//	})
+//	defer rec.Close()
+//	options := &claude.ClaudeAgentOptions{Transcript: rec}
+type TranscriptRecorder struct {
+	opts TranscriptOptions
+
+	mu       sync.Mutex
+	file     *os.File
+	buf      *bufio.Writer
+	comp     io.WriteCloser // Compressor wrapping file; nil if uncompressed
+	w        io.Writer      // Where records are written
+	written  int64
+	openedAt time.Time
+	seq      int
+	closed   bool
+}
+
+// NewTranscriptRecorder creates a recorder. The first file is opened lazily
+// on the first record.
+func NewTranscriptRecorder(opts TranscriptOptions) (*TranscriptRecorder, error) {
+	if opts.Path == "" {
+		return nil, fmt.Errorf("transcript path is required")
+	}
+	switch opts.Compression {
+	case TranscriptCompressionNone, TranscriptCompressionGzip:
+	default:
+		return nil, fmt.Errorf("unsupported transcript compression: %q", opts.Compression)
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	return &TranscriptRecorder{opts: opts}, nil
+}
+
+// Record appends one message. data must be a single JSON value; trailing
+// newlines are ignored.
+func (r *TranscriptRecorder) Record(direction string, data []byte) error {
+	return r.recordAt(direction, data, time.Now().UTC())
+}
+
+// recordAt appends one message, timestamped at.
+func (r *TranscriptRecorder) recordAt(direction string, data []byte, at time.Time) error {
+	line, err := json.Marshal(TranscriptRecord{
+		Time:      at,
+		Direction: direction,
+		Message:   json.RawMessage(strings.TrimRight(string(data), "\r\n")),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode transcript record: %w", err)
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return fmt.Errorf("transcript recorder is closed")
+	}
+	if r.needsRotation() {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := r.w.Write(line)
+	r.written += int64(n)
+	return err
+}
+
+// Files returns the transcript files written so far, oldest first.
+func (r *TranscriptRecorder) Files() ([]string, error) {
+	return filepath.Glob(r.filePrefix() + "*" + r.extension())
+}
+
+// Close flushes and closes the current file.
+func (r *TranscriptRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return r.closeFile()
+}
+
+// needsRotation reports whether a new file must be opened. Caller holds mu.
+func (r *TranscriptRecorder) needsRotation() bool {
+	if r.file == nil {
+		return true
+	}
+	if r.opts.MaxBytes > 0 && r.written >= r.opts.MaxBytes {
+		return true
+	}
+	return r.opts.MaxAge > 0 && time.Since(r.openedAt) >= r.opts.MaxAge
+}
+
+// rotate closes the current file, opens the next one, and prunes old files.
+// Caller holds mu.
+func (r *TranscriptRecorder) rotate() error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+
+	r.seq++
+	name := fmt.Sprintf("%s%s-%04d%s", r.filePrefix(), time.Now().UTC().Format("20060102T150405"), r.seq, r.extension())
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open transcript file: %w", err)
+	}
+
+	r.file = file
+	r.buf = bufio.NewWriter(file)
+	r.w = r.buf
+	r.comp = nil
+	switch {
+	case r.opts.Compressor != nil:
+		r.comp, err = r.opts.Compressor(r.buf)
+	case r.opts.Compression == TranscriptCompressionGzip:
+		r.comp = gzip.NewWriter(r.buf)
+	}
+	if err != nil {
+		file.Close()
+		r.file = nil
+		return fmt.Errorf("failed to create transcript compressor: %w", err)
+	}
+	if r.comp != nil {
+		r.w = r.comp
+	}
+	r.written = 0
+	r.openedAt = time.Now()
+
+	return r.prune()
+}
+
+// closeFile flushes and closes the current file, if any. Caller holds mu.
+func (r *TranscriptRecorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	var firstErr error
+	if r.comp != nil {
+		firstErr = r.comp.Close()
+	}
+	if err := r.buf.Flush(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if err := r.file.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	r.file, r.buf, r.comp, r.w = nil, nil, nil, nil
+	return firstErr
+}
+
+// prune removes the oldest files beyond MaxFiles. Caller holds mu.
+func (r *TranscriptRecorder) prune() error {
+	if r.opts.MaxFiles <= 0 {
+		return nil
+	}
+	files, err := filepath.Glob(r.filePrefix() + "*" + r.extension())
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for len(files) > r.opts.MaxFiles {
+		if err := os.Remove(files[0]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old transcript file: %w", err)
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// filePrefix returns the path prefix shared by all files of this recorder.
+func (r *TranscriptRecorder) filePrefix() string {
+	ext := filepath.Ext(r.opts.Path)
+	return strings.TrimSuffix(r.opts.Path, ext) + "-"
+}
+
+// extension returns the file extension including any compression suffix.
+func (r *TranscriptRecorder) extension() string {
+	ext := filepath.Ext(r.opts.Path)
+	if ext == "" {
+		ext = ".jsonl"
+	}
+	switch {
+	case r.opts.Compressor != nil:
+		return ext + r.opts.Extension
+	case r.opts.Compression == TranscriptCompressionGzip:
+		return ext + ".gz"
+	}
+	return ext
+}
+
+// recordInbound records a message read from the transport, logging failures.
+// Timestamps are in loc, or UTC if loc is nil.
+func (r *TranscriptRecorder) recordInbound(msg map[string]interface{}, loc *time.Location, logger logFunc) {
+	data, err := json.Marshal(msg)
+	if err == nil {
+		err = r.recordAt(TranscriptInbound, data, inTimezone(time.Now().UTC(), loc))
+	}
+	if err != nil {
+		logger("failed to record transcript", err)
+	}
+}
+
+// logFunc reports a non-fatal failure.
+type logFunc func(msg string, err error)
+
+// transcriptTransport records outbound writes before passing them on.
+// Inbound messages are recorded by the query handler as they are routed.
+type transcriptTransport struct {
+	Transport
+	recorder *TranscriptRecorder
+	timezone *time.Location
+	log      logFunc
+}
+
+func (t *transcriptTransport) Write(ctx context.Context, data string) error {
+	if err := t.recorder.recordAt(TranscriptOutbound, []byte(data), inTimezone(time.Now().UTC(), t.timezone)); err != nil {
+		t.log("failed to record transcript", err)
+	}
+	return t.Transport.Write(ctx, data)
+}
+
+// Unwrap returns the wrapped transport, for capability probing.
+func (t *transcriptTransport) Unwrap() Transport {
+	return t.Transport
+}
+
+// withTranscript wraps transport so outbound writes are recorded when
+// options.Transcript is set.
+func withTranscript(transport Transport, options *ClaudeAgentOptions) Transport {
+	if options == nil || options.Transcript == nil {
+		return transport
+	}
+	return &transcriptTransport{
+		Transport: transport,
+		recorder:  options.Transcript,
+		timezone:  options.Timezone,
+		log:       transcriptLogger(options),
+	}
+}
+
+// transcriptLogger returns a logFunc that warns through the configured logger.
+func transcriptLogger(options *ClaudeAgentOptions) logFunc {
+	logger := loggerFor(options)
+	return func(msg string, err error) {
+		logger.Warn(msg, "error", err)
+	}
+}
@@ -0,0 +1,303 @@
+package claude
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ReadTranscript reads the records of one transcript file's contents.
+func ReadTranscript(r io.Reader) ([]TranscriptRecord, error) {
+	var records []TranscriptRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), defaultMaxBufferSize)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record TranscriptRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid transcript record on line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	return records, nil
+}
+
+// ReadTranscriptFiles reads the records of transcript files in order, e.g.
+// TranscriptRecorder.Files. Files ending in ".gz" are decompressed; read
+// files with custom compression through ReadTranscript.
+func ReadTranscriptFiles(paths ...string) ([]TranscriptRecord, error) {
+	var records []TranscriptRecord
+	for _, path := range paths {
+		fileRecords, err := readTranscriptFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		records = append(records, fileRecords...)
+	}
+	return records, nil
+}
+
+func readTranscriptFile(path string) ([]TranscriptRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return ReadTranscript(r)
+}
+
+// ReplayTransport is a Transport that plays back a recorded transcript
+// instead of running the CLI, so tests and offline debugging go through the
+// normal parsing and control protocol with deterministic input.
+//
+// Inbound messages are replayed in recorded order. Those recorded after a
+// user message are held back until the SDK writes its next user message, so
+// each query sees its own response. Control requests the SDK sends (such as
+// initialize) are answered with the recorded response to the next request
+// of the same subtype, or an empty success if there is none. Control
+// requests recorded from the CLI, such as hook callbacks, are replayed and
+// the SDK's answers discarded; hooks get the same callback IDs as long as
+// the same hooks are configured.
+//
+// The message stream ends once everything has been replayed and either
+// input has ended or the transcript has no user messages (as for a Query
+// with a string prompt). A ReplayTransport can be connected once.
+//
+// Example:
+//
+//	records, err := claude.ReadTranscriptFiles("testdata/refactor.jsonl")
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	client := claude.NewClaudeSDKClientWithTransport(options, claude.NewReplayTransport(records))
+type ReplayTransport struct {
+	segments  [][]map[string]interface{}          // segments[0] at Connect, then one per user message
+	responses map[string][]map[string]interface{} // Recorded control responses by request subtype
+	oneShot   bool                                // No user messages were recorded
+
+	mu        sync.Mutex
+	connected bool
+	closed    bool
+	inputDone bool
+	released  int                      // Segments released so far
+	queue     []map[string]interface{} // Released messages not yet read
+	notify    chan struct{}
+	done      chan struct{}
+}
+
+// NewReplayTransport creates a transport that replays records, e.g. from
+// ReadTranscriptFiles.
+func NewReplayTransport(records []TranscriptRecord) *ReplayTransport {
+	t := &ReplayTransport{
+		segments:  [][]map[string]interface{}{nil},
+		responses: make(map[string][]map[string]interface{}),
+		oneShot:   true,
+		notify:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+
+	// Pair control responses with the subtype of the request they answer
+	subtypes := make(map[string]string)
+	for _, record := range records {
+		var msg map[string]interface{}
+		if json.Unmarshal(record.Message, &msg) != nil {
+			continue
+		}
+		msgType, _ := msg["type"].(string)
+		switch {
+		case record.Direction == TranscriptOutbound && msgType == MessageTypeControlRequest:
+			var request ControlRequest
+			if json.Unmarshal(record.Message, &request) == nil {
+				subtypes[request.RequestID] = string(request.Subtype())
+			}
+		case record.Direction == TranscriptOutbound && isReplayUserMessage(msgType):
+			t.segments = append(t.segments, nil)
+			t.oneShot = false
+		case record.Direction == TranscriptInbound && msgType == MessageTypeControlResponse:
+			response, _ := msg["response"].(map[string]interface{})
+			requestID, _ := response["request_id"].(string)
+			if subtype, ok := subtypes[requestID]; ok {
+				t.responses[subtype] = append(t.responses[subtype], response)
+			}
+		case record.Direction == TranscriptInbound:
+			last := len(t.segments) - 1
+			t.segments[last] = append(t.segments[last], msg)
+		}
+	}
+	return t
+}
+
+// isReplayUserMessage reports whether an outbound message of msgType is a
+// user message; large ones are sent compressed.
+func isReplayUserMessage(msgType string) bool {
+	return msgType == "user" || msgType == "compressed"
+}
+
+// Connect releases the messages recorded before the first user message.
+func (t *ReplayTransport) Connect(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.connected || t.closed {
+		return NewCLIConnectionError("replay transport can only be connected once", nil)
+	}
+	t.connected = true
+	t.releaseLocked()
+	return nil
+}
+
+// releaseLocked queues the next segment. Callers hold mu.
+func (t *ReplayTransport) releaseLocked() {
+	if t.released < len(t.segments) {
+		t.queue = append(t.queue, t.segments[t.released]...)
+		t.released++
+	}
+	t.signalLocked()
+}
+
+// signalLocked wakes ReadMessages. Callers hold mu.
+func (t *ReplayTransport) signalLocked() {
+	select {
+	case t.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Write answers control requests and releases the response to each user
+// message; anything else is discarded.
+func (t *ReplayTransport) Write(ctx context.Context, data string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.connected || t.closed {
+		return NewCLIConnectionError("replay transport is not connected", nil)
+	}
+
+	for _, line := range strings.Split(data, "\n") {
+		var msg map[string]interface{}
+		if json.Unmarshal([]byte(line), &msg) != nil {
+			continue
+		}
+		msgType, _ := msg["type"].(string)
+		switch {
+		case msgType == MessageTypeControlRequest:
+			t.answerLocked(msg)
+		case isReplayUserMessage(msgType):
+			t.releaseLocked()
+		}
+	}
+	return nil
+}
+
+// answerLocked queues the recorded response to a control request. Callers
+// hold mu.
+func (t *ReplayTransport) answerLocked(msg map[string]interface{}) {
+	requestID, _ := msg["request_id"].(string)
+	request, _ := msg["request"].(map[string]interface{})
+	subtype, _ := request["subtype"].(string)
+
+	response := map[string]interface{}{"subtype": string(ControlSubtypeSuccess)}
+	if recorded := t.responses[subtype]; len(recorded) > 0 {
+		response = make(map[string]interface{}, len(recorded[0]))
+		for key, value := range recorded[0] {
+			response[key] = value
+		}
+		t.responses[subtype] = recorded[1:]
+	}
+	response["request_id"] = requestID
+	t.queue = append(t.queue, map[string]interface{}{
+		"type":     MessageTypeControlResponse,
+		"response": response,
+	})
+	t.signalLocked()
+}
+
+// ReadMessages returns the replayed messages.
+func (t *ReplayTransport) ReadMessages(ctx context.Context) (<-chan map[string]interface{}, <-chan error) {
+	msgCh := make(chan map[string]interface{})
+	errCh := make(chan error)
+
+	go func() {
+		defer close(msgCh)
+		defer close(errCh)
+		for {
+			t.mu.Lock()
+			queue := t.queue
+			t.queue = nil
+			finished := len(queue) == 0 && t.released == len(t.segments) && (t.inputDone || t.oneShot)
+			t.mu.Unlock()
+			if finished {
+				return
+			}
+
+			for _, msg := range queue {
+				select {
+				case msgCh <- msg:
+				case <-ctx.Done():
+					return
+				case <-t.done:
+					return
+				}
+			}
+			if len(queue) > 0 {
+				continue
+			}
+
+			select {
+			case <-t.notify:
+			case <-ctx.Done():
+				return
+			case <-t.done:
+				return
+			}
+		}
+	}()
+
+	return msgCh, errCh
+}
+
+// Close ends the message stream.
+func (t *ReplayTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.closed {
+		t.closed = true
+		close(t.done)
+	}
+	return nil
+}
+
+// IsReady reports whether the transport is connected and not closed.
+func (t *ReplayTransport) IsReady() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.connected && !t.closed
+}
+
+// EndInput lets the message stream end once everything has been replayed.
+func (t *ReplayTransport) EndInput() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inputDone = true
+	t.signalLocked()
+	return nil
+}
@@ -0,0 +1,141 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+)
+
+// Optional transport capabilities.
+//
+// A Transport may implement any of these interfaces to advertise features
+// beyond the Transport interface. Higher layers probe for them with
+// CapabilitiesOf and CheckLiveness rather than type-asserting directly, so
+// capabilities can be added in later releases without breaking third-party
+// Transport implementations: a transport that implements none of them keeps
+// working with conservative defaults.
+
+// ReconnectCapability is implemented by transports that can report whether
+// Connect may be called again after Close, as session restarts (e.g.
+// ClaudeSDKClient.SetSettingSources with a custom transport) require.
+type ReconnectCapability interface {
+	SupportsReconnect() bool
+}
+
+// CompressionCapability is implemented by transports that can report whether
+// they compress data themselves (e.g. WebSocket permessage-deflate). The SDK
+// then skips its own CompressInputThreshold compression.
+type CompressionCapability interface {
+	SupportsCompression() bool
+}
+
+// LivenessProber is implemented by transports that can check that the other
+// side is still alive. Liveness returns nil if it is, or why it is not.
+type LivenessProber interface {
+	Liveness(ctx context.Context) error
+}
+
+// ReconnectNotifier is implemented by transports that replace a dropped
+// connection on their own, such as WebSocketTransport. The SDK registers a
+// handler called after each reconnect; it fails the control requests sent
+// on the old connection and initializes the new one, whose CLI may not know
+// the session's hooks and SDK MCP servers. The handler must not block.
+type ReconnectNotifier interface {
+	SetReconnectHandler(handler func())
+}
+
+// TransportCapabilities is the result of probing a transport.
+type TransportCapabilities struct {
+	// Reconnect is true if the transport can reconnect after Close; nil if
+	// it does not say.
+	Reconnect *bool
+	// Compression is true if the transport compresses data itself.
+	Compression bool
+	// Liveness is true if the transport implements LivenessProber.
+	Liveness bool
+}
+
+// transportUnwrapper is implemented by the SDK's transport wrappers so
+// capabilities of the wrapped transport are visible through them.
+type transportUnwrapper interface {
+	Unwrap() Transport
+}
+
+// CapabilitiesOf probes transport, and any transports it wraps, for optional
+// capabilities.
+func CapabilitiesOf(transport Transport) TransportCapabilities {
+	var caps TransportCapabilities
+	for transport != nil {
+		if r, ok := transport.(ReconnectCapability); ok && caps.Reconnect == nil {
+			supported := r.SupportsReconnect()
+			caps.Reconnect = &supported
+		}
+		if c, ok := transport.(CompressionCapability); ok && c.SupportsCompression() {
+			caps.Compression = true
+		}
+		if _, ok := transport.(LivenessProber); ok {
+			caps.Liveness = true
+		}
+		transport = unwrapTransport(transport)
+	}
+	return caps
+}
+
+// CheckLiveness returns nil if transport is alive. Transports without a
+// LivenessProber are considered alive while IsReady reports true.
+func CheckLiveness(ctx context.Context, transport Transport) error {
+	for t := transport; t != nil; t = unwrapTransport(t) {
+		if prober, ok := t.(LivenessProber); ok {
+			return prober.Liveness(ctx)
+		}
+	}
+	if transport == nil || !transport.IsReady() {
+		return NewCLIConnectionError("transport is not ready", nil)
+	}
+	return nil
+}
+
+func unwrapTransport(transport Transport) Transport {
+	if u, ok := transport.(transportUnwrapper); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
+
+// SupportsReconnect implements ReconnectCapability: Connect starts a new
+// process after Close.
+func (t *SubprocessCLITransport) SupportsReconnect() bool {
+	return true
+}
+
+// SupportsCompression implements CompressionCapability; pipes are not
+// compressed.
+func (t *SubprocessCLITransport) SupportsCompression() bool {
+	return false
+}
+
+// Liveness implements LivenessProber, reporting whether the CLI process is
+// still running.
+func (t *SubprocessCLITransport) Liveness(ctx context.Context) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	switch {
+	case t.exitError != nil:
+		return t.exitError
+	case t.cmd == nil || !t.ready:
+		return NewCLIConnectionError("CLI process is not running", nil)
+	}
+	if state := t.exit.exited(t.cmd); state != nil {
+		return NewCLIConnectionError(fmt.Sprintf("CLI process exited: %s", state), nil)
+	}
+	return nil
+}
+
+// Liveness reports whether the client's transport is alive. It returns an
+// error if the client is not connected.
+func (c *ClaudeSDKClient) Liveness(ctx context.Context) error {
+	conn, err := c.connected()
+	if err != nil {
+		return err
+	}
+	return CheckLiveness(ctx, conn.transport)
+}
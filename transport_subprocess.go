@@ -9,7 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -32,6 +32,7 @@ type SubprocessCLITransport struct {
 	cliPath       string
 	cwd           string
 	cmd           *exec.Cmd
+	exit          *processExit // Of cmd, which only it may Wait for
 	stdin         io.WriteCloser
 	stdout        io.ReadCloser
 	stderr        io.ReadCloser
@@ -39,22 +40,73 @@ type SubprocessCLITransport struct {
 	exitError     error
 	maxBufferSize int
 	tempFiles     []string // Temporary files created for long command lines
-	mu            sync.RWMutex
-	stderrWg      sync.WaitGroup
+	// Temp files passed to the CLI as descriptors; see TempFileCleanupAfterStart
+	inheritedFiles []*os.File
+	mu             sync.RWMutex
+	stderrWg       sync.WaitGroup
+	stderrMu       sync.Mutex
+	stderrTail     []string          // Last stderrTailLines lines, for error reporting
+	entrypoint     string            // CLAUDE_CODE_ENTRYPOINT for this process
+	env            map[string]string // Snapshot of options.Env taken at construction
+	features       Features          // Of the detected CLI version; all supported if unknown
 }
 
+// processExit waits for a CLI process. exec.Cmd allows a single Wait, which
+// ReadMessages makes once the output is read, and Close makes if nothing
+// read it; either way the other caller gets the same result.
+type processExit struct {
+	once sync.Once
+	done chan struct{} // Closed when the process has been waited for
+	err  error
+}
+
+func newProcessExit() *processExit {
+	return &processExit{done: make(chan struct{})}
+}
+
+// wait waits for cmd once and returns the result of Wait.
+func (e *processExit) wait(cmd *exec.Cmd) error {
+	e.once.Do(func() {
+		e.err = cmd.Wait()
+		close(e.done)
+	})
+	return e.err
+}
+
+// exited returns cmd's state if it has been waited for, or nil.
+func (e *processExit) exited(cmd *exec.Cmd) *os.ProcessState {
+	select {
+	case <-e.done:
+		return cmd.ProcessState
+	default:
+		return nil
+	}
+}
+
+// Values of CLAUDE_CODE_ENTRYPOINT identifying which SDK API started the CLI.
+const (
+	entrypointQuery  = "sdk-go"
+	entrypointClient = "sdk-go-client"
+)
+
+// stderrTailLines is how many trailing stderr lines are kept for error reporting.
+const stderrTailLines = 20
+
 // NewSubprocessCLITransport creates a new subprocess transport.
 func NewSubprocessCLITransport(prompt interface{}, options *ClaudeAgentOptions, cliPath string) (*SubprocessCLITransport, error) {
 	if options == nil {
 		options = &ClaudeAgentOptions{}
 	}
+	options, err := expandOptions(options)
+	if err != nil {
+		return nil, err
+	}
 
 	// Determine if streaming mode
 	_, isStreaming := prompt.(<-chan map[string]interface{})
 
 	// Find CLI if not specified
 	if cliPath == "" {
-		var err error
 		cliPath, err = findCLI()
 		if err != nil {
 			return nil, err
@@ -73,6 +125,13 @@ func NewSubprocessCLITransport(prompt interface{}, options *ClaudeAgentOptions,
 		maxBufferSize = *options.MaxBufferSize
 	}
 
+	// Snapshot env so later changes to options.Env (or another client's
+	// options) cannot affect this process
+	env := make(map[string]string, len(options.Env))
+	for k, v := range options.Env {
+		env[k] = v
+	}
+
 	return &SubprocessCLITransport{
 		prompt:        prompt,
 		isStreaming:   isStreaming,
@@ -80,6 +139,8 @@ func NewSubprocessCLITransport(prompt interface{}, options *ClaudeAgentOptions,
 		cliPath:       cliPath,
 		cwd:           cwd,
 		maxBufferSize: maxBufferSize,
+		entrypoint:    entrypointQuery,
+		env:           env,
 	}, nil
 }
 
@@ -130,10 +191,19 @@ func (t *SubprocessCLITransport) Connect(ctx context.Context) error {
 	if err := t.checkClaudeVersion(ctx); err != nil {
 		return err
 	}
+	logger := loggerFor(t.options)
+	for _, f := range t.features.Unsupported(t.options) {
+		logger.Warn(fmt.Sprintf("%s requires Claude Code %s or later, but %s is installed; ignoring it",
+			f.Option, f.MinVersion, t.features.CLIVersion))
+	}
 
 	// Build command
-	args := t.buildCommand()
+	args, err := t.buildCommand()
+	if err != nil {
+		return err
+	}
 	t.cmd = exec.CommandContext(ctx, t.cliPath, args...)
+	t.cmd.ExtraFiles = t.inheritedFiles
 
 	// Set working directory
 	if t.cwd != "" {
@@ -148,7 +218,6 @@ func (t *SubprocessCLITransport) Connect(ctx context.Context) error {
 	t.cmd.Env = t.buildEnv()
 
 	// Setup pipes
-	var err error
 	t.stdin, err = t.cmd.StdinPipe()
 	if err != nil {
 		return NewCLIConnectionError("failed to create stdin pipe", err)
@@ -159,26 +228,25 @@ func (t *SubprocessCLITransport) Connect(ctx context.Context) error {
 		return NewCLIConnectionError("failed to create stdout pipe", err)
 	}
 
-	// Setup stderr if needed
-	shouldPipeStderr := t.options.Stderr != nil || t.options.ExtraArgs["debug-to-stderr"] != nil
-	if shouldPipeStderr {
-		t.stderr, err = t.cmd.StderrPipe()
-		if err != nil {
-			return NewCLIConnectionError("failed to create stderr pipe", err)
-		}
+	// Setup stderr; it is always captured so process failures can report it
+	t.stderr, err = t.cmd.StderrPipe()
+	if err != nil {
+		return NewCLIConnectionError("failed to create stderr pipe", err)
 	}
 
 	// Start process
-	if err := t.cmd.Start(); err != nil {
+	err = t.cmd.Start()
+	t.releaseInheritedFiles()
+	if err != nil {
 		t.exitError = NewCLIConnectionError("failed to start Claude Code", err)
 		return t.exitError
 	}
+	t.exit = newProcessExit()
 
-	// Start stderr reader if needed
-	if shouldPipeStderr && t.stderr != nil {
-		t.stderrWg.Add(1)
-		go t.handleStderr()
-	}
+	// Start stderr reader
+	t.stderrTail = nil
+	t.stderrWg.Add(1)
+	go t.handleStderr(t.stderr)
 
 	// For non-streaming mode, close stdin immediately
 	if !t.isStreaming {
@@ -190,23 +258,20 @@ func (t *SubprocessCLITransport) Connect(ctx context.Context) error {
 }
 
 // buildCommand constructs CLI arguments from options.
-func (t *SubprocessCLITransport) buildCommand() []string {
+func (t *SubprocessCLITransport) buildCommand() ([]string, error) {
+	return t.spillLongAgents(t.buildArgs())
+}
+
+// buildArgs constructs CLI arguments from options without side effects.
+func (t *SubprocessCLITransport) buildArgs() []string {
 	args := []string{"--output-format", "stream-json", "--verbose"}
 
 	// System prompt
-	if t.options.SystemPrompt != nil {
-		switch sp := t.options.SystemPrompt.(type) {
-		case string:
-			args = append(args, "--system-prompt", sp)
-		case map[string]interface{}:
-			if sp["type"] == "preset" && sp["append"] != nil {
-				args = append(args, "--append-system-prompt", sp["append"].(string))
-			}
-		case SystemPromptPreset:
-			if sp.Append != nil {
-				args = append(args, "--append-system-prompt", *sp.Append)
-			}
-		}
+	if sp, ok := t.options.SystemPrompt.(string); ok {
+		args = append(args, "--system-prompt", sp)
+	}
+	if appended := appendedSystemPrompt(t.options); appended != "" {
+		args = append(args, "--append-system-prompt", appended)
 	}
 
 	// Tool restrictions
@@ -226,12 +291,12 @@ func (t *SubprocessCLITransport) buildCommand() []string {
 	if t.options.Model != nil {
 		args = append(args, "--model", *t.options.Model)
 	}
-	if t.options.FallbackModel != nil {
+	if t.options.FallbackModel != nil && t.features.Supports(FeatureFallbackModel) {
 		args = append(args, "--fallback-model", *t.options.FallbackModel)
 	}
 
 	// Budget and token control
-	if t.options.MaxBudgetUSD != nil {
+	if t.options.MaxBudgetUSD != nil && t.features.Supports(FeatureMaxBudget) {
 		args = append(args, "--max-budget-usd", fmt.Sprintf("%.2f", *t.options.MaxBudgetUSD))
 	}
 	if t.options.MaxThinkingTokens != nil {
@@ -253,13 +318,13 @@ func (t *SubprocessCLITransport) buildCommand() []string {
 	if t.options.Resume != nil {
 		args = append(args, "--resume", *t.options.Resume)
 	}
-	if t.options.ForkSession {
+	if t.options.ForkSession && t.features.Supports(FeatureForkSession) {
 		args = append(args, "--fork-session")
 	}
 
 	// Settings
-	if t.options.Settings != nil {
-		args = append(args, "--settings", *t.options.Settings)
+	if settings := t.buildSettingsValue(); settings != "" {
+		args = append(args, "--settings", settings)
 	}
 
 	// Additional directories
@@ -288,12 +353,12 @@ func (t *SubprocessCLITransport) buildCommand() []string {
 	}
 
 	// Partial messages
-	if t.options.IncludePartialMessages {
+	if t.options.IncludePartialMessages && t.features.Supports(FeaturePartialMessages) {
 		args = append(args, "--include-partial-messages")
 	}
 
 	// Agents
-	if len(t.options.Agents) > 0 {
+	if len(t.options.Agents) > 0 && t.features.Supports(FeatureAgents) {
 		agentsJSON, _ := json.Marshal(t.options.Agents)
 		args = append(args, "--agents", string(agentsJSON))
 	}
@@ -310,7 +375,7 @@ func (t *SubprocessCLITransport) buildCommand() []string {
 	}
 
 	// Plugins
-	if len(t.options.Plugins) > 0 {
+	if len(t.options.Plugins) > 0 && t.features.Supports(FeaturePlugins) {
 		for _, plugin := range t.options.Plugins {
 			if plugin.Type == "local" {
 				args = append(args, "--plugin-dir", plugin.Path)
@@ -319,6 +384,11 @@ func (t *SubprocessCLITransport) buildCommand() []string {
 		}
 	}
 
+	// Debug log, parsed from stderr
+	if _, ok := t.options.ExtraArgs["debug-to-stderr"]; t.options.DebugEvents != nil && !ok {
+		args = append(args, "--debug-to-stderr")
+	}
+
 	// Extra args
 	for flag, value := range t.options.ExtraArgs {
 		if value == nil {
@@ -336,52 +406,134 @@ func (t *SubprocessCLITransport) buildCommand() []string {
 		args = append(args, "--print", "--", t.prompt.(string))
 	}
 
-	// Check if command line is too long (Windows limitation)
-	// This optimization helps when large agent definitions would exceed command line limits
-	cmdStr := strings.Join(args, " ")
-	cmdLengthLimit := nonWindowsCmdLengthLimit
+	return args
+}
+
+// cmdLengthLimit returns the maximum command line length for this platform.
+func cmdLengthLimit() int {
 	if isWindows() {
-		cmdLengthLimit = windowsCmdLengthLimit
-	}
-
-	if len(cmdStr) > cmdLengthLimit && len(t.options.Agents) > 0 {
-		// Command is too long - use temp file for agents
-		// Find the --agents argument and replace its value with @filepath
-		for i, arg := range args {
-			if arg == "--agents" && i+1 < len(args) {
-				agentsJSONValue := args[i+1]
-
-				// Create a temporary file
-				tempFile, err := os.CreateTemp("", "claude-agents-*.json")
-				if err != nil {
-					// Log warning but continue - the command might still work
-					fmt.Fprintf(os.Stderr, "Warning: Failed to create temp file for long command: %v\n", err)
-					break
-				}
+		return windowsCmdLengthLimit
+	}
+	return nonWindowsCmdLengthLimit
+}
 
-				// Write the agents JSON to the file
-				if _, err := tempFile.WriteString(agentsJSONValue); err != nil {
-					tempFile.Close()
-					os.Remove(tempFile.Name())
-					fmt.Fprintf(os.Stderr, "Warning: Failed to write to temp file: %v\n", err)
-					break
-				}
+// agentsNeedTempFile reports whether args exceed the command line limit and
+// --agents would be moved to a temp file.
+func (t *SubprocessCLITransport) agentsNeedTempFile(args []string) bool {
+	return len(strings.Join(args, " ")) > cmdLengthLimit() && len(t.options.Agents) > 0
+}
+
+// spillLongAgents moves the --agents value to a temp file when the command
+// line is too long (Windows limitation). This helps when large agent
+// definitions would exceed command line limits. The file is readable only by
+// the current user and its path is never logged. ClaudeAgentOptions.TempFileSpill
+// can veto it.
+func (t *SubprocessCLITransport) spillLongAgents(args []string) ([]string, error) {
+	if !t.agentsNeedTempFile(args) {
+		return args, nil
+	}
+	logger := loggerFor(t.options)
+	commandLength := len(strings.Join(args, " "))
+
+	// Find the --agents argument and replace its value with @filepath
+	for i, arg := range args {
+		if arg != "--agents" || i+1 >= len(args) {
+			continue
+		}
+		tempFile, err := createTempFile(t.options, "claude-agents-*.json", args[i+1])
+		if err != nil {
+			// Continue - the command might still work
+			logger.Warn("failed to create temp file for --agents", "error", err)
+			break
+		}
+
+		if t.options.TempFileSpill != nil {
+			spill := TempFileSpill{
+				Arg: arg, Size: len(args[i+1]), Path: tempFile.Name(),
+				CommandLength: commandLength, Limit: cmdLengthLimit(),
+			}
+			if err := t.options.TempFileSpill(spill); err != nil {
 				tempFile.Close()
+				os.Remove(tempFile.Name())
+				return nil, NewCLIConnectionError("passing --agents via temp file was vetoed", err)
+			}
+		}
 
-				// Track for cleanup
-				t.tempFiles = append(t.tempFiles, tempFile.Name())
+		// Track for cleanup
+		t.tempFiles = append(t.tempFiles, tempFile.Name())
+		if inheritTempFiles(t.options) {
+			args[i+1] = "@" + inheritedFilePath(len(t.inheritedFiles))
+			t.inheritedFiles = append(t.inheritedFiles, tempFile)
+		} else {
+			tempFile.Close()
+			args[i+1] = "@" + tempFile.Name()
+		}
+
+		logger.Info("command line too long, passing --agents via temp file",
+			"length", commandLength, "limit", cmdLengthLimit())
+		break
+	}
+	return args, nil
+}
+
+// releaseInheritedFiles removes temp files handed to the CLI as inherited
+// descriptors once it has started; the CLI keeps its own descriptors open.
+func (t *SubprocessCLITransport) releaseInheritedFiles() {
+	for _, f := range t.inheritedFiles {
+		f.Close()
+	}
+	t.inheritedFiles = nil
+	if inheritTempFiles(t.options) {
+		t.removeTempFiles()
+	}
+}
 
-				// Replace agents JSON with @filepath reference
-				args[i+1] = "@" + tempFile.Name()
+// removeTempFiles deletes all temp files created for the CLI.
+func (t *SubprocessCLITransport) removeTempFiles() {
+	for _, tempFile := range t.tempFiles {
+		if err := os.Remove(tempFile); err != nil && !os.IsNotExist(err) {
+			// Log but don't fail on cleanup errors
+			loggerFor(t.options).Warn("failed to remove temp file", "error", err)
+		}
+	}
+	t.tempFiles = nil
+}
 
-				fmt.Fprintf(os.Stderr, "Command line length (%d) exceeds limit (%d). Using temp file for --agents: %s\n",
-					len(cmdStr), cmdLengthLimit, tempFile.Name())
-				break
+// buildSettingsValue returns the --settings value, merging SDK-managed settings
+// (such as OutputStyle) into the user's Settings.
+//
+// Settings may be a JSON object string or a path to a settings file. When
+// SDK-managed settings must be merged in, a file path is read and inlined.
+func (t *SubprocessCLITransport) buildSettingsValue() string {
+	if t.options.OutputStyle == nil {
+		if t.options.Settings != nil {
+			return *t.options.Settings
+		}
+		return ""
+	}
+
+	settings := make(map[string]interface{})
+	if t.options.Settings != nil {
+		raw := strings.TrimSpace(*t.options.Settings)
+		if !strings.HasPrefix(raw, "{") {
+			// Treat as a file path
+			data, err := os.ReadFile(raw)
+			if err != nil {
+				loggerFor(t.options).Warn("failed to read settings file", "path", raw, "error", err)
+				return *t.options.Settings
 			}
+			raw = string(data)
+		}
+		if err := json.Unmarshal([]byte(raw), &settings); err != nil {
+			loggerFor(t.options).Warn("failed to parse settings", "error", err)
+			return *t.options.Settings
 		}
 	}
 
-	return args
+	settings["outputStyle"] = *t.options.OutputStyle
+
+	data, _ := json.Marshal(settings)
+	return string(data)
 }
 
 // isWindows returns true if running on Windows
@@ -389,41 +541,121 @@ func isWindows() bool {
 	return os.PathSeparator == '\\' && os.PathListSeparator == ';'
 }
 
+// envOverrides returns the variables set on top of the parent environment.
+func (t *SubprocessCLITransport) envOverrides() map[string]string {
+	// Variables set for this process only; the parent environment is never
+	// modified, so concurrent clients with different Env maps don't interfere.
+	// User env may override the entrypoint but not the SDK version or user
+	// agent.
+	overrides := map[string]string{"CLAUDE_CODE_ENTRYPOINT": withAppEntrypoint(t.entrypoint, t.options)}
+	if t.options.ForceUTF8Output {
+		for k, v := range utf8Environment {
+			overrides[k] = v
+		}
+	}
+	for k, v := range t.env {
+		overrides[k] = v
+	}
+	if t.options.MaxOutputTokens != nil {
+		overrides[maxOutputTokensEnv] = strconv.Itoa(*t.options.MaxOutputTokens)
+	}
+	for k, v := range autoCompactEnvironment(t.options.AutoCompact) {
+		overrides[k] = v
+	}
+	overrides["CLAUDE_AGENT_SDK_VERSION"] = sdkVersion
+	overrides[userAgentEnv] = userAgent(t.options)
+
+	// Set PWD if cwd is specified
+	if t.cwd != "" {
+		overrides["PWD"] = t.cwd
+	}
+	return overrides
+}
+
 // buildEnv constructs environment variables.
 func (t *SubprocessCLITransport) buildEnv() []string {
-	env := os.Environ()
+	overrides := t.envOverrides()
 
-	// Add user env vars
-	for k, v := range t.options.Env {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	env := make([]string, 0, len(os.Environ())+len(overrides))
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, overridden := overrides[key]; !overridden {
+			env = append(env, kv)
+		}
 	}
 
-	// Add SDK identifier
-	env = append(env, "CLAUDE_CODE_ENTRYPOINT=sdk-go")
-	env = append(env, fmt.Sprintf("CLAUDE_AGENT_SDK_VERSION=%s", sdkVersion))
-
-	// Set PWD if cwd is specified
-	if t.cwd != "" {
-		env = append(env, fmt.Sprintf("PWD=%s", t.cwd))
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, fmt.Sprintf("%s=%s", k, overrides[k]))
 	}
 
 	return env
 }
 
 // handleStderr reads stderr in background.
-func (t *SubprocessCLITransport) handleStderr() {
+func (t *SubprocessCLITransport) handleStderr(stderr io.Reader) {
 	defer t.stderrWg.Done()
 
-	scanner := bufio.NewScanner(t.stderr)
+	scanner := bufio.NewScanner(stderr)
+	lineNo := 0
 	for scanner.Scan() {
-		line := scanner.Text()
+		lineNo++
+		line := t.sanitizeLine(scanner.Text(), "stderr", lineNo)
 		if line == "" {
 			continue
 		}
 
+		t.stderrMu.Lock()
+		t.stderrTail = append(t.stderrTail, line)
+		if len(t.stderrTail) > stderrTailLines {
+			t.stderrTail = t.stderrTail[len(t.stderrTail)-stderrTailLines:]
+		}
+		t.stderrMu.Unlock()
+
 		if t.options.Stderr != nil {
 			t.options.Stderr(line)
 		}
+		if t.options.DebugEvents != nil {
+			if event, ok := ParseDebugLine(line); ok {
+				t.options.DebugEvents(event)
+			}
+		}
+	}
+}
+
+// sanitizeLine replaces invalid UTF-8 in a line of CLI output, reporting a
+// DecodingWarning if any was found.
+func (t *SubprocessCLITransport) sanitizeLine(line, stream string, lineNo int) string {
+	sanitized, warning, ok := sanitizeUTF8(line)
+	if !ok {
+		warning.Stream, warning.Line = stream, lineNo
+		reportDecodingWarning(t.options, warning)
+	}
+	return sanitized
+}
+
+// stderrOutput returns the trailing stderr lines captured so far.
+func (t *SubprocessCLITransport) stderrOutput() string {
+	t.stderrMu.Lock()
+	defer t.stderrMu.Unlock()
+	return strings.Join(t.stderrTail, "\n")
+}
+
+// waitForStderr waits briefly for the stderr reader to drain.
+func (t *SubprocessCLITransport) waitForStderr(timeout time.Duration) {
+	stderrDone := make(chan struct{})
+	go func() {
+		t.stderrWg.Wait()
+		close(stderrDone)
+	}()
+	select {
+	case <-stderrDone:
+	case <-time.After(timeout):
+		// Stderr reader didn't finish, continue anyway
 	}
 }
 
@@ -436,8 +668,8 @@ func (t *SubprocessCLITransport) Write(ctx context.Context, data string) error {
 		return NewCLIConnectionError("transport is not ready for writing", nil)
 	}
 
-	if t.cmd.ProcessState != nil {
-		return NewCLIConnectionError(fmt.Sprintf("cannot write to terminated process (exit code: %d)", t.cmd.ProcessState.ExitCode()), nil)
+	if state := t.exit.exited(t.cmd); state != nil {
+		return NewCLIConnectionError(fmt.Sprintf("cannot write to terminated process (exit code: %d)", state.ExitCode()), nil)
 	}
 
 	if t.exitError != nil {
@@ -456,14 +688,18 @@ func (t *SubprocessCLITransport) Write(ctx context.Context, data string) error {
 
 // ReadMessages reads and parses messages from stdout.
 func (t *SubprocessCLITransport) ReadMessages(ctx context.Context) (<-chan map[string]interface{}, <-chan error) {
-	msgCh := make(chan map[string]interface{}, 10)
+	msgCh := make(chan map[string]interface{}, bufferSizeOrDefault(t.options.TransportChannelBufferSize, defaultTransportChannelBufferSize))
 	errCh := make(chan error, 1)
 
-	go func() {
+	t.mu.RLock()
+	cmd, exit, stdout := t.cmd, t.exit, t.stdout
+	t.mu.RUnlock()
+
+	go withProfileLabels(ctx, profileLabelsEnabled(t.options), func(ctx context.Context) {
 		defer close(msgCh)
 		defer close(errCh)
 
-		scanner := bufio.NewScanner(t.stdout)
+		scanner := bufio.NewScanner(stdout)
 		// Set initial buffer size for scanner (configurable, default 64KB)
 		initialSize := 64 * 1024
 		if t.options != nil && t.options.ScannerInitialBufferSize != nil && *t.options.ScannerInitialBufferSize > 0 {
@@ -473,6 +709,15 @@ func (t *SubprocessCLITransport) ReadMessages(ctx context.Context) (<-chan map[s
 		scanner.Buffer(buf, t.maxBufferSize)
 
 		var jsonBuffer strings.Builder
+		lineNo := 0
+
+		// With ParseConcurrency, complete messages are found with json.Valid
+		// and decoded by a worker pool, in order
+		var decoder *orderedDecoder
+		if workers := parseConcurrency(t.options); workers > 1 {
+			decoder = newOrderedDecoder(ctx, workers, msgCh, metricsFor(t.options))
+			defer decoder.close()
+		}
 
 		for scanner.Scan() {
 			select {
@@ -481,7 +726,8 @@ func (t *SubprocessCLITransport) ReadMessages(ctx context.Context) (<-chan map[s
 			default:
 			}
 
-			line := scanner.Text()
+			lineNo++
+			line := t.sanitizeLine(scanner.Text(), "stdout", lineNo)
 			line = strings.TrimSpace(line)
 			if line == "" {
 				continue
@@ -500,6 +746,9 @@ func (t *SubprocessCLITransport) ReadMessages(ctx context.Context) (<-chan map[s
 				jsonBuffer.WriteString(jsonLine)
 
 				if jsonBuffer.Len() > t.maxBufferSize {
+					if decoder != nil {
+						decoder.close()
+					}
 					errCh <- NewCLIJSONDecodeError(
 						fmt.Sprintf("JSON message exceeded maximum buffer size of %d bytes", t.maxBufferSize),
 						fmt.Errorf("buffer size %d exceeds limit %d", jsonBuffer.Len(), t.maxBufferSize),
@@ -507,10 +756,22 @@ func (t *SubprocessCLITransport) ReadMessages(ctx context.Context) (<-chan map[s
 					return
 				}
 
+				if decoder != nil {
+					if raw := []byte(jsonBuffer.String()); len(raw) > 0 && raw[0] == '{' && json.Valid(raw) {
+						jsonBuffer.Reset()
+						if !decoder.submit(ctx, raw) {
+							return
+						}
+					}
+					continue
+				}
+
 				// Try to parse
 				var data map[string]interface{}
+				decodeStart := time.Now()
 				if err := json.Unmarshal([]byte(jsonBuffer.String()), &data); err == nil {
 					// Successfully parsed
+					observeSince(metricsFor(t.options), MetricParseDuration, decodeStart, decodeStageLabels)
 					jsonBuffer.Reset()
 					msgCh <- data
 				}
@@ -518,23 +779,41 @@ func (t *SubprocessCLITransport) ReadMessages(ctx context.Context) (<-chan map[s
 			}
 		}
 
+		if decoder != nil {
+			decoder.close()
+		}
+
 		if err := scanner.Err(); err != nil && err != io.EOF {
 			errCh <- NewCLIConnectionError("error reading from stdout", err)
 			return
 		}
 
+		// Drain stderr before Wait closes the pipe
+		t.waitForStderr(time.Second)
+
 		// Wait for process to complete
-		if err := t.cmd.Wait(); err != nil {
+		if err := exit.wait(cmd); err != nil {
 			if exitErr, ok := err.(*exec.ExitError); ok {
-				t.exitError = NewProcessError(
-					"command failed",
-					exitErr.ExitCode(),
-					"check stderr output for details",
-				)
-				errCh <- t.exitError
+				var exitError error
+				stderr := t.stderrOutput()
+				if detail := findAuthFailure(stderr); detail != "" {
+					exitError = NewAuthenticationError(AuthErrorSourceStderr, detail, nil)
+				} else {
+					if stderr == "" {
+						stderr = "check stderr output for details"
+					}
+					exitError = NewProcessError("command failed", exitErr.ExitCode(), stderr)
+				}
+				t.mu.Lock()
+				if t.exit == exit {
+					// Not closed and reconnected meanwhile
+					t.exitError = exitError
+				}
+				t.mu.Unlock()
+				errCh <- exitError
 			}
 		}
-	}()
+	}, "claude_sdk", "read")
 
 	return msgCh, errCh
 }
@@ -580,52 +859,34 @@ func (t *SubprocessCLITransport) Close() error {
 	}
 
 	// Kill process if still running
-	if t.cmd.Process != nil && t.cmd.ProcessState == nil {
+	if t.cmd.Process != nil && t.exit.exited(t.cmd) == nil {
 		t.cmd.Process.Kill()
 	}
 
-	// Wait for process with timeout to avoid hanging
-	if t.cmd != nil && t.cmd.Process != nil {
-		done := make(chan struct{})
-		go func() {
-			t.cmd.Wait()
-			close(done)
-		}()
+	// Wait for process with timeout to avoid hanging; ReadMessages usually
+	// waits for it, once its output ends
+	if t.cmd.Process != nil {
+		go t.exit.wait(t.cmd)
 
 		// Wait up to 2 seconds for process to exit
 		select {
-		case <-done:
+		case <-t.exit.done:
 			// Process exited normally
 		case <-time.After(2 * time.Second):
 			// Force kill if still running
-			if t.cmd.Process != nil {
-				t.cmd.Process.Signal(os.Kill)
-			}
+			t.cmd.Process.Signal(os.Kill)
 		}
 	}
 
 	// Wait for stderr reader to finish (with timeout)
-	stderrDone := make(chan struct{})
-	go func() {
-		t.stderrWg.Wait()
-		close(stderrDone)
-	}()
-	select {
-	case <-stderrDone:
-	case <-time.After(1 * time.Second):
-		// Stderr reader didn't finish, continue anyway
-	}
+	t.waitForStderr(1 * time.Second)
 
 	// Clean up temporary files
-	for _, tempFile := range t.tempFiles {
-		if err := os.Remove(tempFile); err != nil {
-			// Log but don't fail on cleanup errors
-			fmt.Fprintf(os.Stderr, "Warning: Failed to remove temp file %s: %v\n", tempFile, err)
-		}
-	}
-	t.tempFiles = nil
+	t.releaseInheritedFiles()
+	t.removeTempFiles()
 
 	t.cmd = nil
+	t.exit = nil
 	t.exitError = nil
 
 	return nil
@@ -652,23 +913,17 @@ func (t *SubprocessCLITransport) checkClaudeVersion(ctx context.Context) error {
 	}
 
 	// Parse version from output
-	versionStr := strings.TrimSpace(string(output))
-	re := regexp.MustCompile(`([0-9]+\.[0-9]+\.[0-9]+)`)
-	match := re.FindStringSubmatch(versionStr)
-
-	if match == nil {
+	version := parseCLIVersion(strings.TrimSpace(string(output)))
+	if version == "" {
 		// Couldn't parse version, skip check
 		return nil
 	}
-
-	version := match[1]
+	t.features = FeatureMatrix(version)
 
 	// Compare versions
 	if compareVersions(version, minimumClaudeCodeVersion) < 0 {
-		warning := fmt.Sprintf("Warning: Claude Code version %s is unsupported in the Agent SDK. "+
-			"Minimum required version is %s. "+
-			"Some features may not work correctly.", version, minimumClaudeCodeVersion)
-		fmt.Fprintln(os.Stderr, warning)
+		loggerFor(t.options).Warn("Claude Code version is unsupported in the Agent SDK; some features may not work correctly",
+			"version", version, "minimum", minimumClaudeCodeVersion)
 	}
 
 	return nil
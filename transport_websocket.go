@@ -0,0 +1,736 @@
+package claude
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Defaults for WebSocketOptions.
+const (
+	defaultWebSocketDialTimeout    = 10 * time.Second
+	defaultWebSocketPingInterval   = 30 * time.Second
+	defaultWebSocketInitialBackoff = 500 * time.Millisecond
+	defaultWebSocketMaxBackoff     = 30 * time.Second
+)
+
+// WebSocketOptions configures a WebSocketTransport. The zero value is valid.
+type WebSocketOptions struct {
+	Header         http.Header   // Extra handshake headers, e.g. Authorization
+	TLSConfig      *tls.Config   // For wss:// URLs, e.g. custom roots or client certificates (default: system roots)
+	DialTimeout    time.Duration // Per connection attempt, including the handshake (default 10s)
+	PingInterval   time.Duration // Keepalive pings (default 30s, negative = none)
+	PongTimeout    time.Duration // How long past PingInterval a pong may be late before the connection counts as dropped (default: PingInterval)
+	MaxMessageSize int           // Largest message accepted from the server (default 1MB)
+
+	// Reconnect redials a connection that drops unexpectedly, so the
+	// session survives network blips if the server keeps it (nil = a drop
+	// ends the transport with an error)
+	Reconnect *WebSocketReconnect
+}
+
+// WebSocketReconnect configures how a WebSocketTransport reconnects.
+// Delays double after each failed attempt, from InitialBackoff up to
+// MaxBackoff.
+type WebSocketReconnect struct {
+	MaxAttempts    int           // Attempts per drop before giving up (0 = until Close)
+	InitialBackoff time.Duration // Default 500ms
+	MaxBackoff     time.Duration // Default 30s
+
+	// OnReconnect, if set, is called after each successful reconnect
+	OnReconnect func(attempt int)
+}
+
+// WebSocketTransport is a Transport that speaks the CLI's stream-json
+// control protocol to a remote Claude Code server over a WebSocket, so
+// agents can run against a centrally hosted CLI instead of local
+// subprocesses. Each WebSocket text message carries one or more
+// newline-delimited JSON messages, in both directions.
+//
+// The server runs the CLI and chooses its flags; ClaudeAgentOptions that
+// map to CLI flags (Model, SystemPrompt, ...) are not sent, while those
+// carried by the control protocol (hooks, CanUseTool, SDK MCP servers)
+// work as with a subprocess. The CLI reads its prompt from the connection,
+// so use the transport with ClaudeSDKClient or QueryStream. After a
+// reconnect the SDK fails the control requests sent on the old connection
+// and initializes the new one, in case the server started a fresh CLI.
+//
+// Example:
+//
+//	transport, err := claude.NewWebSocketTransport("wss://agents.example.com/cli", &claude.WebSocketOptions{
+//	    Header:    http.Header{"Authorization": {"Bearer " + token}},
+//	    Reconnect: &claude.WebSocketReconnect{MaxAttempts: 5},
+//	})
+//	if err != nil { ... }
+//	client := claude.NewClaudeSDKClientWithTransport(options, transport)
+type WebSocketTransport struct {
+	url     *url.URL
+	options WebSocketOptions
+
+	mu        sync.Mutex
+	conn      *wsConn
+	connected chan struct{} // Closed when conn is set; replaced while reconnecting
+	closed    chan struct{} // Closed by Close
+	ready     bool
+	readErr   error  // Why the connection ended, once it has
+	onRedial  func() // See SetReconnectHandler
+}
+
+// NewWebSocketTransport returns a transport for the ws:// or wss:// URL of
+// a remote Claude Code server. options may be nil.
+func NewWebSocketTransport(rawURL string, options *WebSocketOptions) (*WebSocketTransport, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebSocket URL: %w", err)
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return nil, fmt.Errorf("invalid WebSocket URL %q: scheme must be ws or wss", rawURL)
+	}
+	t := &WebSocketTransport{url: u}
+	if options != nil {
+		t.options = *options
+	}
+	if t.options.DialTimeout <= 0 {
+		t.options.DialTimeout = defaultWebSocketDialTimeout
+	}
+	if t.options.PingInterval == 0 {
+		t.options.PingInterval = defaultWebSocketPingInterval
+	}
+	if t.options.PongTimeout <= 0 {
+		t.options.PongTimeout = t.options.PingInterval
+	}
+	if t.options.MaxMessageSize <= 0 {
+		t.options.MaxMessageSize = defaultMaxBufferSize
+	}
+	return t, nil
+}
+
+// Connect dials the server and completes the WebSocket handshake. It does
+// nothing if the transport is already connected, or reconnecting.
+func (t *WebSocketTransport) Connect(ctx context.Context) error {
+	if t.isActive() {
+		return nil
+	}
+	conn, err := t.dial(ctx)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.isActiveLocked() {
+		conn.close() // Lost a race with another Connect
+		return nil
+	}
+	// Release a connection that failed, and its keepalive
+	if t.conn != nil {
+		t.conn.close()
+	}
+	if t.closed != nil && !t.isClosedLocked() {
+		close(t.closed)
+	}
+	t.conn = conn
+	t.connected = make(chan struct{})
+	close(t.connected)
+	t.closed = make(chan struct{})
+	t.ready = true
+	t.readErr = nil
+	if t.options.PingInterval > 0 {
+		go t.keepAlive(t.closed)
+	}
+	return nil
+}
+
+// Write sends data, one or more JSON lines, as a text message. While
+// reconnecting it waits for the new connection.
+func (t *WebSocketTransport) Write(ctx context.Context, data string) error {
+	conn, err := t.currentConn(ctx)
+	if err != nil {
+		return err
+	}
+	if err := conn.writeFrame(wsOpText, []byte(data)); err != nil {
+		return NewCLIConnectionError("failed to write to WebSocket", err)
+	}
+	return nil
+}
+
+// ReadMessages returns the messages received from the server, reconnecting
+// per WebSocketOptions.Reconnect if the connection drops.
+func (t *WebSocketTransport) ReadMessages(ctx context.Context) (<-chan map[string]interface{}, <-chan error) {
+	msgCh := make(chan map[string]interface{}, defaultTransportChannelBufferSize)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(msgCh)
+		defer close(errCh)
+
+		for {
+			conn, err := t.currentConn(ctx)
+			if err != nil {
+				if !errors.Is(err, ErrClosed) && ctx.Err() == nil {
+					errCh <- err
+				}
+				return
+			}
+			err = t.readConn(ctx, conn, msgCh)
+			if ctx.Err() != nil || t.isClosed() {
+				return
+			}
+			if errors.Is(err, errWebSocketClosedByPeer) {
+				// Like a CLI process exiting: the stream ends without error
+				t.fail(NewCLIConnectionError("WebSocket closed by the server", nil))
+				return
+			}
+			if err = t.reconnect(ctx, err); err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}()
+
+	return msgCh, errCh
+}
+
+// readConn delivers conn's messages until it fails.
+func (t *WebSocketTransport) readConn(ctx context.Context, conn *wsConn, msgCh chan<- map[string]interface{}) error {
+	for {
+		payload, err := conn.readMessage()
+		if err != nil {
+			return err
+		}
+		for _, line := range bytes.Split(payload, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+			var msg map[string]interface{}
+			if err := json.Unmarshal(line, &msg); err != nil {
+				return NewCLIJSONDecodeError(string(line), err)
+			}
+			select {
+			case msgCh <- msg:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// reconnect replaces a dropped connection, or returns why it cannot.
+func (t *WebSocketTransport) reconnect(ctx context.Context, cause error) error {
+	var jsonErr *CLIJSONDecodeError
+	policy := t.options.Reconnect
+	if policy == nil || errors.As(cause, &jsonErr) {
+		return t.fail(NewCLIConnectionError("WebSocket connection ended", cause))
+	}
+
+	t.mu.Lock()
+	if t.conn != nil {
+		t.conn.close()
+	}
+	t.conn = nil
+	t.connected = make(chan struct{})
+	closed := t.closed
+	t.mu.Unlock()
+
+	backoff := policy.InitialBackoff
+	if backoff <= 0 {
+		backoff = defaultWebSocketInitialBackoff
+	}
+	maxBackoff := policy.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultWebSocketMaxBackoff
+	}
+	for attempt := 1; policy.MaxAttempts <= 0 || attempt <= policy.MaxAttempts; attempt++ {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-closed:
+			timer.Stop()
+			return ErrClosed
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		backoff = min(2*backoff, maxBackoff)
+
+		conn, err := t.dial(ctx)
+		if err != nil {
+			cause = err
+			continue
+		}
+		t.mu.Lock()
+		if t.isClosedLocked() {
+			t.mu.Unlock()
+			conn.close()
+			return ErrClosed
+		}
+		t.conn = conn
+		close(t.connected)
+		onRedial := t.onRedial
+		t.mu.Unlock()
+		if onRedial != nil {
+			onRedial()
+		}
+		if policy.OnReconnect != nil {
+			policy.OnReconnect(attempt)
+		}
+		return nil
+	}
+	return t.fail(NewCLIConnectionError("WebSocket reconnect failed", cause))
+}
+
+// fail ends the transport with err, failing writes waiting for a
+// connection.
+func (t *WebSocketTransport) fail(err error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.readErr = err
+	t.ready = false
+	if t.connected != nil {
+		select {
+		case <-t.connected:
+		default:
+			close(t.connected)
+		}
+	}
+	return err
+}
+
+// currentConn returns the connection, waiting for a reconnect in progress.
+func (t *WebSocketTransport) currentConn(ctx context.Context) (*wsConn, error) {
+	for {
+		t.mu.Lock()
+		conn, connected, closed, readErr := t.conn, t.connected, t.closed, t.readErr
+		t.mu.Unlock()
+		switch {
+		case closed == nil:
+			return nil, ErrNotConnected
+		case t.isClosed():
+			return nil, ErrClosed
+		case readErr != nil:
+			return nil, readErr
+		case conn != nil:
+			return conn, nil
+		}
+		select {
+		case <-connected:
+		case <-closed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// SetReconnectHandler implements ReconnectNotifier: handler is called after
+// each reconnect, before WebSocketReconnect.OnReconnect.
+func (t *WebSocketTransport) SetReconnectHandler(handler func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onRedial = handler
+}
+
+// keepAlive pings the server until closed, so idle connections are not
+// dropped by proxies. A connection whose pongs stop for PongTimeout past
+// PingInterval fails its next read, see wsConn.readMessage.
+func (t *WebSocketTransport) keepAlive(closed <-chan struct{}) {
+	ticker := time.NewTicker(t.options.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			t.mu.Lock()
+			conn := t.conn
+			t.mu.Unlock()
+			if conn != nil {
+				conn.writeFrame(wsOpPing, nil) // A failure shows up as a read error
+			}
+		}
+	}
+}
+
+// Liveness implements LivenessProber: it pings the server and waits for the
+// pong.
+func (t *WebSocketTransport) Liveness(ctx context.Context) error {
+	t.mu.Lock()
+	conn, readErr := t.conn, t.readErr
+	t.mu.Unlock()
+	if readErr != nil {
+		return readErr
+	}
+	if conn == nil || !t.IsReady() {
+		return NewCLIConnectionError("WebSocket is not connected", nil)
+	}
+	pong := conn.awaitPong()
+	if err := conn.writeFrame(wsOpPing, nil); err != nil {
+		return NewCLIConnectionError("WebSocket ping failed", err)
+	}
+	select {
+	case <-pong:
+		return nil
+	case <-ctx.Done():
+		return NewCLIConnectionError("WebSocket ping timed out", ctx.Err())
+	}
+}
+
+// SupportsReconnect implements ReconnectCapability: Connect dials again
+// after Close.
+func (t *WebSocketTransport) SupportsReconnect() bool {
+	return true
+}
+
+// SupportsCompression implements CompressionCapability; messages are not
+// compressed.
+func (t *WebSocketTransport) SupportsCompression() bool {
+	return false
+}
+
+// Close sends a close frame and closes the connection.
+func (t *WebSocketTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed == nil || t.isClosedLocked() {
+		return nil
+	}
+	close(t.closed)
+	t.ready = false
+	if t.conn != nil {
+		t.conn.writeClose(wsCloseNormal)
+		t.conn.close()
+	}
+	return nil
+}
+
+// IsReady reports whether the transport is connected and not closed.
+func (t *WebSocketTransport) IsReady() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ready
+}
+
+// EndInput does nothing: a WebSocket cannot be half-closed, so the server
+// ends the CLI's input when the connection closes.
+func (t *WebSocketTransport) EndInput() error {
+	return nil
+}
+
+func (t *WebSocketTransport) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.isClosedLocked()
+}
+
+// isActive reports whether the transport is connected or reconnecting.
+func (t *WebSocketTransport) isActive() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.isActiveLocked()
+}
+
+// isActiveLocked is isActive for callers holding mu.
+func (t *WebSocketTransport) isActiveLocked() bool {
+	return t.closed != nil && !t.isClosedLocked() && t.readErr == nil
+}
+
+// isClosedLocked reports whether Close was called. Callers hold mu.
+func (t *WebSocketTransport) isClosedLocked() bool {
+	if t.closed == nil {
+		return false
+	}
+	select {
+	case <-t.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// dial connects to the server and performs the opening handshake.
+func (t *WebSocketTransport) dial(ctx context.Context) (*wsConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.options.DialTimeout)
+	defer cancel()
+
+	host := t.url.Host
+	if t.url.Port() == "" {
+		if t.url.Scheme == "wss" {
+			host = net.JoinHostPort(t.url.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(t.url.Hostname(), "80")
+		}
+	}
+	var conn net.Conn
+	var err error
+	if t.url.Scheme == "wss" {
+		config := t.options.TLSConfig.Clone()
+		if config == nil {
+			config = &tls.Config{}
+		}
+		if config.ServerName == "" {
+			config.ServerName = t.url.Hostname()
+		}
+		conn, err = (&tls.Dialer{Config: config}).DialContext(ctx, "tcp", host)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", host)
+	}
+	if err != nil {
+		return nil, NewCLIConnectionError(fmt.Sprintf("failed to connect to %s", t.url.Redacted()), err)
+	}
+
+	// Bound the handshake by ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	ws, err := wsHandshake(conn, t.url, t.options.Header, t.options.MaxMessageSize)
+	if err != nil {
+		conn.Close()
+		return nil, NewCLIConnectionError(fmt.Sprintf("WebSocket handshake with %s failed", t.url.Redacted()), err)
+	}
+	conn.SetDeadline(time.Time{})
+	if t.options.PingInterval > 0 {
+		ws.pongWindow = t.options.PingInterval + t.options.PongTimeout
+		ws.extendReadDeadline()
+	}
+	return ws, nil
+}
+
+// WebSocket protocol (RFC 6455) constants.
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	wsCloseNormal = 1000
+
+	wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// errWebSocketClosedByPeer is returned by readMessage when the server
+// closes the connection with a close frame.
+var errWebSocketClosedByPeer = errors.New("WebSocket closed by the server")
+
+// wsConn is a client WebSocket connection.
+type wsConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	maxSize int
+
+	writeMu sync.Mutex // Serializes frames
+
+	pongMu     sync.Mutex
+	pongs      []chan struct{} // Waiting for the next pong
+	pongWindow time.Duration   // Reads fail if no pong arrives within it (0 = never)
+
+	closeOnce sync.Once
+}
+
+// wsHandshake performs the client opening handshake on conn.
+func wsHandshake(conn net.Conn, u *url.URL, header http.Header, maxSize int) (*wsConn, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	requestURL := *u
+	requestURL.Scheme = map[string]string{"ws": "http", "wss": "https"}[u.Scheme]
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &requestURL,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for name, values := range header {
+		req.Header[name] = append([]string(nil), values...)
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", userAgent(nil))
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		return nil, errors.New("invalid Sec-WebSocket-Accept header")
+	}
+	return &wsConn{conn: conn, reader: reader, maxSize: maxSize}, nil
+}
+
+// writeFrame sends one unfragmented, masked frame.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	header := make([]byte, 0, 14)
+	header = append(header, 0x80|opcode)
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, 0x80|byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 0x80|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 0x80|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	header = append(header, mask...)
+	masked := make([]byte, len(payload))
+	for i, b := range payload {
+		masked[i] = b ^ mask[i%4]
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.conn.Write(append(header, masked...))
+	return err
+}
+
+// writeClose sends a close frame with code, ignoring errors.
+func (c *wsConn) writeClose(code uint16) {
+	c.writeFrame(wsOpClose, binary.BigEndian.AppendUint16(nil, code))
+}
+
+// readMessage returns the payload of the next text or binary message,
+// answering control frames on the way.
+func (c *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			if c.pongWindow > 0 && errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil, fmt.Errorf("no pong from the server within %v: %w", c.pongWindow, err)
+			}
+			return nil, err
+		}
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			c.extendReadDeadline()
+			c.pongMu.Lock()
+			for _, waiter := range c.pongs {
+				close(waiter)
+			}
+			c.pongs = nil
+			c.pongMu.Unlock()
+			continue
+		case wsOpClose:
+			c.writeFrame(wsOpClose, payload)
+			return nil, errWebSocketClosedByPeer
+		case wsOpText, wsOpBinary, wsOpContinuation:
+		default:
+			return nil, fmt.Errorf("unknown WebSocket opcode %#x", opcode)
+		}
+		if len(message)+len(payload) > c.maxSize {
+			return nil, fmt.Errorf("WebSocket message exceeds the maximum size of %d bytes", c.maxSize)
+		}
+		message = append(message, payload...)
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// readFrame reads one frame.
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.reader, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = head[0]&0x80 != 0
+	opcode = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > uint64(c.maxSize) {
+		return false, 0, nil, fmt.Errorf("WebSocket frame exceeds the maximum size of %d bytes", c.maxSize)
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// awaitPong returns a channel closed when the next pong arrives.
+func (c *wsConn) awaitPong() <-chan struct{} {
+	waiter := make(chan struct{})
+	c.pongMu.Lock()
+	defer c.pongMu.Unlock()
+	c.pongs = append(c.pongs, waiter)
+	return waiter
+}
+
+// extendReadDeadline gives the server pongWindow from now to answer a ping.
+func (c *wsConn) extendReadDeadline() {
+	if c.pongWindow > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.pongWindow))
+	}
+}
+
+func (c *wsConn) close() {
+	c.closeOnce.Do(func() { c.conn.Close() })
+}
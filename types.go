@@ -3,6 +3,8 @@ package claude
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
+	"time"
 )
 
 // PermissionMode defines the permission handling mode.
@@ -34,6 +36,18 @@ const (
 	HookEventStop             HookEvent = "Stop"
 	HookEventSubagentStop     HookEvent = "SubagentStop"
 	HookEventPreCompact       HookEvent = "PreCompact"
+
+	// HookEventSessionStart runs when a session starts or resumes; matchers
+	// match SessionStartHookInput.Source
+	HookEventSessionStart HookEvent = "SessionStart"
+	// HookEventSessionEnd runs when a session ends; matchers are ignored
+	HookEventSessionEnd HookEvent = "SessionEnd"
+	// HookEventNotification runs when Claude Code shows a notification;
+	// matchers match NotificationHookInput.NotificationType
+	HookEventNotification HookEvent = "Notification"
+	// HookEventPermissionRequest runs when Claude Code would show a
+	// permission dialog; matchers match the tool name
+	HookEventPermissionRequest HookEvent = "PermissionRequest"
 )
 
 // Message interface for all message types.
@@ -89,8 +103,12 @@ func (ImageBlock) isContentBlock() {}
 
 // UserMessage represents a user message.
 type UserMessage struct {
-	Content         interface{} `json:"content"` // Can be string or []ContentBlock
-	ParentToolUseID *string     `json:"parent_tool_use_id,omitempty"`
+	Content         interface{}            `json:"content"` // Can be string or []ContentBlock
+	ParentToolUseID *string                `json:"parent_tool_use_id,omitempty"`
+	UUID            string                 `json:"uuid,omitempty"`
+	SessionID       string                 `json:"session_id,omitempty"`
+	ToolUseResult   map[string]interface{} `json:"tool_use_result,omitempty"` // Structured tool output, when the CLI provides it
+	CorrelationID   string                 `json:"-"`                         // Set from the query's context, see WithCorrelationID
 }
 
 func (UserMessage) isMessage() {}
@@ -100,14 +118,19 @@ type AssistantMessage struct {
 	Content         []ContentBlock `json:"content"`
 	Model           string         `json:"model"`
 	ParentToolUseID *string        `json:"parent_tool_use_id,omitempty"`
+	UUID            string         `json:"uuid,omitempty"`
+	SessionID       string         `json:"session_id,omitempty"`
+	Error           string         `json:"error,omitempty"` // e.g. "authentication_failed", "rate_limit"
+	CorrelationID   string         `json:"-"`               // Set from the query's context, see WithCorrelationID
 }
 
 func (AssistantMessage) isMessage() {}
 
 // SystemMessage represents a system message with metadata.
 type SystemMessage struct {
-	Subtype string                 `json:"subtype"`
-	Data    map[string]interface{} `json:"data"`
+	Subtype       string                 `json:"subtype"`
+	Data          map[string]interface{} `json:"data"`
+	CorrelationID string                 `json:"-"` // Set from the query's context, see WithCorrelationID
 }
 
 func (SystemMessage) isMessage() {}
@@ -121,8 +144,19 @@ type ResultMessage struct {
 	NumTurns      int                    `json:"num_turns"`
 	SessionID     string                 `json:"session_id"`
 	TotalCostUSD  *float64               `json:"total_cost_usd,omitempty"`
-	Usage         map[string]interface{} `json:"usage,omitempty"`
+	Usage         map[string]interface{} `json:"usage,omitempty"`      // Raw; see ParseUsage
+	ModelUsage    map[string]interface{} `json:"modelUsage,omitempty"` // Raw per-model usage, by model ID; see ParseUsage
 	Result        *string                `json:"result,omitempty"`
+	UUID          string                 `json:"uuid,omitempty"`
+	CorrelationID string                 `json:"-"` // Set from the query's context, see WithCorrelationID
+
+	// MaxOutputTokens is the per-response output limit the query ran with,
+	// set by the SDK from ClaudeAgentOptions.MaxOutputTokens (0 = CLI default)
+	MaxOutputTokens int `json:"-"`
+
+	// Summary reports what a tool-heavy query did, set by
+	// ClaudeSDKClient.Query when ClaudeAgentOptions.FinalSummary is enabled
+	Summary *FinalSummary `json:"-"`
 }
 
 func (ResultMessage) isMessage() {}
@@ -133,6 +167,7 @@ type StreamEvent struct {
 	SessionID       string                 `json:"session_id"`
 	Event           map[string]interface{} `json:"event"`
 	ParentToolUseID *string                `json:"parent_tool_use_id,omitempty"`
+	CorrelationID   string                 `json:"-"` // Set from the query's context, see WithCorrelationID
 }
 
 func (StreamEvent) isMessage() {}
@@ -144,6 +179,12 @@ type SystemPromptPreset struct {
 	Append *string `json:"append,omitempty"`
 }
 
+// OutputStyleInfo describes the current and available Claude Code output styles.
+type OutputStyleInfo struct {
+	Current   string   `json:"output_style"`
+	Available []string `json:"available_output_styles,omitempty"`
+}
+
 // AgentDefinition represents a custom agent configuration.
 type AgentDefinition struct {
 	Description string   `json:"description"`
@@ -152,10 +193,33 @@ type AgentDefinition struct {
 	Model       *string  `json:"model,omitempty"` // "sonnet", "opus", "haiku", "inherit"
 }
 
-// PermissionRuleValue represents a permission rule.
+// PermissionRuleValue represents a permission rule. It is sent with the
+// CLI's camelCase keys; the snake_case keys tool_name and rule_content,
+// used by earlier releases, are still accepted when decoding.
 type PermissionRuleValue struct {
-	ToolName    string  `json:"tool_name"`
-	RuleContent *string `json:"rule_content,omitempty"`
+	ToolName    string  `json:"toolName"`
+	RuleContent *string `json:"ruleContent,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting both key styles.
+func (r *PermissionRuleValue) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ToolName          string  `json:"toolName"`
+		RuleContent       *string `json:"ruleContent"`
+		LegacyToolName    string  `json:"tool_name"`
+		LegacyRuleContent *string `json:"rule_content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	r.ToolName, r.RuleContent = raw.ToolName, raw.RuleContent
+	if r.ToolName == "" {
+		r.ToolName = raw.LegacyToolName
+	}
+	if r.RuleContent == nil {
+		r.RuleContent = raw.LegacyRuleContent
+	}
+	return nil
 }
 
 // PermissionBehavior defines permission behavior.
@@ -177,9 +241,21 @@ const (
 	PermissionUpdateDestinationSession         PermissionUpdateDestination = "session"
 )
 
+// PermissionUpdateType defines the kind of permission update.
+type PermissionUpdateType string
+
+const (
+	PermissionUpdateTypeAddRules          PermissionUpdateType = "addRules"
+	PermissionUpdateTypeReplaceRules      PermissionUpdateType = "replaceRules"
+	PermissionUpdateTypeRemoveRules       PermissionUpdateType = "removeRules"
+	PermissionUpdateTypeSetMode           PermissionUpdateType = "setMode"
+	PermissionUpdateTypeAddDirectories    PermissionUpdateType = "addDirectories"
+	PermissionUpdateTypeRemoveDirectories PermissionUpdateType = "removeDirectories"
+)
+
 // PermissionUpdate represents a permission update configuration.
 type PermissionUpdate struct {
-	Type        string                       `json:"type"` // "addRules", "replaceRules", "removeRules", "setMode", "addDirectories", "removeDirectories"
+	Type        PermissionUpdateType         `json:"type"`
 	Rules       []PermissionRuleValue        `json:"rules,omitempty"`
 	Behavior    *PermissionBehavior          `json:"behavior,omitempty"`
 	Mode        *PermissionMode              `json:"mode,omitempty"`
@@ -190,6 +266,8 @@ type PermissionUpdate struct {
 // ToolPermissionContext provides context for tool permission callbacks.
 type ToolPermissionContext struct {
 	Suggestions []PermissionUpdate `json:"suggestions,omitempty"`
+	RequestID   string             `json:"request_id,omitempty"` // Control protocol request ID
+	Metadata    Metadata           `json:"-"`                    // Metadata of the current query or turn; nil if none
 }
 
 // PermissionResult is the interface for permission callback results.
@@ -326,7 +404,10 @@ type CanUseTool func(ctx context.Context, toolName string, input map[string]inte
 //
 // Hook-Specific Output:
 //   - HookSpecificOutput: Event-specific controls (e.g., permissionDecision for
-//     PreToolUse, additionalContext for PostToolUse)
+//     PreToolUse, additionalContext for PostToolUse); see the HookOutput keys.
+//     hookEventName is filled in from the hook's input if omitted.
+//
+// Helpers such as DenyToolUse and AddContext build common outputs.
 type HookJSONOutput struct {
 	// Common control fields
 	Continue       *bool   `json:"continue,omitempty"`
@@ -348,6 +429,8 @@ type HookJSONOutput struct {
 
 // HookContext provides context information for hook callbacks.
 type HookContext struct {
+	RequestID string   // Control protocol request ID
+	Metadata  Metadata // Metadata of the current query or turn; nil if none
 	// Future: abort signal support
 }
 
@@ -376,27 +459,18 @@ type HookContext struct {
 //	blockDangerousTools := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
 //	    toolName := input["tool_name"].(string)
 //	    if toolName == "Bash" {
-//	        return HookJSONOutput{
-//	            HookSpecificOutput: map[string]interface{}{
-//	                "permissionDecision": "deny",
-//	                "permissionDecisionReason": "Bash tool is blocked",
-//	            },
-//	        }, nil
+//	        return DenyToolUse("Bash tool is blocked"), nil
 //	    }
 //	    return HookJSONOutput{}, nil
 //	}
 //
-// Example - UserPromptSubmit hook to modify user input:
+// Example - UserPromptSubmit hook to add instructions to every prompt:
 //
-//	modifyPrompt := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
-//	    prompt := input["prompt"].(string)
-//	    enhanced := prompt + "\nPlease be concise in your response."
-//	    return HookJSONOutput{
-//	        HookSpecificOutput: map[string]interface{}{
-//	            "prompt": enhanced,
-//	        },
-//	    }, nil
+//	beConcise := func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error) {
+//	    return AddContext("Please be concise in your response."), nil
 //	}
+//
+// See also AllowToolUse, AskToolUse, Block, and StopExecution.
 type HookCallback func(ctx context.Context, input map[string]interface{}, toolUseID *string, hookCtx HookContext) (HookJSONOutput, error)
 
 // Strongly-typed hook input structs for type safety and better IDE support
@@ -455,15 +529,66 @@ type PreCompactHookInput struct {
 	CustomInstructions *string `json:"custom_instructions,omitempty"`
 }
 
+// SessionStartHookInput is the input data for SessionStart hook events.
+type SessionStartHookInput struct {
+	BaseHookInput
+	HookEventName string `json:"hook_event_name"` // "SessionStart"
+	Source        string `json:"source"`          // "startup", "resume", "clear", or "compact"
+}
+
+// SessionEndHookInput is the input data for SessionEnd hook events.
+type SessionEndHookInput struct {
+	BaseHookInput
+	HookEventName string `json:"hook_event_name"` // "SessionEnd"
+	Reason        string `json:"reason"`          // e.g. "clear", "logout", "prompt_input_exit", "other"
+}
+
+// NotificationHookInput is the input data for Notification hook events.
+type NotificationHookInput struct {
+	BaseHookInput
+	HookEventName    string  `json:"hook_event_name"` // "Notification"
+	Message          string  `json:"message"`
+	Title            *string `json:"title,omitempty"`
+	NotificationType string  `json:"notification_type,omitempty"` // e.g. "permission_prompt", "idle_prompt"
+}
+
+// PermissionRequestHookInput is the input data for PermissionRequest hook
+// events.
+type PermissionRequestHookInput struct {
+	BaseHookInput
+	HookEventName         string                 `json:"hook_event_name"` // "PermissionRequest"
+	ToolName              string                 `json:"tool_name"`
+	ToolInput             map[string]interface{} `json:"tool_input"`
+	PermissionSuggestions []PermissionUpdate     `json:"permission_suggestions,omitempty"`
+}
+
 // HookMatcher configures hook matching and callbacks.
 type HookMatcher struct {
-	Matcher string         // Tool name pattern or nil for all
+	// Matcher selects the tools (or what the HookEvent documents) the hooks
+	// run for. Empty or "*" matches all, "Write|Edit" is a regular
+	// expression for the whole name, and the SDK itself evaluates globs such
+	// as "mcp__calc__*" and searches such as "/^mcp__/" before calling Hooks
+	Matcher string
 	Hooks   []HookCallback // List of hook callbacks
+
+	// Agent restricts the hooks to calls made inside the named subagent (the
+	// Task tool's subagent_type). Empty runs the hooks for every agent,
+	// including the main conversation. Use MainAgent for the main conversation only.
+	Agent string
 }
 
+// MainAgent is the HookMatcher.Agent value matching only the main conversation.
+const MainAgent = "main"
+
 // StderrCallback is called for each line of stderr output.
 type StderrCallback func(line string)
 
+// AuthenticationCallback is called when the CLI reports that it is not
+// authenticated. It may refresh credentials (e.g. run a device-code flow or
+// write a new token); returning nil marks the AuthenticationError as Recovered
+// so the caller knows a retry can succeed.
+type AuthenticationCallback func(ctx context.Context, err *AuthenticationError) error
+
 // McpServerConfig represents MCP server configuration (various types).
 type McpServerConfig interface {
 	isMcpServerConfig()
@@ -499,9 +624,34 @@ func (McpHTTPServerConfig) isMcpServerConfig() {}
 
 // McpSdkServerConfig represents an SDK MCP server (in-process).
 type McpSdkServerConfig struct {
-	Type     string      `json:"type"` // "sdk"
-	Name     string      `json:"name"`
-	Instance interface{} `json:"-"` // MCP Server instance (not serialized)
+	Type     string             `json:"type"` // "sdk"
+	Name     string             `json:"name"`
+	Instance interface{}        `json:"-"` // MCP Server instance (not serialized)
+	Executor *McpServerExecutor `json:"-"` // Optional isolated executor (not serialized)
+}
+
+// McpServerExecutor configures an isolated goroutine pool for an SDK MCP server.
+//
+// Without an executor, every MCP request runs on its own control-request
+// goroutine with no bound on concurrency, and a panicking tool handler takes
+// down the process. With an executor, requests for the server are queued to a
+// fixed pool of workers so a slow or misbehaving server cannot starve hooks or
+// other servers.
+//
+// Example:
+//
+//	cfg := server.ToConfig()
+//	cfg.Executor = &claude.McpServerExecutor{
+//	    PoolSize:      4,
+//	    QueueDepth:    16,
+//	    IsolatePanics: true,
+//	    CallTimeout:   30 * time.Second,
+//	}
+type McpServerExecutor struct {
+	PoolSize      int           // Number of worker goroutines (default: 1)
+	QueueDepth    int           // Requests that may wait for a worker before being rejected (default: 0, unbuffered)
+	IsolatePanics bool          // Recover handler panics and return a JSON-RPC internal error
+	CallTimeout   time.Duration // Per-request timeout (default: none)
 }
 
 func (McpSdkServerConfig) isMcpServerConfig() {}
@@ -530,6 +680,13 @@ type ClaudeAgentOptions struct {
 	// System prompt configuration
 	SystemPrompt interface{} `json:"system_prompt,omitempty"` // Can be string or SystemPromptPreset
 
+	// Locale (a BCP 47 tag, e.g. "de-DE") and Timezone tell Claude where the
+	// user is, as text appended to the system prompt. Timezone also sets the
+	// zone of transcript timestamps (default: UTC) and timeline times
+	// (default: local time).
+	Locale   string         `json:"-"`
+	Timezone *time.Location `json:"-"`
+
 	// MCP servers
 	McpServers map[string]McpServerConfig `json:"mcp_servers,omitempty"`
 
@@ -550,6 +707,25 @@ type ClaudeAgentOptions struct {
 	// Budget and token control
 	MaxBudgetUSD      *float64 `json:"max_budget_usd,omitempty"`
 	MaxThinkingTokens *int     `json:"max_thinking_tokens,omitempty"`
+	MaxOutputTokens   *int     `json:"-"` // Maximum tokens per response; see WithMaxOutputTokens
+
+	// AutoCompact controls when the CLI compacts the conversation as the
+	// context window fills up; see ClaudeSDKClient.Compact for compacting on
+	// demand (default: the CLI's settings)
+	AutoCompact *AutoCompactConfig `json:"-"`
+
+	// BudgetStrategy downgrades the model as session cost grows and stops at
+	// a hard cap (ClaudeSDKClient only, default: disabled)
+	BudgetStrategy *BudgetStrategy `json:"-"`
+
+	// BudgetLimits adds per-query, per-session, and daily cost limits
+	// enforced by the SDK (default: none)
+	BudgetLimits *BudgetLimits `json:"-"`
+
+	// CostAlert calls a function once the cumulative cost of a
+	// ClaudeSDKClient's queries crosses a soft threshold, without stopping
+	// anything; see ClaudeSDKClient.CostTracker (default: none)
+	CostAlert *CostAlert `json:"-"`
 
 	// Working directory and environment
 	Cwd     *string           `json:"cwd,omitempty"`
@@ -557,24 +733,185 @@ type ClaudeAgentOptions struct {
 	User    *string           `json:"user,omitempty"`
 	AddDirs []string          `json:"add_dirs,omitempty"`
 
+	// AppName and AppVersion identify the application using the SDK. They
+	// are appended to CLAUDE_CODE_ENTRYPOINT and the SDK's user agent as
+	// "name/version", and sent in the initialize request (default: unset)
+	AppName    string `json:"-"`
+	AppVersion string `json:"-"`
+
 	// Settings
 	Settings       *string         `json:"settings,omitempty"`
 	SettingSources []SettingSource `json:"setting_sources,omitempty"`
+	OutputStyle    *string         `json:"output_style,omitempty"` // Output style name, merged into Settings
+
+	// ReadOnly denies every tool SDK-side, regardless of CLI settings, except
+	// Read, Grep, Glob, LS, WebSearch, WebFetch, Bash commands in
+	// ReadOnlyBashCommands, and MCP tools in ReadOnlyMcpTools. Without the
+	// control protocol (a string prompt to Query), known mutating tools and
+	// all configured MCP servers are disallowed instead.
+	ReadOnly             bool     `json:"-"`
+	ReadOnlyBashCommands []string `json:"-"` // Allowed Bash command prefixes (default: DefaultReadOnlyBashCommands)
+	ReadOnlyMcpTools     []string `json:"-"` // Allowed MCP tools by full name, e.g. "mcp__docs__search" (default: none)
+
+	// ToolResultFilter rewrites tool result text, e.g. RedactSecrets(), before
+	// messages reach transcripts, history, and the caller. Results of SDK MCP
+	// tools are filtered before they are returned to the CLI, so the model
+	// never sees the original; output of built-in and external tools reaches
+	// the model as the CLI produced it.
+	ToolResultFilter ToolResultFilter `json:"-"`
+
+	// PolicyWatcher enforces a declarative permission policy file, reloading
+	// it when it changes. Enforced with hooks, so it requires streaming mode.
+	PolicyWatcher *PolicyWatcher `json:"-"`
+
+	// ToolLimits caps calls per time window and concurrent calls, by tool
+	// name. Enforced with hooks, so it requires streaming mode.
+	ToolLimits map[string]ToolLimit `json:"-"`
+
+	// ToolMocks answers calls of mocked tools with canned results instead of
+	// running them, for tests. Enforced with hooks ahead of every other hook
+	// and CanUseTool, so it requires streaming mode.
+	ToolMocks *ToolMocks `json:"-"`
 
 	// Callbacks
 	CanUseTool CanUseTool                  `json:"-"` // Function, not serialized
 	Hooks      map[HookEvent][]HookMatcher `json:"-"` // Functions, not serialized
 	Stderr     StderrCallback              `json:"-"` // Function, not serialized
+	// DebugEvents receives the CLI's debug log parsed into DebugEvents, and
+	// runs the CLI with --debug-to-stderr (default: disabled)
+	DebugEvents DebugEventCallback `json:"-"`
+	// AuditLog records every tool invocation to its sink (default: disabled)
+	AuditLog *AuditLog `json:"-"`
+	// Approver puts tool uses to a human when CanUseTool returns a
+	// PermissionResultAsk, e.g. a TerminalApprover or WebhookApprover
+	// (default: none; an ask fails the permission request)
+	Approver Approver `json:"-"`
+	// CanUseToolTimeout bounds each CanUseTool call, not counting an
+	// Approver it defers to. A call that runs longer is cancelled and the
+	// CLI gets a CallbackTimeoutError, which denies the tool use
+	// (default: none)
+	CanUseToolTimeout time.Duration `json:"-"`
+	// HookTimeout bounds each hook callback call. A call that runs longer
+	// is cancelled and the CLI gets a CallbackTimeoutError (default: none)
+	HookTimeout time.Duration `json:"-"`
+
+	// DynamicHooks lets ClaudeSDKClient.AddHook and RemoveHook change hooks
+	// while connected. A catch-all hook is registered with the CLI for every
+	// event, so each hook event round-trips to the SDK (default: disabled).
+	DynamicHooks bool `json:"-"`
+
+	// Metadata is made available to hooks, CanUseTool, and SDK MCP tool
+	// handlers for every query. Metadata attached to a query's context with
+	// WithMetadata overrides it key by key.
+	Metadata Metadata `json:"-"`
+
+	OnAuthenticationError AuthenticationCallback `json:"-"` // Function, not serialized
+	OnError               ErrorCallback          `json:"-"` // Function, not serialized
+
+	// ErrorsFromResults fails a query whose ResultMessage reports an error
+	// (IsError or an "error_*" subtype) with a *ResultError on its error
+	// channel, after delivering the message (default: disabled)
+	ErrorsFromResults bool `json:"-"`
+
+	// CancelBehavior selects what happens to the turn in progress when a
+	// query's context is canceled (default: CancelInterrupt)
+	CancelBehavior CancelBehavior `json:"-"`
+
+	// EventSink receives serialized events for every message, tool use,
+	// permission decision, and result (default: disabled)
+	EventSink EventSink `json:"-"`
+
+	// Notifier sends periodic status digests of a long-running session to a
+	// callback or webhook (ClaudeSDKClient only, default: disabled)
+	Notifier *Notifier `json:"-"`
+
+	// TempDir is where temp files passed to the CLI, such as long agent
+	// definitions, are created (default: os.TempDir()). Files are always
+	// created with 0600 permissions.
+	TempDir string `json:"-"`
+
+	// TempFileCleanup selects when those temp files are deleted (default:
+	// when the transport closes)
+	TempFileCleanup TempFileCleanup `json:"-"`
+
+	// TempFileSpill is told about each value passed through a temp file and
+	// can veto it (default: allowed, and logged to Logger)
+	TempFileSpill TempFileSpillCallback `json:"-"`
+
+	// Metrics receives timings of message parsing, routing, callbacks,
+	// queries, and tool calls, plus token and cost totals if it implements
+	// CounterMetrics (default: disabled). See NewExpvarMetrics.
+	Metrics Metrics `json:"-"`
+
+	// TracerProvider receives a span per query, tool call, and control
+	// request; adapt an OpenTelemetry provider to it (default: disabled)
+	TracerProvider TracerProvider `json:"-"`
+
+	// ProfileLabels sets pprof labels ("claude_sdk" and, for callbacks,
+	// "claude_sdk_callback") on SDK goroutines so CPU profiles attribute time
+	// to SDK operations (default: disabled)
+	ProfileLabels bool `json:"-"`
+
+	// Transcript records every raw message exchanged with the CLI (default: disabled)
+	Transcript *TranscriptRecorder `json:"-"`
+
+	// Logger receives SDK diagnostics such as configuration warnings (default: discarded)
+	Logger *slog.Logger `json:"-"`
 
 	// Agents
 	Agents map[string]AgentDefinition `json:"agents,omitempty"`
 
 	// Advanced options
-	IncludePartialMessages   bool               `json:"include_partial_messages,omitempty"`
-	MaxBufferSize            *int               `json:"max_buffer_size,omitempty"` // Maximum buffer size for JSON messages (default: 10MB)
-	ScannerInitialBufferSize *int               `json:"-"`                         // Initial buffer size for scanner (default: 64KB, not sent to CLI)
-	MessageChannelBufferSize *int               `json:"-"`                         // Internal buffer size for message channels (default: 100, not sent to CLI)
-	ExtraArgs                map[string]*string `json:"extra_args,omitempty"`      // nil value = flag without value
+	IncludePartialMessages     bool               `json:"include_partial_messages,omitempty"`
+	StreamEventFilter          *StreamEventFilter `json:"-"`                         // Thins out partial messages before delivery
+	MaxBufferSize              *int               `json:"max_buffer_size,omitempty"` // Maximum buffer size for JSON messages (default: 10MB)
+	ScannerInitialBufferSize   *int               `json:"-"`                         // Initial buffer size for scanner (default: 64KB, not sent to CLI)
+	MessageChannelBufferSize   *int               `json:"-"`                         // Internal buffer size for message channels (default: 100, not sent to CLI)
+	AdaptiveBuffering          *AdaptiveBuffering `json:"-"`                         // Resizes the internal message buffer from observed load (not sent to CLI)
+	OutputChannelBufferSize    *int               `json:"-"`                         // Buffer size for channels returned to callers (default: 10, not sent to CLI)
+	TransportChannelBufferSize *int               `json:"-"`                         // Buffer size for the transport's parsed-message channel (default: 10, not sent to CLI)
+	ParseConcurrency           int                `json:"-"`                         // Goroutines decoding CLI output JSON, delivering in order (default: 1, not sent to CLI)
+	ExtraArgs                  map[string]*string `json:"extra_args,omitempty"`      // nil value = flag without value
+
+	// LargePromptThreshold is the prompt size in bytes above which Query sends
+	// the prompt over stdin instead of as a CLI argument (default: 32KB, 4KB on Windows)
+	LargePromptThreshold *int `json:"-"`
+
+	// CompressInputThreshold gzips user messages larger than this many bytes
+	// if the CLI advertises compressed input during initialize; otherwise
+	// they are sent as plain JSON (default: disabled)
+	CompressInputThreshold *int `json:"-"`
+
+	// History retains the last N parsed messages on ClaudeSDKClient (default: disabled).
+	// See ClaudeSDKClient.History().
+	HistorySize *int `json:"-"`
+
+	// FinalSummary makes ClaudeSDKClient.Query follow a query that made at
+	// least FinalSummaryMinToolUses tool calls (default: 3) with a short turn
+	// asking Claude what it did, attached to the ResultMessage as Summary.
+	// FinalSummaryModel, e.g. "haiku", answers that turn at lower cost
+	// (default: the session's model); the model in use before it, including
+	// one set with SetModel, is restored afterwards. Default: disabled.
+	FinalSummary            bool   `json:"-"`
+	FinalSummaryMinToolUses int    `json:"-"`
+	FinalSummaryModel       string `json:"-"`
+
+	// RecordTimeline records thinking, text, and tool call spans for
+	// ClaudeSDKClient.Timeline (default: disabled)
+	RecordTimeline bool `json:"-"`
+
+	// Strict parsing: fail (or warn via ParseWarning) when assistant/result
+	// messages contain unexpected fields or lack fields the CLI normally sends.
+	// Useful in integration environments to catch CLI/SDK protocol skew early.
+	StrictParsing bool                 `json:"-"`
+	ParseWarning  ParseWarningCallback `json:"-"` // If set, strict mode warns instead of failing
+
+	// Invalid UTF-8 in CLI stdout or stderr is always replaced with U+FFFD;
+	// DecodingWarning is told about each affected line. ForceUTF8Output sets
+	// LANG, LC_ALL, PYTHONIOENCODING, and PYTHONUTF8 for the CLI so it and
+	// its tools write UTF-8 regardless of the host locale; Env still wins.
+	DecodingWarning DecodingWarningCallback `json:"-"`
+	ForceUTF8Output bool                    `json:"-"`
 
 	// Plugins
 	Plugins []SdkPluginConfig `json:"plugins,omitempty"`
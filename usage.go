@@ -0,0 +1,92 @@
+package claude
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Usage is the token usage of a query, decoded from ResultMessage.Usage and
+// ResultMessage.ModelUsage.
+type Usage struct {
+	InputTokens              int           `json:"input_tokens"`
+	OutputTokens             int           `json:"output_tokens"`
+	CacheCreationInputTokens int           `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int           `json:"cache_read_input_tokens"`
+	ServiceTier              string        `json:"service_tier,omitempty"`
+	ServerToolUse            ServerToolUse `json:"server_tool_use"`
+
+	// Models breaks usage down by model ID, e.g. when subagents or a
+	// fallback model ran; empty if the CLI did not report it
+	Models map[string]ModelUsage `json:"models,omitempty"`
+}
+
+// ServerToolUse counts the server-side tool calls in Usage.
+type ServerToolUse struct {
+	WebSearchRequests int `json:"web_search_requests"`
+	WebFetchRequests  int `json:"web_fetch_requests"`
+}
+
+// ModelUsage is one model's share of a query's usage.
+type ModelUsage struct {
+	InputTokens              int     `json:"inputTokens"`
+	OutputTokens             int     `json:"outputTokens"`
+	CacheReadInputTokens     int     `json:"cacheReadInputTokens"`
+	CacheCreationInputTokens int     `json:"cacheCreationInputTokens"`
+	WebSearchRequests        int     `json:"webSearchRequests"`
+	CostUSD                  float64 `json:"costUSD"`
+	ContextWindow            int     `json:"contextWindow"` // The model's context window in tokens
+}
+
+// TotalInputTokens returns the input tokens including those written to and
+// read from the prompt cache.
+func (u *Usage) TotalInputTokens() int {
+	return u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// ParseUsage decodes a raw usage map, as in ResultMessage.Usage, into a
+// Usage. Fields the SDK does not know are ignored; the raw map keeps them.
+func ParseUsage(usage map[string]interface{}) (*Usage, error) {
+	parsed := &Usage{}
+	if err := decodeUsage(usage, parsed); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// ParseUsage returns the message's usage with its per-model breakdown; see
+// ParseUsage. A message without usage returns a zero Usage.
+//
+// Example:
+//
+//	usage, err := result.ParseUsage()
+//	if err != nil { ... }
+//	for model, u := range usage.Models {
+//	    log.Printf("%s: %d in, %d out, $%.4f", model, u.InputTokens, u.OutputTokens, u.CostUSD)
+//	}
+func (m *ResultMessage) ParseUsage() (*Usage, error) {
+	usage, err := ParseUsage(m.Usage)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.ModelUsage) > 0 {
+		if err := decodeUsage(m.ModelUsage, &usage.Models); err != nil {
+			return nil, err
+		}
+	}
+	return usage, nil
+}
+
+// decodeUsage decodes raw into target through JSON.
+func decodeUsage(raw map[string]interface{}, target interface{}) error {
+	if raw == nil {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage: %w", err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to decode usage: %w", err)
+	}
+	return nil
+}
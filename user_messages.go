@@ -0,0 +1,85 @@
+package claude
+
+import "encoding/base64"
+
+// NewUserMessage returns a user message for QueryStream or
+// ClaudeSDKClient.Query. content is a string or a list of content blocks,
+// e.g. from NewTextBlock and NewImageBlock.
+//
+// Example:
+//
+//	promptCh <- claude.NewUserMessage("What is Go?")
+func NewUserMessage(content interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "user",
+		"message": map[string]interface{}{
+			"role":    "user",
+			"content": content,
+		},
+		"parent_tool_use_id": nil,
+		"session_id":         "default",
+	}
+}
+
+// NewToolResultMessage returns a user message answering tool call
+// toolUseID, for agent loops that run tools themselves. content is a string
+// or a list of text and image blocks; isError marks a failed call.
+//
+// Example:
+//
+//	for _, block := range assistant.Content {
+//	    if use, ok := block.(claude.ToolUseBlock); ok {
+//	        output, err := runTool(use.Name, use.Input)
+//	        if err != nil {
+//	            promptCh <- claude.NewToolResultMessage(use.ID, err.Error(), true)
+//	            continue
+//	        }
+//	        promptCh <- claude.NewToolResultMessage(use.ID, output, false)
+//	    }
+//	}
+func NewToolResultMessage(toolUseID string, content interface{}, isError bool) map[string]interface{} {
+	result := ToolResultBlock{ToolUseID: toolUseID, Content: content}
+	if isError {
+		result.IsError = &isError
+	}
+	return NewToolResultsMessage(result)
+}
+
+// NewToolResultsMessage returns one user message answering several tool
+// calls, as Claude expects when a single assistant turn made parallel calls.
+func NewToolResultsMessage(results ...ToolResultBlock) map[string]interface{} {
+	blocks := make([]map[string]interface{}, 0, len(results))
+	for _, result := range results {
+		block := map[string]interface{}{
+			"type":        "tool_result",
+			"tool_use_id": result.ToolUseID,
+		}
+		if result.Content != nil {
+			block["content"] = result.Content
+		}
+		if result.IsError != nil && *result.IsError {
+			block["is_error"] = true
+		}
+		blocks = append(blocks, block)
+	}
+	return NewUserMessage(blocks)
+}
+
+// NewTextBlock returns a text content block for NewUserMessage or a tool
+// result.
+func NewTextBlock(text string) map[string]interface{} {
+	return map[string]interface{}{"type": "text", "text": text}
+}
+
+// NewImageBlock returns a base64 image content block for NewUserMessage or
+// a tool result. mediaType is e.g. "image/png".
+func NewImageBlock(data []byte, mediaType string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "image",
+		"source": map[string]interface{}{
+			"type":       "base64",
+			"media_type": mediaType,
+			"data":       base64.StdEncoding.EncodeToString(data),
+		},
+	}
+}
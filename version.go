@@ -0,0 +1,132 @@
+package claude
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// sdkModulePath is the import path of this module.
+const sdkModulePath = "github.com/clsx524/claude-agent-sdk-go"
+
+// sdkName identifies this SDK to the CLI.
+const sdkName = "claude-agent-sdk-go"
+
+// userAgentEnv carries the SDK's user agent to the CLI.
+const userAgentEnv = "CLAUDE_AGENT_SDK_USER_AGENT"
+
+// Version returns the version of this SDK.
+func Version() string {
+	return sdkVersion
+}
+
+// BuildInfo describes the SDK linked into this program.
+type BuildInfo struct {
+	Version           string // SDK version, as returned by Version
+	ModuleVersion     string // Module version the program was built with, e.g. "v0.1.0" or a pseudo-version; "(devel)" when built inside the SDK's own module
+	Commit            string // VCS revision of the SDK, if known
+	MinimumCLIVersion string // Oldest Claude Code CLI version the SDK supports
+	GoVersion         string // Go toolchain the program was built with
+}
+
+// ReadBuildInfo returns the SDK's build metadata. The module version and
+// commit come from the build information embedded by the Go toolchain and
+// are empty if it is unavailable.
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:           sdkVersion,
+		MinimumCLIVersion: minimumClaudeCodeVersion,
+		GoVersion:         runtime.Version(),
+	}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if build.Main.Path == sdkModulePath {
+		info.ModuleVersion = build.Main.Version
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+		return info
+	}
+	for _, dep := range build.Deps {
+		if dep.Path != sdkModulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		info.ModuleVersion = dep.Version
+		info.Commit = pseudoVersionRevision(dep.Version)
+	}
+	return info
+}
+
+// pseudoVersionRevision returns the commit abbreviation at the end of a
+// pseudo-version such as v0.0.0-20250101120000-abcdef123456, or "".
+func pseudoVersionRevision(version string) string {
+	version, _, _ = strings.Cut(version, "+")
+	i := strings.LastIndex(version, "-")
+	if i < 0 {
+		return ""
+	}
+	revision := version[i+1:]
+	if len(revision) != 12 || strings.Trim(revision, "0123456789abcdef") != "" {
+		return ""
+	}
+	return revision
+}
+
+// appProduct returns the options' application as a "name/version" product
+// token, or "" if AppName is unset.
+func appProduct(options *ClaudeAgentOptions) string {
+	if options == nil || options.AppName == "" {
+		return ""
+	}
+	product := strings.Join(strings.Fields(options.AppName), "-")
+	if options.AppVersion != "" {
+		product += "/" + strings.Join(strings.Fields(options.AppVersion), "-")
+	}
+	return product
+}
+
+// userAgent identifies the SDK and the application using it, e.g.
+// "claude-agent-sdk-go/0.1.0 (go1.25.0; linux/amd64) billing-bot/2.3".
+func userAgent(options *ClaudeAgentOptions) string {
+	ua := fmt.Sprintf("%s/%s (%s; %s/%s)", sdkName, sdkVersion, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if app := appProduct(options); app != "" {
+		ua += " " + app
+	}
+	return ua
+}
+
+// withAppEntrypoint appends the options' application to a
+// CLAUDE_CODE_ENTRYPOINT value.
+func withAppEntrypoint(entrypoint string, options *ClaudeAgentOptions) string {
+	if app := appProduct(options); app != "" {
+		return entrypoint + " " + app
+	}
+	return entrypoint
+}
+
+// sdkInitializeInfo describes the SDK in the initialize request.
+func sdkInitializeInfo(options *ClaudeAgentOptions) map[string]interface{} {
+	info := map[string]interface{}{
+		"name":       sdkName,
+		"version":    sdkVersion,
+		"user_agent": userAgent(options),
+	}
+	if commit := ReadBuildInfo().Commit; commit != "" {
+		info["commit"] = commit
+	}
+	if options != nil && options.AppName != "" {
+		info["app_name"] = options.AppName
+		if options.AppVersion != "" {
+			info["app_version"] = options.AppVersion
+		}
+	}
+	return info
+}